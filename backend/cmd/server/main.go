@@ -3,6 +3,7 @@ package main
 import (
 	"context"
 	"database/sql"
+	"flag"
 	"fmt"
 	"log"
 	"net/http"
@@ -14,9 +15,12 @@ import (
 	"github.com/joho/godotenv"
 	_ "github.com/lib/pq"
 	"github.com/pinggolf/m3-planning-tools/internal/api"
+	"github.com/pinggolf/m3-planning-tools/internal/auth"
 	"github.com/pinggolf/m3-planning-tools/internal/config"
 	"github.com/pinggolf/m3-planning-tools/internal/db"
 	"github.com/pinggolf/m3-planning-tools/internal/queue"
+	"github.com/pinggolf/m3-planning-tools/internal/services"
+	"github.com/pinggolf/m3-planning-tools/internal/simulator"
 	"github.com/pinggolf/m3-planning-tools/internal/workers"
 )
 
@@ -26,6 +30,12 @@ func main() {
 		log.Printf("Warning: .env file not found, using environment variables")
 	}
 
+	// Check for simulator command (runs without database or Infor configuration)
+	if len(os.Args) > 1 && os.Args[1] == "simulate" {
+		runSimulator(os.Args[2:])
+		return
+	}
+
 	// Load configuration
 	cfg, err := config.Load()
 	if err != nil {
@@ -70,6 +80,22 @@ func main() {
 	// Initialize database layer
 	queries := db.New(database)
 
+	// Seed system settings for configured environments that are new (or missed settings
+	// added by migrations, which only seed TRN and PRD)
+	for _, env := range cfg.Environments {
+		template := cfg.SettingsTemplate(env)
+		if template == env.Key {
+			continue
+		}
+		added, err := queries.SeedEnvironmentSettings(context.Background(), env.Key, template)
+		if err != nil {
+			log.Fatalf("Failed to seed %s system settings from %s: %v", env.Key, template, err)
+		}
+		if added > 0 {
+			log.Printf("Seeded %d %s system settings from %s", added, env.Key, template)
+		}
+	}
+
 	// Initialize NATS connection
 	log.Println("Connecting to NATS...")
 	natsManager, err := queue.NewManager(cfg.NATSURL)
@@ -79,18 +105,34 @@ func main() {
 	defer natsManager.Close()
 	log.Println("NATS connection established")
 
+	// Credential broker: NATS messages carry job-scoped references, workers exchange them for tokens
+	credentialBroker, err := auth.NewCredentialBroker(cfg, queries, auth.NewServiceAccountTokenManager(cfg))
+	if err != nil {
+		log.Fatalf("Failed to create credential broker: %v", err)
+	}
+
 	// Start snapshot worker
 	log.Println("Starting snapshot worker...")
-	snapshotWorker := workers.NewSnapshotWorker(natsManager, queries, cfg)
+	snapshotWorker := workers.NewSnapshotWorker(natsManager, queries, cfg, credentialBroker)
 	if err := snapshotWorker.Start(); err != nil {
 		log.Fatalf("Failed to start snapshot worker: %v", err)
 	}
 	log.Println("Snapshot worker started")
 
+	// Start refresh scheduler (uses service account tokens, so no user session is needed)
+	refreshScheduler := workers.NewRefreshScheduler(natsManager, queries, credentialBroker, cfg.EnvironmentKeys())
+	refreshScheduler.Start()
+	defer refreshScheduler.Stop()
+
+	// Start notification digest scheduler (sends digest subscriptions on notification_digest_cron)
+	digestScheduler := workers.NewNotificationDigestScheduler(queries, services.NewNotificationService(queries, cfg), cfg.EnvironmentKeys())
+	digestScheduler.Start()
+	defer digestScheduler.Stop()
+
 	// Initialize API server
 	// Note: Context cache refresh is triggered after user login via API handlers
 	// This uses user session tokens instead of service account tokens
-	server := api.NewServer(cfg, queries, natsManager, database)
+	server := api.NewServer(cfg, queries, natsManager, database, credentialBroker)
 
 	// Create HTTP server
 	httpServer := &http.Server{
@@ -141,3 +183,21 @@ func runMigrations(cfg *config.Config) {
 	}
 	log.Println("Migrations completed successfully")
 }
+
+func runSimulator(args []string) {
+	flags := flag.NewFlagSet("simulate", flag.ExitOnError)
+	addr := flags.String("addr", ":8090", "Listen address")
+	fixtures := flags.String("fixtures", "testdata/simulator", "Fixture directory (tables/, mi/, users/)")
+	flags.Parse(args)
+
+	sim, err := simulator.New(*fixtures)
+	if err != nil {
+		log.Fatalf("Failed to load simulator fixtures: %v", err)
+	}
+
+	log.Printf("Compass/M3 simulator listening on %s (fixtures: %s)", *addr, *fixtures)
+	log.Printf("Compass base URL: http://localhost%s%s", *addr, simulator.CompassPath)
+	if err := http.ListenAndServe(*addr, sim.Handler()); err != nil {
+		log.Fatalf("Simulator failed: %v", err)
+	}
+}
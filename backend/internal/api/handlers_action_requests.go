@@ -0,0 +1,420 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gorilla/mux"
+	"github.com/pinggolf/m3-planning-tools/internal/db"
+	"github.com/pinggolf/m3-planning-tools/internal/services"
+)
+
+const (
+	maxActionRequestReasonLength = 2000
+	actionRequestListLimit       = 200
+)
+
+// CreateActionRequestRequest is the body of POST /issues/{id}/action-requests
+type CreateActionRequestRequest struct {
+	Operation string `json:"operation"`
+	Reason    string `json:"reason"`
+}
+
+// ReviewActionRequestRequest is the body of the approve, reject and cancel endpoints
+type ReviewActionRequestRequest struct {
+	Comment string `json:"comment"`
+}
+
+// ActionRequestListResponse lists action requests with the operations that need approval
+type ActionRequestListResponse struct {
+	Requests                   []*db.ActionRequest `json:"requests"`
+	ApprovalRequiredOperations []string            `json:"approvalRequiredOperations"`
+}
+
+// handleCreateActionRequest submits an issue's MO delete or close for approval
+func (s *Server) handleCreateActionRequest(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	issueID, err := strconv.ParseInt(mux.Vars(r)["id"], 10, 64)
+	if err != nil {
+		http.Error(w, "Invalid issue ID", http.StatusBadRequest)
+		return
+	}
+
+	var req CreateActionRequestRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	if _, ok := services.ApprovalPermission(req.Operation); !ok {
+		http.Error(w, fmt.Sprintf("Operation %s does not support approval", req.Operation), http.StatusBadRequest)
+		return
+	}
+
+	req.Reason = strings.TrimSpace(req.Reason)
+	if req.Reason == "" {
+		http.Error(w, "A reason is required", http.StatusBadRequest)
+		return
+	}
+	if len(req.Reason) > maxActionRequestReasonLength {
+		http.Error(w, fmt.Sprintf("Reason is too long (max %d characters)", maxActionRequestReasonLength), http.StatusBadRequest)
+		return
+	}
+
+	issue, err := s.db.GetIssueByID(ctx, issueID)
+	if err != nil {
+		http.Error(w, "Issue not found", http.StatusNotFound)
+		return
+	}
+
+	// Reject requests that could never be executed
+	action, reqErr := newMOAction(req.Operation, issue)
+	if reqErr != nil {
+		http.Error(w, reqErr.message, reqErr.status)
+		return
+	}
+
+	actor := s.newIssueActionActor(r)
+	if actor.userID == "" {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	actionRequest := &db.ActionRequest{
+		Environment:     actor.environment,
+		Operation:       action.operation,
+		IssueID:         action.issueID,
+		DetectorType:    action.detectorType,
+		Company:         action.company,
+		Facility:        action.facility,
+		OrderType:       "MO",
+		OrderNumber:     action.orderNumber,
+		Reason:          req.Reason,
+		RequestedBy:     actor.userID,
+		RequestedByName: actor.userName,
+	}
+	if action.status != nil {
+		actionRequest.OrderStatus = fmt.Sprint(action.status)
+	}
+
+	if err := s.db.CreateActionRequest(ctx, actionRequest); err != nil {
+		if err == db.ErrActionRequestExists {
+			http.Error(w, fmt.Sprintf("MO %s already has an open %s request", action.orderNumber, moActionLabels[action.operation]), http.StatusConflict)
+			return
+		}
+		log.Printf("Failed to create action request: %v", err)
+		http.Error(w, "Failed to create action request", http.StatusInternalServerError)
+		return
+	}
+
+	s.logActionRequest(ctx, actionRequest, "submit", actor, map[string]interface{}{
+		"reason": actionRequest.Reason,
+	})
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(actionRequest)
+}
+
+// handleListActionRequests lists the environment's action requests, optionally filtered by status
+func (s *Server) handleListActionRequests(w http.ResponseWriter, r *http.Request) {
+	session, _ := s.sessionStore.Get(r, "m3-session")
+	environment, _ := session.Values["environment"].(string)
+	if environment == "" {
+		http.Error(w, "Environment not set in session", http.StatusUnauthorized)
+		return
+	}
+
+	requests, err := s.db.ListActionRequests(r.Context(), environment, r.URL.Query().Get("status"), actionRequestListLimit)
+	if err != nil {
+		log.Printf("Failed to list action requests: %v", err)
+		http.Error(w, "Failed to list action requests", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(ActionRequestListResponse{
+		Requests:                   requests,
+		ApprovalRequiredOperations: s.permissionService.ApprovalRequiredOperations(environment),
+	})
+}
+
+// handleGetActionRequest returns one action request
+func (s *Server) handleGetActionRequest(w http.ResponseWriter, r *http.Request) {
+	actionRequest, ok := s.loadActionRequest(w, r)
+	if !ok {
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(actionRequest)
+}
+
+// handleApproveActionRequest approves a pending request and executes its M3 transaction
+// with the approver's session
+func (s *Server) handleApproveActionRequest(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	actionRequest, ok := s.loadActionRequest(w, r)
+	if !ok {
+		return
+	}
+	var req ReviewActionRequestRequest
+	if !decodeOptionalBody(w, r, &req) {
+		return
+	}
+
+	actor := s.newIssueActionActor(r)
+	if !s.authorizeReview(w, r, actionRequest, actor) {
+		return
+	}
+
+	// Re-check the order in the latest snapshot; it may have moved on since the request
+	if state, err := s.db.GetOrderState(ctx, actionRequest.Environment, "MO", actionRequest.OrderNumber, actionRequest.Facility); err != nil {
+		log.Printf("Failed to load order state for action request %d: %v", actionRequest.ID, err)
+	} else if state == nil {
+		http.Error(w, fmt.Sprintf("MO %s no longer exists", actionRequest.OrderNumber), http.StatusConflict)
+		return
+	} else if reqErr := checkMOStatus(actionRequest.Operation, state.Status); reqErr != nil {
+		http.Error(w, reqErr.message, http.StatusConflict)
+		return
+	}
+
+	m3Client, err := s.getM3APIClient(r)
+	if err != nil {
+		http.Error(w, "Failed to get M3 API client", http.StatusInternalServerError)
+		return
+	}
+
+	approved, err := s.db.ReviewActionRequest(ctx, actionRequest.Environment, actionRequest.ID, db.ActionRequestApproved, actor.userID, actor.userName, strings.TrimSpace(req.Comment))
+	if err != nil {
+		log.Printf("Failed to approve action request %d: %v", actionRequest.ID, err)
+		http.Error(w, "Failed to approve action request", http.StatusInternalServerError)
+		return
+	}
+	if approved == nil {
+		http.Error(w, "Action request is no longer pending", http.StatusConflict)
+		return
+	}
+	s.logActionRequest(ctx, approved, "approve", actor, map[string]interface{}{
+		"comment": approved.ReviewComment,
+	})
+
+	action := &moAction{
+		operation:    approved.Operation,
+		issueID:      approved.IssueID,
+		detectorType: approved.DetectorType,
+		company:      approved.Company,
+		facility:     approved.Facility,
+		orderNumber:  approved.OrderNumber,
+		status:       approved.OrderStatus,
+	}
+	response, execErr := s.executeMOAction(ctx, m3Client, action, actor, map[string]interface{}{
+		"action_request_id": approved.ID,
+		"requested_by":      approved.RequestedBy,
+		"requested_by_name": approved.RequestedByName,
+		"reason":            approved.Reason,
+	})
+
+	errorMessage := ""
+	if execErr != nil {
+		errorMessage = execErr.Error()
+		s.logActionRequest(ctx, approved, "execution_failed", actor, map[string]interface{}{
+			"error": errorMessage,
+		})
+	}
+	// A nil *M3Response would be stored as JSON null
+	var m3Response interface{}
+	if response != nil {
+		m3Response = response
+	}
+	if err := s.db.CompleteActionRequest(ctx, approved.ID, execErr == nil, m3Response, errorMessage); err != nil {
+		log.Printf("Failed to record result of action request %d: %v", approved.ID, err)
+	}
+
+	completed, err := s.db.GetActionRequest(ctx, approved.Environment, approved.ID)
+	if err != nil || completed == nil {
+		completed = approved
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if execErr != nil {
+		w.WriteHeader(http.StatusBadGateway)
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"error":   fmt.Sprintf("Approved, but failed to %s: %v", strings.ToLower(moActionLabels[approved.Operation]), execErr),
+			"request": completed,
+		})
+		return
+	}
+	json.NewEncoder(w).Encode(completed)
+}
+
+// handleRejectActionRequest rejects a pending request
+func (s *Server) handleRejectActionRequest(w http.ResponseWriter, r *http.Request) {
+	actionRequest, ok := s.loadActionRequest(w, r)
+	if !ok {
+		return
+	}
+	var req ReviewActionRequestRequest
+	if !decodeOptionalBody(w, r, &req) {
+		return
+	}
+
+	actor := s.newIssueActionActor(r)
+	if !s.authorizeReview(w, r, actionRequest, actor) {
+		return
+	}
+
+	s.finishReview(w, r, actionRequest, db.ActionRequestRejected, "reject", actor, req.Comment)
+}
+
+// handleCancelActionRequest withdraws a pending request; only its requester may cancel it
+func (s *Server) handleCancelActionRequest(w http.ResponseWriter, r *http.Request) {
+	actionRequest, ok := s.loadActionRequest(w, r)
+	if !ok {
+		return
+	}
+	var req ReviewActionRequestRequest
+	if !decodeOptionalBody(w, r, &req) {
+		return
+	}
+
+	actor := s.newIssueActionActor(r)
+	if actor.userID == "" || actor.userID != actionRequest.RequestedBy {
+		http.Error(w, "Forbidden: only the requester can cancel an action request", http.StatusForbidden)
+		return
+	}
+	if actionRequest.Status != db.ActionRequestPending {
+		http.Error(w, fmt.Sprintf("Action request is %s", actionRequest.Status), http.StatusConflict)
+		return
+	}
+
+	s.finishReview(w, r, actionRequest, db.ActionRequestCancelled, "cancel", actor, req.Comment)
+}
+
+// finishReview moves a pending request to rejected or cancelled and audits it
+func (s *Server) finishReview(w http.ResponseWriter, r *http.Request, actionRequest *db.ActionRequest, status, operation string, actor issueActionActor, comment string) {
+	ctx := r.Context()
+
+	reviewed, err := s.db.ReviewActionRequest(ctx, actionRequest.Environment, actionRequest.ID, status, actor.userID, actor.userName, strings.TrimSpace(comment))
+	if err != nil {
+		log.Printf("Failed to %s action request %d: %v", operation, actionRequest.ID, err)
+		http.Error(w, fmt.Sprintf("Failed to %s action request", operation), http.StatusInternalServerError)
+		return
+	}
+	if reviewed == nil {
+		http.Error(w, "Action request is no longer pending", http.StatusConflict)
+		return
+	}
+
+	s.logActionRequest(ctx, reviewed, operation, actor, map[string]interface{}{
+		"comment": reviewed.ReviewComment,
+	})
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(reviewed)
+}
+
+// authorizeReview checks the session user may approve or reject a request: it must be pending,
+// reviewed by someone other than the requester, who holds the operation's permission in the
+// order's facility
+func (s *Server) authorizeReview(w http.ResponseWriter, r *http.Request, actionRequest *db.ActionRequest, actor issueActionActor) bool {
+	if actionRequest.Status != db.ActionRequestPending {
+		http.Error(w, fmt.Sprintf("Action request is %s", actionRequest.Status), http.StatusConflict)
+		return false
+	}
+	if actor.userID == "" || actor.userID == actionRequest.RequestedBy {
+		http.Error(w, "Forbidden: action requests must be reviewed by a different user", http.StatusForbidden)
+		return false
+	}
+
+	permission, ok := services.ApprovalPermission(actionRequest.Operation)
+	if !ok {
+		http.Error(w, fmt.Sprintf("Operation %s does not support approval", actionRequest.Operation), http.StatusBadRequest)
+		return false
+	}
+	perms, err := s.getEffectivePermissions(r)
+	if err != nil {
+		log.Printf("ERROR: Failed to resolve permissions for %s: %v", r.URL.Path, err)
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return false
+	}
+	if !perms.Can(permission, actionRequest.Facility) {
+		log.Printf("WARN: User %s (%s) denied reviewing action request %d in facility %s", perms.UserID, perms.RoleFor(actionRequest.Facility), actionRequest.ID, actionRequest.Facility)
+		http.Error(w, fmt.Sprintf("Forbidden: %s permission required in facility %s", permission, actionRequest.Facility), http.StatusForbidden)
+		return false
+	}
+	return true
+}
+
+// loadActionRequest loads the session environment's action request in the {id} route variable,
+// writing the error response if it cannot
+func (s *Server) loadActionRequest(w http.ResponseWriter, r *http.Request) (*db.ActionRequest, bool) {
+	id, err := strconv.ParseInt(mux.Vars(r)["id"], 10, 64)
+	if err != nil {
+		http.Error(w, "Invalid action request ID", http.StatusBadRequest)
+		return nil, false
+	}
+
+	session, _ := s.sessionStore.Get(r, "m3-session")
+	environment, _ := session.Values["environment"].(string)
+	if environment == "" {
+		http.Error(w, "Environment not set in session", http.StatusUnauthorized)
+		return nil, false
+	}
+
+	actionRequest, err := s.db.GetActionRequest(r.Context(), environment, id)
+	if err != nil {
+		log.Printf("Failed to get action request %d: %v", id, err)
+		http.Error(w, "Failed to get action request", http.StatusInternalServerError)
+		return nil, false
+	}
+	if actionRequest == nil {
+		http.Error(w, "Action request not found", http.StatusNotFound)
+		return nil, false
+	}
+	return actionRequest, true
+}
+
+// logActionRequest writes an action_request audit entry for one step of the approval flow
+func (s *Server) logActionRequest(ctx context.Context, actionRequest *db.ActionRequest, operation string, actor issueActionActor, metadata map[string]interface{}) {
+	metadata["operation"] = actionRequest.Operation
+	metadata["issue_id"] = actionRequest.IssueID
+	metadata["production_order_number"] = actionRequest.OrderNumber
+	metadata["production_order_type"] = actionRequest.OrderType
+	metadata["requested_by"] = actionRequest.RequestedBy
+
+	err := s.auditService.Log(ctx, services.AuditParams{
+		Environment: actionRequest.Environment,
+		EntityType:  "action_request",
+		EntityID:    fmt.Sprintf("%d", actionRequest.ID),
+		Operation:   operation,
+		UserID:      actor.userID,
+		UserName:    actor.userName,
+		Company:     actionRequest.Company,
+		Facility:    actionRequest.Facility,
+		Metadata:    metadata,
+		IPAddress:   actor.ipAddress,
+		UserAgent:   actor.userAgent,
+	})
+	if err != nil {
+		// Log error but don't fail the request
+		log.Printf("Failed to create audit log: %v", err)
+	}
+}
+
+// decodeOptionalBody decodes a JSON body that may be empty
+func decodeOptionalBody(w http.ResponseWriter, r *http.Request, v interface{}) bool {
+	if err := json.NewDecoder(r.Body).Decode(v); err != nil && err != io.EOF {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return false
+	}
+	return true
+}
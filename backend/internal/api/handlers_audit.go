@@ -1,14 +1,19 @@
 package api
 
 import (
+	"context"
 	"database/sql"
 	"encoding/json"
+	"fmt"
+	"log"
 	"math"
 	"net/http"
 	"strconv"
 	"time"
 
+	"github.com/gorilla/mux"
 	"github.com/pinggolf/m3-planning-tools/internal/db"
+	"github.com/pinggolf/m3-planning-tools/internal/services"
 )
 
 // AuditLogListResponse wraps audit log data with pagination metadata
@@ -184,3 +189,242 @@ func (s *Server) handleListAuditLogs(w http.ResponseWriter, r *http.Request) {
 		},
 	})
 }
+
+// orderDateChange records one order moved by an align action so it can be reverted
+type orderDateChange struct {
+	OrderType   string            `json:"order_type"`
+	OrderNumber string            `json:"order_number"`
+	Facility    string            `json:"facility"`
+	Applied     map[string]string `json:"applied"`      // Parameters sent to M3
+	BeforeState *db.OrderState    `json:"before_state"` // Snapshot values before the change
+}
+
+func newOrderDateChange(orderType, orderNumber, facility string, applied map[string]string, beforeState *db.OrderState) orderDateChange {
+	return orderDateChange{
+		OrderType:   orderType,
+		OrderNumber: orderNumber,
+		Facility:    facility,
+		Applied:     applied,
+		BeforeState: beforeState,
+	}
+}
+
+// orderRevertResult is the outcome of one compensating M3 call
+type orderRevertResult struct {
+	OrderType   string            `json:"order_type"`
+	OrderNumber string            `json:"order_number"`
+	Program     string            `json:"program,omitempty"`
+	Transaction string            `json:"transaction,omitempty"`
+	Record      map[string]string `json:"record,omitempty"`
+	Success     bool              `json:"success"`
+	Error       string            `json:"error,omitempty"`
+}
+
+// nonRevertibleOperations explains why M3 offers no compensating transaction for an operation
+var nonRevertibleOperations = map[string]string{
+	"delete_mop": "Deleted MOPs cannot be restored: PMS170MI can only create a new MOP with a new PLPN and no pegging",
+	"delete_mo":  "Deleted MOs cannot be restored: PMS100MI has no transaction to recreate an MO under its old number",
+	"close_mo":   "Closed MOs cannot be reopened through the M3 API",
+}
+
+// handleRevertAuditLog issues the compensating M3 calls for an audited write operation
+// Only date alignments can be reverted: each moved order is rescheduled back to its recorded before-state
+func (s *Server) handleRevertAuditLog(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	actor := s.newIssueActionActor(r)
+	environment := actor.environment
+	if environment == "" {
+		http.Error(w, "Environment not set in session", http.StatusUnauthorized)
+		return
+	}
+	if actor.userID == "" {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	id, err := strconv.ParseInt(mux.Vars(r)["id"], 10, 64)
+	if err != nil {
+		http.Error(w, "Invalid audit log ID", http.StatusBadRequest)
+		return
+	}
+
+	entry, err := s.db.GetAuditLogByID(ctx, environment, id)
+	if err != nil {
+		log.Printf("Failed to load audit log %d: %v", id, err)
+		http.Error(w, "Failed to load audit log", http.StatusInternalServerError)
+		return
+	}
+	if entry == nil {
+		http.Error(w, "Audit log not found", http.StatusNotFound)
+		return
+	}
+
+	if reason, ok := nonRevertibleOperations[entry.Operation]; ok {
+		http.Error(w, reason, http.StatusUnprocessableEntity)
+		return
+	}
+	if entry.Operation != "align_earliest" && entry.Operation != "align_latest" {
+		http.Error(w, fmt.Sprintf("Operation %s cannot be reverted", entry.Operation), http.StatusUnprocessableEntity)
+		return
+	}
+
+	var metadata struct {
+		Changes []orderDateChange `json:"changes"`
+	}
+	if len(entry.Metadata) > 0 {
+		json.Unmarshal(entry.Metadata, &metadata)
+	}
+	if len(metadata.Changes) == 0 {
+		http.Error(w, "No before-state recorded for this entry", http.StatusUnprocessableEntity)
+		return
+	}
+
+	// Orders restored by an earlier (possibly partial) revert are not touched again
+	reverted, err := s.revertedOrders(ctx, id)
+	if err != nil {
+		log.Printf("Failed to load previous reverts of audit log %d: %v", id, err)
+		http.Error(w, "Failed to load previous reverts", http.StatusInternalServerError)
+		return
+	}
+
+	pending := make([]orderDateChange, 0, len(metadata.Changes))
+	for _, change := range metadata.Changes {
+		if !reverted[change.OrderType+":"+change.OrderNumber] {
+			pending = append(pending, change)
+		}
+	}
+	if len(pending) == 0 {
+		http.Error(w, "Audit log has already been reverted", http.StatusConflict)
+		return
+	}
+
+	m3Client, err := s.getM3APIClient(r)
+	if err != nil {
+		http.Error(w, "Failed to get M3 API client", http.StatusInternalServerError)
+		return
+	}
+
+	results := make([]orderRevertResult, 0, len(pending))
+	changes := []orderDateChange{}
+	revertedCount := 0
+	failedCount := 0
+
+	for _, change := range pending {
+		result := orderRevertResult{OrderType: change.OrderType, OrderNumber: change.OrderNumber}
+		beforeState := s.orderBeforeState(ctx, environment, change.OrderType, change.OrderNumber, change.Facility)
+
+		program, transaction, params, err := s.buildOrderDateRevert(ctx, change)
+		if err == nil {
+			result.Program, result.Transaction, result.Record = program, transaction, params
+			_, err = m3Client.Execute(ctx, program, transaction, params)
+		}
+
+		if err != nil {
+			failedCount++
+			result.Error = err.Error()
+			log.Printf("Failed to revert %s %s: %v", change.OrderType, change.OrderNumber, err)
+		} else {
+			revertedCount++
+			result.Success = true
+			changes = append(changes, newOrderDateChange(change.OrderType, change.OrderNumber, change.Facility, params, beforeState))
+			log.Printf("Reverted %s %s via %s/%s", change.OrderType, change.OrderNumber, program, transaction)
+		}
+		results = append(results, result)
+	}
+
+	if revertedCount > 0 {
+		err = s.auditService.Log(ctx, services.AuditParams{
+			Environment: environment,
+			EntityType:  "audit_log",
+			EntityID:    fmt.Sprintf("%d", id),
+			Operation:   "revert",
+			UserID:      actor.userID,
+			UserName:    actor.userName,
+			Facility:    entry.Facility.String,
+			Metadata: map[string]interface{}{
+				"reverted_operation":   entry.Operation,
+				"reverted_entity_type": entry.EntityType,
+				"reverted_entity_id":   entry.EntityID.String,
+				"reverted_count":       revertedCount,
+				"failed_count":         failedCount,
+				"results":              results,
+				"changes":              changes,
+			},
+			IPAddress: actor.ipAddress,
+			UserAgent: actor.userAgent,
+		})
+		if err != nil {
+			log.Printf("Failed to create audit log: %v", err)
+		}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"success":        failedCount == 0,
+		"reverted_count": revertedCount,
+		"failed_count":   failedCount,
+		"skipped_count":  len(metadata.Changes) - len(pending),
+		"results":        results,
+	})
+}
+
+// revertedOrders returns the orders (type:number) already restored by earlier reverts of an audit entry
+func (s *Server) revertedOrders(ctx context.Context, auditLogID int64) (map[string]bool, error) {
+	entries, err := s.db.GetAuditLogsByEntity(ctx, "audit_log", fmt.Sprintf("%d", auditLogID), 100)
+	if err != nil {
+		return nil, err
+	}
+
+	reverted := make(map[string]bool)
+	for _, entry := range entries {
+		if entry.Operation != "revert" {
+			continue
+		}
+		var metadata struct {
+			Results []orderRevertResult `json:"results"`
+		}
+		if err := json.Unmarshal(entry.Metadata, &metadata); err != nil {
+			continue
+		}
+		for _, result := range metadata.Results {
+			if result.Success {
+				reverted[result.OrderType+":"+result.OrderNumber] = true
+			}
+		}
+	}
+
+	return reverted, nil
+}
+
+// buildOrderDateRevert builds the compensating M3 call that moves an order back to its recorded dates
+// MOs are rescheduled to their old start date; MOPs get their old finish date back (PMS170MI only updates FIDT)
+func (s *Server) buildOrderDateRevert(ctx context.Context, change orderDateChange) (string, string, map[string]string, error) {
+	before := change.BeforeState
+	if before == nil {
+		return "", "", nil, fmt.Errorf("no before-state recorded for %s %s", change.OrderType, change.OrderNumber)
+	}
+
+	switch change.OrderType {
+	case "MO":
+		if before.StartDate == "" || before.StartDate == "0" {
+			return "", "", nil, fmt.Errorf("MO %s has no recorded start date", change.OrderNumber)
+		}
+		params, err := s.buildRescheduleMOParams(ctx, change.OrderNumber, change.Facility, before.StartDate)
+		if err != nil {
+			return "", "", nil, err
+		}
+		return "PMS100MI", "Reschedule", params, nil
+	case "MOP":
+		if before.FinishDate == "" || before.FinishDate == "0" {
+			return "", "", nil, fmt.Errorf("MOP %s has no recorded finish date", change.OrderNumber)
+		}
+		return "PMS170MI", "Updat", map[string]string{
+			"PLPN": change.OrderNumber,
+			"FIDT": before.FinishDate,
+			"IGWA": "1", // Ignore warnings
+		}, nil
+	}
+
+	return "", "", nil, fmt.Errorf("unknown order type: %s", change.OrderType)
+}
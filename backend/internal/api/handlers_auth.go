@@ -6,6 +6,7 @@ import (
 	"fmt"
 	"log"
 	"net/http"
+	"strings"
 
 	"github.com/gorilla/sessions"
 	"github.com/pinggolf/m3-planning-tools/internal/infor"
@@ -15,7 +16,7 @@ import (
 
 // LoginRequest represents the login request payload
 type LoginRequest struct {
-	Environment string `json:"environment"` // "TRN" or "PRD"
+	Environment string `json:"environment"` // Environment key, e.g. "TRN"
 }
 
 // LoginResponse represents the login response
@@ -23,6 +24,13 @@ type LoginResponse struct {
 	AuthURL string `json:"authUrl"`
 }
 
+// EnvironmentResponse describes a configured M3 environment for the login page
+type EnvironmentResponse struct {
+	Key        string `json:"key"`
+	Label      string `json:"label"`
+	Production bool   `json:"production"`
+}
+
 // AuthStatusResponse represents the authentication status
 type AuthStatusResponse struct {
 	Authenticated bool                 `json:"authenticated"`
@@ -73,6 +81,21 @@ type M3UserInfoResponse struct {
 	TimeZone         string `json:"timeZone"`
 }
 
+// handleListEnvironments returns the configured M3 environments (no auth required)
+func (s *Server) handleListEnvironments(w http.ResponseWriter, r *http.Request) {
+	environments := make([]EnvironmentResponse, 0, len(s.config.Environments))
+	for _, env := range s.config.Environments {
+		environments = append(environments, EnvironmentResponse{
+			Key:        env.Key,
+			Label:      env.Label,
+			Production: env.Production,
+		})
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(environments)
+}
+
 // handleLogin initiates the OAuth login flow
 func (s *Server) handleLogin(w http.ResponseWriter, r *http.Request) {
 	var req LoginRequest
@@ -82,8 +105,8 @@ func (s *Server) handleLogin(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// Validate environment
-	if req.Environment != "TRN" && req.Environment != "PRD" {
-		http.Error(w, "Invalid environment. Must be TRN or PRD", http.StatusBadRequest)
+	if !s.config.HasEnvironment(req.Environment) {
+		http.Error(w, fmt.Sprintf("Invalid environment. Must be one of: %s", strings.Join(s.config.EnvironmentKeys(), ", ")), http.StatusBadRequest)
 		return
 	}
 
@@ -299,46 +322,7 @@ func (s *Server) handleAuthStatus(w http.ResponseWriter, r *http.Request) {
 	// Get combined user profile from Postgres cache (15-min TTL)
 	var userProfile *UserProfileResponse
 	if userProfileID, ok := session.Values["user_profile_id"].(string); ok && userProfileID != "" {
-		profile, err := s.userProfileService.GetProfile(r.Context(), userProfileID)
-
-		// If profile is nil (cache expired) or error occurred, try to refresh it
-		if profile == nil || err != nil {
-			if err != nil {
-				log.Printf("WARNING: Failed to get cached user profile: %v, attempting refresh\n", err)
-			} else {
-				log.Printf("INFO: User profile cache expired, refreshing from Infor API\n")
-			}
-
-			// Refresh profile from Infor and M3
-			if inforClient, clientErr := s.getInforClient(r); clientErr == nil {
-				if inforProfile, profileErr := inforClient.GetUserProfile(r.Context()); profileErr == nil {
-					// Create combined profile
-					combinedProfile := &infor.CombinedUserProfile{
-						UserProfile: *inforProfile,
-					}
-
-					// Fetch M3 user info
-					if m3Client, m3Err := s.getM3APIClient(r); m3Err == nil {
-						if m3Info, m3InfoErr := infor.GetM3UserInfo(r.Context(), m3Client); m3InfoErr == nil {
-							combinedProfile.M3Info = m3Info
-							log.Printf("INFO: Refreshed M3 user info for: %s\n", m3Info.UserID)
-						}
-					}
-
-					// Re-cache the refreshed profile
-					if cacheErr := s.userProfileService.SetProfile(r.Context(), combinedProfile); cacheErr == nil {
-						profile = combinedProfile
-						log.Printf("INFO: User profile refreshed and cached for: %s\n", inforProfile.DisplayName)
-					} else {
-						log.Printf("WARNING: Failed to cache refreshed profile: %v\n", cacheErr)
-					}
-				} else {
-					log.Printf("WARNING: Failed to refresh Infor profile: %v\n", profileErr)
-				}
-			} else {
-				log.Printf("WARNING: Failed to create Infor client for profile refresh: %v\n", clientErr)
-			}
-		}
+		profile := s.loadUserProfile(r, userProfileID)
 
 		if profile != nil {
 			// Get primary email
@@ -388,8 +372,6 @@ func (s *Server) handleAuthStatus(w http.ResponseWriter, r *http.Request) {
 				Groups:      groups,
 				M3Info:      m3Info,
 			}
-		} else if err != nil {
-			log.Printf("WARNING: Failed to get user profile from cache: %v\n", err)
 		}
 	}
 
@@ -413,6 +395,53 @@ func (s *Server) handleAuthStatus(w http.ResponseWriter, r *http.Request) {
 	json.NewEncoder(w).Encode(response)
 }
 
+// loadUserProfile returns the cached combined user profile, refreshing it from Infor and M3
+// when the 15-minute cache has expired. Returns nil if no profile could be loaded
+func (s *Server) loadUserProfile(r *http.Request, userProfileID string) *infor.CombinedUserProfile {
+	profile, err := s.userProfileService.GetProfile(r.Context(), userProfileID)
+
+	// If profile is nil (cache expired) or error occurred, try to refresh it
+	if profile == nil || err != nil {
+		if err != nil {
+			log.Printf("WARNING: Failed to get cached user profile: %v, attempting refresh\n", err)
+		} else {
+			log.Printf("INFO: User profile cache expired, refreshing from Infor API\n")
+		}
+
+		// Refresh profile from Infor and M3
+		if inforClient, clientErr := s.getInforClient(r); clientErr == nil {
+			if inforProfile, profileErr := inforClient.GetUserProfile(r.Context()); profileErr == nil {
+				// Create combined profile
+				combinedProfile := &infor.CombinedUserProfile{
+					UserProfile: *inforProfile,
+				}
+
+				// Fetch M3 user info
+				if m3Client, m3Err := s.getM3APIClient(r); m3Err == nil {
+					if m3Info, m3InfoErr := infor.GetM3UserInfo(r.Context(), m3Client); m3InfoErr == nil {
+						combinedProfile.M3Info = m3Info
+						log.Printf("INFO: Refreshed M3 user info for: %s\n", m3Info.UserID)
+					}
+				}
+
+				// Re-cache the refreshed profile
+				if cacheErr := s.userProfileService.SetProfile(r.Context(), combinedProfile); cacheErr == nil {
+					profile = combinedProfile
+					log.Printf("INFO: User profile refreshed and cached for: %s\n", inforProfile.DisplayName)
+				} else {
+					log.Printf("WARNING: Failed to cache refreshed profile: %v\n", cacheErr)
+				}
+			} else {
+				log.Printf("WARNING: Failed to refresh Infor profile: %v\n", profileErr)
+			}
+		} else {
+			log.Printf("WARNING: Failed to create Infor client for profile refresh: %v\n", clientErr)
+		}
+	}
+
+	return profile
+}
+
 // handleGetContext returns the user's current organizational context
 func (s *Server) handleGetContext(w http.ResponseWriter, r *http.Request) {
 	session, _ := s.sessionStore.Get(r, "m3-session")
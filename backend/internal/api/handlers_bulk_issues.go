@@ -0,0 +1,729 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/nats-io/nats.go"
+	"github.com/pinggolf/m3-planning-tools/internal/db"
+	"github.com/pinggolf/m3-planning-tools/internal/m3api"
+	"github.com/pinggolf/m3-planning-tools/internal/queue"
+	"github.com/pinggolf/m3-planning-tools/internal/services"
+)
+
+// Bulk issue actions (same operation names as the single-issue audit entries)
+const (
+	BulkActionDeleteMOP     = "delete_mop"
+	BulkActionDeleteMO      = "delete_mo"
+	BulkActionCloseMO       = "close_mo"
+	BulkActionAlignEarliest = "align_earliest"
+	BulkActionAlignLatest   = "align_latest"
+)
+
+// bulkActionPermissions is the permission each bulk action requires in every selected issue's facility
+var bulkActionPermissions = map[string]services.Permission{
+	BulkActionDeleteMOP:     services.PermissionDeleteMOP,
+	BulkActionDeleteMO:      services.PermissionDeleteMO,
+	BulkActionCloseMO:       services.PermissionCloseMO,
+	BulkActionAlignEarliest: services.PermissionAlignOrders,
+	BulkActionAlignLatest:   services.PermissionAlignOrders,
+}
+
+const (
+	maxBulkActionIssues = 2000 // Largest selection a single bulk action may cover
+	bulkActionChunkSize = 100  // Transactions per M3 bulk request (one progress update each)
+)
+
+// BulkIssueFilter selects issues the same way as the issue list endpoint
+type BulkIssueFilter struct {
+	DetectorType   string `json:"detectorType"`
+	Facility       string `json:"facility"`
+	Warehouse      string `json:"warehouse"`
+	NewOnly        bool   `json:"newOnly"`
+	IncludeIgnored bool   `json:"includeIgnored"`
+}
+
+// BulkIssueActionRequest is the body of POST /issues/bulk
+// Either IssueIDs or Filter selects the issues. DryRun defaults to true so callers
+// always see the planned M3 transactions before anything is executed.
+type BulkIssueActionRequest struct {
+	Action               string           `json:"action"`
+	IssueIDs             []int64          `json:"issueIds,omitempty"`
+	Filter               *BulkIssueFilter `json:"filter,omitempty"`
+	DryRun               *bool            `json:"dryRun,omitempty"`
+	ExpectedTransactions *int             `json:"expectedTransactions,omitempty"` // Reject execution if the plan changed since the preview
+}
+
+// BulkSkippedIssue is an issue (or one of its orders) the action cannot be applied to
+type BulkSkippedIssue struct {
+	IssueID int64  `json:"issueId"`
+	Reason  string `json:"reason"`
+}
+
+// BulkIssueActionPlan is the dry-run preview of a bulk action
+type BulkIssueActionPlan struct {
+	Action           string               `json:"action"`
+	DryRun           bool                 `json:"dryRun"`
+	IssueCount       int                  `json:"issueCount"`
+	Transactions     []*db.BulkActionItem `json:"transactions"`
+	ByProgram        map[string]int       `json:"byProgram"`
+	Skipped          []BulkSkippedIssue   `json:"skipped"`
+	ApprovalRequired bool                 `json:"approvalRequired,omitempty"` // The action must go through individual action requests
+}
+
+// BulkActionProgress is streamed over NATS/SSE while a bulk action executes
+type BulkActionProgress struct {
+	JobID     string               `json:"jobId"`
+	Status    string               `json:"status"`
+	Progress  int                  `json:"progress"`
+	Processed int                  `json:"processed"`
+	Total     int                  `json:"total"`
+	Succeeded int                  `json:"succeeded"`
+	Failed    int                  `json:"failed"`
+	Results   []*db.BulkActionItem `json:"results,omitempty"` // Items completed since the previous update
+	Error     string               `json:"error,omitempty"`
+}
+
+// issueActionActor identifies who performs an issue action for the audit log
+type issueActionActor struct {
+	environment string
+	userID      string
+	userName    string
+	ipAddress   string
+	userAgent   string
+}
+
+// newIssueActionActor returns the session user of a request as an issue action actor
+// The user ID is the Infor user profile ID, the same ID permissions are resolved for
+func (s *Server) newIssueActionActor(r *http.Request) issueActionActor {
+	session, _ := s.sessionStore.Get(r, "m3-session")
+	environment, _ := session.Values["environment"].(string)
+	userID, _ := session.Values["user_profile_id"].(string)
+	userName, _ := session.Values["user_full_name"].(string)
+	return issueActionActor{
+		environment: environment,
+		userID:      userID,
+		userName:    userName,
+		ipAddress:   getIPAddress(r),
+		userAgent:   r.Header.Get("User-Agent"),
+	}
+}
+
+// handleBulkIssueAction previews (dryRun, the default) or starts a bulk issue action
+func (s *Server) handleBulkIssueAction(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	session, _ := s.sessionStore.Get(r, "m3-session")
+	environment, _ := session.Values["environment"].(string)
+	if environment == "" {
+		http.Error(w, "Environment not set in session", http.StatusUnauthorized)
+		return
+	}
+
+	var req BulkIssueActionRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	permission, ok := bulkActionPermissions[req.Action]
+	if !ok {
+		http.Error(w, fmt.Sprintf("Invalid action: %s", req.Action), http.StatusBadRequest)
+		return
+	}
+
+	// The selection's facilities are only known once planned, so issues in facilities where
+	// the user lacks the permission are skipped by the plan
+	perms, err := s.getEffectivePermissions(r)
+	if err != nil {
+		log.Printf("Failed to resolve permissions for bulk action: %v", err)
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+	if !perms.CanInAnyFacility(permission) {
+		http.Error(w, fmt.Sprintf("Forbidden: %s permission required", permission), http.StatusForbidden)
+		return
+	}
+
+	if len(req.IssueIDs) == 0 && req.Filter == nil {
+		http.Error(w, "Either issueIds or filter is required", http.StatusBadRequest)
+		return
+	}
+
+	plan, err := s.planBulkIssueAction(ctx, environment, req, perms)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	dryRun := req.DryRun == nil || *req.DryRun
+	plan.DryRun = dryRun
+	plan.ApprovalRequired = s.permissionService.ApprovalRequired(environment, req.Action)
+
+	w.Header().Set("Content-Type", "application/json")
+	if dryRun {
+		json.NewEncoder(w).Encode(plan)
+		return
+	}
+
+	// Operations that need approval cannot bypass it in bulk
+	if plan.ApprovalRequired {
+		w.WriteHeader(http.StatusConflict)
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"error":            fmt.Sprintf("%s requires approval in %s: submit an action request per issue", req.Action, environment),
+			"approvalRequired": true,
+		})
+		return
+	}
+
+	if req.ExpectedTransactions != nil && *req.ExpectedTransactions != len(plan.Transactions) {
+		w.WriteHeader(http.StatusConflict)
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"error": fmt.Sprintf("Plan changed since preview: expected %d transactions, found %d", *req.ExpectedTransactions, len(plan.Transactions)),
+			"plan":  plan,
+		})
+		return
+	}
+
+	if len(plan.Transactions) == 0 {
+		http.Error(w, "No transactions to execute", http.StatusBadRequest)
+		return
+	}
+
+	m3Client, err := s.getM3APIClient(r)
+	if err != nil {
+		http.Error(w, "Failed to get M3 API client", http.StatusInternalServerError)
+		return
+	}
+
+	actor := s.newIssueActionActor(r)
+
+	selection, _ := json.Marshal(map[string]interface{}{
+		"issueIds": req.IssueIDs,
+		"filter":   req.Filter,
+	})
+
+	jobID := fmt.Sprintf("bulk-%d", time.Now().UnixNano())
+	if err := s.db.CreateBulkActionJob(ctx, db.CreateBulkActionJobParams{
+		JobID:         jobID,
+		Environment:   environment,
+		UserID:        actor.userID,
+		Action:        req.Action,
+		Selection:     string(selection),
+		SkippedIssues: len(plan.Skipped),
+		Items:         plan.Transactions,
+	}); err != nil {
+		log.Printf("Failed to create bulk action job: %v", err)
+		http.Error(w, "Failed to create bulk action job", http.StatusInternalServerError)
+		return
+	}
+
+	// Execution outlives the request; progress is streamed via /issues/bulk/{jobId}/progress
+	go s.runBulkIssueAction(jobID, req.Action, plan.Transactions, m3Client, actor)
+
+	w.WriteHeader(http.StatusAccepted)
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"jobId":             jobID,
+		"action":            req.Action,
+		"totalTransactions": len(plan.Transactions),
+		"skipped":           plan.Skipped,
+	})
+}
+
+// planBulkIssueAction resolves the selected issues and builds the M3 transactions for the action
+func (s *Server) planBulkIssueAction(ctx context.Context, environment string, req BulkIssueActionRequest, perms *services.EffectivePermissions) (*BulkIssueActionPlan, error) {
+	var issues []*db.DetectedIssue
+	var err error
+
+	plan := &BulkIssueActionPlan{
+		Action:       req.Action,
+		Transactions: make([]*db.BulkActionItem, 0),
+		ByProgram:    make(map[string]int),
+		Skipped:      make([]BulkSkippedIssue, 0),
+	}
+
+	if len(req.IssueIDs) > 0 {
+		if len(req.IssueIDs) > maxBulkActionIssues {
+			return nil, fmt.Errorf("too many issues selected (%d, max %d)", len(req.IssueIDs), maxBulkActionIssues)
+		}
+		issues, err = s.db.GetIssuesByIDs(ctx, environment, req.IssueIDs)
+		if err != nil {
+			return nil, err
+		}
+
+		found := make(map[int64]bool, len(issues))
+		for _, issue := range issues {
+			found[issue.ID] = true
+		}
+		for _, id := range req.IssueIDs {
+			if !found[id] {
+				plan.Skipped = append(plan.Skipped, BulkSkippedIssue{IssueID: id, Reason: "issue not found"})
+			}
+		}
+	} else {
+		f := req.Filter
+		issues, err = s.db.GetIssuesFiltered(ctx, environment, f.DetectorType, f.Facility, f.Warehouse, f.IncludeIgnored, f.NewOnly, maxBulkActionIssues+1, 0)
+		if err != nil {
+			return nil, err
+		}
+		if len(issues) > maxBulkActionIssues {
+			return nil, fmt.Errorf("filter matches more than %d issues, narrow the selection", maxBulkActionIssues)
+		}
+	}
+
+	plan.IssueCount = len(issues)
+	permission := bulkActionPermissions[req.Action]
+
+	// The same order can appear in several issues; only transact it once
+	seen := make(map[string]bool)
+	for _, issue := range issues {
+		if !perms.Can(permission, issue.Facility) {
+			plan.Skipped = append(plan.Skipped, BulkSkippedIssue{
+				IssueID: issue.ID,
+				Reason:  fmt.Sprintf("%s role cannot %s in facility %s", perms.RoleFor(issue.Facility), req.Action, issue.Facility),
+			})
+			continue
+		}
+
+		items, skipped := s.planBulkIssueItems(ctx, req.Action, issue)
+		plan.Skipped = append(plan.Skipped, skipped...)
+
+		for _, item := range items {
+			key := item.Program + "/" + item.Transaction + "/" + item.Facility + "/" + item.OrderNumber
+			if seen[key] {
+				continue
+			}
+			seen[key] = true
+			plan.Transactions = append(plan.Transactions, item)
+			plan.ByProgram[item.Program]++
+		}
+	}
+
+	return plan, nil
+}
+
+// planBulkIssueItems builds one issue's transactions with the same checks as the single-issue handlers
+func (s *Server) planBulkIssueItems(ctx context.Context, action string, issue *db.DetectedIssue) ([]*db.BulkActionItem, []BulkSkippedIssue) {
+	skip := func(reason string) []BulkSkippedIssue {
+		return []BulkSkippedIssue{{IssueID: issue.ID, Reason: reason}}
+	}
+
+	var issueData map[string]interface{}
+	if err := json.Unmarshal([]byte(issue.IssueData), &issueData); err != nil {
+		return nil, skip("failed to parse issue data")
+	}
+	company, _ := issueData["company"].(string)
+
+	newItem := func(orderType, orderNumber, program, transaction string, record map[string]string) *db.BulkActionItem {
+		return &db.BulkActionItem{
+			IssueID:     issue.ID,
+			Facility:    issue.Facility,
+			OrderType:   orderType,
+			OrderNumber: orderNumber,
+			Program:     program,
+			Transaction: transaction,
+			Record:      record,
+		}
+	}
+
+	orderType := issue.ProductionOrderType.String
+	orderNumber := issue.ProductionOrderNumber.String
+
+	switch action {
+	case BulkActionDeleteMOP:
+		if orderType != "MOP" {
+			return nil, skip("not a MOP")
+		}
+		plpn, err := strconv.ParseInt(orderNumber, 10, 64)
+		if err != nil {
+			return nil, skip("invalid planned order number format")
+		}
+		record := map[string]string{"PLPN": fmt.Sprintf("%d", plpn)}
+		if company != "" {
+			record["CONO"] = company
+		}
+		return []*db.BulkActionItem{newItem(orderType, orderNumber, "PMS170MI", "DelPlannedMO", record)}, nil
+
+	case BulkActionDeleteMO, BulkActionCloseMO:
+		if orderType != "MO" {
+			return nil, skip("not an MO")
+		}
+		if statusStr, ok := issueData["status"].(string); ok {
+			if status, err := strconv.Atoi(statusStr); err == nil {
+				if action == BulkActionDeleteMO && status > 22 {
+					return nil, skip("MO status is too advanced for deletion, use close_mo")
+				}
+				if action == BulkActionCloseMO && status <= 22 {
+					return nil, skip("MO status allows deletion, use delete_mo")
+				}
+			}
+		}
+		if action == BulkActionDeleteMO {
+			record := map[string]string{"MFNO": orderNumber}
+			if company != "" {
+				record["CONO"] = company
+			}
+			return []*db.BulkActionItem{newItem(orderType, orderNumber, "PMS100MI", "DltMO", record)}, nil
+		}
+		record := map[string]string{"MFNO": orderNumber, "FACI": issue.Facility}
+		return []*db.BulkActionItem{newItem(orderType, orderNumber, "PMS100MI", "CloseMO", record)}, nil
+
+	case BulkActionAlignEarliest, BulkActionAlignLatest:
+		if issue.DetectorType != "joint_delivery_date_mismatch" {
+			return nil, skip("not a joint delivery date mismatch issue")
+		}
+
+		dateKey := "min_date"
+		if action == BulkActionAlignLatest {
+			dateKey = "max_date"
+		}
+		targetDate, ok := issueData[dateKey].(float64)
+		if !ok {
+			return nil, skip(fmt.Sprintf("invalid %s in issue data", dateKey))
+		}
+		orders, ok := issueData["orders"].([]interface{})
+		if !ok || len(orders) == 0 {
+			return nil, skip("no orders found in issue data")
+		}
+
+		alignmentDate, _ := getAlignmentDate(int(targetDate))
+		alignmentDateStr := fmt.Sprintf("%d", alignmentDate)
+
+		items := make([]*db.BulkActionItem, 0, len(orders))
+		skipped := make([]BulkSkippedIssue, 0)
+		for _, orderInterface := range orders {
+			order, ok := orderInterface.(map[string]interface{})
+			if !ok {
+				continue
+			}
+			number, _ := order["number"].(string)
+			typ, _ := order["type"].(string)
+			currentDate, _ := order["date"].(string)
+
+			// Already aligned to the target date
+			if currentDate == alignmentDateStr {
+				continue
+			}
+
+			switch typ {
+			case "MO":
+				record, err := s.buildRescheduleMOParams(ctx, number, issue.Facility, alignmentDateStr)
+				if err != nil {
+					skipped = append(skipped, BulkSkippedIssue{IssueID: issue.ID, Reason: fmt.Sprintf("MO %s: %v", number, err)})
+					continue
+				}
+				items = append(items, newItem(typ, number, "PMS100MI", "Reschedule", record))
+			case "MOP":
+				record, err := s.buildUpdateMOPDatesParams(ctx, number, currentDate, alignmentDateStr)
+				if err != nil {
+					skipped = append(skipped, BulkSkippedIssue{IssueID: issue.ID, Reason: fmt.Sprintf("MOP %s: %v", number, err)})
+					continue
+				}
+				items = append(items, newItem(typ, number, "PMS170MI", "Updat", record))
+			default:
+				skipped = append(skipped, BulkSkippedIssue{IssueID: issue.ID, Reason: fmt.Sprintf("unknown order type: %s", typ)})
+			}
+		}
+		return items, skipped
+	}
+
+	return nil, skip(fmt.Sprintf("unsupported action: %s", action))
+}
+
+// runBulkIssueAction executes the planned transactions in M3 bulk requests, recording each result
+func (s *Server) runBulkIssueAction(jobID, action string, items []*db.BulkActionItem, m3Client *m3api.Client, actor issueActionActor) {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Minute)
+	defer cancel()
+
+	log.Printf("Starting bulk action %s (%s): %d transactions", jobID, action, len(items))
+
+	// Bulk requests are per program, so group while keeping plan order within a program
+	programOrder := make([]string, 0)
+	byProgram := make(map[string][]*db.BulkActionItem)
+	for _, item := range items {
+		if _, ok := byProgram[item.Program]; !ok {
+			programOrder = append(programOrder, item.Program)
+		}
+		byProgram[item.Program] = append(byProgram[item.Program], item)
+	}
+
+	progress := BulkActionProgress{JobID: jobID, Status: "running", Total: len(items)}
+	s.publishBulkActionProgress(progress)
+
+	for _, program := range programOrder {
+		programItems := byProgram[program]
+		for start := 0; start < len(programItems); start += bulkActionChunkSize {
+			end := start + bulkActionChunkSize
+			if end > len(programItems) {
+				end = len(programItems)
+			}
+			chunk := programItems[start:end]
+
+			requests := make([]m3api.BulkRequestItem, len(chunk))
+			beforeStates := make([]*db.OrderState, len(chunk))
+			for i, item := range chunk {
+				requests[i] = m3api.BulkRequestItem{
+					Program:     item.Program,
+					Transaction: item.Transaction,
+					Record:      item.Record,
+				}
+				beforeStates[i] = s.orderBeforeState(ctx, actor.environment, item.OrderType, item.OrderNumber, item.Facility)
+			}
+
+			resp, err := m3Client.ExecuteBulk(ctx, requests)
+			for i, item := range chunk {
+				succeeded, errorMessage := bulkItemOutcome(resp, err, i)
+				item.Status = "failed"
+				item.ErrorMessage = errorMessage
+				if succeeded {
+					item.Status = "succeeded"
+					progress.Succeeded++
+				} else {
+					progress.Failed++
+				}
+				s.recordBulkActionItem(ctx, jobID, action, item, beforeStates[i], actor)
+			}
+
+			progress.Processed += len(chunk)
+			progress.Progress = progress.Processed * 100 / progress.Total
+			progress.Results = chunk
+			s.publishBulkActionProgress(progress)
+		}
+	}
+
+	status := "completed"
+	errorMessage := ""
+	if progress.Succeeded == 0 && progress.Failed > 0 {
+		status = "failed"
+		errorMessage = "All transactions failed"
+	}
+	if err := s.db.CompleteBulkActionJob(context.Background(), jobID, status, errorMessage); err != nil {
+		log.Printf("Failed to complete bulk action job %s: %v", jobID, err)
+	}
+
+	progress.Status = status
+	progress.Progress = 100
+	progress.Results = nil
+	progress.Error = errorMessage
+	s.publishBulkActionProgress(progress)
+
+	log.Printf("Bulk action %s finished: %d succeeded, %d failed", jobID, progress.Succeeded, progress.Failed)
+}
+
+// bulkItemOutcome extracts the result of the i-th transaction of a single-program bulk request
+func bulkItemOutcome(resp *m3api.BulkResponse, err error, i int) (bool, string) {
+	if resp == nil {
+		if err != nil {
+			return false, err.Error()
+		}
+		return false, "no response from M3"
+	}
+	if i >= len(resp.Results) {
+		return false, "no result returned for transaction"
+	}
+
+	result := resp.Results[i]
+	if result.IsSuccess() {
+		return true, ""
+	}
+	if result.NotProcessed {
+		return false, "not processed"
+	}
+	return false, result.ErrorMessage
+}
+
+// recordBulkActionItem stores an item result, applies the local snapshot update and writes the audit entry
+func (s *Server) recordBulkActionItem(ctx context.Context, jobID, action string, item *db.BulkActionItem, beforeState *db.OrderState, actor issueActionActor) {
+	succeeded := item.Status == "succeeded"
+
+	if err := s.db.UpdateBulkActionItemResult(ctx, jobID, item.ID, succeeded, item.ErrorMessage); err != nil {
+		log.Printf("Failed to record bulk action item %d: %v", item.ID, err)
+	}
+
+	// Hide deleted/closed orders until the next refresh, as the single-issue actions do
+	if succeeded {
+		switch action {
+		case BulkActionDeleteMOP:
+			if plpn, err := strconv.ParseInt(item.OrderNumber, 10, 64); err == nil {
+				if err := s.db.MarkMOPAsDeletedRemotely(ctx, plpn, item.Facility); err != nil {
+					log.Printf("Failed to mark MOP %d as deleted: %v", plpn, err)
+				}
+			}
+		case BulkActionDeleteMO, BulkActionCloseMO:
+			if err := s.db.MarkMOAsDeletedRemotely(ctx, item.OrderNumber, item.Facility); err != nil {
+				log.Printf("Failed to mark MO %s as deleted: %v", item.OrderNumber, err)
+			}
+		}
+	}
+
+	metadata := map[string]interface{}{
+		"bulk_job_id":             jobID,
+		"production_order_number": item.OrderNumber,
+		"production_order_type":   item.OrderType,
+		"program":                 item.Program,
+		"transaction":             item.Transaction,
+		"record":                  item.Record,
+		"before_state":            beforeState,
+		"success":                 succeeded,
+		"error":                   item.ErrorMessage,
+	}
+	// Aligned orders can be moved back via /audit-logs/{id}/revert
+	if succeeded && (action == BulkActionAlignEarliest || action == BulkActionAlignLatest) {
+		metadata["changes"] = []orderDateChange{
+			newOrderDateChange(item.OrderType, item.OrderNumber, item.Facility, item.Record, beforeState),
+		}
+	}
+
+	err := s.auditService.Log(ctx, services.AuditParams{
+		Environment: actor.environment,
+		EntityType:  "issue",
+		EntityID:    fmt.Sprintf("%d", item.IssueID),
+		Operation:   action,
+		UserID:      actor.userID,
+		UserName:    actor.userName,
+		Facility:    item.Facility,
+		Metadata:    metadata,
+		IPAddress:   actor.ipAddress,
+		UserAgent:   actor.userAgent,
+	})
+	if err != nil {
+		log.Printf("Failed to create audit log: %v", err)
+	}
+}
+
+// publishBulkActionProgress publishes a progress update for SSE subscribers
+func (s *Server) publishBulkActionProgress(progress BulkActionProgress) {
+	data, _ := json.Marshal(progress)
+	if err := s.natsManager.Publish(queue.GetBulkActionProgressSubject(progress.JobID), data); err != nil {
+		log.Printf("Failed to publish bulk action progress: %v", err)
+	}
+}
+
+// handleGetBulkIssueAction returns a bulk action job with its per-item results
+func (s *Server) handleGetBulkIssueAction(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	jobID := mux.Vars(r)["jobId"]
+
+	session, _ := s.sessionStore.Get(r, "m3-session")
+	environment, _ := session.Values["environment"].(string)
+
+	job, err := s.db.GetBulkActionJob(ctx, jobID)
+	if err != nil {
+		http.Error(w, "Failed to load bulk action", http.StatusInternalServerError)
+		return
+	}
+	if job == nil || job.Environment != environment {
+		http.Error(w, "Bulk action not found", http.StatusNotFound)
+		return
+	}
+
+	items, err := s.db.GetBulkActionItems(ctx, jobID)
+	if err != nil {
+		http.Error(w, "Failed to load bulk action items", http.StatusInternalServerError)
+		return
+	}
+
+	response := map[string]interface{}{
+		"job":   job,
+		"items": items,
+	}
+	if job.CompletedAt.Valid {
+		response["completedAt"] = job.CompletedAt.Time
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
+}
+
+// handleBulkIssueActionProgressSSE streams bulk action progress via Server-Sent Events
+func (s *Server) handleBulkIssueActionProgressSSE(w http.ResponseWriter, r *http.Request) {
+	jobID := mux.Vars(r)["jobId"]
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.Header().Set("X-Accel-Buffering", "no")
+
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		http.Error(w, "Streaming not supported", http.StatusInternalServerError)
+		return
+	}
+	rc := http.NewResponseController(w)
+	ctx := r.Context()
+
+	// Subscribe before reading the job so no update between the two is lost
+	msgChan := make(chan *nats.Msg, 10)
+	sub, err := s.natsManager.Subscribe(queue.GetBulkActionProgressSubject(jobID), func(msg *nats.Msg) {
+		select {
+		case msgChan <- msg:
+		case <-ctx.Done():
+		}
+	})
+	if err != nil {
+		sendSSEEvent(w, flusher, rc, "error", map[string]string{"error": "Failed to subscribe to bulk action progress"})
+		return
+	}
+	defer sub.Unsubscribe()
+
+	// Send the current state so late subscribers (or finished jobs) get a snapshot
+	job, err := s.db.GetBulkActionJob(ctx, jobID)
+	if err != nil || job == nil {
+		sendSSEEvent(w, flusher, rc, "error", map[string]string{"error": "Bulk action not found"})
+		return
+	}
+	processed := job.Succeeded + job.Failed
+	initial := BulkActionProgress{
+		JobID:     job.ID,
+		Status:    job.Status,
+		Processed: processed,
+		Total:     job.TotalTransactions,
+		Succeeded: job.Succeeded,
+		Failed:    job.Failed,
+		Error:     job.ErrorMessage,
+	}
+	if job.TotalTransactions > 0 {
+		initial.Progress = processed * 100 / job.TotalTransactions
+	}
+	if job.Status != "running" {
+		sendSSEEvent(w, flusher, rc, "complete", initial)
+		return
+	}
+	sendSSEEvent(w, flusher, rc, "progress", initial)
+
+	heartbeat := time.NewTicker(5 * time.Second)
+	defer heartbeat.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+
+		case msg := <-msgChan:
+			var update BulkActionProgress
+			if err := json.Unmarshal(msg.Data, &update); err != nil {
+				log.Printf("Failed to parse bulk action progress: %v", err)
+				continue
+			}
+
+			if update.Status == "running" {
+				sendSSEEvent(w, flusher, rc, "progress", update)
+				continue
+			}
+
+			sendSSEEvent(w, flusher, rc, "complete", update)
+			return
+
+		case <-heartbeat.C:
+			rc.SetWriteDeadline(time.Now().Add(30 * time.Second))
+			fmt.Fprintf(w, ": heartbeat\n\n")
+			flusher.Flush()
+		}
+	}
+}
@@ -90,7 +90,7 @@ func (s *Server) handleGetEffectiveContext(w http.ResponseWriter, r *http.Reques
 	// Get environment from session
 	environment, _ := session.Values["environment"].(string)
 	if environment == "" {
-		environment = "PRD" // Default fallback
+		environment = s.config.DefaultEnvironment() // Default fallback
 	}
 
 	// Get effective context
@@ -203,7 +203,7 @@ func (s *Server) handleRetryLoadContext(w http.ResponseWriter, r *http.Request)
 
 	// Get environment (already fetched above on line 169)
 	if environment == "" {
-		environment = "PRD" // Default fallback
+		environment = s.config.DefaultEnvironment() // Default fallback
 	}
 
 	response := EffectiveContextResponse{
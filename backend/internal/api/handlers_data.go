@@ -1,41 +1,53 @@
 package api
 
 import (
+	"context"
+	"encoding/base64"
 	"encoding/json"
 	"fmt"
 	"log"
 	"net/http"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/gorilla/mux"
+	"github.com/pinggolf/m3-planning-tools/internal/db"
+	"github.com/pinggolf/m3-planning-tools/internal/queue"
 	"github.com/pinggolf/m3-planning-tools/internal/services"
 )
 
-// Placeholder handlers for data endpoints
-// These will be implemented once we have the full database schema and M3 client
-
 // RefreshRequest represents a refresh request
 type RefreshRequest struct {
-	JobID       string `json:"jobId"`
-	Environment string `json:"environment"`
-	AccessToken string `json:"accessToken"`
-	Company     string `json:"company"`
-	Facility    string `json:"facility"`
-	Language    string `json:"language"`
+	JobID         string   `json:"jobId"`
+	Environment   string   `json:"environment"`
+	CredentialRef string   `json:"credentialRef"` // Job-scoped reference exchanged with the credential broker
+	Company       string   `json:"company"`
+	Facility      string   `json:"facility"`
+	Facilities    []string `json:"facilities,omitempty"`
+	Language      string   `json:"language"`
+	RefreshMode   string   `json:"refreshMode,omitempty"`
 }
 
 // handleSnapshotRefresh initiates a data refresh from M3 via NATS
 func (s *Server) handleSnapshotRefresh(w http.ResponseWriter, r *http.Request) {
 	session, _ := s.sessionStore.Get(r, "m3-session")
 
-	// Get environment and access token
+	// Get environment and the user's tokens (handed to the credential broker, never to NATS)
 	environment, _ := session.Values["environment"].(string)
-	accessToken, err := s.authManager.GetAccessToken(session)
+	userToken, err := s.authManager.GetSessionToken(session)
 	if err != nil {
 		http.Error(w, "Failed to get access token", http.StatusUnauthorized)
 		return
 	}
 
+	// The job and its brokered credential belong to the session's Infor user profile
+	userID, _ := session.Values["user_profile_id"].(string)
+	if userID == "" {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
 	// Get effective context (respects temporary overrides)
 	effectiveContext := s.contextService.GetEffectiveContext(session)
 
@@ -44,47 +56,71 @@ func (s *Server) handleSnapshotRefresh(w http.ResponseWriter, r *http.Request) {
 		http.Error(w, "Company context is not set. Please select a company before refreshing data.", http.StatusBadRequest)
 		return
 	}
-	if effectiveContext.Facility == "" {
+
+	// Optional body selects the refresh mode (defaults to the snapshot_refresh_mode system setting)
+	// and the facilities to load (defaults to the context facility)
+	var requestBody struct {
+		Mode          string   `json:"mode"`
+		Facilities    []string `json:"facilities"`
+		AllFacilities bool     `json:"allFacilities"`
+	}
+	if !decodeOptionalBody(w, r, &requestBody) {
+		return
+	}
+
+	if effectiveContext.Facility == "" && len(requestBody.Facilities) == 0 && !requestBody.AllFacilities {
 		http.Error(w, "Facility context is not set. Please select a facility before refreshing data.", http.StatusBadRequest)
 		return
 	}
 
-	// DEBUG: Print token and context for manual testing
-	log.Printf("=== SNAPSHOT REFRESH REQUEST ===")
-	log.Printf("Token: %s", accessToken)
-	log.Printf("Environment: %s", environment)
-	log.Printf("Company: %s", effectiveContext.Company)
-	log.Printf("Facility: %s", effectiveContext.Facility)
-	log.Printf("Language: %s", effectiveContext.Language)
-	log.Printf("=======================================")
+	facilities, err := s.resolveRefreshFacilities(r, environment, effectiveContext.Company, effectiveContext.Facility, requestBody.Facilities, requestBody.AllFacilities)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	refreshMode := requestBody.Mode
+	if refreshMode == "" {
+		refreshMode = services.LoadSystemSettingString(s.db, environment, "snapshot_refresh_mode", services.RefreshModeFull)
+	}
+	if refreshMode != services.RefreshModeFull && refreshMode != services.RefreshModeIncremental {
+		http.Error(w, "Invalid refresh mode. Must be 'full' or 'incremental'", http.StatusBadRequest)
+		return
+	}
 
 	// Generate job ID
 	jobID := generateJobID()
 
 	// Create job record in database
 	ctx := r.Context()
-	userID := session.Values["user_id"]
-	if userID == nil {
-		userID = "anonymous"
+	if err := s.db.CreateRefreshJob(ctx, jobID, environment, userID, "snapshot_refresh"); err != nil {
+		http.Error(w, "Failed to create job", http.StatusInternalServerError)
+		return
 	}
 
-	if err := s.db.CreateRefreshJob(ctx, jobID, environment, userID.(string), "snapshot_refresh"); err != nil {
-		http.Error(w, "Failed to create job", http.StatusInternalServerError)
+	// Workers exchange this reference for the user's token, refreshed server side as the job runs
+	credentialRef, err := s.credentialBroker.IssueForUser(ctx, jobID, environment, userID, userToken)
+	if err != nil {
+		log.Printf("Failed to issue job credential for %s: %v", jobID, err)
+		s.db.FailJob(ctx, jobID, "Failed to issue job credential")
+		http.Error(w, "Failed to issue job credential", http.StatusInternalServerError)
 		return
 	}
 
 	// Publish refresh request to NATS
 	refreshMsg := RefreshRequest{
-		JobID:       jobID,
-		Environment: environment,
-		AccessToken: accessToken,
-		Company:     effectiveContext.Company,
-		Facility:    effectiveContext.Facility,
-		Language:    effectiveContext.Language,
+		JobID:         jobID,
+		Environment:   environment,
+		CredentialRef: credentialRef,
+		Company:       effectiveContext.Company,
+		Facility:      facilities[0],
+		Facilities:    facilities,
+		Language:      effectiveContext.Language,
+		RefreshMode:   refreshMode,
 	}
 
 	msgData, _ := json.Marshal(refreshMsg)
-	subject := getRefreshSubject(environment)
+	subject := queue.GetSnapshotRefreshSubject(environment)
 
 	if err := s.natsManager.Publish(subject, msgData); err != nil {
 		s.db.FailJob(ctx, jobID, "Failed to publish job to queue")
@@ -95,19 +131,61 @@ func (s *Server) handleSnapshotRefresh(w http.ResponseWriter, r *http.Request) {
 	log.Printf("Snapshot refresh job %s queued for environment %s", jobID, environment)
 
 	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(map[string]string{
-		"status":  "queued",
-		"jobId":   jobID,
-		"message": "Snapshot refresh job queued",
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"status":      "queued",
+		"jobId":       jobID,
+		"refreshMode": refreshMode,
+		"facilities":  facilities,
+		"message":     "Snapshot refresh job queued",
 	})
 }
 
-// getRefreshSubject returns the NATS subject for refresh based on environment
-func getRefreshSubject(environment string) string {
-	if environment == "TRN" {
-		return "snapshot.refresh.TRN"
+// resolveRefreshFacilities returns the facilities a refresh should load
+// Defaults to the context facility; allFacilities expands to every cached facility of the company
+func (s *Server) resolveRefreshFacilities(r *http.Request, environment, company, contextFacility string, requested []string, allFacilities bool) ([]string, error) {
+	if !allFacilities && len(requested) == 0 {
+		return []string{contextFacility}, nil
+	}
+
+	repo, err := s.getContextRepositoryForRequest(r, environment)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get context repository: %w", err)
+	}
+
+	cached, err := repo.GetFacilities(r.Context(), false)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load facilities: %w", err)
+	}
+
+	companyFacilities := make(map[string]bool)
+	var all []string
+	for _, f := range cached {
+		if f.CompanyNumber == company && !companyFacilities[f.Facility] {
+			companyFacilities[f.Facility] = true
+			all = append(all, f.Facility)
+		}
 	}
-	return "snapshot.refresh.PRD"
+
+	if allFacilities {
+		if len(all) == 0 {
+			return nil, fmt.Errorf("no facilities found for company %s", company)
+		}
+		return all, nil
+	}
+
+	facilities := make([]string, 0, len(requested))
+	seen := make(map[string]bool)
+	for _, facility := range requested {
+		if !companyFacilities[facility] {
+			return nil, fmt.Errorf("facility %s does not belong to company %s", facility, company)
+		}
+		if !seen[facility] {
+			seen[facility] = true
+			facilities = append(facilities, facility)
+		}
+	}
+
+	return facilities, nil
 }
 
 // generateJobID generates a unique job ID
@@ -329,62 +407,335 @@ func (s *Server) handleSnapshotSummary(w http.ResponseWriter, r *http.Request) {
 	})
 }
 
-// handleListProductionOrders lists all production orders (unified MO/MOP view)
+// ProductionOrderListResponse wraps a page of production orders with keyset pagination metadata
+type ProductionOrderListResponse struct {
+	Data       []*db.ProductionOrderSummary `json:"data"`
+	Pagination CursorPaginationMeta         `json:"pagination"`
+}
+
+// CursorPaginationMeta contains keyset pagination information
+// NextCursor is passed back as the cursor query parameter to fetch the following page
+type CursorPaginationMeta struct {
+	PageSize   int    `json:"pageSize"`
+	HasMore    bool   `json:"hasMore"`
+	NextCursor string `json:"nextCursor,omitempty"`
+}
+
+// productionOrderCursor is the decoded keyset cursor: the last row's sort value and id
+// Sort and Desc are included so a cursor cannot be reused with a different ordering
+type productionOrderCursor struct {
+	Value string `json:"v"`
+	ID    int64  `json:"id"`
+	Sort  string `json:"s"`
+	Desc  bool   `json:"d"`
+}
+
+func encodeProductionOrderCursor(cursor productionOrderCursor) string {
+	data, _ := json.Marshal(cursor)
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+func decodeProductionOrderCursor(encoded string) (*productionOrderCursor, error) {
+	data, err := base64.RawURLEncoding.DecodeString(encoded)
+	if err != nil {
+		return nil, err
+	}
+	var cursor productionOrderCursor
+	if err := json.Unmarshal(data, &cursor); err != nil {
+		return nil, err
+	}
+	return &cursor, nil
+}
+
+// parseM3DateParam normalizes a YYYYMMDD or YYYY-MM-DD query parameter to M3's YYYYMMDD format
+func parseM3DateParam(r *http.Request, name string) (string, error) {
+	value := strings.ReplaceAll(strings.TrimSpace(r.URL.Query().Get(name)), "-", "")
+	if value == "" {
+		return "", nil
+	}
+	if _, err := time.Parse("20060102", value); err != nil {
+		return "", fmt.Errorf("invalid %s: expected YYYYMMDD", name)
+	}
+	return value, nil
+}
+
+// splitListParam splits a comma-separated query parameter, dropping empty values
+func splitListParam(r *http.Request, name string) []string {
+	var values []string
+	for _, value := range strings.Split(r.URL.Query().Get(name), ",") {
+		if value = strings.TrimSpace(value); value != "" {
+			values = append(values, value)
+		}
+	}
+	return values
+}
+
+// handleListProductionOrders lists production orders (unified MO/MOP view) with filters,
+// sorting and keyset pagination
 func (s *Server) handleListProductionOrders(w http.ResponseWriter, r *http.Request) {
-	// TODO: Query production_orders table
-	// Support filtering by date range, facility, status, etc.
+	ctx := r.Context()
+
+	session, _ := s.sessionStore.Get(r, "m3-session")
+	environment, _ := session.Values["environment"].(string)
+	if environment == "" {
+		http.Error(w, "Environment not set in session", http.StatusUnauthorized)
+		return
+	}
+
+	query := r.URL.Query()
+	params := db.ListProductionOrdersParams{
+		Environment:    environment,
+		Facility:       query.Get("facility"),
+		Warehouse:      query.Get("warehouse"),
+		ItemNumber:     query.Get("item"),
+		ProductNumber:  query.Get("product"),
+		OrderType:      strings.ToUpper(query.Get("order_type")),
+		MOTypes:        splitListParam(r, "mo_type"),
+		Statuses:       splitListParam(r, "status"),
+		LinkedCONumber: query.Get("co_number"),
+		LinkedCOLine:   query.Get("co_line"),
+		IncludeDeleted: query.Get("include_deleted") == "true",
+		SortColumn:     "plannedStartDate",
+		Descending:     query.Get("order") == "desc",
+	}
+
+	if params.OrderType != "" && params.OrderType != "MO" && params.OrderType != "MOP" {
+		http.Error(w, "Invalid order_type. Must be 'MO' or 'MOP'", http.StatusBadRequest)
+		return
+	}
+
+	if sort := query.Get("sort"); sort != "" {
+		if _, ok := db.ProductionOrderSortColumns[sort]; !ok {
+			http.Error(w, fmt.Sprintf("Invalid sort: %s", sort), http.StatusBadRequest)
+			return
+		}
+		params.SortColumn = sort
+	}
+
+	var err error
+	for name, target := range map[string]*string{
+		"start_from":  &params.StartFrom,
+		"start_to":    &params.StartTo,
+		"finish_from": &params.FinishFrom,
+		"finish_to":   &params.FinishTo,
+	} {
+		if *target, err = parseM3DateParam(r, name); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+	}
+
+	pageSize := 50 // default
+	if pageSizeStr := query.Get("page_size"); pageSizeStr != "" {
+		if parsedSize, err := strconv.Atoi(pageSizeStr); err == nil {
+			// Validate page size is one of the allowed values
+			switch parsedSize {
+			case 25, 50, 100, 200:
+				pageSize = parsedSize
+			}
+		}
+	}
+
+	if cursorStr := query.Get("cursor"); cursorStr != "" {
+		cursor, err := decodeProductionOrderCursor(cursorStr)
+		if err != nil || cursor.Sort != params.SortColumn || cursor.Desc != params.Descending {
+			http.Error(w, "Invalid cursor", http.StatusBadRequest)
+			return
+		}
+		params.AfterValue = &cursor.Value
+		params.AfterID = cursor.ID
+	}
+
+	// Fetch one extra row to know whether another page follows
+	params.Limit = pageSize + 1
+	orders, err := s.db.ListProductionOrders(ctx, params)
+	if err != nil {
+		log.Printf("Failed to list production orders: %v", err)
+		http.Error(w, "Failed to fetch production orders", http.StatusInternalServerError)
+		return
+	}
+
+	pagination := CursorPaginationMeta{PageSize: pageSize}
+	if len(orders) > pageSize {
+		orders = orders[:pageSize]
+		last := orders[len(orders)-1]
+		pagination.HasMore = true
+		pagination.NextCursor = encodeProductionOrderCursor(productionOrderCursor{
+			Value: last.SortValue,
+			ID:    last.ID,
+			Sort:  params.SortColumn,
+			Desc:  params.Descending,
+		})
+	}
 
 	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode([]interface{}{})
+	json.NewEncoder(w).Encode(ProductionOrderListResponse{
+		Data:       orders,
+		Pagination: pagination,
+	})
+}
+
+// parseOrderID reads the numeric {id} path variable, writing a 400 if it is invalid
+func parseOrderID(w http.ResponseWriter, r *http.Request) (int64, bool) {
+	id, err := strconv.ParseInt(mux.Vars(r)["id"], 10, 64)
+	if err != nil {
+		http.Error(w, "Invalid order ID", http.StatusBadRequest)
+		return 0, false
+	}
+	return id, true
 }
 
-// handleGetProductionOrder gets a single production order
+// handleGetProductionOrder gets a single production order with the full MO/MOP attributes and linked CO line
 func (s *Server) handleGetProductionOrder(w http.ResponseWriter, r *http.Request) {
-	vars := mux.Vars(r)
-	id := vars["id"]
+	ctx := r.Context()
+
+	session, _ := s.sessionStore.Get(r, "m3-session")
+	environment, _ := session.Values["environment"].(string)
+	if environment == "" {
+		http.Error(w, "Environment not set in session", http.StatusUnauthorized)
+		return
+	}
+
+	id, ok := parseOrderID(w, r)
+	if !ok {
+		return
+	}
 
-	// TODO: Query production_orders table by ID
+	order, err := s.db.GetProductionOrderByID(ctx, environment, id)
+	if err != nil {
+		log.Printf("Failed to get production order %d: %v", id, err)
+		http.Error(w, "Failed to fetch production order", http.StatusInternalServerError)
+		return
+	}
+	if order == nil {
+		http.Error(w, "Production order not found", http.StatusNotFound)
+		return
+	}
+
+	var detail *db.OrderDetail
+	if order.MOID != nil {
+		detail, err = s.db.GetManufacturingOrderDetail(ctx, environment, *order.MOID)
+	} else if order.MOPID != nil {
+		detail, err = s.db.GetPlannedOrderDetail(ctx, environment, *order.MOPID)
+	}
+	if err != nil {
+		log.Printf("Failed to get details of production order %d: %v", id, err)
+		http.Error(w, "Failed to fetch production order", http.StatusInternalServerError)
+		return
+	}
+
+	response := map[string]interface{}{
+		"order":        order,
+		"attributes":   nil,
+		"linkedCoLine": nil,
+	}
+	if detail != nil {
+		response["attributes"] = detail.Attributes
+		response["linkedCoLine"] = detail.LinkedCOLine
+	}
 
 	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(map[string]string{
-		"id": id,
-	})
+	json.NewEncoder(w).Encode(response)
 }
 
 // handleGetManufacturingOrder gets full MO details
 func (s *Server) handleGetManufacturingOrder(w http.ResponseWriter, r *http.Request) {
-	vars := mux.Vars(r)
-	id := vars["id"]
-
-	// TODO: Query manufacturing_orders table with all details
-	// Include operations, materials, actuals, etc.
-
-	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(map[string]string{
-		"id": id,
-	})
+	s.writeOrderDetail(w, r, s.db.GetManufacturingOrderDetail)
 }
 
 // handleGetPlannedOrder gets full MOP details
 func (s *Server) handleGetPlannedOrder(w http.ResponseWriter, r *http.Request) {
-	vars := mux.Vars(r)
-	id := vars["id"]
+	s.writeOrderDetail(w, r, s.db.GetPlannedOrderDetail)
+}
 
-	// TODO: Query planned_manufacturing_orders table with all details
-	// Include planning parameters, demand references, etc.
+// writeOrderDetail loads an MO or MOP by ID with the given query and writes it as JSON
+func (s *Server) writeOrderDetail(w http.ResponseWriter, r *http.Request, load func(context.Context, string, int64) (*db.OrderDetail, error)) {
+	session, _ := s.sessionStore.Get(r, "m3-session")
+	environment, _ := session.Values["environment"].(string)
+	if environment == "" {
+		http.Error(w, "Environment not set in session", http.StatusUnauthorized)
+		return
+	}
+
+	id, ok := parseOrderID(w, r)
+	if !ok {
+		return
+	}
+
+	detail, err := load(r.Context(), environment, id)
+	if err != nil {
+		log.Printf("Failed to get order %d: %v", id, err)
+		http.Error(w, "Failed to fetch order", http.StatusInternalServerError)
+		return
+	}
+	if detail == nil {
+		http.Error(w, "Order not found", http.StatusNotFound)
+		return
+	}
 
 	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(map[string]string{
-		"id": id,
-	})
+	json.NewEncoder(w).Encode(detail)
 }
 
-// handleGetTimeline returns timeline view of production orders
+// maxTimelineOrders caps the orders returned by the timeline endpoint
+const maxTimelineOrders = 5000
+
+// handleGetTimeline returns the production orders whose planned start-finish span overlaps a date window
+// The window defaults to today through 90 days ahead
 func (s *Server) handleGetTimeline(w http.ResponseWriter, r *http.Request) {
-	// TODO: Generate timeline data for visualization
+	session, _ := s.sessionStore.Get(r, "m3-session")
+	environment, _ := session.Values["environment"].(string)
+	if environment == "" {
+		http.Error(w, "Environment not set in session", http.StatusUnauthorized)
+		return
+	}
+
+	startDate, err := parseM3DateParam(r, "startDate")
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	endDate, err := parseM3DateParam(r, "endDate")
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if startDate == "" {
+		startDate = time.Now().Format("20060102")
+	}
+	if endDate == "" {
+		start, _ := time.Parse("20060102", startDate)
+		endDate = start.AddDate(0, 0, 90).Format("20060102")
+	}
+	if endDate < startDate {
+		http.Error(w, "endDate must not be before startDate", http.StatusBadRequest)
+		return
+	}
+
+	orders, err := s.db.ListProductionOrders(r.Context(), db.ListProductionOrdersParams{
+		Environment: environment,
+		Facility:    r.URL.Query().Get("facility"),
+		FinishFrom:  startDate,
+		StartTo:     endDate,
+		SortColumn:  "plannedStartDate",
+		Limit:       maxTimelineOrders + 1,
+	})
+	if err != nil {
+		log.Printf("Failed to load timeline: %v", err)
+		http.Error(w, "Failed to load timeline", http.StatusInternalServerError)
+		return
+	}
+
+	truncated := len(orders) > maxTimelineOrders
+	if truncated {
+		orders = orders[:maxTimelineOrders]
+	}
 
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(map[string]interface{}{
-		"orders": []interface{}{},
+		"startDate": startDate,
+		"endDate":   endDate,
+		"orders":    orders,
+		"truncated": truncated,
 	})
 }
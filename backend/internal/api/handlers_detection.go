@@ -8,10 +8,10 @@ import (
 
 // handleListDetectors lists all available detectors with their enabled status
 func (s *Server) handleListDetectors(w http.ResponseWriter, r *http.Request) {
-	handlers.HandleListDetectors(s.db)(w, r)
+	handlers.HandleListDetectors(s.db, s.config)(w, r)
 }
 
 // handleTriggerDetection triggers specific detectors without a full refresh
 func (s *Server) handleTriggerDetection(w http.ResponseWriter, r *http.Request) {
-	handlers.HandleTriggerDetection(s.natsManager, s.db)(w, r)
+	handlers.HandleTriggerDetection(s.natsManager, s.db, s.config)(w, r)
 }
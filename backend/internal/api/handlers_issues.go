@@ -49,6 +49,7 @@ func (s *Server) handleListIssues(w http.ResponseWriter, r *http.Request) {
 	facility := r.URL.Query().Get("facility")
 	warehouse := r.URL.Query().Get("warehouse")
 	includeIgnored := r.URL.Query().Get("include_ignored") == "true"
+	newOnly := r.URL.Query().Get("new_only") == "true" // only issues new since the last refresh
 
 	// Parse pagination parameters
 	page := 1
@@ -75,7 +76,7 @@ func (s *Server) handleListIssues(w http.ResponseWriter, r *http.Request) {
 	offset := (page - 1) * pageSize
 
 	// Get total count for pagination metadata
-	totalCount, err := s.db.GetIssuesFilteredCount(ctx, environment, detectorType, facility, warehouse, includeIgnored)
+	totalCount, err := s.db.GetIssuesFilteredCount(ctx, environment, detectorType, facility, warehouse, includeIgnored, newOnly)
 	if err != nil {
 		http.Error(w, "Failed to count issues", http.StatusInternalServerError)
 		return
@@ -89,7 +90,7 @@ func (s *Server) handleListIssues(w http.ResponseWriter, r *http.Request) {
 
 	// Get filtered issues with pagination
 	var issues []*db.DetectedIssue
-	issues, err = s.db.GetIssuesFiltered(ctx, environment, detectorType, facility, warehouse, includeIgnored, pageSize, offset)
+	issues, err = s.db.GetIssuesFiltered(ctx, environment, detectorType, facility, warehouse, includeIgnored, newOnly, pageSize, offset)
 	if err != nil {
 		http.Error(w, "Failed to fetch issues", http.StatusInternalServerError)
 		return
@@ -104,12 +105,23 @@ func (s *Server) handleListIssues(w http.ResponseWriter, r *http.Request) {
 			"facility":     issue.Facility,
 			"issueKey":     issue.IssueKey,
 			"isIgnored":    issue.IsIgnored,
+			"isNew":        issue.IsNew,
 		}
 
 		if issue.DetectedAt.Valid {
 			item["detectedAt"] = issue.DetectedAt.Time
 		}
 
+		// Issue age is measured from when the issue key was first seen
+		if issue.FirstSeenAt.Valid {
+			item["firstSeenAt"] = issue.FirstSeenAt.Time
+			item["ageDays"] = int(time.Since(issue.FirstSeenAt.Time).Hours() / 24)
+		}
+
+		if issue.LastSeenAt.Valid {
+			item["lastSeenAt"] = issue.LastSeenAt.Time
+		}
+
 		if issue.Warehouse.Valid {
 			item["warehouse"] = issue.Warehouse.String
 		}
@@ -187,6 +199,126 @@ func (s *Server) handleGetIssueSummary(w http.ResponseWriter, r *http.Request) {
 	json.NewEncoder(w).Encode(summary)
 }
 
+// handleGetIssueTrends returns issue counts across completed refresh jobs for trend charts
+func (s *Server) handleGetIssueTrends(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	// Get environment from session
+	session, _ := s.sessionStore.Get(r, "m3-session")
+	environment, _ := session.Values["environment"].(string)
+	if environment == "" {
+		http.Error(w, "Environment not set in session", http.StatusUnauthorized)
+		return
+	}
+
+	// Parse query parameters
+	days := 30
+	if daysStr := r.URL.Query().Get("days"); daysStr != "" {
+		parsedDays, err := strconv.Atoi(daysStr)
+		if err != nil || parsedDays < 1 {
+			http.Error(w, "Invalid days parameter", http.StatusBadRequest)
+			return
+		}
+		days = parsedDays
+	}
+	since := time.Now().AddDate(0, 0, -days)
+
+	groupBy := r.URL.Query().Get("group_by")
+	switch groupBy {
+	case "", "detector_type", "facility", "warehouse":
+	default:
+		http.Error(w, "group_by must be detector_type, facility or warehouse", http.StatusBadRequest)
+		return
+	}
+
+	params := db.IssueTrendParams{
+		Environment:  environment,
+		Since:        since,
+		GroupBy:      groupBy,
+		DetectorType: r.URL.Query().Get("detector_type"),
+		Facility:     r.URL.Query().Get("facility"),
+		Warehouse:    r.URL.Query().Get("warehouse"),
+	}
+
+	series, err := s.db.GetIssueTrends(ctx, params)
+	if err != nil {
+		log.Printf("Failed to fetch issue trends: %v", err)
+		http.Error(w, "Failed to fetch issue trends", http.StatusInternalServerError)
+		return
+	}
+
+	jobTotals, err := s.db.GetDetectionJobTotals(ctx, environment, since)
+	if err != nil {
+		log.Printf("Failed to fetch detection job totals: %v", err)
+		http.Error(w, "Failed to fetch issue trends", http.StatusInternalServerError)
+		return
+	}
+
+	jobs := make([]map[string]interface{}, 0, len(jobTotals))
+	for _, job := range jobTotals {
+		item := map[string]interface{}{
+			"jobId":       job.JobID,
+			"completedAt": job.CompletedAt,
+			"totalIssues": job.TotalIssues,
+		}
+
+		var issuesByType map[string]int
+		if err := json.Unmarshal([]byte(job.IssuesByType), &issuesByType); err == nil {
+			item["issuesByType"] = issuesByType
+		}
+
+		jobs = append(jobs, item)
+	}
+
+	if series == nil {
+		series = []db.IssueCountPoint{}
+	}
+
+	response := map[string]interface{}{
+		"days":   days,
+		"jobs":   jobs,
+		"series": series,
+	}
+
+	// Per-issue history when a specific issue key is requested
+	if issueKey := r.URL.Query().Get("issue_key"); issueKey != "" {
+		if params.DetectorType == "" {
+			http.Error(w, "detector_type is required with issue_key", http.StatusBadRequest)
+			return
+		}
+
+		entries, err := s.db.GetIssueKeyHistory(ctx, environment, params.DetectorType, issueKey, since)
+		if err != nil {
+			log.Printf("Failed to fetch issue history: %v", err)
+			http.Error(w, "Failed to fetch issue history", http.StatusInternalServerError)
+			return
+		}
+
+		history := make([]map[string]interface{}, 0, len(entries))
+		for _, entry := range entries {
+			item := map[string]interface{}{
+				"jobId":      entry.JobID,
+				"recordedAt": entry.RecordedAt,
+				"facility":   entry.Facility,
+			}
+			if entry.Warehouse.Valid {
+				item["warehouse"] = entry.Warehouse.String
+			}
+			if entry.ProductionOrderNumber.Valid {
+				item["productionOrderNumber"] = entry.ProductionOrderNumber.String
+			}
+			if entry.ProductionOrderType.Valid {
+				item["productionOrderType"] = entry.ProductionOrderType.String
+			}
+			history = append(history, item)
+		}
+		response["issueHistory"] = history
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
+}
+
 // handleGetIssueDetail gets a specific issue with full details
 func (s *Server) handleGetIssueDetail(w http.ResponseWriter, r *http.Request) {
 	vars := mux.Vars(r)
@@ -459,6 +591,11 @@ func (s *Server) handleDeletePlannedMO(w http.ResponseWriter, r *http.Request) {
 		}
 	}
 
+	// Capture the order's before-state for the audit log
+	session, _ := s.sessionStore.Get(r, "m3-session")
+	environment, _ := session.Values["environment"].(string)
+	beforeState := s.orderBeforeState(ctx, environment, issue.ProductionOrderType.String, issue.ProductionOrderNumber.String, issue.Facility)
+
 	// Execute M3 API call
 	response, err := m3Client.Execute(ctx, "PMS170MI", "DelPlannedMO", params)
 	if err != nil {
@@ -476,14 +613,16 @@ func (s *Server) handleDeletePlannedMO(w http.ResponseWriter, r *http.Request) {
 
 	// Create audit log entry
 	err = s.auditService.Log(ctx, services.AuditParams{
-		EntityType: "issue",
-		EntityID:   fmt.Sprintf("%d", issueID),
-		Operation:  "delete_mop",
-		Facility:   issue.Facility,
+		Environment: environment,
+		EntityType:  "issue",
+		EntityID:    fmt.Sprintf("%d", issueID),
+		Operation:   "delete_mop",
+		Facility:    issue.Facility,
 		Metadata: map[string]interface{}{
 			"detector_type":           issue.DetectorType,
 			"production_order_number": issue.ProductionOrderNumber.String,
 			"production_order_type":   issue.ProductionOrderType.String,
+			"before_state":            beforeState,
 			"m3_response":             response,
 		},
 		IPAddress: getIPAddress(r),
@@ -503,6 +642,17 @@ func (s *Server) handleDeletePlannedMO(w http.ResponseWriter, r *http.Request) {
 
 // handleDeleteMO deletes a Manufacturing Order (MO) via M3 API
 func (s *Server) handleDeleteMO(w http.ResponseWriter, r *http.Request) {
+	s.handleMOAction(w, r, BulkActionDeleteMO)
+}
+
+// handleCloseMO closes a Manufacturing Order (MO) via M3 API
+func (s *Server) handleCloseMO(w http.ResponseWriter, r *http.Request) {
+	s.handleMOAction(w, r, BulkActionCloseMO)
+}
+
+// handleMOAction deletes or closes an issue's MO, unless the environment requires the
+// operation to go through an approved action request
+func (s *Server) handleMOAction(w http.ResponseWriter, r *http.Request, operation string) {
 	ctx := r.Context()
 
 	// Parse issue ID from URL
@@ -521,27 +671,23 @@ func (s *Server) handleDeleteMO(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// Verify this is an MO
-	if issue.ProductionOrderType.String != "MO" {
-		http.Error(w, "This operation is only valid for MOs", http.StatusBadRequest)
+	action, reqErr := newMOAction(operation, issue)
+	if reqErr != nil {
+		http.Error(w, reqErr.message, reqErr.status)
 		return
 	}
 
-	// Parse issue data JSON
-	var issueData map[string]interface{}
-	if err := json.Unmarshal([]byte(issue.IssueData), &issueData); err != nil {
-		http.Error(w, "Failed to parse issue data", http.StatusInternalServerError)
+	actor := s.newIssueActionActor(r)
+	if s.permissionService.ApprovalRequired(actor.environment, operation) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusConflict)
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"error":            fmt.Sprintf("%s requires approval in %s: submit an action request", moActionLabels[operation], actor.environment),
+			"approvalRequired": true,
+		})
 		return
 	}
 
-	// Verify status is <= 22
-	if statusStr, ok := issueData["status"].(string); ok {
-		if status, err := strconv.Atoi(statusStr); err == nil && status > 22 {
-			http.Error(w, "MO status is too advanced for deletion. Use Close instead.", http.StatusBadRequest)
-			return
-		}
-	}
-
 	// Get M3 API client
 	m3Client, err := s.getM3APIClient(r)
 	if err != nil {
@@ -549,52 +695,12 @@ func (s *Server) handleDeleteMO(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// Call M3 API to delete the MO
-	params := map[string]string{
-		"MFNO": issue.ProductionOrderNumber.String,
-	}
-
-	// Add company if available from issue data
-	if companyStr, ok := issueData["company"].(string); ok {
-		params["CONO"] = companyStr
-	}
-
-	// Execute M3 API call
-	response, err := m3Client.Execute(ctx, "PMS100MI", "DltMO", params)
+	response, err := s.executeMOAction(ctx, m3Client, action, actor, nil)
 	if err != nil {
-		log.Printf("Failed to delete MO %s: %v", issue.ProductionOrderNumber.String, err)
-		http.Error(w, fmt.Sprintf("Failed to delete MO: %v", err), http.StatusInternalServerError)
+		http.Error(w, fmt.Sprintf("Failed to %s: %v", strings.ToLower(moActionLabels[operation]), err), http.StatusInternalServerError)
 		return
 	}
 
-	// Mark the MO as deleted in our database
-	err = s.db.MarkMOAsDeletedRemotely(ctx, issue.ProductionOrderNumber.String, issue.Facility)
-	if err != nil {
-		log.Printf("Failed to mark MO as deleted: %v", err)
-		// Continue anyway - M3 delete succeeded
-	}
-
-	// Create audit log entry
-	err = s.auditService.Log(ctx, services.AuditParams{
-		EntityType: "issue",
-		EntityID:   fmt.Sprintf("%d", issueID),
-		Operation:  "delete_mo",
-		Facility:   issue.Facility,
-		Metadata: map[string]interface{}{
-			"detector_type":           issue.DetectorType,
-			"production_order_number": issue.ProductionOrderNumber.String,
-			"production_order_type":   issue.ProductionOrderType.String,
-			"status":                  issueData["status"],
-			"m3_response":             response,
-		},
-		IPAddress: getIPAddress(r),
-		UserAgent: r.Header.Get("User-Agent"),
-	})
-	if err != nil {
-		// Log error but don't fail the request
-		log.Printf("Failed to create audit log: %v", err)
-	}
-
 	// Return success with M3 response
 	w.WriteHeader(http.StatusOK)
 	json.NewEncoder(w).Encode(map[string]interface{}{
@@ -603,102 +709,139 @@ func (s *Server) handleDeleteMO(w http.ResponseWriter, r *http.Request) {
 	})
 }
 
-// handleCloseMO closes a Manufacturing Order (MO) via M3 API
-func (s *Server) handleCloseMO(w http.ResponseWriter, r *http.Request) {
-	ctx := r.Context()
-
-	// Parse issue ID from URL
-	vars := mux.Vars(r)
-	idStr := vars["id"]
-	issueID, err := strconv.ParseInt(idStr, 10, 64)
-	if err != nil {
-		http.Error(w, "Invalid issue ID", http.StatusBadRequest)
-		return
-	}
+// moActionLabels names the MO operations in messages
+var moActionLabels = map[string]string{
+	BulkActionDeleteMO: "Delete MO",
+	BulkActionCloseMO:  "Close MO",
+}
 
-	// Get issue details
-	issue, err := s.db.GetIssueByID(ctx, issueID)
-	if err != nil {
-		http.Error(w, "Issue not found", http.StatusNotFound)
-		return
-	}
+// moAction is a delete or close of the MO an issue refers to
+type moAction struct {
+	operation    string // BulkActionDeleteMO or BulkActionCloseMO
+	issueID      int64
+	detectorType string
+	company      string
+	facility     string
+	orderNumber  string
+	status       interface{} // MO status from the issue data
+}
 
+// newMOAction checks an issue's MO can be deleted (status <= 22) or closed (status > 22)
+func newMOAction(operation string, issue *db.DetectedIssue) (*moAction, *requestError) {
 	// Verify this is an MO
 	if issue.ProductionOrderType.String != "MO" {
-		http.Error(w, "This operation is only valid for MOs", http.StatusBadRequest)
-		return
+		return nil, &requestError{status: http.StatusBadRequest, message: "This operation is only valid for MOs"}
 	}
 
 	// Parse issue data JSON
 	var issueData map[string]interface{}
 	if err := json.Unmarshal([]byte(issue.IssueData), &issueData); err != nil {
-		http.Error(w, "Failed to parse issue data", http.StatusInternalServerError)
-		return
+		return nil, &requestError{status: http.StatusInternalServerError, message: "Failed to parse issue data"}
 	}
 
-	// Verify status is > 22
 	if statusStr, ok := issueData["status"].(string); ok {
-		if status, err := strconv.Atoi(statusStr); err == nil && status <= 22 {
-			http.Error(w, "MO status allows deletion. Use Delete instead.", http.StatusBadRequest)
-			return
+		if reqErr := checkMOStatus(operation, statusStr); reqErr != nil {
+			return nil, reqErr
 		}
 	}
 
-	// Get M3 API client
-	m3Client, err := s.getM3APIClient(r)
+	action := &moAction{
+		operation:    operation,
+		issueID:      issue.ID,
+		detectorType: issue.DetectorType,
+		facility:     issue.Facility,
+		orderNumber:  issue.ProductionOrderNumber.String,
+		status:       issueData["status"],
+	}
+	// Add company if available from issue data
+	if companyStr, ok := issueData["company"].(string); ok {
+		action.company = companyStr
+	}
+	return action, nil
+}
+
+// checkMOStatus verifies an MO status (WHST) allows deletion (<= 22) or closing (> 22)
+func checkMOStatus(operation, statusStr string) *requestError {
+	status, err := strconv.Atoi(statusStr)
 	if err != nil {
-		http.Error(w, "Failed to get M3 API client", http.StatusInternalServerError)
-		return
+		return nil
 	}
+	if operation == BulkActionDeleteMO && status > 22 {
+		return &requestError{status: http.StatusBadRequest, message: "MO status is too advanced for deletion. Use Close instead."}
+	}
+	if operation == BulkActionCloseMO && status <= 22 {
+		return &requestError{status: http.StatusBadRequest, message: "MO status allows deletion. Use Delete instead."}
+	}
+	return nil
+}
 
-	// Call M3 API to close the MO
-	params := map[string]string{
-		"MFNO": issue.ProductionOrderNumber.String,
-		"FACI": issue.Facility,
+// executeMOAction runs the M3 transaction of an MO action, marks the MO as deleted and writes
+// the issue audit entry; metadata is added to the audit entry
+func (s *Server) executeMOAction(ctx context.Context, m3Client *m3api.Client, action *moAction, actor issueActionActor, metadata map[string]interface{}) (*m3api.M3Response, error) {
+	var transaction string
+	var params map[string]string
+	switch action.operation {
+	case BulkActionDeleteMO:
+		transaction = "DltMO"
+		params = map[string]string{"MFNO": action.orderNumber}
+		if action.company != "" {
+			params["CONO"] = action.company
+		}
+	case BulkActionCloseMO:
+		transaction = "CloseMO"
+		params = map[string]string{"MFNO": action.orderNumber, "FACI": action.facility}
+	default:
+		return nil, fmt.Errorf("unsupported MO action: %s", action.operation)
 	}
 
+	// Capture the order's before-state for the audit log
+	beforeState := s.orderBeforeState(ctx, actor.environment, "MO", action.orderNumber, action.facility)
+
 	// Execute M3 API call
-	response, err := m3Client.Execute(ctx, "PMS100MI", "CloseMO", params)
+	response, err := m3Client.Execute(ctx, "PMS100MI", transaction, params)
 	if err != nil {
-		log.Printf("Failed to close MO %s: %v", issue.ProductionOrderNumber.String, err)
-		http.Error(w, fmt.Sprintf("Failed to close MO: %v", err), http.StatusInternalServerError)
-		return
+		log.Printf("Failed to %s %s: %v", strings.ToLower(moActionLabels[action.operation]), action.orderNumber, err)
+		return nil, err
 	}
 
-	// Mark the MO as deleted (closed) in our database
-	err = s.db.MarkMOAsDeletedRemotely(ctx, issue.ProductionOrderNumber.String, issue.Facility)
-	if err != nil {
-		log.Printf("Failed to mark MO as closed: %v", err)
-		// Continue anyway - M3 close succeeded
+	// Mark the MO as deleted (or closed) in our database
+	if err := s.db.MarkMOAsDeletedRemotely(ctx, action.orderNumber, action.facility); err != nil {
+		log.Printf("Failed to mark MO as deleted: %v", err)
+		// Continue anyway - M3 call succeeded
+	}
+
+	auditMetadata := map[string]interface{}{
+		"detector_type":           action.detectorType,
+		"production_order_number": action.orderNumber,
+		"production_order_type":   "MO",
+		"status":                  action.status,
+		"before_state":            beforeState,
+		"m3_response":             response,
+	}
+	for key, value := range metadata {
+		auditMetadata[key] = value
 	}
 
 	// Create audit log entry
 	err = s.auditService.Log(ctx, services.AuditParams{
-		EntityType: "issue",
-		EntityID:   fmt.Sprintf("%d", issueID),
-		Operation:  "close_mo",
-		Facility:   issue.Facility,
-		Metadata: map[string]interface{}{
-			"detector_type":           issue.DetectorType,
-			"production_order_number": issue.ProductionOrderNumber.String,
-			"production_order_type":   issue.ProductionOrderType.String,
-			"status":                  issueData["status"],
-			"m3_response":             response,
-		},
-		IPAddress: getIPAddress(r),
-		UserAgent: r.Header.Get("User-Agent"),
+		Environment: actor.environment,
+		EntityType:  "issue",
+		EntityID:    fmt.Sprintf("%d", action.issueID),
+		Operation:   action.operation,
+		UserID:      actor.userID,
+		UserName:    actor.userName,
+		Company:     action.company,
+		Facility:    action.facility,
+		Metadata:    auditMetadata,
+		IPAddress:   actor.ipAddress,
+		UserAgent:   actor.userAgent,
 	})
 	if err != nil {
 		// Log error but don't fail the request
 		log.Printf("Failed to create audit log: %v", err)
 	}
 
-	// Return success with M3 response
-	w.WriteHeader(http.StatusOK)
-	json.NewEncoder(w).Encode(map[string]interface{}{
-		"success":     true,
-		"m3_response": response,
-	})
+	return response, nil
 }
 
 // getCurrentDateYYYYMMDD returns current date in YYYYMMDD format
@@ -792,11 +935,16 @@ func (s *Server) handleAlignEarliestMOs(w http.ResponseWriter, r *http.Request)
 		return
 	}
 
+	// Get environment from session
+	session, _ := s.sessionStore.Get(r, "m3-session")
+	environment, _ := session.Values["environment"].(string)
+
 	// Process each order
 	alignedCount := 0
 	skippedCount := 0
 	failedCount := 0
 	failures := []map[string]string{}
+	changes := []orderDateChange{}
 
 	for _, orderInterface := range orders {
 		order, ok := orderInterface.(map[string]interface{})
@@ -816,13 +964,15 @@ func (s *Server) handleAlignEarliestMOs(w http.ResponseWriter, r *http.Request)
 		}
 
 		var alignErr error
+		var applied map[string]string
+		beforeState := s.orderBeforeState(ctx, environment, orderType, orderNumber, issue.Facility)
 
 		if orderType == "MO" {
 			// Reschedule MO to alignment date (may be adjusted from min_date if past)
-			alignErr = s.rescheduleMO(ctx, m3Client, orderNumber, issue.Facility, alignmentDateStr)
+			applied, alignErr = s.rescheduleMO(ctx, m3Client, orderNumber, issue.Facility, alignmentDateStr)
 		} else if orderType == "MOP" {
 			// Update MOP dates (maintaining duration)
-			alignErr = s.updateMOPDates(ctx, m3Client, orderNumber, currentDate, alignmentDateStr)
+			applied, alignErr = s.updateMOPDates(ctx, m3Client, orderNumber, currentDate, alignmentDateStr)
 		} else {
 			alignErr = fmt.Errorf("unknown order type: %s", orderType)
 		}
@@ -837,6 +987,7 @@ func (s *Server) handleAlignEarliestMOs(w http.ResponseWriter, r *http.Request)
 			log.Printf("Failed to align %s %s: %v", orderType, orderNumber, alignErr)
 		} else {
 			alignedCount++
+			changes = append(changes, newOrderDateChange(orderType, orderNumber, issue.Facility, applied, beforeState))
 			log.Printf("Successfully aligned %s %s to %s", orderType, orderNumber, alignmentDateStr)
 		}
 	}
@@ -845,10 +996,6 @@ func (s *Server) handleAlignEarliestMOs(w http.ResponseWriter, r *http.Request)
 	if alignedCount > 0 {
 		jdcd, _ := issueData["jdcd"].(string)
 
-		// Get environment from session
-		session, _ := s.sessionStore.Get(r, "m3-session")
-		environment, _ := session.Values["environment"].(string)
-
 		err = s.auditService.Log(ctx, services.AuditParams{
 			Environment: environment,
 			EntityType:  "jdcd_group",
@@ -864,6 +1011,7 @@ func (s *Server) handleAlignEarliestMOs(w http.ResponseWriter, r *http.Request)
 				"original_min_date": minDate,
 				"co_number":         issue.CONumber.String,
 				"jdcd":              jdcd,
+				"changes":           changes,
 			},
 			IPAddress: getIPAddress(r),
 			UserAgent: r.Header.Get("User-Agent"),
@@ -954,11 +1102,16 @@ func (s *Server) handleAlignLatestMOs(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	// Get environment from session
+	session, _ := s.sessionStore.Get(r, "m3-session")
+	environment, _ := session.Values["environment"].(string)
+
 	// Process each order
 	alignedCount := 0
 	skippedCount := 0
 	failedCount := 0
 	failures := []map[string]string{}
+	changes := []orderDateChange{}
 
 	for _, orderInterface := range orders {
 		order, ok := orderInterface.(map[string]interface{})
@@ -978,13 +1131,15 @@ func (s *Server) handleAlignLatestMOs(w http.ResponseWriter, r *http.Request) {
 		}
 
 		var alignErr error
+		var applied map[string]string
+		beforeState := s.orderBeforeState(ctx, environment, orderType, orderNumber, issue.Facility)
 
 		if orderType == "MO" {
 			// Reschedule MO to alignment date (may be adjusted from max_date if past)
-			alignErr = s.rescheduleMO(ctx, m3Client, orderNumber, issue.Facility, alignmentDateStr)
+			applied, alignErr = s.rescheduleMO(ctx, m3Client, orderNumber, issue.Facility, alignmentDateStr)
 		} else if orderType == "MOP" {
 			// Update MOP dates (maintaining duration)
-			alignErr = s.updateMOPDates(ctx, m3Client, orderNumber, currentDate, alignmentDateStr)
+			applied, alignErr = s.updateMOPDates(ctx, m3Client, orderNumber, currentDate, alignmentDateStr)
 		} else {
 			alignErr = fmt.Errorf("unknown order type: %s", orderType)
 		}
@@ -999,6 +1154,7 @@ func (s *Server) handleAlignLatestMOs(w http.ResponseWriter, r *http.Request) {
 			log.Printf("Failed to align %s %s: %v", orderType, orderNumber, alignErr)
 		} else {
 			alignedCount++
+			changes = append(changes, newOrderDateChange(orderType, orderNumber, issue.Facility, applied, beforeState))
 			log.Printf("Successfully aligned %s %s to %s", orderType, orderNumber, alignmentDateStr)
 		}
 	}
@@ -1007,10 +1163,6 @@ func (s *Server) handleAlignLatestMOs(w http.ResponseWriter, r *http.Request) {
 	if alignedCount > 0 {
 		jdcd, _ := issueData["jdcd"].(string)
 
-		// Get environment from session
-		session, _ := s.sessionStore.Get(r, "m3-session")
-		environment, _ := session.Values["environment"].(string)
-
 		err = s.auditService.Log(ctx, services.AuditParams{
 			Environment: environment,
 			EntityType:  "jdcd_group",
@@ -1026,6 +1178,7 @@ func (s *Server) handleAlignLatestMOs(w http.ResponseWriter, r *http.Request) {
 				"original_max_date": int(maxDate),
 				"co_number":         issue.CONumber.String,
 				"jdcd":              jdcd,
+				"changes":           changes,
 			},
 			IPAddress: getIPAddress(r),
 			UserAgent: r.Header.Get("User-Agent"),
@@ -1055,8 +1208,30 @@ func (s *Server) handleAlignLatestMOs(w http.ResponseWriter, r *http.Request) {
 	json.NewEncoder(w).Encode(response)
 }
 
-// rescheduleMO reschedules a manufacturing order to a new start date
-func (s *Server) rescheduleMO(ctx context.Context, m3Client *m3api.Client, mfno, facility, newStartDate string) error {
+// rescheduleMO reschedules a manufacturing order to a new start date and returns the parameters sent to M3
+func (s *Server) rescheduleMO(ctx context.Context, m3Client *m3api.Client, mfno, facility, newStartDate string) (map[string]string, error) {
+	params, err := s.buildRescheduleMOParams(ctx, mfno, facility, newStartDate)
+	if err != nil {
+		return nil, err
+	}
+
+	log.Printf("Rescheduling MO %s to %s (FACI: %s, PRNO: %s, ORQA: %s)", mfno, newStartDate, facility, params["PRNO"], params["ORQA"])
+
+	response, err := m3Client.Execute(ctx, "PMS100MI", "Reschedule", params)
+	if err != nil {
+		return nil, fmt.Errorf("M3 API error: %w", err)
+	}
+
+	// Log successful reschedule
+	if response != nil {
+		log.Printf("Successfully rescheduled MO %s to %s", mfno, newStartDate)
+	}
+
+	return params, nil
+}
+
+// buildRescheduleMOParams builds the PMS100MI/Reschedule parameters for moving an MO to a new start date
+func (s *Server) buildRescheduleMOParams(ctx context.Context, mfno, facility, newStartDate string) (map[string]string, error) {
 	// Get MO details from production_orders view
 	moQuery := `
 		SELECT prno, ordered_quantity
@@ -1067,11 +1242,10 @@ func (s *Server) rescheduleMO(ctx context.Context, m3Client *m3api.Client, mfno,
 	var prno, orqa string
 	err := s.db.DB().QueryRowContext(ctx, moQuery, mfno, facility).Scan(&prno, &orqa)
 	if err != nil {
-		return fmt.Errorf("failed to get MO details: %w", err)
+		return nil, fmt.Errorf("failed to get MO details: %w", err)
 	}
 
-	// Call M3 API to reschedule
-	params := map[string]string{
+	return map[string]string{
 		"FACI": facility,
 		"PRNO": prno,
 		"MFNO": mfno,
@@ -1082,28 +1256,46 @@ func (s *Server) rescheduleMO(ctx context.Context, m3Client *m3api.Client, mfno,
 		"DSP2": "1",           // Auto-approve: MO connected to order
 		"DSP3": "1",           // Auto-approve: order contains subcontract
 		"DSP4": "1",           // Auto-approve: quantity not divisible
-	}
+	}, nil
+}
 
-	log.Printf("Rescheduling MO %s to %s (FACI: %s, PRNO: %s, ORQA: %s)", mfno, newStartDate, facility, prno, orqa)
+// updateMOPDates updates a MOP's start and finish dates (maintaining production duration)
+// and returns the parameters sent to M3
+func (s *Server) updateMOPDates(ctx context.Context, m3Client *m3api.Client, plpnStr, currentStartDate, newStartDate string) (map[string]string, error) {
+	params, err := s.buildUpdateMOPDatesParams(ctx, plpnStr, currentStartDate, newStartDate)
+	if err != nil {
+		return nil, err
+	}
 
-	response, err := m3Client.Execute(ctx, "PMS100MI", "Reschedule", params)
+	response, err := m3Client.Execute(ctx, "PMS170MI", "Updat", params)
 	if err != nil {
-		return fmt.Errorf("M3 API error: %w", err)
+		return nil, fmt.Errorf("M3 API error: %w", err)
 	}
 
-	// Log successful reschedule
+	// Log successful update
 	if response != nil {
-		log.Printf("Successfully rescheduled MO %s to %s", mfno, newStartDate)
+		log.Printf("Successfully updated MOP %s finish date to %s", plpnStr, params["FIDT"])
 	}
 
-	return nil
+	return params, nil
 }
 
-// updateMOPDates updates a MOP's start and finish dates (maintaining production duration)
-func (s *Server) updateMOPDates(ctx context.Context, m3Client *m3api.Client, plpnStr, currentStartDate, newStartDate string) error {
+// orderBeforeState captures an order's snapshot state for the audit log, nil if it cannot be read
+func (s *Server) orderBeforeState(ctx context.Context, environment, orderType, orderNumber, facility string) *db.OrderState {
+	state, err := s.db.GetOrderState(ctx, environment, orderType, orderNumber, facility)
+	if err != nil {
+		log.Printf("Failed to capture before-state of %s %s: %v", orderType, orderNumber, err)
+		return nil
+	}
+	return state
+}
+
+// buildUpdateMOPDatesParams builds the PMS170MI/Updat parameters that move a MOP's finish date
+// so its start lands on newStartDate while keeping the production duration
+func (s *Server) buildUpdateMOPDatesParams(ctx context.Context, plpnStr, currentStartDate, newStartDate string) (map[string]string, error) {
 	plpn, err := strconv.ParseInt(plpnStr, 10, 64)
 	if err != nil {
-		return fmt.Errorf("invalid PLPN format: %w", err)
+		return nil, fmt.Errorf("invalid PLPN format: %w", err)
 	}
 
 	// Get MOP details for current finish date
@@ -1116,7 +1308,7 @@ func (s *Server) updateMOPDates(ctx context.Context, m3Client *m3api.Client, plp
 	var currentStart, currentFinish string
 	err = s.db.DB().QueryRowContext(ctx, mopQuery, plpn).Scan(&currentStart, &currentFinish)
 	if err != nil {
-		return fmt.Errorf("failed to get MOP details: %w", err)
+		return nil, fmt.Errorf("failed to get MOP details: %w", err)
 	}
 
 	// Validate dates
@@ -1124,21 +1316,21 @@ func (s *Server) updateMOPDates(ctx context.Context, m3Client *m3api.Client, plp
 		currentStart = currentStartDate // Fall back to issue data
 	}
 	if currentFinish == "" || currentFinish == "0" {
-		return fmt.Errorf("MOP %d has no finish date, cannot calculate new finish", plpn)
+		return nil, fmt.Errorf("MOP %d has no finish date, cannot calculate new finish", plpn)
 	}
 
 	// Calculate new finish date based on desired start date and maintaining production duration
 	startInt, err := strconv.Atoi(currentStart)
 	if err != nil {
-		return fmt.Errorf("invalid current start date: %w", err)
+		return nil, fmt.Errorf("invalid current start date: %w", err)
 	}
 	finishInt, err := strconv.Atoi(currentFinish)
 	if err != nil {
-		return fmt.Errorf("invalid current finish date: %w", err)
+		return nil, fmt.Errorf("invalid current finish date: %w", err)
 	}
 	newStartInt, err := strconv.Atoi(newStartDate)
 	if err != nil {
-		return fmt.Errorf("invalid new start date: %w", err)
+		return nil, fmt.Errorf("invalid new start date: %w", err)
 	}
 
 	duration := finishInt - startInt
@@ -1153,24 +1345,12 @@ func (s *Server) updateMOPDates(ctx context.Context, m3Client *m3api.Client, plp
 	log.Printf("Updating MOP %d: finish date %s → %s (maintaining %d day duration for target start %s)",
 		plpn, currentFinish, newFinishDate, duration, newStartDate)
 
-	// Call M3 API to update MOP - NOTE: MOPs can only update finish date, not start date
-	params := map[string]string{
+	// NOTE: MOPs can only update finish date, not start date
+	return map[string]string{
 		"PLPN": plpnStr,
 		"FIDT": newFinishDate, // Only update finish date (calculated to align with desired start)
 		"IGWA": "1",           // Ignore warnings
-	}
-
-	response, err := m3Client.Execute(ctx, "PMS170MI", "Updat", params)
-	if err != nil {
-		return fmt.Errorf("M3 API error: %w", err)
-	}
-
-	// Log successful update
-	if response != nil {
-		log.Printf("Successfully updated MOP %d finish date to %s", plpn, newFinishDate)
-	}
-
-	return nil
+	}, nil
 }
 
 
@@ -7,6 +7,7 @@ import (
 	"net/http"
 
 	"github.com/gorilla/mux"
+	"github.com/pinggolf/m3-planning-tools/internal/compass"
 )
 
 // CustomerOrderLineResponse represents a CO line returned from CFIN lookup
@@ -45,6 +46,13 @@ func (s *Server) handleLookupCFIN(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	// The CFIN comes from the URL, so it must be a valid configuration number before it goes into the query
+	cfinLiteral, err := compass.Literal(compass.FieldCFIN, cfin)
+	if err != nil {
+		http.Error(w, "Invalid CFIN: must be a configuration number of up to 10 digits", http.StatusBadRequest)
+		return
+	}
+
 	// Get Compass client with user's session credentials
 	compassClient, err := s.getCompassClient(r)
 	if err != nil {
@@ -70,11 +78,11 @@ func (s *Server) handleLookupCFIN(w http.ResponseWriter, r *http.Request) {
 			oline.PLDT as planned_date,
 			oline.CUNO as customer_number
 		FROM "default".OOLINE oline
-		WHERE oline.CFIN = '%s'
+		WHERE oline.CFIN = %s
 		  AND oline.deleted = 'false'
 		ORDER BY oline.ORNO, oline.PONR, oline.POSX
 		LIMIT 100
-	`, cfin)
+	`, cfinLiteral)
 
 	// Execute query with pagination (page size 100 means single page for LIMIT 100)
 	jsonData, _, err := compassClient.ExecuteQueryWithPagination(r.Context(), query, 100, nil)
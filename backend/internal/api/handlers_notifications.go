@@ -0,0 +1,248 @@
+package api
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gorilla/mux"
+	"github.com/pinggolf/m3-planning-tools/internal/db"
+	"github.com/pinggolf/m3-planning-tools/internal/services"
+)
+
+// NotificationSubscriptionRequest is the body for creating or updating a subscription
+type NotificationSubscriptionRequest struct {
+	Channel      string `json:"channel"`      // email, webhook, teams, slack
+	Target       string `json:"target"`       // Email address or webhook URL
+	DetectorType string `json:"detectorType"` // Empty = all detectors
+	Facility     string `json:"facility"`     // Empty = all facilities
+	MinSeverity  string `json:"minSeverity"`  // Defaults to critical
+	Delivery     string `json:"delivery"`     // immediate (default) or digest
+	Enabled      *bool  `json:"enabled"`      // Defaults to true
+}
+
+// NotificationSubscriptionResponse is a subscription as returned by the API
+type NotificationSubscriptionResponse struct {
+	ID           int64  `json:"id"`
+	Channel      string `json:"channel"`
+	Target       string `json:"target"`
+	DetectorType string `json:"detectorType"`
+	Facility     string `json:"facility"`
+	MinSeverity  string `json:"minSeverity"`
+	Delivery     string `json:"delivery"`
+	Enabled      bool   `json:"enabled"`
+	CreatedAt    string `json:"createdAt"`
+	UpdatedAt    string `json:"updatedAt"`
+}
+
+func toNotificationSubscriptionResponse(sub *db.NotificationSubscription) NotificationSubscriptionResponse {
+	return NotificationSubscriptionResponse{
+		ID:           sub.ID,
+		Channel:      sub.Channel,
+		Target:       sub.Target,
+		DetectorType: sub.DetectorType.String,
+		Facility:     sub.Facility.String,
+		MinSeverity:  sub.MinSeverity,
+		Delivery:     sub.Delivery,
+		Enabled:      sub.Enabled,
+		CreatedAt:    sub.CreatedAt.Format("2006-01-02T15:04:05Z07:00"),
+		UpdatedAt:    sub.UpdatedAt.Format("2006-01-02T15:04:05Z07:00"),
+	}
+}
+
+// notificationSessionUser returns the environment and user ID for subscription handlers
+func (s *Server) notificationSessionUser(w http.ResponseWriter, r *http.Request) (string, string, bool) {
+	session, _ := s.sessionStore.Get(r, "m3-session")
+	environment, _ := session.Values["environment"].(string)
+	if environment == "" {
+		http.Error(w, "Environment not set in session", http.StatusUnauthorized)
+		return "", "", false
+	}
+
+	userID, err := s.getUserIDFromSession(r)
+	if err != nil {
+		http.Error(w, "Failed to get user ID", http.StatusUnauthorized)
+		return "", "", false
+	}
+
+	return environment, userID, true
+}
+
+// parseNotificationSubscriptionRequest decodes and validates a subscription body
+func (s *Server) parseNotificationSubscriptionRequest(w http.ResponseWriter, r *http.Request) (db.NotificationSubscriptionParams, bool) {
+	var req NotificationSubscriptionRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return db.NotificationSubscriptionParams{}, false
+	}
+
+	params := db.NotificationSubscriptionParams{
+		Channel:      strings.ToLower(strings.TrimSpace(req.Channel)),
+		Target:       strings.TrimSpace(req.Target),
+		DetectorType: strings.TrimSpace(req.DetectorType),
+		Facility:     strings.TrimSpace(req.Facility),
+		MinSeverity:  strings.ToLower(req.MinSeverity),
+		Delivery:     strings.ToLower(req.Delivery),
+		Enabled:      req.Enabled == nil || *req.Enabled,
+	}
+	if params.MinSeverity == "" {
+		params.MinSeverity = "critical"
+	}
+	if params.Delivery == "" {
+		params.Delivery = services.NotificationDeliveryImmediate
+	}
+
+	if err := s.notificationService.ValidateSubscription(params); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return db.NotificationSubscriptionParams{}, false
+	}
+
+	return params, true
+}
+
+// handleListNotificationSubscriptions lists the current user's subscriptions
+func (s *Server) handleListNotificationSubscriptions(w http.ResponseWriter, r *http.Request) {
+	environment, userID, ok := s.notificationSessionUser(w, r)
+	if !ok {
+		return
+	}
+
+	subscriptions, err := s.db.ListNotificationSubscriptions(r.Context(), environment, userID)
+	if err != nil {
+		log.Printf("Failed to list notification subscriptions: %v", err)
+		http.Error(w, "Failed to list subscriptions", http.StatusInternalServerError)
+		return
+	}
+
+	response := make([]NotificationSubscriptionResponse, 0, len(subscriptions))
+	for _, sub := range subscriptions {
+		response = append(response, toNotificationSubscriptionResponse(sub))
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
+}
+
+// handleCreateNotificationSubscription creates a subscription for the current user
+func (s *Server) handleCreateNotificationSubscription(w http.ResponseWriter, r *http.Request) {
+	environment, userID, ok := s.notificationSessionUser(w, r)
+	if !ok {
+		return
+	}
+
+	params, ok := s.parseNotificationSubscriptionRequest(w, r)
+	if !ok {
+		return
+	}
+
+	sub, err := s.db.CreateNotificationSubscription(r.Context(), environment, userID, params)
+	if err != nil {
+		log.Printf("Failed to create notification subscription: %v", err)
+		http.Error(w, "Failed to create subscription", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(toNotificationSubscriptionResponse(sub))
+}
+
+// handleUpdateNotificationSubscription replaces one of the current user's subscriptions
+func (s *Server) handleUpdateNotificationSubscription(w http.ResponseWriter, r *http.Request) {
+	environment, userID, ok := s.notificationSessionUser(w, r)
+	if !ok {
+		return
+	}
+
+	id, err := strconv.ParseInt(mux.Vars(r)["id"], 10, 64)
+	if err != nil {
+		http.Error(w, "Invalid subscription ID", http.StatusBadRequest)
+		return
+	}
+
+	params, ok := s.parseNotificationSubscriptionRequest(w, r)
+	if !ok {
+		return
+	}
+
+	sub, err := s.db.UpdateNotificationSubscription(r.Context(), id, environment, userID, params)
+	if err != nil {
+		log.Printf("Failed to update notification subscription %d: %v", id, err)
+		http.Error(w, "Failed to update subscription", http.StatusInternalServerError)
+		return
+	}
+	if sub == nil {
+		http.Error(w, "Subscription not found", http.StatusNotFound)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(toNotificationSubscriptionResponse(sub))
+}
+
+// handleDeleteNotificationSubscription deletes one of the current user's subscriptions
+func (s *Server) handleDeleteNotificationSubscription(w http.ResponseWriter, r *http.Request) {
+	environment, userID, ok := s.notificationSessionUser(w, r)
+	if !ok {
+		return
+	}
+
+	id, err := strconv.ParseInt(mux.Vars(r)["id"], 10, 64)
+	if err != nil {
+		http.Error(w, "Invalid subscription ID", http.StatusBadRequest)
+		return
+	}
+
+	deleted, err := s.db.DeleteNotificationSubscription(r.Context(), id, environment, userID)
+	if err != nil {
+		log.Printf("Failed to delete notification subscription %d: %v", id, err)
+		http.Error(w, "Failed to delete subscription", http.StatusInternalServerError)
+		return
+	}
+	if !deleted {
+		http.Error(w, "Subscription not found", http.StatusNotFound)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// handleTestNotificationSubscription sends a sample notification to a subscription's target
+func (s *Server) handleTestNotificationSubscription(w http.ResponseWriter, r *http.Request) {
+	environment, userID, ok := s.notificationSessionUser(w, r)
+	if !ok {
+		return
+	}
+
+	id, err := strconv.ParseInt(mux.Vars(r)["id"], 10, 64)
+	if err != nil {
+		http.Error(w, "Invalid subscription ID", http.StatusBadRequest)
+		return
+	}
+
+	sub, err := s.db.GetNotificationSubscription(r.Context(), id)
+	if err != nil {
+		http.Error(w, "Failed to load subscription", http.StatusInternalServerError)
+		return
+	}
+	if sub == nil || sub.Environment != environment || sub.UserID != userID {
+		http.Error(w, "Subscription not found", http.StatusNotFound)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := s.notificationService.SendTest(r.Context(), sub); err != nil {
+		w.WriteHeader(http.StatusBadGateway)
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"success": false,
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"success": true,
+	})
+}
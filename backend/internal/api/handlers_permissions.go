@@ -0,0 +1,20 @@
+package api
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+)
+
+// handleGetPermissions returns the user's effective permissions per facility so the UI can hide actions
+func (s *Server) handleGetPermissions(w http.ResponseWriter, r *http.Request) {
+	perms, err := s.getEffectivePermissions(r)
+	if err != nil {
+		log.Printf("Failed to resolve permissions: %v", err)
+		http.Error(w, "Failed to resolve permissions", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(perms)
+}
@@ -8,6 +8,7 @@ import (
 	"net/http"
 
 	"github.com/pinggolf/m3-planning-tools/internal/db"
+	"github.com/pinggolf/m3-planning-tools/internal/services"
 )
 
 // UserSettingsResponse represents the API response for user settings
@@ -210,6 +211,30 @@ func (s *Server) handleUpdateSystemSettings(w http.ResponseWriter, r *http.Reque
 		return
 	}
 
+	// Reject invalid authorization settings; at runtime they would silently fall back to defaults
+	if raw, ok := req.Settings["role_mappings"]; ok {
+		if _, err := services.ParseRoleMappings(raw); err != nil {
+			http.Error(w, fmt.Sprintf("Invalid role_mappings: %v", err), http.StatusBadRequest)
+			return
+		}
+	}
+	if role, ok := req.Settings["default_role"]; ok && !services.IsValidRole(role) {
+		http.Error(w, fmt.Sprintf("Invalid default_role: %s", role), http.StatusBadRequest)
+		return
+	}
+	if raw, ok := req.Settings["approval_required_operations"]; ok {
+		if _, err := services.ParseApprovalRequiredOperations(raw); err != nil {
+			http.Error(w, fmt.Sprintf("Invalid approval_required_operations: %v", err), http.StatusBadRequest)
+			return
+		}
+	}
+	if raw, ok := req.Settings["issue_notification_severity"]; ok {
+		if _, err := services.ParseIssueNotificationSeverity(raw); err != nil {
+			http.Error(w, fmt.Sprintf("Invalid issue_notification_severity: %v", err), http.StatusBadRequest)
+			return
+		}
+	}
+
 	// Update settings
 	if err := s.settingsService.UpdateSystemSettings(r.Context(), environment, req.Settings, userID); err != nil {
 		http.Error(w, "Failed to update system settings", http.StatusInternalServerError)
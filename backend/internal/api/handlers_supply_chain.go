@@ -0,0 +1,82 @@
+package api
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+	"strings"
+
+	"github.com/gorilla/mux"
+	"github.com/pinggolf/m3-planning-tools/internal/services"
+)
+
+// handleGetSupplyChain returns the multi-level pegging tree of a supply chain
+// GET /api/supply-chain/{scnb}
+func (s *Server) handleGetSupplyChain(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	session, _ := s.sessionStore.Get(r, "m3-session")
+	environment, _ := session.Values["environment"].(string)
+	if environment == "" {
+		http.Error(w, "Environment not set in session", http.StatusUnauthorized)
+		return
+	}
+
+	scnb := mux.Vars(r)["scnb"]
+
+	tree, err := s.supplyChainService.GetTree(ctx, environment, scnb)
+	if err != nil {
+		log.Printf("Failed to build supply chain %s: %v", scnb, err)
+		http.Error(w, "Failed to fetch supply chain", http.StatusInternalServerError)
+		return
+	}
+	if tree == nil {
+		http.Error(w, "Supply chain not found", http.StatusNotFound)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(tree)
+}
+
+// handleGetSupplyChainsForOrder returns the pegging trees of every supply chain an order is part of
+// GET /api/supply-chain/orders/{orderType}/{orderNumber}?line=
+// orderType is CO, DO, PO, MO or MOP; line optionally narrows a CO, DO or PO to one line
+func (s *Server) handleGetSupplyChainsForOrder(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	session, _ := s.sessionStore.Get(r, "m3-session")
+	environment, _ := session.Values["environment"].(string)
+	if environment == "" {
+		http.Error(w, "Environment not set in session", http.StatusUnauthorized)
+		return
+	}
+
+	vars := mux.Vars(r)
+	orderType := strings.ToUpper(vars["orderType"])
+	orderNumber := vars["orderNumber"]
+	line := r.URL.Query().Get("line")
+
+	if len(services.SupplyChainOrderCategories(orderType)) == 0 {
+		http.Error(w, "Invalid order type - use CO, DO, PO, MO or MOP", http.StatusBadRequest)
+		return
+	}
+	if orderType == services.SupplyChainNodeMO || orderType == services.SupplyChainNodeMOP {
+		line = ""
+	}
+
+	trees, err := s.supplyChainService.GetTreesForOrder(ctx, environment, orderType, orderNumber, line)
+	if err != nil {
+		log.Printf("Failed to build supply chains of %s %s: %v", orderType, orderNumber, err)
+		http.Error(w, "Failed to fetch supply chains", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"orderType":    orderType,
+		"orderNumber":  orderNumber,
+		"line":         line,
+		"supplyChains": trees,
+	})
+}
@@ -4,6 +4,10 @@ import (
 	"fmt"
 	"log"
 	"net/http"
+	"strconv"
+
+	"github.com/gorilla/mux"
+	"github.com/pinggolf/m3-planning-tools/internal/services"
 )
 
 // adminMiddleware checks if the user has system administrator role
@@ -41,3 +45,103 @@ func (s *Server) adminMiddleware(next http.Handler) http.Handler {
 		next.ServeHTTP(w, r)
 	})
 }
+
+// requestError is returned by facility resolvers to choose the HTTP status of a failed lookup
+type requestError struct {
+	status  int
+	message string
+}
+
+func (e *requestError) Error() string {
+	return e.message
+}
+
+// facilityResolver returns the facility a request acts on, for facility-scoped permission checks
+type facilityResolver func(r *http.Request) (string, error)
+
+// requirePermission allows a request only if the user's role in the facility it acts on grants permission
+func (s *Server) requirePermission(permission services.Permission, resolveFacility facilityResolver) mux.MiddlewareFunc {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			perms, err := s.getEffectivePermissions(r)
+			if err != nil {
+				log.Printf("ERROR: Failed to resolve permissions for %s: %v", r.URL.Path, err)
+				http.Error(w, "Unauthorized", http.StatusUnauthorized)
+				return
+			}
+
+			facility, err := resolveFacility(r)
+			if err != nil {
+				status := http.StatusInternalServerError
+				if reqErr, ok := err.(*requestError); ok {
+					status = reqErr.status
+				}
+				http.Error(w, err.Error(), status)
+				return
+			}
+
+			if !perms.Can(permission, facility) {
+				log.Printf("WARN: User %s (%s) denied %s in facility %s", perms.UserID, perms.RoleFor(facility), permission, facility)
+				http.Error(w, fmt.Sprintf("Forbidden: %s permission required in facility %s", permission, facility), http.StatusForbidden)
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// getEffectivePermissions resolves the session user's permissions in the session environment
+func (s *Server) getEffectivePermissions(r *http.Request) (*services.EffectivePermissions, error) {
+	session, _ := s.sessionStore.Get(r, "m3-session")
+	environment, _ := session.Values["environment"].(string)
+	if environment == "" {
+		return nil, fmt.Errorf("no environment in session")
+	}
+
+	userID, err := s.getUserIDFromSession(r)
+	if err != nil {
+		return nil, err
+	}
+
+	profile := s.loadUserProfile(r, userID)
+	if profile == nil {
+		return nil, fmt.Errorf("user profile unavailable for %s", userID)
+	}
+
+	return s.permissionService.ResolvePermissions(environment, profile), nil
+}
+
+// issueFacility resolves the facility of the issue in the {id} route variable
+func (s *Server) issueFacility(r *http.Request) (string, error) {
+	issueID, err := strconv.ParseInt(mux.Vars(r)["id"], 10, 64)
+	if err != nil {
+		return "", &requestError{status: http.StatusBadRequest, message: "Invalid issue ID"}
+	}
+
+	issue, err := s.db.GetIssueByID(r.Context(), issueID)
+	if err != nil {
+		return "", &requestError{status: http.StatusNotFound, message: "Issue not found"}
+	}
+	return issue.Facility, nil
+}
+
+// auditLogFacility resolves the facility of the audit log entry in the {id} route variable
+func (s *Server) auditLogFacility(r *http.Request) (string, error) {
+	session, _ := s.sessionStore.Get(r, "m3-session")
+	environment, _ := session.Values["environment"].(string)
+
+	id, err := strconv.ParseInt(mux.Vars(r)["id"], 10, 64)
+	if err != nil {
+		return "", &requestError{status: http.StatusBadRequest, message: "Invalid audit log ID"}
+	}
+
+	entry, err := s.db.GetAuditLogByID(r.Context(), environment, id)
+	if err != nil {
+		return "", fmt.Errorf("failed to load audit log: %w", err)
+	}
+	if entry == nil {
+		return "", &requestError{status: http.StatusNotFound, message: "Audit log not found"}
+	}
+	return entry.Facility.String, nil
+}
@@ -26,16 +26,20 @@ type Server struct {
 	router                *mux.Router
 	sessionStore          sessions.Store
 	authManager           *auth.Manager
+	credentialBroker      *auth.CredentialBroker
 	natsManager           *queue.Manager
 	contextService        *services.ContextService
 	auditService          *services.AuditService
 	userProfileService    *services.UserProfileService
 	settingsService       *services.SettingsService
 	detectorConfigService *services.DetectorConfigService
+	notificationService   *services.NotificationService
+	supplyChainService    *services.SupplyChainService
+	permissionService     *services.PermissionService
 }
 
 // NewServer creates a new API server instance
-func NewServer(cfg *config.Config, queries *db.Queries, natsManager *queue.Manager, database *sql.DB) *Server {
+func NewServer(cfg *config.Config, queries *db.Queries, natsManager *queue.Manager, database *sql.DB, credentialBroker *auth.CredentialBroker) *Server {
 	// Initialize session store (cookie-based for auth tokens only)
 	// User profiles stored in Postgres to avoid cookie size limits and enable scaling
 	sessionStore := sessions.NewCookieStore([]byte(cfg.SessionSecret))
@@ -78,12 +82,16 @@ func NewServer(cfg *config.Config, queries *db.Queries, natsManager *queue.Manag
 		router:                mux.NewRouter(),
 		sessionStore:          sessionStore,
 		authManager:           authManager,
+		credentialBroker:      credentialBroker,
 		natsManager:           natsManager,
 		contextService:        contextService,
 		auditService:          auditService,
 		userProfileService:    userProfileService,
 		settingsService:       settingsService,
 		detectorConfigService: detectorConfigService,
+		notificationService:   services.NewNotificationService(queries, cfg),
+		supplyChainService:    services.NewSupplyChainService(queries),
+		permissionService:     services.NewPermissionService(queries),
 	}
 
 	s.setupRoutes()
@@ -205,6 +213,7 @@ func (s *Server) setupRoutes() {
 
 	// Auth routes
 	authRouter := api.PathPrefix("/auth").Subrouter()
+	authRouter.HandleFunc("/environments", s.handleListEnvironments).Methods("GET")
 	authRouter.HandleFunc("/login", s.handleLogin).Methods("POST")
 	authRouter.HandleFunc("/callback", s.handleAuthCallback).Methods("GET")
 	authRouter.HandleFunc("/logout", s.handleLogout).Methods("POST")
@@ -271,14 +280,32 @@ func (s *Server) setupRoutes() {
 	// Data lookup endpoints
 	protected.HandleFunc("/lookup/cfin/{cfin}", s.handleLookupCFIN).Methods("GET")
 
+	// Supply chain (MPREAL pegging) endpoints
+	protected.HandleFunc("/supply-chain/orders/{orderType}/{orderNumber}", s.handleGetSupplyChainsForOrder).Methods("GET")
+	protected.HandleFunc("/supply-chain/{scnb}", s.handleGetSupplyChain).Methods("GET")
+
 	// Issue detection endpoints
 	protected.HandleFunc("/issues", s.handleListIssues).Methods("GET")
 	protected.HandleFunc("/issues/summary", s.handleGetIssueSummary).Methods("GET")
+	protected.HandleFunc("/issues/trends", s.handleGetIssueTrends).Methods("GET")
+	protected.HandleFunc("/issues/bulk", s.handleBulkIssueAction).Methods("POST")
+	protected.HandleFunc("/issues/bulk/{jobId}", s.handleGetBulkIssueAction).Methods("GET")
+	protected.HandleFunc("/issues/bulk/{jobId}/progress", s.handleBulkIssueActionProgressSSE).Methods("GET")
 	protected.HandleFunc("/issues/{id}", s.handleGetIssueDetail).Methods("GET")
-	protected.HandleFunc("/issues/{id}/ignore", s.handleIgnoreIssue).Methods("POST")
-	protected.HandleFunc("/issues/{id}/unignore", s.handleUnignoreIssue).Methods("POST")
-	protected.HandleFunc("/issues/{id}/delete-mop", s.handleDeletePlannedMO).Methods("POST")
-	protected.HandleFunc("/issues/{id}/delete-mo", s.handleDeleteMO).Methods("POST")
+
+	// Issue write actions require a role granting the permission in the issue's facility
+	// (bulk actions check each selected issue's facility when planning)
+	protected.Handle("/issues/{id}/ignore", s.requirePermission(services.PermissionIgnoreIssue, s.issueFacility)(http.HandlerFunc(s.handleIgnoreIssue))).Methods("POST")
+	protected.Handle("/issues/{id}/unignore", s.requirePermission(services.PermissionIgnoreIssue, s.issueFacility)(http.HandlerFunc(s.handleUnignoreIssue))).Methods("POST")
+	protected.Handle("/issues/{id}/delete-mop", s.requirePermission(services.PermissionDeleteMOP, s.issueFacility)(http.HandlerFunc(s.handleDeletePlannedMO))).Methods("POST")
+	protected.Handle("/issues/{id}/delete-mo", s.requirePermission(services.PermissionDeleteMO, s.issueFacility)(http.HandlerFunc(s.handleDeleteMO))).Methods("POST")
+	protected.Handle("/issues/{id}/close-mo", s.requirePermission(services.PermissionCloseMO, s.issueFacility)(http.HandlerFunc(s.handleCloseMO))).Methods("POST")
+	protected.Handle("/issues/{id}/action-requests", s.requirePermission(services.PermissionRequestAction, s.issueFacility)(http.HandlerFunc(s.handleCreateActionRequest))).Methods("POST")
+	protected.Handle("/issues/{id}/align-earliest", s.requirePermission(services.PermissionAlignOrders, s.issueFacility)(http.HandlerFunc(s.handleAlignEarliestMOs))).Methods("POST")
+	protected.Handle("/issues/{id}/align-latest", s.requirePermission(services.PermissionAlignOrders, s.issueFacility)(http.HandlerFunc(s.handleAlignLatestMOs))).Methods("POST")
+
+	// Effective permissions of the current user (lets the UI hide actions)
+	protected.HandleFunc("/permissions", s.handleGetPermissions).Methods("GET")
 
 	// Anomaly detection endpoints
 	protected.HandleFunc("/anomalies", s.handleListAnomalies).Methods("GET")
@@ -286,12 +313,24 @@ func (s *Server) setupRoutes() {
 	protected.HandleFunc("/anomalies/count", s.handleGetAnomalyCount).Methods("GET")
 	protected.HandleFunc("/anomalies/{id}/acknowledge", s.handleAcknowledgeAnomaly).Methods("POST")
 	protected.HandleFunc("/anomalies/{id}/resolve", s.handleResolveAnomaly).Methods("POST")
-	protected.HandleFunc("/issues/{id}/close-mo", s.handleCloseMO).Methods("POST")
-	protected.HandleFunc("/issues/{id}/align-earliest", s.handleAlignEarliestMOs).Methods("POST")
-	protected.HandleFunc("/issues/{id}/align-latest", s.handleAlignLatestMOs).Methods("POST")
 
 	// Audit log endpoints
 	protected.HandleFunc("/audit-logs", s.handleListAuditLogs).Methods("GET")
+	protected.Handle("/audit-logs/{id}/revert", s.requirePermission(services.PermissionRevertAuditLog, s.auditLogFacility)(http.HandlerFunc(s.handleRevertAuditLog))).Methods("POST")
+
+	// Action requests (two-person approval); approve/reject check the operation's permission per request
+	protected.HandleFunc("/action-requests", s.handleListActionRequests).Methods("GET")
+	protected.HandleFunc("/action-requests/{id}", s.handleGetActionRequest).Methods("GET")
+	protected.HandleFunc("/action-requests/{id}/approve", s.handleApproveActionRequest).Methods("POST")
+	protected.HandleFunc("/action-requests/{id}/reject", s.handleRejectActionRequest).Methods("POST")
+	protected.HandleFunc("/action-requests/{id}/cancel", s.handleCancelActionRequest).Methods("POST")
+
+	// Notification subscription routes (per user)
+	protected.HandleFunc("/notifications/subscriptions", s.handleListNotificationSubscriptions).Methods("GET")
+	protected.HandleFunc("/notifications/subscriptions", s.handleCreateNotificationSubscription).Methods("POST")
+	protected.HandleFunc("/notifications/subscriptions/{id}", s.handleUpdateNotificationSubscription).Methods("PUT")
+	protected.HandleFunc("/notifications/subscriptions/{id}", s.handleDeleteNotificationSubscription).Methods("DELETE")
+	protected.HandleFunc("/notifications/subscriptions/{id}/test", s.handleTestNotificationSubscription).Methods("POST")
 
 	// Settings routes (user settings - authenticated users only)
 	protected.HandleFunc("/settings/user", s.handleGetUserSettings).Methods("GET")
@@ -0,0 +1,339 @@
+package auth
+
+import (
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/pinggolf/m3-planning-tools/internal/config"
+	"github.com/pinggolf/m3-planning-tools/internal/db"
+	"golang.org/x/oauth2"
+)
+
+// Credential reference timing
+const (
+	credentialReferenceTTL  = 6 * time.Hour   // Longest a refresh job can keep exchanging its reference
+	credentialStatusRecheck = 1 * time.Minute // How long a worker trusts a cached token before re-checking the job
+)
+
+// CredentialBroker issues job-scoped credential references and exchanges them for M3 tokens
+// NATS messages only carry the opaque reference; tokens stay on the server, encrypted at rest.
+// User references keep the user's refresh token server side, so a job outlives the access token
+// it started with; service account references fetch client credentials tokens on demand
+type CredentialBroker struct {
+	db              *db.Queries
+	oauth           map[string]*oauth2.Config // OAuth config per environment key, for user token refreshes
+	serviceAccounts *ServiceAccountTokenManager
+	refreshBuffer   time.Duration
+	gcm             cipher.AEAD
+
+	cache      map[string]*cachedCredential // Decrypted tokens per reference hash
+	cacheMutex sync.Mutex
+}
+
+// cachedCredential is a worker-side copy of an exchanged token
+type cachedCredential struct {
+	accessToken string
+	expiry      time.Time
+	checkedAt   time.Time
+}
+
+// NewCredentialBroker creates a credential broker
+// Tokens are encrypted with a key derived from SESSION_SECRET, so every server and worker
+// process sharing the database and secret can exchange references
+func NewCredentialBroker(cfg *config.Config, database *db.Queries, serviceAccounts *ServiceAccountTokenManager) (*CredentialBroker, error) {
+	key := sha256.Sum256([]byte("job-credentials:" + cfg.SessionSecret))
+	block, err := aes.NewCipher(key[:])
+	if err != nil {
+		return nil, fmt.Errorf("failed to create credential cipher: %w", err)
+	}
+	gcm, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create credential cipher: %w", err)
+	}
+
+	return &CredentialBroker{
+		db:              database,
+		oauth:           newOAuthConfigs(cfg),
+		serviceAccounts: serviceAccounts,
+		refreshBuffer:   cfg.TokenRefreshBuffer,
+		gcm:             gcm,
+		cache:           make(map[string]*cachedCredential),
+	}, nil
+}
+
+// IssueForUser issues a reference backed by the tokens of the user who started the job
+func (b *CredentialBroker) IssueForUser(ctx context.Context, jobID, environment, userID string, token *oauth2.Token) (string, error) {
+	if _, ok := b.oauth[environment]; !ok {
+		return "", fmt.Errorf("invalid environment: %s", environment)
+	}
+	if token == nil || token.AccessToken == "" {
+		return "", fmt.Errorf("no access token available")
+	}
+
+	accessToken, err := b.encrypt(token.AccessToken)
+	if err != nil {
+		return "", err
+	}
+	cred := &db.JobCredential{
+		JobID:       jobID,
+		Environment: environment,
+		UserID:      sql.NullString{String: userID, Valid: userID != ""},
+		Source:      db.JobCredentialSourceUser,
+		AccessToken: sql.NullString{String: accessToken, Valid: true},
+		TokenExpiry: sql.NullTime{Time: token.Expiry, Valid: !token.Expiry.IsZero()},
+	}
+	if token.RefreshToken != "" {
+		refreshToken, err := b.encrypt(token.RefreshToken)
+		if err != nil {
+			return "", err
+		}
+		cred.RefreshToken = sql.NullString{String: refreshToken, Valid: true}
+	}
+
+	return b.issue(ctx, cred)
+}
+
+// IssueForServiceAccount issues a reference backed by the environment's service account
+// A token is fetched up front so a misconfigured service account fails before the job is queued
+func (b *CredentialBroker) IssueForServiceAccount(ctx context.Context, jobID, environment, userID string) (string, error) {
+	if _, err := b.serviceAccounts.GetToken(environment); err != nil {
+		return "", fmt.Errorf("failed to get service account token: %w", err)
+	}
+
+	return b.issue(ctx, &db.JobCredential{
+		JobID:       jobID,
+		Environment: environment,
+		UserID:      sql.NullString{String: userID, Valid: userID != ""},
+		Source:      db.JobCredentialSourceServiceAccount,
+	})
+}
+
+// issue stores a credential under a new random reference and returns the reference
+func (b *CredentialBroker) issue(ctx context.Context, cred *db.JobCredential) (string, error) {
+	raw := make([]byte, 32)
+	if _, err := rand.Read(raw); err != nil {
+		return "", fmt.Errorf("failed to generate credential reference: %w", err)
+	}
+	reference := base64.RawURLEncoding.EncodeToString(raw)
+
+	cred.ReferenceHash = hashReference(reference)
+	cred.ExpiresAt = time.Now().Add(credentialReferenceTTL)
+	if err := b.db.CreateJobCredential(ctx, cred); err != nil {
+		return "", err
+	}
+
+	// Opportunistically drop references of finished or expired jobs
+	if deleted, err := b.db.DeleteExpiredJobCredentials(ctx); err != nil {
+		log.Printf("Failed to clean up job credentials: %v", err)
+	} else if deleted > 0 {
+		log.Printf("Removed %d expired job credential(s)", deleted)
+	}
+
+	return reference, nil
+}
+
+// TokenSource returns a token function for a job's Compass or M3 API client
+func (b *CredentialBroker) TokenSource(reference, jobID string) func() (string, error) {
+	return func() (string, error) {
+		ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+		defer cancel()
+		return b.Token(ctx, reference, jobID)
+	}
+}
+
+// Token exchanges a reference for a valid access token
+// The reference must belong to jobID, be unexpired and its job must still be pending or running.
+// User tokens are refreshed with the stored refresh token once they are within the refresh buffer
+func (b *CredentialBroker) Token(ctx context.Context, reference, jobID string) (string, error) {
+	if reference == "" {
+		return "", fmt.Errorf("no credential reference for job %s", jobID)
+	}
+	referenceHash := hashReference(reference)
+
+	if token, ok := b.cachedToken(referenceHash); ok {
+		return token, nil
+	}
+
+	cred, err := b.db.GetJobCredential(ctx, referenceHash)
+	if err != nil {
+		return "", err
+	}
+	if err := validateJobCredential(cred, jobID); err != nil {
+		b.forget(referenceHash)
+		return "", err
+	}
+
+	if cred.Source == db.JobCredentialSourceServiceAccount {
+		// The service account manager caches and renews its own token
+		return b.serviceAccounts.GetToken(cred.Environment)
+	}
+
+	if b.fresh(cred) {
+		return b.remember(referenceHash, cred)
+	}
+
+	cred, err = b.db.RefreshJobCredential(ctx, referenceHash, func(locked *db.JobCredential) (*db.JobCredentialTokens, error) {
+		// Another worker of the job may have refreshed while this one waited for the lock
+		if b.fresh(locked) {
+			return nil, nil
+		}
+		return b.refreshUserToken(ctx, locked)
+	})
+	if err != nil {
+		return "", err
+	}
+	return b.remember(referenceHash, cred)
+}
+
+// refreshUserToken redeems a credential's refresh token and returns the encrypted new tokens
+func (b *CredentialBroker) refreshUserToken(ctx context.Context, cred *db.JobCredential) (*db.JobCredentialTokens, error) {
+	if !cred.RefreshToken.Valid {
+		return nil, fmt.Errorf("job credential has expired and no refresh token is available")
+	}
+	refreshToken, err := b.decrypt(cred.RefreshToken.String)
+	if err != nil {
+		return nil, err
+	}
+	oauthConfig, ok := b.oauth[cred.Environment]
+	if !ok {
+		return nil, fmt.Errorf("invalid environment: %s", cred.Environment)
+	}
+
+	newToken, err := oauthConfig.TokenSource(ctx, &oauth2.Token{RefreshToken: refreshToken}).Token()
+	if err != nil {
+		return nil, fmt.Errorf("failed to refresh job token: %w", err)
+	}
+	// Keep the current refresh token unless the provider rotated it
+	if newToken.RefreshToken != "" {
+		refreshToken = newToken.RefreshToken
+	}
+
+	tokens := &db.JobCredentialTokens{TokenExpiry: newToken.Expiry}
+	if tokens.AccessToken, err = b.encrypt(newToken.AccessToken); err != nil {
+		return nil, err
+	}
+	if tokens.RefreshToken, err = b.encrypt(refreshToken); err != nil {
+		return nil, err
+	}
+
+	log.Printf("Refreshed job token for %s (job %s, expires: %v)", cred.Environment, cred.JobID, newToken.Expiry)
+	return tokens, nil
+}
+
+// validateJobCredential checks a reference may still be exchanged for jobID
+func validateJobCredential(cred *db.JobCredential, jobID string) error {
+	if cred == nil {
+		return fmt.Errorf("unknown credential reference")
+	}
+	if cred.JobID != jobID {
+		return fmt.Errorf("credential reference does not belong to job %s", jobID)
+	}
+	if time.Now().After(cred.ExpiresAt) {
+		return fmt.Errorf("credential reference for job %s has expired", jobID)
+	}
+	if cred.JobStatus != "pending" && cred.JobStatus != "running" {
+		return fmt.Errorf("job %s is %s, its credential reference is no longer valid", jobID, cred.JobStatus)
+	}
+	return nil
+}
+
+// fresh reports whether a user credential's access token is valid beyond the refresh buffer
+func (b *CredentialBroker) fresh(cred *db.JobCredential) bool {
+	if !cred.AccessToken.Valid {
+		return false
+	}
+	// Tokens without an expiry never need refreshing
+	return !cred.TokenExpiry.Valid || time.Until(cred.TokenExpiry.Time) > b.refreshBuffer
+}
+
+// cachedToken returns a cached access token that is valid and recently checked against its job
+func (b *CredentialBroker) cachedToken(referenceHash string) (string, bool) {
+	b.cacheMutex.Lock()
+	defer b.cacheMutex.Unlock()
+
+	cached, ok := b.cache[referenceHash]
+	if !ok {
+		return "", false
+	}
+	if time.Since(cached.checkedAt) > credentialStatusRecheck ||
+		(!cached.expiry.IsZero() && time.Until(cached.expiry) <= b.refreshBuffer) {
+		delete(b.cache, referenceHash)
+		return "", false
+	}
+	return cached.accessToken, true
+}
+
+// remember decrypts a user credential's access token and caches it
+func (b *CredentialBroker) remember(referenceHash string, cred *db.JobCredential) (string, error) {
+	accessToken, err := b.decrypt(cred.AccessToken.String)
+	if err != nil {
+		return "", err
+	}
+
+	cached := &cachedCredential{accessToken: accessToken, checkedAt: time.Now()}
+	if cred.TokenExpiry.Valid {
+		cached.expiry = cred.TokenExpiry.Time
+	}
+
+	b.cacheMutex.Lock()
+	defer b.cacheMutex.Unlock()
+	// Drop stale entries so finished jobs don't accumulate
+	for hash, entry := range b.cache {
+		if time.Since(entry.checkedAt) > credentialStatusRecheck {
+			delete(b.cache, hash)
+		}
+	}
+	b.cache[referenceHash] = cached
+
+	return accessToken, nil
+}
+
+// forget removes a reference from the cache
+func (b *CredentialBroker) forget(referenceHash string) {
+	b.cacheMutex.Lock()
+	defer b.cacheMutex.Unlock()
+	delete(b.cache, referenceHash)
+}
+
+// encrypt seals a token as base64(nonce || ciphertext)
+func (b *CredentialBroker) encrypt(plaintext string) (string, error) {
+	nonce := make([]byte, b.gcm.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return "", fmt.Errorf("failed to encrypt token: %w", err)
+	}
+	sealed := b.gcm.Seal(nonce, nonce, []byte(plaintext), nil)
+	return base64.StdEncoding.EncodeToString(sealed), nil
+}
+
+// decrypt opens a token sealed by encrypt
+func (b *CredentialBroker) decrypt(encoded string) (string, error) {
+	sealed, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil {
+		return "", fmt.Errorf("failed to decrypt token: %w", err)
+	}
+	nonceSize := b.gcm.NonceSize()
+	if len(sealed) < nonceSize {
+		return "", fmt.Errorf("failed to decrypt token: ciphertext too short")
+	}
+	plaintext, err := b.gcm.Open(nil, sealed[:nonceSize], sealed[nonceSize:], nil)
+	if err != nil {
+		return "", fmt.Errorf("failed to decrypt token: %w", err)
+	}
+	return string(plaintext), nil
+}
+
+// hashReference returns the stored form of a reference
+func hashReference(reference string) string {
+	sum := sha256.Sum256([]byte(reference))
+	return hex.EncodeToString(sum[:])
+}
@@ -14,44 +14,36 @@ import (
 type Manager struct {
 	config       *config.Config
 	sessionStore sessions.Store
-	trnOAuth     *oauth2.Config
-	prdOAuth     *oauth2.Config
+	oauth        map[string]*oauth2.Config // OAuth config per environment key
 }
 
 // NewManager creates a new auth manager
 func NewManager(cfg *config.Config, store sessions.Store) *Manager {
-	// Configure OAuth for TRN environment
-	trnOAuth := &oauth2.Config{
-		ClientID:     cfg.TRNClientID,
-		ClientSecret: cfg.TRNClientSecret,
-		Endpoint: oauth2.Endpoint{
-			AuthURL:  cfg.TRNAuthEndpoint,
-			TokenURL: cfg.TRNTokenEndpoint,
-		},
-		RedirectURL: cfg.OAuthRedirectURI,
-		Scopes:      []string{"openid", "profile"},
-	}
-
-	// Configure OAuth for PRD environment
-	prdOAuth := &oauth2.Config{
-		ClientID:     cfg.PRDClientID,
-		ClientSecret: cfg.PRDClientSecret,
-		Endpoint: oauth2.Endpoint{
-			AuthURL:  cfg.PRDAuthEndpoint,
-			TokenURL: cfg.PRDTokenEndpoint,
-		},
-		RedirectURL: cfg.OAuthRedirectURI,
-		Scopes:      []string{"openid", "profile"},
-	}
-
 	return &Manager{
 		config:       cfg,
 		sessionStore: store,
-		trnOAuth:     trnOAuth,
-		prdOAuth:     prdOAuth,
+		oauth:        newOAuthConfigs(cfg),
 	}
 }
 
+// newOAuthConfigs configures the authorization code flow for every configured environment
+func newOAuthConfigs(cfg *config.Config) map[string]*oauth2.Config {
+	oauth := make(map[string]*oauth2.Config, len(cfg.Environments))
+	for _, env := range cfg.Environments {
+		oauth[env.Key] = &oauth2.Config{
+			ClientID:     env.ClientID,
+			ClientSecret: env.ClientSecret,
+			Endpoint: oauth2.Endpoint{
+				AuthURL:  env.AuthEndpoint,
+				TokenURL: env.TokenEndpoint,
+			},
+			RedirectURL: cfg.OAuthRedirectURI,
+			Scopes:      []string{"openid", "profile"},
+		}
+	}
+	return oauth
+}
+
 // GetAuthorizationURL generates the OAuth authorization URL for the specified environment
 func (m *Manager) GetAuthorizationURL(environment string) (string, error) {
 	oauthConfig, err := m.getOAuthConfig(environment)
@@ -154,16 +146,29 @@ func (m *Manager) GetAccessToken(session *sessions.Session) (string, error) {
 	return token, nil
 }
 
+// GetSessionToken returns the OAuth tokens held in the session
+// Used to hand a user's tokens to the credential broker when the user starts a background job
+func (m *Manager) GetSessionToken(session *sessions.Session) (*oauth2.Token, error) {
+	accessToken, err := m.GetAccessToken(session)
+	if err != nil {
+		return nil, err
+	}
+
+	token := &oauth2.Token{AccessToken: accessToken}
+	token.RefreshToken, _ = session.Values["refresh_token"].(string)
+	if expiryUnix, ok := session.Values["token_expiry"].(int64); ok {
+		token.Expiry = time.Unix(expiryUnix, 0)
+	}
+	return token, nil
+}
+
 // getOAuthConfig returns the OAuth config for the specified environment
 func (m *Manager) getOAuthConfig(environment string) (*oauth2.Config, error) {
-	switch environment {
-	case "TRN":
-		return m.trnOAuth, nil
-	case "PRD":
-		return m.prdOAuth, nil
-	default:
+	oauthConfig, ok := m.oauth[environment]
+	if !ok {
 		return nil, fmt.Errorf("invalid environment: %s", environment)
 	}
+	return oauthConfig, nil
 }
 
 // generateRandomState generates a random state string for CSRF protection
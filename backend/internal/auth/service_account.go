@@ -14,93 +14,54 @@ import (
 // ServiceAccountTokenManager manages OAuth tokens for service accounts (background workers)
 // Uses client credentials flow instead of authorization code flow
 type ServiceAccountTokenManager struct {
-	trnConfig *clientcredentials.Config
-	prdConfig *clientcredentials.Config
-	trnToken  *oauth2.Token
-	prdToken  *oauth2.Token
-	trnMutex  sync.RWMutex
-	prdMutex  sync.RWMutex
-	config    *config.Config
+	accounts map[string]*serviceAccount // Per environment key
+	config   *config.Config
+}
+
+// serviceAccount holds the client credentials and cached token of one environment
+type serviceAccount struct {
+	config *clientcredentials.Config
+	token  *oauth2.Token
+	mutex  sync.RWMutex
 }
 
 // NewServiceAccountTokenManager creates a new service account token manager
 func NewServiceAccountTokenManager(cfg *config.Config) *ServiceAccountTokenManager {
-	// Configure client credentials for TRN environment
-	trnConfig := &clientcredentials.Config{
-		ClientID:     cfg.TRNClientID,
-		ClientSecret: cfg.TRNClientSecret,
-		TokenURL:     cfg.TRNTokenEndpoint,
-		Scopes:       []string{}, // Client credentials typically don't need scopes
-	}
-
-	// Configure client credentials for PRD environment
-	prdConfig := &clientcredentials.Config{
-		ClientID:     cfg.PRDClientID,
-		ClientSecret: cfg.PRDClientSecret,
-		TokenURL:     cfg.PRDTokenEndpoint,
-		Scopes:       []string{}, // Client credentials typically don't need scopes
+	// Configure client credentials for every configured environment
+	accounts := make(map[string]*serviceAccount, len(cfg.Environments))
+	for _, env := range cfg.Environments {
+		accounts[env.Key] = &serviceAccount{
+			config: &clientcredentials.Config{
+				ClientID:     env.ClientID,
+				ClientSecret: env.ClientSecret,
+				TokenURL:     env.TokenEndpoint,
+				Scopes:       []string{}, // Client credentials typically don't need scopes
+			},
+		}
 	}
 
 	return &ServiceAccountTokenManager{
-		trnConfig: trnConfig,
-		prdConfig: prdConfig,
-		config:    cfg,
+		accounts: accounts,
+		config:   cfg,
 	}
 }
 
+// Environments returns the environment keys the manager holds credentials for, in registry order
+func (m *ServiceAccountTokenManager) Environments() []string {
+	return m.config.EnvironmentKeys()
+}
+
 // GetToken returns a valid access token for the specified environment
 // Refreshes the token automatically if expired
 func (m *ServiceAccountTokenManager) GetToken(environment string) (string, error) {
-	switch environment {
-	case "TRN":
-		return m.getTRNToken()
-	case "PRD":
-		return m.getPRDToken()
-	default:
+	account, ok := m.accounts[environment]
+	if !ok {
 		return "", fmt.Errorf("invalid environment: %s", environment)
 	}
-}
-
-// getTRNToken gets or refreshes the TRN environment token
-func (m *ServiceAccountTokenManager) getTRNToken() (string, error) {
-	m.trnMutex.RLock()
-	token := m.trnToken
-	m.trnMutex.RUnlock()
-
-	// Check if token is valid
-	if token != nil && token.Valid() {
-		return token.AccessToken, nil
-	}
-
-	// Token is expired or doesn't exist, acquire lock to refresh
-	m.trnMutex.Lock()
-	defer m.trnMutex.Unlock()
-
-	// Double-check after acquiring write lock (another goroutine may have refreshed)
-	if m.trnToken != nil && m.trnToken.Valid() {
-		return m.trnToken.AccessToken, nil
-	}
-
-	// Fetch new token
-	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
-	defer cancel()
-
-	newToken, err := m.trnConfig.Token(ctx)
-	if err != nil {
-		return "", fmt.Errorf("failed to get TRN token: %w", err)
-	}
-
-	m.trnToken = newToken
-	fmt.Printf("Service account token obtained for TRN (expires: %v)\n", newToken.Expiry)
-
-	return newToken.AccessToken, nil
-}
 
-// getPRDToken gets or refreshes the PRD environment token
-func (m *ServiceAccountTokenManager) getPRDToken() (string, error) {
-	m.prdMutex.RLock()
-	token := m.prdToken
-	m.prdMutex.RUnlock()
+	account.mutex.RLock()
+	token := account.token
+	account.mutex.RUnlock()
 
 	// Check if token is valid
 	if token != nil && token.Valid() {
@@ -108,25 +69,25 @@ func (m *ServiceAccountTokenManager) getPRDToken() (string, error) {
 	}
 
 	// Token is expired or doesn't exist, acquire lock to refresh
-	m.prdMutex.Lock()
-	defer m.prdMutex.Unlock()
+	account.mutex.Lock()
+	defer account.mutex.Unlock()
 
 	// Double-check after acquiring write lock (another goroutine may have refreshed)
-	if m.prdToken != nil && m.prdToken.Valid() {
-		return m.prdToken.AccessToken, nil
+	if account.token != nil && account.token.Valid() {
+		return account.token.AccessToken, nil
 	}
 
 	// Fetch new token
 	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
 	defer cancel()
 
-	newToken, err := m.prdConfig.Token(ctx)
+	newToken, err := account.config.Token(ctx)
 	if err != nil {
-		return "", fmt.Errorf("failed to get PRD token: %w", err)
+		return "", fmt.Errorf("failed to get %s token: %w", environment, err)
 	}
 
-	m.prdToken = newToken
-	fmt.Printf("Service account token obtained for PRD (expires: %v)\n", newToken.Expiry)
+	account.token = newToken
+	fmt.Printf("Service account token obtained for %s (expires: %v)\n", environment, newToken.Expiry)
 
 	return newToken.AccessToken, nil
 }
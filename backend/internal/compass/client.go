@@ -301,83 +301,253 @@ func (c *Client) WaitForQueryCompletion(ctx context.Context, jobID string, pollI
 	}
 }
 
-// ExecuteQueryWithPagination executes a query with automatic pagination when results exceed page size
-// Returns: (data []byte, totalRecords int, error)
-// Optimized for Apache Spark Data Fabric - submits with maxRecords=0 (unlimited) and paginates results
-// progressCallback is optional (can be nil) and will be called after each page is fetched
-func (c *Client) ExecuteQueryWithPagination(ctx context.Context, query string, pageSize int, progressCallback PaginationProgressCallback) ([]byte, int, error) {
-	// Submit query with unlimited records (Spark will execute full query)
-	submitResp, err := c.SubmitQuery(ctx, query, 0)
-	if err != nil {
-		return nil, 0, fmt.Errorf("failed to submit query: %w", err)
+// PageHandler receives one page of query results. Returning an error stops pagination.
+type PageHandler func(records []map[string]interface{}) error
+
+// StreamOptions controls how StreamQueryResultsWithOptions fetches result pages
+type StreamOptions struct {
+	PageSize     int
+	Concurrency  int           // Pages fetched in parallel (default 1)
+	MaxRetries   int           // Retries per page after the first attempt fails
+	RetryBackoff time.Duration // Delay before the first retry, doubled for each further retry (default 1s)
+
+	// Throttle is called before every page request, e.g. to wait on a shared rate limiter
+	Throttle func(ctx context.Context) error
+
+	// QueryID resumes an earlier Compass query instead of submitting a new one, skipping
+	// records before StartOffset. If that query can no longer be read, the query is
+	// submitted again and streamed from offset 0.
+	QueryID     string
+	StartOffset int
+
+	// OnQueryReady is called once the query has finished, before any page is fetched,
+	// with the query ID and the offset streaming starts from
+	OnQueryReady func(queryID string, totalRecords, startOffset int) error
+
+	// OnPageHandled is called after handler has processed a page, with the offset of the
+	// next unhandled record. Pages are always handled in order, so everything before
+	// nextOffset has been handled.
+	OnPageHandled func(nextOffset int) error
+}
+
+// StreamQueryResults executes a query and hands each result page to handler as it is fetched
+// Returns: (totalRecords int, error)
+// Only one page is held in memory at a time, so memory stays bounded however large the result is.
+// progressCallback is optional (can be nil) and will be called after each page is handled
+func (c *Client) StreamQueryResults(ctx context.Context, query string, pageSize int, handler PageHandler, progressCallback PaginationProgressCallback) (int, error) {
+	return c.StreamQueryResultsWithOptions(ctx, query, StreamOptions{PageSize: pageSize}, handler, progressCallback)
+}
+
+// StreamQueryResultsWithOptions executes (or resumes) a query and hands result pages to handler in order
+// Returns: (totalRecords int, error)
+// Submits with maxRecords=0 (unlimited) and paginates results in PageSize chunks. Up to
+// Concurrency pages are fetched in parallel, each retried with exponential backoff; at most
+// Concurrency pages are held in memory while waiting for handler.
+// progressCallback is optional (can be nil) and will be called after each page is handled
+func (c *Client) StreamQueryResultsWithOptions(ctx context.Context, query string, opts StreamOptions, handler PageHandler, progressCallback PaginationProgressCallback) (int, error) {
+	pageSize := opts.PageSize
+	if pageSize <= 0 {
+		return 0, fmt.Errorf("page size must be positive, got %d", pageSize)
+	}
+	concurrency := opts.Concurrency
+	if concurrency < 1 {
+		concurrency = 1
 	}
 
-	// Wait for completion and get total record count
-	statusResp, err := c.WaitForQueryCompletion(ctx, submitResp.JobID, 2*time.Second)
+	queryID, totalRecords, startOffset, err := c.prepareStream(ctx, query, opts)
 	if err != nil {
-		return nil, 0, fmt.Errorf("query execution failed: %w", err)
+		return 0, err
 	}
 
-	totalRecords := statusResp.RecordCount
-	fmt.Printf("Query %s completed: %d total records\n", submitResp.JobID, totalRecords)
+	if opts.OnQueryReady != nil {
+		if err := opts.OnQueryReady(queryID, totalRecords, startOffset); err != nil {
+			return 0, err
+		}
+	}
 
-	// Handle empty result - return empty array for ParseResults compatibility
-	if totalRecords == 0 {
-		emptyArray := []map[string]interface{}{}
-		data, _ := json.Marshal(emptyArray)
-		return data, 0, nil
+	if startOffset >= totalRecords {
+		return totalRecords, nil
 	}
 
-	// Single page optimization (no pagination needed)
-	// GetQueryResult returns raw array, which is what we want
-	if totalRecords <= pageSize {
-		data, err := c.GetQueryResult(ctx, submitResp.JobID, 0, totalRecords)
-		if err == nil && progressCallback != nil {
-			progressCallback(1, 1, totalRecords, totalRecords, totalRecords)
-		}
-		return data, totalRecords, err
+	numPages := (totalRecords - startOffset + pageSize - 1) / pageSize // Ceiling division
+	if numPages > 1 {
+		fmt.Printf("Paginating: %d pages of up to %d records each from offset %d (total: %d, %d in parallel)\n",
+			numPages, pageSize, startOffset, totalRecords, concurrency)
 	}
 
-	// Multi-page fetch required
-	// Data Fabric returns raw JSON arrays: [{...}, {...}]
-	// We need to fetch multiple pages and combine them into a single array
-	numPages := (totalRecords + pageSize - 1) / pageSize // Ceiling division
-	fmt.Printf("Paginating: %d pages of up to %d records each (total: %d)\n", numPages, pageSize, totalRecords)
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
 
-	var allRecords []map[string]interface{}
+	// Each page has its own buffered channel so fetchers never block, and pages are
+	// handled in order however they complete. The semaphore is released once a page
+	// has been handled, bounding the pages held in memory.
+	type pageResult struct {
+		records []map[string]interface{}
+		err     error
+	}
+	results := make([]chan pageResult, numPages)
+	for i := range results {
+		results[i] = make(chan pageResult, 1)
+	}
+	sem := make(chan struct{}, concurrency)
 
-	for page := 0; page < numPages; page++ {
-		offset := page * pageSize
-		limit := pageSize
-		if offset+limit > totalRecords {
-			limit = totalRecords - offset
+	go func() {
+		for page := 0; page < numPages; page++ {
+			select {
+			case sem <- struct{}{}:
+			case <-ctx.Done():
+				return
+			}
+
+			offset := startOffset + page*pageSize
+			limit := pageSize
+			if offset+limit > totalRecords {
+				limit = totalRecords - offset
+			}
+
+			go func(page, offset, limit int) {
+				records, err := c.fetchPageWithRetry(ctx, queryID, offset, limit, opts)
+				if err != nil {
+					err = fmt.Errorf("failed to fetch page %d/%d: %w", page+1, numPages, err)
+				}
+				results[page] <- pageResult{records: records, err: err}
+			}(page, offset, limit)
 		}
+	}()
 
-		// Fetch page (returns raw JSON array)
-		pageData, err := c.GetQueryResult(ctx, submitResp.JobID, offset, limit)
-		if err != nil {
-			return nil, 0, fmt.Errorf("failed to fetch page %d/%d: %w", page+1, numPages, err)
+	totalFetched := startOffset
+	for page := 0; page < numPages; page++ {
+		var result pageResult
+		select {
+		case result = <-results[page]:
+		case <-ctx.Done():
+			return totalFetched, ctx.Err()
+		}
+		if result.err != nil {
+			return totalFetched, result.err
 		}
 
-		// Parse page as array (Data Fabric returns raw arrays, not wrapped objects)
-		var pageRecords []map[string]interface{}
-		if err := json.Unmarshal(pageData, &pageRecords); err != nil {
-			return nil, 0, fmt.Errorf("failed to parse page %d/%d: %w", page+1, numPages, err)
+		if err := handler(result.records); err != nil {
+			return totalFetched, fmt.Errorf("failed to handle page %d/%d: %w", page+1, numPages, err)
 		}
+		<-sem
+		totalFetched += len(result.records)
 
-		// Append records
-		allRecords = append(allRecords, pageRecords...)
+		if opts.OnPageHandled != nil {
+			nextOffset := startOffset + (page+1)*pageSize
+			if nextOffset > totalRecords {
+				nextOffset = totalRecords
+			}
+			if err := opts.OnPageHandled(nextOffset); err != nil {
+				return totalFetched, err
+			}
+		}
 
 		// Report pagination progress
 		if progressCallback != nil {
-			progressCallback(page+1, numPages, len(pageRecords), len(allRecords), totalRecords)
+			progressCallback(page+1, numPages, len(result.records), totalFetched, totalRecords)
+		}
+
+		if numPages > 1 {
+			fmt.Printf("Page %d/%d: %d records (total: %d/%d)\n",
+				page+1, numPages, len(result.records), totalFetched, totalRecords)
+		}
+	}
+
+	return totalRecords, nil
+}
+
+// prepareStream resumes opts.QueryID when set, otherwise submits the query, and waits for it to finish
+// Returns: (queryID, totalRecords, startOffset, error)
+func (c *Client) prepareStream(ctx context.Context, query string, opts StreamOptions) (string, int, int, error) {
+	if opts.QueryID != "" {
+		statusResp, err := c.WaitForQueryCompletion(ctx, opts.QueryID, 2*time.Second)
+		if err == nil {
+			fmt.Printf("Resuming query %s at offset %d: %d total records\n", opts.QueryID, opts.StartOffset, statusResp.RecordCount)
+			return opts.QueryID, statusResp.RecordCount, opts.StartOffset, nil
 		}
+		if ctx.Err() != nil {
+			return "", 0, 0, ctx.Err()
+		}
+		fmt.Printf("Cannot resume query %s (%v), submitting it again\n", opts.QueryID, err)
+	}
 
-		fmt.Printf("Page %d/%d: %d records (total: %d/%d)\n",
-			page+1, numPages, len(pageRecords), len(allRecords), totalRecords)
+	// Submit query with unlimited records (Spark will execute full query)
+	submitResp, err := c.SubmitQuery(ctx, query, 0)
+	if err != nil {
+		return "", 0, 0, fmt.Errorf("failed to submit query: %w", err)
 	}
 
-	fmt.Printf("Pagination complete. Total records fetched: %d\n", len(allRecords))
+	// Wait for completion and get total record count
+	statusResp, err := c.WaitForQueryCompletion(ctx, submitResp.JobID, 2*time.Second)
+	if err != nil {
+		return "", 0, 0, fmt.Errorf("query execution failed: %w", err)
+	}
+
+	fmt.Printf("Query %s completed: %d total records\n", submitResp.JobID, statusResp.RecordCount)
+	return submitResp.JobID, statusResp.RecordCount, 0, nil
+}
+
+// fetchPageWithRetry fetches and parses one result page, retrying with exponential backoff
+func (c *Client) fetchPageWithRetry(ctx context.Context, queryID string, offset, limit int, opts StreamOptions) ([]map[string]interface{}, error) {
+	backoff := opts.RetryBackoff
+	if backoff <= 0 {
+		backoff = time.Second
+	}
+
+	var lastErr error
+	for attempt := 0; attempt <= opts.MaxRetries; attempt++ {
+		if attempt > 0 {
+			fmt.Printf("Retrying page at offset %d in %v (attempt %d/%d): %v\n", offset, backoff, attempt+1, opts.MaxRetries+1, lastErr)
+			select {
+			case <-time.After(backoff):
+			case <-ctx.Done():
+				return nil, ctx.Err()
+			}
+			backoff *= 2
+		}
+
+		if opts.Throttle != nil {
+			if err := opts.Throttle(ctx); err != nil {
+				return nil, err
+			}
+		}
+
+		// Data Fabric returns raw JSON arrays: [{...}, {...}]
+		pageData, err := c.GetQueryResult(ctx, queryID, offset, limit)
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil, ctx.Err()
+			}
+			lastErr = err
+			continue
+		}
+
+		var records []map[string]interface{}
+		if err := json.Unmarshal(pageData, &records); err != nil {
+			lastErr = fmt.Errorf("failed to parse page: %w", err)
+			continue
+		}
+		return records, nil
+	}
+
+	return nil, lastErr
+}
+
+// ExecuteQueryWithPagination executes a query with automatic pagination when results exceed page size
+// Returns: (data []byte, totalRecords int, error)
+// All pages are combined into a single JSON array, so this is only suitable for small result
+// sets (lookups, removal checks). Use StreamQueryResults for snapshot-sized queries.
+// progressCallback is optional (can be nil) and will be called after each page is fetched
+func (c *Client) ExecuteQueryWithPagination(ctx context.Context, query string, pageSize int, progressCallback PaginationProgressCallback) ([]byte, int, error) {
+	allRecords := []map[string]interface{}{}
+	totalRecords, err := c.StreamQueryResults(ctx, query, pageSize, func(records []map[string]interface{}) error {
+		allRecords = append(allRecords, records...)
+		return nil
+	}, progressCallback)
+	if err != nil {
+		return nil, 0, err
+	}
 
 	// Return combined array (ParseResults expects a raw array)
 	data, err := json.Marshal(allRecords)
@@ -0,0 +1,69 @@
+package compass
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// Compass Data Fabric SQL has no bind parameters, so every value written into a query goes
+// through Literal: it is checked against the M3 field's format first and then quoted, with
+// embedded quotes doubled as a second line of defence.
+
+// FieldFormat is the format of an M3 field a value must match before it becomes a literal
+type FieldFormat struct {
+	Field   string // M3 field name, used in error messages
+	pattern *regexp.Regexp
+}
+
+// newFieldFormat creates a field format from a pattern matching the whole value
+func newFieldFormat(field, pattern string) FieldFormat {
+	return FieldFormat{Field: field, pattern: regexp.MustCompile(`^(?:` + pattern + `)$`)}
+}
+
+// M3 field formats of the values the application writes into Compass queries
+var (
+	FieldCONO = newFieldFormat("CONO", `[0-9]{1,3}`)          // Company, numeric 3
+	FieldFACI = newFieldFormat("FACI", `[A-Za-z0-9]{1,3}`)    // Facility, alphanumeric 3
+	FieldLNCD = newFieldFormat("LNCD", `[A-Za-z0-9]{1,2}`)    // Language, alphanumeric 2
+	FieldORNO = newFieldFormat("ORNO", `[A-Za-z0-9_-]{1,10}`) // Customer order number, alphanumeric 10
+	FieldCFIN = newFieldFormat("CFIN", `[0-9]{1,10}`)         // Configuration number, numeric 10
+)
+
+// Validate reports whether value matches the field format
+func (f FieldFormat) Validate(value string) error {
+	if !f.pattern.MatchString(value) {
+		return fmt.Errorf("invalid %s value %q", f.Field, value)
+	}
+	return nil
+}
+
+// Literal returns value as a quoted Compass SQL string literal after checking its format
+func Literal(format FieldFormat, value string) (string, error) {
+	if err := format.Validate(value); err != nil {
+		return "", err
+	}
+	return quoteLiteral(value), nil
+}
+
+// LiteralList returns values as a comma-separated list of literals for an IN clause
+func LiteralList(format FieldFormat, values []string) (string, error) {
+	if len(values) == 0 {
+		return "", fmt.Errorf("at least one %s value is required", format.Field)
+	}
+
+	literals := make([]string, len(values))
+	for i, value := range values {
+		literal, err := Literal(format, value)
+		if err != nil {
+			return "", err
+		}
+		literals[i] = literal
+	}
+	return strings.Join(literals, ", "), nil
+}
+
+// quoteLiteral quotes a string literal, doubling embedded single quotes
+func quoteLiteral(value string) string {
+	return "'" + strings.ReplaceAll(value, "'", "''") + "'"
+}
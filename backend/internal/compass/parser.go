@@ -526,7 +526,6 @@ type ManufacturingOrderRecord struct {
 	WHLO string
 	WHSL string
 	BANO string
-	PendingPutawayQty string
 
 	// Reference orders
 	RORC int
@@ -633,7 +632,6 @@ func ParseManufacturingOrder(record map[string]interface{}) (*ManufacturingOrder
 		WHLO: getString(record, "WHLO"),
 		WHSL: getString(record, "WHSL"),
 		BANO: getString(record, "BANO"),
-		PendingPutawayQty: getString(record, "PAQT"),
 
 		RORC: getInt(record, "RORC"),
 		RORN: getString(record, "RORN"),
@@ -887,8 +885,8 @@ func ParsePlannedOrder(record map[string]interface{}) (*PlannedOrderRecord, erro
 		RORX: getInt(record, "RORX"),
 		RORH: getString(record, "RORH"),
 
-		PLLO: getString(record, "PLLO"),
-		PLHL: getString(record, "PLHL"),
+		PLLO: getStringFromAny(record, "PLLO"), // Integer proposal numbers in Data Fabric
+		PLHL: getStringFromAny(record, "PLHL"),
 
 		NUAU: getInt(record, "NUAU"),
 		ORDP: getString(record, "ORDP"),
@@ -1084,6 +1082,11 @@ func GetString(record map[string]interface{}, key string) string {
 	return getString(record, key)
 }
 
+// GetStringFromAny extracts a value as a string, converting numeric Data Fabric values
+func GetStringFromAny(record map[string]interface{}, key string) string {
+	return getStringFromAny(record, key)
+}
+
 // GetInt safely extracts an integer value from a Compass record
 func GetInt(record map[string]interface{}, key string) int {
 	return getInt(record, key)
@@ -6,25 +6,42 @@ import (
 )
 
 // QueryBuilder builds SQL queries for Compass Data Fabric
+// The context values are validated and stored as quoted literals (see Literal), so the
+// builders can write them into queries as-is
 type QueryBuilder struct {
-	lastSyncDate int    // YYYYMMDD format
-	company      string // Company number (CONO)
-	facility     string // Facility (FACI)
-	language     string // Language code (LNCD)
+	lastSyncDate    int    // YYYYMMDD format
+	companyLiteral  string // Company number (CONO), e.g. '100'
+	facilityLiteral string // Facility (FACI)
+	languageLiteral string // Language code (LNCD)
 }
 
 // NewQueryBuilder creates a new query builder
-func NewQueryBuilder(lastSyncDate int, company string, facility string, language string) *QueryBuilder {
+// Returns an error if company, facility or language do not match their M3 field formats
+func NewQueryBuilder(lastSyncDate int, company string, facility string, language string) (*QueryBuilder, error) {
 	// Default to English if language is not provided
 	if language == "" {
 		language = "GB"
 	}
-	return &QueryBuilder{
-		lastSyncDate: lastSyncDate,
-		company:      company,
-		facility:     facility,
-		language:     language,
+
+	companyLiteral, err := Literal(FieldCONO, company)
+	if err != nil {
+		return nil, fmt.Errorf("invalid query context: %w", err)
+	}
+	facilityLiteral, err := Literal(FieldFACI, facility)
+	if err != nil {
+		return nil, fmt.Errorf("invalid query context: %w", err)
 	}
+	languageLiteral, err := Literal(FieldLNCD, language)
+	if err != nil {
+		return nil, fmt.Errorf("invalid query context: %w", err)
+	}
+
+	return &QueryBuilder{
+		lastSyncDate:    lastSyncDate,
+		companyLiteral:  companyLiteral,
+		facilityLiteral: facilityLiteral,
+		languageLiteral: languageLiteral,
+	}, nil
 }
 
 // BuildCustomerOrderLinesQuery builds the query for OOLINE (Customer Order Lines)
@@ -144,7 +161,6 @@ func (qb *QueryBuilder) BuildManufacturingOrdersQuery() string {
 
 		// Warehouse
 		"mo.WHLO", "mo.WHSL", "mo.BANO",
-		// "pp.TRQT as PAQT",  // DISABLED: MPTAWY table has severe performance issues
 
 		// Reference orders
 		"mo.RORC", "mo.RORN", "mo.RORL", "mo.RORX",
@@ -194,9 +210,6 @@ func (qb *QueryBuilder) BuildManufacturingOrdersQuery() string {
 	query := fmt.Sprintf(`
 SELECT %s
 FROM MWOHED mo
--- DISABLED: MPTAWY join removed due to severe table performance issues
--- Even simple SELECT queries on MPTAWY hang for 10+ minutes
--- TODO: Re-enable when MPTAWY performance is fixed
 -- Direct link: MO → CO
 LEFT JOIN MPREAL mpreal_direct
   ON mpreal_direct.ARDN = mo.MFNO
@@ -224,10 +237,10 @@ LEFT JOIN MITMAS m
 WHERE mo.deleted = 'false'
   AND mo.LMDT >= %d
   AND mo.WHST <= '20'
-  AND mo.CONO = '%s'
-  AND mo.FACI = '%s'
+  AND mo.CONO = %s
+  AND mo.FACI = %s
 ORDER BY mo.STDT, mo.LMDT
-`, strings.Join(fields, ", "), qb.lastSyncDate, qb.company, qb.facility)
+`, strings.Join(fields, ", "), qb.lastSyncDate, qb.companyLiteral, qb.facilityLiteral)
 
 	return strings.TrimSpace(query)
 }
@@ -329,10 +342,10 @@ LEFT JOIN MITMAS m
 WHERE mop.deleted = 'false'
   AND mop.LMDT >= %d
   AND mop.PSTS = '20'
-  AND mop.CONO = '%s'
-  AND mop.FACI = '%s'
+  AND mop.CONO = %s
+  AND mop.FACI = %s
 ORDER BY mop.PLDT, mop.LMDT
-`, strings.Join(fields, ", "), qb.lastSyncDate, qb.company, qb.facility)
+`, strings.Join(fields, ", "), qb.lastSyncDate, qb.companyLiteral, qb.facilityLiteral)
 
 	return strings.TrimSpace(query)
 }
@@ -401,9 +414,9 @@ ORDER BY LMDT, LMTS
 // Only fetches CO lines referenced by the MOPs/MOs we loaded (via MPREAL)
 // DEPRECATED: This approach causes issues when there are many order numbers
 // Use BuildOpenCustomerOrderLinesQuery instead
-func (qb *QueryBuilder) BuildCustomerOrderLinesByOrderNumbersQuery(orderNumbers []string) string {
+func (qb *QueryBuilder) BuildCustomerOrderLinesByOrderNumbersQuery(orderNumbers []string) (string, error) {
 	if len(orderNumbers) == 0 {
-		return ""
+		return "", nil
 	}
 
 	fields := []string{
@@ -432,10 +445,10 @@ func (qb *QueryBuilder) BuildCustomerOrderLinesByOrderNumbersQuery(orderNumbers
 		"timestamp", "deleted",
 	}
 
-	// Build IN clause (quote all order numbers)
-	quotedOrders := make([]string, len(orderNumbers))
-	for i, orderNum := range orderNumbers {
-		quotedOrders[i] = fmt.Sprintf("'%s'", orderNum)
+	// Build IN clause (validate and quote all order numbers)
+	orderList, err := LiteralList(FieldORNO, orderNumbers)
+	if err != nil {
+		return "", err
 	}
 
 	query := fmt.Sprintf(`
@@ -444,9 +457,9 @@ FROM OOLINE
 WHERE deleted = 'false'
   AND ORNO IN (%s)
 ORDER BY ORNO, PONR, POSX
-`, strings.Join(fields, ", "), strings.Join(quotedOrders, ", "))
+`, strings.Join(fields, ", "), orderList)
 
-	return strings.TrimSpace(query)
+	return strings.TrimSpace(query), nil
 }
 
 // BuildOpenCustomerOrderLinesQuery builds a query for all open CO lines
@@ -608,9 +621,9 @@ LEFT JOIN OOTYPE ootype
   ON oh.ORTP = ootype.ORTP
   AND ootype.deleted = 'false'
 LEFT JOIN CSYTAB dm
-  ON dm.CONO = '%s'
+  ON dm.CONO = %s
   AND dm.STCO = 'MODL'
-  AND dm.LNCD = '%s'
+  AND dm.LNCD = %s
   AND dm.STKY = oh.MODL
   AND dm.deleted = 'false'
 LEFT JOIN MITMAS m
@@ -620,10 +633,11 @@ LEFT JOIN MITMAS m
 WHERE ol.deleted = 'false'
   AND ol.ORST >= '20'
   AND ol.ORST < '30'
-  AND ol.CONO = '%s'
-  AND ol.FACI = '%s'
+  AND ol.LMDT >= %d
+  AND ol.CONO = %s
+  AND ol.FACI = %s
 ORDER BY ol.ORNO, ol.PONR, ol.POSX
-`, strings.Join(fieldList, ", "), qb.company, qb.language, qb.company, qb.facility)
+`, strings.Join(fieldList, ", "), qb.companyLiteral, qb.languageLiteral, qb.lastSyncDate, qb.companyLiteral, qb.facilityLiteral)
 
 	return strings.TrimSpace(query)
 }
@@ -674,31 +688,118 @@ ORDER BY LMDT, LMTS
 }
 
 // BuildMPREALQuery builds the query for MPREAL (Pre-Allocation/Supply Chain Links)
-// Loads all pre-allocation records for supply chain resolution
-// Filtered by company context
+// Loads the pre-allocation records of the facility's warehouses (MITWHL.FACI); every link of
+// a multi-level chain shares the chain's SCNB, so the rows can be walked from the CO line down
+// Filtered by company and facility context
 // For full refresh, use GetFullRefreshDate() as the lastSyncDate parameter
 func (qb *QueryBuilder) BuildMPREALQuery() string {
 	fields := []string{
 		// Core identifiers
-		"CONO", "WHLO", "ITNO",
+		"r.CONO", "w.FACI", "r.WHLO", "r.ITNO",
 
 		// Acquisition (source) order
-		"AOCA", "ARDN", "ARDL", "ARDX",
+		"r.AOCA", "r.ARDN", "r.ARDL", "r.ARDX",
 
 		// Demand (destination) order
-		"DOCA", "DRDN", "DRDL", "DRDX",
+		"r.DOCA", "r.DRDN", "r.DRDL", "r.DRDX",
 
 		// Quantity
-		"PQTY", "PQTR",
+		"r.PQTY", "r.PQTR",
 
 		// Supply Chain Number (CRITICAL for multi-level linking!)
-		"SCNB",
+		"r.SCNB",
 
 		// Planning
-		"RESP", "PATY",
+		"r.RESP", "r.PATY",
 
 		// M3 audit
-		"RGDT", "RGTM", "LMDT", "CHNO", "CHID", "LMTS",
+		"r.RGDT", "r.RGTM", "r.LMDT", "r.CHNO", "r.CHID", "r.LMTS",
+
+		// Data Lake
+		"r.timestamp", "r.deleted",
+	}
+
+	query := fmt.Sprintf(`
+SELECT %s
+FROM MPREAL r
+INNER JOIN MITWHL w
+  ON w.CONO = r.CONO
+  AND w.WHLO = r.WHLO
+  AND w.deleted = 'false'
+WHERE r.deleted = 'false'
+  AND r.LMDT >= %d
+  AND r.CONO = %s
+  AND w.FACI = %s
+ORDER BY r.SCNB, r.AOCA, r.DOCA
+`, strings.Join(fields, ", "), qb.lastSyncDate, qb.companyLiteral, qb.facilityLiteral)
+
+	return strings.TrimSpace(query)
+}
+
+// BuildMaterialLinesQuery builds the query for MWOMAT (MO Material Lines)
+// Loads the open component requirements of MOs in the snapshot (WHST <= '20')
+// Lines that are fully issued (WMST '90') are skipped
+// Filtered by company and facility context; always a full load for the facility
+func (qb *QueryBuilder) BuildMaterialLinesQuery() string {
+	fields := []string{
+		// Core identifiers
+		"mat.CONO", "mat.FACI", "mat.MFNO", "mat.PRNO", "mat.MSEQ",
+
+		// Component
+		"mat.MTNO", "mat.WHLO", "mat.OPNO", "mat.WMST", "mat.SPMT", "mat.PEUN",
+
+		// Quantities
+		"mat.REQT", "mat.RPQT", "mat.ALQT",
+
+		// Dates
+		"mat.RDAT",
+
+		// M3 audit
+		"mat.LMDT",
+
+		// Data Lake
+		"mat.timestamp", "mat.deleted",
+	}
+
+	query := fmt.Sprintf(`
+SELECT %s
+FROM MWOMAT mat
+INNER JOIN MWOHED mo
+  ON mo.CONO = mat.CONO
+  AND mo.FACI = mat.FACI
+  AND mo.MFNO = mat.MFNO
+  AND mo.deleted = 'false'
+  AND mo.WHST <= '20'
+WHERE mat.deleted = 'false'
+  AND mat.WMST < '90'
+  AND mat.CONO = %s
+  AND mat.FACI = %s
+ORDER BY mat.MFNO, mat.MSEQ
+`, strings.Join(fields, ", "), qb.companyLiteral, qb.facilityLiteral)
+
+	return strings.TrimSpace(query)
+}
+
+// BuildProductStructuresQuery builds the query for MPDMAT (Product Structure Materials)
+// Loads the components of every product structure in the facility that is still valid
+// on or after validFrom (YYYYMMDD), used to explode firmed MOP requirements
+// Filtered by company and facility context; always a full load for the facility
+func (qb *QueryBuilder) BuildProductStructuresQuery(validFrom int) string {
+	fields := []string{
+		// Core identifiers
+		"CONO", "FACI", "PRNO", "STRT", "MSEQ",
+
+		// Component
+		"MTNO", "OPNO", "PEUN",
+
+		// Quantities
+		"CNQT", "WAPC",
+
+		// Validity
+		"FDAT", "TDAT",
+
+		// M3 audit
+		"LMDT",
 
 		// Data Lake
 		"timestamp", "deleted",
@@ -706,12 +807,243 @@ func (qb *QueryBuilder) BuildMPREALQuery() string {
 
 	query := fmt.Sprintf(`
 SELECT %s
-FROM MPREAL
+FROM MPDMAT
 WHERE deleted = 'false'
-  AND LMDT >= %d
-  AND CONO = '%s'
-ORDER BY SCNB, AOCA, DOCA
-`, strings.Join(fields, ", "), qb.lastSyncDate, qb.company)
+  AND (TDAT = 0 OR TDAT >= %d)
+  AND CONO = %s
+  AND FACI = %s
+ORDER BY PRNO, STRT, MSEQ
+`, strings.Join(fields, ", "), validFrom, qb.companyLiteral, qb.facilityLiteral)
+
+	return strings.TrimSpace(query)
+}
+
+// BuildItemBalancesQuery builds the query for MITBAL (Item Balances)
+// Loads balances with stock on hand in the facility's warehouses (MITWHL.FACI)
+// Items without a row have nothing available
+func (qb *QueryBuilder) BuildItemBalancesQuery() string {
+	fields := []string{
+		// Core identifiers
+		"b.CONO", "w.FACI", "b.WHLO", "b.ITNO",
+
+		// Quantities
+		"b.STQT", "b.ALQT", "b.QUQT", "b.RJQT",
+
+		// M3 audit
+		"b.LMDT",
+
+		// Data Lake
+		"b.timestamp", "b.deleted",
+	}
+
+	query := fmt.Sprintf(`
+SELECT %s
+FROM MITBAL b
+INNER JOIN MITWHL w
+  ON w.CONO = b.CONO
+  AND w.WHLO = b.WHLO
+  AND w.deleted = 'false'
+WHERE b.deleted = 'false'
+  AND b.STQT > 0
+  AND b.CONO = %s
+  AND w.FACI = %s
+ORDER BY b.WHLO, b.ITNO
+`, strings.Join(fields, ", "), qb.companyLiteral, qb.facilityLiteral)
+
+	return strings.TrimSpace(query)
+}
+
+// BuildItemLocationsQuery builds the query for MITLOC (Item Locations)
+// Loads approved stock (STAS '2') per location and lot in the facility's warehouses
+func (qb *QueryBuilder) BuildItemLocationsQuery() string {
+	fields := []string{
+		// Core identifiers
+		"l.CONO", "w.FACI", "l.WHLO", "l.ITNO", "l.WHSL", "l.BANO", "l.CAMU",
+
+		// Status and quantities
+		"l.STAS", "l.STQT", "l.ALQT",
+
+		// M3 audit
+		"l.LMDT",
+
+		// Data Lake
+		"l.timestamp", "l.deleted",
+	}
+
+	query := fmt.Sprintf(`
+SELECT %s
+FROM MITLOC l
+INNER JOIN MITWHL w
+  ON w.CONO = l.CONO
+  AND w.WHLO = l.WHLO
+  AND w.deleted = 'false'
+WHERE l.deleted = 'false'
+  AND l.STAS = '2'
+  AND l.STQT > 0
+  AND l.CONO = %s
+  AND w.FACI = %s
+ORDER BY l.WHLO, l.ITNO, l.WHSL
+`, strings.Join(fields, ", "), qb.companyLiteral, qb.facilityLiteral)
+
+	return strings.TrimSpace(query)
+}
+
+// BuildOperationsQuery builds the query for MWOOPE (MO Operations)
+// Loads the operations of MOs in the snapshot (WHST <= '20') with their work center and times
+// Finished operations (WOST '90') are skipped
+// Filtered by company and facility context; always a full load for the facility
+func (qb *QueryBuilder) BuildOperationsQuery() string {
+	fields := []string{
+		// Core identifiers
+		"op.CONO", "op.FACI", "op.MFNO", "op.PRNO", "op.OPNO",
+
+		// Operation
+		"op.PLGR", "op.OPDS", "op.WOST",
+
+		// Times
+		"op.SETI", "op.PITI", "op.PUSN",
+
+		// Quantities
+		"op.ORQT", "op.MAQT",
+
+		// Dates
+		"op.STDT", "op.FIDT",
+
+		// M3 audit
+		"op.LMDT",
+
+		// Data Lake
+		"op.timestamp", "op.deleted",
+	}
+
+	query := fmt.Sprintf(`
+SELECT %s
+FROM MWOOPE op
+INNER JOIN MWOHED mo
+  ON mo.CONO = op.CONO
+  AND mo.FACI = op.FACI
+  AND mo.MFNO = op.MFNO
+  AND mo.deleted = 'false'
+  AND mo.WHST <= '20'
+WHERE op.deleted = 'false'
+  AND op.WOST < '90'
+  AND op.CONO = %s
+  AND op.FACI = %s
+ORDER BY op.MFNO, op.OPNO
+`, strings.Join(fields, ", "), qb.companyLiteral, qb.facilityLiteral)
+
+	return strings.TrimSpace(query)
+}
+
+// BuildProductOperationsQuery builds the query for MPDOPE (Routing Operations)
+// Loads the routing operations of every product in the facility that are still valid
+// on or after validFrom (YYYYMMDD), used to load MOPs onto work centers
+// Filtered by company and facility context; always a full load for the facility
+func (qb *QueryBuilder) BuildProductOperationsQuery(validFrom int) string {
+	fields := []string{
+		// Core identifiers
+		"CONO", "FACI", "PRNO", "STRT", "OPNO",
+
+		// Operation
+		"PLGR", "OPDS",
+
+		// Times
+		"SETI", "PITI", "PUSN",
+
+		// Validity
+		"FDAT", "TDAT",
+
+		// M3 audit
+		"LMDT",
+
+		// Data Lake
+		"timestamp", "deleted",
+	}
+
+	query := fmt.Sprintf(`
+SELECT %s
+FROM MPDOPE
+WHERE deleted = 'false'
+  AND (TDAT = 0 OR TDAT >= %d)
+  AND CONO = %s
+  AND FACI = %s
+ORDER BY PRNO, STRT, OPNO
+`, strings.Join(fields, ", "), validFrom, qb.companyLiteral, qb.facilityLiteral)
+
+	return strings.TrimSpace(query)
+}
+
+// BuildWorkCentersQuery builds the query for MPDWCT (Work Centers)
+// Loads every work center of the facility with its number of resources
+func (qb *QueryBuilder) BuildWorkCentersQuery() string {
+	fields := []string{
+		// Core identifiers
+		"CONO", "FACI", "PLGR",
+
+		// Work center
+		"PLNM", "WCTY", "NOMA",
+
+		// M3 audit
+		"LMDT",
+
+		// Data Lake
+		"timestamp", "deleted",
+	}
+
+	query := fmt.Sprintf(`
+SELECT %s
+FROM MPDWCT
+WHERE deleted = 'false'
+  AND CONO = %s
+  AND FACI = %s
+ORDER BY PLGR
+`, strings.Join(fields, ", "), qb.companyLiteral, qb.facilityLiteral)
+
+	return strings.TrimSpace(query)
+}
+
+// BuildManufacturingOrderRemovalsQuery builds a query for MOs that changed since lastSyncDate
+// and no longer belong in the snapshot: deleted in M3 or progressed past WHST '20'.
+// Used by incremental refresh to reconcile rows that the upsert query cannot see.
+func (qb *QueryBuilder) BuildManufacturingOrderRemovalsQuery() string {
+	query := fmt.Sprintf(`
+SELECT FACI, MFNO
+FROM MWOHED
+WHERE LMDT >= %d
+  AND CONO = %s
+  AND FACI = %s
+  AND (deleted = 'true' OR WHST > '20')
+`, qb.lastSyncDate, qb.companyLiteral, qb.facilityLiteral)
+
+	return strings.TrimSpace(query)
+}
+
+// BuildPlannedOrderRemovalsQuery builds a query for MOPs that changed since lastSyncDate
+// and no longer belong in the snapshot: deleted in M3 (e.g. released to an MO) or no longer firmed.
+func (qb *QueryBuilder) BuildPlannedOrderRemovalsQuery() string {
+	query := fmt.Sprintf(`
+SELECT PLPN
+FROM MMOPLP
+WHERE LMDT >= %d
+  AND CONO = %s
+  AND FACI = %s
+  AND (deleted = 'true' OR PSTS <> '20')
+`, qb.lastSyncDate, qb.companyLiteral, qb.facilityLiteral)
+
+	return strings.TrimSpace(query)
+}
+
+// BuildCustomerOrderLineRemovalsQuery builds a query for CO lines that changed since lastSyncDate
+// and are no longer open: deleted in M3 or outside the ORST 20-29 range.
+func (qb *QueryBuilder) BuildCustomerOrderLineRemovalsQuery() string {
+	query := fmt.Sprintf(`
+SELECT ORNO, PONR, POSX
+FROM OOLINE
+WHERE LMDT >= %d
+  AND CONO = %s
+  AND FACI = %s
+  AND (deleted = 'true' OR ORST < '20' OR ORST >= '30')
+`, qb.lastSyncDate, qb.companyLiteral, qb.facilityLiteral)
 
 	return strings.TrimSpace(query)
 }
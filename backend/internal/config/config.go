@@ -21,25 +21,8 @@ type Config struct {
 	DatabaseMaxIdleConnections   int
 	DatabaseConnectionLifetime   time.Duration
 
-	// M3 TRN Environment
-	TRNTenantID         string
-	TRNInstanceID       string
-	TRNClientID         string
-	TRNClientSecret     string
-	TRNAuthEndpoint     string
-	TRNTokenEndpoint    string
-	TRNAPIBaseURL       string
-	TRNCompassBaseURL   string
-
-	// M3 PRD Environment
-	PRDTenantID         string
-	PRDInstanceID       string
-	PRDClientID         string
-	PRDClientSecret     string
-	PRDAuthEndpoint     string
-	PRDTokenEndpoint    string
-	PRDAPIBaseURL       string
-	PRDCompassBaseURL   string
+	// M3 environments (tenants), keyed by M3Environment.Key - see environments.go
+	Environments []*M3Environment
 
 	// OAuth settings
 	OAuthRedirectURI    string
@@ -63,18 +46,19 @@ type Config struct {
 	MaxQueryRecords       int
 	QueryTimeout          int
 	MaxConcurrentQueries  int
-}
 
-// M3Environment represents TRN or PRD environment configuration
-type M3Environment struct {
-	TenantID        string
-	InstanceID      string
-	ClientID        string
-	ClientSecret    string
-	AuthEndpoint    string
-	TokenEndpoint   string
-	APIBaseURL      string
-	CompassBaseURL  string
+	// Notification settings (outbound email for subscriptions)
+	SMTPHost     string
+	SMTPPort     int
+	SMTPUsername string
+	SMTPPassword string
+	SMTPFrom     string
+
+	// Hosts webhook, Teams and Slack subscriptions may post to (comma-separated, "*.example.com"
+	// allows subdomains); empty allows any host that resolves to public addresses
+	NotificationWebhookHosts string
+	// Development only: lets webhook targets resolve to loopback/private addresses (local stubs)
+	NotificationWebhookAllowPrivate bool
 }
 
 // Load reads configuration from environment variables
@@ -89,24 +73,6 @@ func Load() (*Config, error) {
 		DatabaseMaxIdleConnections: getEnvAsInt("DATABASE_MAX_IDLE_CONNECTIONS", 5),
 		DatabaseConnectionLifetime: getEnvAsDuration("DATABASE_CONNECTION_LIFETIME", 5*time.Minute),
 
-		TRNTenantID:       getEnv("TRN_TENANT_ID", ""),
-		TRNInstanceID:     getEnv("TRN_INSTANCE_ID", ""),
-		TRNClientID:       getEnv("TRN_CLIENT_ID", ""),
-		TRNClientSecret:   getEnv("TRN_CLIENT_SECRET", ""),
-		TRNAuthEndpoint:   getEnv("TRN_AUTH_ENDPOINT", ""),
-		TRNTokenEndpoint:  getEnv("TRN_TOKEN_ENDPOINT", ""),
-		TRNAPIBaseURL:     getEnv("TRN_API_BASE_URL", ""),
-		TRNCompassBaseURL: getEnv("TRN_COMPASS_BASE_URL", ""),
-
-		PRDTenantID:       getEnv("PRD_TENANT_ID", ""),
-		PRDInstanceID:     getEnv("PRD_INSTANCE_ID", ""),
-		PRDClientID:       getEnv("PRD_CLIENT_ID", ""),
-		PRDClientSecret:   getEnv("PRD_CLIENT_SECRET", ""),
-		PRDAuthEndpoint:   getEnv("PRD_AUTH_ENDPOINT", ""),
-		PRDTokenEndpoint:  getEnv("PRD_TOKEN_ENDPOINT", ""),
-		PRDAPIBaseURL:     getEnv("PRD_API_BASE_URL", ""),
-		PRDCompassBaseURL: getEnv("PRD_COMPASS_BASE_URL", ""),
-
 		OAuthRedirectURI:   getEnv("OAUTH_REDIRECT_URI", "http://localhost:8080/api/auth/callback"),
 		OAuthScopes:        getEnv("OAUTH_SCOPES", "openid profile"),
 		SessionSecret:      getEnv("SESSION_SECRET", ""),
@@ -126,8 +92,23 @@ func Load() (*Config, error) {
 		MaxConcurrentQueries: getEnvAsInt("MAX_CONCURRENT_QUERIES", 5),
 
 		RunMigrations: getEnvAsBool("RUN_MIGRATIONS", false),
+
+		SMTPHost:     getEnv("SMTP_HOST", ""),
+		SMTPPort:     getEnvAsInt("SMTP_PORT", 25),
+		SMTPUsername: getEnv("SMTP_USERNAME", ""),
+		SMTPPassword: getEnv("SMTP_PASSWORD", ""),
+		SMTPFrom:     getEnv("SMTP_FROM", "m3-planning-tools@localhost"),
+
+		NotificationWebhookHosts:        getEnv("NOTIFICATION_WEBHOOK_HOSTS", ""),
+		NotificationWebhookAllowPrivate: getEnvAsBool("NOTIFICATION_WEBHOOK_ALLOW_PRIVATE", false),
 	}
 
+	environments, err := loadEnvironments()
+	if err != nil {
+		return nil, err
+	}
+	cfg.Environments = environments
+
 	// Validate required configuration
 	if err := cfg.Validate(); err != nil {
 		return nil, err
@@ -144,43 +125,20 @@ func (c *Config) Validate() error {
 	if c.SessionSecret == "" {
 		return fmt.Errorf("SESSION_SECRET is required")
 	}
-	if c.TRNClientID == "" || c.TRNClientSecret == "" {
-		return fmt.Errorf("TRN OAuth credentials are required")
+	if c.NotificationWebhookAllowPrivate && c.AppEnv == "production" {
+		return fmt.Errorf("NOTIFICATION_WEBHOOK_ALLOW_PRIVATE is for local development and cannot be enabled when APP_ENV=production")
 	}
-	if c.PRDClientID == "" || c.PRDClientSecret == "" {
-		return fmt.Errorf("PRD OAuth credentials are required")
-	}
-	return nil
+	return c.validateEnvironments()
 }
 
 // GetEnvironmentConfig returns configuration for the specified environment
 func (c *Config) GetEnvironmentConfig(env string) (*M3Environment, error) {
-	switch env {
-	case "TRN":
-		return &M3Environment{
-			TenantID:       c.TRNTenantID,
-			InstanceID:     c.TRNInstanceID,
-			ClientID:       c.TRNClientID,
-			ClientSecret:   c.TRNClientSecret,
-			AuthEndpoint:   c.TRNAuthEndpoint,
-			TokenEndpoint:  c.TRNTokenEndpoint,
-			APIBaseURL:     c.TRNAPIBaseURL,
-			CompassBaseURL: c.TRNCompassBaseURL,
-		}, nil
-	case "PRD":
-		return &M3Environment{
-			TenantID:       c.PRDTenantID,
-			InstanceID:     c.PRDInstanceID,
-			ClientID:       c.PRDClientID,
-			ClientSecret:   c.PRDClientSecret,
-			AuthEndpoint:   c.PRDAuthEndpoint,
-			TokenEndpoint:  c.PRDTokenEndpoint,
-			APIBaseURL:     c.PRDAPIBaseURL,
-			CompassBaseURL: c.PRDCompassBaseURL,
-		}, nil
-	default:
-		return nil, fmt.Errorf("invalid environment: %s", env)
+	for _, environment := range c.Environments {
+		if environment.Key == env {
+			return environment, nil
+		}
 	}
+	return nil, fmt.Errorf("invalid environment: %s", env)
 }
 
 // Helper functions for reading environment variables
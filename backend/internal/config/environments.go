@@ -0,0 +1,176 @@
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+)
+
+// M3Environment represents one M3 tenant/environment (e.g. TRN, PRD, TST)
+type M3Environment struct {
+	Key            string `json:"key"`           // Short code stored in every environment column, e.g. "PRD2"
+	Label          string `json:"label"`         // Display name on the login page, defaults to Key
+	Production     bool   `json:"production"`    // Production tenants are highlighted and confirm before switching
+	SettingsFrom   string `json:"settings_from"` // Environment whose system settings seed a new environment
+	TenantID       string `json:"tenant_id"`
+	InstanceID     string `json:"instance_id"`
+	ClientID       string `json:"client_id"`
+	ClientSecret   string `json:"client_secret"`
+	AuthEndpoint   string `json:"auth_endpoint"`
+	TokenEndpoint  string `json:"token_endpoint"`
+	APIBaseURL     string `json:"api_base_url"`
+	CompassBaseURL string `json:"compass_base_url"`
+}
+
+// environmentKeyPattern keeps keys usable as NATS subject tokens, environment variable prefixes
+// and values of the VARCHAR(10) environment columns
+var environmentKeyPattern = regexp.MustCompile(`^[A-Z][A-Z0-9_]{0,9}$`)
+
+// migrationSeededEnvironments are the environments migrations insert system settings for
+var migrationSeededEnvironments = map[string]bool{"TRN": true, "PRD": true}
+
+// environmentsFile is the layout of the M3_ENVIRONMENTS_FILE registry
+type environmentsFile struct {
+	Environments []*M3Environment `json:"environments"`
+}
+
+// loadEnvironments builds the environment registry
+// With M3_ENVIRONMENTS_FILE set, environments come from that JSON file; otherwise M3_ENVIRONMENTS
+// lists the keys (default "TRN,PRD"). Either way, {KEY}_* variables fill in any value the file
+// leaves empty, so secrets can stay out of the file
+func loadEnvironments() ([]*M3Environment, error) {
+	var environments []*M3Environment
+
+	if path := getEnv("M3_ENVIRONMENTS_FILE", ""); path != "" {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read M3_ENVIRONMENTS_FILE: %w", err)
+		}
+		var file environmentsFile
+		if err := json.Unmarshal(data, &file); err != nil {
+			return nil, fmt.Errorf("failed to parse M3_ENVIRONMENTS_FILE: %w", err)
+		}
+		environments = file.Environments
+	} else {
+		for _, key := range strings.Split(getEnv("M3_ENVIRONMENTS", "TRN,PRD"), ",") {
+			if key = strings.ToUpper(strings.TrimSpace(key)); key != "" {
+				environments = append(environments, &M3Environment{
+					Key:        key,
+					Production: getEnvAsBool(key+"_PRODUCTION", strings.HasPrefix(key, "PRD")),
+				})
+			}
+		}
+	}
+
+	for _, env := range environments {
+		env.Key = strings.ToUpper(strings.TrimSpace(env.Key))
+		applyEnvironmentVariables(env)
+	}
+
+	return environments, nil
+}
+
+// applyEnvironmentVariables fills the values an environment leaves empty from {KEY}_* variables
+func applyEnvironmentVariables(env *M3Environment) {
+	fields := []struct {
+		target *string
+		suffix string
+	}{
+		{&env.Label, "LABEL"},
+		{&env.SettingsFrom, "SETTINGS_FROM"},
+		{&env.TenantID, "TENANT_ID"},
+		{&env.InstanceID, "INSTANCE_ID"},
+		{&env.ClientID, "CLIENT_ID"},
+		{&env.ClientSecret, "CLIENT_SECRET"},
+		{&env.AuthEndpoint, "AUTH_ENDPOINT"},
+		{&env.TokenEndpoint, "TOKEN_ENDPOINT"},
+		{&env.APIBaseURL, "API_BASE_URL"},
+		{&env.CompassBaseURL, "COMPASS_BASE_URL"},
+	}
+	for _, field := range fields {
+		if *field.target == "" {
+			*field.target = getEnv(env.Key+"_"+field.suffix, "")
+		}
+	}
+	if env.Label == "" {
+		env.Label = env.Key
+	}
+}
+
+// validateEnvironments checks the registry is usable by every subsystem
+func (c *Config) validateEnvironments() error {
+	environments := c.Environments
+	if len(environments) == 0 {
+		return fmt.Errorf("at least one M3 environment is required")
+	}
+
+	seen := make(map[string]bool, len(environments))
+	for _, env := range environments {
+		if !environmentKeyPattern.MatchString(env.Key) {
+			return fmt.Errorf("invalid environment key %q: use up to 10 upper-case letters, digits or underscores", env.Key)
+		}
+		if seen[env.Key] {
+			return fmt.Errorf("duplicate environment key: %s", env.Key)
+		}
+		seen[env.Key] = true
+
+		if env.ClientID == "" || env.ClientSecret == "" {
+			return fmt.Errorf("%s OAuth credentials are required", env.Key)
+		}
+	}
+
+	for _, env := range environments {
+		if env.SettingsFrom != "" && !seen[env.SettingsFrom] {
+			return fmt.Errorf("%s settings_from refers to unknown environment %s", env.Key, env.SettingsFrom)
+		}
+		// A production environment nothing seeds would run on code defaults, e.g. without approvals
+		if env.Production && c.SettingsTemplate(env) == env.Key && !migrationSeededEnvironments[env.Key] {
+			return fmt.Errorf("%s is a production environment with no production environment to take settings from: set settings_from (%s_SETTINGS_FROM)", env.Key, env.Key)
+		}
+	}
+
+	return nil
+}
+
+// EnvironmentKeys returns the keys of all configured environments, in registry order
+func (c *Config) EnvironmentKeys() []string {
+	keys := make([]string, 0, len(c.Environments))
+	for _, env := range c.Environments {
+		keys = append(keys, env.Key)
+	}
+	return keys
+}
+
+// HasEnvironment reports whether env is a configured environment
+func (c *Config) HasEnvironment(env string) bool {
+	_, err := c.GetEnvironmentConfig(env)
+	return err == nil
+}
+
+// DefaultEnvironment returns the first configured environment, used when a request names none
+func (c *Config) DefaultEnvironment() string {
+	if len(c.Environments) == 0 {
+		return ""
+	}
+	return c.Environments[0].Key
+}
+
+// SettingsTemplate returns the environment whose system settings seed env
+// Defaults to the first production environment for production environments, so a second
+// production tenant never inherits a test tenant's settings (e.g. no approvals), and to the
+// first configured environment otherwise
+func (c *Config) SettingsTemplate(env *M3Environment) string {
+	if env.SettingsFrom != "" {
+		return env.SettingsFrom
+	}
+	if env.Production {
+		for _, candidate := range c.Environments {
+			if candidate.Production {
+				return candidate.Key
+			}
+		}
+	}
+	return c.DefaultEnvironment()
+}
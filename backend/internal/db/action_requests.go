@@ -0,0 +1,195 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+// Action request statuses
+const (
+	ActionRequestPending   = "pending"
+	ActionRequestApproved  = "approved" // Approved, M3 transaction running
+	ActionRequestRejected  = "rejected"
+	ActionRequestCancelled = "cancelled"
+	ActionRequestExecuted  = "executed"
+	ActionRequestFailed    = "failed" // Approved, but the M3 transaction failed
+)
+
+// ErrActionRequestExists is returned when the order already has an open request for the operation
+var ErrActionRequestExists = errors.New("an open action request already exists for this order")
+
+// ActionRequest is a destructive M3 operation awaiting or past a second user's approval
+type ActionRequest struct {
+	ID              int64           `json:"id"`
+	Environment     string          `json:"environment"`
+	Operation       string          `json:"operation"`
+	IssueID         int64           `json:"issueId"`
+	DetectorType    string          `json:"detectorType"`
+	Company         string          `json:"company,omitempty"`
+	Facility        string          `json:"facility"`
+	OrderType       string          `json:"orderType"`
+	OrderNumber     string          `json:"orderNumber"`
+	OrderStatus     string          `json:"orderStatus,omitempty"`
+	Reason          string          `json:"reason"`
+	Status          string          `json:"status"`
+	RequestedBy     string          `json:"requestedBy"`
+	RequestedByName string          `json:"requestedByName,omitempty"`
+	RequestedAt     time.Time       `json:"requestedAt"`
+	ReviewedBy      string          `json:"reviewedBy,omitempty"`
+	ReviewedByName  string          `json:"reviewedByName,omitempty"`
+	ReviewedAt      *time.Time      `json:"reviewedAt,omitempty"`
+	ReviewComment   string          `json:"reviewComment,omitempty"`
+	ExecutedAt      *time.Time      `json:"executedAt,omitempty"`
+	ErrorMessage    string          `json:"errorMessage,omitempty"`
+	M3Response      json.RawMessage `json:"m3Response,omitempty"`
+}
+
+// actionRequestColumns is the column list scanned by scanActionRequest
+const actionRequestColumns = `
+	id, environment, operation, issue_id, detector_type, COALESCE(company, ''), facility,
+	order_type, order_number, COALESCE(order_status, ''), reason, status,
+	requested_by, COALESCE(requested_by_name, ''), requested_at,
+	COALESCE(reviewed_by, ''), COALESCE(reviewed_by_name, ''), reviewed_at, COALESCE(review_comment, ''),
+	executed_at, COALESCE(error_message, ''), m3_response`
+
+// scanActionRequest scans one row selected with actionRequestColumns
+func scanActionRequest(row interface{ Scan(...interface{}) error }) (*ActionRequest, error) {
+	req := &ActionRequest{}
+	var reviewedAt, executedAt sql.NullTime
+	var m3Response []byte
+	if err := row.Scan(
+		&req.ID, &req.Environment, &req.Operation, &req.IssueID, &req.DetectorType, &req.Company, &req.Facility,
+		&req.OrderType, &req.OrderNumber, &req.OrderStatus, &req.Reason, &req.Status,
+		&req.RequestedBy, &req.RequestedByName, &req.RequestedAt,
+		&req.ReviewedBy, &req.ReviewedByName, &reviewedAt, &req.ReviewComment,
+		&executedAt, &req.ErrorMessage, &m3Response,
+	); err != nil {
+		return nil, err
+	}
+
+	if reviewedAt.Valid {
+		req.ReviewedAt = &reviewedAt.Time
+	}
+	if executedAt.Valid {
+		req.ExecutedAt = &executedAt.Time
+	}
+	if len(m3Response) > 0 {
+		req.M3Response = m3Response
+	}
+	return req, nil
+}
+
+// CreateActionRequest inserts a pending action request and sets its ID and timestamps
+// Returns ErrActionRequestExists if the order already has a pending or approved request for the operation
+func (q *Queries) CreateActionRequest(ctx context.Context, req *ActionRequest) error {
+	req.Status = ActionRequestPending
+	err := q.db.QueryRowContext(ctx, `
+		INSERT INTO action_requests (
+			environment, operation, issue_id, detector_type, company, facility,
+			order_type, order_number, order_status, reason, status, requested_by, requested_by_name
+		) VALUES ($1, $2, $3, $4, NULLIF($5, ''), $6, $7, $8, NULLIF($9, ''), $10, $11, $12, NULLIF($13, ''))
+		RETURNING id, requested_at
+	`, req.Environment, req.Operation, req.IssueID, req.DetectorType, req.Company, req.Facility,
+		req.OrderType, req.OrderNumber, req.OrderStatus, req.Reason, req.Status, req.RequestedBy, req.RequestedByName,
+	).Scan(&req.ID, &req.RequestedAt)
+	if pqErr, ok := err.(*pq.Error); ok && pqErr.Code == "23505" {
+		return ErrActionRequestExists
+	}
+	if err != nil {
+		return fmt.Errorf("failed to create action request: %w", err)
+	}
+	return nil
+}
+
+// GetActionRequest returns an environment's action request, or nil if not found
+func (q *Queries) GetActionRequest(ctx context.Context, environment string, id int64) (*ActionRequest, error) {
+	req, err := scanActionRequest(q.db.QueryRowContext(ctx, `
+		SELECT `+actionRequestColumns+`
+		FROM action_requests
+		WHERE environment = $1 AND id = $2
+	`, environment, id))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get action request: %w", err)
+	}
+	return req, nil
+}
+
+// ListActionRequests returns an environment's action requests, newest first
+// An empty status returns requests in every status
+func (q *Queries) ListActionRequests(ctx context.Context, environment, status string, limit int) ([]*ActionRequest, error) {
+	rows, err := q.db.QueryContext(ctx, `
+		SELECT `+actionRequestColumns+`
+		FROM action_requests
+		WHERE environment = $1 AND ($2 = '' OR status = $2)
+		ORDER BY requested_at DESC
+		LIMIT $3
+	`, environment, status, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query action requests: %w", err)
+	}
+	defer rows.Close()
+
+	requests := make([]*ActionRequest, 0)
+	for rows.Next() {
+		req, err := scanActionRequest(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan action request: %w", err)
+		}
+		requests = append(requests, req)
+	}
+
+	return requests, rows.Err()
+}
+
+// ReviewActionRequest moves a pending request to approved, rejected or cancelled
+// Returns nil if the request is no longer pending, so concurrent reviewers cannot both act on it
+func (q *Queries) ReviewActionRequest(ctx context.Context, environment string, id int64, status, reviewedBy, reviewedByName, comment string) (*ActionRequest, error) {
+	req, err := scanActionRequest(q.db.QueryRowContext(ctx, `
+		UPDATE action_requests
+		SET status = $3, reviewed_by = $4, reviewed_by_name = NULLIF($5, ''),
+		    review_comment = NULLIF($6, ''), reviewed_at = NOW(), updated_at = NOW()
+		WHERE environment = $1 AND id = $2 AND status = 'pending'
+		RETURNING `+actionRequestColumns,
+		environment, id, status, reviewedBy, reviewedByName, comment))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to review action request: %w", err)
+	}
+	return req, nil
+}
+
+// CompleteActionRequest records the M3 result of an approved request
+func (q *Queries) CompleteActionRequest(ctx context.Context, id int64, succeeded bool, m3Response interface{}, errorMessage string) error {
+	status := ActionRequestFailed
+	if succeeded {
+		status = ActionRequestExecuted
+	}
+
+	var response interface{}
+	if m3Response != nil {
+		data, _ := json.Marshal(m3Response)
+		response = string(data)
+	}
+
+	_, err := q.db.ExecContext(ctx, `
+		UPDATE action_requests
+		SET status = $2, m3_response = $3, error_message = NULLIF($4, ''),
+		    executed_at = NOW(), updated_at = NOW()
+		WHERE id = $1 AND status = 'approved'
+	`, id, status, response, errorMessage)
+	if err != nil {
+		return fmt.Errorf("failed to complete action request: %w", err)
+	}
+	return nil
+}
@@ -83,6 +83,7 @@ func (q *Queries) GetAnomaliesFiltered(ctx context.Context, environment, severit
 		  AND job_id = (
 		      SELECT id FROM refresh_jobs
 		      WHERE environment = $1
+		        AND status = 'completed'
 		      ORDER BY created_at DESC
 		      LIMIT 1
 		  )
@@ -141,6 +142,7 @@ func (q *Queries) GetAnomaliesFilteredCount(ctx context.Context, environment, se
 		  AND job_id = (
 		      SELECT id FROM refresh_jobs
 		      WHERE environment = $1
+		        AND status = 'completed'
 		      ORDER BY created_at DESC
 		      LIMIT 1
 		  )
@@ -180,6 +182,7 @@ func (q *Queries) GetAnomalySummary(ctx context.Context, environment string) (ma
 		  AND job_id = (
 		      SELECT id FROM refresh_jobs
 		      WHERE environment = $1
+		        AND status = 'completed'
 		      ORDER BY created_at DESC
 		      LIMIT 1
 		  )
@@ -345,6 +348,7 @@ func (q *Queries) GetActiveAnomalyCount(ctx context.Context, environment string)
 		  AND job_id = (
 		      SELECT id FROM refresh_jobs
 		      WHERE environment = $1
+		        AND status = 'completed'
 		      ORDER BY created_at DESC
 		      LIMIT 1
 		  )
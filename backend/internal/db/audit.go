@@ -2,6 +2,7 @@ package db
 
 import (
 	"context"
+	"database/sql"
 	"fmt"
 )
 
@@ -229,3 +230,32 @@ func (q *Queries) GetAuditLogsByEntity(ctx context.Context, entityType, entityID
 
 	return logs, rows.Err()
 }
+
+// GetAuditLogByID retrieves a single audit entry of an environment, or nil if not found
+func (q *Queries) GetAuditLogByID(ctx context.Context, environment string, id int64) (*AuditLog, error) {
+	query := `
+		SELECT
+			id, timestamp, user_id, user_name,
+			entity_type, entity_id, operation,
+			company, facility, warehouse,
+			metadata, ip_address, user_agent, created_at
+		FROM audit_log
+		WHERE id = $1 AND environment = $2
+	`
+
+	var log AuditLog
+	err := q.db.QueryRowContext(ctx, query, id, environment).Scan(
+		&log.ID, &log.Timestamp, &log.UserID, &log.UserName,
+		&log.EntityType, &log.EntityID, &log.Operation,
+		&log.Company, &log.Facility, &log.Warehouse,
+		&log.Metadata, &log.IPAddress, &log.UserAgent, &log.CreatedAt,
+	)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return &log, nil
+}
@@ -0,0 +1,225 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+// BulkActionJob is one execution of a bulk issue action
+type BulkActionJob struct {
+	ID                string       `json:"id"`
+	Environment       string       `json:"environment"`
+	UserID            string       `json:"userId"`
+	Action            string       `json:"action"`
+	Status            string       `json:"status"`
+	Selection         string       `json:"-"` // JSONB
+	TotalTransactions int          `json:"totalTransactions"`
+	Succeeded         int          `json:"succeeded"`
+	Failed            int          `json:"failed"`
+	SkippedIssues     int          `json:"skippedIssues"`
+	ErrorMessage      string       `json:"errorMessage,omitempty"`
+	CreatedAt         time.Time    `json:"createdAt"`
+	CompletedAt       sql.NullTime `json:"-"`
+}
+
+// BulkActionItem is one planned M3 transaction of a bulk action
+type BulkActionItem struct {
+	ID           int64             `json:"id"`
+	Seq          int               `json:"seq"`
+	IssueID      int64             `json:"issueId"`
+	Facility     string            `json:"facility"`
+	OrderType    string            `json:"orderType"`
+	OrderNumber  string            `json:"orderNumber"`
+	Program      string            `json:"program"`
+	Transaction  string            `json:"transaction"`
+	Record       map[string]string `json:"record"`
+	Status       string            `json:"status"`
+	ErrorMessage string            `json:"errorMessage,omitempty"`
+}
+
+// CreateBulkActionJobParams holds the job row and its planned items
+type CreateBulkActionJobParams struct {
+	JobID         string
+	Environment   string
+	UserID        string
+	Action        string
+	Selection     string // JSON
+	SkippedIssues int
+	Items         []*BulkActionItem
+}
+
+// CreateBulkActionJob inserts a bulk action job with its pending items and sets the item IDs
+func (q *Queries) CreateBulkActionJob(ctx context.Context, params CreateBulkActionJobParams) error {
+	tx, err := q.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, `
+		INSERT INTO bulk_action_jobs (id, environment, user_id, action, selection, total_transactions, skipped_issues)
+		VALUES ($1, $2, NULLIF($3, ''), $4, $5, $6, $7)
+	`, params.JobID, params.Environment, params.UserID, params.Action, params.Selection,
+		len(params.Items), params.SkippedIssues); err != nil {
+		return fmt.Errorf("failed to create bulk action job: %w", err)
+	}
+
+	for i, item := range params.Items {
+		record, _ := json.Marshal(item.Record)
+		item.Seq = i + 1
+		item.Status = "pending"
+		if err := tx.QueryRowContext(ctx, `
+			INSERT INTO bulk_action_items (
+				job_id, seq, issue_id, facility, order_type, order_number,
+				program, transaction_name, record
+			) VALUES ($1, $2, $3, $4, NULLIF($5, ''), NULLIF($6, ''), $7, $8, $9)
+			RETURNING id
+		`, params.JobID, item.Seq, item.IssueID, item.Facility, item.OrderType, item.OrderNumber,
+			item.Program, item.Transaction, string(record)).Scan(&item.ID); err != nil {
+			return fmt.Errorf("failed to create bulk action item: %w", err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit bulk action job: %w", err)
+	}
+	return nil
+}
+
+// UpdateBulkActionItemResult records the M3 result of one item and bumps the job counters
+func (q *Queries) UpdateBulkActionItemResult(ctx context.Context, jobID string, itemID int64, succeeded bool, errorMessage string) error {
+	status := "failed"
+	if succeeded {
+		status = "succeeded"
+	}
+
+	if _, err := q.db.ExecContext(ctx, `
+		UPDATE bulk_action_items
+		SET status = $2, error_message = NULLIF($3, ''), updated_at = NOW()
+		WHERE id = $1
+	`, itemID, status, errorMessage); err != nil {
+		return fmt.Errorf("failed to update bulk action item: %w", err)
+	}
+
+	counter := "failed"
+	if succeeded {
+		counter = "succeeded"
+	}
+	if _, err := q.db.ExecContext(ctx, fmt.Sprintf(`
+		UPDATE bulk_action_jobs
+		SET %s = %s + 1, updated_at = NOW()
+		WHERE id = $1
+	`, counter, counter), jobID); err != nil {
+		return fmt.Errorf("failed to update bulk action job counters: %w", err)
+	}
+
+	return nil
+}
+
+// CompleteBulkActionJob marks a bulk action job as completed or failed
+func (q *Queries) CompleteBulkActionJob(ctx context.Context, jobID, status, errorMessage string) error {
+	_, err := q.db.ExecContext(ctx, `
+		UPDATE bulk_action_jobs
+		SET status = $2, error_message = NULLIF($3, ''), completed_at = NOW(), updated_at = NOW()
+		WHERE id = $1
+	`, jobID, status, errorMessage)
+	if err != nil {
+		return fmt.Errorf("failed to complete bulk action job: %w", err)
+	}
+	return nil
+}
+
+// GetBulkActionJob returns a bulk action job, or nil if not found
+func (q *Queries) GetBulkActionJob(ctx context.Context, jobID string) (*BulkActionJob, error) {
+	job := &BulkActionJob{}
+	var userID, errorMessage sql.NullString
+	err := q.db.QueryRowContext(ctx, `
+		SELECT id, environment, user_id, action, status, selection, total_transactions,
+		       succeeded, failed, skipped_issues, error_message, created_at, completed_at
+		FROM bulk_action_jobs
+		WHERE id = $1
+	`, jobID).Scan(
+		&job.ID, &job.Environment, &userID, &job.Action, &job.Status, &job.Selection,
+		&job.TotalTransactions, &job.Succeeded, &job.Failed, &job.SkippedIssues,
+		&errorMessage, &job.CreatedAt, &job.CompletedAt,
+	)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get bulk action job: %w", err)
+	}
+
+	job.UserID = userID.String
+	job.ErrorMessage = errorMessage.String
+	return job, nil
+}
+
+// GetBulkActionItems returns a bulk action job's items in execution order
+func (q *Queries) GetBulkActionItems(ctx context.Context, jobID string) ([]*BulkActionItem, error) {
+	rows, err := q.db.QueryContext(ctx, `
+		SELECT id, seq, issue_id, facility, COALESCE(order_type, ''), COALESCE(order_number, ''),
+		       program, transaction_name, record, status, COALESCE(error_message, '')
+		FROM bulk_action_items
+		WHERE job_id = $1
+		ORDER BY seq
+	`, jobID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query bulk action items: %w", err)
+	}
+	defer rows.Close()
+
+	items := make([]*BulkActionItem, 0)
+	for rows.Next() {
+		item := &BulkActionItem{}
+		var record string
+		if err := rows.Scan(
+			&item.ID, &item.Seq, &item.IssueID, &item.Facility, &item.OrderType, &item.OrderNumber,
+			&item.Program, &item.Transaction, &record, &item.Status, &item.ErrorMessage,
+		); err != nil {
+			return nil, fmt.Errorf("failed to scan bulk action item: %w", err)
+		}
+		json.Unmarshal([]byte(record), &item.Record)
+		items = append(items, item)
+	}
+
+	return items, rows.Err()
+}
+
+// GetIssuesByIDs returns the environment's issues with the given IDs
+func (q *Queries) GetIssuesByIDs(ctx context.Context, environment string, ids []int64) ([]*DetectedIssue, error) {
+	rows, err := q.db.QueryContext(ctx, `
+		SELECT id, job_id, detector_type, detected_at, facility, warehouse,
+			   issue_key, production_order_number, production_order_type,
+			   co_number, co_line, co_suffix, issue_data, created_at
+		FROM detected_issues
+		WHERE environment = $1 AND id = ANY($2)
+		ORDER BY id
+	`, environment, pq.Array(ids))
+	if err != nil {
+		return nil, fmt.Errorf("failed to query issues by ID: %w", err)
+	}
+	defer rows.Close()
+
+	issues := make([]*DetectedIssue, 0)
+	for rows.Next() {
+		issue := &DetectedIssue{Environment: environment}
+		if err := rows.Scan(
+			&issue.ID, &issue.JobID, &issue.DetectorType, &issue.DetectedAt,
+			&issue.Facility, &issue.Warehouse, &issue.IssueKey,
+			&issue.ProductionOrderNumber, &issue.ProductionOrderType,
+			&issue.CONumber, &issue.COLine, &issue.COSuffix,
+			&issue.IssueData, &issue.CreatedAt,
+		); err != nil {
+			return nil, fmt.Errorf("failed to scan issue: %w", err)
+		}
+		issues = append(issues, issue)
+	}
+
+	return issues, rows.Err()
+}
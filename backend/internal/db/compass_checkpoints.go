@@ -0,0 +1,96 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+)
+
+// CompassQueryCheckpoint is the Compass query a data batch job is reading and the
+// offset of the next page it has not yet loaded
+type CompassQueryCheckpoint struct {
+	JobID          string
+	ParentJobID    string
+	Environment    string
+	CompassQueryID string
+	TotalRecords   int
+	NextOffset     int
+	CreatedAt      time.Time
+	UpdatedAt      time.Time
+}
+
+// GetCompassQueryCheckpoint returns a batch job's checkpoint, or nil if it has none
+func (q *Queries) GetCompassQueryCheckpoint(ctx context.Context, jobID string) (*CompassQueryCheckpoint, error) {
+	var cp CompassQueryCheckpoint
+	err := q.db.QueryRowContext(ctx, `
+		SELECT job_id, parent_job_id, environment, compass_query_id,
+			total_records, next_offset, created_at, updated_at
+		FROM compass_query_checkpoints
+		WHERE job_id = $1
+	`, jobID).Scan(
+		&cp.JobID, &cp.ParentJobID, &cp.Environment, &cp.CompassQueryID,
+		&cp.TotalRecords, &cp.NextOffset, &cp.CreatedAt, &cp.UpdatedAt,
+	)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get compass query checkpoint: %w", err)
+	}
+	return &cp, nil
+}
+
+// SaveCompassQueryCheckpoint records the Compass query a batch job is reading, replacing
+// any earlier checkpoint for the job
+func (q *Queries) SaveCompassQueryCheckpoint(ctx context.Context, cp *CompassQueryCheckpoint) error {
+	_, err := q.db.ExecContext(ctx, `
+		INSERT INTO compass_query_checkpoints (
+			job_id, parent_job_id, environment, compass_query_id, total_records, next_offset
+		) VALUES ($1, $2, $3, $4, $5, $6)
+		ON CONFLICT (job_id) DO UPDATE SET
+			compass_query_id = EXCLUDED.compass_query_id,
+			total_records = EXCLUDED.total_records,
+			next_offset = EXCLUDED.next_offset,
+			updated_at = NOW()
+	`, cp.JobID, cp.ParentJobID, cp.Environment, cp.CompassQueryID, cp.TotalRecords, cp.NextOffset)
+	if err != nil {
+		return fmt.Errorf("failed to save compass query checkpoint: %w", err)
+	}
+	return nil
+}
+
+// UpdateCompassQueryCheckpointOffset records that every record before nextOffset has been loaded
+func (q *Queries) UpdateCompassQueryCheckpointOffset(ctx context.Context, jobID string, nextOffset int) error {
+	_, err := q.db.ExecContext(ctx, `
+		UPDATE compass_query_checkpoints
+		SET next_offset = $2, updated_at = NOW()
+		WHERE job_id = $1
+	`, jobID, nextOffset)
+	if err != nil {
+		return fmt.Errorf("failed to update compass query checkpoint: %w", err)
+	}
+	return nil
+}
+
+// DeleteCompassQueryCheckpoint removes a batch job's checkpoint once it has completed,
+// including the checkpoints of its individual queries ("<jobID>-<part>") when it runs several
+func (q *Queries) DeleteCompassQueryCheckpoint(ctx context.Context, jobID string) error {
+	_, err := q.db.ExecContext(ctx, `
+		DELETE FROM compass_query_checkpoints
+		WHERE job_id = $1 OR starts_with(job_id, $1 || '-')
+	`, jobID)
+	if err != nil {
+		return fmt.Errorf("failed to delete compass query checkpoint: %w", err)
+	}
+	return nil
+}
+
+// DeleteCompassQueryCheckpointsForJob removes all checkpoints of a refresh job's batches
+func (q *Queries) DeleteCompassQueryCheckpointsForJob(ctx context.Context, parentJobID string) error {
+	_, err := q.db.ExecContext(ctx, `DELETE FROM compass_query_checkpoints WHERE parent_job_id = $1`, parentJobID)
+	if err != nil {
+		return fmt.Errorf("failed to delete compass query checkpoints: %w", err)
+	}
+	return nil
+}
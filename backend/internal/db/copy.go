@@ -0,0 +1,55 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+
+	"github.com/lib/pq"
+)
+
+// copySeqColumn records each row's position in the batch, so the upsert out of the
+// temp table can keep the last occurrence when a batch holds the same key twice
+const copySeqColumn = "copy_seq"
+
+// copyIntoTempTable streams rows into a temp table with PostgreSQL COPY. The temp table
+// has the given columns of target plus copy_seq, and is dropped when tx ends.
+// row(i) returns the values for the i-th row in column order.
+func copyIntoTempTable(ctx context.Context, tx *sql.Tx, target, temp string, columns []string, count int, row func(i int) []interface{}, progressCallback InsertProgressCallback) error {
+	_, err := tx.ExecContext(ctx, fmt.Sprintf(
+		"CREATE TEMP TABLE %s ON COMMIT DROP AS SELECT %s FROM %s WITH NO DATA",
+		temp, strings.Join(columns, ", "), target))
+	if err != nil {
+		return fmt.Errorf("failed to create temp table %s: %w", temp, err)
+	}
+
+	if _, err := tx.ExecContext(ctx, fmt.Sprintf("ALTER TABLE %s ADD COLUMN %s INTEGER", temp, copySeqColumn)); err != nil {
+		return fmt.Errorf("failed to add %s to temp table %s: %w", copySeqColumn, temp, err)
+	}
+
+	stmt, err := tx.PrepareContext(ctx, pq.CopyIn(temp, append(columns[:len(columns):len(columns)], copySeqColumn)...))
+	if err != nil {
+		return fmt.Errorf("failed to start copy into %s: %w", temp, err)
+	}
+	defer stmt.Close()
+
+	const insertProgressInterval = 10000
+	for i := 0; i < count; i++ {
+		if _, err := stmt.ExecContext(ctx, append(row(i), i)...); err != nil {
+			return fmt.Errorf("failed to copy row %d into %s: %w", i+1, temp, err)
+		}
+
+		// Report insertion progress every N records
+		if progressCallback != nil && ((i+1)%insertProgressInterval == 0 || (i+1) == count) {
+			progressCallback(i+1, count)
+		}
+	}
+
+	// An Exec without arguments flushes the buffered rows and completes the COPY
+	if _, err := stmt.ExecContext(ctx); err != nil {
+		return fmt.Errorf("failed to copy rows into %s: %w", temp, err)
+	}
+
+	return nil
+}
@@ -4,6 +4,8 @@ import (
 	"context"
 	"database/sql"
 	"fmt"
+	"strconv"
+	"strings"
 )
 
 // InsertProgressCallback is called during batch insertion to report progress
@@ -13,7 +15,7 @@ type InsertProgressCallback func(inserted, total int)
 // CustomerOrderLine represents a customer order line - all M3 fields as strings
 type CustomerOrderLine struct {
 	ID          int64
-	Environment string // M3 environment (TRN or PRD)
+	Environment string // M3 environment key (e.g. TRN, PRD)
 
 	// M3 Core Identifiers
 	CONO, DIVI, ORNO, PONR, POSX string
@@ -85,6 +87,9 @@ type CustomerOrderLine struct {
 	// Enrichment: Delivery Method Description (from CSYTAB)
 	DeliveryMethodDescription string
 
+	// Enrichment: Item Type (from MITMAS)
+	ItemType string
+
 	// M3 Attributes (ATV1-ATV0)
 	ATV1, ATV2, ATV3, ATV4, ATV5 string
 	ATV6, ATV7, ATV8, ATV9, ATV0 string
@@ -118,6 +123,44 @@ type CustomerOrderLine struct {
 
 // Note: InsertCustomerOrderLine removed - use BatchInsertCustomerOrderLines instead
 
+// customerOrderLineColumns are the customer_order_lines columns loaded by BatchInsertCustomerOrderLines, in copy order
+var customerOrderLineColumns = []string{
+	"environment",
+	"cono", "divi", "orno", "ponr", "posx",
+	"itno", "itds", "teds", "repi",
+	"orst", "orty",
+	"faci", "whlo",
+	"orqt", "rnqt", "alqt", "dlqt", "ivqt",
+	"orqa", "rnqa", "alqa", "dlqa", "ivqa",
+	"alun", "cofa", "spun",
+	"dwdt", "dwhm", "codt", "cohm", "pldt", "fded", "lded",
+	"sapr", "nepr", "lnam", "cucd",
+	"dip1", "dip2", "dip3", "dip4", "dip5", "dip6",
+	"dia1", "dia2", "dia3", "dia4", "dia5", "dia6",
+	"rorc", "rorn", "rorl", "rorx",
+	"cuno", "cuor", "cupo", "cusx",
+	"customer_name",
+	"prno", "hdpr", "popn", "alwt", "alwq",
+	"adid", "rout", "rodn", "dsdt", "dshm", "modl", "tedl", "tel2",
+	"tepa", "pact", "cupa",
+	"e0pa", "dsgp", "pusn", "putp",
+	"jdcd",
+	"dlix", "ortp",
+	"co_type_description",
+	"delivery_method",
+	"delivery_method_description",
+	"item_type",
+	"atv1", "atv2", "atv3", "atv4", "atv5", "atv6", "atv7", "atv8", "atv9", "atv0",
+	"uca1", "uca2", "uca3", "uca4", "uca5", "uca6", "uca7", "uca8", "uca9", "uca0",
+	"udn1", "udn2", "udn3", "udn4", "udn5", "udn6",
+	"uid1", "uid2", "uid3",
+	"uct1",
+	"atnr", "atmo", "atpr", "cfin",
+	"proj", "elno",
+	"rgdt", "rgtm", "lmdt", "chno", "chid", "lmts",
+	"m3_timestamp",
+}
+
 // BatchInsertCustomerOrderLines inserts multiple CO lines with all M3 fields as strings
 // progressCallback is optional (can be nil) and will be called periodically during insertion
 func (q *Queries) BatchInsertCustomerOrderLines(ctx context.Context, lines []*CustomerOrderLine, progressCallback InsertProgressCallback) error {
@@ -131,112 +174,11 @@ func (q *Queries) BatchInsertCustomerOrderLines(ctx context.Context, lines []*Cu
 	}
 	defer tx.Rollback()
 
-	stmt, err := tx.PrepareContext(ctx, `
-		INSERT INTO customer_order_lines (
-			environment,
-			cono, divi, orno, ponr, posx,
-			itno, itds, teds, repi,
-			orst, orty,
-			faci, whlo,
-			orqt, rnqt, alqt, dlqt, ivqt,
-			orqa, rnqa, alqa, dlqa, ivqa,
-			alun, cofa, spun,
-			dwdt, dwhm, codt, cohm, pldt, fded, lded,
-			sapr, nepr, lnam, cucd,
-			dip1, dip2, dip3, dip4, dip5, dip6,
-			dia1, dia2, dia3, dia4, dia5, dia6,
-			rorc, rorn, rorl, rorx,
-			cuno, cuor, cupo, cusx,
-			customer_name,
-			prno, hdpr, popn, alwt, alwq,
-			adid, rout, rodn, dsdt, dshm, modl, tedl, tel2,
-			tepa, pact, cupa,
-			e0pa, dsgp, pusn, putp,
-			jdcd,
-			dlix, ortp,
-			co_type_description,
-			delivery_method,
-			delivery_method_description,
-			atv1, atv2, atv3, atv4, atv5, atv6, atv7, atv8, atv9, atv0,
-			uca1, uca2, uca3, uca4, uca5, uca6, uca7, uca8, uca9, uca0,
-			udn1, udn2, udn3, udn4, udn5, udn6,
-			uid1, uid2, uid3,
-			uct1,
-			atnr, atmo, atpr, cfin,
-			proj, elno,
-			rgdt, rgtm, lmdt, chno, chid, lmts,
-			m3_timestamp,
-			sync_timestamp
-		) VALUES (
-			$1,
-			$2, $3, $4, $5, $6,
-			$7, $8, $9, $10,
-			$11, $12,
-			$13, $14,
-			$15, $16, $17, $18, $19,
-			$20, $21, $22, $23, $24,
-			$25, $26, $27,
-			$28, $29, $30, $31, $32, $33, $34,
-			$35, $36, $37, $38,
-			$39, $40, $41, $42, $43, $44,
-			$45, $46, $47, $48, $49, $50,
-			$51, $52, $53, $54,
-			$55, $56, $57, $58,
-			$59,
-			$60, $61, $62, $63, $64,
-			$65, $66, $67, $68, $69, $70, $71, $72,
-			$73, $74, $75,
-			$76, $77, $78, $79,
-			$80,
-			$81, $82,
-			$83,
-			$84,
-			$85,
-			$86, $87, $88, $89, $90, $91, $92, $93, $94, $95,
-			$96, $97, $98, $99, $100, $101, $102, $103, $104, $105,
-			$106, $107, $108, $109, $110, $111,
-			$112, $113, $114,
-			$115,
-			$116, $117, $118, $119,
-			$120, $121,
-			$122, $123, $124, $125, $126, $127,
-			$128,
-			NOW()
-		)
-		ON CONFLICT (environment, orno, ponr, posx)
-		DO UPDATE SET
-			itds = EXCLUDED.itds,
-			teds = EXCLUDED.teds,
-			orst = EXCLUDED.orst,
-			orqt = EXCLUDED.orqt,
-			rnqt = EXCLUDED.rnqt,
-			alqt = EXCLUDED.alqt,
-			dlqt = EXCLUDED.dlqt,
-			ivqt = EXCLUDED.ivqt,
-			dwdt = EXCLUDED.dwdt,
-			codt = EXCLUDED.codt,
-			pldt = EXCLUDED.pldt,
-			jdcd = EXCLUDED.jdcd,
-			dlix = EXCLUDED.dlix,
-			ortp = EXCLUDED.ortp,
-			customer_name = EXCLUDED.customer_name,
-			co_type_description = EXCLUDED.co_type_description,
-			delivery_method = EXCLUDED.delivery_method,
-			delivery_method_description = EXCLUDED.delivery_method_description,
-			lmdt = EXCLUDED.lmdt,
-			lmts = EXCLUDED.lmts,
-			m3_timestamp = EXCLUDED.m3_timestamp,
-			sync_timestamp = NOW(),
-			updated_at = NOW()
-	`)
-	if err != nil {
-		return fmt.Errorf("failed to prepare statement: %w", err)
-	}
-	defer stmt.Close()
-
-	const insertProgressInterval = 10000
-	for i, line := range lines {
-		_, err = stmt.ExecContext(ctx,
+	// COPY the batch into a temp table, then upsert it into the snapshot table in one statement
+	target := q.snapshotTable("customer_order_lines")
+	if err := copyIntoTempTable(ctx, tx, target, "customer_order_lines_copy", customerOrderLineColumns, len(lines), func(i int) []interface{} {
+		line := lines[i]
+		return []interface{}{
 			line.Environment,
 			line.CONO, line.DIVI, line.ORNO, line.PONR, line.POSX,
 			line.ITNO, line.ITDS, line.TEDS, line.REPI,
@@ -261,6 +203,7 @@ func (q *Queries) BatchInsertCustomerOrderLines(ctx context.Context, lines []*Cu
 			line.COTypeDescription,
 			line.DeliveryMethod,
 			line.DeliveryMethodDescription,
+			line.ItemType,
 			line.ATV1, line.ATV2, line.ATV3, line.ATV4, line.ATV5, line.ATV6, line.ATV7, line.ATV8, line.ATV9, line.ATV0,
 			line.UCA1, line.UCA2, line.UCA3, line.UCA4, line.UCA5, line.UCA6, line.UCA7, line.UCA8, line.UCA9, line.UCA0,
 			line.UDN1, line.UDN2, line.UDN3, line.UDN4, line.UDN5, line.UDN6,
@@ -270,47 +213,122 @@ func (q *Queries) BatchInsertCustomerOrderLines(ctx context.Context, lines []*Cu
 			line.PROJ, line.ELNO,
 			line.RGDT, line.RGTM, line.LMDT, line.CHNO, line.CHID, line.LMTS,
 			line.M3Timestamp,
-		)
-		if err != nil {
-			return fmt.Errorf("failed to insert line %s-%s: %w", line.ORNO, line.PONR, err)
 		}
+	}, progressCallback); err != nil {
+		return err
+	}
 
-		// Report insertion progress every N records
-		if progressCallback != nil && ((i+1)%insertProgressInterval == 0 || (i+1) == len(lines)) {
-			progressCallback(i+1, len(lines))
-		}
+	// DISTINCT ON keeps the last row when the batch holds the same key twice
+	columns := strings.Join(customerOrderLineColumns, ", ")
+	_, err = tx.ExecContext(ctx, fmt.Sprintf(`
+		INSERT INTO %s (%s, sync_timestamp)
+		SELECT DISTINCT ON (environment, orno, ponr, posx) %s, NOW()
+		FROM customer_order_lines_copy
+		ORDER BY environment, orno, ponr, posx, copy_seq DESC
+		ON CONFLICT (environment, orno, ponr, posx)
+		DO UPDATE SET
+			itds = EXCLUDED.itds,
+			teds = EXCLUDED.teds,
+			orst = EXCLUDED.orst,
+			orqt = EXCLUDED.orqt,
+			rnqt = EXCLUDED.rnqt,
+			alqt = EXCLUDED.alqt,
+			dlqt = EXCLUDED.dlqt,
+			ivqt = EXCLUDED.ivqt,
+			dwdt = EXCLUDED.dwdt,
+			codt = EXCLUDED.codt,
+			pldt = EXCLUDED.pldt,
+			jdcd = EXCLUDED.jdcd,
+			dlix = EXCLUDED.dlix,
+			ortp = EXCLUDED.ortp,
+			customer_name = EXCLUDED.customer_name,
+			co_type_description = EXCLUDED.co_type_description,
+			delivery_method = EXCLUDED.delivery_method,
+			delivery_method_description = EXCLUDED.delivery_method_description,
+			item_type = EXCLUDED.item_type,
+			lmdt = EXCLUDED.lmdt,
+			lmts = EXCLUDED.lmts,
+			m3_timestamp = EXCLUDED.m3_timestamp,
+			sync_timestamp = NOW(),
+			updated_at = NOW()
+	`, target, columns, columns))
+	if err != nil {
+		return fmt.Errorf("failed to upsert CO lines: %w", err)
 	}
 
 	return tx.Commit()
 }
 
-// GetLastSyncDate gets the last LMDT value for incremental loading
-// DEPRECATED: System now uses full refresh with table truncation.
-// Kept for backward compatibility or potential future incremental mode.
-func (q *Queries) GetLastSyncDate(ctx context.Context, tableName string) (int, error) {
-	var lmdt sql.NullInt32
+// GetLastSyncDate gets the last LMDT value loaded for an environment, used as the
+// lower bound for incremental (delta) refreshes. LMDT is day-granular, so rows changed
+// on that same day are fetched again and upserted idempotently.
+// Returns 20200101 when nothing has been loaded yet, which yields a full load.
+func (q *Queries) GetLastSyncDate(ctx context.Context, environment, tableName string) (int, error) {
+	var lmdt sql.NullString
 
-	query := ""
 	switch tableName {
-	case "customer_order_lines":
-		query = "SELECT MAX(lmdt) FROM customer_order_lines WHERE lmdt IS NOT NULL"
-	case "manufacturing_orders":
-		query = "SELECT MAX(lmdt) FROM manufacturing_orders WHERE lmdt IS NOT NULL"
-	case "planned_manufacturing_orders":
-		query = "SELECT MAX(lmdt) FROM planned_manufacturing_orders WHERE lmdt IS NOT NULL"
+	case "customer_order_lines", "manufacturing_orders", "planned_manufacturing_orders":
 	default:
 		return 0, fmt.Errorf("unknown table: %s", tableName)
 	}
+	query := fmt.Sprintf("SELECT MAX(lmdt) FROM %s WHERE environment = $1 AND lmdt <> ''", q.snapshotTable(tableName))
 
-	err := q.db.QueryRowContext(ctx, query).Scan(&lmdt)
+	err := q.db.QueryRowContext(ctx, query, environment).Scan(&lmdt)
 	if err != nil && err != sql.ErrNoRows {
-		return 0, err
+		return 0, fmt.Errorf("failed to get last sync date for %s: %w", tableName, err)
 	}
 
 	if lmdt.Valid {
-		return int(lmdt.Int32), nil
+		if date, err := strconv.Atoi(lmdt.String); err == nil && date > 0 {
+			return date, nil
+		}
 	}
 
 	// No previous sync, return a far past date for full load
 	return 20200101, nil // January 1, 2020
 }
+
+// CustomerOrderLineKey identifies a CO line for reconciliation deletes
+type CustomerOrderLineKey struct {
+	ORNO, PONR, POSX string
+}
+
+// DeleteCustomerOrderLines removes CO lines that were deleted or closed in M3
+// Used by incremental refresh; full refresh truncates instead
+func (q *Queries) DeleteCustomerOrderLines(ctx context.Context, environment string, keys []CustomerOrderLineKey) (int, error) {
+	if len(keys) == 0 {
+		return 0, nil
+	}
+
+	tx, err := q.db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	stmt, err := tx.PrepareContext(ctx, fmt.Sprintf(`
+		DELETE FROM %s
+		WHERE environment = $1 AND orno = $2 AND ponr = $3 AND posx = $4
+	`, q.snapshotTable("customer_order_lines")))
+	if err != nil {
+		return 0, fmt.Errorf("failed to prepare statement: %w", err)
+	}
+	defer stmt.Close()
+
+	deleted := 0
+	for _, key := range keys {
+		result, err := stmt.ExecContext(ctx, environment, key.ORNO, key.PONR, key.POSX)
+		if err != nil {
+			return 0, fmt.Errorf("failed to delete line %s-%s: %w", key.ORNO, key.PONR, err)
+		}
+		if n, err := result.RowsAffected(); err == nil {
+			deleted += int(n)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, fmt.Errorf("failed to commit delete transaction: %w", err)
+	}
+
+	return deleted, nil
+}
@@ -0,0 +1,216 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+)
+
+// IssueCountPoint is one data point of an issue trend series
+type IssueCountPoint struct {
+	JobID      string    `json:"jobId"`
+	RecordedAt time.Time `json:"recordedAt"`
+	GroupKey   string    `json:"groupKey"` // detector type, facility or warehouse depending on grouping
+	IssueCount int       `json:"issueCount"`
+}
+
+// IssueTrendParams filters issue count history
+type IssueTrendParams struct {
+	Environment  string
+	Since        time.Time
+	GroupBy      string // "detector_type" (default), "facility" or "warehouse"
+	DetectorType string
+	Facility     string
+	Warehouse    string
+}
+
+// DetectionJobTotals is the issues_by_type summary of one completed detection job
+type DetectionJobTotals struct {
+	JobID        string    `json:"jobId"`
+	CompletedAt  time.Time `json:"completedAt"`
+	TotalIssues  int       `json:"totalIssues"`
+	IssuesByType string    `json:"-"` // JSONB
+}
+
+// IssueHistoryEntry is one occurrence of an issue in a completed job
+type IssueHistoryEntry struct {
+	JobID                 string         `json:"jobId"`
+	RecordedAt            time.Time      `json:"recordedAt"`
+	Facility              string         `json:"facility"`
+	Warehouse             sql.NullString `json:"-"`
+	ProductionOrderNumber sql.NullString `json:"-"`
+	ProductionOrderType   sql.NullString `json:"-"`
+}
+
+// RecordIssueHistory snapshots a completed job's issues into the history tables
+// Safe to call more than once for the same job
+func (q *Queries) RecordIssueHistory(ctx context.Context, environment, jobID string) error {
+	tx, err := q.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, `
+		INSERT INTO issue_count_history (environment, job_id, detector_type, facility, warehouse, issue_count, recorded_at)
+		SELECT environment, job_id, detector_type, facility, COALESCE(warehouse, ''), COUNT(*), NOW()
+		FROM detected_issues
+		WHERE environment = $1 AND job_id = $2
+		GROUP BY environment, job_id, detector_type, facility, COALESCE(warehouse, '')
+		ON CONFLICT (job_id, detector_type, facility, warehouse)
+		DO UPDATE SET issue_count = EXCLUDED.issue_count
+	`, environment, jobID); err != nil {
+		return fmt.Errorf("failed to record issue counts: %w", err)
+	}
+
+	if _, err := tx.ExecContext(ctx, `DELETE FROM issue_history WHERE job_id = $1`, jobID); err != nil {
+		return fmt.Errorf("failed to clear issue history for job: %w", err)
+	}
+
+	if _, err := tx.ExecContext(ctx, `
+		INSERT INTO issue_history (
+			environment, job_id, detector_type, facility, warehouse, issue_key,
+			production_order_number, production_order_type, co_number, co_line, recorded_at
+		)
+		SELECT environment, job_id, detector_type, facility, warehouse, issue_key,
+			   production_order_number, production_order_type, co_number, co_line, NOW()
+		FROM detected_issues
+		WHERE environment = $1 AND job_id = $2
+	`, environment, jobID); err != nil {
+		return fmt.Errorf("failed to record issue history: %w", err)
+	}
+
+	return tx.Commit()
+}
+
+// PruneIssueHistory removes history rows older than the retention period
+func (q *Queries) PruneIssueHistory(ctx context.Context, environment string, retentionDays int) error {
+	cutoff := time.Now().AddDate(0, 0, -retentionDays)
+
+	if _, err := q.db.ExecContext(ctx, `
+		DELETE FROM issue_history WHERE environment = $1 AND recorded_at < $2
+	`, environment, cutoff); err != nil {
+		return fmt.Errorf("failed to prune issue history: %w", err)
+	}
+
+	if _, err := q.db.ExecContext(ctx, `
+		DELETE FROM issue_count_history WHERE environment = $1 AND recorded_at < $2
+	`, environment, cutoff); err != nil {
+		return fmt.Errorf("failed to prune issue count history: %w", err)
+	}
+
+	return nil
+}
+
+// GetIssueTrends returns issue counts per completed job grouped by detector, facility or warehouse
+func (q *Queries) GetIssueTrends(ctx context.Context, params IssueTrendParams) ([]IssueCountPoint, error) {
+	groupColumn := "detector_type"
+	switch params.GroupBy {
+	case "facility":
+		groupColumn = "facility"
+	case "warehouse":
+		groupColumn = "warehouse"
+	}
+
+	query := fmt.Sprintf(`
+		SELECT job_id, MIN(recorded_at), %s, SUM(issue_count)
+		FROM issue_count_history
+		WHERE environment = $1 AND recorded_at >= $2
+	`, groupColumn)
+	args := []interface{}{params.Environment, params.Since}
+	argPos := 3
+
+	if params.DetectorType != "" {
+		query += fmt.Sprintf(" AND detector_type = $%d", argPos)
+		args = append(args, params.DetectorType)
+		argPos++
+	}
+	if params.Facility != "" {
+		query += fmt.Sprintf(" AND facility = $%d", argPos)
+		args = append(args, params.Facility)
+		argPos++
+	}
+	if params.Warehouse != "" {
+		query += fmt.Sprintf(" AND warehouse = $%d", argPos)
+		args = append(args, params.Warehouse)
+		argPos++
+	}
+
+	query += fmt.Sprintf(`
+		GROUP BY job_id, %s
+		ORDER BY MIN(recorded_at), %s
+	`, groupColumn, groupColumn)
+
+	rows, err := q.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query issue trends: %w", err)
+	}
+	defer rows.Close()
+
+	var points []IssueCountPoint
+	for rows.Next() {
+		var p IssueCountPoint
+		if err := rows.Scan(&p.JobID, &p.RecordedAt, &p.GroupKey, &p.IssueCount); err != nil {
+			return nil, fmt.Errorf("failed to scan issue trend: %w", err)
+		}
+		points = append(points, p)
+	}
+
+	return points, rows.Err()
+}
+
+// GetDetectionJobTotals returns issues_by_type for completed detection jobs since a given time
+func (q *Queries) GetDetectionJobTotals(ctx context.Context, environment string, since time.Time) ([]DetectionJobTotals, error) {
+	rows, err := q.db.QueryContext(ctx, `
+		SELECT job_id, completed_at, COALESCE(total_issues_found, 0), COALESCE(issues_by_type::text, '{}')
+		FROM issue_detection_jobs
+		WHERE environment = $1
+		  AND status = 'completed'
+		  AND completed_at >= $2
+		ORDER BY completed_at
+	`, environment, since)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query detection job totals: %w", err)
+	}
+	defer rows.Close()
+
+	var totals []DetectionJobTotals
+	for rows.Next() {
+		var t DetectionJobTotals
+		if err := rows.Scan(&t.JobID, &t.CompletedAt, &t.TotalIssues, &t.IssuesByType); err != nil {
+			return nil, fmt.Errorf("failed to scan detection job totals: %w", err)
+		}
+		totals = append(totals, t)
+	}
+
+	return totals, rows.Err()
+}
+
+// GetIssueKeyHistory returns every completed job an issue appeared in since a given time
+func (q *Queries) GetIssueKeyHistory(ctx context.Context, environment, detectorType, issueKey string, since time.Time) ([]IssueHistoryEntry, error) {
+	rows, err := q.db.QueryContext(ctx, `
+		SELECT job_id, recorded_at, facility, warehouse, production_order_number, production_order_type
+		FROM issue_history
+		WHERE environment = $1
+		  AND detector_type = $2
+		  AND issue_key = $3
+		  AND recorded_at >= $4
+		ORDER BY recorded_at
+	`, environment, detectorType, issueKey, since)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query issue history: %w", err)
+	}
+	defer rows.Close()
+
+	var entries []IssueHistoryEntry
+	for rows.Next() {
+		var e IssueHistoryEntry
+		if err := rows.Scan(&e.JobID, &e.RecordedAt, &e.Facility, &e.Warehouse, &e.ProductionOrderNumber, &e.ProductionOrderType); err != nil {
+			return nil, fmt.Errorf("failed to scan issue history: %w", err)
+		}
+		entries = append(entries, e)
+	}
+
+	return entries, rows.Err()
+}
@@ -0,0 +1,118 @@
+package db
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/lib/pq"
+)
+
+// IssueLifecycleSummary counts how a job's issues compare to the previous job
+type IssueLifecycleSummary struct {
+	NewIssues        int `json:"newIssues"`
+	PersistingIssues int `json:"persistingIssues"`
+	ResolvedIssues   int `json:"resolvedIssues"`
+}
+
+// UpdateIssueLifecycle diffs a job's issues against the open issue keys from previous jobs
+// Only detectors that ran in this job can resolve issues, and only in the facilities the job
+// loaded, so a disabled or failed detector or another facility's open issues do not look resolved
+func (q *Queries) UpdateIssueLifecycle(ctx context.Context, environment, jobID string, facilities, detectorTypes []string) (*IssueLifecycleSummary, error) {
+	tx, err := q.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	summary := &IssueLifecycleSummary{}
+
+	// Open issues no longer found by their detector are resolved
+	for _, detectorType := range detectorTypes {
+		result, err := tx.ExecContext(ctx, `
+			UPDATE issue_lifecycle l
+			SET status = 'resolved',
+				resolved_at = NOW(),
+				resolved_job_id = $2
+			WHERE l.environment = $1
+			  AND l.detector_type = $3
+			  AND l.facility = ANY($4::text[])
+			  AND l.status = 'open'
+			  AND NOT EXISTS (
+				SELECT 1 FROM detected_issues d
+				WHERE d.environment = l.environment
+				  AND d.job_id = $2
+				  AND d.detector_type = l.detector_type
+				  AND d.issue_key = l.issue_key
+			  )
+		`, environment, jobID, detectorType, pq.Array(facilities))
+		if err != nil {
+			return nil, fmt.Errorf("failed to resolve issues for %s: %w", detectorType, err)
+		}
+		resolved, _ := result.RowsAffected()
+		summary.ResolvedIssues += int(resolved)
+	}
+
+	// Open issues found again are persisting
+	result, err := tx.ExecContext(ctx, `
+		UPDATE issue_lifecycle l
+		SET last_seen_at = NOW(),
+			last_seen_job_id = $2
+		FROM (
+			SELECT DISTINCT detector_type, issue_key
+			FROM detected_issues
+			WHERE environment = $1 AND job_id = $2
+		) d
+		WHERE l.environment = $1
+		  AND l.detector_type = d.detector_type
+		  AND l.issue_key = d.issue_key
+		  AND l.status = 'open'
+	`, environment, jobID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to update persisting issues: %w", err)
+	}
+	persisting, _ := result.RowsAffected()
+	summary.PersistingIssues = int(persisting)
+
+	// Unknown or previously resolved issue keys are new
+	result, err = tx.ExecContext(ctx, `
+		INSERT INTO issue_lifecycle (
+			environment, detector_type, issue_key, facility, status,
+			first_seen_at, first_seen_job_id, last_seen_at, last_seen_job_id
+		)
+		SELECT environment, detector_type, issue_key, MIN(facility), 'open', NOW(), $2, NOW(), $2
+		FROM detected_issues
+		WHERE environment = $1 AND job_id = $2
+		GROUP BY environment, detector_type, issue_key
+		ON CONFLICT (environment, detector_type, issue_key) DO UPDATE
+		SET status = 'open',
+			first_seen_at = NOW(),
+			first_seen_job_id = EXCLUDED.first_seen_job_id,
+			last_seen_at = NOW(),
+			last_seen_job_id = EXCLUDED.last_seen_job_id,
+			resolved_at = NULL,
+			resolved_job_id = NULL
+		WHERE issue_lifecycle.status = 'resolved'
+	`, environment, jobID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to record new issues: %w", err)
+	}
+	newIssues, _ := result.RowsAffected()
+	summary.NewIssues = int(newIssues)
+
+	if _, err := tx.ExecContext(ctx, `
+		UPDATE issue_detection_jobs
+		SET new_issues = $1,
+			persisting_issues = $2,
+			resolved_issues = $3,
+			updated_at = NOW()
+		WHERE job_id = $4
+	`, summary.NewIssues, summary.PersistingIssues, summary.ResolvedIssues, jobID); err != nil {
+		return nil, fmt.Errorf("failed to store lifecycle summary: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit issue lifecycle: %w", err)
+	}
+
+	return summary, nil
+}
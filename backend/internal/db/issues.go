@@ -27,7 +27,7 @@ type IssueDetectionJob struct {
 // DetectedIssue represents a detected issue
 type DetectedIssue struct {
 	ID                    int64          `json:"id"`
-	Environment           string         `json:"environment"` // M3 environment (TRN or PRD)
+	Environment           string         `json:"environment"` // M3 environment key (e.g. TRN, PRD)
 	JobID                 string         `json:"job_id"`
 	DetectorType          string         `json:"detector_type"`
 	DetectedAt            sql.NullTime   `json:"detected_at"`
@@ -43,6 +43,9 @@ type DetectedIssue struct {
 	CreatedAt             sql.NullTime   `json:"created_at"`
 	IsIgnored             bool           `json:"is_ignored"`
 	MOTypeDescription     sql.NullString `json:"mo_type_description"`
+	FirstSeenAt           sql.NullTime   `json:"first_seen_at"`
+	LastSeenAt            sql.NullTime   `json:"last_seen_at"`
+	IsNew                 bool           `json:"is_new"` // not open in the previous refresh
 }
 
 // CreateIssueDetectionJob creates a new detection job
@@ -118,6 +121,14 @@ func (q *Queries) ClearIssuesForJob(ctx context.Context, jobID string) error {
 	return err
 }
 
+// PruneIssuesExceptJob removes an environment's issues from every job other than the given one
+// Called once a refresh completes, so the previous job's issues stay visible while it runs
+func (q *Queries) PruneIssuesExceptJob(ctx context.Context, environment, jobID string) error {
+	query := `DELETE FROM detected_issues WHERE environment = $1 AND job_id <> $2`
+	_, err := q.db.ExecContext(ctx, query, environment, jobID)
+	return err
+}
+
 // ClearIssuesForDetector removes issues for a specific detector and job
 func (q *Queries) ClearIssuesForDetector(ctx context.Context, jobID, detectorType string) error {
 	query := `
@@ -139,6 +150,7 @@ func (q *Queries) GetIssuesByDetectorType(ctx context.Context, environment, dete
 		AND job_id = (
 			SELECT id FROM refresh_jobs
 			WHERE environment = $1
+			  AND status = 'completed'
 			ORDER BY created_at DESC
 			LIMIT 1
 		)
@@ -183,6 +195,7 @@ func (q *Queries) GetIssuesByFacility(ctx context.Context, environment, facility
 		AND job_id = (
 			SELECT id FROM refresh_jobs
 			WHERE environment = $1
+			  AND status = 'completed'
 			ORDER BY created_at DESC
 			LIMIT 1
 		)
@@ -217,13 +230,16 @@ func (q *Queries) GetIssuesByFacility(ctx context.Context, environment, facility
 }
 
 // GetIssuesFiltered gets issues with optional filters for a specific environment
-func (q *Queries) GetIssuesFiltered(ctx context.Context, environment, detectorType, facility, warehouse string, includeIgnored bool, limit, offset int) ([]*DetectedIssue, error) {
+// newOnly restricts results to issues that were not open in the previous refresh
+func (q *Queries) GetIssuesFiltered(ctx context.Context, environment, detectorType, facility, warehouse string, includeIgnored, newOnly bool, limit, offset int) ([]*DetectedIssue, error) {
 	query := `
 		SELECT di.id, di.job_id, di.detector_type, di.detected_at, di.facility, di.warehouse,
 			   di.issue_key, di.production_order_number, di.production_order_type,
 			   di.co_number, di.co_line, di.co_suffix, di.issue_data, di.created_at,
 			   ig.id IS NOT NULL as is_ignored,
-			   mot.order_type_description as mo_type_description
+			   mot.order_type_description as mo_type_description,
+			   lc.first_seen_at, lc.last_seen_at,
+			   COALESCE(lc.first_seen_job_id = di.job_id, false) as is_new
 		FROM detected_issues di
 		LEFT JOIN ignored_issues ig
 			ON di.environment = ig.environment
@@ -231,6 +247,10 @@ func (q *Queries) GetIssuesFiltered(ctx context.Context, environment, detectorTy
 			AND di.detector_type = ig.detector_type
 			AND di.issue_key = ig.issue_key
 			AND di.production_order_number = ig.production_order_number
+		LEFT JOIN issue_lifecycle lc
+			ON lc.environment = di.environment
+			AND lc.detector_type = di.detector_type
+			AND lc.issue_key = di.issue_key
 		LEFT JOIN m3_manufacturing_order_types mot
 			ON mot.environment = di.environment
 			AND mot.order_type = di.issue_data->>'mo_type'
@@ -249,6 +269,7 @@ func (q *Queries) GetIssuesFiltered(ctx context.Context, environment, detectorTy
 		AND di.job_id = (
 			SELECT id FROM refresh_jobs
 			WHERE environment = $1
+			  AND status = 'completed'
 			ORDER BY created_at DESC
 			LIMIT 1
 		)
@@ -280,6 +301,10 @@ func (q *Queries) GetIssuesFiltered(ctx context.Context, environment, detectorTy
 		query += " AND ig.id IS NULL"
 	}
 
+	if newOnly {
+		query += " AND lc.first_seen_job_id = di.job_id"
+	}
+
 	query += fmt.Sprintf(" ORDER BY di.detected_at DESC OFFSET $%d LIMIT $%d", argNum, argNum+1)
 	args = append(args, offset, limit)
 
@@ -300,6 +325,8 @@ func (q *Queries) GetIssuesFiltered(ctx context.Context, environment, detectorTy
 			&issue.IssueData, &issue.CreatedAt,
 			&issue.IsIgnored,
 			&issue.MOTypeDescription,
+			&issue.FirstSeenAt, &issue.LastSeenAt,
+			&issue.IsNew,
 		)
 		if err != nil {
 			return nil, err
@@ -321,6 +348,7 @@ func (q *Queries) GetRecentIssues(ctx context.Context, environment string, limit
 		AND job_id = (
 			SELECT id FROM refresh_jobs
 			WHERE environment = $1
+			  AND status = 'completed'
 			ORDER BY created_at DESC
 			LIMIT 1
 		)
@@ -362,6 +390,7 @@ func (q *Queries) GetIssueCountForLatestJob(ctx context.Context, environment str
 		AND job_id = (
 			SELECT id FROM refresh_jobs
 			WHERE environment = $1
+			  AND status = 'completed'
 			ORDER BY created_at DESC
 			LIMIT 1
 		)
@@ -376,7 +405,7 @@ func (q *Queries) GetIssueCountForLatestJob(ctx context.Context, environment str
 }
 
 // GetIssuesFilteredCount gets the total count of issues matching the filters for a specific environment
-func (q *Queries) GetIssuesFilteredCount(ctx context.Context, environment, detectorType, facility, warehouse string, includeIgnored bool) (int, error) {
+func (q *Queries) GetIssuesFilteredCount(ctx context.Context, environment, detectorType, facility, warehouse string, includeIgnored, newOnly bool) (int, error) {
 	query := `
 		SELECT COUNT(*)
 		FROM detected_issues di
@@ -386,6 +415,10 @@ func (q *Queries) GetIssuesFilteredCount(ctx context.Context, environment, detec
 			AND di.detector_type = ig.detector_type
 			AND di.issue_key = ig.issue_key
 			AND di.production_order_number = ig.production_order_number
+		LEFT JOIN issue_lifecycle lc
+			ON lc.environment = di.environment
+			AND lc.detector_type = di.detector_type
+			AND lc.issue_key = di.issue_key
 		LEFT JOIN planned_manufacturing_orders mop
 			ON di.environment = mop.environment
 			AND di.production_order_type = 'MOP'
@@ -400,6 +433,7 @@ func (q *Queries) GetIssuesFilteredCount(ctx context.Context, environment, detec
 		AND di.job_id = (
 			SELECT id FROM refresh_jobs
 			WHERE environment = $1
+			  AND status = 'completed'
 			ORDER BY created_at DESC
 			LIMIT 1
 		)
@@ -431,6 +465,10 @@ func (q *Queries) GetIssuesFilteredCount(ctx context.Context, environment, detec
 		query += " AND ig.id IS NULL"
 	}
 
+	if newOnly {
+		query += " AND lc.first_seen_job_id = di.job_id"
+	}
+
 	var count int
 	err := q.db.QueryRowContext(ctx, query, args...).Scan(&count)
 	if err == sql.ErrNoRows {
@@ -472,6 +510,7 @@ func (q *Queries) GetIssueSummary(ctx context.Context, environment string, inclu
 		AND di.job_id = (
 			SELECT id FROM refresh_jobs
 			WHERE environment = $1
+			  AND status = 'completed'
 			ORDER BY created_at DESC
 			LIMIT 1
 		)
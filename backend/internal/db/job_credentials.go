@@ -0,0 +1,166 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+)
+
+// Job credential sources
+const (
+	JobCredentialSourceUser           = "user"
+	JobCredentialSourceServiceAccount = "service_account"
+)
+
+// JobCredential is a job-scoped credential reference held by the credential broker
+// Token columns hold ciphertext; encryption is the broker's concern
+type JobCredential struct {
+	ReferenceHash string
+	JobID         string
+	Environment   string
+	UserID        sql.NullString
+	Source        string
+	AccessToken   sql.NullString
+	RefreshToken  sql.NullString
+	TokenExpiry   sql.NullTime
+	ExpiresAt     time.Time
+	CreatedAt     time.Time
+	UpdatedAt     time.Time
+
+	// Joined from refresh_jobs
+	JobStatus string
+}
+
+// JobCredentialTokens is the (encrypted) token set written back after a refresh
+type JobCredentialTokens struct {
+	AccessToken  string
+	RefreshToken string
+	TokenExpiry  time.Time
+}
+
+const jobCredentialColumns = `
+	jc.reference_hash, jc.job_id, jc.environment, jc.user_id, jc.source,
+	jc.access_token, jc.refresh_token, jc.token_expiry,
+	jc.expires_at, jc.created_at, jc.updated_at,
+	rj.status
+`
+
+// CreateJobCredential stores a new credential reference for a refresh job
+func (q *Queries) CreateJobCredential(ctx context.Context, cred *JobCredential) error {
+	query := `
+		INSERT INTO job_credentials (
+			reference_hash, job_id, environment, user_id, source,
+			access_token, refresh_token, token_expiry, expires_at
+		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+	`
+	_, err := q.db.ExecContext(ctx, query,
+		cred.ReferenceHash, cred.JobID, cred.Environment, cred.UserID, cred.Source,
+		cred.AccessToken, cred.RefreshToken, cred.TokenExpiry, cred.ExpiresAt,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to create job credential: %w", err)
+	}
+	return nil
+}
+
+// GetJobCredential retrieves a credential reference by its hash, with its job's current status
+// Returns nil if the reference does not exist
+func (q *Queries) GetJobCredential(ctx context.Context, referenceHash string) (*JobCredential, error) {
+	query := `
+		SELECT ` + jobCredentialColumns + `
+		FROM job_credentials jc
+		JOIN refresh_jobs rj ON rj.id = jc.job_id
+		WHERE jc.reference_hash = $1
+	`
+	cred, err := scanJobCredential(q.db.QueryRowContext(ctx, query, referenceHash))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get job credential: %w", err)
+	}
+	return cred, nil
+}
+
+// RefreshJobCredential locks a credential reference and lets refresh replace its tokens
+// The row lock serializes workers of the same job, so a refresh token is only redeemed once;
+// refresh sees the tokens as stored after the lock and returns nil to keep them unchanged
+func (q *Queries) RefreshJobCredential(ctx context.Context, referenceHash string, refresh func(*JobCredential) (*JobCredentialTokens, error)) (*JobCredential, error) {
+	tx, err := q.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	query := `
+		SELECT ` + jobCredentialColumns + `
+		FROM job_credentials jc
+		JOIN refresh_jobs rj ON rj.id = jc.job_id
+		WHERE jc.reference_hash = $1
+		FOR UPDATE OF jc
+	`
+	cred, err := scanJobCredential(tx.QueryRowContext(ctx, query, referenceHash))
+	if err == sql.ErrNoRows {
+		return nil, fmt.Errorf("job credential not found")
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to lock job credential: %w", err)
+	}
+
+	tokens, err := refresh(cred)
+	if err != nil {
+		return nil, err
+	}
+	if tokens == nil {
+		return cred, nil
+	}
+
+	_, err = tx.ExecContext(ctx, `
+		UPDATE job_credentials
+		SET access_token = $1, refresh_token = $2, token_expiry = $3, updated_at = NOW()
+		WHERE reference_hash = $4
+	`, tokens.AccessToken, tokens.RefreshToken, tokens.TokenExpiry, referenceHash)
+	if err != nil {
+		return nil, fmt.Errorf("failed to update job credential: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit job credential: %w", err)
+	}
+
+	cred.AccessToken = sql.NullString{String: tokens.AccessToken, Valid: true}
+	cred.RefreshToken = sql.NullString{String: tokens.RefreshToken, Valid: true}
+	cred.TokenExpiry = sql.NullTime{Time: tokens.TokenExpiry, Valid: true}
+	return cred, nil
+}
+
+// DeleteExpiredJobCredentials removes references that expired or whose job has finished
+func (q *Queries) DeleteExpiredJobCredentials(ctx context.Context) (int64, error) {
+	query := `
+		DELETE FROM job_credentials jc
+		USING refresh_jobs rj
+		WHERE rj.id = jc.job_id
+		  AND (jc.expires_at < NOW() OR rj.status IN ('completed', 'failed', 'cancelled'))
+	`
+	result, err := q.db.ExecContext(ctx, query)
+	if err != nil {
+		return 0, fmt.Errorf("failed to delete expired job credentials: %w", err)
+	}
+	return result.RowsAffected()
+}
+
+// scanJobCredential scans one row selected with jobCredentialColumns
+func scanJobCredential(row *sql.Row) (*JobCredential, error) {
+	cred := &JobCredential{}
+	err := row.Scan(
+		&cred.ReferenceHash, &cred.JobID, &cred.Environment, &cred.UserID, &cred.Source,
+		&cred.AccessToken, &cred.RefreshToken, &cred.TokenExpiry,
+		&cred.ExpiresAt, &cred.CreatedAt, &cred.UpdatedAt,
+		&cred.JobStatus,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return cred, nil
+}
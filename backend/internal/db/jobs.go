@@ -311,6 +311,50 @@ func (q *Queries) GetLatestSnapshotRefreshJob(ctx context.Context, environment s
 	return job, nil
 }
 
+// GetPreviousSnapshotRefreshJob gets the snapshot_refresh job that ran before the given job
+// Returns nil if the given job is the first snapshot refresh for the environment
+func (q *Queries) GetPreviousSnapshotRefreshJob(ctx context.Context, environment, jobID string) (*RefreshJob, error) {
+	query := `
+		SELECT
+			id, environment, job_type, user_id, status,
+			current_step, total_steps, completed_steps, progress_percentage,
+			co_lines_processed, mos_processed, mops_processed,
+			records_per_second, estimated_seconds_remaining,
+			current_operation, current_batch, total_batches,
+			started_at, completed_at, duration_seconds,
+			error_message, retry_count, max_retries,
+			created_at, updated_at
+		FROM refresh_jobs
+		WHERE environment = $1
+		  AND job_type = 'snapshot_refresh'
+		  AND id <> $2
+		  AND created_at < (SELECT created_at FROM refresh_jobs WHERE id = $2)
+		ORDER BY created_at DESC
+		LIMIT 1
+	`
+
+	job := &RefreshJob{}
+	err := q.db.QueryRowContext(ctx, query, environment, jobID).Scan(
+		&job.ID, &job.Environment, &job.JobType, &job.UserID, &job.Status,
+		&job.CurrentStep, &job.TotalSteps, &job.CompletedSteps, &job.ProgressPct,
+		&job.COLinesProcessed, &job.MOsProcessed, &job.MOPsProcessed,
+		&job.RecordsPerSecond, &job.EstimatedSecondsRemaining,
+		&job.CurrentOperation, &job.CurrentBatch, &job.TotalBatches,
+		&job.StartedAt, &job.CompletedAt, &job.DurationSeconds,
+		&job.ErrorMessage, &job.RetryCount, &job.MaxRetries,
+		&job.CreatedAt, &job.UpdatedAt,
+	)
+
+	if err == sql.ErrNoRows {
+		return nil, nil // No earlier snapshot refresh job
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get previous snapshot refresh job: %w", err)
+	}
+
+	return job, nil
+}
+
 // GetActiveRefreshJob gets the currently running or pending refresh job for an environment
 // Returns nil if no active job exists
 func (q *Queries) GetActiveRefreshJob(ctx context.Context, environment string) (*RefreshJob, error) {
@@ -394,33 +438,53 @@ func (q *Queries) GetLatestRefreshJobByEnvironment(ctx context.Context, environm
 	return job, nil
 }
 
-// GetRefreshJobContext gets company and facility from the data loaded by a refresh job
-func (q *Queries) GetRefreshJobContext(ctx context.Context, jobID string) (company, facility string, err error) {
-	// Get company and facility from production_orders for this refresh job's environment
+// GetRefreshJobContext gets company and the facilities from the data loaded by a refresh job
+// A multi-facility refresh returns every facility it loaded, sorted
+func (q *Queries) GetRefreshJobContext(ctx context.Context, jobID string) (company string, facilities []string, err error) {
+	// Get company and facilities from production_orders for this refresh job's environment
 	query := `
 		SELECT DISTINCT po.cono, po.faci
 		FROM production_orders po
 		WHERE po.environment = (
 			SELECT environment FROM refresh_jobs WHERE id = $1
 		)
-		LIMIT 1
+		ORDER BY po.cono, po.faci
 	`
 
-	err = q.db.QueryRowContext(ctx, query, jobID).Scan(&company, &facility)
-	if err == sql.ErrNoRows {
+	rows, err := q.db.QueryContext(ctx, query, jobID)
+	if err != nil {
+		return "", nil, fmt.Errorf("failed to get refresh job context: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var cono, faci string
+		if err := rows.Scan(&cono, &faci); err != nil {
+			return "", nil, fmt.Errorf("failed to scan refresh job context: %w", err)
+		}
+		if company == "" {
+			company = cono
+		}
+		// A snapshot holds a single company; ignore stray rows from any other
+		if cono == company {
+			facilities = append(facilities, faci)
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return "", nil, fmt.Errorf("failed to get refresh job context: %w", err)
+	}
+
+	if len(facilities) == 0 {
 		// Get environment from refresh job for better error message
 		var env string
 		q.db.QueryRowContext(ctx, "SELECT environment FROM refresh_jobs WHERE id = $1", jobID).Scan(&env)
 		if env != "" {
-			return "", "", fmt.Errorf("no production orders found for environment %s (refresh job %s) - run data refresh first", env, jobID)
+			return "", nil, fmt.Errorf("no production orders found for environment %s (refresh job %s) - run data refresh first", env, jobID)
 		}
-		return "", "", fmt.Errorf("no production orders found for refresh job %s", jobID)
-	}
-	if err != nil {
-		return "", "", fmt.Errorf("failed to get refresh job context: %w", err)
+		return "", nil, fmt.Errorf("no production orders found for refresh job %s", jobID)
 	}
 
-	return company, facility, nil
+	return company, facilities, nil
 }
 
 // CreateManualDetectionJob creates a manual detection job in both tables atomically
@@ -4,12 +4,13 @@ import (
 	"context"
 	"database/sql"
 	"fmt"
+	"strings"
 )
 
 // ManufacturingOrder represents a manufacturing order record - all M3 fields as strings
 type ManufacturingOrder struct {
 	ID             int64
-	Environment    string // M3 environment (TRN or PRD)
+	Environment    string // M3 environment key (e.g. TRN, PRD)
 
 	// M3 Core Identifiers
 	CONO           string
@@ -55,7 +56,7 @@ type ManufacturingOrder struct {
 	WHLO           string
 	WHSL           string
 	BANO           string
-	PendingPutawayQty string // Pending putaway quantity from MPTAWY.TRQT
+	PendingPutawayQty string // Reported but not yet received quantity (MAQT - RVQT)
 
 	// M3 Reference Orders
 	RORC           string
@@ -126,6 +127,29 @@ type ManufacturingOrder struct {
 	SyncTime       sql.NullTime
 }
 
+// manufacturingOrderColumns are the manufacturing_orders columns loaded by BatchInsertManufacturingOrders, in copy order
+var manufacturingOrderColumns = []string{
+	"environment",
+	"cono", "divi", "faci", "mfno", "prno", "itno",
+	"whst", "whhs", "wmst", "mohs",
+	"orqt", "maqt", "orqa", "rvqt", "rvqa", "maqa",
+	"stdt", "fidt", "msti", "mfti", "fstd", "ffid", "rsdt", "refd", "rpdt",
+	"prio", "resp", "plgr", "wcln", "prdy",
+	"whlo", "whsl", "bano", "pending_putaway_qty",
+	"rorc", "rorn", "rorl", "rorx",
+	"prhl", "mfhl", "prlo", "mflo", "levl",
+	"cfin", "atnr",
+	"orty", "getp",
+	"bdcd", "scex", "strt", "ecve",
+	"aoid", "nuop", "nufo",
+	"actp", "txt1", "txt2",
+	"proj", "elno",
+	"rgdt", "rgtm", "lmdt", "lmts", "chno", "chid",
+	"m3_timestamp",
+	"linked_co_number", "linked_co_line", "linked_co_suffix", "allocated_qty",
+	"item_type", "item_description", "item_group", "product_group", "procurement_group", "group_technology_class",
+}
+
 // BatchInsertManufacturingOrders inserts multiple MOs efficiently with all M3 fields
 func (q *Queries) BatchInsertManufacturingOrders(ctx context.Context, orders []*ManufacturingOrder, progressCallback InsertProgressCallback) error {
 	if len(orders) == 0 {
@@ -138,50 +162,42 @@ func (q *Queries) BatchInsertManufacturingOrders(ctx context.Context, orders []*
 	}
 	defer tx.Rollback()
 
-	stmt, err := tx.PrepareContext(ctx, `
-		INSERT INTO manufacturing_orders (
-			environment,
-			cono, divi, faci, mfno, prno, itno,
-			whst, whhs, wmst, mohs,
-			orqt, maqt, orqa, rvqt, rvqa, maqa,
-			stdt, fidt, msti, mfti, fstd, ffid, rsdt, refd, rpdt,
-			prio, resp, plgr, wcln, prdy,
-			whlo, whsl, bano, pending_putaway_qty,
-			rorc, rorn, rorl, rorx,
-			prhl, mfhl, prlo, mflo, levl,
-			cfin, atnr,
-			orty, getp,
-			bdcd, scex, strt, ecve,
-			aoid, nuop, nufo,
-			actp, txt1, txt2,
-			proj, elno,
-			rgdt, rgtm, lmdt, lmts, chno, chid,
-			m3_timestamp,
-			linked_co_number, linked_co_line, linked_co_suffix, allocated_qty,
-			item_type, item_description, item_group, product_group, procurement_group, group_technology_class,
-			sync_timestamp
-		) VALUES (
-			$1,
-			$2, $3, $4, $5, $6, $7,
-			$8, $9, $10, $11,
-			$12, $13, $14, $15, $16, $17,
-			$18, $19, $20, $21, $22, $23, $24, $25, $26,
-			$27, $28, $29, $30, $31,
-			$32, $33, $34, $35,
-			$36, $37, $38, $39,
-			$40, $41, $42, $43, $44,
-			$45, $46,
-			$47, $48,
-			$49, $50, $51, $52,
-			$53, $54, $55,
-			$56, $57, $58,
-			$59, $60,
-			$61, $62, $63, $64, $65, $66,
-			$67,
-			$68, $69, $70, $71,
-			$72, $73, $74, $75, $76, $77,
-			NOW()
-		)
+	// COPY the batch into a temp table, then upsert it into the snapshot table in one statement
+	target := q.snapshotTable("manufacturing_orders")
+	if err := copyIntoTempTable(ctx, tx, target, "manufacturing_orders_copy", manufacturingOrderColumns, len(orders), func(i int) []interface{} {
+		mo := orders[i]
+		return []interface{}{
+			mo.Environment,
+			mo.CONO, mo.DIVI, mo.FACI, mo.MFNO, mo.PRNO, mo.ITNO,
+			mo.WHST, mo.WHHS, mo.WMST, mo.MOHS,
+			mo.ORQT, mo.MAQT, mo.ORQA, mo.RVQT, mo.RVQA, mo.MAQA,
+			mo.STDT, mo.FIDT, mo.MSTI, mo.MFTI, mo.FSTD, mo.FFID, mo.RSDT, mo.REFD, mo.RPDT,
+			mo.PRIO, mo.RESP, mo.PLGR, mo.WCLN, mo.PRDY,
+			mo.WHLO, mo.WHSL, mo.BANO, mo.PendingPutawayQty,
+			mo.RORC, mo.RORN, mo.RORL, mo.RORX,
+			mo.PRHL, mo.MFHL, mo.PRLO, mo.MFLO, mo.LEVL,
+			mo.CFIN, mo.ATNR,
+			mo.ORTY, mo.GETP,
+			mo.BDCD, mo.SCEX, mo.STRT, mo.ECVE,
+			mo.AOID, mo.NUOP, mo.NUFO,
+			mo.ACTP, mo.TXT1, mo.TXT2,
+			mo.PROJ, mo.ELNO,
+			mo.RGDT, mo.RGTM, mo.LMDT, mo.LMTS, mo.CHNO, mo.CHID,
+			mo.M3Timestamp,
+			mo.LinkedCONumber, mo.LinkedCOLine, mo.LinkedCOSuffix, mo.AllocatedQty,
+			mo.ItemType, mo.ItemDescription, mo.ItemGroup, mo.ProductGroup, mo.ProcurementGroup, mo.GroupTechnologyClass,
+		}
+	}, progressCallback); err != nil {
+		return err
+	}
+
+	// DISTINCT ON keeps the last row when the batch holds the same key twice
+	columns := strings.Join(manufacturingOrderColumns, ", ")
+	_, err = tx.ExecContext(ctx, fmt.Sprintf(`
+		INSERT INTO %s (%s, sync_timestamp)
+		SELECT DISTINCT ON (environment, faci, mfno) %s, NOW()
+		FROM manufacturing_orders_copy
+		ORDER BY environment, faci, mfno, copy_seq DESC
 		ON CONFLICT (environment, faci, mfno)
 		DO UPDATE SET
 			whst = EXCLUDED.whst,
@@ -256,43 +272,9 @@ func (q *Queries) BatchInsertManufacturingOrders(ctx context.Context, orders []*
 			group_technology_class = EXCLUDED.group_technology_class,
 			sync_timestamp = NOW(),
 			updated_at = NOW()
-	`)
+	`, target, columns, columns))
 	if err != nil {
-		return fmt.Errorf("failed to prepare statement: %w", err)
-	}
-	defer stmt.Close()
-
-	const insertProgressInterval = 10000
-	for i, mo := range orders {
-		_, err = stmt.ExecContext(ctx,
-			mo.Environment,
-			mo.CONO, mo.DIVI, mo.FACI, mo.MFNO, mo.PRNO, mo.ITNO,
-			mo.WHST, mo.WHHS, mo.WMST, mo.MOHS,
-			mo.ORQT, mo.MAQT, mo.ORQA, mo.RVQT, mo.RVQA, mo.MAQA,
-			mo.STDT, mo.FIDT, mo.MSTI, mo.MFTI, mo.FSTD, mo.FFID, mo.RSDT, mo.REFD, mo.RPDT,
-			mo.PRIO, mo.RESP, mo.PLGR, mo.WCLN, mo.PRDY,
-			mo.WHLO, mo.WHSL, mo.BANO, mo.PendingPutawayQty,
-			mo.RORC, mo.RORN, mo.RORL, mo.RORX,
-			mo.PRHL, mo.MFHL, mo.PRLO, mo.MFLO, mo.LEVL,
-			mo.CFIN, mo.ATNR,
-			mo.ORTY, mo.GETP,
-			mo.BDCD, mo.SCEX, mo.STRT, mo.ECVE,
-			mo.AOID, mo.NUOP, mo.NUFO,
-			mo.ACTP, mo.TXT1, mo.TXT2,
-			mo.PROJ, mo.ELNO,
-			mo.RGDT, mo.RGTM, mo.LMDT, mo.LMTS, mo.CHNO, mo.CHID,
-			mo.M3Timestamp,
-			mo.LinkedCONumber, mo.LinkedCOLine, mo.LinkedCOSuffix, mo.AllocatedQty,
-			mo.ItemType, mo.ItemDescription, mo.ItemGroup, mo.ProductGroup, mo.ProcurementGroup, mo.GroupTechnologyClass,
-		)
-		if err != nil {
-			return fmt.Errorf("failed to insert MO %s: %w", mo.MFNO, err)
-		}
-
-		// Report insertion progress every N records
-		if progressCallback != nil && ((i+1)%insertProgressInterval == 0 || (i+1) == len(orders)) {
-			progressCallback(i+1, len(orders))
-		}
+		return fmt.Errorf("failed to upsert MOs: %w", err)
 	}
 
 	return tx.Commit()
@@ -300,8 +282,8 @@ func (q *Queries) BatchInsertManufacturingOrders(ctx context.Context, orders []*
 
 // UpdateProductionOrdersFromMOs updates the production_orders unified view from MOs
 func (q *Queries) UpdateProductionOrdersFromMOs(ctx context.Context) error {
-	query := `
-		INSERT INTO production_orders (
+	query := fmt.Sprintf(`
+		INSERT INTO %s (
 			environment, order_type, order_number,
 			cono, divi, faci,
 			prno, itno,
@@ -340,7 +322,7 @@ func (q *Queries) UpdateProductionOrdersFromMOs(ctx context.Context) error {
 			mo.linked_co_number, mo.linked_co_line, mo.linked_co_suffix, mo.allocated_qty,
 			mo.orty,
 			mo.id, NOW(), mo.deleted_remotely
-		FROM manufacturing_orders mo
+		FROM %s mo
 		ORDER BY mo.environment, mo.mfno,
 		         CASE WHEN mo.lmdt = '' THEN '99999999' ELSE mo.lmdt END DESC,
 		         mo.id DESC
@@ -377,7 +359,7 @@ func (q *Queries) UpdateProductionOrdersFromMOs(ctx context.Context) error {
 			deleted_remotely = EXCLUDED.deleted_remotely,
 			sync_timestamp = NOW(),
 			updated_at = NOW()
-	`
+	`, q.snapshotTable("production_orders"), q.snapshotTable("manufacturing_orders"))
 
 	_, err := q.db.ExecContext(ctx, query)
 	return err
@@ -393,3 +375,49 @@ func (q *Queries) MarkMOAsDeletedRemotely(ctx context.Context, mfno string, faci
 	_, err := q.db.ExecContext(ctx, query, mfno, facility)
 	return err
 }
+
+// ManufacturingOrderKey identifies an MO for reconciliation deletes
+type ManufacturingOrderKey struct {
+	FACI, MFNO string
+}
+
+// DeleteManufacturingOrders removes MOs that were deleted or progressed past the snapshot scope in M3
+// Matching production_orders rows are removed via ON DELETE CASCADE on mo_id
+// Used by incremental refresh; full refresh truncates instead
+func (q *Queries) DeleteManufacturingOrders(ctx context.Context, environment string, keys []ManufacturingOrderKey) (int, error) {
+	if len(keys) == 0 {
+		return 0, nil
+	}
+
+	tx, err := q.db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	stmt, err := tx.PrepareContext(ctx, fmt.Sprintf(`
+		DELETE FROM %s
+		WHERE environment = $1 AND faci = $2 AND mfno = $3
+	`, q.snapshotTable("manufacturing_orders")))
+	if err != nil {
+		return 0, fmt.Errorf("failed to prepare statement: %w", err)
+	}
+	defer stmt.Close()
+
+	deleted := 0
+	for _, key := range keys {
+		result, err := stmt.ExecContext(ctx, environment, key.FACI, key.MFNO)
+		if err != nil {
+			return 0, fmt.Errorf("failed to delete MO %s: %w", key.MFNO, err)
+		}
+		if n, err := result.RowsAffected(); err == nil {
+			deleted += int(n)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, fmt.Errorf("failed to commit delete transaction: %w", err)
+	}
+
+	return deleted, nil
+}
@@ -0,0 +1,239 @@
+package db
+
+import (
+	"context"
+	"fmt"
+	"strings"
+)
+
+// MOMaterial represents an MO material line (MWOMAT) - all M3 fields as strings
+type MOMaterial struct {
+	Environment string
+	CONO        string
+	FACI        string
+	MFNO        string
+	PRNO        string
+	MSEQ        string
+	MTNO        string
+	WHLO        string
+	OPNO        string
+	WMST        string
+	SPMT        string
+	PEUN        string
+	REQT        string
+	RPQT        string
+	ALQT        string
+	RDAT        string
+	LMDT        string
+	M3Timestamp string
+}
+
+// ProductStructureMaterial represents a product structure component (MPDMAT) - all M3 fields as strings
+type ProductStructureMaterial struct {
+	Environment string
+	CONO        string
+	FACI        string
+	PRNO        string
+	STRT        string
+	MSEQ        string
+	MTNO        string
+	OPNO        string
+	PEUN        string
+	CNQT        string
+	WAPC        string
+	FDAT        string
+	TDAT        string
+	LMDT        string
+	M3Timestamp string
+}
+
+// ItemBalance represents a warehouse item balance (MITBAL) - all M3 fields as strings
+type ItemBalance struct {
+	Environment string
+	CONO        string
+	FACI        string
+	WHLO        string
+	ITNO        string
+	STQT        string
+	ALQT        string
+	QUQT        string
+	RJQT        string
+	LMDT        string
+	M3Timestamp string
+}
+
+// ItemLocation represents stock on a location and lot (MITLOC) - all M3 fields as strings
+type ItemLocation struct {
+	Environment string
+	CONO        string
+	FACI        string
+	WHLO        string
+	ITNO        string
+	WHSL        string
+	BANO        string
+	CAMU        string
+	STAS        string
+	STQT        string
+	ALQT        string
+	LMDT        string
+	M3Timestamp string
+}
+
+// Material snapshot tables, loaded per facility by the materials data job
+const (
+	MOMaterialsTable       = "mo_materials"
+	ProductStructuresTable = "product_structures"
+	ItemBalancesTable      = "item_balances"
+	ItemLocationsTable     = "item_locations"
+)
+
+var moMaterialColumns = []string{
+	"environment", "cono", "faci", "mfno", "prno", "mseq",
+	"mtno", "whlo", "opno", "wmst", "spmt", "peun",
+	"reqt", "rpqt", "alqt", "rdat",
+	"lmdt", "m3_timestamp",
+}
+
+var productStructureColumns = []string{
+	"environment", "cono", "faci", "prno", "strt", "mseq",
+	"mtno", "opno", "peun", "cnqt", "wapc", "fdat", "tdat",
+	"lmdt", "m3_timestamp",
+}
+
+var itemBalanceColumns = []string{
+	"environment", "cono", "faci", "whlo", "itno",
+	"stqt", "alqt", "quqt", "rjqt",
+	"lmdt", "m3_timestamp",
+}
+
+var itemLocationColumns = []string{
+	"environment", "cono", "faci", "whlo", "itno", "whsl", "bano", "camu",
+	"stas", "stqt", "alqt",
+	"lmdt", "m3_timestamp",
+}
+
+// BatchInsertMOMaterials upserts MO material lines
+func (q *Queries) BatchInsertMOMaterials(ctx context.Context, materials []*MOMaterial) error {
+	keys := []string{"environment", "cono", "faci", "mfno", "mseq"}
+	return q.upsertFacilityRows(ctx, MOMaterialsTable, moMaterialColumns, keys, len(materials), func(i int) []interface{} {
+		m := materials[i]
+		return []interface{}{
+			m.Environment, m.CONO, m.FACI, m.MFNO, m.PRNO, m.MSEQ,
+			m.MTNO, m.WHLO, m.OPNO, m.WMST, m.SPMT, m.PEUN,
+			m.REQT, m.RPQT, m.ALQT, m.RDAT,
+			m.LMDT, m.M3Timestamp,
+		}
+	})
+}
+
+// BatchInsertProductStructures upserts product structure components
+func (q *Queries) BatchInsertProductStructures(ctx context.Context, components []*ProductStructureMaterial) error {
+	keys := []string{"environment", "cono", "faci", "prno", "strt", "mseq", "fdat"}
+	return q.upsertFacilityRows(ctx, ProductStructuresTable, productStructureColumns, keys, len(components), func(i int) []interface{} {
+		c := components[i]
+		return []interface{}{
+			c.Environment, c.CONO, c.FACI, c.PRNO, c.STRT, c.MSEQ,
+			c.MTNO, c.OPNO, c.PEUN, c.CNQT, c.WAPC, c.FDAT, c.TDAT,
+			c.LMDT, c.M3Timestamp,
+		}
+	})
+}
+
+// BatchInsertItemBalances upserts warehouse item balances
+func (q *Queries) BatchInsertItemBalances(ctx context.Context, balances []*ItemBalance) error {
+	keys := []string{"environment", "cono", "whlo", "itno"}
+	return q.upsertFacilityRows(ctx, ItemBalancesTable, itemBalanceColumns, keys, len(balances), func(i int) []interface{} {
+		b := balances[i]
+		return []interface{}{
+			b.Environment, b.CONO, b.FACI, b.WHLO, b.ITNO,
+			b.STQT, b.ALQT, b.QUQT, b.RJQT,
+			b.LMDT, b.M3Timestamp,
+		}
+	})
+}
+
+// BatchInsertItemLocations upserts location and lot stock
+func (q *Queries) BatchInsertItemLocations(ctx context.Context, locations []*ItemLocation) error {
+	keys := []string{"environment", "cono", "whlo", "itno", "whsl", "bano", "camu"}
+	return q.upsertFacilityRows(ctx, ItemLocationsTable, itemLocationColumns, keys, len(locations), func(i int) []interface{} {
+		l := locations[i]
+		return []interface{}{
+			l.Environment, l.CONO, l.FACI, l.WHLO, l.ITNO, l.WHSL, l.BANO, l.CAMU,
+			l.STAS, l.STQT, l.ALQT,
+			l.LMDT, l.M3Timestamp,
+		}
+	})
+}
+
+// upsertFacilityRows COPYs a batch into a temp table and upserts it into a snapshot table
+// that is reloaded per facility, updating every non-key column on conflict
+func (q *Queries) upsertFacilityRows(ctx context.Context, table string, columns, keys []string, count int, row func(i int) []interface{}) error {
+	if count == 0 {
+		return nil
+	}
+
+	tx, err := q.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	target := q.snapshotTable(table)
+	temp := table + "_copy"
+	if err := copyIntoTempTable(ctx, tx, target, temp, columns, count, row, nil); err != nil {
+		return err
+	}
+
+	isKey := make(map[string]bool, len(keys))
+	for _, key := range keys {
+		isKey[key] = true
+	}
+	updates := make([]string, 0, len(columns))
+	for _, column := range columns {
+		if !isKey[column] {
+			updates = append(updates, fmt.Sprintf("%s = EXCLUDED.%s", column, column))
+		}
+	}
+
+	// DISTINCT ON keeps the last row when the batch holds the same key twice
+	columnList := strings.Join(columns, ", ")
+	keyList := strings.Join(keys, ", ")
+	_, err = tx.ExecContext(ctx, fmt.Sprintf(`
+		INSERT INTO %s (%s, sync_timestamp)
+		SELECT DISTINCT ON (%s) %s, NOW()
+		FROM %s
+		ORDER BY %s, copy_seq DESC
+		ON CONFLICT (%s)
+		DO UPDATE SET
+			%s,
+			sync_timestamp = NOW(),
+			updated_at = NOW()
+	`, target, columnList, keyList, columnList, temp, keyList, keyList, strings.Join(updates, ",\n\t\t\t")))
+	if err != nil {
+		return fmt.Errorf("failed to upsert %s: %w", table, err)
+	}
+
+	return tx.Commit()
+}
+
+// DeleteFacilityRows removes a facility's rows from a material, operation or supply chain
+// snapshot table before the facility is reloaded
+func (q *Queries) DeleteFacilityRows(ctx context.Context, table, environment, facility string) (int, error) {
+	switch table {
+	case MOMaterialsTable, ProductStructuresTable, ItemBalancesTable, ItemLocationsTable,
+		MOOperationsTable, ProductOperationsTable, WorkCentersTable,
+		SupplyChainLinksTable:
+	default:
+		return 0, fmt.Errorf("unknown facility snapshot table: %s", table)
+	}
+
+	result, err := q.db.ExecContext(ctx,
+		fmt.Sprintf("DELETE FROM %s WHERE environment = $1 AND faci = $2", q.snapshotTable(table)),
+		environment, facility)
+	if err != nil {
+		return 0, fmt.Errorf("failed to clear %s for facility %s: %w", table, facility, err)
+	}
+
+	rows, _ := result.RowsAffected()
+	return int(rows), nil
+}
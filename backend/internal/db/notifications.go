@@ -0,0 +1,346 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+)
+
+// NotificationSubscription is a user's subscription to outbound alerts
+type NotificationSubscription struct {
+	ID           int64          `json:"id"`
+	Environment  string         `json:"environment"`
+	UserID       string         `json:"userId"`
+	Channel      string         `json:"channel"`
+	Target       string         `json:"target"`
+	DetectorType sql.NullString `json:"-"` // NULL = all detectors
+	Facility     sql.NullString `json:"-"` // NULL = all facilities
+	MinSeverity  string         `json:"minSeverity"`
+	Delivery     string         `json:"delivery"` // "immediate" or "digest"
+	Enabled      bool           `json:"enabled"`
+	CreatedAt    time.Time      `json:"createdAt"`
+	UpdatedAt    time.Time      `json:"updatedAt"`
+}
+
+// NotificationSubscriptionParams holds the editable fields of a subscription
+type NotificationSubscriptionParams struct {
+	Channel      string
+	Target       string
+	DetectorType string // Empty = all detectors
+	Facility     string // Empty = all facilities
+	MinSeverity  string
+	Delivery     string
+	Enabled      bool
+}
+
+// NewIssueCount is the number of issues first seen in a job per detector and facility
+type NewIssueCount struct {
+	DetectorType string
+	Facility     string
+	Count        int
+}
+
+// NotificationDigestBatch is a subscription's claimed pending digest items
+type NotificationDigestBatch struct {
+	SubscriptionID int64
+	ItemIDs        []int64
+	Items          []string // JSONB arrays, one per queued job
+}
+
+const notificationSubscriptionColumns = `
+	id, environment, user_id, channel, target, detector_type, facility,
+	min_severity, delivery, enabled, created_at, updated_at
+`
+
+func scanNotificationSubscription(scanner interface{ Scan(...interface{}) error }) (*NotificationSubscription, error) {
+	sub := &NotificationSubscription{}
+	err := scanner.Scan(
+		&sub.ID, &sub.Environment, &sub.UserID, &sub.Channel, &sub.Target,
+		&sub.DetectorType, &sub.Facility, &sub.MinSeverity, &sub.Delivery,
+		&sub.Enabled, &sub.CreatedAt, &sub.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return sub, nil
+}
+
+func (q *Queries) queryNotificationSubscriptions(ctx context.Context, query string, args ...interface{}) ([]*NotificationSubscription, error) {
+	rows, err := q.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query notification subscriptions: %w", err)
+	}
+	defer rows.Close()
+
+	subscriptions := make([]*NotificationSubscription, 0)
+	for rows.Next() {
+		sub, err := scanNotificationSubscription(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan notification subscription: %w", err)
+		}
+		subscriptions = append(subscriptions, sub)
+	}
+
+	return subscriptions, rows.Err()
+}
+
+// ListNotificationSubscriptions returns a user's subscriptions in an environment
+func (q *Queries) ListNotificationSubscriptions(ctx context.Context, environment, userID string) ([]*NotificationSubscription, error) {
+	return q.queryNotificationSubscriptions(ctx, `
+		SELECT `+notificationSubscriptionColumns+`
+		FROM notification_subscriptions
+		WHERE environment = $1 AND user_id = $2
+		ORDER BY id
+	`, environment, userID)
+}
+
+// GetActiveNotificationSubscriptions returns all enabled subscriptions of an environment
+func (q *Queries) GetActiveNotificationSubscriptions(ctx context.Context, environment string) ([]*NotificationSubscription, error) {
+	return q.queryNotificationSubscriptions(ctx, `
+		SELECT `+notificationSubscriptionColumns+`
+		FROM notification_subscriptions
+		WHERE environment = $1 AND enabled
+		ORDER BY id
+	`, environment)
+}
+
+// GetNotificationSubscription returns a subscription by ID, or nil if not found
+func (q *Queries) GetNotificationSubscription(ctx context.Context, id int64) (*NotificationSubscription, error) {
+	row := q.db.QueryRowContext(ctx, `
+		SELECT `+notificationSubscriptionColumns+`
+		FROM notification_subscriptions
+		WHERE id = $1
+	`, id)
+
+	sub, err := scanNotificationSubscription(row)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get notification subscription: %w", err)
+	}
+	return sub, nil
+}
+
+// CreateNotificationSubscription inserts a subscription and returns it
+func (q *Queries) CreateNotificationSubscription(ctx context.Context, environment, userID string, params NotificationSubscriptionParams) (*NotificationSubscription, error) {
+	row := q.db.QueryRowContext(ctx, `
+		INSERT INTO notification_subscriptions (
+			environment, user_id, channel, target, detector_type, facility,
+			min_severity, delivery, enabled
+		) VALUES ($1, $2, $3, $4, NULLIF($5, ''), NULLIF($6, ''), $7, $8, $9)
+		RETURNING `+notificationSubscriptionColumns,
+		environment, userID, params.Channel, params.Target, params.DetectorType, params.Facility,
+		params.MinSeverity, params.Delivery, params.Enabled)
+
+	sub, err := scanNotificationSubscription(row)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create notification subscription: %w", err)
+	}
+	return sub, nil
+}
+
+// UpdateNotificationSubscription updates a user's subscription, returning nil if it does not exist
+func (q *Queries) UpdateNotificationSubscription(ctx context.Context, id int64, environment, userID string, params NotificationSubscriptionParams) (*NotificationSubscription, error) {
+	row := q.db.QueryRowContext(ctx, `
+		UPDATE notification_subscriptions
+		SET channel = $4,
+			target = $5,
+			detector_type = NULLIF($6, ''),
+			facility = NULLIF($7, ''),
+			min_severity = $8,
+			delivery = $9,
+			enabled = $10,
+			updated_at = NOW()
+		WHERE id = $1 AND environment = $2 AND user_id = $3
+		RETURNING `+notificationSubscriptionColumns,
+		id, environment, userID, params.Channel, params.Target, params.DetectorType, params.Facility,
+		params.MinSeverity, params.Delivery, params.Enabled)
+
+	sub, err := scanNotificationSubscription(row)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to update notification subscription: %w", err)
+	}
+	return sub, nil
+}
+
+// DeleteNotificationSubscription deletes a user's subscription, returning false if it does not exist
+func (q *Queries) DeleteNotificationSubscription(ctx context.Context, id int64, environment, userID string) (bool, error) {
+	result, err := q.db.ExecContext(ctx, `
+		DELETE FROM notification_subscriptions
+		WHERE id = $1 AND environment = $2 AND user_id = $3
+	`, id, environment, userID)
+	if err != nil {
+		return false, fmt.Errorf("failed to delete notification subscription: %w", err)
+	}
+
+	rows, _ := result.RowsAffected()
+	return rows > 0, nil
+}
+
+// GetNewAnomaliesForJob returns a job's anomaly alerts that the previous completed job did not raise
+// An alert is new when no alert with the same detector, entity and severity existed before,
+// so an escalation from warning to critical is reported again
+func (q *Queries) GetNewAnomaliesForJob(ctx context.Context, environment, jobID string) ([]*AnomalyAlert, error) {
+	rows, err := q.db.QueryContext(ctx, `
+		SELECT id, environment, job_id, detector_type, severity, entity_type, entity_id,
+		       message, metrics, affected_count, threshold_value, actual_value, status,
+		       detected_at, acknowledged_at, acknowledged_by, resolved_at, resolved_by,
+		       notes, created_at, updated_at
+		FROM anomaly_alerts a
+		WHERE a.environment = $1 AND a.job_id = $2
+		  AND NOT EXISTS (
+			SELECT 1 FROM anomaly_alerts p
+			WHERE p.environment = a.environment
+			  AND p.job_id = (
+				SELECT id FROM refresh_jobs
+				WHERE environment = $1 AND status = 'completed' AND id <> $2
+				ORDER BY created_at DESC
+				LIMIT 1
+			  )
+			  AND p.detector_type = a.detector_type
+			  AND p.severity = a.severity
+			  AND p.entity_type IS NOT DISTINCT FROM a.entity_type
+			  AND p.entity_id IS NOT DISTINCT FROM a.entity_id
+		  )
+		ORDER BY a.severity DESC, a.detector_type
+	`, environment, jobID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query anomalies for job: %w", err)
+	}
+	defer rows.Close()
+
+	anomalies := make([]*AnomalyAlert, 0)
+	for rows.Next() {
+		anomaly := &AnomalyAlert{}
+		err := rows.Scan(
+			&anomaly.ID, &anomaly.Environment, &anomaly.JobID, &anomaly.DetectorType,
+			&anomaly.Severity, &anomaly.EntityType, &anomaly.EntityID,
+			&anomaly.Message, &anomaly.Metrics, &anomaly.AffectedCount,
+			&anomaly.ThresholdValue, &anomaly.ActualValue, &anomaly.Status,
+			&anomaly.DetectedAt, &anomaly.AcknowledgedAt, &anomaly.AcknowledgedBy,
+			&anomaly.ResolvedAt, &anomaly.ResolvedBy, &anomaly.Notes,
+			&anomaly.CreatedAt, &anomaly.UpdatedAt,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan anomaly: %w", err)
+		}
+		anomalies = append(anomalies, anomaly)
+	}
+
+	return anomalies, rows.Err()
+}
+
+// GetNewIssueCountsForJob counts issues first seen in a job (see UpdateIssueLifecycle)
+func (q *Queries) GetNewIssueCountsForJob(ctx context.Context, environment, jobID string) ([]NewIssueCount, error) {
+	rows, err := q.db.QueryContext(ctx, `
+		SELECT detector_type, COALESCE(facility, ''), COUNT(*)
+		FROM issue_lifecycle
+		WHERE environment = $1 AND first_seen_job_id = $2 AND status = 'open'
+		GROUP BY detector_type, facility
+		ORDER BY detector_type, facility
+	`, environment, jobID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query new issue counts: %w", err)
+	}
+	defer rows.Close()
+
+	counts := make([]NewIssueCount, 0)
+	for rows.Next() {
+		var c NewIssueCount
+		if err := rows.Scan(&c.DetectorType, &c.Facility, &c.Count); err != nil {
+			return nil, fmt.Errorf("failed to scan new issue count: %w", err)
+		}
+		counts = append(counts, c)
+	}
+
+	return counts, rows.Err()
+}
+
+// EnqueueNotificationDigestItems queues a job's matching items (JSON array) for a digest subscription
+func (q *Queries) EnqueueNotificationDigestItems(ctx context.Context, subscriptionID int64, jobID, itemsJSON string) error {
+	_, err := q.db.ExecContext(ctx, `
+		INSERT INTO notification_digest_items (subscription_id, job_id, items)
+		VALUES ($1, $2, $3)
+	`, subscriptionID, jobID, itemsJSON)
+	if err != nil {
+		return fmt.Errorf("failed to enqueue digest items: %w", err)
+	}
+	return nil
+}
+
+// ClaimNotificationDigestItems marks an environment's pending digest items as sent and returns them
+// grouped by subscription. Claiming in one UPDATE keeps concurrent instances from sending twice;
+// call ReleaseNotificationDigestItems if delivery fails.
+func (q *Queries) ClaimNotificationDigestItems(ctx context.Context, environment string) ([]*NotificationDigestBatch, error) {
+	rows, err := q.db.QueryContext(ctx, `
+		UPDATE notification_digest_items i
+		SET sent_at = NOW()
+		FROM notification_subscriptions s
+		WHERE i.subscription_id = s.id
+		  AND s.environment = $1
+		  AND s.enabled
+		  AND i.sent_at IS NULL
+		RETURNING i.subscription_id, i.id, i.items
+	`, environment)
+	if err != nil {
+		return nil, fmt.Errorf("failed to claim digest items: %w", err)
+	}
+	defer rows.Close()
+
+	batches := make([]*NotificationDigestBatch, 0)
+	bySubscription := make(map[int64]*NotificationDigestBatch)
+	for rows.Next() {
+		var subscriptionID, itemID int64
+		var items string
+		if err := rows.Scan(&subscriptionID, &itemID, &items); err != nil {
+			return nil, fmt.Errorf("failed to scan digest item: %w", err)
+		}
+
+		batch, ok := bySubscription[subscriptionID]
+		if !ok {
+			batch = &NotificationDigestBatch{SubscriptionID: subscriptionID}
+			bySubscription[subscriptionID] = batch
+			batches = append(batches, batch)
+		}
+		batch.ItemIDs = append(batch.ItemIDs, itemID)
+		batch.Items = append(batch.Items, items)
+	}
+
+	return batches, rows.Err()
+}
+
+// ReleaseNotificationDigestItems returns claimed digest items to pending so the next digest retries them
+func (q *Queries) ReleaseNotificationDigestItems(ctx context.Context, itemIDs []int64) error {
+	for _, id := range itemIDs {
+		if _, err := q.db.ExecContext(ctx, `
+			UPDATE notification_digest_items SET sent_at = NULL WHERE id = $1
+		`, id); err != nil {
+			return fmt.Errorf("failed to release digest item %d: %w", id, err)
+		}
+	}
+	return nil
+}
+
+// RecordNotificationDelivery logs the outcome of one notification send
+func (q *Queries) RecordNotificationDelivery(ctx context.Context, subscriptionID int64, jobID string, digest bool, itemCount int, sendErr error) error {
+	status := "sent"
+	errorMessage := ""
+	if sendErr != nil {
+		status = "failed"
+		errorMessage = sendErr.Error()
+	}
+
+	_, err := q.db.ExecContext(ctx, `
+		INSERT INTO notification_deliveries (subscription_id, job_id, digest, item_count, status, error_message)
+		VALUES ($1, NULLIF($2, ''), $3, $4, $5, NULLIF($6, ''))
+	`, subscriptionID, jobID, digest, itemCount, status, errorMessage)
+	if err != nil {
+		return fmt.Errorf("failed to record notification delivery: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,131 @@
+package db
+
+import (
+	"context"
+)
+
+// MOOperation represents an MO operation (MWOOPE) - all M3 fields as strings
+type MOOperation struct {
+	Environment string
+	CONO        string
+	FACI        string
+	MFNO        string
+	PRNO        string
+	OPNO        string
+	PLGR        string
+	OPDS        string
+	WOST        string
+	SETI        string
+	PITI        string
+	PUSN        string
+	ORQT        string
+	MAQT        string
+	STDT        string
+	FIDT        string
+	LMDT        string
+	M3Timestamp string
+}
+
+// ProductOperation represents a routing operation (MPDOPE) - all M3 fields as strings
+type ProductOperation struct {
+	Environment string
+	CONO        string
+	FACI        string
+	PRNO        string
+	STRT        string
+	OPNO        string
+	PLGR        string
+	OPDS        string
+	SETI        string
+	PITI        string
+	PUSN        string
+	FDAT        string
+	TDAT        string
+	LMDT        string
+	M3Timestamp string
+}
+
+// WorkCenter represents a work center (MPDWCT) - all M3 fields as strings
+type WorkCenter struct {
+	Environment string
+	CONO        string
+	FACI        string
+	PLGR        string
+	PLNM        string
+	WCTY        string
+	NOMA        string
+	LMDT        string
+	M3Timestamp string
+}
+
+// Operation snapshot tables, loaded per facility by the operations data job
+const (
+	MOOperationsTable      = "mo_operations"
+	ProductOperationsTable = "product_operations"
+	WorkCentersTable       = "work_centers"
+)
+
+var moOperationColumns = []string{
+	"environment", "cono", "faci", "mfno", "prno", "opno",
+	"plgr", "opds", "wost",
+	"seti", "piti", "pusn", "orqt", "maqt",
+	"stdt", "fidt",
+	"lmdt", "m3_timestamp",
+}
+
+var productOperationColumns = []string{
+	"environment", "cono", "faci", "prno", "strt", "opno",
+	"plgr", "opds",
+	"seti", "piti", "pusn",
+	"fdat", "tdat",
+	"lmdt", "m3_timestamp",
+}
+
+var workCenterColumns = []string{
+	"environment", "cono", "faci", "plgr",
+	"plnm", "wcty", "noma",
+	"lmdt", "m3_timestamp",
+}
+
+// BatchInsertMOOperations upserts MO operations
+func (q *Queries) BatchInsertMOOperations(ctx context.Context, operations []*MOOperation) error {
+	keys := []string{"environment", "cono", "faci", "mfno", "opno"}
+	return q.upsertFacilityRows(ctx, MOOperationsTable, moOperationColumns, keys, len(operations), func(i int) []interface{} {
+		o := operations[i]
+		return []interface{}{
+			o.Environment, o.CONO, o.FACI, o.MFNO, o.PRNO, o.OPNO,
+			o.PLGR, o.OPDS, o.WOST,
+			o.SETI, o.PITI, o.PUSN, o.ORQT, o.MAQT,
+			o.STDT, o.FIDT,
+			o.LMDT, o.M3Timestamp,
+		}
+	})
+}
+
+// BatchInsertProductOperations upserts routing operations
+func (q *Queries) BatchInsertProductOperations(ctx context.Context, operations []*ProductOperation) error {
+	keys := []string{"environment", "cono", "faci", "prno", "strt", "opno", "fdat"}
+	return q.upsertFacilityRows(ctx, ProductOperationsTable, productOperationColumns, keys, len(operations), func(i int) []interface{} {
+		o := operations[i]
+		return []interface{}{
+			o.Environment, o.CONO, o.FACI, o.PRNO, o.STRT, o.OPNO,
+			o.PLGR, o.OPDS,
+			o.SETI, o.PITI, o.PUSN,
+			o.FDAT, o.TDAT,
+			o.LMDT, o.M3Timestamp,
+		}
+	})
+}
+
+// BatchInsertWorkCenters upserts work centers
+func (q *Queries) BatchInsertWorkCenters(ctx context.Context, workCenters []*WorkCenter) error {
+	keys := []string{"environment", "cono", "faci", "plgr"}
+	return q.upsertFacilityRows(ctx, WorkCentersTable, workCenterColumns, keys, len(workCenters), func(i int) []interface{} {
+		w := workCenters[i]
+		return []interface{}{
+			w.Environment, w.CONO, w.FACI, w.PLGR,
+			w.PLNM, w.WCTY, w.NOMA,
+			w.LMDT, w.M3Timestamp,
+		}
+	})
+}
@@ -0,0 +1,63 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+)
+
+// OrderState is an order's snapshot values as they were before an M3 write
+// Stored as before_state in the audit metadata so the write can be reverted later
+type OrderState struct {
+	OrderType     string `json:"order_type"`
+	OrderNumber   string `json:"order_number"`
+	Company       string `json:"company"`
+	Facility      string `json:"facility"`
+	ItemNumber    string `json:"item_number"`
+	ProductNumber string `json:"product_number"`
+	Warehouse     string `json:"warehouse"`
+	Status        string `json:"status"` // WHST for MOs, PSTS for MOPs
+	StartDate     string `json:"start_date"`
+	FinishDate    string `json:"finish_date"`
+	Quantity      string `json:"quantity"` // ORQA for MOs, PPQT for MOPs
+}
+
+// GetOrderState returns the snapshot state of an MO or MOP, or nil if the order is not in the snapshot
+func (q *Queries) GetOrderState(ctx context.Context, environment, orderType, orderNumber, facility string) (*OrderState, error) {
+	var query string
+	switch orderType {
+	case "MO":
+		query = `
+			SELECT cono, faci, mfno, itno, COALESCE(prno, ''), COALESCE(whlo, ''),
+			       COALESCE(whst, ''), COALESCE(stdt, ''), COALESCE(fidt, ''), COALESCE(orqa, '')
+			FROM manufacturing_orders
+			WHERE environment = $1 AND mfno = $2 AND faci = $3
+			LIMIT 1
+		`
+	case "MOP":
+		query = `
+			SELECT cono, faci, plpn, itno, COALESCE(prno, ''), COALESCE(whlo, ''),
+			       COALESCE(psts, ''), COALESCE(stdt, ''), COALESCE(fidt, ''), COALESCE(ppqt, '')
+			FROM planned_manufacturing_orders
+			WHERE environment = $1 AND plpn = $2 AND faci = $3
+			LIMIT 1
+		`
+	default:
+		return nil, fmt.Errorf("unknown order type: %s", orderType)
+	}
+
+	state := &OrderState{OrderType: orderType}
+	err := q.db.QueryRowContext(ctx, query, environment, orderNumber, facility).Scan(
+		&state.Company, &state.Facility, &state.OrderNumber, &state.ItemNumber,
+		&state.ProductNumber, &state.Warehouse, &state.Status,
+		&state.StartDate, &state.FinishDate, &state.Quantity,
+	)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get %s state: %w", orderType, err)
+	}
+
+	return state, nil
+}
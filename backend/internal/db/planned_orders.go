@@ -5,12 +5,13 @@ import (
 	"database/sql"
 	"encoding/json"
 	"fmt"
+	"strings"
 )
 
 // PlannedManufacturingOrder represents a planned manufacturing order record - all M3 fields as strings
 type PlannedManufacturingOrder struct {
 	ID              int64
-	Environment     string // M3 environment (TRN or PRD)
+	Environment     string // M3 environment key (e.g. TRN, PRD)
 
 	// M3 Core Identifiers
 	CONO            string
@@ -98,6 +99,27 @@ type PlannedManufacturingOrder struct {
 	SyncTime        sql.NullTime
 }
 
+// plannedOrderColumns are the planned_manufacturing_orders columns loaded by BatchInsertPlannedOrders, in copy order
+var plannedOrderColumns = []string{
+	"environment",
+	"cono", "divi", "faci", "plpn", "plps", "prno", "itno",
+	"psts", "whst", "actp",
+	"orty", "gety",
+	"ppqt", "orqa",
+	"reld", "stdt", "fidt", "msti", "mfti", "pldt",
+	"resp", "prip", "plgr", "wcln", "prdy",
+	"whlo",
+	"rorc", "rorn", "rorl", "rorx", "rorh",
+	"pllo", "plhl",
+	"atnr", "cfin",
+	"proj", "elno",
+	"messages",
+	"nuau", "ordp",
+	"rgdt", "rgtm", "lmdt", "lmts", "chno", "chid",
+	"m3_timestamp",
+	"linked_co_number", "linked_co_line", "linked_co_suffix", "allocated_qty",
+}
+
 // BatchInsertPlannedOrders inserts multiple MOPs efficiently with all M3 fields
 func (q *Queries) BatchInsertPlannedOrders(ctx context.Context, orders []*PlannedManufacturingOrder, progressCallback InsertProgressCallback) error {
 	if len(orders) == 0 {
@@ -110,46 +132,40 @@ func (q *Queries) BatchInsertPlannedOrders(ctx context.Context, orders []*Planne
 	}
 	defer tx.Rollback()
 
-	stmt, err := tx.PrepareContext(ctx, `
-		INSERT INTO planned_manufacturing_orders (
-			environment,
-			cono, divi, faci, plpn, plps, prno, itno,
-			psts, whst, actp,
-			orty, gety,
-			ppqt, orqa,
-			reld, stdt, fidt, msti, mfti, pldt,
-			resp, prip, plgr, wcln, prdy,
-			whlo,
-			rorc, rorn, rorl, rorx, rorh,
-			pllo, plhl,
-			atnr, cfin,
-			proj, elno,
-			messages,
-			nuau, ordp,
-			rgdt, rgtm, lmdt, lmts, chno, chid,
-			m3_timestamp,
-			linked_co_number, linked_co_line, linked_co_suffix, allocated_qty,
-			sync_timestamp
-		) VALUES (
-			$1,
-			$2, $3, $4, $5, $6, $7, $8,
-			$9, $10, $11,
-			$12, $13,
-			$14, $15,
-			$16, $17, $18, $19, $20, $21,
-			$22, $23, $24, $25, $26,
-			$27,
-			$28, $29, $30, $31, $32,
-			$33, $34,
-			$35, $36,
-			$37, $38,
-			$39,
-			$40, $41,
-			$42, $43, $44, $45, $46, $47,
-			$48,
-			$49, $50, $51, $52,
-			NOW()
-		)
+	// COPY the batch into a temp table, then upsert it into the snapshot table in one statement
+	target := q.snapshotTable("planned_manufacturing_orders")
+	if err := copyIntoTempTable(ctx, tx, target, "planned_manufacturing_orders_copy", plannedOrderColumns, len(orders), func(i int) []interface{} {
+		mop := orders[i]
+		return []interface{}{
+			mop.Environment,
+			mop.CONO, mop.DIVI, mop.FACI, mop.PLPN, mop.PLPS, mop.PRNO, mop.ITNO,
+			mop.PSTS, mop.WHST, mop.ACTP,
+			mop.ORTY, mop.GETY,
+			mop.PPQT, mop.ORQA,
+			mop.RELD, mop.STDT, mop.FIDT, mop.MSTI, mop.MFTI, mop.PLDT,
+			mop.RESP, mop.PRIP, mop.PLGR, mop.WCLN, mop.PRDY,
+			mop.WHLO,
+			mop.RORC, mop.RORN, mop.RORL, mop.RORX, mop.RORH,
+			mop.PLLO, mop.PLHL,
+			mop.ATNR, mop.CFIN,
+			mop.PROJ, mop.ELNO,
+			mop.Messages,
+			mop.NUAU, mop.ORDP,
+			mop.RGDT, mop.RGTM, mop.LMDT, mop.LMTS, mop.CHNO, mop.CHID,
+			mop.M3Timestamp,
+			mop.LinkedCONumber, mop.LinkedCOLine, mop.LinkedCOSuffix, mop.AllocatedQty,
+		}
+	}, progressCallback); err != nil {
+		return err
+	}
+
+	// DISTINCT ON keeps the last row when the batch holds the same key twice
+	columns := strings.Join(plannedOrderColumns, ", ")
+	_, err = tx.ExecContext(ctx, fmt.Sprintf(`
+		INSERT INTO %s (%s, sync_timestamp)
+		SELECT DISTINCT ON (environment, plpn) %s, NOW()
+		FROM planned_manufacturing_orders_copy
+		ORDER BY environment, plpn, copy_seq DESC
 		ON CONFLICT (environment, plpn)
 		DO UPDATE SET
 			psts = EXCLUDED.psts,
@@ -198,41 +214,9 @@ func (q *Queries) BatchInsertPlannedOrders(ctx context.Context, orders []*Planne
 			allocated_qty = EXCLUDED.allocated_qty,
 			sync_timestamp = NOW(),
 			updated_at = NOW()
-	`)
+	`, target, columns, columns))
 	if err != nil {
-		return fmt.Errorf("failed to prepare statement: %w", err)
-	}
-	defer stmt.Close()
-
-	const insertProgressInterval = 10000
-	for i, mop := range orders {
-		_, err = stmt.ExecContext(ctx,
-			mop.Environment,
-			mop.CONO, mop.DIVI, mop.FACI, mop.PLPN, mop.PLPS, mop.PRNO, mop.ITNO,
-			mop.PSTS, mop.WHST, mop.ACTP,
-			mop.ORTY, mop.GETY,
-			mop.PPQT, mop.ORQA,
-			mop.RELD, mop.STDT, mop.FIDT, mop.MSTI, mop.MFTI, mop.PLDT,
-			mop.RESP, mop.PRIP, mop.PLGR, mop.WCLN, mop.PRDY,
-			mop.WHLO,
-			mop.RORC, mop.RORN, mop.RORL, mop.RORX, mop.RORH,
-			mop.PLLO, mop.PLHL,
-			mop.ATNR, mop.CFIN,
-			mop.PROJ, mop.ELNO,
-			mop.Messages,
-			mop.NUAU, mop.ORDP,
-			mop.RGDT, mop.RGTM, mop.LMDT, mop.LMTS, mop.CHNO, mop.CHID,
-			mop.M3Timestamp,
-			mop.LinkedCONumber, mop.LinkedCOLine, mop.LinkedCOSuffix, mop.AllocatedQty,
-		)
-		if err != nil {
-			return fmt.Errorf("failed to insert MOP %s: %w", mop.PLPN, err)
-		}
-
-		// Report insertion progress every N records
-		if progressCallback != nil && ((i+1)%insertProgressInterval == 0 || (i+1) == len(orders)) {
-			progressCallback(i+1, len(orders))
-		}
+		return fmt.Errorf("failed to upsert MOPs: %w", err)
 	}
 
 	return tx.Commit()
@@ -240,8 +224,8 @@ func (q *Queries) BatchInsertPlannedOrders(ctx context.Context, orders []*Planne
 
 // UpdateProductionOrdersFromMOPs updates the production_orders unified view from MOPs
 func (q *Queries) UpdateProductionOrdersFromMOPs(ctx context.Context) error {
-	query := `
-		INSERT INTO production_orders (
+	query := fmt.Sprintf(`
+		INSERT INTO %s (
 			environment, order_type, order_number,
 			cono, divi, faci,
 			prno, itno,
@@ -280,7 +264,7 @@ func (q *Queries) UpdateProductionOrdersFromMOPs(ctx context.Context) error {
 			mop.linked_co_number, mop.linked_co_line, mop.linked_co_suffix, mop.allocated_qty,
 			mop.orty,
 			mop.id, NOW(), mop.deleted_remotely
-		FROM planned_manufacturing_orders mop
+		FROM %s mop
 		ORDER BY mop.environment, mop.plpn,
 		         CASE WHEN mop.lmdt = '' THEN '99999999' ELSE mop.lmdt END DESC,
 		         mop.id DESC
@@ -312,7 +296,7 @@ func (q *Queries) UpdateProductionOrdersFromMOPs(ctx context.Context) error {
 			deleted_remotely = EXCLUDED.deleted_remotely,
 			sync_timestamp = NOW(),
 			updated_at = NOW()
-	`
+	`, q.snapshotTable("production_orders"), q.snapshotTable("planned_manufacturing_orders"))
 
 	_, err := q.db.ExecContext(ctx, query)
 	return err
@@ -328,3 +312,44 @@ func (q *Queries) MarkMOPAsDeletedRemotely(ctx context.Context, plpn int64, faci
 	_, err := q.db.ExecContext(ctx, query, fmt.Sprintf("%d", plpn), facility)
 	return err
 }
+
+// DeletePlannedOrders removes MOPs that were deleted or are no longer firmed in M3
+// Matching production_orders rows are removed via ON DELETE CASCADE on mop_id
+// Used by incremental refresh; full refresh truncates instead
+func (q *Queries) DeletePlannedOrders(ctx context.Context, environment string, plpns []string) (int, error) {
+	if len(plpns) == 0 {
+		return 0, nil
+	}
+
+	tx, err := q.db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	stmt, err := tx.PrepareContext(ctx, fmt.Sprintf(`
+		DELETE FROM %s
+		WHERE environment = $1 AND plpn = $2
+	`, q.snapshotTable("planned_manufacturing_orders")))
+	if err != nil {
+		return 0, fmt.Errorf("failed to prepare statement: %w", err)
+	}
+	defer stmt.Close()
+
+	deleted := 0
+	for _, plpn := range plpns {
+		result, err := stmt.ExecContext(ctx, environment, plpn)
+		if err != nil {
+			return 0, fmt.Errorf("failed to delete MOP %s: %w", plpn, err)
+		}
+		if n, err := result.RowsAffected(); err == nil {
+			deleted += int(n)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, fmt.Errorf("failed to commit delete transaction: %w", err)
+	}
+
+	return deleted, nil
+}
@@ -0,0 +1,311 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+
+	"github.com/lib/pq"
+)
+
+// ProductionOrderSortColumns maps the sort keys accepted by the browse API to production_orders columns
+var ProductionOrderSortColumns = map[string]string{
+	"plannedStartDate":  "po.planned_start_date",
+	"plannedFinishDate": "po.planned_finish_date",
+	"orderNumber":       "po.order_number",
+	"itemNumber":        "po.itno",
+	"productNumber":     "po.prno",
+	"warehouse":         "po.warehouse",
+	"status":            "po.status",
+}
+
+// ProductionOrderSummary is a production_orders row as returned by the browse API
+type ProductionOrderSummary struct {
+	ID                int64  `json:"id"`
+	OrderNumber       string `json:"orderNumber"`
+	OrderType         string `json:"orderType"`
+	Company           string `json:"company"`
+	Facility          string `json:"facility"`
+	Warehouse         string `json:"warehouse,omitempty"`
+	ItemNumber        string `json:"itemNumber"`
+	ProductNumber     string `json:"productNumber,omitempty"`
+	MOType            string `json:"moType,omitempty"`
+	MOTypeDescription string `json:"moTypeDescription,omitempty"`
+	Status            string `json:"status,omitempty"`
+	ProposalStatus    string `json:"proposalStatus,omitempty"`
+	OrderedQuantity   string `json:"orderedQuantity,omitempty"`
+	PlannedStartDate  string `json:"plannedStartDate,omitempty"`
+	PlannedFinishDate string `json:"plannedFinishDate,omitempty"`
+	LinkedCONumber    string `json:"linkedCoNumber,omitempty"`
+	LinkedCOLine      string `json:"linkedCoLine,omitempty"`
+	LinkedCOSuffix    string `json:"linkedCoSuffix,omitempty"`
+	MOID              *int64 `json:"moId,omitempty"`
+	MOPID             *int64 `json:"mopId,omitempty"`
+
+	// SortValue is the value of the active sort column, used to build the next keyset cursor
+	SortValue string `json:"-"`
+}
+
+// ListProductionOrdersParams filters and pages the production order browse query
+// Date bounds are inclusive YYYYMMDD strings. Paging is keyset-based: AfterValue/AfterID
+// are the sort value and id of the last row of the previous page.
+type ListProductionOrdersParams struct {
+	Environment    string
+	Facility       string
+	Warehouse      string
+	ItemNumber     string
+	ProductNumber  string
+	OrderType      string   // MO or MOP
+	MOTypes        []string // ORTY
+	Statuses       []string
+	StartFrom      string
+	StartTo        string
+	FinishFrom     string
+	FinishTo       string
+	LinkedCONumber string
+	LinkedCOLine   string
+	IncludeDeleted bool
+
+	SortColumn string // Key of ProductionOrderSortColumns
+	Descending bool
+	AfterValue *string
+	AfterID    int64
+	Limit      int
+}
+
+const productionOrderSummaryColumns = `
+	po.id, po.order_number, po.order_type, po.cono, po.faci, COALESCE(po.warehouse, ''),
+	po.itno, COALESCE(po.prno, ''), COALESCE(po.orty, ''), COALESCE(mot.order_type_description, ''),
+	COALESCE(po.status, ''), COALESCE(po.proposal_status, ''), COALESCE(po.ordered_quantity, ''),
+	COALESCE(po.planned_start_date, ''), COALESCE(po.planned_finish_date, ''),
+	COALESCE(po.linked_co_number, ''), COALESCE(po.linked_co_line, ''), COALESCE(po.linked_co_suffix, ''),
+	po.mo_id, po.mop_id`
+
+const productionOrderSummaryJoins = `
+	LEFT JOIN m3_manufacturing_order_types mot
+		ON mot.environment = po.environment
+		AND mot.company_number = po.cono
+		AND mot.order_type = po.orty`
+
+func scanProductionOrderSummary(scanner interface{ Scan(...interface{}) error }, extra ...interface{}) (*ProductionOrderSummary, error) {
+	order := &ProductionOrderSummary{}
+	var moID, mopID sql.NullInt64
+	dest := []interface{}{
+		&order.ID, &order.OrderNumber, &order.OrderType, &order.Company, &order.Facility, &order.Warehouse,
+		&order.ItemNumber, &order.ProductNumber, &order.MOType, &order.MOTypeDescription,
+		&order.Status, &order.ProposalStatus, &order.OrderedQuantity,
+		&order.PlannedStartDate, &order.PlannedFinishDate,
+		&order.LinkedCONumber, &order.LinkedCOLine, &order.LinkedCOSuffix,
+		&moID, &mopID,
+	}
+	if err := scanner.Scan(append(dest, extra...)...); err != nil {
+		return nil, err
+	}
+	if moID.Valid {
+		order.MOID = &moID.Int64
+	}
+	if mopID.Valid {
+		order.MOPID = &mopID.Int64
+	}
+	return order, nil
+}
+
+// ListProductionOrders returns one page of the environment's production orders (MOs and MOPs)
+func (q *Queries) ListProductionOrders(ctx context.Context, params ListProductionOrdersParams) ([]*ProductionOrderSummary, error) {
+	sortColumn, ok := ProductionOrderSortColumns[params.SortColumn]
+	if !ok {
+		return nil, fmt.Errorf("invalid sort column: %s", params.SortColumn)
+	}
+	sortExpr := fmt.Sprintf("COALESCE(%s, '')", sortColumn)
+
+	query := fmt.Sprintf(`
+		SELECT %s, %s
+		FROM production_orders po
+		%s
+		WHERE po.environment = $1
+	`, productionOrderSummaryColumns, sortExpr, productionOrderSummaryJoins)
+
+	args := []interface{}{params.Environment}
+	argNum := 2
+	addFilter := func(condition string, value interface{}) {
+		query += fmt.Sprintf(" AND "+condition, argNum)
+		args = append(args, value)
+		argNum++
+	}
+
+	if !params.IncludeDeleted {
+		query += " AND COALESCE(po.deleted_remotely, false) = false"
+	}
+	if params.Facility != "" {
+		addFilter("po.faci = $%d", params.Facility)
+	}
+	if params.Warehouse != "" {
+		addFilter("po.warehouse = $%d", params.Warehouse)
+	}
+	if params.ItemNumber != "" {
+		addFilter("po.itno = $%d", params.ItemNumber)
+	}
+	if params.ProductNumber != "" {
+		addFilter("po.prno = $%d", params.ProductNumber)
+	}
+	if params.OrderType != "" {
+		addFilter("po.order_type = $%d", params.OrderType)
+	}
+	if len(params.MOTypes) > 0 {
+		addFilter("po.orty = ANY($%d)", pq.Array(params.MOTypes))
+	}
+	if len(params.Statuses) > 0 {
+		addFilter("po.status = ANY($%d)", pq.Array(params.Statuses))
+	}
+	if params.StartFrom != "" {
+		addFilter("po.planned_start_date >= $%d", params.StartFrom)
+	}
+	if params.StartTo != "" {
+		addFilter("po.planned_start_date <= $%d", params.StartTo)
+	}
+	if params.FinishFrom != "" {
+		addFilter("po.planned_finish_date >= $%d", params.FinishFrom)
+	}
+	if params.FinishTo != "" {
+		addFilter("po.planned_finish_date <= $%d", params.FinishTo)
+	}
+	if params.LinkedCONumber != "" {
+		addFilter("po.linked_co_number = $%d", params.LinkedCONumber)
+	}
+	if params.LinkedCOLine != "" {
+		addFilter("po.linked_co_line = $%d", params.LinkedCOLine)
+	}
+
+	// Keyset pagination on (sort value, id), both in the same direction
+	direction, comparison := "ASC", ">"
+	if params.Descending {
+		direction, comparison = "DESC", "<"
+	}
+	if params.AfterValue != nil {
+		query += fmt.Sprintf(" AND (%s, po.id) %s ($%d, $%d)", sortExpr, comparison, argNum, argNum+1)
+		args = append(args, *params.AfterValue, params.AfterID)
+		argNum += 2
+	}
+
+	query += fmt.Sprintf(" ORDER BY %s %s, po.id %s LIMIT $%d", sortExpr, direction, direction, argNum)
+	args = append(args, params.Limit)
+
+	rows, err := q.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query production orders: %w", err)
+	}
+	defer rows.Close()
+
+	orders := make([]*ProductionOrderSummary, 0)
+	for rows.Next() {
+		var sortValue string
+		order, err := scanProductionOrderSummary(rows, &sortValue)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan production order: %w", err)
+		}
+		order.SortValue = sortValue
+		orders = append(orders, order)
+	}
+
+	return orders, rows.Err()
+}
+
+// GetProductionOrderByID returns a production order of the environment, or nil if not found
+func (q *Queries) GetProductionOrderByID(ctx context.Context, environment string, id int64) (*ProductionOrderSummary, error) {
+	query := fmt.Sprintf(`
+		SELECT %s
+		FROM production_orders po
+		%s
+		WHERE po.environment = $1 AND po.id = $2
+	`, productionOrderSummaryColumns, productionOrderSummaryJoins)
+
+	order, err := scanProductionOrderSummary(q.db.QueryRowContext(ctx, query, environment, id))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get production order: %w", err)
+	}
+	return order, nil
+}
+
+// OrderDetail is the full snapshot row of an MO or MOP with its linked CO line
+// Attributes holds every column of the source row (manufacturing_orders or planned_manufacturing_orders)
+type OrderDetail struct {
+	ID           int64           `json:"id"`
+	OrderType    string          `json:"orderType"`
+	OrderNumber  string          `json:"orderNumber"`
+	Facility     string          `json:"facility"`
+	Attributes   json.RawMessage `json:"attributes"`
+	LinkedCOLine json.RawMessage `json:"linkedCoLine"` // Full customer_order_lines row, null if not linked
+}
+
+// GetManufacturingOrderDetail returns an MO with all of its columns, or nil if not found
+func (q *Queries) GetManufacturingOrderDetail(ctx context.Context, environment string, id int64) (*OrderDetail, error) {
+	return q.getOrderDetail(ctx, environment, id, "MO", `
+		SELECT id, mfno, faci, to_jsonb(mo),
+		       COALESCE(linked_co_number, ''), COALESCE(linked_co_line, ''), COALESCE(linked_co_suffix, '')
+		FROM manufacturing_orders mo
+		WHERE environment = $1 AND id = $2
+	`)
+}
+
+// GetPlannedOrderDetail returns a MOP with all of its columns, or nil if not found
+func (q *Queries) GetPlannedOrderDetail(ctx context.Context, environment string, id int64) (*OrderDetail, error) {
+	return q.getOrderDetail(ctx, environment, id, "MOP", `
+		SELECT id, plpn, faci, to_jsonb(mop),
+		       COALESCE(linked_co_number, ''), COALESCE(linked_co_line, ''), COALESCE(linked_co_suffix, '')
+		FROM planned_manufacturing_orders mop
+		WHERE environment = $1 AND id = $2
+	`)
+}
+
+func (q *Queries) getOrderDetail(ctx context.Context, environment string, id int64, orderType, query string) (*OrderDetail, error) {
+	detail := &OrderDetail{OrderType: orderType}
+	var attributes []byte
+	var coNumber, coLine, coSuffix string
+	err := q.db.QueryRowContext(ctx, query, environment, id).Scan(
+		&detail.ID, &detail.OrderNumber, &detail.Facility, &attributes,
+		&coNumber, &coLine, &coSuffix,
+	)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get %s detail: %w", orderType, err)
+	}
+	detail.Attributes = attributes
+
+	detail.LinkedCOLine, err = q.getLinkedCOLine(ctx, environment, coNumber, coLine, coSuffix)
+	if err != nil {
+		return nil, err
+	}
+
+	return detail, nil
+}
+
+// getLinkedCOLine returns the full customer_order_lines row as JSON, or JSON null if there is none
+func (q *Queries) getLinkedCOLine(ctx context.Context, environment, orno, ponr, posx string) (json.RawMessage, error) {
+	if orno == "" || ponr == "" {
+		return json.RawMessage("null"), nil
+	}
+
+	// An empty suffix matches any suffix; the lowest one is the original line
+	var line []byte
+	err := q.db.QueryRowContext(ctx, `
+		SELECT to_jsonb(col)
+		FROM customer_order_lines col
+		WHERE environment = $1 AND orno = $2 AND ponr = $3 AND ($4 = '' OR posx = $4)
+		ORDER BY posx
+		LIMIT 1
+	`, environment, orno, ponr, posx).Scan(&line)
+	if err == sql.ErrNoRows {
+		return json.RawMessage("null"), nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get linked CO line: %w", err)
+	}
+
+	return line, nil
+}
@@ -17,6 +17,7 @@ type Queries struct {
 	cacheTablesMeta []CacheTableMetadata
 	cacheMetaExpiry time.Time
 	cacheMetaMutex  sync.RWMutex
+	tableSuffix     string // "" for live snapshot tables, "_staging" for the staging generation
 }
 
 // New creates a new Queries instance
@@ -29,39 +30,147 @@ func (q *Queries) DB() *sql.DB {
 	return q.db
 }
 
-// TruncateAnalysisTables deletes snapshot data for a specific environment
-// This preserves data for other environments while clearing the specified one
-// Uses DELETE with WHERE clause instead of TRUNCATE for environment filtering
-func (q *Queries) TruncateAnalysisTables(ctx context.Context, environment string) error {
-	// Use TRUNCATE CASCADE for instant clearing (much faster than DELETE)
-	// NOTE: This truncates ALL environments, not just the specified one
-	// TODO: When multi-environment support is needed, use table partitioning or optimize DELETE
+// Staging returns a Queries instance whose snapshot loads (batch inserts, deletes and
+// production order finalize) target the staging generation instead of the live tables.
+// All other queries are unaffected.
+func (q *Queries) Staging() *Queries {
+	return &Queries{db: q.db, tableSuffix: stagingTableSuffix}
+}
+
+// snapshotTable returns the physical table name of a snapshot table for this generation
+func (q *Queries) snapshotTable(name string) string {
+	return name + q.tableSuffix
+}
 
-	tables := []string{
-		"detected_issues",              // First - clear old detection results
-		"production_orders",            // Second - has FKs to MOs/MOPs
-		"customer_order_lines",         // Third - referenced by production analysis
-		"manufacturing_orders",         // Fourth - has FK from production_orders
-		"planned_manufacturing_orders", // Fifth - has FK from production_orders
+const stagingTableSuffix = "_staging"
+
+// snapshotTables lists the snapshot tables in load order (parents before production_orders)
+// Each has a <name>_staging twin with the same columns (see migrations 061, 069, 070 and 074)
+var snapshotTables = []string{
+	"customer_order_lines",
+	"manufacturing_orders",
+	"planned_manufacturing_orders",
+	MOMaterialsTable, // Material tables (migration 069) are reloaded per facility by the materials job
+	ProductStructuresTable,
+	ItemBalancesTable,
+	ItemLocationsTable,
+	MOOperationsTable, // Operation tables (migration 070) are reloaded per facility by the operations job
+	ProductOperationsTable,
+	WorkCentersTable,
+	SupplyChainLinksTable, // MPREAL links (migration 074) are reloaded per facility by the supply chain job
+	"production_orders",   // Last - has FKs to MOs/MOPs
+}
+
+// PrepareSnapshotStaging clears the staging generation for an environment before a refresh
+// When seedFromLive is set, the current live rows are copied in first so an incremental
+// refresh can apply deltas on top of them. production_orders is rebuilt by finalize and
+// is never seeded.
+func (q *Queries) PrepareSnapshotStaging(ctx context.Context, environment string, seedFromLive bool) error {
+	tx, err := q.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
 	}
+	defer tx.Rollback()
+
+	// Clear in reverse order so production_orders goes before the tables it references
+	for i := len(snapshotTables) - 1; i >= 0; i-- {
+		table := snapshotTables[i] + stagingTableSuffix
+		if _, err := tx.ExecContext(ctx, fmt.Sprintf("DELETE FROM %s WHERE environment = $1", table), environment); err != nil {
+			return fmt.Errorf("failed to clear %s: %w", table, err)
+		}
+	}
+
+	if seedFromLive {
+		for _, table := range snapshotTables {
+			if table == "production_orders" {
+				continue
+			}
+			if err := copySnapshotRows(ctx, tx, table, table+stagingTableSuffix, environment); err != nil {
+				return err
+			}
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit staging preparation: %w", err)
+	}
+
+	return nil
+}
 
+// PromoteSnapshotStaging atomically replaces an environment's live snapshot data with the
+// staging generation. Readers see either the old or the new generation, never a partial one.
+// Row IDs are preserved (both generations share the live ID sequences), so production_orders
+// foreign keys stay valid after the copy.
+func (q *Queries) PromoteSnapshotStaging(ctx context.Context, environment string) error {
 	tx, err := q.db.BeginTx(ctx, nil)
 	if err != nil {
 		return fmt.Errorf("failed to begin transaction: %w", err)
 	}
 	defer tx.Rollback()
 
-	for _, table := range tables {
-		query := fmt.Sprintf("TRUNCATE TABLE %s CASCADE", table)
-		_, err := tx.ExecContext(ctx, query)
-		if err != nil {
-			return fmt.Errorf("failed to truncate %s: %w", table, err)
+	for i := len(snapshotTables) - 1; i >= 0; i-- {
+		table := snapshotTables[i]
+		if _, err := tx.ExecContext(ctx, fmt.Sprintf("DELETE FROM %s WHERE environment = $1", table), environment); err != nil {
+			return fmt.Errorf("failed to clear live %s: %w", table, err)
+		}
+	}
+
+	for _, table := range snapshotTables {
+		if err := copySnapshotRows(ctx, tx, table+stagingTableSuffix, table, environment); err != nil {
+			return err
+		}
+	}
+
+	for i := len(snapshotTables) - 1; i >= 0; i-- {
+		table := snapshotTables[i] + stagingTableSuffix
+		if _, err := tx.ExecContext(ctx, fmt.Sprintf("DELETE FROM %s WHERE environment = $1", table), environment); err != nil {
+			return fmt.Errorf("failed to clear %s: %w", table, err)
 		}
-		fmt.Printf("Truncated table: %s\n", table)
 	}
 
 	if err := tx.Commit(); err != nil {
-		return fmt.Errorf("failed to commit truncate transaction: %w", err)
+		return fmt.Errorf("failed to commit snapshot promotion: %w", err)
+	}
+
+	return nil
+}
+
+// copySnapshotRows copies one environment's rows between a snapshot table and its staging twin
+// The column list is read from the destination table so column order differences don't matter
+func copySnapshotRows(ctx context.Context, tx *sql.Tx, fromTable, toTable, environment string) error {
+	rows, err := tx.QueryContext(ctx, `
+		SELECT column_name
+		FROM information_schema.columns
+		WHERE table_schema = current_schema() AND table_name = $1
+		ORDER BY ordinal_position
+	`, toTable)
+	if err != nil {
+		return fmt.Errorf("failed to read columns of %s: %w", toTable, err)
+	}
+
+	var columns []string
+	for rows.Next() {
+		var column string
+		if err := rows.Scan(&column); err != nil {
+			rows.Close()
+			return fmt.Errorf("failed to scan column of %s: %w", toTable, err)
+		}
+		columns = append(columns, `"`+column+`"`)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("failed to read columns of %s: %w", toTable, err)
+	}
+	if len(columns) == 0 {
+		return fmt.Errorf("table %s not found", toTable)
+	}
+
+	columnList := strings.Join(columns, ", ")
+	query := fmt.Sprintf("INSERT INTO %s (%s) SELECT %s FROM %s WHERE environment = $1",
+		toTable, columnList, columnList, fromTable)
+	if _, err := tx.ExecContext(ctx, query, environment); err != nil {
+		return fmt.Errorf("failed to copy %s into %s: %w", fromTable, toTable, err)
 	}
 
 	return nil
@@ -0,0 +1,37 @@
+package db
+
+import (
+	"fmt"
+
+	"github.com/lib/pq"
+)
+
+// QueryArgs collects the positional parameters of a query assembled from optional clauses
+// Add returns the placeholder for a value, so clauses only ever contain placeholders and
+// values from settings or requests never become part of the SQL text. Column names passed
+// to clause builders must be constants.
+type QueryArgs struct {
+	values []interface{}
+}
+
+// NewQueryArgs starts a parameter list with the query's fixed parameters ($1, $2, ...)
+func NewQueryArgs(values ...interface{}) *QueryArgs {
+	return &QueryArgs{values: values}
+}
+
+// Add appends a parameter and returns its placeholder
+func (a *QueryArgs) Add(value interface{}) string {
+	a.values = append(a.values, value)
+	return fmt.Sprintf("$%d", len(a.values))
+}
+
+// AddArray appends a list as a text array parameter and returns its placeholder, for use
+// with = ANY(...) and <> ALL(...)
+func (a *QueryArgs) AddArray(values []string) string {
+	return a.Add(pq.Array(values)) + "::text[]"
+}
+
+// Values returns the parameters in placeholder order
+func (a *QueryArgs) Values() []interface{} {
+	return a.values
+}
@@ -0,0 +1,41 @@
+package db
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// ClaimScheduledRefreshRun records a schedule occurrence, returning false if another
+// instance already claimed it
+func (q *Queries) ClaimScheduledRefreshRun(ctx context.Context, environment, scheduleName string, scheduledFor time.Time, company, facility string) (bool, error) {
+	result, err := q.db.ExecContext(ctx, `
+		INSERT INTO scheduled_refresh_runs (environment, schedule_name, scheduled_for, company, facility)
+		VALUES ($1, $2, $3, $4, $5)
+		ON CONFLICT (environment, schedule_name, scheduled_for) DO NOTHING
+	`, environment, scheduleName, scheduledFor, company, facility)
+	if err != nil {
+		return false, fmt.Errorf("failed to claim scheduled refresh run: %w", err)
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("failed to claim scheduled refresh run: %w", err)
+	}
+
+	return rows > 0, nil
+}
+
+// UpdateScheduledRefreshRun records the outcome of a claimed schedule occurrence
+func (q *Queries) UpdateScheduledRefreshRun(ctx context.Context, environment, scheduleName string, scheduledFor time.Time, jobID, status, message string) error {
+	query := `
+		UPDATE scheduled_refresh_runs
+		SET job_id = NULLIF($4, ''),
+			status = $5,
+			message = NULLIF($6, ''),
+			updated_at = NOW()
+		WHERE environment = $1 AND schedule_name = $2 AND scheduled_for = $3
+	`
+	_, err := q.db.ExecContext(ctx, query, environment, scheduleName, scheduledFor, jobID, status, message)
+	return err
+}
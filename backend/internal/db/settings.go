@@ -10,7 +10,7 @@ import (
 // UserSettings represents user-specific default context overrides
 type UserSettings struct {
 	UserID           string
-	Environment      string // M3 environment (TRN or PRD)
+	Environment      string // M3 environment key (e.g. TRN, PRD)
 	DefaultWarehouse sql.NullString
 	DefaultFacility  sql.NullString
 	DefaultDivision  sql.NullString
@@ -22,7 +22,7 @@ type UserSettings struct {
 // SystemSetting represents a system-wide configuration setting
 type SystemSetting struct {
 	ID             int32
-	Environment    string // M3 environment (TRN or PRD)
+	Environment    string // M3 environment key (e.g. TRN, PRD)
 	SettingKey     string
 	SettingValue   string
 	SettingType    string
@@ -162,3 +162,25 @@ func (q *Queries) UpdateSystemSetting(ctx context.Context, params UpdateSystemSe
 	_, err := q.db.ExecContext(ctx, query, params.SettingValue, params.LastModifiedBy, params.Environment, params.SettingKey)
 	return err
 }
+
+// SeedEnvironmentSettings copies the system settings of fromEnvironment that environment does not
+// have yet, so a newly configured environment starts from the same baseline
+// Existing settings are never overwritten. Returns the number of settings added
+func (q *Queries) SeedEnvironmentSettings(ctx context.Context, environment, fromEnvironment string) (int64, error) {
+	query := `
+		INSERT INTO system_settings (
+			environment, setting_key, setting_value, setting_type, description, category,
+			constraints, last_modified_by, last_modified_at, created_at
+		)
+		SELECT $1, setting_key, setting_value, setting_type, description, category,
+		       constraints, last_modified_by, NOW(), NOW()
+		FROM system_settings
+		WHERE environment = $2
+		ON CONFLICT (environment, setting_key) DO NOTHING
+	`
+	result, err := q.db.ExecContext(ctx, query, environment, fromEnvironment)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
@@ -0,0 +1,229 @@
+package db
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/lib/pq"
+)
+
+// SupplyChainLink represents a pre-allocation link (MPREAL) - all M3 fields as strings
+// Each link pegs an acquisition order (AOCA/ARDN/ARDL/ARDX) to a demand order
+// (DOCA/DRDN/DRDL/DRDX); all links of a multi-level chain share its SCNB
+type SupplyChainLink struct {
+	Environment string
+	CONO        string
+	FACI        string
+	WHLO        string
+	ITNO        string
+	AOCA        string
+	ARDN        string
+	ARDL        string
+	ARDX        string
+	DOCA        string
+	DRDN        string
+	DRDL        string
+	DRDX        string
+	PQTY        string
+	PQTR        string
+	SCNB        string
+	RESP        string
+	PATY        string
+	LMDT        string
+	M3Timestamp string
+}
+
+// SupplyChainLinksTable is the MPREAL snapshot table, loaded per facility by the supply chain data job
+const SupplyChainLinksTable = "supply_chain_links"
+
+var supplyChainLinkColumns = []string{
+	"environment", "cono", "faci", "whlo", "itno",
+	"aoca", "ardn", "ardl", "ardx",
+	"doca", "drdn", "drdl", "drdx",
+	"pqty", "pqtr", "scnb",
+	"resp", "paty",
+	"lmdt", "m3_timestamp",
+}
+
+// BatchInsertSupplyChainLinks upserts pre-allocation links
+func (q *Queries) BatchInsertSupplyChainLinks(ctx context.Context, links []*SupplyChainLink) error {
+	keys := []string{"environment", "cono", "aoca", "ardn", "ardl", "ardx", "doca", "drdn", "drdl", "drdx"}
+	return q.upsertFacilityRows(ctx, SupplyChainLinksTable, supplyChainLinkColumns, keys, len(links), func(i int) []interface{} {
+		l := links[i]
+		return []interface{}{
+			l.Environment, l.CONO, l.FACI, l.WHLO, l.ITNO,
+			l.AOCA, l.ARDN, l.ARDL, l.ARDX,
+			l.DOCA, l.DRDN, l.DRDL, l.DRDX,
+			l.PQTY, l.PQTR, l.SCNB,
+			l.RESP, l.PATY,
+			l.LMDT, l.M3Timestamp,
+		}
+	})
+}
+
+// GetSupplyChainLinks returns every link of a supply chain (SCNB)
+func (q *Queries) GetSupplyChainLinks(ctx context.Context, environment, scnb string) ([]*SupplyChainLink, error) {
+	rows, err := q.db.QueryContext(ctx, `
+		SELECT environment, cono, faci, COALESCE(whlo, ''), COALESCE(itno, ''),
+		       aoca, ardn, COALESCE(ardl, ''), COALESCE(ardx, ''),
+		       doca, drdn, COALESCE(drdl, ''), COALESCE(drdx, ''),
+		       COALESCE(pqty, ''), COALESCE(pqtr, ''), scnb,
+		       COALESCE(resp, ''), COALESCE(paty, ''),
+		       COALESCE(lmdt, ''), COALESCE(m3_timestamp, '')
+		FROM supply_chain_links
+		WHERE environment = $1 AND scnb = $2
+		ORDER BY aoca, ardn, doca, drdn
+	`, environment, scnb)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query supply chain links: %w", err)
+	}
+	defer rows.Close()
+
+	var links []*SupplyChainLink
+	for rows.Next() {
+		l := &SupplyChainLink{}
+		if err := rows.Scan(
+			&l.Environment, &l.CONO, &l.FACI, &l.WHLO, &l.ITNO,
+			&l.AOCA, &l.ARDN, &l.ARDL, &l.ARDX,
+			&l.DOCA, &l.DRDN, &l.DRDL, &l.DRDX,
+			&l.PQTY, &l.PQTR, &l.SCNB,
+			&l.RESP, &l.PATY,
+			&l.LMDT, &l.M3Timestamp,
+		); err != nil {
+			return nil, fmt.Errorf("failed to scan supply chain link: %w", err)
+		}
+		links = append(links, l)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read supply chain links: %w", err)
+	}
+
+	return links, nil
+}
+
+// FindSupplyChainNumbers returns the supply chains (SCNB) an order takes part in, on either
+// the acquisition or the demand side. categories are the M3 order categories the order can
+// appear under; an empty line matches any line.
+func (q *Queries) FindSupplyChainNumbers(ctx context.Context, environment string, categories []string, number, line string) ([]string, error) {
+	rows, err := q.db.QueryContext(ctx, `
+		SELECT DISTINCT scnb
+		FROM supply_chain_links
+		WHERE environment = $1
+		  AND (
+			(aoca = ANY($2) AND ardn = $3 AND ($4 = '' OR ardl = $4))
+			OR (doca = ANY($2) AND drdn = $3 AND ($4 = '' OR drdl = $4))
+		  )
+		ORDER BY scnb
+	`, environment, pq.Array(categories), number, line)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query supply chain numbers: %w", err)
+	}
+	defer rows.Close()
+
+	var numbers []string
+	for rows.Next() {
+		var scnb string
+		if err := rows.Scan(&scnb); err != nil {
+			return nil, fmt.Errorf("failed to scan supply chain number: %w", err)
+		}
+		numbers = append(numbers, scnb)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read supply chain numbers: %w", err)
+	}
+
+	return numbers, nil
+}
+
+// GetProductionOrdersByNumbers returns the MOs or MOPs (orderType) with the given order numbers
+func (q *Queries) GetProductionOrdersByNumbers(ctx context.Context, environment, orderType string, numbers []string) ([]*ProductionOrderSummary, error) {
+	if len(numbers) == 0 {
+		return nil, nil
+	}
+
+	query := fmt.Sprintf(`
+		SELECT %s
+		FROM production_orders po
+		%s
+		WHERE po.environment = $1
+		  AND po.order_type = $2
+		  AND po.order_number = ANY($3)
+		  AND po.deleted_remotely = false
+	`, productionOrderSummaryColumns, productionOrderSummaryJoins)
+
+	rows, err := q.db.QueryContext(ctx, query, environment, orderType, pq.Array(numbers))
+	if err != nil {
+		return nil, fmt.Errorf("failed to query production orders: %w", err)
+	}
+	defer rows.Close()
+
+	var orders []*ProductionOrderSummary
+	for rows.Next() {
+		order, err := scanProductionOrderSummary(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan production order: %w", err)
+		}
+		orders = append(orders, order)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read production orders: %w", err)
+	}
+
+	return orders, nil
+}
+
+// SupplyChainCOLine is the part of a customer_order_lines row shown on a supply chain node
+type SupplyChainCOLine struct {
+	OrderNumber       string
+	LineNumber        string
+	LineSuffix        string
+	Facility          string
+	Warehouse         string
+	ItemNumber        string
+	Status            string
+	OrderedQuantity   string
+	RemainingQuantity string
+	RequestedDate     string
+	ConfirmedDate     string
+	CustomerNumber    string
+	CustomerName      string
+}
+
+// GetSupplyChainCOLines returns the CO lines of the given customer order numbers
+func (q *Queries) GetSupplyChainCOLines(ctx context.Context, environment string, orderNumbers []string) ([]*SupplyChainCOLine, error) {
+	if len(orderNumbers) == 0 {
+		return nil, nil
+	}
+
+	rows, err := q.db.QueryContext(ctx, `
+		SELECT orno, ponr, posx, COALESCE(faci, ''), COALESCE(whlo, ''), itno,
+		       COALESCE(orst, ''), COALESCE(orqt, ''), COALESCE(rnqt, ''),
+		       COALESCE(dwdt, ''), COALESCE(codt, ''),
+		       COALESCE(cuno, ''), COALESCE(customer_name, '')
+		FROM customer_order_lines
+		WHERE environment = $1 AND orno = ANY($2)
+	`, environment, pq.Array(orderNumbers))
+	if err != nil {
+		return nil, fmt.Errorf("failed to query CO lines: %w", err)
+	}
+	defer rows.Close()
+
+	var lines []*SupplyChainCOLine
+	for rows.Next() {
+		l := &SupplyChainCOLine{}
+		if err := rows.Scan(
+			&l.OrderNumber, &l.LineNumber, &l.LineSuffix, &l.Facility, &l.Warehouse, &l.ItemNumber,
+			&l.Status, &l.OrderedQuantity, &l.RemainingQuantity,
+			&l.RequestedDate, &l.ConfirmedDate,
+			&l.CustomerNumber, &l.CustomerName,
+		); err != nil {
+			return nil, fmt.Errorf("failed to scan CO line: %w", err)
+		}
+		lines = append(lines, l)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read CO lines: %w", err)
+	}
+
+	return lines, nil
+}
@@ -5,8 +5,10 @@ import (
 	"fmt"
 	"log"
 	"net/http"
+	"strings"
 	"time"
 
+	"github.com/pinggolf/m3-planning-tools/internal/config"
 	"github.com/pinggolf/m3-planning-tools/internal/db"
 	"github.com/pinggolf/m3-planning-tools/internal/queue"
 	"github.com/pinggolf/m3-planning-tools/internal/services"
@@ -23,7 +25,7 @@ type DetectorInfo struct {
 
 // TriggerDetectionRequest represents a request to trigger specific detectors
 type TriggerDetectionRequest struct {
-	Environment   string   `json:"environment"`   // "TRN" or "PRD"
+	Environment   string   `json:"environment"`   // Environment key, e.g. "TRN"
 	DetectorNames []string `json:"detectorNames"` // List of detector names to run
 }
 
@@ -37,11 +39,11 @@ type TriggerDetectionResponse struct {
 }
 
 // HandleListDetectors returns all available detectors with their enabled status
-func HandleListDetectors(database *db.Queries) http.HandlerFunc {
+func HandleListDetectors(database *db.Queries, cfg *config.Config) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		environment := r.URL.Query().Get("environment")
 		if environment == "" {
-			environment = "TRN" // Default
+			environment = cfg.DefaultEnvironment() // Default
 		}
 
 		ctx := r.Context()
@@ -83,7 +85,7 @@ func HandleListDetectors(database *db.Queries) http.HandlerFunc {
 }
 
 // HandleTriggerDetection triggers specific detectors without a full refresh
-func HandleTriggerDetection(natsManager *queue.Manager, database *db.Queries) http.HandlerFunc {
+func HandleTriggerDetection(natsManager *queue.Manager, database *db.Queries, cfg *config.Config) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		var req TriggerDetectionRequest
 		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
@@ -92,8 +94,8 @@ func HandleTriggerDetection(natsManager *queue.Manager, database *db.Queries) ht
 		}
 
 		// Validate environment
-		if req.Environment != "TRN" && req.Environment != "PRD" {
-			http.Error(w, "environment must be TRN or PRD", http.StatusBadRequest)
+		if !cfg.HasEnvironment(req.Environment) {
+			http.Error(w, fmt.Sprintf("environment must be one of: %s", strings.Join(cfg.EnvironmentKeys(), ", ")), http.StatusBadRequest)
 			return
 		}
 
@@ -112,8 +114,8 @@ func HandleTriggerDetection(natsManager *queue.Manager, database *db.Queries) ht
 			return
 		}
 
-		// Get company and facility from latest refresh job data
-		company, facility, err := database.GetRefreshJobContext(ctx, latestRefreshJob.ID)
+		// Get company and facilities from latest refresh job data
+		company, facilities, err := database.GetRefreshJobContext(ctx, latestRefreshJob.ID)
 		if err != nil {
 			http.Error(w, fmt.Sprintf("Failed to get refresh job context: %v", err), http.StatusInternalServerError)
 			return
@@ -153,7 +155,8 @@ func HandleTriggerDetection(natsManager *queue.Manager, database *db.Queries) ht
 				DetectorName: detectorName,
 				Environment:  req.Environment,
 				Company:      company,
-				Facility:     facility,
+				Facility:     facilities[0],
+				Facilities:   facilities,
 			}
 
 			data, _ := json.Marshal(job)
@@ -175,7 +178,8 @@ func HandleTriggerDetection(natsManager *queue.Manager, database *db.Queries) ht
 			DetectorNames:  req.DetectorNames,
 			TotalDetectors: len(req.DetectorNames),
 			Company:        company,
-			Facility:       facility,
+			Facility:       facilities[0],
+			Facilities:     facilities,
 		}
 
 		coordData, _ := json.Marshal(coordinatorMsg)
@@ -86,28 +86,26 @@ func (m *Manager) Request(subject string, data []byte, timeout time.Duration) (*
 const (
 	// Snapshot refresh subjects
 	SubjectSnapshotRefresh       = "snapshot.refresh"
-	SubjectSnapshotRefreshTRN    = "snapshot.refresh.TRN"
-	SubjectSnapshotRefreshPRD    = "snapshot.refresh.PRD"
+	SubjectSnapshotRefreshEnv    = "snapshot.refresh.%s"       // snapshot.refresh.{environment}
 	SubjectSnapshotProgress      = "snapshot.progress.%s"      // snapshot.progress.{jobID}
 	SubjectSnapshotComplete      = "snapshot.complete.%s"      // snapshot.complete.{jobID}
 	SubjectSnapshotError         = "snapshot.error.%s"         // snapshot.error.{jobID}
 	SubjectSnapshotCancel        = "snapshot.cancel.%s"        // snapshot.cancel.{jobID}
 
 	// Batch distribution subjects (for parallel data loading)
-	SubjectSnapshotBatchTRN      = "snapshot.batch.TRN.>"      // Wildcard for all TRN batch jobs
-	SubjectSnapshotBatchPRD      = "snapshot.batch.PRD.>"      // Wildcard for all PRD batch jobs
 	SubjectBatchStart            = "snapshot.batch.start.%s"    // snapshot.batch.start.{parentJobId}
 	SubjectBatchComplete         = "snapshot.batch.complete.%s" // snapshot.batch.complete.{parentJobId}
+	SubjectBatchHeartbeat        = "snapshot.batch.heartbeat.%s" // snapshot.batch.heartbeat.{parentJobId}
 
 	// Detector distribution subjects (for parallel detector execution)
-	SubjectSnapshotDetectorTRN   = "snapshot.detector.TRN.>"      // Wildcard for all TRN detector jobs
-	SubjectSnapshotDetectorPRD   = "snapshot.detector.PRD.>"      // Wildcard for all PRD detector jobs
 	SubjectDetectorStart         = "snapshot.detector.start.%s"    // snapshot.detector.start.{parentJobId}
 	SubjectDetectorComplete      = "snapshot.detector.complete.%s" // snapshot.detector.complete.{parentJobId}
 
 	// Detector coordinator subjects (for manual detection triggers)
-	SubjectDetectorCoordinateTRN = "snapshot.detector.coordinate.TRN" // Coordinator jobs for TRN
-	SubjectDetectorCoordinatePRD = "snapshot.detector.coordinate.PRD" // Coordinator jobs for PRD
+	SubjectDetectorCoordinate    = "snapshot.detector.coordinate.%s" // snapshot.detector.coordinate.{environment}
+
+	// Bulk issue action subjects
+	SubjectBulkActionProgress    = "bulk.action.progress.%s"   // bulk.action.progress.{jobID}
 
 	// Analysis subjects
 	SubjectAnalysisRun           = "analysis.run"
@@ -121,15 +119,12 @@ const (
 )
 
 // GetSnapshotRefreshSubject returns the subject for snapshot refresh based on environment
+// Example: GetSnapshotRefreshSubject("TRN") → "snapshot.refresh.TRN"
 func GetSnapshotRefreshSubject(environment string) string {
-	switch environment {
-	case "TRN":
-		return SubjectSnapshotRefreshTRN
-	case "PRD":
-		return SubjectSnapshotRefreshPRD
-	default:
+	if environment == "" {
 		return SubjectSnapshotRefresh
 	}
+	return fmt.Sprintf(SubjectSnapshotRefreshEnv, environment)
 }
 
 // GetProgressSubject returns the progress subject for a job
@@ -147,6 +142,11 @@ func GetErrorSubject(jobID string) string {
 	return fmt.Sprintf(SubjectSnapshotError, jobID)
 }
 
+// GetBulkActionProgressSubject returns the progress subject for a bulk issue action job
+func GetBulkActionProgressSubject(jobID string) string {
+	return fmt.Sprintf(SubjectBulkActionProgress, jobID)
+}
+
 // GetBatchSubject returns the subject for a specific batch type
 // Example: GetBatchSubject("TRN", "mops") → "snapshot.batch.TRN.mops"
 func GetBatchSubject(environment, phase string) string {
@@ -171,6 +171,12 @@ func GetBatchCompleteSubject(parentJobID string) string {
 	return fmt.Sprintf(SubjectBatchComplete, parentJobID)
 }
 
+// GetBatchHeartbeatSubject returns the subject for batch heartbeats
+// Running batch jobs publish here periodically so the coordinator can detect lost workers
+func GetBatchHeartbeatSubject(parentJobID string) string {
+	return fmt.Sprintf(SubjectBatchHeartbeat, parentJobID)
+}
+
 // GetDetectorSubject returns the subject for a specific detector job
 // Example: GetDetectorSubject("TRN", "unlinked_production_orders") → "snapshot.detector.TRN.unlinked_production_orders"
 func GetDetectorSubject(environment, detectorName string) string {
@@ -192,12 +198,5 @@ func GetDetectorCompleteSubject(parentJobID string) string {
 // GetDetectorCoordinateSubject returns the subject for detector coordinator jobs
 // Example: GetDetectorCoordinateSubject("TRN") → "snapshot.detector.coordinate.TRN"
 func GetDetectorCoordinateSubject(environment string) string {
-	switch environment {
-	case "TRN":
-		return SubjectDetectorCoordinateTRN
-	case "PRD":
-		return SubjectDetectorCoordinatePRD
-	default:
-		return fmt.Sprintf("snapshot.detector.coordinate.%s", environment)
-	}
+	return fmt.Sprintf(SubjectDetectorCoordinate, environment)
 }
@@ -12,20 +12,18 @@ import (
 
 // ContextCacheWorker handles background refreshing of M3 context cache
 type ContextCacheWorker struct {
-	db          *db.Queries
-	m3ClientTRN *m3api.Client
-	m3ClientPRD *m3api.Client
-	stopChan    chan struct{}
-	wg          sync.WaitGroup
+	db        *db.Queries
+	m3Clients map[string]*m3api.Client // M3 API client per environment key
+	stopChan  chan struct{}
+	wg        sync.WaitGroup
 }
 
-// NewContextCacheWorker creates a new context cache worker
-func NewContextCacheWorker(queries *db.Queries, m3ClientTRN, m3ClientPRD *m3api.Client) *ContextCacheWorker {
+// NewContextCacheWorker creates a new context cache worker for the given environments' M3 clients
+func NewContextCacheWorker(queries *db.Queries, m3Clients map[string]*m3api.Client) *ContextCacheWorker {
 	return &ContextCacheWorker{
-		db:          queries,
-		m3ClientTRN: m3ClientTRN,
-		m3ClientPRD: m3ClientPRD,
-		stopChan:    make(chan struct{}),
+		db:        queries,
+		m3Clients: m3Clients,
+		stopChan:  make(chan struct{}),
 	}
 }
 
@@ -64,30 +62,24 @@ func (w *ContextCacheWorker) run() {
 	}
 }
 
-// refreshCache refreshes all M3 context data for both environments
+// refreshCache refreshes all M3 context data for every environment
 func (w *ContextCacheWorker) refreshCache() {
 	fmt.Println("Starting M3 context cache refresh...")
 	start := time.Now()
 
 	ctx := context.Background()
 
-	// Refresh for both environments in parallel
+	// Refresh all environments in parallel
 	var wg sync.WaitGroup
-	wg.Add(2)
-
-	go func() {
-		defer wg.Done()
-		if err := w.refreshEnvironmentCache(ctx, "TRN", w.m3ClientTRN); err != nil {
-			fmt.Printf("Error refreshing TRN cache: %v\n", err)
-		}
-	}()
-
-	go func() {
-		defer wg.Done()
-		if err := w.refreshEnvironmentCache(ctx, "PRD", w.m3ClientPRD); err != nil {
-			fmt.Printf("Error refreshing PRD cache: %v\n", err)
-		}
-	}()
+	for environment, m3Client := range w.m3Clients {
+		wg.Add(1)
+		go func(environment string, m3Client *m3api.Client) {
+			defer wg.Done()
+			if err := w.refreshEnvironmentCache(ctx, environment, m3Client); err != nil {
+				fmt.Printf("Error refreshing %s cache: %v\n", environment, err)
+			}
+		}(environment, m3Client)
+	}
 
 	wg.Wait()
 
@@ -131,13 +123,8 @@ func (w *ContextCacheWorker) PrimeCache(environment string) {
 
 	ctx := context.Background()
 
-	var m3Client *m3api.Client
-	switch environment {
-	case "TRN":
-		m3Client = w.m3ClientTRN
-	case "PRD":
-		m3Client = w.m3ClientPRD
-	default:
+	m3Client, ok := w.m3Clients[environment]
+	if !ok {
 		fmt.Printf("Unknown environment: %s\n", environment)
 		return
 	}
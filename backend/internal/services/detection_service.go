@@ -19,6 +19,7 @@ type DetectionService struct {
 	registry         *detectors.DetectorRegistry
 	configService    *DetectorConfigService
 	progressCallback ProgressCallback
+	notifier         *NotificationService
 }
 
 // NewDetectionService creates a new detection service
@@ -30,8 +31,12 @@ func NewDetectionService(database *db.Queries, configService *DetectorConfigServ
 	registry.Register(detectors.NewUnlinkedProductionOrdersDetector(configService))
 	registry.Register(detectors.NewJointDeliveryDateMismatchDetector(configService))
 	registry.Register(detectors.NewDLIXDateMismatchDetector(configService))
-	// DISABLED: CO Quantity Mismatch detector requires PAQT from MPTAWY table which has severe performance issues
-	// registry.Register(detectors.NewCOQuantityMismatchDetector(configService))
+	registry.Register(detectors.NewMaterialShortageDetector(configService))
+	registry.Register(detectors.NewWorkCenterOverloadDetector(configService))
+	registry.Register(detectors.NewLateDeliveryDetector(configService))
+	registry.Register(detectors.NewCOQuantityMismatchDetector(configService))
+	registry.Register(detectors.NewOrphanedCODemandDetector(configService))
+	registry.Register(detectors.NewMultiLevelDateConflictDetector(configService))
 
 	return &DetectionService{
 		db:            database,
@@ -45,6 +50,11 @@ func (s *DetectionService) SetProgressCallback(callback ProgressCallback) {
 	s.progressCallback = callback
 }
 
+// SetNotificationService enables subscribed notifications after RunAllDetectors
+func (s *DetectionService) SetNotificationService(notifier *NotificationService) {
+	s.notifier = notifier
+}
+
 // reportProgress calls the progress callback if set
 func (s *DetectionService) reportProgress(phase string, stepNum, totalSteps int, message string) {
 	if s.progressCallback != nil {
@@ -53,8 +63,9 @@ func (s *DetectionService) reportProgress(phase string, stepNum, totalSteps int,
 }
 
 // RunAllDetectors executes all registered detectors (respects enabled/disabled settings)
-func (s *DetectionService) RunAllDetectors(ctx context.Context, jobID, environment, company, facility string) error {
-	log.Printf("Starting issue detection for job %s (environment: %s, company: %s, facility: %s)", jobID, environment, company, facility)
+// for every facility the job loaded; issues from all facilities land in the same job
+func (s *DetectionService) RunAllDetectors(ctx context.Context, jobID, environment, company string, facilities []string) error {
+	log.Printf("Starting issue detection for job %s (environment: %s, company: %s, facilities: %v)", jobID, environment, company, facilities)
 
 	allDetectors := s.registry.GetAll()
 
@@ -109,7 +120,7 @@ func (s *DetectionService) RunAllDetectors(ctx context.Context, jobID, environme
 		log.Printf("Running detector %d/%d: %s", i+1, totalDetectors, detector.Name())
 		s.reportProgress("detection", i, totalDetectors, fmt.Sprintf("Running %s detector", detector.Description()))
 
-		issuesFound, err := detector.Detect(ctx, s.db, jobID, environment, company, facility)
+		issuesFound, err := s.RunDetector(ctx, detector, jobID, environment, company, facilities)
 		if err != nil {
 			log.Printf("Detector %s failed: %v", detector.Name(), err)
 			s.db.IncrementFailedDetectors(ctx, jobID)
@@ -134,20 +145,61 @@ func (s *DetectionService) RunAllDetectors(ctx context.Context, jobID, environme
 		return fmt.Errorf("failed to complete detection job: %w", err)
 	}
 
+	// Diff against the previous job: new, persisting and resolved issues
+	s.TrackIssueLifecycle(ctx, jobID, environment, facilities, issuesByType)
+
 	s.reportProgress("detection", totalDetectors, totalDetectors, fmt.Sprintf("Detection complete - %d issues found", totalIssues))
 
 	log.Printf("Issue detection completed - %d total issues found across %d enabled detectors", totalIssues, completedDetectors)
 
 	// Phase 2: Run anomaly detectors
 	log.Printf("Starting anomaly detection for job %s", jobID)
-	if err := s.RunAnomalyDetectors(ctx, jobID, environment, company, facility); err != nil {
+	if err := s.RunAnomalyDetectors(ctx, jobID, environment, company, facilities); err != nil {
 		log.Printf("Anomaly detection failed: %v", err)
 		// Don't fail the whole job if anomaly detection fails
 	}
 
+	// Phase 3: Notify subscribers of new anomalies and issues
+	if s.notifier != nil {
+		s.notifier.NotifyJob(ctx, environment, jobID)
+	}
+
 	return nil
 }
 
+// RunDetector executes one detector once per facility and returns the issues found across all of them
+// It stops at the first facility that fails
+func (s *DetectionService) RunDetector(ctx context.Context, detector detectors.IssueDetector, jobID, environment, company string, facilities []string) (int, error) {
+	issuesFound := 0
+	for _, facility := range facilities {
+		found, err := detector.Detect(ctx, s.db, jobID, environment, company, facility)
+		if err != nil {
+			return issuesFound, fmt.Errorf("facility %s: %w", facility, err)
+		}
+		issuesFound += found
+	}
+	return issuesFound, nil
+}
+
+// TrackIssueLifecycle updates first-seen/last-seen/resolved state for a job's issues
+// issuesByType holds only the detectors that completed, so failed detectors resolve nothing;
+// facilities are the facilities the job loaded, the only ones whose issues can be resolved
+func (s *DetectionService) TrackIssueLifecycle(ctx context.Context, jobID, environment string, facilities []string, issuesByType map[string]int) {
+	detectorTypes := make([]string, 0, len(issuesByType))
+	for detectorType := range issuesByType {
+		detectorTypes = append(detectorTypes, detectorType)
+	}
+
+	summary, err := s.db.UpdateIssueLifecycle(ctx, environment, jobID, facilities, detectorTypes)
+	if err != nil {
+		log.Printf("Warning: failed to update issue lifecycle for job %s: %v", jobID, err)
+		return
+	}
+
+	log.Printf("Issue lifecycle for job %s: %d new, %d persisting, %d resolved",
+		jobID, summary.NewIssues, summary.PersistingIssues, summary.ResolvedIssues)
+}
+
 // loadEnabledDetectors loads detector enable/disable settings from system_settings for a specific environment
 // Returns map of setting_key → enabled (true/false)
 // Default: all detectors enabled if setting doesn't exist
@@ -198,9 +250,9 @@ func (s *DetectionService) IsDetectorEnabled(ctx context.Context, environment, d
 	return true, nil // Default: enabled
 }
 
-// RunAnomalyDetectors executes all anomaly detectors
-func (s *DetectionService) RunAnomalyDetectors(ctx context.Context, jobID, environment, company, facility string) error {
-	log.Printf("Starting anomaly detection for job %s (environment: %s)", jobID, environment)
+// RunAnomalyDetectors executes all anomaly detectors for each facility the job loaded
+func (s *DetectionService) RunAnomalyDetectors(ctx context.Context, jobID, environment, company string, facilities []string) error {
+	log.Printf("Starting anomaly detection for job %s (environment: %s, facilities: %v)", jobID, environment, facilities)
 
 	// Get raw DB connection for anomaly detectors
 	rawDB := s.db.DB()
@@ -246,23 +298,25 @@ func (s *DetectionService) RunAnomalyDetectors(ctx context.Context, jobID, envir
 			continue
 		}
 
-		log.Printf("Running anomaly detector: %s", detector.Name())
-		alerts, err := detector.Detect(ctx, environment)
-		if err != nil {
-			log.Printf("Anomaly detector %s failed: %v", detector.Name(), err)
-			continue
-		}
-
-		// Store alerts in database
-		for _, alert := range alerts {
-			if err := s.storeAnomalyAlert(ctx, jobID, environment, facility, alert); err != nil {
-				log.Printf("Failed to store anomaly alert: %v", err)
+		for _, facility := range facilities {
+			log.Printf("Running anomaly detector: %s (facility: %s)", detector.Name(), facility)
+			alerts, err := detector.Detect(ctx, environment, facility)
+			if err != nil {
+				log.Printf("Anomaly detector %s failed for facility %s: %v", detector.Name(), facility, err)
 				continue
 			}
-			totalAlerts++
-		}
 
-		log.Printf("Anomaly detector %s found %d alerts", detector.Name(), len(alerts))
+			// Store alerts in database
+			for _, alert := range alerts {
+				if err := s.storeAnomalyAlert(ctx, jobID, environment, facility, alert); err != nil {
+					log.Printf("Failed to store anomaly alert: %v", err)
+					continue
+				}
+				totalAlerts++
+			}
+
+			log.Printf("Anomaly detector %s found %d alerts for facility %s", detector.Name(), len(alerts), facility)
+		}
 	}
 
 	log.Printf("Anomaly detection completed - %d total alerts found", totalAlerts)
@@ -271,6 +325,12 @@ func (s *DetectionService) RunAnomalyDetectors(ctx context.Context, jobID, envir
 
 // storeAnomalyAlert stores an anomaly alert in the anomaly_alerts table
 func (s *DetectionService) storeAnomalyAlert(ctx context.Context, jobID, environment, facility string, alert *detectors.AnomalyAlert) error {
+	// anomaly_alerts has no facility column, so the facility is kept with the metrics
+	if alert.Metrics == nil {
+		alert.Metrics = make(map[string]interface{})
+	}
+	alert.Metrics["facility"] = facility
+
 	// Convert metrics to JSON
 	metricsJSON, err := json.Marshal(alert.Metrics)
 	if err != nil {
@@ -137,6 +137,8 @@ func (s *DetectorConfigService) LoadFilters(ctx context.Context, environment, de
 		MinOrderAgeDays:      0,
 		ExcludeFacilities:    []string{},
 		MinQuantityThreshold: 0.0,
+		IncludeItemTypes:     []string{},
+		ExcludeItemTypes:     []string{},
 	}
 
 	prefix := fmt.Sprintf("detector_%s_", detectorName)
@@ -167,6 +169,10 @@ func (s *DetectorConfigService) LoadFilters(ctx context.Context, environment, de
 			json.Unmarshal([]byte(setting.SettingValue), &filters.ExcludeFacilities)
 		case strings.HasSuffix(key, "_min_quantity_threshold"):
 			filters.MinQuantityThreshold, _ = strconv.ParseFloat(setting.SettingValue, 64)
+		case strings.HasSuffix(key, "_include_item_types"):
+			json.Unmarshal([]byte(setting.SettingValue), &filters.IncludeItemTypes)
+		case strings.HasSuffix(key, "_exclude_item_types"):
+			json.Unmarshal([]byte(setting.SettingValue), &filters.ExcludeItemTypes)
 		}
 	}
 
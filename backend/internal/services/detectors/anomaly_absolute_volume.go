@@ -31,7 +31,7 @@ func (d *AbsoluteVolumeDetector) Name() string {
 }
 
 // Detect performs the anomaly detection
-func (d *AbsoluteVolumeDetector) Detect(ctx context.Context, env string) ([]*AnomalyAlert, error) {
+func (d *AbsoluteVolumeDetector) Detect(ctx context.Context, env, facility string) ([]*AnomalyAlert, error) {
 	query := `
 		SELECT
 			COALESCE(prno, 'UNKNOWN') as product,
@@ -39,6 +39,7 @@ func (d *AbsoluteVolumeDetector) Detect(ctx context.Context, env string) ([]*Ano
 			COUNT(*) as unlinked_count
 		FROM planned_manufacturing_orders
 		WHERE environment = $1
+		  AND faci = $3
 		  AND (linked_co_number IS NULL OR linked_co_number = '')
 		  AND deleted_remotely = false
 		  AND psts = '20'
@@ -48,7 +49,7 @@ func (d *AbsoluteVolumeDetector) Detect(ctx context.Context, env string) ([]*Ano
 		LIMIT 20
 	`
 
-	rows, err := d.DB.QueryContext(ctx, query, env, d.warningThreshold)
+	rows, err := d.DB.QueryContext(ctx, query, env, d.warningThreshold, facility)
 	if err != nil {
 		return nil, fmt.Errorf("failed to query absolute volume: %w", err)
 	}
@@ -34,7 +34,7 @@ func (d *DateClusteringDetector) Name() string {
 }
 
 // Detect performs the anomaly detection
-func (d *DateClusteringDetector) Detect(ctx context.Context, env string) ([]*AnomalyAlert, error) {
+func (d *DateClusteringDetector) Detect(ctx context.Context, env, facility string) ([]*AnomalyAlert, error) {
 	query := `
 		WITH product_totals AS (
 			SELECT
@@ -42,6 +42,7 @@ func (d *DateClusteringDetector) Detect(ctx context.Context, env string) ([]*Ano
 				COUNT(*) as total_mops
 			FROM planned_manufacturing_orders
 			WHERE environment = $1
+			  AND faci = $4
 			  AND (linked_co_number IS NULL OR linked_co_number = '')
 			  AND deleted_remotely = false
 			  AND psts = '20'
@@ -57,6 +58,7 @@ func (d *DateClusteringDetector) Detect(ctx context.Context, env string) ([]*Ano
 		FROM planned_manufacturing_orders mop
 		INNER JOIN product_totals pt ON mop.prno = pt.prno
 		WHERE mop.environment = $1
+		  AND mop.faci = $4
 		  AND (mop.linked_co_number IS NULL OR mop.linked_co_number = '')
 		  AND mop.deleted_remotely = false
 		  AND mop.psts = '20'
@@ -67,7 +69,7 @@ func (d *DateClusteringDetector) Detect(ctx context.Context, env string) ([]*Ano
 		LIMIT 20
 	`
 
-	rows, err := d.DB.QueryContext(ctx, query, env, d.minAffectedCount, d.warningThreshold)
+	rows, err := d.DB.QueryContext(ctx, query, env, d.minAffectedCount, d.warningThreshold, facility)
 	if err != nil {
 		return nil, fmt.Errorf("failed to query date clustering: %w", err)
 	}
@@ -12,8 +12,8 @@ type AnomalyDetector interface {
 	// Name returns the unique identifier for this detector
 	Name() string
 
-	// Detect performs anomaly detection and returns alerts
-	Detect(ctx context.Context, env string) ([]*AnomalyAlert, error)
+	// Detect performs anomaly detection on one facility's data and returns alerts
+	Detect(ctx context.Context, env, facility string) ([]*AnomalyAlert, error)
 
 	// Enabled returns whether this detector is currently enabled
 	Enabled() bool
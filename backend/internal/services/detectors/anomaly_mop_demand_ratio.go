@@ -32,7 +32,7 @@ func (d *MOPDemandRatioDetector) Name() string {
 }
 
 // Detect performs the anomaly detection
-func (d *MOPDemandRatioDetector) Detect(ctx context.Context, env string) ([]*AnomalyAlert, error) {
+func (d *MOPDemandRatioDetector) Detect(ctx context.Context, env, facility string) ([]*AnomalyAlert, error) {
 	query := `
 		WITH product_demand AS (
 			SELECT
@@ -42,6 +42,7 @@ func (d *MOPDemandRatioDetector) Detect(ctx context.Context, env string) ([]*Ano
 				SUM(CAST(orqt AS DECIMAL)) as total_demand_qty
 			FROM customer_order_lines
 			WHERE environment = $1
+			  AND faci = $4
 			  AND orst >= '20' AND orst < '66'
 			GROUP BY itno, whlo
 		),
@@ -52,6 +53,7 @@ func (d *MOPDemandRatioDetector) Detect(ctx context.Context, env string) ([]*Ano
 				COUNT(*) as unlinked_mop_count
 			FROM planned_manufacturing_orders
 			WHERE environment = $1
+			  AND faci = $4
 			  AND (linked_co_number IS NULL OR linked_co_number = '')
 			  AND deleted_remotely = false
 			  AND psts = '20'
@@ -73,7 +75,7 @@ func (d *MOPDemandRatioDetector) Detect(ctx context.Context, env string) ([]*Ano
 		LIMIT 20
 	`
 
-	rows, err := d.DB.QueryContext(ctx, query, env, d.warningMOPsPerCOLine, d.criticalMOPsPerUnitDemand)
+	rows, err := d.DB.QueryContext(ctx, query, env, d.warningMOPsPerCOLine, d.criticalMOPsPerUnitDemand, facility)
 	if err != nil {
 		return nil, fmt.Errorf("failed to query MOP-to-demand ratio: %w", err)
 	}
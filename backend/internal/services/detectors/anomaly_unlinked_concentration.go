@@ -33,7 +33,7 @@ func (d *UnlinkedConcentrationDetector) Name() string {
 }
 
 // Detect performs the anomaly detection
-func (d *UnlinkedConcentrationDetector) Detect(ctx context.Context, env string) ([]*AnomalyAlert, error) {
+func (d *UnlinkedConcentrationDetector) Detect(ctx context.Context, env, facility string) ([]*AnomalyAlert, error) {
 	var alerts []*AnomalyAlert
 
 	// Get total unlinked count once for both queries
@@ -42,23 +42,24 @@ func (d *UnlinkedConcentrationDetector) Detect(ctx context.Context, env string)
 		SELECT COUNT(*)
 		FROM planned_manufacturing_orders
 		WHERE environment = $1
+		  AND faci = $2
 		  AND (linked_co_number IS NULL OR linked_co_number = '')
 		  AND deleted_remotely = false
 		  AND psts = '20'
-	`, env).Scan(&totalUnlinked); err != nil {
+	`, env, facility).Scan(&totalUnlinked); err != nil {
 		log.Printf("Failed to get total unlinked count: %v", err)
 		return nil, fmt.Errorf("failed to get total unlinked count: %w", err)
 	}
 
 	// Check product concentration
-	productAlerts, err := d.detectProductConcentration(ctx, env, totalUnlinked)
+	productAlerts, err := d.detectProductConcentration(ctx, env, facility, totalUnlinked)
 	if err != nil {
 		return nil, err
 	}
 	alerts = append(alerts, productAlerts...)
 
 	// Check CFIN concentration
-	cfinAlerts, err := d.detectCFINConcentration(ctx, env, totalUnlinked)
+	cfinAlerts, err := d.detectCFINConcentration(ctx, env, facility, totalUnlinked)
 	if err != nil {
 		return nil, err
 	}
@@ -68,7 +69,7 @@ func (d *UnlinkedConcentrationDetector) Detect(ctx context.Context, env string)
 }
 
 // detectProductConcentration detects product-based concentration anomalies
-func (d *UnlinkedConcentrationDetector) detectProductConcentration(ctx context.Context, env string, totalUnlinked int) ([]*AnomalyAlert, error) {
+func (d *UnlinkedConcentrationDetector) detectProductConcentration(ctx context.Context, env, facility string, totalUnlinked int) ([]*AnomalyAlert, error) {
 	query := `
 		WITH total_unlinked AS (
 			SELECT $4::integer as total
@@ -80,6 +81,7 @@ func (d *UnlinkedConcentrationDetector) detectProductConcentration(ctx context.C
 			ROUND((COUNT(*) * 100.0 / NULLIF((SELECT total FROM total_unlinked), 0))::numeric, 2) as concentration_pct
 		FROM planned_manufacturing_orders
 		WHERE environment = $1
+			AND faci = $5
 			AND (linked_co_number IS NULL OR linked_co_number = '')
 			AND deleted_remotely = false
 			AND psts = '20'
@@ -90,7 +92,7 @@ func (d *UnlinkedConcentrationDetector) detectProductConcentration(ctx context.C
 		LIMIT 20
 	`
 
-	rows, err := d.DB.QueryContext(ctx, query, env, d.minAffectedCount, d.warningThreshold, totalUnlinked)
+	rows, err := d.DB.QueryContext(ctx, query, env, d.minAffectedCount, d.warningThreshold, totalUnlinked, facility)
 	if err != nil {
 		return nil, fmt.Errorf("failed to query product concentration: %w", err)
 	}
@@ -150,7 +152,7 @@ func (d *UnlinkedConcentrationDetector) detectProductConcentration(ctx context.C
 }
 
 // detectCFINConcentration detects CFIN-based concentration anomalies
-func (d *UnlinkedConcentrationDetector) detectCFINConcentration(ctx context.Context, env string, totalUnlinked int) ([]*AnomalyAlert, error) {
+func (d *UnlinkedConcentrationDetector) detectCFINConcentration(ctx context.Context, env, facility string, totalUnlinked int) ([]*AnomalyAlert, error) {
 	query := `
 		WITH total_unlinked AS (
 			SELECT $4::integer as total
@@ -162,6 +164,7 @@ func (d *UnlinkedConcentrationDetector) detectCFINConcentration(ctx context.Cont
 			ROUND((COUNT(*) * 100.0 / NULLIF((SELECT total FROM total_unlinked), 0))::numeric, 2) as concentration_pct
 		FROM planned_manufacturing_orders
 		WHERE environment = $1
+			AND faci = $5
 			AND (linked_co_number IS NULL OR linked_co_number = '')
 			AND deleted_remotely = false
 			AND psts = '20'
@@ -174,7 +177,7 @@ func (d *UnlinkedConcentrationDetector) detectCFINConcentration(ctx context.Cont
 		LIMIT 20
 	`
 
-	rows, err := d.DB.QueryContext(ctx, query, env, d.minAffectedCount, d.warningThreshold, totalUnlinked)
+	rows, err := d.DB.QueryContext(ctx, query, env, d.minAffectedCount, d.warningThreshold, totalUnlinked, facility)
 	if err != nil {
 		return nil, fmt.Errorf("failed to query CFIN concentration: %w", err)
 	}
@@ -6,12 +6,14 @@ import (
 	"encoding/json"
 	"fmt"
 	"log"
+	"math"
 
 	"github.com/pinggolf/m3-planning-tools/internal/db"
 )
 
 // COQuantityMismatchDetector finds CO lines where production order quantities don't match remaining quantity
 // Implements M3 putaway logic: MOs don't reduce CO remaining qty until putaway complete
+// Pending putaway is the MO's reported quantity not yet received into stock (MWOHED MAQT - RVQT)
 type COQuantityMismatchDetector struct {
 	configService ConfigService
 }
@@ -44,10 +46,18 @@ func (d *COQuantityMismatchDetector) Detect(ctx context.Context, queries *db.Que
 		toleranceRaw = float64(0.01)
 	}
 	tolerance := toleranceRaw.(float64)
-	log.Printf("[%s] Using tolerance_threshold = %.6f for facility %s", d.Name(), tolerance, facility)
+
+	// Variances below the minimum quantity threshold are not reported, even when beyond tolerance
+	filters, err := d.configService.LoadFilters(ctx, environment, d.Name())
+	if err != nil {
+		log.Printf("[%s] Warning: failed to load filters: %v (reporting all variances)", d.Name(), err)
+	}
+	reportThreshold := math.Max(tolerance, filters.MinQuantityThreshold)
+	log.Printf("[%s] Using tolerance_threshold = %.6f, min_quantity_threshold = %.6f for facility %s",
+		d.Name(), tolerance, filters.MinQuantityThreshold, facility)
 
 	// Build the detection query with putaway logic
-	query := fmt.Sprintf(`
+	query := `
 WITH production_orders_countable AS (
     -- Determine which POs should count toward CO line supply
     SELECT
@@ -154,12 +164,12 @@ quantity_mismatches AS (
         AND agg.environment = col.environment
     WHERE col.orst >= '20' AND col.orst < '30'  -- Reserved only
       AND col.rnqa IS NOT NULL AND col.rnqa != ''
-      AND ABS(agg.total_po_quantity - CAST(NULLIF(col.rnqa, '') AS DECIMAL)) > %f  -- tolerance
+      AND ABS(agg.total_po_quantity - CAST(NULLIF(col.rnqa, '') AS DECIMAL)) > $4  -- tolerance
 )
 SELECT * FROM quantity_mismatches ORDER BY ABS(quantity_variance) DESC
-`, tolerance)
+`
 
-	rows, err := queries.DB().QueryContext(ctx, query, environment, company, facility)
+	rows, err := queries.DB().QueryContext(ctx, query, environment, company, facility, reportThreshold)
 	if err != nil {
 		return 0, fmt.Errorf("failed to query quantity mismatches: %w", err)
 	}
@@ -212,6 +222,7 @@ SELECT * FROM quantity_mismatches ORDER BY ABS(quantity_variance) DESC
 			"delivery_method":        deliveryMethod,
 			"co_status":              coStatus,
 			"tolerance_threshold":    tolerance,
+			"min_quantity_threshold": filters.MinQuantityThreshold,
 			"production_orders":      orders,
 		}
 
@@ -3,7 +3,6 @@ package detectors
 import (
 	"context"
 	"fmt"
-	"strings"
 
 	"github.com/pinggolf/m3-planning-tools/internal/db"
 )
@@ -25,32 +24,49 @@ type DetectorFilters struct {
 	MinOrderAgeDays      int
 	ExcludeFacilities    []string
 	MinQuantityThreshold float64
+	IncludeItemTypes     []string
+	ExcludeItemTypes     []string
 }
 
 // Helper functions shared by all detectors
 
+// The filter builders below add their values to args and return clauses with placeholders;
+// columnName is always a constant in the calling detector
+
 // buildStatusExclusionSQL builds a WHERE clause to exclude specific statuses
-func buildStatusExclusionSQL(columnName string, statuses []string) string {
+func buildStatusExclusionSQL(args *db.QueryArgs, columnName string, statuses []string) string {
 	if len(statuses) == 0 {
 		return ""
 	}
-	quoted := make([]string, len(statuses))
-	for i, s := range statuses {
-		quoted[i] = fmt.Sprintf("'%s'", s)
-	}
-	return fmt.Sprintf("AND %s NOT IN (%s)", columnName, strings.Join(quoted, ","))
+	return fmt.Sprintf("AND %s <> ALL(%s)", columnName, args.AddArray(statuses))
 }
 
 // buildFacilityExclusionSQL builds a WHERE clause to exclude specific facilities
-func buildFacilityExclusionSQL(facilities []string) string {
+func buildFacilityExclusionSQL(args *db.QueryArgs, facilities []string) string {
 	if len(facilities) == 0 {
 		return ""
 	}
-	quoted := make([]string, len(facilities))
-	for i, f := range facilities {
-		quoted[i] = fmt.Sprintf("'%s'", f)
+	return fmt.Sprintf("AND faci <> ALL(%s)", args.AddArray(facilities))
+}
+
+// buildItemTypeFilterSQL builds a WHERE clause restricting a query to (or excluding) specific MITMAS item types
+func buildItemTypeFilterSQL(args *db.QueryArgs, columnName string, include, exclude []string) string {
+	clause := ""
+	if len(include) > 0 {
+		clause += fmt.Sprintf("AND %s = ANY(%s) ", columnName, args.AddArray(include))
+	}
+	if len(exclude) > 0 {
+		clause += fmt.Sprintf("AND COALESCE(%s, '') <> ALL(%s)", columnName, args.AddArray(exclude))
+	}
+	return clause
+}
+
+// buildMinQuantitySQL builds a WHERE clause keeping rows whose quantity column is at least minQuantity
+func buildMinQuantitySQL(args *db.QueryArgs, columnName string, minQuantity float64) string {
+	if minQuantity <= 0 {
+		return ""
 	}
-	return fmt.Sprintf("AND faci NOT IN (%s)", strings.Join(quoted, ","))
+	return fmt.Sprintf("AND CAST(%s AS DECIMAL) >= %s", columnName, args.Add(minQuantity))
 }
 
 // IssueDetector interface - all detectors must implement this
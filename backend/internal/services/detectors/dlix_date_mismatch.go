@@ -50,7 +50,7 @@ func (d *DLIXDateMismatchDetector) Detect(ctx context.Context, queries *db.Queri
 
 	// Find DLIX groups with start dates beyond tolerance
 	// Group by linked_co_number + dlix to analyze delivery groups within same customer order
-	query := fmt.Sprintf(`
+	query := `
 		WITH dlix_production_orders AS (
 			SELECT
 				po.linked_co_number as co_number,
@@ -127,7 +127,7 @@ func (d *DLIXDateMismatchDetector) Detect(ctx context.Context, queries *db.Queri
 			HAVING (
 				-- TO_DATE subtraction returns integer days directly, no EXTRACT needed
 				(TO_DATE(MAX(planned_start_date), 'YYYYMMDD') -
-				 TO_DATE(MIN(planned_start_date), 'YYYYMMDD')) > %d
+				 TO_DATE(MIN(planned_start_date), 'YYYYMMDD')) > $4
 			)
 		)
 		SELECT
@@ -144,9 +144,9 @@ func (d *DLIXDateMismatchDetector) Detect(ctx context.Context, queries *db.Queri
 			dates,
 			orders
 		FROM mismatched_dlix_groups
-	`, toleranceDays)
+	`
 
-	rows, err := queries.DB().QueryContext(ctx, query, environment, company, facility)
+	rows, err := queries.DB().QueryContext(ctx, query, environment, company, facility, toleranceDays)
 	if err != nil {
 		return 0, fmt.Errorf("failed to query delivery date mismatches: %w", err)
 	}
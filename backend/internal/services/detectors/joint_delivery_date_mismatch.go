@@ -50,7 +50,7 @@ func (d *JointDeliveryDateMismatchDetector) Detect(ctx context.Context, queries
 
 	// Find JDCD groups with start dates beyond tolerance
 	// Group by linked_co_number + jdcd to analyze joint delivery groups within same customer order
-	query := fmt.Sprintf(`
+	query := `
 		WITH jdcd_production_orders AS (
 			SELECT
 				po.linked_co_number as co_number,
@@ -127,7 +127,7 @@ func (d *JointDeliveryDateMismatchDetector) Detect(ctx context.Context, queries
 			HAVING (
 				-- TO_DATE subtraction returns integer days directly, no EXTRACT needed
 				(TO_DATE(MAX(planned_start_date), 'YYYYMMDD') -
-				 TO_DATE(MIN(planned_start_date), 'YYYYMMDD')) > %d
+				 TO_DATE(MIN(planned_start_date), 'YYYYMMDD')) > $4
 			)
 		)
 		SELECT
@@ -144,9 +144,9 @@ func (d *JointDeliveryDateMismatchDetector) Detect(ctx context.Context, queries
 			dates,
 			orders
 		FROM mismatched_jdcd_groups
-	`, toleranceDays)
+	`
 
-	rows, err := queries.DB().QueryContext(ctx, query, environment, company, facility)
+	rows, err := queries.DB().QueryContext(ctx, query, environment, company, facility, toleranceDays)
 	if err != nil {
 		return 0, fmt.Errorf("failed to query joint delivery date mismatches: %w", err)
 	}
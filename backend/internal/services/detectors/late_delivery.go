@@ -0,0 +1,275 @@
+package detectors
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"log"
+
+	"github.com/pinggolf/m3-planning-tools/internal/db"
+)
+
+// LateDeliveryDetector finds MOs/MOPs that finish too late for their linked CO line's delivery date
+type LateDeliveryDetector struct {
+	configService ConfigService
+}
+
+// NewLateDeliveryDetector creates a new detector with config service
+func NewLateDeliveryDetector(configService ConfigService) *LateDeliveryDetector {
+	return &LateDeliveryDetector{configService: configService}
+}
+
+func (d *LateDeliveryDetector) Name() string {
+	return "late_delivery"
+}
+
+func (d *LateDeliveryDetector) Label() string {
+	return "Late to Delivery"
+}
+
+func (d *LateDeliveryDetector) Description() string {
+	return "Detects production orders finishing after their linked CO line's confirmed delivery date minus transport lead time"
+}
+
+// linkedOrderDates is a production order linked to a CO line, with the dates being compared
+type linkedOrderDates struct {
+	orderNumber           string
+	orderType             string
+	facility              string
+	warehouse             string
+	itemNumber            string
+	productNumber         string
+	moType                string
+	quantity              string
+	plannedStartDate      string
+	plannedFinishDate     string
+	coNumber              string
+	coLine                string
+	coSuffix              string
+	confirmedDeliveryDate string
+	requestedDeliveryDate string
+	customerNumber        string
+	customerName          string
+	coTypeNumber          string
+	coTypeDescription     string
+	deliveryMethod        string
+}
+
+// thresholdScope identifies the warehouse and MO type a threshold is resolved for
+type thresholdScope struct {
+	warehouse string
+	moType    string
+}
+
+func (d *LateDeliveryDetector) Detect(ctx context.Context, queries *db.Queries, refreshJobID, environment, company, facility string) (int, error) {
+	log.Printf("[%s] Running detector for environment %s, facility %s, refresh job %s", d.Name(), environment, facility, refreshJobID)
+
+	orders, err := d.loadLinkedOrders(ctx, queries, environment, company, facility)
+	if err != nil {
+		return 0, err
+	}
+
+	// Thresholds can be overridden per warehouse, facility and MO type - resolve each scope once
+	toleranceDays := make(map[thresholdScope]int)
+	leadTimeDays := make(map[thresholdScope]int)
+	resolve := func(parameter string, cache map[thresholdScope]int, order linkedOrderDates) int {
+		scope := thresholdScope{warehouse: order.warehouse, moType: order.moType}
+		if value, ok := cache[scope]; ok {
+			return value
+		}
+		var warehouse, moType *string
+		if order.warehouse != "" {
+			warehouse = &order.warehouse
+		}
+		if order.moType != "" {
+			moType = &order.moType
+		}
+		value := 0
+		raw, found, err := d.configService.ResolveThreshold(ctx, environment, d.Name(), parameter, warehouse, &facility, moType)
+		if err != nil || !found {
+			log.Printf("[%s] Warning: failed to resolve %s: %v (using default 0)", d.Name(), parameter, err)
+		} else if number, ok := raw.(float64); ok {
+			value = int(number)
+		}
+		cache[scope] = value
+		return value
+	}
+
+	issuesFound := 0
+
+	for _, order := range orders {
+		finishDate, ok := parseM3Date(order.plannedFinishDate)
+		if !ok {
+			continue
+		}
+
+		// Compare against the confirmed delivery date, or the requested date if none is confirmed yet
+		deliveryDate, ok := parseM3Date(order.confirmedDeliveryDate)
+		deliveryDateSource := "confirmed"
+		if !ok {
+			deliveryDate, ok = parseM3Date(order.requestedDeliveryDate)
+			deliveryDateSource = "requested"
+		}
+		if !ok {
+			continue
+		}
+
+		tolerance := resolve("tolerance_days", toleranceDays, order)
+		leadTime := resolve("lead_time_days", leadTimeDays, order)
+
+		// The order must finish by the delivery date minus the transport lead time
+		requiredFinishDate := deliveryDate.AddDate(0, 0, -leadTime)
+		daysLate := int(finishDate.Sub(requiredFinishDate).Hours() / 24)
+		if daysLate <= tolerance {
+			continue
+		}
+
+		issueData := map[string]interface{}{
+			"planned_start_date":      order.plannedStartDate,
+			"planned_finish_date":     order.plannedFinishDate,
+			"confirmed_delivery_date": order.confirmedDeliveryDate,
+			"requested_delivery_date": order.requestedDeliveryDate,
+			"delivery_date_source":    deliveryDateSource,
+			"required_finish_date":    formatM3Date(requiredFinishDate),
+			"days_late":               daysLate,
+			"lead_time_days":          leadTime,
+			"tolerance_days":          tolerance,
+			"item_number":             order.itemNumber,
+			"product_number":          order.productNumber,
+			"quantity":                order.quantity,
+			"warehouse":               order.warehouse,
+			"company":                 company,
+			"customer_number":         order.customerNumber,
+			"customer_name":           order.customerName,
+			"co_type_number":          order.coTypeNumber,
+			"co_type_description":     order.coTypeDescription,
+			"delivery_method":         order.deliveryMethod,
+		}
+		if order.moType != "" {
+			issueData["mo_type"] = order.moType
+		}
+
+		if err := d.insertIssue(ctx, queries, refreshJobID, environment, order, issueData); err != nil {
+			log.Printf("Error inserting issue: %v", err)
+			continue
+		}
+
+		issuesFound++
+	}
+
+	log.Printf("[%s] Found %d production orders finishing too late for delivery (%d linked orders checked)", d.Name(), issuesFound, len(orders))
+	return issuesFound, nil
+}
+
+// loadLinkedOrders returns the production orders linked to an open CO line, with the line's delivery dates
+func (d *LateDeliveryDetector) loadLinkedOrders(ctx context.Context, queries *db.Queries, environment, company, facility string) ([]linkedOrderDates, error) {
+	rows, err := queries.DB().QueryContext(ctx, `
+		SELECT
+			po.order_number,
+			po.order_type,
+			po.faci,
+			po.warehouse,
+			po.itno,
+			po.prno,
+			po.orty,
+			po.ordered_quantity,
+			po.planned_start_date,
+			po.planned_finish_date,
+			po.linked_co_number,
+			po.linked_co_line,
+			po.linked_co_suffix,
+			col.codt,
+			col.dwdt,
+			col.cuno,
+			col.customer_name,
+			col.ortp,
+			col.co_type_description,
+			col.delivery_method
+		FROM production_orders po
+		INNER JOIN customer_order_lines col
+			ON po.linked_co_number = col.orno
+			AND po.linked_co_line = col.ponr
+			AND po.linked_co_suffix = col.posx
+			AND po.environment = col.environment
+		WHERE po.environment = $1
+		  AND po.cono = $2
+		  AND po.faci = $3
+		  AND po.planned_finish_date IS NOT NULL
+		  AND po.planned_finish_date != ''
+		  AND po.deleted_remotely = false
+		  AND col.orst >= '20'
+		  AND col.orst < '30'
+		ORDER BY po.planned_finish_date, po.order_type, po.order_number
+	`, environment, company, facility)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query production orders linked to CO lines: %w", err)
+	}
+	defer rows.Close()
+
+	var orders []linkedOrderDates
+	for rows.Next() {
+		var order linkedOrderDates
+		var warehouse, itno, prno, orty, quantity, startDate sql.NullString
+		var codt, dwdt, cuno, customerName, ortp, coTypeDescription, deliveryMethod sql.NullString
+
+		if err := rows.Scan(
+			&order.orderNumber, &order.orderType, &order.facility, &warehouse, &itno, &prno, &orty, &quantity,
+			&startDate, &order.plannedFinishDate,
+			&order.coNumber, &order.coLine, &order.coSuffix,
+			&codt, &dwdt, &cuno, &customerName, &ortp, &coTypeDescription, &deliveryMethod,
+		); err != nil {
+			return nil, fmt.Errorf("failed to scan linked production order: %w", err)
+		}
+
+		order.warehouse = warehouse.String
+		order.itemNumber = itno.String
+		order.productNumber = prno.String
+		order.moType = orty.String
+		order.quantity = quantity.String
+		order.plannedStartDate = startDate.String
+		order.confirmedDeliveryDate = codt.String
+		order.requestedDeliveryDate = dwdt.String
+		order.customerNumber = cuno.String
+		order.customerName = customerName.String
+		order.coTypeNumber = ortp.String
+		order.coTypeDescription = coTypeDescription.String
+		order.deliveryMethod = deliveryMethod.String
+		orders = append(orders, order)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read linked production orders: %w", err)
+	}
+
+	return orders, nil
+}
+
+func (d *LateDeliveryDetector) insertIssue(ctx context.Context, queries *db.Queries, refreshJobID, environment string, order linkedOrderDates, issueData map[string]interface{}) error {
+	issueDataJSON, _ := json.Marshal(issueData)
+
+	query := `
+		INSERT INTO detected_issues (
+			environment, job_id, detector_type, facility, warehouse,
+			issue_key, production_order_number, production_order_type,
+			co_number, co_line, co_suffix,
+			issue_data
+		)
+		VALUES (
+			$1, $2, $3, $4, $5,
+			$6, $7, $8,
+			$9, $10, $11,
+			$12
+		)
+	`
+
+	issueKey := order.orderNumber // One issue per late production order
+
+	_, err := queries.DB().ExecContext(ctx, query,
+		environment, refreshJobID, d.Name(), order.facility, nullIfEmpty(order.warehouse),
+		issueKey, order.orderNumber, order.orderType,
+		order.coNumber, order.coLine, order.coSuffix,
+		issueDataJSON,
+	)
+
+	return err
+}
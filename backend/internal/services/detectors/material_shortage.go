@@ -0,0 +1,453 @@
+package detectors
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"log"
+	"math"
+	"time"
+
+	"github.com/pinggolf/m3-planning-tools/internal/db"
+)
+
+// MaterialShortageDetector finds MOs and firmed MOPs starting soon whose components are not covered by stock
+type MaterialShortageDetector struct {
+	configService ConfigService
+}
+
+// NewMaterialShortageDetector creates a new detector with config service
+func NewMaterialShortageDetector(configService ConfigService) *MaterialShortageDetector {
+	return &MaterialShortageDetector{configService: configService}
+}
+
+func (d *MaterialShortageDetector) Name() string {
+	return "material_shortage"
+}
+
+func (d *MaterialShortageDetector) Label() string {
+	return "Material Shortages"
+}
+
+func (d *MaterialShortageDetector) Description() string {
+	return "Detects MOs and firmed MOPs starting within the horizon whose components are not covered by on-hand stock"
+}
+
+// materialDemand is the open requirement of one component line of an MO or exploded MOP
+type materialDemand struct {
+	orderType      string
+	orderNumber    string
+	faci           string
+	orderWarehouse string
+	itno           string
+	prno           string
+	moType         string
+	startDate      string
+	warehouse      string // Component warehouse
+	component      string
+	sequence       string
+	operation      string
+	unit           string
+	required       float64
+	coNumber       string
+	coLine         string
+	coSuffix       string
+}
+
+// stockKey identifies an item balance
+type stockKey struct {
+	warehouse string
+	item      string
+}
+
+// stockBalance is the on-hand stock of an item in a warehouse, and what is left of it
+// after earlier demands have been covered
+type stockBalance struct {
+	onHand    float64
+	allocated float64
+	remaining float64
+}
+
+// stockLocation is approved stock on one location and lot
+type stockLocation struct {
+	Location  string  `json:"location"`
+	Lot       string  `json:"lot,omitempty"`
+	OnHand    float64 `json:"on_hand"`
+	Allocated float64 `json:"allocated"`
+}
+
+// maxStockLocations caps the locations listed per short component
+const maxStockLocations = 10
+
+func (d *MaterialShortageDetector) Detect(ctx context.Context, queries *db.Queries, refreshJobID, environment, company, facility string) (int, error) {
+	log.Printf("[%s] Running detector for environment %s, facility %s, refresh job %s", d.Name(), environment, facility, refreshJobID)
+
+	// Resolve horizon_days threshold (use facility scope, no warehouse/MO type)
+	horizonDaysRaw, foundHorizon, err := d.configService.ResolveThreshold(
+		ctx, environment, d.Name(), "horizon_days", nil, &facility, nil)
+	if err != nil || !foundHorizon {
+		log.Printf("[%s] Warning: failed to resolve horizon_days: %v (using default 14)", d.Name(), err)
+		horizonDaysRaw = float64(14)
+	}
+
+	horizonDays := int(horizonDaysRaw.(float64))
+	horizonEnd := time.Now().AddDate(0, 0, horizonDays)
+	horizonEndInt := horizonEnd.Year()*10000 + int(horizonEnd.Month())*100 + horizonEnd.Day()
+	log.Printf("[%s] Using horizon_days = %d (start dates up to %d) for facility %s", d.Name(), horizonDays, horizonEndInt, facility)
+
+	balances, err := d.loadBalances(ctx, queries, environment, company, facility)
+	if err != nil {
+		return 0, err
+	}
+
+	demands, err := d.loadDemands(ctx, queries, environment, company, facility, horizonEndInt)
+	if err != nil {
+		return 0, err
+	}
+
+	// Cover demands from stock in start date order; whatever is left uncovered is short
+	type orderShortage struct {
+		demand     materialDemand
+		components []map[string]interface{}
+	}
+	shortages := make(map[string]*orderShortage)
+	var orderKeys []string
+
+	for _, demand := range demands {
+		key := stockKey{warehouse: demand.warehouse, item: demand.component}
+		balance := balances[key]
+		if balance == nil {
+			balance = &stockBalance{}
+			balances[key] = balance
+		}
+
+		available := balance.remaining
+		covered := math.Min(available, demand.required)
+		balance.remaining -= covered
+		shortage := demand.required - covered
+		if shortage <= 1e-9 {
+			continue
+		}
+
+		orderKey := demand.orderType + ":" + demand.orderNumber
+		order, exists := shortages[orderKey]
+		if !exists {
+			order = &orderShortage{demand: demand}
+			shortages[orderKey] = order
+			orderKeys = append(orderKeys, orderKey)
+		}
+
+		order.components = append(order.components, map[string]interface{}{
+			"component":          demand.component,
+			"warehouse":          demand.warehouse,
+			"sequence":           demand.sequence,
+			"operation":          demand.operation,
+			"unit":               demand.unit,
+			"required_quantity":  roundQuantity(demand.required),
+			"available_quantity": roundQuantity(available),
+			"shortage_quantity":  roundQuantity(shortage),
+			"on_hand_quantity":   roundQuantity(balance.onHand),
+			"allocated_quantity": roundQuantity(balance.allocated),
+		})
+	}
+
+	if len(orderKeys) > 0 {
+		locations, err := d.loadLocations(ctx, queries, environment, company, facility)
+		if err != nil {
+			log.Printf("[%s] Warning: %v (issues will not list stock locations)", d.Name(), err)
+		}
+		for _, orderKey := range orderKeys {
+			for _, component := range shortages[orderKey].components {
+				key := stockKey{warehouse: component["warehouse"].(string), item: component["component"].(string)}
+				if locs := locations[key]; len(locs) > 0 {
+					component["locations"] = locs
+				}
+			}
+		}
+	}
+
+	issuesFound := 0
+
+	for _, orderKey := range orderKeys {
+		order := shortages[orderKey]
+		demand := order.demand
+
+		issueData := map[string]interface{}{
+			"item_number":          demand.itno,
+			"product_number":       demand.prno,
+			"start_date":           demand.startDate,
+			"warehouse":            demand.orderWarehouse,
+			"company":              company,
+			"horizon_days":         horizonDays,
+			"num_short_components": len(order.components),
+			"components":           order.components,
+		}
+		if demand.moType != "" {
+			issueData["mo_type"] = demand.moType
+		}
+
+		if err := d.insertIssue(ctx, queries, refreshJobID, environment, demand, issueData); err != nil {
+			log.Printf("Error inserting issue: %v", err)
+			continue
+		}
+
+		issuesFound++
+	}
+
+	log.Printf("[%s] Found %d orders with material shortages (%d component demands checked)", d.Name(), issuesFound, len(demands))
+	return issuesFound, nil
+}
+
+// loadBalances returns the available on-hand stock (MITBAL STQT - ALQT) per warehouse and item
+func (d *MaterialShortageDetector) loadBalances(ctx context.Context, queries *db.Queries, environment, company, facility string) (map[stockKey]*stockBalance, error) {
+	rows, err := queries.DB().QueryContext(ctx, `
+		SELECT
+			whlo,
+			itno,
+			COALESCE(CAST(NULLIF(stqt, '') AS NUMERIC), 0) as on_hand,
+			COALESCE(CAST(NULLIF(alqt, '') AS NUMERIC), 0) as allocated
+		FROM item_balances
+		WHERE environment = $1
+		  AND cono = $2
+		  AND faci = $3
+	`, environment, company, facility)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query item balances: %w", err)
+	}
+	defer rows.Close()
+
+	balances := make(map[stockKey]*stockBalance)
+	for rows.Next() {
+		var key stockKey
+		var balance stockBalance
+		if err := rows.Scan(&key.warehouse, &key.item, &balance.onHand, &balance.allocated); err != nil {
+			return nil, fmt.Errorf("failed to scan item balance: %w", err)
+		}
+		balance.remaining = math.Max(balance.onHand-balance.allocated, 0)
+		balances[key] = &balance
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read item balances: %w", err)
+	}
+
+	return balances, nil
+}
+
+// loadLocations returns the approved locations holding stock per warehouse and item
+func (d *MaterialShortageDetector) loadLocations(ctx context.Context, queries *db.Queries, environment, company, facility string) (map[stockKey][]stockLocation, error) {
+	rows, err := queries.DB().QueryContext(ctx, `
+		SELECT
+			whlo,
+			itno,
+			whsl,
+			bano,
+			COALESCE(CAST(NULLIF(stqt, '') AS NUMERIC), 0) as on_hand,
+			COALESCE(CAST(NULLIF(alqt, '') AS NUMERIC), 0) as allocated
+		FROM item_locations
+		WHERE environment = $1
+		  AND cono = $2
+		  AND faci = $3
+		ORDER BY whlo, itno, whsl, bano
+	`, environment, company, facility)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query item locations: %w", err)
+	}
+	defer rows.Close()
+
+	locations := make(map[stockKey][]stockLocation)
+	for rows.Next() {
+		var key stockKey
+		var loc stockLocation
+		if err := rows.Scan(&key.warehouse, &key.item, &loc.Location, &loc.Lot, &loc.OnHand, &loc.Allocated); err != nil {
+			return nil, fmt.Errorf("failed to scan item location: %w", err)
+		}
+		if len(locations[key]) < maxStockLocations {
+			loc.OnHand = roundQuantity(loc.OnHand)
+			loc.Allocated = roundQuantity(loc.Allocated)
+			locations[key] = append(locations[key], loc)
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read item locations: %w", err)
+	}
+
+	return locations, nil
+}
+
+// loadDemands returns the open component requirements of MOs and firmed MOPs starting
+// on or before horizonEnd, in the order stock is assigned to them
+// MO requirements are MWOMAT REQT - RPQT - ALQT (allocated stock is already reserved).
+// MOP requirements are exploded from the product's lowest structure type valid at the
+// MOP start date: CNQT * PPQT, plus the waste percentage.
+func (d *MaterialShortageDetector) loadDemands(ctx context.Context, queries *db.Queries, environment, company, facility string, horizonEnd int) ([]materialDemand, error) {
+	rows, err := queries.DB().QueryContext(ctx, `
+		WITH mo_demand AS (
+			SELECT
+				'MO' as order_type,
+				mo.mfno as order_number,
+				mo.faci,
+				mo.whlo as order_warehouse,
+				mo.itno,
+				mo.prno,
+				mo.orty,
+				mo.stdt as start_date,
+				COALESCE(NULLIF(mat.whlo, ''), mo.whlo) as component_warehouse,
+				mat.mtno as component,
+				mat.mseq,
+				mat.opno,
+				mat.peun,
+				COALESCE(CAST(NULLIF(mat.reqt, '') AS NUMERIC), 0)
+					- COALESCE(CAST(NULLIF(mat.rpqt, '') AS NUMERIC), 0)
+					- COALESCE(CAST(NULLIF(mat.alqt, '') AS NUMERIC), 0) as required_qty,
+				mo.linked_co_number,
+				mo.linked_co_line,
+				mo.linked_co_suffix
+			FROM manufacturing_orders mo
+			INNER JOIN mo_materials mat
+				ON mat.environment = mo.environment
+				AND mat.faci = mo.faci
+				AND mat.mfno = mo.mfno
+			WHERE mo.environment = $1
+			  AND mo.cono = $2
+			  AND mo.faci = $3
+			  AND mo.deleted_remotely = false
+			  AND mo.stdt IS NOT NULL
+			  AND mo.stdt != ''
+			  AND CAST(mo.stdt AS INTEGER) <= $4
+		),
+		mop_demand AS (
+			SELECT
+				'MOP' as order_type,
+				CAST(mop.plpn AS VARCHAR) as order_number,
+				mop.faci,
+				mop.whlo as order_warehouse,
+				mop.itno,
+				mop.prno,
+				mop.orty,
+				mop.stdt as start_date,
+				mop.whlo as component_warehouse,
+				ps.mtno as component,
+				ps.mseq,
+				ps.opno,
+				ps.peun,
+				COALESCE(CAST(NULLIF(ps.cnqt, '') AS NUMERIC), 0)
+					* COALESCE(CAST(NULLIF(mop.ppqt, '') AS NUMERIC), 0)
+					* (1 + COALESCE(CAST(NULLIF(ps.wapc, '') AS NUMERIC), 0) / 100) as required_qty,
+				mop.linked_co_number,
+				mop.linked_co_line,
+				mop.linked_co_suffix
+			FROM planned_manufacturing_orders mop
+			INNER JOIN product_structures ps
+				ON ps.environment = mop.environment
+				AND ps.faci = mop.faci
+				AND ps.prno = mop.prno
+				AND ps.strt = (
+					SELECT MIN(s.strt)
+					FROM product_structures s
+					WHERE s.environment = mop.environment
+					  AND s.faci = mop.faci
+					  AND s.prno = mop.prno
+				)
+				AND (COALESCE(ps.fdat, '') = '' OR CAST(ps.fdat AS INTEGER) <= CAST(mop.stdt AS INTEGER))
+				AND (COALESCE(ps.tdat, '') = '' OR CAST(ps.tdat AS INTEGER) >= CAST(mop.stdt AS INTEGER))
+			WHERE mop.environment = $1
+			  AND mop.cono = $2
+			  AND mop.faci = $3
+			  AND mop.psts = '20'
+			  AND mop.deleted_remotely = false
+			  AND mop.stdt IS NOT NULL
+			  AND mop.stdt != ''
+			  AND CAST(mop.stdt AS INTEGER) <= $4
+		)
+		SELECT
+			order_type, order_number, faci, order_warehouse, itno, prno, orty, start_date,
+			component_warehouse, component, mseq, opno, peun, required_qty,
+			linked_co_number, linked_co_line, linked_co_suffix
+		FROM (
+			SELECT * FROM mo_demand WHERE required_qty > 0
+			UNION ALL
+			SELECT * FROM mop_demand WHERE required_qty > 0
+		) demand
+		ORDER BY start_date, order_type, order_number, COALESCE(CAST(NULLIF(mseq, '') AS INTEGER), 0)
+	`, environment, company, facility, horizonEnd)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query material demand: %w", err)
+	}
+	defer rows.Close()
+
+	var demands []materialDemand
+	for rows.Next() {
+		var demand materialDemand
+		var orderWarehouse, itno, prno, orty, warehouse, sequence, operation, unit, coNumber, coLine, coSuffix sql.NullString
+
+		if err := rows.Scan(
+			&demand.orderType, &demand.orderNumber, &demand.faci, &orderWarehouse, &itno, &prno, &orty, &demand.startDate,
+			&warehouse, &demand.component, &sequence, &operation, &unit, &demand.required,
+			&coNumber, &coLine, &coSuffix,
+		); err != nil {
+			return nil, fmt.Errorf("failed to scan material demand: %w", err)
+		}
+
+		demand.orderWarehouse = orderWarehouse.String
+		demand.itno = itno.String
+		demand.prno = prno.String
+		demand.moType = orty.String
+		demand.warehouse = warehouse.String
+		demand.sequence = sequence.String
+		demand.operation = operation.String
+		demand.unit = unit.String
+		demand.coNumber = coNumber.String
+		demand.coLine = coLine.String
+		demand.coSuffix = coSuffix.String
+		demands = append(demands, demand)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read material demand: %w", err)
+	}
+
+	return demands, nil
+}
+
+func (d *MaterialShortageDetector) insertIssue(ctx context.Context, queries *db.Queries, refreshJobID, environment string, demand materialDemand, issueData map[string]interface{}) error {
+	issueDataJSON, _ := json.Marshal(issueData)
+
+	query := `
+		INSERT INTO detected_issues (
+			environment, job_id, detector_type, facility, warehouse,
+			issue_key, production_order_number, production_order_type,
+			co_number, co_line, co_suffix,
+			issue_data
+		)
+		VALUES (
+			$1, $2, $3, $4, $5,
+			$6, $7, $8,
+			$9, $10, $11,
+			$12
+		)
+	`
+
+	issueKey := demand.orderType + ":" + demand.orderNumber // One issue per order, listing every short component; MFNO and PLPN ranges overlap
+
+	_, err := queries.DB().ExecContext(ctx, query,
+		environment, refreshJobID, d.Name(), demand.faci, demand.orderWarehouse,
+		issueKey, demand.orderNumber, demand.orderType,
+		nullIfEmpty(demand.coNumber), nullIfEmpty(demand.coLine), nullIfEmpty(demand.coSuffix),
+		issueDataJSON,
+	)
+
+	return err
+}
+
+// roundQuantity trims floating point noise from computed quantities
+func roundQuantity(qty float64) float64 {
+	return math.Round(qty*1e6) / 1e6
+}
+
+// nullIfEmpty stores an empty string as NULL
+func nullIfEmpty(value string) interface{} {
+	if value == "" {
+		return nil
+	}
+	return value
+}
@@ -0,0 +1,315 @@
+package detectors
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"log"
+
+	"github.com/pinggolf/m3-planning-tools/internal/db"
+)
+
+// MultiLevelDateConflictDetector walks the MO/MOP hierarchy and finds lower-level orders that
+// finish after the parent order they feed is planned to start
+// MOs link to their parent through MFLO (top level MFHL), MOPs through PLLO (top level PLHL)
+type MultiLevelDateConflictDetector struct {
+	configService ConfigService
+}
+
+// NewMultiLevelDateConflictDetector creates a new detector with config service
+func NewMultiLevelDateConflictDetector(configService ConfigService) *MultiLevelDateConflictDetector {
+	return &MultiLevelDateConflictDetector{configService: configService}
+}
+
+func (d *MultiLevelDateConflictDetector) Name() string {
+	return "multi_level_date_conflict"
+}
+
+func (d *MultiLevelDateConflictDetector) Label() string {
+	return "Component After Parent Start"
+}
+
+func (d *MultiLevelDateConflictDetector) Description() string {
+	return "Detects lower-level MOs/MOPs finishing after the start date of the parent order they feed in a multi-level structure"
+}
+
+// hierarchyOrder is an MO or MOP with its place in the multi-level structure
+type hierarchyOrder struct {
+	orderNumber    string
+	orderType      string
+	facility       string
+	warehouse      string
+	itemNumber     string
+	productNumber  string
+	moType         string
+	status         string
+	quantity       string
+	startDate      string
+	finishDate     string
+	parentNumber   string // MFLO / PLLO
+	topLevelNumber string // MFHL / PLHL
+	level          string // LEVL (MOs only)
+	coNumber       string
+	coLine         string
+	coSuffix       string
+}
+
+func (d *MultiLevelDateConflictDetector) Detect(ctx context.Context, queries *db.Queries, refreshJobID, environment, company, facility string) (int, error) {
+	log.Printf("[%s] Running detector for environment %s, facility %s, refresh job %s", d.Name(), environment, facility, refreshJobID)
+
+	// Load global filters for this environment
+	filters, err := d.configService.LoadFilters(ctx, environment, d.Name())
+	if err != nil {
+		log.Printf("[%s] Warning: failed to load filters: %v (using defaults)", d.Name(), err)
+		filters = DetectorFilters{}
+	}
+	excludedStatuses := map[string]map[string]bool{"MO": {}, "MOP": {}}
+	for _, status := range filters.ExcludeMOStatuses {
+		excludedStatuses["MO"][status] = true
+	}
+	for _, status := range filters.ExcludeMOPStatuses {
+		excludedStatuses["MOP"][status] = true
+	}
+
+	// Excluded orders are still loaded - they remain part of the chains of the orders they feed
+	orders, err := d.loadHierarchy(ctx, queries, environment, company, facility)
+	if err != nil {
+		return 0, err
+	}
+
+	// MOs only feed MOs and MOPs only feed MOPs, so each type has its own hierarchy
+	byNumber := map[string]map[string]*hierarchyOrder{"MO": {}, "MOP": {}}
+	for _, order := range orders {
+		byNumber[order.orderType][order.orderNumber] = order
+	}
+	parentOf := func(order *hierarchyOrder) *hierarchyOrder {
+		if order.parentNumber == "" || order.parentNumber == order.orderNumber {
+			return nil
+		}
+		return byNumber[order.orderType][order.parentNumber]
+	}
+
+	// The tolerance can be overridden per warehouse, facility and MO type - resolve each scope once
+	toleranceDays := make(map[thresholdScope]int)
+	resolveTolerance := func(order *hierarchyOrder) int {
+		scope := thresholdScope{warehouse: order.warehouse, moType: order.moType}
+		if value, ok := toleranceDays[scope]; ok {
+			return value
+		}
+		var warehouse, moType *string
+		if order.warehouse != "" {
+			warehouse = &order.warehouse
+		}
+		if order.moType != "" {
+			moType = &order.moType
+		}
+		value := 0
+		raw, found, err := d.configService.ResolveThreshold(ctx, environment, d.Name(), "tolerance_days", warehouse, &facility, moType)
+		if err != nil || !found {
+			log.Printf("[%s] Warning: failed to resolve tolerance_days: %v (using default 0)", d.Name(), err)
+		} else if number, ok := raw.(float64); ok {
+			value = int(number)
+		}
+		toleranceDays[scope] = value
+		return value
+	}
+
+	issuesFound := 0
+	childOrders := 0
+
+	for _, order := range orders {
+		parent := parentOf(order)
+		if parent == nil {
+			continue
+		}
+		childOrders++
+
+		if excludedStatuses[order.orderType][order.status] || excludedStatuses[parent.orderType][parent.status] {
+			continue
+		}
+
+		finishDate, ok := parseM3Date(order.finishDate)
+		if !ok {
+			continue
+		}
+		parentStartDate, ok := parseM3Date(parent.startDate)
+		if !ok {
+			continue
+		}
+
+		tolerance := resolveTolerance(order)
+		daysLate := int(finishDate.Sub(parentStartDate).Hours() / 24)
+		if daysLate <= tolerance {
+			continue
+		}
+
+		// Walk up to the top-level order; a corrupt hierarchy must not loop forever
+		chain := []*hierarchyOrder{order}
+		visited := map[string]bool{order.orderNumber: true}
+		for current := parent; current != nil && !visited[current.orderNumber]; current = parentOf(current) {
+			visited[current.orderNumber] = true
+			chain = append(chain, current)
+		}
+		topLevel := chain[len(chain)-1]
+		// MFHL/PLHL names the top level even when it is outside this facility's snapshot
+		topLevelNumber := order.topLevelNumber
+		if topLevelNumber == "" {
+			topLevelNumber = topLevel.orderNumber
+		}
+
+		// Report the chain top-down, from the top-level order to the late component
+		chainData := make([]map[string]interface{}, 0, len(chain))
+		for i := len(chain) - 1; i >= 0; i-- {
+			link := chain[i]
+			entry := map[string]interface{}{
+				"order_number":   link.orderNumber,
+				"order_type":     link.orderType,
+				"item_number":    link.itemNumber,
+				"product_number": link.productNumber,
+				"status":         link.status,
+				"quantity":       link.quantity,
+				"start_date":     link.startDate,
+				"finish_date":    link.finishDate,
+			}
+			if link.level != "" {
+				entry["level"] = link.level
+			}
+			chainData = append(chainData, entry)
+		}
+
+		issueData := map[string]interface{}{
+			"item_number":            order.itemNumber,
+			"product_number":         order.productNumber,
+			"quantity":               order.quantity,
+			"start_date":             order.startDate,
+			"finish_date":            order.finishDate,
+			"status":                 order.status,
+			"parent_order_number":    parent.orderNumber,
+			"parent_item_number":     parent.itemNumber,
+			"parent_start_date":      parent.startDate,
+			"parent_finish_date":     parent.finishDate,
+			"top_level_order_number": topLevel.orderNumber,
+			"top_level_item_number":  topLevel.itemNumber,
+			"top_level_finish_date":  topLevel.finishDate,
+			"days_late":              daysLate,
+			"tolerance_days":         tolerance,
+			"chain_depth":            len(chain),
+			"chain":                  chainData,
+			"warehouse":              order.warehouse,
+			"company":                company,
+		}
+		if order.moType != "" {
+			issueData["mo_type"] = order.moType
+		}
+		if order.level != "" {
+			issueData["level"] = order.level
+		}
+
+		if err := d.insertIssue(ctx, queries, refreshJobID, environment, order, topLevel, issueData); err != nil {
+			log.Printf("Error inserting issue: %v", err)
+			continue
+		}
+
+		issuesFound++
+	}
+
+	log.Printf("[%s] Found %d lower-level orders finishing after their parent starts (%d lower-level orders checked)", d.Name(), issuesFound, childOrders)
+	return issuesFound, nil
+}
+
+// loadHierarchy returns the facility's MOs and MOPs with their parent and top-level references
+func (d *MultiLevelDateConflictDetector) loadHierarchy(ctx context.Context, queries *db.Queries, environment, company, facility string) ([]*hierarchyOrder, error) {
+	rows, err := queries.DB().QueryContext(ctx, `
+		SELECT
+			mfno, 'MO', faci, whlo, itno, prno, orty, whst, orqt, stdt, fidt,
+			mflo, mfhl, levl,
+			linked_co_number, linked_co_line, linked_co_suffix
+		FROM manufacturing_orders
+		WHERE environment = $1
+		  AND cono = $2
+		  AND faci = $3
+		  AND deleted_remotely = false
+		UNION ALL
+		SELECT
+			plpn, 'MOP', faci, whlo, itno, prno, orty, psts, ppqt, stdt, fidt,
+			pllo, plhl, NULL,
+			linked_co_number, linked_co_line, linked_co_suffix
+		FROM planned_manufacturing_orders
+		WHERE environment = $1
+		  AND cono = $2
+		  AND faci = $3
+		  AND deleted_remotely = false
+	`, environment, company, facility)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query MO/MOP hierarchy: %w", err)
+	}
+	defer rows.Close()
+
+	var orders []*hierarchyOrder
+	for rows.Next() {
+		order := &hierarchyOrder{}
+		var whlo, itno, prno, orty, status, quantity, stdt, fidt sql.NullString
+		var parent, topLevel, level, coNumber, coLine, coSuffix sql.NullString
+
+		if err := rows.Scan(
+			&order.orderNumber, &order.orderType, &order.facility, &whlo, &itno, &prno, &orty, &status, &quantity, &stdt, &fidt,
+			&parent, &topLevel, &level,
+			&coNumber, &coLine, &coSuffix,
+		); err != nil {
+			return nil, fmt.Errorf("failed to scan MO/MOP hierarchy row: %w", err)
+		}
+
+		order.warehouse = whlo.String
+		order.itemNumber = itno.String
+		order.productNumber = prno.String
+		order.moType = orty.String
+		order.status = status.String
+		order.quantity = quantity.String
+		order.startDate = stdt.String
+		order.finishDate = fidt.String
+		order.parentNumber = parent.String
+		order.topLevelNumber = topLevel.String
+		order.level = level.String
+		order.coNumber = coNumber.String
+		order.coLine = coLine.String
+		order.coSuffix = coSuffix.String
+		orders = append(orders, order)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read MO/MOP hierarchy: %w", err)
+	}
+
+	return orders, nil
+}
+
+func (d *MultiLevelDateConflictDetector) insertIssue(ctx context.Context, queries *db.Queries, refreshJobID, environment string, order, topLevel *hierarchyOrder, issueData map[string]interface{}) error {
+	issueDataJSON, _ := json.Marshal(issueData)
+
+	query := `
+		INSERT INTO detected_issues (
+			environment, job_id, detector_type, facility, warehouse,
+			issue_key, production_order_number, production_order_type,
+			co_number, co_line, co_suffix,
+			issue_data
+		)
+		VALUES (
+			$1, $2, $3, $4, $5,
+			$6, $7, $8,
+			$9, $10, $11,
+			$12
+		)
+	`
+
+	issueKey := order.orderNumber // One issue per late lower-level order
+
+	// The CO demand of a multi-level structure is linked to its top-level order
+	_, err := queries.DB().ExecContext(ctx, query,
+		environment, refreshJobID, d.Name(), order.facility, nullIfEmpty(order.warehouse),
+		issueKey, order.orderNumber, order.orderType,
+		nullIfEmpty(topLevel.coNumber), nullIfEmpty(topLevel.coLine), nullIfEmpty(topLevel.coSuffix),
+		issueDataJSON,
+	)
+
+	return err
+}
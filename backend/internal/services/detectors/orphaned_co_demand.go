@@ -0,0 +1,269 @@
+package detectors
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/pinggolf/m3-planning-tools/internal/db"
+)
+
+// OrphanedCODemandDetector finds open CO lines with remaining quantity but no linked MO/MOP
+// This is the reverse of UnlinkedProductionOrdersDetector: demand without supply
+type OrphanedCODemandDetector struct {
+	configService ConfigService
+}
+
+// NewOrphanedCODemandDetector creates a new detector with config service
+func NewOrphanedCODemandDetector(configService ConfigService) *OrphanedCODemandDetector {
+	return &OrphanedCODemandDetector{configService: configService}
+}
+
+func (d *OrphanedCODemandDetector) Name() string {
+	return "orphaned_co_demand"
+}
+
+func (d *OrphanedCODemandDetector) Label() string {
+	return "Orphaned CO Demand"
+}
+
+func (d *OrphanedCODemandDetector) Description() string {
+	return "Detects open customer order lines with remaining quantity, no linked MO/MOP and a delivery date inside the planning horizon"
+}
+
+// orphanedCOLine is an open CO line with no linked production order
+type orphanedCOLine struct {
+	coNumber              string
+	coLine                string
+	coSuffix              string
+	facility              string
+	warehouse             string
+	itemNumber            string
+	itemDescription       string
+	itemType              string
+	status                string
+	orderedQuantity       string
+	remainingQuantity     string
+	confirmedDeliveryDate string
+	requestedDeliveryDate string
+	customerNumber        string
+	customerName          string
+	coTypeNumber          string
+	coTypeDescription     string
+	deliveryMethod        string
+}
+
+func (d *OrphanedCODemandDetector) Detect(ctx context.Context, queries *db.Queries, refreshJobID, environment, company, facility string) (int, error) {
+	log.Printf("[%s] Running detector for environment %s, facility %s, refresh job %s", d.Name(), environment, facility, refreshJobID)
+
+	// Load global filters for this environment
+	filters, err := d.configService.LoadFilters(ctx, environment, d.Name())
+	if err != nil {
+		log.Printf("[%s] Warning: failed to load filters: %v (using defaults)", d.Name(), err)
+		filters = DetectorFilters{}
+	}
+
+	lines, err := d.loadOrphanedLines(ctx, queries, environment, company, facility, filters)
+	if err != nil {
+		return 0, err
+	}
+
+	// The horizon can be overridden per warehouse - resolve each warehouse once
+	horizonDays := make(map[string]int)
+	resolveHorizon := func(warehouse string) int {
+		if value, ok := horizonDays[warehouse]; ok {
+			return value
+		}
+		var warehouseScope *string
+		if warehouse != "" {
+			warehouseScope = &warehouse
+		}
+		value := 28
+		raw, found, err := d.configService.ResolveThreshold(ctx, environment, d.Name(), "horizon_days", warehouseScope, &facility, nil)
+		if err != nil || !found {
+			log.Printf("[%s] Warning: failed to resolve horizon_days: %v (using default %d)", d.Name(), err, value)
+		} else if number, ok := raw.(float64); ok {
+			value = int(number)
+		}
+		horizonDays[warehouse] = value
+		return value
+	}
+
+	now := time.Now()
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+	issuesFound := 0
+
+	for _, line := range lines {
+		// Use the confirmed delivery date, or the requested date if none is confirmed yet
+		deliveryDate, ok := parseM3Date(line.confirmedDeliveryDate)
+		deliveryDateSource := "confirmed"
+		if !ok {
+			deliveryDate, ok = parseM3Date(line.requestedDeliveryDate)
+			deliveryDateSource = "requested"
+		}
+		if !ok {
+			continue
+		}
+
+		// Overdue lines are always inside the horizon
+		horizon := resolveHorizon(line.warehouse)
+		if deliveryDate.After(today.AddDate(0, 0, horizon)) {
+			continue
+		}
+
+		issueData := map[string]interface{}{
+			"item_number":             line.itemNumber,
+			"item_description":        line.itemDescription,
+			"item_type":               line.itemType,
+			"co_status":               line.status,
+			"ordered_quantity":        line.orderedQuantity,
+			"remaining_quantity":      line.remainingQuantity,
+			"confirmed_delivery_date": line.confirmedDeliveryDate,
+			"requested_delivery_date": line.requestedDeliveryDate,
+			"delivery_date_source":    deliveryDateSource,
+			"days_until_delivery":     int(deliveryDate.Sub(today).Hours() / 24),
+			"horizon_days":            horizon,
+			"warehouse":               line.warehouse,
+			"company":                 company,
+			"customer_number":         line.customerNumber,
+			"customer_name":           line.customerName,
+			"co_type_number":          line.coTypeNumber,
+			"co_type_description":     line.coTypeDescription,
+			"delivery_method":         line.deliveryMethod,
+		}
+
+		if err := d.insertIssue(ctx, queries, refreshJobID, environment, line, issueData); err != nil {
+			log.Printf("Error inserting issue: %v", err)
+			continue
+		}
+
+		issuesFound++
+	}
+
+	log.Printf("[%s] Found %d CO lines without supply inside the horizon (%d unlinked open lines checked)", d.Name(), issuesFound, len(lines))
+	return issuesFound, nil
+}
+
+// loadOrphanedLines returns the facility's open CO lines with remaining quantity that no MO/MOP is linked to
+func (d *OrphanedCODemandDetector) loadOrphanedLines(ctx context.Context, queries *db.Queries, environment, company, facility string, filters DetectorFilters) ([]orphanedCOLine, error) {
+	args := db.NewQueryArgs(environment, company, facility)
+	itemTypeClause := buildItemTypeFilterSQL(args, "col.item_type", filters.IncludeItemTypes, filters.ExcludeItemTypes)
+	quantityClause := buildMinQuantitySQL(args, "col.rnqt", filters.MinQuantityThreshold)
+
+	query := fmt.Sprintf(`
+		SELECT
+			col.orno,
+			col.ponr,
+			col.posx,
+			col.faci,
+			col.whlo,
+			col.itno,
+			col.itds,
+			col.item_type,
+			col.orst,
+			col.orqt,
+			col.rnqt,
+			col.codt,
+			col.dwdt,
+			col.cuno,
+			col.customer_name,
+			col.ortp,
+			col.co_type_description,
+			col.delivery_method
+		FROM customer_order_lines col
+		WHERE col.environment = $1
+		  AND col.cono = $2
+		  AND col.faci = $3
+		  AND col.orst >= '20'
+		  AND col.orst < '30'
+		  AND col.rnqt IS NOT NULL
+		  AND col.rnqt != ''
+		  AND CAST(col.rnqt AS DECIMAL) > 0
+		  AND NOT EXISTS (
+			SELECT 1
+			FROM production_orders po
+			WHERE po.environment = col.environment
+			  AND po.linked_co_number = col.orno
+			  AND po.linked_co_line = col.ponr
+			  AND po.linked_co_suffix = col.posx
+			  AND po.deleted_remotely = false
+		  )
+		  %s
+		  %s
+		ORDER BY col.orno, col.ponr, col.posx
+	`, itemTypeClause, quantityClause)
+
+	rows, err := queries.DB().QueryContext(ctx, query, args.Values()...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query CO lines without supply: %w", err)
+	}
+	defer rows.Close()
+
+	var lines []orphanedCOLine
+	for rows.Next() {
+		var line orphanedCOLine
+		var faci, whlo, itds, itemType, orst, orqt, codt, dwdt sql.NullString
+		var cuno, customerName, ortp, coTypeDescription, deliveryMethod sql.NullString
+
+		if err := rows.Scan(
+			&line.coNumber, &line.coLine, &line.coSuffix, &faci, &whlo,
+			&line.itemNumber, &itds, &itemType, &orst, &orqt, &line.remainingQuantity,
+			&codt, &dwdt, &cuno, &customerName, &ortp, &coTypeDescription, &deliveryMethod,
+		); err != nil {
+			return nil, fmt.Errorf("failed to scan CO line: %w", err)
+		}
+
+		line.facility = faci.String
+		line.warehouse = whlo.String
+		line.itemDescription = itds.String
+		line.itemType = itemType.String
+		line.status = orst.String
+		line.orderedQuantity = orqt.String
+		line.confirmedDeliveryDate = codt.String
+		line.requestedDeliveryDate = dwdt.String
+		line.customerNumber = cuno.String
+		line.customerName = customerName.String
+		line.coTypeNumber = ortp.String
+		line.coTypeDescription = coTypeDescription.String
+		line.deliveryMethod = deliveryMethod.String
+		lines = append(lines, line)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read CO lines: %w", err)
+	}
+
+	return lines, nil
+}
+
+func (d *OrphanedCODemandDetector) insertIssue(ctx context.Context, queries *db.Queries, refreshJobID, environment string, line orphanedCOLine, issueData map[string]interface{}) error {
+	issueDataJSON, _ := json.Marshal(issueData)
+
+	query := `
+		INSERT INTO detected_issues (
+			environment, job_id, detector_type, facility, warehouse,
+			issue_key, production_order_number, production_order_type,
+			co_number, co_line, co_suffix,
+			issue_data
+		)
+		VALUES (
+			$1, $2, $3, $4, $5,
+			$6, NULL, NULL,
+			$7, $8, $9,
+			$10
+		)
+	`
+
+	issueKey := fmt.Sprintf("%s-%s-%s", line.coNumber, line.coLine, line.coSuffix) // One issue per CO line
+
+	_, err := queries.DB().ExecContext(ctx, query,
+		environment, refreshJobID, d.Name(), line.facility, nullIfEmpty(line.warehouse),
+		issueKey,
+		line.coNumber, line.coLine, line.coSuffix,
+		issueDataJSON,
+	)
+
+	return err
+}
@@ -6,6 +6,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"log"
+	"strings"
 	"time"
 
 	"github.com/pinggolf/m3-planning-tools/internal/db"
@@ -43,28 +44,31 @@ func (d *UnlinkedProductionOrdersDetector) Detect(ctx context.Context, queries *
 		filters = DetectorFilters{}
 	}
 
-	// Build filter clauses
-	moStatusClause := buildStatusExclusionSQL("whst", filters.ExcludeMOStatuses)
-	mopStatusClause := buildStatusExclusionSQL("psts", filters.ExcludeMOPStatuses)
-	facilityClause := buildFacilityExclusionSQL(filters.ExcludeFacilities)
-
-	// Build age filter (only flag orders older than min_order_age_days)
-	ageClause := ""
+	// Build age filter cutoff (only flag orders older than min_order_age_days)
+	cutoffDateInt := 0
 	if filters.MinOrderAgeDays > 0 {
 		cutoffDate := time.Now().AddDate(0, 0, -filters.MinOrderAgeDays)
-		cutoffDateInt := cutoffDate.Year()*10000 + int(cutoffDate.Month())*100 + cutoffDate.Day()
-		ageClause = fmt.Sprintf("AND CAST(stdt AS INTEGER) < %d", cutoffDateInt)
+		cutoffDateInt = cutoffDate.Year()*10000 + int(cutoffDate.Month())*100 + cutoffDate.Day()
 	}
 
-	// Build quantity filter
-	quantityClause := ""
-	if filters.MinQuantityThreshold > 0 {
-		quantityClause = fmt.Sprintf("AND CAST(orqt AS DECIMAL) >= %.6f", filters.MinQuantityThreshold)
+	// Build filter clauses; each query has its own parameter list
+	buildFilters := func(args *db.QueryArgs, statusColumn string, excludeStatuses []string, quantityColumn string) string {
+		clauses := []string{
+			buildStatusExclusionSQL(args, statusColumn, excludeStatuses),
+			buildFacilityExclusionSQL(args, filters.ExcludeFacilities),
+			buildMinQuantitySQL(args, quantityColumn, filters.MinQuantityThreshold),
+		}
+		if cutoffDateInt > 0 {
+			clauses = append(clauses, fmt.Sprintf("AND CAST(stdt AS INTEGER) < %s", args.Add(cutoffDateInt)))
+		}
+		return strings.Join(clauses, "\n\t\t  ")
 	}
 
 	issuesFound := 0
 
 	// Find unlinked MOs with filters
+	moArgs := db.NewQueryArgs(environment, company, facility)
+	moFilters := buildFilters(moArgs, "whst", filters.ExcludeMOStatuses, "orqt")
 	moQuery := fmt.Sprintf(`
 		SELECT
 			mfno as order_number,
@@ -87,12 +91,9 @@ func (d *UnlinkedProductionOrdersDetector) Detect(ctx context.Context, queries *
 		  AND (linked_co_number IS NULL OR linked_co_number = '')
 		  AND deleted_remotely = false
 		  %s
-		  %s
-		  %s
-		  %s
-	`, moStatusClause, facilityClause, ageClause, quantityClause)
+	`, moFilters)
 
-	moRows, err := queries.DB().QueryContext(ctx, moQuery, environment, company, facility)
+	moRows, err := queries.DB().QueryContext(ctx, moQuery, moArgs.Values()...)
 	if err != nil {
 		return 0, fmt.Errorf("failed to query unlinked MOs: %w", err)
 	}
@@ -135,13 +136,9 @@ func (d *UnlinkedProductionOrdersDetector) Detect(ctx context.Context, queries *
 		issuesFound++
 	}
 
-	// Build quantity filter for MOPs
-	quantityClauseMOP := ""
-	if filters.MinQuantityThreshold > 0 {
-		quantityClauseMOP = fmt.Sprintf("AND CAST(ppqt AS DECIMAL) >= %.6f", filters.MinQuantityThreshold)
-	}
-
 	// Find unlinked MOPs with filters
+	mopArgs := db.NewQueryArgs(environment, company, facility)
+	mopFilters := buildFilters(mopArgs, "psts", filters.ExcludeMOPStatuses, "ppqt")
 	mopQuery := fmt.Sprintf(`
 		SELECT
 			CAST(plpn AS VARCHAR) as order_number,
@@ -164,12 +161,9 @@ func (d *UnlinkedProductionOrdersDetector) Detect(ctx context.Context, queries *
 		  AND (linked_co_number IS NULL OR linked_co_number = '')
 		  AND deleted_remotely = false
 		  %s
-		  %s
-		  %s
-		  %s
-	`, mopStatusClause, facilityClause, ageClause, quantityClauseMOP)
+	`, mopFilters)
 
-	mopRows, err := queries.DB().QueryContext(ctx, mopQuery, environment, company, facility)
+	mopRows, err := queries.DB().QueryContext(ctx, mopQuery, mopArgs.Values()...)
 	if err != nil {
 		return issuesFound, fmt.Errorf("failed to query unlinked MOPs: %w", err)
 	}
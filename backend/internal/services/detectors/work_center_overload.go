@@ -0,0 +1,470 @@
+package detectors
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"log"
+	"math"
+	"sort"
+	"time"
+
+	"github.com/pinggolf/m3-planning-tools/internal/db"
+)
+
+// WorkCenterOverloadDetector finds work centers whose planned load exceeds their capacity per day or week
+// Capacity is modelled, not read from M3: MPDWCT resources x hours_per_resource x the working days
+// of a fixed weekday pattern (working_days_per_week). Work center calendars and available capacity
+// are deliberately out of scope, as no calendar table is extracted yet, so holidays, shutdowns and
+// shift patterns are not reflected; issue data carries capacity_basis so planners can tell.
+type WorkCenterOverloadDetector struct {
+	configService ConfigService
+}
+
+// NewWorkCenterOverloadDetector creates a new detector with config service
+func NewWorkCenterOverloadDetector(configService ConfigService) *WorkCenterOverloadDetector {
+	return &WorkCenterOverloadDetector{configService: configService}
+}
+
+func (d *WorkCenterOverloadDetector) Name() string {
+	return "work_center_overload"
+}
+
+func (d *WorkCenterOverloadDetector) Label() string {
+	return "Work Center Overloads"
+}
+
+func (d *WorkCenterOverloadDetector) Description() string {
+	return "Detects work centers whose planned MO and MOP operation hours exceed their capacity per day or week. Capacity is resources x hours per resource x working days from a fixed weekday pattern; M3 work center calendars (holidays, shutdowns, shift patterns) are not used"
+}
+
+// operationLoad is the remaining work of one MO operation or routed MOP operation
+type operationLoad struct {
+	orderType   string
+	orderNumber string
+	itno        string
+	operation   string
+	workCenter  string
+	hours       float64
+	startDate   string
+	finishDate  string
+}
+
+// workCenterCapacity is a work center and the number of resources it plans with
+type workCenterCapacity struct {
+	name      string
+	resources float64
+}
+
+// bucketKey identifies one work center bucket
+type bucketKey struct {
+	workCenter  string
+	bucketStart time.Time
+}
+
+// bucketOrder is the load one order puts on a work center bucket
+type bucketOrder struct {
+	OrderType   string   `json:"order_type"`
+	OrderNumber string   `json:"order_number"`
+	ItemNumber  string   `json:"item_number,omitempty"`
+	Operations  []string `json:"operations"`
+	StartDate   string   `json:"start_date,omitempty"`
+	Hours       float64  `json:"hours"`
+}
+
+// bucketLoad is the planned load of a work center bucket and the orders contributing to it
+type bucketLoad struct {
+	hours  float64
+	orders map[string]*bucketOrder
+}
+
+// maxBucketOrders caps the contributing orders listed per overloaded bucket (largest first)
+const maxBucketOrders = 50
+
+func (d *WorkCenterOverloadDetector) Detect(ctx context.Context, queries *db.Queries, refreshJobID, environment, company, facility string) (int, error) {
+	log.Printf("[%s] Running detector for environment %s, facility %s, refresh job %s", d.Name(), environment, facility, refreshJobID)
+
+	// Resolve thresholds (use facility scope, no warehouse/MO type)
+	horizonDays := int(d.resolveThreshold(ctx, environment, facility, "horizon_days", 28))
+	bucketDays := int(d.resolveThreshold(ctx, environment, facility, "bucket_days", 7))
+	hoursPerResource := d.resolveThreshold(ctx, environment, facility, "hours_per_resource", 8)
+	workingDaysPerWeek := int(d.resolveThreshold(ctx, environment, facility, "working_days_per_week", 5))
+	tolerancePercent := d.resolveThreshold(ctx, environment, facility, "tolerance_percent", 0)
+	if bucketDays < 1 {
+		bucketDays = 1
+	}
+
+	now := time.Now()
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+	horizonEnd := today.AddDate(0, 0, horizonDays)
+	// Buckets are aligned on the Monday of the current week
+	firstMonday := today.AddDate(0, 0, -((int(today.Weekday()) + 6) % 7))
+	log.Printf("[%s] Using horizon_days = %d, bucket_days = %d, hours_per_resource = %.2f, working_days_per_week = %d, tolerance_percent = %.2f for facility %s",
+		d.Name(), horizonDays, bucketDays, hoursPerResource, workingDaysPerWeek, tolerancePercent, facility)
+
+	isWorkingDay := func(day time.Time) bool {
+		return (int(day.Weekday())+6)%7 < workingDaysPerWeek
+	}
+	bucketStartOf := func(day time.Time) time.Time {
+		offset := int(day.Sub(firstMonday).Hours() / 24)
+		return firstMonday.AddDate(0, 0, offset/bucketDays*bucketDays)
+	}
+
+	workCenters, err := d.loadWorkCenters(ctx, queries, environment, company, facility)
+	if err != nil {
+		return 0, err
+	}
+
+	loads, err := d.loadOperations(ctx, queries, environment, company, facility, formatM3Date(horizonEnd))
+	if err != nil {
+		return 0, err
+	}
+
+	// Spread each operation's hours evenly over the working days between its start and
+	// finish dates; overdue work is due now, so it is planned from today
+	buckets := make(map[bucketKey]*bucketLoad)
+	for _, load := range loads {
+		start, ok := parseM3Date(load.startDate)
+		if !ok {
+			continue
+		}
+		if start.Before(today) {
+			start = today
+		}
+		finish, ok := parseM3Date(load.finishDate)
+		if !ok || finish.Before(start) {
+			finish = start
+		}
+
+		var days []time.Time
+		for day := start; !day.After(finish); day = day.AddDate(0, 0, 1) {
+			if isWorkingDay(day) {
+				days = append(days, day)
+			}
+		}
+		if len(days) == 0 {
+			days = []time.Time{start}
+		}
+
+		hoursPerDay := load.hours / float64(len(days))
+		for _, day := range days {
+			if day.After(horizonEnd) {
+				break
+			}
+			key := bucketKey{workCenter: load.workCenter, bucketStart: bucketStartOf(day)}
+			bucket := buckets[key]
+			if bucket == nil {
+				bucket = &bucketLoad{orders: make(map[string]*bucketOrder)}
+				buckets[key] = bucket
+			}
+			bucket.hours += hoursPerDay
+
+			orderKey := load.orderType + ":" + load.orderNumber
+			order := bucket.orders[orderKey]
+			if order == nil {
+				order = &bucketOrder{
+					OrderType:   load.orderType,
+					OrderNumber: load.orderNumber,
+					ItemNumber:  load.itno,
+					StartDate:   load.startDate,
+				}
+				bucket.orders[orderKey] = order
+			}
+			order.Hours += hoursPerDay
+			if len(order.Operations) == 0 || order.Operations[len(order.Operations)-1] != load.operation {
+				order.Operations = append(order.Operations, load.operation)
+			}
+		}
+	}
+
+	keys := make([]bucketKey, 0, len(buckets))
+	for key := range buckets {
+		keys = append(keys, key)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		if keys[i].workCenter != keys[j].workCenter {
+			return keys[i].workCenter < keys[j].workCenter
+		}
+		return keys[i].bucketStart.Before(keys[j].bucketStart)
+	})
+
+	issuesFound := 0
+
+	for _, key := range keys {
+		bucket := buckets[key]
+
+		workCenter, known := workCenters[key.workCenter]
+		if !known {
+			workCenter = workCenterCapacity{resources: 1}
+		}
+
+		// Capacity counts the bucket's working days from today up to the horizon
+		bucketEnd := key.bucketStart.AddDate(0, 0, bucketDays-1)
+		workingDays := 0
+		for day := key.bucketStart; !day.After(bucketEnd); day = day.AddDate(0, 0, 1) {
+			if !day.Before(today) && !day.After(horizonEnd) && isWorkingDay(day) {
+				workingDays++
+			}
+		}
+		capacity := workCenter.resources * hoursPerResource * float64(workingDays)
+
+		if bucket.hours <= capacity*(1+tolerancePercent/100)+1e-6 {
+			continue
+		}
+
+		orders := make([]*bucketOrder, 0, len(bucket.orders))
+		for _, order := range bucket.orders {
+			order.Hours = roundHours(order.Hours)
+			orders = append(orders, order)
+		}
+		sort.Slice(orders, func(i, j int) bool {
+			if orders[i].Hours != orders[j].Hours {
+				return orders[i].Hours > orders[j].Hours
+			}
+			return orders[i].OrderNumber < orders[j].OrderNumber
+		})
+		numOrders := len(orders)
+		if len(orders) > maxBucketOrders {
+			orders = orders[:maxBucketOrders]
+		}
+
+		issueData := map[string]interface{}{
+			"work_center":        key.workCenter,
+			"work_center_known":  known,
+			"bucket_start":       formatM3Date(key.bucketStart),
+			"bucket_end":         formatM3Date(bucketEnd),
+			"bucket_days":        bucketDays,
+			"working_days":       workingDays,
+			"resources":          workCenter.resources,
+			"hours_per_resource": hoursPerResource,
+			"capacity_hours":     roundHours(capacity),
+			"capacity_basis":     "working_days_per_week",
+			"load_hours":         roundHours(bucket.hours),
+			"overload_hours":     roundHours(bucket.hours - capacity),
+			"tolerance_percent":  tolerancePercent,
+			"company":            company,
+			"horizon_days":       horizonDays,
+			"num_orders":         numOrders,
+			"orders":             orders,
+		}
+		if workCenter.name != "" {
+			issueData["work_center_name"] = workCenter.name
+		}
+		if capacity > 0 {
+			issueData["utilization_percent"] = math.Round(bucket.hours / capacity * 100)
+		}
+
+		issueKey := fmt.Sprintf("%s:%s", key.workCenter, formatM3Date(key.bucketStart))
+		if err := d.insertIssue(ctx, queries, refreshJobID, environment, facility, issueKey, issueData); err != nil {
+			log.Printf("Error inserting issue: %v", err)
+			continue
+		}
+
+		issuesFound++
+	}
+
+	log.Printf("[%s] Found %d overloaded work center buckets (%d operations checked, %d buckets loaded)", d.Name(), issuesFound, len(loads), len(buckets))
+	return issuesFound, nil
+}
+
+// resolveThreshold resolves a numeric detector setting for the facility, falling back to a default
+func (d *WorkCenterOverloadDetector) resolveThreshold(ctx context.Context, environment, facility, parameter string, defaultValue float64) float64 {
+	raw, found, err := d.configService.ResolveThreshold(ctx, environment, d.Name(), parameter, nil, &facility, nil)
+	if err != nil || !found {
+		log.Printf("[%s] Warning: failed to resolve %s: %v (using default %v)", d.Name(), parameter, err, defaultValue)
+		return defaultValue
+	}
+	value, ok := raw.(float64)
+	if !ok {
+		log.Printf("[%s] Warning: %s is not a number (using default %v)", d.Name(), parameter, defaultValue)
+		return defaultValue
+	}
+	return value
+}
+
+// loadWorkCenters returns the facility's work centers (MPDWCT) by work center ID
+// A work center without a number of machines counts as one resource
+func (d *WorkCenterOverloadDetector) loadWorkCenters(ctx context.Context, queries *db.Queries, environment, company, facility string) (map[string]workCenterCapacity, error) {
+	rows, err := queries.DB().QueryContext(ctx, `
+		SELECT
+			plgr,
+			COALESCE(plnm, '') as name,
+			COALESCE(CAST(NULLIF(noma, '') AS NUMERIC), 0) as resources
+		FROM work_centers
+		WHERE environment = $1
+		  AND cono = $2
+		  AND faci = $3
+	`, environment, company, facility)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query work centers: %w", err)
+	}
+	defer rows.Close()
+
+	workCenters := make(map[string]workCenterCapacity)
+	for rows.Next() {
+		var plgr string
+		var workCenter workCenterCapacity
+		if err := rows.Scan(&plgr, &workCenter.name, &workCenter.resources); err != nil {
+			return nil, fmt.Errorf("failed to scan work center: %w", err)
+		}
+		if workCenter.resources <= 0 {
+			workCenter.resources = 1
+		}
+		workCenters[plgr] = workCenter
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read work centers: %w", err)
+	}
+
+	return workCenters, nil
+}
+
+// loadOperations returns the remaining operation hours of MOs and MOPs starting on or
+// before horizonEnd, per work center
+// MO operations (MWOOPE) load their run time for the quantity not yet manufactured, plus
+// setup while nothing has been reported. MOPs are routed through the product's lowest
+// routing (MPDOPE) valid at the MOP start date and load setup plus run time for PPQT.
+// Operation times are in hours, with run time given per PUSN units.
+func (d *WorkCenterOverloadDetector) loadOperations(ctx context.Context, queries *db.Queries, environment, company, facility, horizonEnd string) ([]operationLoad, error) {
+	rows, err := queries.DB().QueryContext(ctx, `
+		WITH mo_load AS (
+			SELECT
+				'MO' as order_type,
+				mo.mfno as order_number,
+				mo.itno,
+				op.opno,
+				op.plgr,
+				CASE WHEN COALESCE(CAST(NULLIF(op.maqt, '') AS NUMERIC), 0) = 0
+					THEN COALESCE(CAST(NULLIF(op.seti, '') AS NUMERIC), 0) ELSE 0 END
+					+ COALESCE(CAST(NULLIF(op.piti, '') AS NUMERIC), 0)
+					* GREATEST(COALESCE(CAST(NULLIF(op.orqt, '') AS NUMERIC), 0) - COALESCE(CAST(NULLIF(op.maqt, '') AS NUMERIC), 0), 0)
+					/ COALESCE(NULLIF(CAST(NULLIF(op.pusn, '') AS NUMERIC), 0), 1) as hours,
+				COALESCE(NULLIF(NULLIF(op.stdt, ''), '0'), mo.stdt) as start_date,
+				COALESCE(NULLIF(NULLIF(op.fidt, ''), '0'), mo.fidt) as finish_date
+			FROM manufacturing_orders mo
+			INNER JOIN mo_operations op
+				ON op.environment = mo.environment
+				AND op.faci = mo.faci
+				AND op.mfno = mo.mfno
+			WHERE mo.environment = $1
+			  AND mo.cono = $2
+			  AND mo.faci = $3
+			  AND mo.deleted_remotely = false
+		),
+		mop_load AS (
+			SELECT
+				'MOP' as order_type,
+				CAST(mop.plpn AS VARCHAR) as order_number,
+				mop.itno,
+				ro.opno,
+				ro.plgr,
+				COALESCE(CAST(NULLIF(ro.seti, '') AS NUMERIC), 0)
+					+ COALESCE(CAST(NULLIF(ro.piti, '') AS NUMERIC), 0)
+					* COALESCE(CAST(NULLIF(mop.ppqt, '') AS NUMERIC), 0)
+					/ COALESCE(NULLIF(CAST(NULLIF(ro.pusn, '') AS NUMERIC), 0), 1) as hours,
+				mop.stdt as start_date,
+				mop.fidt as finish_date
+			FROM planned_manufacturing_orders mop
+			INNER JOIN product_operations ro
+				ON ro.environment = mop.environment
+				AND ro.faci = mop.faci
+				AND ro.prno = mop.prno
+				AND ro.strt = (
+					SELECT MIN(r.strt)
+					FROM product_operations r
+					WHERE r.environment = mop.environment
+					  AND r.faci = mop.faci
+					  AND r.prno = mop.prno
+				)
+				AND (ro.fdat = '' OR CAST(ro.fdat AS INTEGER) <= CAST(mop.stdt AS INTEGER))
+				AND (COALESCE(ro.tdat, '') IN ('', '0') OR CAST(ro.tdat AS INTEGER) >= CAST(mop.stdt AS INTEGER))
+			WHERE mop.environment = $1
+			  AND mop.cono = $2
+			  AND mop.faci = $3
+			  AND mop.deleted_remotely = false
+			  AND mop.stdt IS NOT NULL
+			  AND mop.stdt != ''
+		)
+		SELECT order_type, order_number, itno, opno, plgr, hours, start_date, finish_date
+		FROM (
+			SELECT * FROM mo_load
+			UNION ALL
+			SELECT * FROM mop_load
+		) load
+		WHERE hours > 0
+		  AND COALESCE(plgr, '') != ''
+		  AND COALESCE(start_date, '') NOT IN ('', '0')
+		  AND CAST(start_date AS INTEGER) <= CAST($4 AS INTEGER)
+		ORDER BY plgr, start_date, order_type, order_number, opno
+	`, environment, company, facility, horizonEnd)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query operation load: %w", err)
+	}
+	defer rows.Close()
+
+	var loads []operationLoad
+	for rows.Next() {
+		var load operationLoad
+		var itno, operation, finishDate sql.NullString
+
+		if err := rows.Scan(
+			&load.orderType, &load.orderNumber, &itno, &operation, &load.workCenter,
+			&load.hours, &load.startDate, &finishDate,
+		); err != nil {
+			return nil, fmt.Errorf("failed to scan operation load: %w", err)
+		}
+
+		load.itno = itno.String
+		load.operation = operation.String
+		load.finishDate = finishDate.String
+		loads = append(loads, load)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read operation load: %w", err)
+	}
+
+	return loads, nil
+}
+
+func (d *WorkCenterOverloadDetector) insertIssue(ctx context.Context, queries *db.Queries, refreshJobID, environment, facility, issueKey string, issueData map[string]interface{}) error {
+	issueDataJSON, _ := json.Marshal(issueData)
+
+	// One issue per work center bucket - the contributing orders are listed in issue_data
+	query := `
+		INSERT INTO detected_issues (
+			environment, job_id, detector_type, facility,
+			issue_key, issue_data
+		)
+		VALUES ($1, $2, $3, $4, $5, $6)
+	`
+
+	_, err := queries.DB().ExecContext(ctx, query,
+		environment, refreshJobID, d.Name(), facility,
+		issueKey, issueDataJSON,
+	)
+
+	return err
+}
+
+// parseM3Date parses an M3 YYYYMMDD date
+func parseM3Date(value string) (time.Time, bool) {
+	if len(value) != 8 {
+		return time.Time{}, false
+	}
+	date, err := time.Parse("20060102", value)
+	if err != nil {
+		return time.Time{}, false
+	}
+	return date, true
+}
+
+// formatM3Date formats a date as an M3 YYYYMMDD date
+func formatM3Date(date time.Time) string {
+	return date.Format("20060102")
+}
+
+// roundHours rounds computed hours to two decimals
+func roundHours(hours float64) float64 {
+	return math.Round(hours*100) / 100
+}
@@ -0,0 +1,319 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/pinggolf/m3-planning-tools/internal/config"
+	"github.com/pinggolf/m3-planning-tools/internal/db"
+	"github.com/pinggolf/m3-planning-tools/internal/services/notifications"
+)
+
+// Notification delivery modes
+const (
+	NotificationDeliveryImmediate = "immediate"
+	NotificationDeliveryDigest    = "digest"
+)
+
+// defaultIssueSeverity is the severity of new issues from detectors missing from the
+// issue_notification_severity setting (detected_issues has no severity of its own)
+const defaultIssueSeverity = "warning"
+
+// NotificationService sends subscribed alerts for new anomalies and issues
+type NotificationService struct {
+	db          *db.Queries
+	channels    *notifications.Registry
+	frontendURL string
+}
+
+// NewNotificationService creates a notification service with the email, webhook, Teams and Slack channels
+func NewNotificationService(database *db.Queries, cfg *config.Config) *NotificationService {
+	smtpConfig := notifications.SMTPConfig{
+		Host:     cfg.SMTPHost,
+		Port:     cfg.SMTPPort,
+		Username: cfg.SMTPUsername,
+		Password: cfg.SMTPPassword,
+		From:     cfg.SMTPFrom,
+	}
+
+	targetPolicy := notifications.NewTargetPolicy(cfg.NotificationWebhookHosts, cfg.NotificationWebhookAllowPrivate)
+	if cfg.NotificationWebhookAllowPrivate {
+		log.Printf("WARNING: NOTIFICATION_WEBHOOK_ALLOW_PRIVATE is set - webhook targets may resolve to loopback and private addresses")
+	}
+
+	return &NotificationService{
+		db: database,
+		channels: notifications.NewRegistry(
+			notifications.NewEmailChannel(smtpConfig),
+			notifications.NewWebhookChannel(targetPolicy),
+			notifications.NewTeamsChannel(targetPolicy),
+			notifications.NewSlackChannel(targetPolicy),
+		),
+		frontendURL: strings.TrimSuffix(cfg.FrontendURL, "/"),
+	}
+}
+
+// ValidateSubscription checks channel, target, severity and delivery of a subscription
+func (s *NotificationService) ValidateSubscription(params db.NotificationSubscriptionParams) error {
+	channel, err := s.channels.Get(params.Channel)
+	if err != nil {
+		return err
+	}
+	if err := channel.ValidateTarget(params.Target); err != nil {
+		return err
+	}
+	if !notifications.ValidSeverity(params.MinSeverity) {
+		return fmt.Errorf("invalid minSeverity: %s (must be info, warning or critical)", params.MinSeverity)
+	}
+	if params.Delivery != NotificationDeliveryImmediate && params.Delivery != NotificationDeliveryDigest {
+		return fmt.Errorf("invalid delivery: %s (must be immediate or digest)", params.Delivery)
+	}
+	return nil
+}
+
+// NotifyJob matches a completed detection job's new anomalies and issues against the
+// environment's subscriptions, sending immediate notifications and queueing digest items
+// Errors are logged, never returned, so notifications cannot fail a refresh
+func (s *NotificationService) NotifyJob(ctx context.Context, environment, jobID string) {
+	if LoadSystemSettingString(s.db, environment, "notifications_enabled", "true") != "true" {
+		return
+	}
+
+	subscriptions, err := s.db.GetActiveNotificationSubscriptions(ctx, environment)
+	if err != nil {
+		log.Printf("Notifications: %v", err)
+		return
+	}
+	if len(subscriptions) == 0 {
+		return
+	}
+
+	items, err := s.collectJobItems(ctx, environment, jobID)
+	if err != nil {
+		log.Printf("Notifications: failed to collect items for job %s: %v", jobID, err)
+		return
+	}
+	if len(items) == 0 {
+		return
+	}
+
+	sent, queued := 0, 0
+	for _, sub := range subscriptions {
+		matched := matchSubscription(sub, items)
+		if len(matched) == 0 {
+			continue
+		}
+
+		if sub.Delivery == NotificationDeliveryDigest {
+			itemsJSON, _ := json.Marshal(matched)
+			if err := s.db.EnqueueNotificationDigestItems(ctx, sub.ID, jobID, string(itemsJSON)); err != nil {
+				log.Printf("Notifications: %v", err)
+				continue
+			}
+			queued++
+			continue
+		}
+
+		msg := s.buildMessage(environment, jobID, matched, false)
+		if err := s.deliver(ctx, sub, jobID, msg); err == nil {
+			sent++
+		}
+	}
+
+	log.Printf("Notifications for job %s: %d sent, %d queued for digest", jobID, sent, queued)
+}
+
+// SendDigests sends all pending digest items of an environment, one message per subscription
+func (s *NotificationService) SendDigests(ctx context.Context, environment string) {
+	batches, err := s.db.ClaimNotificationDigestItems(ctx, environment)
+	if err != nil {
+		log.Printf("Notifications: %v", err)
+		return
+	}
+
+	for _, batch := range batches {
+		sub, err := s.db.GetNotificationSubscription(ctx, batch.SubscriptionID)
+		if err != nil || sub == nil {
+			log.Printf("Notifications: digest subscription %d unavailable: %v", batch.SubscriptionID, err)
+			continue
+		}
+
+		items := make([]notifications.Item, 0)
+		for _, raw := range batch.Items {
+			var jobItems []notifications.Item
+			if err := json.Unmarshal([]byte(raw), &jobItems); err != nil {
+				log.Printf("Notifications: skipping malformed digest items for subscription %d: %v", sub.ID, err)
+				continue
+			}
+			items = append(items, jobItems...)
+		}
+		if len(items) == 0 {
+			continue
+		}
+
+		msg := s.buildMessage(environment, "", items, true)
+		if err := s.deliver(ctx, sub, "", msg); err != nil {
+			// Put the items back so the next digest retries them
+			if releaseErr := s.db.ReleaseNotificationDigestItems(ctx, batch.ItemIDs); releaseErr != nil {
+				log.Printf("Notifications: %v", releaseErr)
+			}
+		}
+	}
+}
+
+// SendTest sends a sample notification so users can verify a subscription's target
+func (s *NotificationService) SendTest(ctx context.Context, sub *db.NotificationSubscription) error {
+	items := []notifications.Item{{
+		Kind:         notifications.KindAnomaly,
+		DetectorType: "test",
+		Severity:     sub.MinSeverity,
+		Summary:      "Test notification - your subscription is working",
+		Count:        1,
+	}}
+
+	msg := s.buildMessage(sub.Environment, "", items, sub.Delivery == NotificationDeliveryDigest)
+	msg.Title = fmt.Sprintf("[%s] M3 Planning Tools test notification", sub.Environment)
+
+	return s.deliver(ctx, sub, "", msg)
+}
+
+// deliver sends a message through the subscription's channel and records the outcome
+func (s *NotificationService) deliver(ctx context.Context, sub *db.NotificationSubscription, jobID string, msg *notifications.Message) error {
+	channel, err := s.channels.Get(sub.Channel)
+	if err == nil {
+		sendCtx, cancel := context.WithTimeout(ctx, 30*time.Second)
+		err = channel.Send(sendCtx, sub.Target, msg)
+		cancel()
+	}
+
+	if err != nil {
+		log.Printf("Notifications: %s delivery for subscription %d failed: %v", sub.Channel, sub.ID, err)
+	}
+	if recordErr := s.db.RecordNotificationDelivery(ctx, sub.ID, jobID, msg.Digest, len(msg.Items), err); recordErr != nil {
+		log.Printf("Notifications: %v", recordErr)
+	}
+
+	return err
+}
+
+// collectJobItems builds notification items from a job's anomalies and newly seen issues
+func (s *NotificationService) collectJobItems(ctx context.Context, environment, jobID string) ([]notifications.Item, error) {
+	items := make([]notifications.Item, 0)
+
+	anomalies, err := s.db.GetNewAnomaliesForJob(ctx, environment, jobID)
+	if err != nil {
+		return nil, err
+	}
+	for _, anomaly := range anomalies {
+		summary := anomaly.Message.String
+		if summary == "" {
+			summary = fmt.Sprintf("%s anomaly", anomaly.DetectorType)
+		}
+		items = append(items, notifications.Item{
+			Kind:         notifications.KindAnomaly,
+			DetectorType: anomaly.DetectorType,
+			Severity:     anomaly.Severity,
+			Summary:      summary,
+			Count:        int(anomaly.AffectedCount.Int32),
+		})
+	}
+
+	newIssues, err := s.db.GetNewIssueCountsForJob(ctx, environment, jobID)
+	if err != nil {
+		return nil, err
+	}
+	severities, err := ParseIssueNotificationSeverity(LoadSystemSettingString(s.db, environment, "issue_notification_severity", "{}"))
+	if err != nil {
+		log.Printf("Notifications: %v (all issues are %s)", err, defaultIssueSeverity)
+	}
+	for _, c := range newIssues {
+		severity, ok := severities[c.DetectorType]
+		if !ok {
+			severity = defaultIssueSeverity
+		}
+		items = append(items, notifications.Item{
+			Kind:         notifications.KindIssue,
+			DetectorType: c.DetectorType,
+			Facility:     c.Facility,
+			Severity:     severity,
+			Summary:      fmt.Sprintf("%d new %s issues", c.Count, c.DetectorType),
+			Count:        c.Count,
+		})
+	}
+
+	return items, nil
+}
+
+// ParseIssueNotificationSeverity parses and validates an issue_notification_severity setting value
+func ParseIssueNotificationSeverity(raw string) (map[string]string, error) {
+	var severities map[string]string
+	if err := json.Unmarshal([]byte(raw), &severities); err != nil {
+		return nil, fmt.Errorf("failed to parse issue notification severity: %w", err)
+	}
+	for detectorType, severity := range severities {
+		if !notifications.ValidSeverity(severity) {
+			return nil, fmt.Errorf("invalid severity %q for %s (must be info, warning or critical)", severity, detectorType)
+		}
+	}
+	return severities, nil
+}
+
+// matchSubscription filters items by the subscription's detector, facility and minimum severity
+// Anomalies are environment-wide, so the facility filter only applies to issues
+func matchSubscription(sub *db.NotificationSubscription, items []notifications.Item) []notifications.Item {
+	matched := make([]notifications.Item, 0)
+	for _, item := range items {
+		if sub.DetectorType.Valid && sub.DetectorType.String != item.DetectorType {
+			continue
+		}
+		if sub.Facility.Valid && item.Kind == notifications.KindIssue && sub.Facility.String != item.Facility {
+			continue
+		}
+		if !notifications.SeverityAtLeast(item.Severity, sub.MinSeverity) {
+			continue
+		}
+		matched = append(matched, item)
+	}
+	return matched
+}
+
+// buildMessage renders the title and link for a set of items
+func (s *NotificationService) buildMessage(environment, jobID string, items []notifications.Item, digest bool) *notifications.Message {
+	anomalyCount, issueCount := 0, 0
+	for _, item := range items {
+		if item.Kind == notifications.KindAnomaly {
+			anomalyCount++
+		} else {
+			issueCount += item.Count
+		}
+	}
+
+	severity := notifications.HighestSeverity(items)
+	title := fmt.Sprintf("[%s] %d anomalies, %d new issues detected", environment, anomalyCount, issueCount)
+	if digest {
+		title = fmt.Sprintf("[%s] Planning digest: %d anomalies, %d new issues", environment, anomalyCount, issueCount)
+	}
+
+	link := ""
+	if s.frontendURL != "" {
+		link = s.frontendURL + "/issues"
+		if issueCount == 0 {
+			link = s.frontendURL + "/anomalies"
+		}
+	}
+
+	return &notifications.Message{
+		Environment: environment,
+		JobID:       jobID,
+		Title:       title,
+		Severity:    severity,
+		Digest:      digest,
+		Link:        link,
+		Items:       items,
+		SentAt:      time.Now(),
+	}
+}
@@ -0,0 +1,124 @@
+package notifications
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Channel names stored on notification subscriptions
+const (
+	ChannelEmail   = "email"
+	ChannelWebhook = "webhook"
+	ChannelTeams   = "teams"
+	ChannelSlack   = "slack"
+)
+
+// Item kinds
+const (
+	KindAnomaly = "anomaly"
+	KindIssue   = "issue"
+)
+
+// Item is one alert line in a notification (an anomaly or a group of new issues)
+type Item struct {
+	Kind         string `json:"kind"` // "anomaly" or "issue"
+	DetectorType string `json:"detectorType"`
+	Facility     string `json:"facility,omitempty"`
+	Severity     string `json:"severity"`
+	Summary      string `json:"summary"`
+	Count        int    `json:"count"`
+}
+
+// Message is a rendered notification sent to one subscription target
+type Message struct {
+	Environment string    `json:"environment"`
+	JobID       string    `json:"jobId,omitempty"`
+	Title       string    `json:"title"`
+	Severity    string    `json:"severity"` // Highest item severity
+	Digest      bool      `json:"digest"`
+	Link        string    `json:"link,omitempty"`
+	Items       []Item    `json:"items"`
+	SentAt      time.Time `json:"sentAt"`
+}
+
+// Channel delivers notifications to a target (email address or webhook URL)
+type Channel interface {
+	// Name returns the channel identifier stored on subscriptions
+	Name() string
+
+	// ValidateTarget checks that a subscription target is usable by this channel
+	ValidateTarget(target string) error
+
+	// Send delivers the message to the target
+	Send(ctx context.Context, target string, msg *Message) error
+}
+
+// Registry maps channel names to their implementations
+type Registry struct {
+	channels map[string]Channel
+}
+
+// NewRegistry creates a registry with the given channels
+func NewRegistry(channels ...Channel) *Registry {
+	r := &Registry{channels: make(map[string]Channel)}
+	for _, channel := range channels {
+		r.channels[channel.Name()] = channel
+	}
+	return r
+}
+
+// Get returns the channel with the given name
+func (r *Registry) Get(name string) (Channel, error) {
+	channel, ok := r.channels[name]
+	if !ok {
+		return nil, fmt.Errorf("unknown notification channel: %s", name)
+	}
+	return channel, nil
+}
+
+// severityRank orders severities for threshold comparison
+var severityRank = map[string]int{
+	"info":     1,
+	"warning":  2,
+	"critical": 3,
+}
+
+// SeverityAtLeast reports whether severity meets the minimum severity
+func SeverityAtLeast(severity, minimum string) bool {
+	return severityRank[severity] >= severityRank[minimum]
+}
+
+// ValidSeverity reports whether severity is a known severity level
+func ValidSeverity(severity string) bool {
+	_, ok := severityRank[severity]
+	return ok
+}
+
+// HighestSeverity returns the most severe level among the items
+func HighestSeverity(items []Item) string {
+	highest := "info"
+	for _, item := range items {
+		if severityRank[item.Severity] > severityRank[highest] {
+			highest = item.Severity
+		}
+	}
+	return highest
+}
+
+// renderText renders a message as plain text lines, shared by email and chat channels
+func renderText(msg *Message) string {
+	var b strings.Builder
+	for _, item := range msg.Items {
+		fmt.Fprintf(&b, "[%s] %s", strings.ToUpper(item.Severity), item.Summary)
+		if item.Facility != "" {
+			fmt.Fprintf(&b, " (facility %s)", item.Facility)
+		}
+		b.WriteString("\n")
+	}
+	if msg.Link != "" {
+		fmt.Fprintf(&b, "\nOpen in M3 Planning Tools: %s\n", msg.Link)
+	}
+	return b.String()
+}
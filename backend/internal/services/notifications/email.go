@@ -0,0 +1,82 @@
+package notifications
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/mail"
+	"net/smtp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// SMTPConfig holds the outbound mail server settings
+type SMTPConfig struct {
+	Host     string
+	Port     int
+	Username string
+	Password string
+	From     string
+}
+
+// EmailChannel sends notifications through an SMTP server
+type EmailChannel struct {
+	config SMTPConfig
+}
+
+// NewEmailChannel creates an SMTP email channel
+func NewEmailChannel(config SMTPConfig) *EmailChannel {
+	return &EmailChannel{config: config}
+}
+
+// Name returns the channel identifier
+func (c *EmailChannel) Name() string { return ChannelEmail }
+
+// ValidateTarget checks the recipient address
+func (c *EmailChannel) ValidateTarget(target string) error {
+	if _, err := mail.ParseAddress(target); err != nil {
+		return fmt.Errorf("invalid email address: %w", err)
+	}
+	return nil
+}
+
+// Send delivers the message as a plain-text email
+// Authentication is only used when a username is configured, so local SMTP stubs work without it
+func (c *EmailChannel) Send(ctx context.Context, target string, msg *Message) error {
+	if c.config.Host == "" {
+		return fmt.Errorf("SMTP is not configured (SMTP_HOST is empty)")
+	}
+
+	addr := net.JoinHostPort(c.config.Host, strconv.Itoa(c.config.Port))
+
+	var auth smtp.Auth
+	if c.config.Username != "" {
+		auth = smtp.PlainAuth("", c.config.Username, c.config.Password, c.config.Host)
+	}
+
+	var body strings.Builder
+	fmt.Fprintf(&body, "From: %s\r\n", c.config.From)
+	fmt.Fprintf(&body, "To: %s\r\n", target)
+	fmt.Fprintf(&body, "Subject: %s\r\n", msg.Title)
+	fmt.Fprintf(&body, "Date: %s\r\n", msg.SentAt.Format(time.RFC1123Z))
+	body.WriteString("MIME-Version: 1.0\r\n")
+	body.WriteString("Content-Type: text/plain; charset=UTF-8\r\n\r\n")
+	body.WriteString(strings.ReplaceAll(renderText(msg), "\n", "\r\n"))
+
+	// net/smtp has no context support; run in a goroutine so cancellation is honoured
+	done := make(chan error, 1)
+	go func() {
+		done <- smtp.SendMail(addr, auth, c.config.From, []string{target}, []byte(body.String()))
+	}()
+
+	select {
+	case err := <-done:
+		if err != nil {
+			return fmt.Errorf("failed to send email: %w", err)
+		}
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
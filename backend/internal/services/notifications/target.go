@@ -0,0 +1,137 @@
+package notifications
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"syscall"
+	"time"
+)
+
+// carrierGradeNAT is the shared address space (RFC 6598), not covered by net.IP.IsPrivate
+var carrierGradeNAT = &net.IPNet{IP: net.IPv4(100, 64, 0, 0), Mask: net.CIDRMask(10, 32)}
+
+// TargetPolicy restricts where the webhook-based channels may post
+// Any logged-in user can create a subscription, so targets must never reach the server's own
+// network: hosts must be on the allowlist (when configured) and resolve to public addresses only.
+// The address check runs again when connecting, so a DNS change after validation cannot bypass it.
+type TargetPolicy struct {
+	allowedHosts []string // Lowercased host names; "*.example.com" allows subdomains of example.com
+	allowPrivate bool     // Development only: also allow loopback and private addresses (local webhook stubs)
+	resolver     *net.Resolver
+	client       *http.Client
+}
+
+// NewTargetPolicy creates a policy from a comma-separated host allowlist (empty allows any public host)
+// allowPrivate additionally permits loopback and private addresses; it must only be set in development
+func NewTargetPolicy(allowedHosts string, allowPrivate bool) *TargetPolicy {
+	p := &TargetPolicy{allowPrivate: allowPrivate, resolver: net.DefaultResolver}
+	for _, host := range strings.Split(allowedHosts, ",") {
+		if host = strings.ToLower(strings.TrimSpace(host)); host != "" {
+			p.allowedHosts = append(p.allowedHosts, host)
+		}
+	}
+
+	dialer := &net.Dialer{
+		Timeout: 10 * time.Second,
+		Control: func(network, address string, _ syscall.RawConn) error {
+			host, _, err := net.SplitHostPort(address)
+			if err != nil {
+				return err
+			}
+			if ip := net.ParseIP(host); ip == nil || !p.addressAllowed(ip) {
+				return fmt.Errorf("webhook target address %s is not a public address", host)
+			}
+			return nil
+		},
+	}
+	p.client = &http.Client{
+		Timeout: 15 * time.Second,
+		// No proxy: the dialer must see the webhook's own address
+		Transport: &http.Transport{
+			DialContext:         dialer.DialContext,
+			TLSHandshakeTimeout: 10 * time.Second,
+		},
+		// Redirects are not followed, so an allowed host cannot forward the request elsewhere
+		CheckRedirect: func(*http.Request, []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}
+	return p
+}
+
+// Client returns the HTTP client that enforces the policy on every connection
+func (p *TargetPolicy) Client() *http.Client {
+	return p.client
+}
+
+// ValidateURL checks that a webhook target is an http(s) URL on an allowed host that resolves
+// to public addresses only
+func (p *TargetPolicy) ValidateURL(target string) error {
+	u, err := url.Parse(target)
+	if err != nil || u.Host == "" {
+		return fmt.Errorf("webhook target must be an absolute URL")
+	}
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return fmt.Errorf("webhook target must be an http(s) URL")
+	}
+	if u.User != nil {
+		return fmt.Errorf("webhook target must not contain credentials")
+	}
+
+	host := strings.ToLower(u.Hostname())
+	if !p.hostAllowed(host) {
+		return fmt.Errorf("webhook host %s is not in the allowed hosts (NOTIFICATION_WEBHOOK_HOSTS)", host)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	addrs, err := p.resolver.LookupIPAddr(ctx, host)
+	if err != nil {
+		return fmt.Errorf("failed to resolve webhook host %s: %w", host, err)
+	}
+	for _, addr := range addrs {
+		if !p.addressAllowed(addr.IP) {
+			return fmt.Errorf("webhook host %s resolves to a non-public address", host)
+		}
+	}
+	return nil
+}
+
+// hostAllowed reports whether host matches the allowlist; an empty allowlist allows any host
+func (p *TargetPolicy) hostAllowed(host string) bool {
+	if len(p.allowedHosts) == 0 {
+		return true
+	}
+	for _, allowed := range p.allowedHosts {
+		if suffix, ok := strings.CutPrefix(allowed, "*"); ok {
+			if strings.HasSuffix(host, suffix) && len(host) > len(suffix) {
+				return true
+			}
+		} else if host == allowed {
+			return true
+		}
+	}
+	return false
+}
+
+// addressAllowed reports whether the policy may connect to ip
+// Link-local addresses (cloud metadata endpoints) stay blocked even when private targets are allowed
+func (p *TargetPolicy) addressAllowed(ip net.IP) bool {
+	if isPublicIP(ip) {
+		return true
+	}
+	return p.allowPrivate && (ip.IsLoopback() || ip.IsPrivate() || carrierGradeNAT.Contains(ip))
+}
+
+// isPublicIP reports whether ip is a globally routable unicast address
+func isPublicIP(ip net.IP) bool {
+	return ip.IsGlobalUnicast() &&
+		!ip.IsPrivate() &&
+		!ip.IsLoopback() &&
+		!ip.IsLinkLocalUnicast() &&
+		!carrierGradeNAT.Contains(ip)
+}
@@ -0,0 +1,141 @@
+package notifications
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+)
+
+// postJSON posts a JSON payload and treats any non-2xx response as a failure
+// The target is validated again before sending, as the subscription may predate the policy
+func postJSON(ctx context.Context, policy *TargetPolicy, target string, payload interface{}) error {
+	if err := policy.ValidateURL(target); err != nil {
+		return err
+	}
+
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return fmt.Errorf("failed to marshal payload: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, target, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := policy.Client().Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to post notification: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		respBody, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("webhook returned status %d: %s", resp.StatusCode, string(respBody))
+	}
+
+	return nil
+}
+
+// WebhookChannel posts the message as JSON to a generic webhook URL
+type WebhookChannel struct {
+	policy *TargetPolicy
+}
+
+// NewWebhookChannel creates a generic JSON webhook channel
+func NewWebhookChannel(policy *TargetPolicy) *WebhookChannel {
+	return &WebhookChannel{policy: policy}
+}
+
+// Name returns the channel identifier
+func (c *WebhookChannel) Name() string { return ChannelWebhook }
+
+// ValidateTarget checks the webhook URL
+func (c *WebhookChannel) ValidateTarget(target string) error { return c.policy.ValidateURL(target) }
+
+// Send posts the message unchanged so receivers get the structured items
+func (c *WebhookChannel) Send(ctx context.Context, target string, msg *Message) error {
+	return postJSON(ctx, c.policy, target, msg)
+}
+
+// TeamsChannel posts a MessageCard to a Microsoft Teams incoming webhook
+type TeamsChannel struct {
+	policy *TargetPolicy
+}
+
+// NewTeamsChannel creates a Teams incoming webhook channel
+func NewTeamsChannel(policy *TargetPolicy) *TeamsChannel {
+	return &TeamsChannel{policy: policy}
+}
+
+// Name returns the channel identifier
+func (c *TeamsChannel) Name() string { return ChannelTeams }
+
+// ValidateTarget checks the webhook URL
+func (c *TeamsChannel) ValidateTarget(target string) error { return c.policy.ValidateURL(target) }
+
+// Send posts the message as a MessageCard with one fact per item
+func (c *TeamsChannel) Send(ctx context.Context, target string, msg *Message) error {
+	themeColor := "0078D7"
+	switch msg.Severity {
+	case "critical":
+		themeColor = "D13438"
+	case "warning":
+		themeColor = "FFB900"
+	}
+
+	facts := make([]map[string]string, 0, len(msg.Items))
+	for _, item := range msg.Items {
+		name := item.DetectorType
+		if item.Facility != "" {
+			name = fmt.Sprintf("%s (%s)", name, item.Facility)
+		}
+		facts = append(facts, map[string]string{"name": name, "value": item.Summary})
+	}
+
+	card := map[string]interface{}{
+		"@type":      "MessageCard",
+		"@context":   "https://schema.org/extensions",
+		"summary":    msg.Title,
+		"themeColor": themeColor,
+		"title":      msg.Title,
+		"sections":   []map[string]interface{}{{"facts": facts}},
+	}
+	if msg.Link != "" {
+		card["potentialAction"] = []map[string]interface{}{{
+			"@type":   "OpenUri",
+			"name":    "Open in M3 Planning Tools",
+			"targets": []map[string]string{{"os": "default", "uri": msg.Link}},
+		}}
+	}
+
+	return postJSON(ctx, c.policy, target, card)
+}
+
+// SlackChannel posts a text message to a Slack incoming webhook
+type SlackChannel struct {
+	policy *TargetPolicy
+}
+
+// NewSlackChannel creates a Slack incoming webhook channel
+func NewSlackChannel(policy *TargetPolicy) *SlackChannel {
+	return &SlackChannel{policy: policy}
+}
+
+// Name returns the channel identifier
+func (c *SlackChannel) Name() string { return ChannelSlack }
+
+// ValidateTarget checks the webhook URL
+func (c *SlackChannel) ValidateTarget(target string) error { return c.policy.ValidateURL(target) }
+
+// Send posts the message as Slack mrkdwn text
+func (c *SlackChannel) Send(ctx context.Context, target string, msg *Message) error {
+	payload := map[string]string{
+		"text": fmt.Sprintf("*%s*\n%s", msg.Title, renderText(msg)),
+	}
+	return postJSON(ctx, c.policy, target, payload)
+}
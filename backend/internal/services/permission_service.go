@@ -0,0 +1,304 @@
+package services
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"sort"
+
+	"github.com/pinggolf/m3-planning-tools/internal/db"
+	"github.com/pinggolf/m3-planning-tools/internal/infor"
+)
+
+// Planning roles, in ascending order of privilege
+const (
+	RoleViewer        = "viewer"
+	RolePlanner       = "planner"
+	RolePlanningAdmin = "planning-admin"
+)
+
+// Permission names an issue write action
+type Permission string
+
+// Issue write permissions
+const (
+	PermissionIgnoreIssue    Permission = "issues.ignore"     // Ignore and unignore issues
+	PermissionDeleteMOP      Permission = "issues.delete_mop" // Delete planned MOs (PMS170MI/DelPlannedMO)
+	PermissionAlignOrders    Permission = "issues.align"      // Align order dates to the earliest/latest date
+	PermissionDeleteMO       Permission = "issues.delete_mo"  // Delete MOs (PMS100MI/DltMO)
+	PermissionCloseMO        Permission = "issues.close_mo"   // Close MOs (PMS100MI/CloseMO)
+	PermissionRevertAuditLog Permission = "audit.revert"      // Revert a previous M3 write from the audit log
+	PermissionRequestAction  Permission = "issues.request"    // Submit action requests for operations that need approval
+)
+
+// roleRank orders roles; a facility's role is the highest any mapping grants there
+var roleRank = map[string]int{
+	RoleViewer:        1,
+	RolePlanner:       2,
+	RolePlanningAdmin: 3,
+}
+
+// rolePermissions lists what each role may do; every role includes the permissions of the roles below it
+var rolePermissions = map[string][]Permission{
+	RoleViewer:        {},
+	RolePlanner:       {PermissionIgnoreIssue, PermissionDeleteMOP, PermissionAlignOrders, PermissionRequestAction},
+	RolePlanningAdmin: {PermissionIgnoreIssue, PermissionDeleteMOP, PermissionAlignOrders, PermissionRequestAction, PermissionDeleteMO, PermissionCloseMO, PermissionRevertAuditLog},
+}
+
+// approvalOperations maps the operations that can require approval to the permission an
+// approver needs in the order's facility
+var approvalOperations = map[string]Permission{
+	"delete_mo": PermissionDeleteMO,
+	"close_mo":  PermissionCloseMO,
+}
+
+// allFacilities scopes a role mapping to every facility
+const allFacilities = "*"
+
+// RoleMapping is one entry of the role_mappings system setting
+// Users in the Infor group (matched on display name or value) get the role in the listed
+// facilities; no facilities (or "*") means every facility
+type RoleMapping struct {
+	Group      string   `json:"group"`
+	Role       string   `json:"role"`
+	Facilities []string `json:"facilities,omitempty"`
+}
+
+// defaultRoleMappings applies when role_mappings is missing or invalid
+var defaultRoleMappings = []RoleMapping{
+	{Group: "Infor-SystemAdministrator", Role: RolePlanningAdmin},
+}
+
+// FacilityRole is a user's role in one facility
+type FacilityRole struct {
+	Role        string       `json:"role"`
+	Permissions []Permission `json:"permissions"`
+}
+
+// EffectivePermissions is what a user may do in an environment
+// Facilities lists facilities where a mapping grants more than the default; every other
+// facility uses Default
+type EffectivePermissions struct {
+	UserID        string                   `json:"userId"`
+	Environment   string                   `json:"environment"`
+	MatchedGroups []string                 `json:"matchedGroups"`
+	Default       FacilityRole             `json:"default"`
+	Facilities    map[string]*FacilityRole `json:"facilities"`
+}
+
+// RoleFor returns the user's role in a facility
+func (p *EffectivePermissions) RoleFor(facility string) string {
+	if role, ok := p.Facilities[facility]; ok {
+		return role.Role
+	}
+	return p.Default.Role
+}
+
+// Can reports whether the user holds a permission in a facility
+func (p *EffectivePermissions) Can(permission Permission, facility string) bool {
+	for _, granted := range rolePermissions[p.RoleFor(facility)] {
+		if granted == permission {
+			return true
+		}
+	}
+	return false
+}
+
+// CanInAnyFacility reports whether the user holds a permission in at least one facility
+func (p *EffectivePermissions) CanInAnyFacility(permission Permission) bool {
+	if p.Can(permission, "") {
+		return true
+	}
+	for facility := range p.Facilities {
+		if p.Can(permission, facility) {
+			return true
+		}
+	}
+	return false
+}
+
+// PermissionService maps cached Infor group memberships to planning roles
+type PermissionService struct {
+	queries *db.Queries
+}
+
+// NewPermissionService creates a new permission service
+func NewPermissionService(queries *db.Queries) *PermissionService {
+	return &PermissionService{queries: queries}
+}
+
+// ResolvePermissions computes a user's effective permissions from their profile's groups
+// and the environment's role_mappings and default_role settings
+func (s *PermissionService) ResolvePermissions(environment string, profile *infor.CombinedUserProfile) *EffectivePermissions {
+	mappings := s.loadRoleMappings(environment)
+
+	// Unmapped users default to planner, the actions every user had before roles existed
+	defaultRole := LoadSystemSettingString(s.queries, environment, "default_role", RolePlanner)
+	if !IsValidRole(defaultRole) {
+		log.Printf("Warning: Invalid default_role %q for %s, using %s", defaultRole, environment, RolePlanner)
+		defaultRole = RolePlanner
+	}
+
+	return resolvePermissions(environment, profile, mappings, defaultRole)
+}
+
+// resolvePermissions applies role mappings to a profile's groups
+func resolvePermissions(environment string, profile *infor.CombinedUserProfile, mappings []RoleMapping, defaultRole string) *EffectivePermissions {
+	groups := make(map[string]bool, len(profile.Groups)*2)
+	for _, g := range profile.Groups {
+		groups[g.Display] = true
+		groups[g.Value] = true
+	}
+
+	perms := &EffectivePermissions{
+		UserID:        profile.ID,
+		Environment:   environment,
+		MatchedGroups: make([]string, 0),
+		Facilities:    make(map[string]*FacilityRole),
+	}
+
+	facilityRoles := make(map[string]string)
+	matched := make(map[string]bool)
+	for _, mapping := range mappings {
+		if mapping.Group == "" || !groups[mapping.Group] {
+			continue
+		}
+		if !matched[mapping.Group] {
+			matched[mapping.Group] = true
+			perms.MatchedGroups = append(perms.MatchedGroups, mapping.Group)
+		}
+
+		if isAllFacilities(mapping.Facilities) {
+			defaultRole = higherRole(defaultRole, mapping.Role)
+			continue
+		}
+		for _, facility := range mapping.Facilities {
+			facilityRoles[facility] = higherRole(facilityRoles[facility], mapping.Role)
+		}
+	}
+
+	perms.Default = newFacilityRole(defaultRole)
+	for facility, role := range facilityRoles {
+		// Facility mappings only matter where they grant more than the default
+		if roleRank[role] > roleRank[defaultRole] {
+			fr := newFacilityRole(role)
+			perms.Facilities[facility] = &fr
+		}
+	}
+	sort.Strings(perms.MatchedGroups)
+
+	return perms
+}
+
+// loadRoleMappings reads the role_mappings setting, falling back to the defaults when invalid
+func (s *PermissionService) loadRoleMappings(environment string) []RoleMapping {
+	raw := LoadSystemSettingString(s.queries, environment, "role_mappings", "")
+	if raw == "" {
+		return defaultRoleMappings
+	}
+
+	mappings, err := ParseRoleMappings(raw)
+	if err != nil {
+		log.Printf("Warning: Invalid role_mappings for %s, using defaults: %v", environment, err)
+		return defaultRoleMappings
+	}
+	return mappings
+}
+
+// ApprovalRequiredOperations returns the operations that need an approved action request in
+// an environment (the approval_required_operations setting)
+// An invalid setting requires approval for every operation that supports it
+func (s *PermissionService) ApprovalRequiredOperations(environment string) []string {
+	raw := LoadSystemSettingString(s.queries, environment, "approval_required_operations", "[]")
+
+	operations, err := ParseApprovalRequiredOperations(raw)
+	if err != nil {
+		log.Printf("Warning: Invalid approval_required_operations for %s, requiring approval for all: %v", environment, err)
+		operations = make([]string, 0, len(approvalOperations))
+		for operation := range approvalOperations {
+			operations = append(operations, operation)
+		}
+		sort.Strings(operations)
+	}
+	return operations
+}
+
+// ApprovalRequired reports whether an operation needs an approved action request in an environment
+func (s *PermissionService) ApprovalRequired(environment, operation string) bool {
+	for _, required := range s.ApprovalRequiredOperations(environment) {
+		if required == operation {
+			return true
+		}
+	}
+	return false
+}
+
+// ApprovalPermission returns the permission needed to approve an operation, and false if the
+// operation does not support approval
+func ApprovalPermission(operation string) (Permission, bool) {
+	permission, ok := approvalOperations[operation]
+	return permission, ok
+}
+
+// ParseApprovalRequiredOperations parses and validates an approval_required_operations setting value
+func ParseApprovalRequiredOperations(raw string) ([]string, error) {
+	var operations []string
+	if err := json.Unmarshal([]byte(raw), &operations); err != nil {
+		return nil, fmt.Errorf("failed to parse approval required operations: %w", err)
+	}
+	for _, operation := range operations {
+		if _, ok := approvalOperations[operation]; !ok {
+			return nil, fmt.Errorf("operation %q does not support approval (use delete_mo or close_mo)", operation)
+		}
+	}
+	return operations, nil
+}
+
+// IsValidRole reports whether role is a known planning role
+func IsValidRole(role string) bool {
+	_, ok := roleRank[role]
+	return ok
+}
+
+// ParseRoleMappings parses and validates a role_mappings setting value
+func ParseRoleMappings(raw string) ([]RoleMapping, error) {
+	var mappings []RoleMapping
+	if err := json.Unmarshal([]byte(raw), &mappings); err != nil {
+		return nil, fmt.Errorf("failed to parse role mappings: %w", err)
+	}
+	for i, mapping := range mappings {
+		if mapping.Group == "" {
+			return nil, fmt.Errorf("role mapping %d: group is required", i+1)
+		}
+		if !IsValidRole(mapping.Role) {
+			return nil, fmt.Errorf("role mapping %d: unknown role %q (use %s, %s or %s)", i+1, mapping.Role, RoleViewer, RolePlanner, RolePlanningAdmin)
+		}
+	}
+	return mappings, nil
+}
+
+// newFacilityRole returns a role with its permissions
+func newFacilityRole(role string) FacilityRole {
+	return FacilityRole{Role: role, Permissions: rolePermissions[role]}
+}
+
+// higherRole returns the more privileged of two roles
+func higherRole(a, b string) string {
+	if roleRank[b] > roleRank[a] {
+		return b
+	}
+	return a
+}
+
+// isAllFacilities reports whether a mapping's facilities cover every facility
+func isAllFacilities(facilities []string) bool {
+	if len(facilities) == 0 {
+		return true
+	}
+	for _, facility := range facilities {
+		if facility == allFacilities {
+			return true
+		}
+	}
+	return false
+}
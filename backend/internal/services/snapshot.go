@@ -5,7 +5,9 @@ import (
 	"encoding/json"
 	"fmt"
 	"log"
+	"math"
 	"strconv"
+	"time"
 
 	"github.com/pinggolf/m3-planning-tools/internal/compass"
 	"github.com/pinggolf/m3-planning-tools/internal/db"
@@ -15,11 +17,21 @@ import (
 // Parameters: phase, stepNum, totalSteps, message, mopCount, moCount, coCount, currentRecordCount
 type ProgressCallback func(phase string, stepNum, totalSteps int, message string, mopCount, moCount, coCount, currentRecordCount int)
 
+// Refresh modes for snapshot data loading
+const (
+	RefreshModeFull        = "full"        // Truncate tables and reload everything
+	RefreshModeIncremental = "incremental" // Upsert LMDT deltas and reconcile removed rows
+)
+
 // SnapshotService handles data refresh operations
 type SnapshotService struct {
 	compassClient    *compass.Client
 	db               *db.Queries
 	progressCallback ProgressCallback
+	rateLimiter      *RateLimiterService
+	// Batch job whose Compass query progress is checkpointed (empty = no checkpoints)
+	checkpointJobID       string
+	checkpointParentJobID string
 	// Track counts for progress reporting
 	mopCount int
 	moCount  int
@@ -39,6 +51,18 @@ func (s *SnapshotService) SetProgressCallback(callback ProgressCallback) {
 	s.progressCallback = callback
 }
 
+// SetRateLimiter throttles Compass page requests through the shared rate limiter
+func (s *SnapshotService) SetRateLimiter(limiter *RateLimiterService) {
+	s.rateLimiter = limiter
+}
+
+// SetCheckpointJob checkpoints Compass query progress under a data batch job, so a
+// redelivered job resumes from its last loaded page
+func (s *SnapshotService) SetCheckpointJob(jobID, parentJobID string) {
+	s.checkpointJobID = jobID
+	s.checkpointParentJobID = parentJobID
+}
+
 // reportProgress calls the progress callback if set
 func (s *SnapshotService) reportProgress(phase string, stepNum, totalSteps int, message string) {
 	if s.progressCallback != nil {
@@ -56,210 +80,67 @@ func (s *SnapshotService) reportSubProgress(operation string, recordCount int) {
 // RefreshOpenCustomerOrderLines refreshes all open CO lines (status < 30)
 // Filtered by environment, company and facility context
 // This is more efficient than querying by specific order numbers when there are many orders
+// In incremental mode only lines changed since the last sync are fetched, and lines that
+// were deleted or closed in M3 are removed afterwards
 // Returns the count of records processed
-func (s *SnapshotService) RefreshOpenCustomerOrderLines(ctx context.Context, environment, company string, facility string, language string) (int, error) {
-	log.Printf("Refreshing all open customer order lines (status < 30) for environment '%s', company '%s', facility '%s' and language '%s'...", environment, company, facility, language)
+func (s *SnapshotService) RefreshOpenCustomerOrderLines(ctx context.Context, environment, company string, facility string, language string, mode string) (int, error) {
+	log.Printf("Refreshing all open customer order lines (status < 30) for environment '%s', company '%s', facility '%s' and language '%s' (%s)...", environment, company, facility, language, mode)
+
+	// Open CO lines are not bounded by LMDT on a full refresh
+	syncDate := s.resolveSyncDate(ctx, environment, "customer_order_lines", mode, 0)
 
 	// Build query for all open CO lines with context filters
-	qb := compass.NewQueryBuilder(0, company, facility, language)
+	qb, err := compass.NewQueryBuilder(syncDate, company, facility, language)
+	if err != nil {
+		return 0, err
+	}
 	query := qb.BuildOpenCustomerOrderLinesQuery()
 
-	// Execute query
+	// Stream the query page by page so only one page is held in memory
 	log.Println("Submitting Compass query for open CO lines...")
 	s.reportSubProgress("Querying Compass SQL for customer order lines...", 0)
-	pageSize := LoadSystemSettingInt(s.db, environment, "compass_batch_size", 50000)
-	results, totalRecords, err := s.compassClient.ExecuteQueryWithPagination(
-		ctx, query, pageSize,
+	inserted := 0
+	totalRecords, resumedOffset, err := s.streamQuery(ctx, environment, query,
+		func(records []map[string]interface{}) error {
+			dbRecords := make([]*db.CustomerOrderLine, 0, len(records))
+			for _, record := range records {
+				coLine, err := compass.ParseCustomerOrderLine(record)
+				if err != nil {
+					log.Printf("Warning: failed to parse CO line record: %v", err)
+					continue
+				}
+				dbRecords = append(dbRecords, newCustomerOrderLineRecord(environment, coLine))
+			}
+
+			if err := s.db.BatchInsertCustomerOrderLines(ctx, dbRecords, nil); err != nil {
+				return fmt.Errorf("failed to insert CO lines: %w", err)
+			}
+			inserted += len(dbRecords)
+			return nil
+		},
 		func(page, totalPages, pageRecords, totalFetched, totalRecords int) {
-			operation := fmt.Sprintf("Loading page %d/%d from Compass SQL (%d records, %d total)",
-				page, totalPages, pageRecords, totalFetched)
-			s.reportSubProgress(operation, totalFetched)
+			operation := fmt.Sprintf("Loaded page %d/%d from Compass SQL (%d records, %d/%d inserted)",
+				page, totalPages, pageRecords, inserted, totalRecords)
+			s.reportSubProgress(operation, inserted)
 		},
 	)
 	if err != nil {
-		return 0, fmt.Errorf("failed to execute query: %w", err)
+		return 0, fmt.Errorf("failed to load CO lines: %w", err)
 	}
-	log.Printf("Query returned %d total CO line records", totalRecords)
-
-	// Parse results
-	log.Println("Parsing CO line results...")
-	resultSet, err := compass.ParseResults(results)
-	if err != nil {
-		return 0, fmt.Errorf("failed to parse results: %w", err)
+	if resumedOffset > 0 {
+		// Records before the resumed offset were loaded by an earlier attempt of this job
+		inserted += resumedOffset
 	}
+	log.Printf("Query returned %d total CO line records, inserted %d", totalRecords, inserted)
 
-	log.Printf("Received %d CO line records", len(resultSet.Records))
-	s.reportSubProgress(fmt.Sprintf("Processing %d customer order line records...", len(resultSet.Records)), 0)
-
-	// Transform to database records
-	const parseProgressInterval = 5000
-	dbRecords := make([]*db.CustomerOrderLine, 0, len(resultSet.Records))
-	for i, record := range resultSet.Records {
-		coLine, err := compass.ParseCustomerOrderLine(record)
-		if err != nil {
-			log.Printf("Warning: failed to parse CO line record: %v", err)
-			continue
-		}
-
-		// Map all fields directly - all stored as strings
-		dbRecord := &db.CustomerOrderLine{
-			Environment: environment,
-			CONO: coLine.CONO,
-			DIVI: coLine.DIVI,
-			ORNO: coLine.ORNO,
-			PONR: coLine.PONR,
-			POSX: coLine.POSX,
-			ITNO: coLine.ITNO,
-			ITDS: coLine.ITDS,
-			TEDS: coLine.TEDS,
-			REPI: coLine.REPI,
-			ORST: coLine.ORST,
-			ORTY: coLine.ORTY,
-			FACI: coLine.FACI,
-			WHLO: coLine.WHLO,
-			ORQT: coLine.ORQT,
-			RNQT: coLine.RNQT,
-			ALQT: coLine.ALQT,
-			DLQT: coLine.DLQT,
-			IVQT: coLine.IVQT,
-			ORQA: coLine.ORQA,
-			RNQA: coLine.RNQA,
-			ALQA: coLine.ALQA,
-			DLQA: coLine.DLQA,
-			IVQA: coLine.IVQA,
-			ALUN: coLine.ALUN,
-			COFA: coLine.COFA,
-			SPUN: coLine.SPUN,
-			DWDT: coLine.DWDT,
-			DWHM: coLine.DWHM,
-			CODT: coLine.CODT,
-			COHM: coLine.COHM,
-			PLDT: coLine.PLDT,
-			FDED: coLine.FDED,
-			LDED: coLine.LDED,
-			SAPR: coLine.SAPR,
-			NEPR: coLine.NEPR,
-			LNAM: coLine.LNAM,
-			CUCD: coLine.CUCD,
-			DIP1: coLine.DIP1,
-			DIP2: coLine.DIP2,
-			DIP3: coLine.DIP3,
-			DIP4: coLine.DIP4,
-			DIP5: coLine.DIP5,
-			DIP6: coLine.DIP6,
-			DIA1: coLine.DIA1,
-			DIA2: coLine.DIA2,
-			DIA3: coLine.DIA3,
-			DIA4: coLine.DIA4,
-			DIA5: coLine.DIA5,
-			DIA6: coLine.DIA6,
-			RORC: coLine.RORC,
-			RORN: coLine.RORN,
-			RORL: coLine.RORL,
-			RORX: coLine.RORX,
-			CUNO: coLine.CUNO,
-			CUOR: coLine.CUOR,
-			CUPO: coLine.CUPO,
-			CUSX: coLine.CUSX,
-			CustomerName: coLine.CustomerName,
-			PRNO: coLine.PRNO,
-			HDPR: coLine.HDPR,
-			POPN: coLine.POPN,
-			ALWT: coLine.ALWT,
-			ALWQ: coLine.ALWQ,
-			ADID: coLine.ADID,
-			ROUT: coLine.ROUT,
-			RODN: coLine.RODN,
-			DSDT: coLine.DSDT,
-			DSHM: coLine.DSHM,
-			MODL: coLine.MODL,
-			TEDL: coLine.TEDL,
-			TEL2: coLine.TEL2,
-			TEPA: coLine.TEPA,
-			PACT: coLine.PACT,
-			CUPA: coLine.CUPA,
-			E0PA: coLine.E0PA,
-			DSGP: coLine.DSGP,
-			PUSN: coLine.PUSN,
-			PUTP: coLine.PUTP,
-			JDCD: coLine.JDCD,
-			DLIX: coLine.DLIX,
-			ORTP: coLine.ORTP,
-			COTypeDescription: coLine.COTypeDescription,
-			DeliveryMethod: coLine.DeliveryMethod,
-			DeliveryMethodDescription: coLine.DeliveryMethodDescription,
-			ATV1: coLine.ATV1,
-			ATV2: coLine.ATV2,
-			ATV3: coLine.ATV3,
-			ATV4: coLine.ATV4,
-			ATV5: coLine.ATV5,
-			ATV6: coLine.ATV6,
-			ATV7: coLine.ATV7,
-			ATV8: coLine.ATV8,
-			ATV9: coLine.ATV9,
-			ATV0: coLine.ATV0,
-			UCA1: coLine.UCA1,
-			UCA2: coLine.UCA2,
-			UCA3: coLine.UCA3,
-			UCA4: coLine.UCA4,
-			UCA5: coLine.UCA5,
-			UCA6: coLine.UCA6,
-			UCA7: coLine.UCA7,
-			UCA8: coLine.UCA8,
-			UCA9: coLine.UCA9,
-			UCA0: coLine.UCA0,
-			UDN1: coLine.UDN1,
-			UDN2: coLine.UDN2,
-			UDN3: coLine.UDN3,
-			UDN4: coLine.UDN4,
-			UDN5: coLine.UDN5,
-			UDN6: coLine.UDN6,
-			UID1: coLine.UID1,
-			UID2: coLine.UID2,
-			UID3: coLine.UID3,
-			UCT1: coLine.UCT1,
-			ATNR: coLine.ATNR,
-			ATMO: coLine.ATMO,
-			ATPR: coLine.ATPR,
-			CFIN: coLine.CFIN,
-			PROJ: coLine.PROJ,
-			ELNO: coLine.ELNO,
-			RGDT: coLine.RGDT,
-			RGTM: coLine.RGTM,
-			LMDT: coLine.LMDT,
-			CHNO: coLine.CHNO,
-			CHID: coLine.CHID,
-			LMTS: coLine.LMTS,
-			M3Timestamp: coLine.Timestamp,
-		}
-
-		dbRecords = append(dbRecords, dbRecord)
-
-		// Report parsing progress every N records
-		if (i+1)%parseProgressInterval == 0 || (i+1) == len(resultSet.Records) {
-			operation := fmt.Sprintf("Parsed %d/%d records (%d%%)",
-				i+1, len(resultSet.Records),
-				((i+1)*100)/len(resultSet.Records))
-			s.reportSubProgress(operation, i+1)
+	if mode == RefreshModeIncremental {
+		if err := s.reconcileRemovedCustomerOrderLines(ctx, environment, qb); err != nil {
+			return 0, err
 		}
 	}
 
-	// Batch insert
-	log.Printf("Inserting %d CO line records into database...", len(dbRecords))
-	s.reportSubProgress(fmt.Sprintf("Inserting %d customer order lines into database...", len(dbRecords)), len(dbRecords))
-
-	insertCallback := func(inserted, total int) {
-		operation := fmt.Sprintf("Inserted %d/%d customer order lines into database (%d%%)",
-			inserted, total, (inserted*100)/total)
-		s.reportSubProgress(operation, inserted)
-	}
-
-	if err := s.db.BatchInsertCustomerOrderLines(ctx, dbRecords, insertCallback); err != nil {
-		return 0, fmt.Errorf("failed to insert CO lines: %w", err)
-	}
-
-	log.Printf("CO lines refresh completed - inserted %d records", len(dbRecords))
-	return len(dbRecords), nil
+	log.Printf("CO lines refresh completed - inserted %d records", inserted)
+	return inserted, nil
 }
 
 // RefreshCustomerOrderLinesByNumbers refreshes specific CO lines by order numbers
@@ -275,8 +156,14 @@ func (s *SnapshotService) RefreshCustomerOrderLinesByNumbers(ctx context.Context
 	// Build targeted query (no lastSyncDate needed - we want all lines for these orders)
 	// Note: This deprecated method doesn't filter by context in the query builder call
 	// because BuildCustomerOrderLinesByOrderNumbersQuery doesn't use context fields
-	qb := compass.NewQueryBuilder(0, company, facility, "GB")
-	query := qb.BuildCustomerOrderLinesByOrderNumbersQuery(orderNumbers)
+	qb, err := compass.NewQueryBuilder(0, company, facility, "GB")
+	if err != nil {
+		return err
+	}
+	query, err := qb.BuildCustomerOrderLinesByOrderNumbersQuery(orderNumbers)
+	if err != nil {
+		return fmt.Errorf("failed to build CO lines query: %w", err)
+	}
 
 	// Execute query (DEPRECATED method - use batch refresh instead)
 	log.Println("Submitting Compass query for CO lines...")
@@ -305,139 +192,7 @@ func (s *SnapshotService) RefreshCustomerOrderLinesByNumbers(ctx context.Context
 			continue
 		}
 
-		// Map all fields directly - all stored as strings
-		dbRecord := &db.CustomerOrderLine{
-			Environment: environment,
-			CONO: coLine.CONO,
-			DIVI: coLine.DIVI,
-			ORNO: coLine.ORNO,
-			PONR: coLine.PONR,
-			POSX: coLine.POSX,
-			ITNO: coLine.ITNO,
-			ITDS: coLine.ITDS,
-			TEDS: coLine.TEDS,
-			REPI: coLine.REPI,
-			ORST: coLine.ORST,
-			ORTY: coLine.ORTY,
-			FACI: coLine.FACI,
-			WHLO: coLine.WHLO,
-			ORQT: coLine.ORQT,
-			RNQT: coLine.RNQT,
-			ALQT: coLine.ALQT,
-			DLQT: coLine.DLQT,
-			IVQT: coLine.IVQT,
-			ORQA: coLine.ORQA,
-			RNQA: coLine.RNQA,
-			ALQA: coLine.ALQA,
-			DLQA: coLine.DLQA,
-			IVQA: coLine.IVQA,
-			ALUN: coLine.ALUN,
-			COFA: coLine.COFA,
-			SPUN: coLine.SPUN,
-			DWDT: coLine.DWDT,
-			DWHM: coLine.DWHM,
-			CODT: coLine.CODT,
-			COHM: coLine.COHM,
-			PLDT: coLine.PLDT,
-			FDED: coLine.FDED,
-			LDED: coLine.LDED,
-			SAPR: coLine.SAPR,
-			NEPR: coLine.NEPR,
-			LNAM: coLine.LNAM,
-			CUCD: coLine.CUCD,
-			DIP1: coLine.DIP1,
-			DIP2: coLine.DIP2,
-			DIP3: coLine.DIP3,
-			DIP4: coLine.DIP4,
-			DIP5: coLine.DIP5,
-			DIP6: coLine.DIP6,
-			DIA1: coLine.DIA1,
-			DIA2: coLine.DIA2,
-			DIA3: coLine.DIA3,
-			DIA4: coLine.DIA4,
-			DIA5: coLine.DIA5,
-			DIA6: coLine.DIA6,
-			RORC: coLine.RORC,
-			RORN: coLine.RORN,
-			RORL: coLine.RORL,
-			RORX: coLine.RORX,
-			CUNO: coLine.CUNO,
-			CUOR: coLine.CUOR,
-			CUPO: coLine.CUPO,
-			CUSX: coLine.CUSX,
-			CustomerName: coLine.CustomerName,
-			PRNO: coLine.PRNO,
-			HDPR: coLine.HDPR,
-			POPN: coLine.POPN,
-			ALWT: coLine.ALWT,
-			ALWQ: coLine.ALWQ,
-			ADID: coLine.ADID,
-			ROUT: coLine.ROUT,
-			RODN: coLine.RODN,
-			DSDT: coLine.DSDT,
-			DSHM: coLine.DSHM,
-			MODL: coLine.MODL,
-			TEDL: coLine.TEDL,
-			TEL2: coLine.TEL2,
-			TEPA: coLine.TEPA,
-			PACT: coLine.PACT,
-			CUPA: coLine.CUPA,
-			E0PA: coLine.E0PA,
-			DSGP: coLine.DSGP,
-			PUSN: coLine.PUSN,
-			PUTP: coLine.PUTP,
-			JDCD: coLine.JDCD,
-			DLIX: coLine.DLIX,
-			ORTP: coLine.ORTP,
-			COTypeDescription: coLine.COTypeDescription,
-			DeliveryMethod: coLine.DeliveryMethod,
-			DeliveryMethodDescription: coLine.DeliveryMethodDescription,
-			ATV1: coLine.ATV1,
-			ATV2: coLine.ATV2,
-			ATV3: coLine.ATV3,
-			ATV4: coLine.ATV4,
-			ATV5: coLine.ATV5,
-			ATV6: coLine.ATV6,
-			ATV7: coLine.ATV7,
-			ATV8: coLine.ATV8,
-			ATV9: coLine.ATV9,
-			ATV0: coLine.ATV0,
-			UCA1: coLine.UCA1,
-			UCA2: coLine.UCA2,
-			UCA3: coLine.UCA3,
-			UCA4: coLine.UCA4,
-			UCA5: coLine.UCA5,
-			UCA6: coLine.UCA6,
-			UCA7: coLine.UCA7,
-			UCA8: coLine.UCA8,
-			UCA9: coLine.UCA9,
-			UCA0: coLine.UCA0,
-			UDN1: coLine.UDN1,
-			UDN2: coLine.UDN2,
-			UDN3: coLine.UDN3,
-			UDN4: coLine.UDN4,
-			UDN5: coLine.UDN5,
-			UDN6: coLine.UDN6,
-			UID1: coLine.UID1,
-			UID2: coLine.UID2,
-			UID3: coLine.UID3,
-			UCT1: coLine.UCT1,
-			ATNR: coLine.ATNR,
-			ATMO: coLine.ATMO,
-			ATPR: coLine.ATPR,
-			CFIN: coLine.CFIN,
-			PROJ: coLine.PROJ,
-			ELNO: coLine.ELNO,
-			RGDT: coLine.RGDT,
-			RGTM: coLine.RGTM,
-			LMDT: coLine.LMDT,
-			CHNO: coLine.CHNO,
-			CHID: coLine.CHID,
-			LMTS: coLine.LMTS,
-			M3Timestamp: coLine.Timestamp,
-		}
-
-		dbRecords = append(dbRecords, dbRecord)
+		dbRecords = append(dbRecords, newCustomerOrderLineRecord(environment, coLine))
 	}
 
 	// Batch insert
@@ -452,397 +207,658 @@ func (s *SnapshotService) RefreshCustomerOrderLinesByNumbers(ctx context.Context
 
 // RefreshManufacturingOrders refreshes MO data from Compass with MPREAL joins
 // Filtered by environment, company and facility context
+// In incremental mode only MOs whose MWOHED.LMDT changed since the last sync are fetched
+// Note: a changed MPREAL link alone does not bump MWOHED.LMDT; a full refresh picks those up
 // Returns list of unique CO numbers referenced by MOs
-func (s *SnapshotService) RefreshManufacturingOrders(ctx context.Context, environment, company string, facility string) (int, error) {
-	log.Printf("Refreshing manufacturing orders for environment '%s', company '%s' and facility '%s'...", environment, company, facility)
+func (s *SnapshotService) RefreshManufacturingOrders(ctx context.Context, environment, company string, facility string, mode string) (int, error) {
+	log.Printf("Refreshing manufacturing orders for environment '%s', company '%s' and facility '%s' (%s)...", environment, company, facility, mode)
 
-	// Use full refresh date - no incremental loading
-	fullRefreshDate := compass.GetFullRefreshDate()
-	log.Printf("Using full refresh date: %d", fullRefreshDate)
+	syncDate := s.resolveSyncDate(ctx, environment, "manufacturing_orders", mode, compass.GetFullRefreshDate())
+	log.Printf("Using sync date: %d", syncDate)
 
 	// Build query with context filters
-	qb := compass.NewQueryBuilder(fullRefreshDate, company, facility, "GB")
+	qb, err := compass.NewQueryBuilder(syncDate, company, facility, "GB")
+	if err != nil {
+		return 0, err
+	}
 	query := qb.BuildManufacturingOrdersQuery()
 
-	// Execute query (DEPRECATED method - use batch refresh instead)
+	// Stream the query page by page so only one page is held in memory
 	log.Println("Submitting Compass query for MOs...")
 	s.reportSubProgress("Querying Compass SQL for manufacturing orders...", 0)
-	pageSize := LoadSystemSettingInt(s.db, environment, "compass_batch_size", 50000)
-	results, totalRecords, err := s.compassClient.ExecuteQueryWithPagination(
-		ctx, query, pageSize,
+	inserted := 0
+	totalRecords, resumedOffset, err := s.streamQuery(ctx, environment, query,
+		func(records []map[string]interface{}) error {
+			dbRecords := make([]*db.ManufacturingOrder, 0, len(records))
+			for _, record := range records {
+				mo, err := compass.ParseManufacturingOrder(record)
+				if err != nil {
+					log.Printf("Warning: failed to parse MO record: %v", err)
+					continue
+				}
+				dbRecords = append(dbRecords, newManufacturingOrderRecord(environment, mo, record))
+			}
+
+			if err := s.db.BatchInsertManufacturingOrders(ctx, dbRecords, nil); err != nil {
+				return fmt.Errorf("failed to insert MOs: %w", err)
+			}
+			inserted += len(dbRecords)
+			return nil
+		},
 		func(page, totalPages, pageRecords, totalFetched, totalRecords int) {
-			operation := fmt.Sprintf("Loading page %d/%d from Compass SQL (%d records, %d total)",
-				page, totalPages, pageRecords, totalFetched)
-			s.reportSubProgress(operation, totalFetched)
+			operation := fmt.Sprintf("Loaded page %d/%d from Compass SQL (%d records, %d/%d inserted)",
+				page, totalPages, pageRecords, inserted, totalRecords)
+			s.reportSubProgress(operation, inserted)
 		},
 	)
 	if err != nil {
-		return 0, fmt.Errorf("failed to execute query: %w", err)
+		return 0, fmt.Errorf("failed to load MOs: %w", err)
+	}
+	if resumedOffset > 0 {
+		// Records before the resumed offset were loaded by an earlier attempt of this job
+		inserted += resumedOffset
 	}
-	log.Printf("Query returned %d total MO records", totalRecords)
+	log.Printf("Query returned %d total MO records, inserted %d", totalRecords, inserted)
 
-	// Parse results
-	log.Println("Parsing MO results...")
-	resultSet, err := compass.ParseResults(results)
+	if mode == RefreshModeIncremental {
+		if err := s.reconcileRemovedManufacturingOrders(ctx, environment, qb); err != nil {
+			return 0, err
+		}
+	}
+
+	log.Printf("MO refresh completed - inserted %d records", inserted)
+	return inserted, nil
+}
+
+// RefreshPlannedOrders refreshes MOP data from Compass with MPREAL joins
+// Filtered by environment, company and facility context
+// In incremental mode only MOPs whose MMOPLP.LMDT changed since the last sync are fetched
+// Returns list of unique CO numbers referenced by MOPs
+func (s *SnapshotService) RefreshPlannedOrders(ctx context.Context, environment, company string, facility string, mode string) (int, error) {
+	log.Printf("Refreshing planned manufacturing orders (with CO links via MPREAL) for environment '%s', company '%s' and facility '%s' (%s)...", environment, company, facility, mode)
+
+	syncDate := s.resolveSyncDate(ctx, environment, "planned_manufacturing_orders", mode, compass.GetFullRefreshDate())
+	log.Printf("Using sync date: %d", syncDate)
+
+	// Build query with MPREAL join and context filters
+	qb, err := compass.NewQueryBuilder(syncDate, company, facility, "GB")
 	if err != nil {
-		return 0, fmt.Errorf("failed to parse results: %w", err)
+		return 0, err
 	}
+	query := qb.BuildPlannedOrdersWithCOLinksQuery()
 
-	log.Printf("Received %d MO records", len(resultSet.Records))
-	s.reportSubProgress(fmt.Sprintf("Processing %d manufacturing order records...", len(resultSet.Records)), 0)
+	// Stream the query page by page so only one page is held in memory
+	log.Println("Submitting Compass query for MOPs...")
+	s.reportSubProgress("Querying Compass SQL for planned orders...", 0)
+	inserted := 0
+	totalRecords, resumedOffset, err := s.streamQuery(ctx, environment, query,
+		func(records []map[string]interface{}) error {
+			dbRecords := make([]*db.PlannedManufacturingOrder, 0, len(records))
+			for _, record := range records {
+				mop, err := compass.ParsePlannedOrder(record)
+				if err != nil {
+					log.Printf("Warning: failed to parse MOP record: %v", err)
+					continue
+				}
+				dbRecords = append(dbRecords, newPlannedOrderRecord(environment, mop, record))
+			}
 
-	// Transform to database records
-	const parseProgressInterval = 5000
-	dbRecords := make([]*db.ManufacturingOrder, 0, len(resultSet.Records))
-	for i, record := range resultSet.Records {
-		mo, err := compass.ParseManufacturingOrder(record)
+			if err := s.db.BatchInsertPlannedOrders(ctx, dbRecords, nil); err != nil {
+				return fmt.Errorf("failed to insert MOPs: %w", err)
+			}
+			inserted += len(dbRecords)
+			return nil
+		},
+		func(page, totalPages, pageRecords, totalFetched, totalRecords int) {
+			operation := fmt.Sprintf("Loaded page %d/%d from Compass SQL (%d records, %d/%d inserted)",
+				page, totalPages, pageRecords, inserted, totalRecords)
+			s.reportSubProgress(operation, inserted)
+		},
+	)
+	if err != nil {
+		return 0, fmt.Errorf("failed to load MOPs: %w", err)
+	}
+	if resumedOffset > 0 {
+		// Records before the resumed offset were loaded by an earlier attempt of this job
+		inserted += resumedOffset
+	}
+	log.Printf("Query returned %d total MOP records, inserted %d", totalRecords, inserted)
+
+	if mode == RefreshModeIncremental {
+		if err := s.reconcileRemovedPlannedOrders(ctx, environment, qb); err != nil {
+			return 0, err
+		}
+	}
+
+	log.Printf("MOP refresh completed - inserted %d records", inserted)
+	return inserted, nil
+}
+
+// streamQuery streams a Compass query to handler page by page, using the environment's
+// page fetch settings. When a checkpoint job is set, the job's earlier Compass query is
+// resumed from its last loaded page and progress is recorded after every page.
+// Returns: (totalRecords, resumedOffset, error) - resumedOffset records were loaded by an earlier attempt
+func (s *SnapshotService) streamQuery(ctx context.Context, environment, query string, handler compass.PageHandler, progressCallback compass.PaginationProgressCallback) (int, int, error) {
+	opts := s.streamOptions(environment)
+
+	resumedOffset := 0
+	if s.checkpointJobID != "" {
+		checkpoint, err := s.db.GetCompassQueryCheckpoint(ctx, s.checkpointJobID)
 		if err != nil {
-			log.Printf("Warning: failed to parse MO record: %v", err)
-			continue
+			log.Printf("Warning: %v - starting %s from the beginning", err, s.checkpointJobID)
+		} else if checkpoint != nil {
+			log.Printf("Resuming %s from Compass query %s at offset %d/%d",
+				s.checkpointJobID, checkpoint.CompassQueryID, checkpoint.NextOffset, checkpoint.TotalRecords)
+			opts.QueryID = checkpoint.CompassQueryID
+			opts.StartOffset = checkpoint.NextOffset
 		}
 
-		// Extract CO link fields from MPREAL join (all returned as strings)
-		linkedCONumber := getRecordString(record, "linked_co_number")
-		linkedCOLine := getRecordString(record, "linked_co_line")
-		linkedCOSuffix := getRecordString(record, "linked_co_suffix")
-		allocatedQty := getRecordString(record, "allocated_qty")
-
-		// Create database record - all M3 fields stored as strings
-		dbRecord := &db.ManufacturingOrder{
-			Environment:   environment,
-			// Core Identifiers
-			CONO:          intToString(mo.CONO),
-			DIVI:          mo.DIVI,
-			FACI:          mo.FACI,
-			MFNO:          mo.MFNO,
-			PRNO:          mo.PRNO,
-			ITNO:          mo.ITNO,
-
-			// Status
-			WHST:          mo.WHST,
-			WHHS:          mo.WHHS,
-			WMST:          mo.WMST,
-			MOHS:          mo.MOHS,
-
-			// Quantities
-			ORQT:          floatToString(mo.ORQT),
-			MAQT:          floatToString(mo.MAQT),
-			ORQA:          floatToString(mo.ORQA),
-			RVQT:          floatToString(mo.RVQT),
-			RVQA:          floatToString(mo.RVQA),
-			MAQA:          floatToString(mo.MAQA),
-
-			// Dates
-			STDT:          intToString(mo.STDT),
-			FIDT:          intToString(mo.FIDT),
-			MSTI:          intToString(mo.MSTI),
-			MFTI:          intToString(mo.MFTI),
-			FSTD:          intToString(mo.FSTD),
-			FFID:          intToString(mo.FFID),
-			RSDT:          intToString(mo.RSDT),
-			REFD:          intToString(mo.REFD),
-			RPDT:          intToString(mo.RPDT),
-
-			// Planning
-			PRIO:          intToString(mo.PRIO),
-			RESP:          mo.RESP,
-			PLGR:          mo.PLGR,
-			WCLN:          mo.WCLN,
-			PRDY:          intToString(mo.PRDY),
-
-			// Warehouse/Location
-			WHLO:          mo.WHLO,
-			WHSL:          mo.WHSL,
-			BANO:          mo.BANO,
-
-			// Reference Orders
-			RORC:          intToString(mo.RORC),
-			RORN:          mo.RORN,
-			RORL:          intToString(mo.RORL),
-			RORX:          intToString(mo.RORX),
-
-			// Hierarchy
-			PRHL:          mo.PRHL,
-			MFHL:          mo.MFHL,
-			PRLO:          mo.PRLO,
-			MFLO:          mo.MFLO,
-			LEVL:          intToString(mo.LEVL),
-
-			// Configuration
-			CFIN:          int64ToString(mo.CFIN),
-			ATNR:          int64ToString(mo.ATNR),
-
-			// Order Type
-			ORTY:          mo.ORTY,
-			GETP:          mo.GETP,
-
-			// Material/BOM
-			BDCD:          mo.BDCD,
-			SCEX:          mo.SCEX,
-			STRT:          mo.STRT,
-			ECVE:          mo.ECVE,
-
-			// Routing
-			AOID:          mo.AOID,
-			NUOP:          intToString(mo.NUOP),
-			NUFO:          intToString(mo.NUFO),
-
-			// Action/Text
-			ACTP:          mo.ACTP,
-			TXT1:          mo.TXT1,
-			TXT2:          mo.TXT2,
-
-			// Project
-			PROJ:          mo.PROJ,
-			ELNO:          mo.ELNO,
-
-			// M3 Audit
-			RGDT:          intToString(mo.RGDT),
-			RGTM:          intToString(mo.RGTM),
-			LMDT:          intToString(mo.LMDT),
-			LMTS:          int64ToString(mo.LMTS),
-			CHNO:          intToString(mo.CHNO),
-			CHID:          mo.CHID,
-
-			// Metadata
-			M3Timestamp:   int64ToString(mo.Timestamp),
-
-			// CO Link
-			LinkedCONumber: linkedCONumber,
-			LinkedCOLine:   linkedCOLine,
-			LinkedCOSuffix: linkedCOSuffix,
-			AllocatedQty:   allocatedQty,
-
-			// MITMAS Item Master fields
-			ItemType:             mo.ItemType,
-			ItemDescription:      mo.ItemDescription,
-			ItemGroup:            mo.ItemGroup,
-			ProductGroup:         mo.ProductGroup,
-			ProcurementGroup:     mo.ProcurementGroup,
-			GroupTechnologyClass: mo.GroupTechnologyClass,
+		opts.OnQueryReady = func(queryID string, totalRecords, startOffset int) error {
+			resumedOffset = startOffset
+			return s.db.SaveCompassQueryCheckpoint(ctx, &db.CompassQueryCheckpoint{
+				JobID:          s.checkpointJobID,
+				ParentJobID:    s.checkpointParentJobID,
+				Environment:    environment,
+				CompassQueryID: queryID,
+				TotalRecords:   totalRecords,
+				NextOffset:     startOffset,
+			})
+		}
+		opts.OnPageHandled = func(nextOffset int) error {
+			return s.db.UpdateCompassQueryCheckpointOffset(ctx, s.checkpointJobID, nextOffset)
 		}
+	}
 
-		dbRecords = append(dbRecords, dbRecord)
+	totalRecords, err := s.compassClient.StreamQueryResultsWithOptions(ctx, query, opts, handler, progressCallback)
+	return totalRecords, resumedOffset, err
+}
 
-		// Report parsing progress every N records
-		if (i+1)%parseProgressInterval == 0 || (i+1) == len(resultSet.Records) {
-			operation := fmt.Sprintf("Parsed %d/%d records (%d%%)",
-				i+1, len(resultSet.Records),
-				((i+1)*100)/len(resultSet.Records))
-			s.reportSubProgress(operation, i+1)
+// streamOptions returns the environment's Compass page fetch settings, throttled by the rate limiter when set
+func (s *SnapshotService) streamOptions(environment string) compass.StreamOptions {
+	opts := compass.StreamOptions{
+		PageSize:     LoadSystemSettingInt(s.db, environment, "compass_batch_size", 50000),
+		Concurrency:  LoadSystemSettingInt(s.db, environment, "compass_page_concurrency", 2),
+		MaxRetries:   LoadSystemSettingInt(s.db, environment, "compass_page_max_retries", 3),
+		RetryBackoff: time.Duration(LoadSystemSettingInt(s.db, environment, "compass_page_retry_backoff_ms", 1000)) * time.Millisecond,
+	}
+	if s.rateLimiter != nil {
+		opts.Throttle = func(ctx context.Context) error {
+			return s.rateLimiter.Wait(ctx, environment)
 		}
 	}
+	return opts
+}
 
-	// Batch insert
-	log.Printf("Inserting %d MO records into database...", len(dbRecords))
-	s.reportSubProgress(fmt.Sprintf("Inserting %d manufacturing orders into database...", len(dbRecords)), len(dbRecords))
-
-	insertCallback := func(inserted, total int) {
-		operation := fmt.Sprintf("Inserted %d/%d manufacturing orders into database (%d%%)",
-			inserted, total, (inserted*100)/total)
-		s.reportSubProgress(operation, inserted)
+// newCustomerOrderLineRecord maps a parsed CO line to its database record - all fields stored as strings
+func newCustomerOrderLineRecord(environment string, coLine *compass.CustomerOrderLineRecord) *db.CustomerOrderLine {
+	return &db.CustomerOrderLine{
+		Environment: environment,
+		CONO: coLine.CONO,
+		DIVI: coLine.DIVI,
+		ORNO: coLine.ORNO,
+		PONR: coLine.PONR,
+		POSX: coLine.POSX,
+		ITNO: coLine.ITNO,
+		ITDS: coLine.ITDS,
+		TEDS: coLine.TEDS,
+		REPI: coLine.REPI,
+		ORST: coLine.ORST,
+		ORTY: coLine.ORTY,
+		FACI: coLine.FACI,
+		WHLO: coLine.WHLO,
+		ORQT: coLine.ORQT,
+		RNQT: coLine.RNQT,
+		ALQT: coLine.ALQT,
+		DLQT: coLine.DLQT,
+		IVQT: coLine.IVQT,
+		ORQA: coLine.ORQA,
+		RNQA: coLine.RNQA,
+		ALQA: coLine.ALQA,
+		DLQA: coLine.DLQA,
+		IVQA: coLine.IVQA,
+		ALUN: coLine.ALUN,
+		COFA: coLine.COFA,
+		SPUN: coLine.SPUN,
+		DWDT: coLine.DWDT,
+		DWHM: coLine.DWHM,
+		CODT: coLine.CODT,
+		COHM: coLine.COHM,
+		PLDT: coLine.PLDT,
+		FDED: coLine.FDED,
+		LDED: coLine.LDED,
+		SAPR: coLine.SAPR,
+		NEPR: coLine.NEPR,
+		LNAM: coLine.LNAM,
+		CUCD: coLine.CUCD,
+		DIP1: coLine.DIP1,
+		DIP2: coLine.DIP2,
+		DIP3: coLine.DIP3,
+		DIP4: coLine.DIP4,
+		DIP5: coLine.DIP5,
+		DIP6: coLine.DIP6,
+		DIA1: coLine.DIA1,
+		DIA2: coLine.DIA2,
+		DIA3: coLine.DIA3,
+		DIA4: coLine.DIA4,
+		DIA5: coLine.DIA5,
+		DIA6: coLine.DIA6,
+		RORC: coLine.RORC,
+		RORN: coLine.RORN,
+		RORL: coLine.RORL,
+		RORX: coLine.RORX,
+		CUNO: coLine.CUNO,
+		CUOR: coLine.CUOR,
+		CUPO: coLine.CUPO,
+		CUSX: coLine.CUSX,
+		CustomerName: coLine.CustomerName,
+		PRNO: coLine.PRNO,
+		HDPR: coLine.HDPR,
+		POPN: coLine.POPN,
+		ALWT: coLine.ALWT,
+		ALWQ: coLine.ALWQ,
+		ADID: coLine.ADID,
+		ROUT: coLine.ROUT,
+		RODN: coLine.RODN,
+		DSDT: coLine.DSDT,
+		DSHM: coLine.DSHM,
+		MODL: coLine.MODL,
+		TEDL: coLine.TEDL,
+		TEL2: coLine.TEL2,
+		TEPA: coLine.TEPA,
+		PACT: coLine.PACT,
+		CUPA: coLine.CUPA,
+		E0PA: coLine.E0PA,
+		DSGP: coLine.DSGP,
+		PUSN: coLine.PUSN,
+		PUTP: coLine.PUTP,
+		JDCD: coLine.JDCD,
+		DLIX: coLine.DLIX,
+		ORTP: coLine.ORTP,
+		COTypeDescription: coLine.COTypeDescription,
+		DeliveryMethod: coLine.DeliveryMethod,
+		DeliveryMethodDescription: coLine.DeliveryMethodDescription,
+		ItemType: coLine.ItemType,
+		ATV1: coLine.ATV1,
+		ATV2: coLine.ATV2,
+		ATV3: coLine.ATV3,
+		ATV4: coLine.ATV4,
+		ATV5: coLine.ATV5,
+		ATV6: coLine.ATV6,
+		ATV7: coLine.ATV7,
+		ATV8: coLine.ATV8,
+		ATV9: coLine.ATV9,
+		ATV0: coLine.ATV0,
+		UCA1: coLine.UCA1,
+		UCA2: coLine.UCA2,
+		UCA3: coLine.UCA3,
+		UCA4: coLine.UCA4,
+		UCA5: coLine.UCA5,
+		UCA6: coLine.UCA6,
+		UCA7: coLine.UCA7,
+		UCA8: coLine.UCA8,
+		UCA9: coLine.UCA9,
+		UCA0: coLine.UCA0,
+		UDN1: coLine.UDN1,
+		UDN2: coLine.UDN2,
+		UDN3: coLine.UDN3,
+		UDN4: coLine.UDN4,
+		UDN5: coLine.UDN5,
+		UDN6: coLine.UDN6,
+		UID1: coLine.UID1,
+		UID2: coLine.UID2,
+		UID3: coLine.UID3,
+		UCT1: coLine.UCT1,
+		ATNR: coLine.ATNR,
+		ATMO: coLine.ATMO,
+		ATPR: coLine.ATPR,
+		CFIN: coLine.CFIN,
+		PROJ: coLine.PROJ,
+		ELNO: coLine.ELNO,
+		RGDT: coLine.RGDT,
+		RGTM: coLine.RGTM,
+		LMDT: coLine.LMDT,
+		CHNO: coLine.CHNO,
+		CHID: coLine.CHID,
+		LMTS: coLine.LMTS,
+		M3Timestamp: coLine.Timestamp,
 	}
+}
 
-	if err := s.db.BatchInsertManufacturingOrders(ctx, dbRecords, insertCallback); err != nil {
-		return 0, fmt.Errorf("failed to insert MOs: %w", err)
+// newManufacturingOrderRecord maps a parsed MO and its MPREAL link columns to its database record
+func newManufacturingOrderRecord(environment string, mo *compass.ManufacturingOrderRecord, record map[string]interface{}) *db.ManufacturingOrder {
+	// Extract CO link fields from MPREAL join (all returned as strings)
+	linkedCONumber := getRecordString(record, "linked_co_number")
+	linkedCOLine := getRecordString(record, "linked_co_line")
+	linkedCOSuffix := getRecordString(record, "linked_co_suffix")
+	allocatedQty := getRecordString(record, "allocated_qty")
+
+	return &db.ManufacturingOrder{
+		Environment:   environment,
+		// Core Identifiers
+		CONO:          intToString(mo.CONO),
+		DIVI:          mo.DIVI,
+		FACI:          mo.FACI,
+		MFNO:          mo.MFNO,
+		PRNO:          mo.PRNO,
+		ITNO:          mo.ITNO,
+
+		// Status
+		WHST:          mo.WHST,
+		WHHS:          mo.WHHS,
+		WMST:          mo.WMST,
+		MOHS:          mo.MOHS,
+
+		// Quantities
+		ORQT:          floatToString(mo.ORQT),
+		MAQT:          floatToString(mo.MAQT),
+		ORQA:          floatToString(mo.ORQA),
+		RVQT:          floatToString(mo.RVQT),
+		RVQA:          floatToString(mo.RVQA),
+		MAQA:          floatToString(mo.MAQA),
+
+		// Dates
+		STDT:          intToString(mo.STDT),
+		FIDT:          intToString(mo.FIDT),
+		MSTI:          intToString(mo.MSTI),
+		MFTI:          intToString(mo.MFTI),
+		FSTD:          intToString(mo.FSTD),
+		FFID:          intToString(mo.FFID),
+		RSDT:          intToString(mo.RSDT),
+		REFD:          intToString(mo.REFD),
+		RPDT:          intToString(mo.RPDT),
+
+		// Planning
+		PRIO:          intToString(mo.PRIO),
+		RESP:          mo.RESP,
+		PLGR:          mo.PLGR,
+		WCLN:          mo.WCLN,
+		PRDY:          intToString(mo.PRDY),
+
+		// Warehouse/Location
+		WHLO:          mo.WHLO,
+		WHSL:          mo.WHSL,
+		BANO:          mo.BANO,
+		// Pending putaway - reported as manufactured but not yet received into stock.
+		// Derived from MWOHED rather than joining MPTAWY, which is too slow to query.
+		PendingPutawayQty: floatToString(math.Max(mo.MAQT-mo.RVQT, 0)),
+
+		// Reference Orders
+		RORC:          intToString(mo.RORC),
+		RORN:          mo.RORN,
+		RORL:          intToString(mo.RORL),
+		RORX:          intToString(mo.RORX),
+
+		// Hierarchy
+		PRHL:          mo.PRHL,
+		MFHL:          mo.MFHL,
+		PRLO:          mo.PRLO,
+		MFLO:          mo.MFLO,
+		LEVL:          intToString(mo.LEVL),
+
+		// Configuration
+		CFIN:          int64ToString(mo.CFIN),
+		ATNR:          int64ToString(mo.ATNR),
+
+		// Order Type
+		ORTY:          mo.ORTY,
+		GETP:          mo.GETP,
+
+		// Material/BOM
+		BDCD:          mo.BDCD,
+		SCEX:          mo.SCEX,
+		STRT:          mo.STRT,
+		ECVE:          mo.ECVE,
+
+		// Routing
+		AOID:          mo.AOID,
+		NUOP:          intToString(mo.NUOP),
+		NUFO:          intToString(mo.NUFO),
+
+		// Action/Text
+		ACTP:          mo.ACTP,
+		TXT1:          mo.TXT1,
+		TXT2:          mo.TXT2,
+
+		// Project
+		PROJ:          mo.PROJ,
+		ELNO:          mo.ELNO,
+
+		// M3 Audit
+		RGDT:          intToString(mo.RGDT),
+		RGTM:          intToString(mo.RGTM),
+		LMDT:          intToString(mo.LMDT),
+		LMTS:          int64ToString(mo.LMTS),
+		CHNO:          intToString(mo.CHNO),
+		CHID:          mo.CHID,
+
+		// Metadata
+		M3Timestamp:   int64ToString(mo.Timestamp),
+
+		// CO Link
+		LinkedCONumber: linkedCONumber,
+		LinkedCOLine:   linkedCOLine,
+		LinkedCOSuffix: linkedCOSuffix,
+		AllocatedQty:   allocatedQty,
+
+		// MITMAS Item Master fields
+		ItemType:             mo.ItemType,
+		ItemDescription:      mo.ItemDescription,
+		ItemGroup:            mo.ItemGroup,
+		ProductGroup:         mo.ProductGroup,
+		ProcurementGroup:     mo.ProcurementGroup,
+		GroupTechnologyClass: mo.GroupTechnologyClass,
 	}
+}
 
-	// Extract unique CO numbers from linked_co_number field
-	log.Printf("MO refresh completed - inserted %d records", len(dbRecords))
-	return len(dbRecords), nil
+// newPlannedOrderRecord maps a parsed MOP and its MPREAL link columns to its database record
+func newPlannedOrderRecord(environment string, mop *compass.PlannedOrderRecord, record map[string]interface{}) *db.PlannedManufacturingOrder {
+	// Build messages JSONB
+	messagesJSON, _ := json.Marshal(mop.Messages)
+
+	// Extract CO link fields from MPREAL join (all strings)
+	linkedCONumber := getRecordString(record, "linked_co_number")
+	linkedCOLine := getRecordString(record, "linked_co_line")
+	linkedCOSuffix := getRecordString(record, "linked_co_suffix")
+	allocatedQty := getRecordString(record, "allocated_qty")
+
+	return &db.PlannedManufacturingOrder{
+		Environment:   environment,
+		// Core Identifiers
+		CONO:          intToString(mop.CONO),
+		DIVI:          mop.DIVI,
+		FACI:          mop.FACI,
+		PLPN:          int64ToString(mop.PLPN),
+		PLPS:          intToString(mop.PLPS),
+		PRNO:          mop.PRNO,
+		ITNO:          mop.ITNO,
+
+		// Status
+		PSTS:          mop.PSTS,
+		WHST:          mop.WHST,
+		ACTP:          mop.ACTP,
+
+		// Order Type
+		ORTY:          mop.ORTY,
+		GETY:          mop.GETY,
+
+		// Quantities
+		PPQT:          floatToString(mop.PPQT),
+		ORQA:          floatToString(mop.ORQA),
+
+		// Dates
+		RELD:          intToString(mop.RELD),
+		STDT:          intToString(mop.STDT),
+		FIDT:          intToString(mop.FIDT),
+		MSTI:          intToString(mop.MSTI),
+		MFTI:          intToString(mop.MFTI),
+		PLDT:          intToString(mop.PLDT),
+
+		// Planning
+		RESP:          mop.RESP,
+		PRIP:          intToString(mop.PRIP),
+		PLGR:          mop.PLGR,
+		WCLN:          mop.WCLN,
+		PRDY:          intToString(mop.PRDY),
+
+		// Warehouse
+		WHLO:          mop.WHLO,
+
+		// Reference Orders
+		RORC:          intToString(mop.RORC),
+		RORN:          mop.RORN,
+		RORL:          intToString(mop.RORL),
+		RORX:          intToString(mop.RORX),
+		RORH:          mop.RORH,
+
+		// Hierarchy
+		PLLO:          mop.PLLO,
+		PLHL:          mop.PLHL,
+
+		// Configuration
+		ATNR:          int64ToString(mop.ATNR),
+		CFIN:          int64ToString(mop.CFIN),
+
+		// Project
+		PROJ:          mop.PROJ,
+		ELNO:          mop.ELNO,
+
+		// Messages
+		Messages:      messagesJSON,
+
+		// Planning Parameters
+		NUAU:          intToString(mop.NUAU),
+		ORDP:          mop.ORDP,
+
+		// M3 Audit
+		RGDT:          intToString(mop.RGDT),
+		RGTM:          intToString(mop.RGTM),
+		LMDT:          intToString(mop.LMDT),
+		LMTS:          int64ToString(mop.LMTS),
+		CHNO:          intToString(mop.CHNO),
+		CHID:          mop.CHID,
+
+		// Metadata
+		M3Timestamp:   int64ToString(mop.Timestamp),
+
+		// CO Link
+		LinkedCONumber: linkedCONumber,
+		LinkedCOLine:   linkedCOLine,
+		LinkedCOSuffix: linkedCOSuffix,
+		AllocatedQty:   allocatedQty,
+	}
 }
 
-// RefreshPlannedOrders refreshes MOP data from Compass with MPREAL joins
-// Filtered by environment, company and facility context
-// Returns list of unique CO numbers referenced by MOPs
-func (s *SnapshotService) RefreshPlannedOrders(ctx context.Context, environment, company string, facility string) (int, error) {
-	log.Printf("Refreshing planned manufacturing orders (with CO links via MPREAL) for environment '%s', company '%s' and facility '%s'...", environment, company, facility)
+// ========================================
+// Incremental Refresh Helpers
+// ========================================
 
-	// Use full refresh date - no incremental loading
-	fullRefreshDate := compass.GetFullRefreshDate()
-	log.Printf("Using full refresh date: %d", fullRefreshDate)
+// resolveSyncDate returns the LMDT lower bound for a refresh of the given table
+// Full refreshes use fullRefreshDate; incremental refreshes start from the last loaded LMDT
+func (s *SnapshotService) resolveSyncDate(ctx context.Context, environment, tableName, mode string, fullRefreshDate int) int {
+	if mode != RefreshModeIncremental {
+		return fullRefreshDate
+	}
 
-	// Build query with MPREAL join and context filters
-	qb := compass.NewQueryBuilder(fullRefreshDate, company, facility, "GB")
-	query := qb.BuildPlannedOrdersWithCOLinksQuery()
+	lastSyncDate, err := s.db.GetLastSyncDate(ctx, environment, tableName)
+	if err != nil {
+		log.Printf("Warning: %v - falling back to full load for %s", err, tableName)
+		return fullRefreshDate
+	}
 
-	// Execute query (DEPRECATED method - use batch refresh instead)
-	log.Println("Submitting Compass query for MOPs...")
-	s.reportSubProgress("Querying Compass SQL for planned orders...", 0)
-	pageSize := LoadSystemSettingInt(s.db, environment, "compass_batch_size", 50000)
-	results, totalRecords, err := s.compassClient.ExecuteQueryWithPagination(
-		ctx, query, pageSize,
-		func(page, totalPages, pageRecords, totalFetched, totalRecords int) {
-			operation := fmt.Sprintf("Loading page %d/%d from Compass SQL (%d records, %d total)",
-				page, totalPages, pageRecords, totalFetched)
-			s.reportSubProgress(operation, totalFetched)
-		},
-	)
+	log.Printf("Incremental refresh of %s from LMDT %d", tableName, lastSyncDate)
+	return lastSyncDate
+}
+
+// fetchRemovals streams a removals query with the environment's page fetch settings and
+// returns the records. It is not checkpointed: the job's checkpoint belongs to its main query
+func (s *SnapshotService) fetchRemovals(ctx context.Context, environment, query string) ([]map[string]interface{}, error) {
+	var records []map[string]interface{}
+	_, err := s.compassClient.StreamQueryResultsWithOptions(ctx, query, s.streamOptions(environment),
+		func(page []map[string]interface{}) error {
+			records = append(records, page...)
+			return nil
+		}, nil)
 	if err != nil {
-		return 0, fmt.Errorf("failed to execute query: %w", err)
+		return nil, fmt.Errorf("failed to load removals: %w", err)
 	}
-	log.Printf("Query returned %d total MOP records", totalRecords)
 
-	// Parse results
-	log.Println("Parsing MOP results...")
-	resultSet, err := compass.ParseResults(results)
+	return records, nil
+}
+
+// reconcileRemovedCustomerOrderLines deletes CO lines that were deleted or closed in M3 since the last sync
+func (s *SnapshotService) reconcileRemovedCustomerOrderLines(ctx context.Context, environment string, qb *compass.QueryBuilder) error {
+	s.reportSubProgress("Reconciling deleted and closed customer order lines...", 0)
+
+	records, err := s.fetchRemovals(ctx, environment, qb.BuildCustomerOrderLineRemovalsQuery())
 	if err != nil {
-		return 0, fmt.Errorf("failed to parse results: %w", err)
+		return err
 	}
 
-	log.Printf("Received %d MOP records", len(resultSet.Records))
-	s.reportSubProgress(fmt.Sprintf("Processing %d planned order records...", len(resultSet.Records)), 0)
+	keys := make([]db.CustomerOrderLineKey, 0, len(records))
+	for _, record := range records {
+		keys = append(keys, db.CustomerOrderLineKey{
+			ORNO: compass.GetStringFromAny(record, "ORNO"),
+			PONR: compass.GetStringFromAny(record, "PONR"),
+			POSX: compass.GetStringFromAny(record, "POSX"),
+		})
+	}
 
-	// Debug: Print field names from first record
-	if len(resultSet.Records) > 0 {
-		log.Println("=== MOP Record Field Names ===")
-		for key := range resultSet.Records[0] {
-			log.Printf("Field: %s", key)
-		}
-		log.Println("==============================")
+	deleted, err := s.db.DeleteCustomerOrderLines(ctx, environment, keys)
+	if err != nil {
+		return fmt.Errorf("failed to remove CO lines: %w", err)
 	}
 
-	// Transform to database records
-	const parseProgressInterval = 5000
-	dbRecords := make([]*db.PlannedManufacturingOrder, 0, len(resultSet.Records))
-	for i, record := range resultSet.Records {
-		mop, err := compass.ParsePlannedOrder(record)
-		if err != nil {
-			log.Printf("Warning: failed to parse MOP record: %v", err)
-			continue
-		}
+	log.Printf("Removed %d CO lines (%d candidates) deleted or closed in M3", deleted, len(keys))
+	return nil
+}
 
-		// Build messages JSONB
-		messagesJSON, _ := json.Marshal(mop.Messages)
-
-		// Extract CO link fields from MPREAL join (all strings)
-		linkedCONumber := getRecordString(record, "linked_co_number")
-		linkedCOLine := getRecordString(record, "linked_co_line")
-		linkedCOSuffix := getRecordString(record, "linked_co_suffix")
-		allocatedQty := getRecordString(record, "allocated_qty")
-
-		// Create database record - all M3 fields stored as strings
-		dbRecord := &db.PlannedManufacturingOrder{
-			Environment:   environment,
-			// Core Identifiers
-			CONO:          intToString(mop.CONO),
-			DIVI:          mop.DIVI,
-			FACI:          mop.FACI,
-			PLPN:          int64ToString(mop.PLPN),
-			PLPS:          intToString(mop.PLPS),
-			PRNO:          mop.PRNO,
-			ITNO:          mop.ITNO,
-
-			// Status
-			PSTS:          mop.PSTS,
-			WHST:          mop.WHST,
-			ACTP:          mop.ACTP,
-
-			// Order Type
-			ORTY:          mop.ORTY,
-			GETY:          mop.GETY,
-
-			// Quantities
-			PPQT:          floatToString(mop.PPQT),
-			ORQA:          floatToString(mop.ORQA),
-
-			// Dates
-			RELD:          intToString(mop.RELD),
-			STDT:          intToString(mop.STDT),
-			FIDT:          intToString(mop.FIDT),
-			MSTI:          intToString(mop.MSTI),
-			MFTI:          intToString(mop.MFTI),
-			PLDT:          intToString(mop.PLDT),
-
-			// Planning
-			RESP:          mop.RESP,
-			PRIP:          intToString(mop.PRIP),
-			PLGR:          mop.PLGR,
-			WCLN:          mop.WCLN,
-			PRDY:          intToString(mop.PRDY),
-
-			// Warehouse
-			WHLO:          mop.WHLO,
-
-			// Reference Orders
-			RORC:          intToString(mop.RORC),
-			RORN:          mop.RORN,
-			RORL:          intToString(mop.RORL),
-			RORX:          intToString(mop.RORX),
-			RORH:          mop.RORH,
-
-			// Hierarchy
-			PLLO:          mop.PLLO,
-			PLHL:          mop.PLHL,
-
-			// Configuration
-			ATNR:          int64ToString(mop.ATNR),
-			CFIN:          int64ToString(mop.CFIN),
-
-			// Project
-			PROJ:          mop.PROJ,
-			ELNO:          mop.ELNO,
-
-			// Messages
-			Messages:      messagesJSON,
-
-			// Planning Parameters
-			NUAU:          intToString(mop.NUAU),
-			ORDP:          mop.ORDP,
-
-			// M3 Audit
-			RGDT:          intToString(mop.RGDT),
-			RGTM:          intToString(mop.RGTM),
-			LMDT:          intToString(mop.LMDT),
-			LMTS:          int64ToString(mop.LMTS),
-			CHNO:          intToString(mop.CHNO),
-			CHID:          mop.CHID,
-
-			// Metadata
-			M3Timestamp:   int64ToString(mop.Timestamp),
-
-			// CO Link
-			LinkedCONumber: linkedCONumber,
-			LinkedCOLine:   linkedCOLine,
-			LinkedCOSuffix: linkedCOSuffix,
-			AllocatedQty:   allocatedQty,
-		}
+// reconcileRemovedManufacturingOrders deletes MOs that were deleted or progressed past WHST 20 since the last sync
+func (s *SnapshotService) reconcileRemovedManufacturingOrders(ctx context.Context, environment string, qb *compass.QueryBuilder) error {
+	s.reportSubProgress("Reconciling deleted and started manufacturing orders...", 0)
 
-		dbRecords = append(dbRecords, dbRecord)
+	records, err := s.fetchRemovals(ctx, environment, qb.BuildManufacturingOrderRemovalsQuery())
+	if err != nil {
+		return err
+	}
 
-		// Report parsing progress every N records
-		if (i+1)%parseProgressInterval == 0 || (i+1) == len(resultSet.Records) {
-			operation := fmt.Sprintf("Parsed %d/%d records (%d%%)",
-				i+1, len(resultSet.Records),
-				((i+1)*100)/len(resultSet.Records))
-			s.reportSubProgress(operation, i+1)
-		}
+	keys := make([]db.ManufacturingOrderKey, 0, len(records))
+	for _, record := range records {
+		keys = append(keys, db.ManufacturingOrderKey{
+			FACI: compass.GetString(record, "FACI"),
+			MFNO: compass.GetString(record, "MFNO"),
+		})
 	}
 
-	// Extract unique CO numbers while transforming
-	uniqueCONumbers := make(map[string]bool)
-	for _, record := range resultSet.Records {
-		if coNum, ok := record["linked_co_number"].(string); ok && coNum != "" {
-			uniqueCONumbers[coNum] = true
-		}
+	deleted, err := s.db.DeleteManufacturingOrders(ctx, environment, keys)
+	if err != nil {
+		return fmt.Errorf("failed to remove MOs: %w", err)
 	}
 
-	// Batch insert
-	log.Printf("Inserting %d MOP records into database...", len(dbRecords))
-	s.reportSubProgress(fmt.Sprintf("Inserting %d planned orders into database...", len(dbRecords)), len(dbRecords))
+	log.Printf("Removed %d MOs (%d candidates) deleted or started in M3", deleted, len(keys))
+	return nil
+}
+
+// reconcileRemovedPlannedOrders deletes MOPs that were deleted or are no longer firmed since the last sync
+func (s *SnapshotService) reconcileRemovedPlannedOrders(ctx context.Context, environment string, qb *compass.QueryBuilder) error {
+	s.reportSubProgress("Reconciling deleted and released planned orders...", 0)
+
+	records, err := s.fetchRemovals(ctx, environment, qb.BuildPlannedOrderRemovalsQuery())
+	if err != nil {
+		return err
+	}
 
-	insertCallback := func(inserted, total int) {
-		operation := fmt.Sprintf("Inserted %d/%d planned orders into database (%d%%)",
-			inserted, total, (inserted*100)/total)
-		s.reportSubProgress(operation, inserted)
+	plpns := make([]string, 0, len(records))
+	for _, record := range records {
+		plpns = append(plpns, int64ToString(compass.GetInt64(record, "PLPN")))
 	}
 
-	if err := s.db.BatchInsertPlannedOrders(ctx, dbRecords, insertCallback); err != nil {
-		return 0, fmt.Errorf("failed to insert MOPs: %w", err)
+	deleted, err := s.db.DeletePlannedOrders(ctx, environment, plpns)
+	if err != nil {
+		return fmt.Errorf("failed to remove MOPs: %w", err)
 	}
 
-	log.Printf("MOP refresh completed - inserted %d records", len(dbRecords))
-	return len(dbRecords), nil
+	log.Printf("Removed %d MOPs (%d candidates) deleted or released in M3", deleted, len(plpns))
+	return nil
 }
 
 // Helper functions to convert parser types to strings for storage
@@ -940,6 +956,25 @@ func LoadSystemSettingInt(database *db.Queries, environment, key string, default
 	return defaultValue
 }
 
+// LoadSystemSettingString loads a string setting from database with default fallback for a specific environment
+func LoadSystemSettingString(database *db.Queries, environment, key string, defaultValue string) string {
+	ctx := context.Background()
+	settings, err := database.GetSystemSettings(ctx, environment)
+	if err != nil {
+		log.Printf("Warning: Failed to load system settings for %s, using default: %s", key, defaultValue)
+		return defaultValue
+	}
+
+	for _, setting := range settings {
+		if setting.SettingKey == key && setting.SettingValue != "" {
+			return setting.SettingValue
+		}
+	}
+
+	log.Printf("Warning: Setting %s not found, using default: %s", key, defaultValue)
+	return defaultValue
+}
+
 // LoadSystemSettingFloat loads a float setting from database with default fallback for a specific environment
 func LoadSystemSettingFloat(database *db.Queries, environment, key string, defaultValue float64) float64 {
 	ctx := context.Background()
@@ -0,0 +1,248 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/pinggolf/m3-planning-tools/internal/compass"
+	"github.com/pinggolf/m3-planning-tools/internal/db"
+)
+
+// facilityLoad is one Compass query of a multi-table data job and the table it fills
+type facilityLoad struct {
+	table  string
+	label  string
+	query  string
+	insert func(ctx context.Context, records []map[string]interface{}) (int, error)
+}
+
+// RefreshMaterials loads the data the material shortage detector needs for a facility:
+// MO material lines (MWOMAT), product structures (MPDMAT), item balances (MITBAL) and
+// approved location stock (MITLOC)
+// Balances change without the order headers changing, so every refresh reloads the
+// facility's material rows in full, whatever the refresh mode
+// Returns the count of records processed across all four tables
+func (s *SnapshotService) RefreshMaterials(ctx context.Context, environment, company string, facility string, mode string) (int, error) {
+	log.Printf("Refreshing material requirements and stock for environment '%s', company '%s' and facility '%s' (%s)...", environment, company, facility, mode)
+
+	now := time.Now()
+	today := now.Year()*10000 + int(now.Month())*100 + now.Day()
+
+	qb, err := compass.NewQueryBuilder(compass.GetFullRefreshDate(), company, facility, "GB")
+	if err != nil {
+		return 0, err
+	}
+	loads := []facilityLoad{
+		{
+			table: db.MOMaterialsTable,
+			label: "MO material lines",
+			query: qb.BuildMaterialLinesQuery(),
+			insert: func(ctx context.Context, records []map[string]interface{}) (int, error) {
+				rows := make([]*db.MOMaterial, 0, len(records))
+				for _, record := range records {
+					rows = append(rows, newMOMaterialRecord(environment, record))
+				}
+				return len(rows), s.db.BatchInsertMOMaterials(ctx, rows)
+			},
+		},
+		{
+			table: db.ProductStructuresTable,
+			label: "product structures",
+			query: qb.BuildProductStructuresQuery(today),
+			insert: func(ctx context.Context, records []map[string]interface{}) (int, error) {
+				rows := make([]*db.ProductStructureMaterial, 0, len(records))
+				for _, record := range records {
+					rows = append(rows, newProductStructureRecord(environment, record))
+				}
+				return len(rows), s.db.BatchInsertProductStructures(ctx, rows)
+			},
+		},
+		{
+			table: db.ItemBalancesTable,
+			label: "item balances",
+			query: qb.BuildItemBalancesQuery(),
+			insert: func(ctx context.Context, records []map[string]interface{}) (int, error) {
+				rows := make([]*db.ItemBalance, 0, len(records))
+				for _, record := range records {
+					rows = append(rows, newItemBalanceRecord(environment, record))
+				}
+				return len(rows), s.db.BatchInsertItemBalances(ctx, rows)
+			},
+		},
+		{
+			table: db.ItemLocationsTable,
+			label: "item locations",
+			query: qb.BuildItemLocationsQuery(),
+			insert: func(ctx context.Context, records []map[string]interface{}) (int, error) {
+				rows := make([]*db.ItemLocation, 0, len(records))
+				for _, record := range records {
+					rows = append(rows, newItemLocationRecord(environment, record))
+				}
+				return len(rows), s.db.BatchInsertItemLocations(ctx, rows)
+			},
+		},
+	}
+
+	total, err := s.loadFacilityTables(ctx, environment, facility, loads)
+	if err != nil {
+		return 0, err
+	}
+
+	log.Printf("Materials refresh completed - inserted %d records", total)
+	return total, nil
+}
+
+// loadFacilityTables reloads a facility's rows in each load's table from its Compass query
+// Returns the count of records inserted across all tables
+func (s *SnapshotService) loadFacilityTables(ctx context.Context, environment, facility string, loads []facilityLoad) (int, error) {
+	total := 0
+	for _, load := range loads {
+		// Each query has its own checkpoint, so a redelivered job skips tables it already loaded
+		part := s.withCheckpointPart(load.table)
+
+		resuming, err := part.hasCheckpoint(ctx)
+		if err != nil {
+			log.Printf("Warning: %v - reloading %s", err, load.table)
+		}
+		if !resuming {
+			// Drop the facility's previous rows (seeded from live on an incremental refresh)
+			if _, err := s.db.DeleteFacilityRows(ctx, load.table, environment, facility); err != nil {
+				return 0, err
+			}
+		}
+
+		s.reportSubProgress(fmt.Sprintf("Querying Compass SQL for %s...", load.label), total)
+		inserted := 0
+		totalRecords, resumedOffset, err := part.streamQuery(ctx, environment, load.query,
+			func(records []map[string]interface{}) error {
+				count, err := load.insert(ctx, records)
+				if err != nil {
+					return fmt.Errorf("failed to insert %s: %w", load.label, err)
+				}
+				inserted += count
+				return nil
+			},
+			func(page, totalPages, pageRecords, totalFetched, totalRecords int) {
+				operation := fmt.Sprintf("Loaded %s page %d/%d from Compass SQL (%d records, %d/%d inserted)",
+					load.label, page, totalPages, pageRecords, inserted, totalRecords)
+				s.reportSubProgress(operation, total+inserted)
+			},
+		)
+		if err != nil {
+			return 0, fmt.Errorf("failed to load %s: %w", load.label, err)
+		}
+		if resumedOffset > 0 {
+			// Records before the resumed offset were loaded by an earlier attempt of this job
+			inserted += resumedOffset
+		}
+		log.Printf("Query returned %d total %s records, inserted %d", totalRecords, load.label, inserted)
+		total += inserted
+	}
+
+	return total, nil
+}
+
+// withCheckpointPart returns a copy of the service that checkpoints under "<job>-<part>",
+// for data jobs that run more than one Compass query
+func (s *SnapshotService) withCheckpointPart(part string) *SnapshotService {
+	scoped := *s
+	if s.checkpointJobID != "" {
+		scoped.checkpointJobID = s.checkpointJobID + "-" + part
+	}
+	return &scoped
+}
+
+// hasCheckpoint reports whether an earlier attempt of the checkpoint job started its query
+func (s *SnapshotService) hasCheckpoint(ctx context.Context) (bool, error) {
+	if s.checkpointJobID == "" {
+		return false, nil
+	}
+	checkpoint, err := s.db.GetCompassQueryCheckpoint(ctx, s.checkpointJobID)
+	if err != nil {
+		return false, err
+	}
+	return checkpoint != nil, nil
+}
+
+// newMOMaterialRecord maps an MWOMAT record to its database record - all fields stored as strings
+func newMOMaterialRecord(environment string, record map[string]interface{}) *db.MOMaterial {
+	return &db.MOMaterial{
+		Environment: environment,
+		CONO:        compass.GetStringFromAny(record, "CONO"),
+		FACI:        compass.GetStringFromAny(record, "FACI"),
+		MFNO:        compass.GetStringFromAny(record, "MFNO"),
+		PRNO:        compass.GetStringFromAny(record, "PRNO"),
+		MSEQ:        compass.GetStringFromAny(record, "MSEQ"),
+		MTNO:        compass.GetStringFromAny(record, "MTNO"),
+		WHLO:        compass.GetStringFromAny(record, "WHLO"),
+		OPNO:        compass.GetStringFromAny(record, "OPNO"),
+		WMST:        compass.GetStringFromAny(record, "WMST"),
+		SPMT:        compass.GetStringFromAny(record, "SPMT"),
+		PEUN:        compass.GetStringFromAny(record, "PEUN"),
+		REQT:        compass.GetStringFromAny(record, "REQT"),
+		RPQT:        compass.GetStringFromAny(record, "RPQT"),
+		ALQT:        compass.GetStringFromAny(record, "ALQT"),
+		RDAT:        compass.GetStringFromAny(record, "RDAT"),
+		LMDT:        compass.GetStringFromAny(record, "LMDT"),
+		M3Timestamp: compass.GetStringFromAny(record, "timestamp"),
+	}
+}
+
+// newProductStructureRecord maps an MPDMAT record to its database record - all fields stored as strings
+func newProductStructureRecord(environment string, record map[string]interface{}) *db.ProductStructureMaterial {
+	return &db.ProductStructureMaterial{
+		Environment: environment,
+		CONO:        compass.GetStringFromAny(record, "CONO"),
+		FACI:        compass.GetStringFromAny(record, "FACI"),
+		PRNO:        compass.GetStringFromAny(record, "PRNO"),
+		STRT:        compass.GetStringFromAny(record, "STRT"),
+		MSEQ:        compass.GetStringFromAny(record, "MSEQ"),
+		MTNO:        compass.GetStringFromAny(record, "MTNO"),
+		OPNO:        compass.GetStringFromAny(record, "OPNO"),
+		PEUN:        compass.GetStringFromAny(record, "PEUN"),
+		CNQT:        compass.GetStringFromAny(record, "CNQT"),
+		WAPC:        compass.GetStringFromAny(record, "WAPC"),
+		FDAT:        compass.GetStringFromAny(record, "FDAT"),
+		TDAT:        compass.GetStringFromAny(record, "TDAT"),
+		LMDT:        compass.GetStringFromAny(record, "LMDT"),
+		M3Timestamp: compass.GetStringFromAny(record, "timestamp"),
+	}
+}
+
+// newItemBalanceRecord maps an MITBAL record to its database record - all fields stored as strings
+func newItemBalanceRecord(environment string, record map[string]interface{}) *db.ItemBalance {
+	return &db.ItemBalance{
+		Environment: environment,
+		CONO:        compass.GetStringFromAny(record, "CONO"),
+		FACI:        compass.GetStringFromAny(record, "FACI"),
+		WHLO:        compass.GetStringFromAny(record, "WHLO"),
+		ITNO:        compass.GetStringFromAny(record, "ITNO"),
+		STQT:        compass.GetStringFromAny(record, "STQT"),
+		ALQT:        compass.GetStringFromAny(record, "ALQT"),
+		QUQT:        compass.GetStringFromAny(record, "QUQT"),
+		RJQT:        compass.GetStringFromAny(record, "RJQT"),
+		LMDT:        compass.GetStringFromAny(record, "LMDT"),
+		M3Timestamp: compass.GetStringFromAny(record, "timestamp"),
+	}
+}
+
+// newItemLocationRecord maps an MITLOC record to its database record - all fields stored as strings
+func newItemLocationRecord(environment string, record map[string]interface{}) *db.ItemLocation {
+	return &db.ItemLocation{
+		Environment: environment,
+		CONO:        compass.GetStringFromAny(record, "CONO"),
+		FACI:        compass.GetStringFromAny(record, "FACI"),
+		WHLO:        compass.GetStringFromAny(record, "WHLO"),
+		ITNO:        compass.GetStringFromAny(record, "ITNO"),
+		WHSL:        compass.GetStringFromAny(record, "WHSL"),
+		BANO:        compass.GetStringFromAny(record, "BANO"),
+		CAMU:        compass.GetStringFromAny(record, "CAMU"),
+		STAS:        compass.GetStringFromAny(record, "STAS"),
+		STQT:        compass.GetStringFromAny(record, "STQT"),
+		ALQT:        compass.GetStringFromAny(record, "ALQT"),
+		LMDT:        compass.GetStringFromAny(record, "LMDT"),
+		M3Timestamp: compass.GetStringFromAny(record, "timestamp"),
+	}
+}
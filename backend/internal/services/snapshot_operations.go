@@ -0,0 +1,133 @@
+package services
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/pinggolf/m3-planning-tools/internal/compass"
+	"github.com/pinggolf/m3-planning-tools/internal/db"
+)
+
+// RefreshOperations loads the data the work center overload detector needs for a facility:
+// MO operations (MWOOPE), routing operations (MPDOPE) and work centers (MPDWCT)
+// Reported operations change without the order headers changing, so every refresh reloads
+// the facility's operation rows in full, whatever the refresh mode
+// Returns the count of records processed across all three tables
+func (s *SnapshotService) RefreshOperations(ctx context.Context, environment, company string, facility string, mode string) (int, error) {
+	log.Printf("Refreshing operations and work centers for environment '%s', company '%s' and facility '%s' (%s)...", environment, company, facility, mode)
+
+	now := time.Now()
+	today := now.Year()*10000 + int(now.Month())*100 + now.Day()
+
+	qb, err := compass.NewQueryBuilder(compass.GetFullRefreshDate(), company, facility, "GB")
+	if err != nil {
+		return 0, err
+	}
+	loads := []facilityLoad{
+		{
+			table: db.MOOperationsTable,
+			label: "MO operations",
+			query: qb.BuildOperationsQuery(),
+			insert: func(ctx context.Context, records []map[string]interface{}) (int, error) {
+				rows := make([]*db.MOOperation, 0, len(records))
+				for _, record := range records {
+					rows = append(rows, newMOOperationRecord(environment, record))
+				}
+				return len(rows), s.db.BatchInsertMOOperations(ctx, rows)
+			},
+		},
+		{
+			table: db.ProductOperationsTable,
+			label: "routing operations",
+			query: qb.BuildProductOperationsQuery(today),
+			insert: func(ctx context.Context, records []map[string]interface{}) (int, error) {
+				rows := make([]*db.ProductOperation, 0, len(records))
+				for _, record := range records {
+					rows = append(rows, newProductOperationRecord(environment, record))
+				}
+				return len(rows), s.db.BatchInsertProductOperations(ctx, rows)
+			},
+		},
+		{
+			table: db.WorkCentersTable,
+			label: "work centers",
+			query: qb.BuildWorkCentersQuery(),
+			insert: func(ctx context.Context, records []map[string]interface{}) (int, error) {
+				rows := make([]*db.WorkCenter, 0, len(records))
+				for _, record := range records {
+					rows = append(rows, newWorkCenterRecord(environment, record))
+				}
+				return len(rows), s.db.BatchInsertWorkCenters(ctx, rows)
+			},
+		},
+	}
+
+	total, err := s.loadFacilityTables(ctx, environment, facility, loads)
+	if err != nil {
+		return 0, err
+	}
+
+	log.Printf("Operations refresh completed - inserted %d records", total)
+	return total, nil
+}
+
+// newMOOperationRecord maps an MWOOPE record to its database record - all fields stored as strings
+func newMOOperationRecord(environment string, record map[string]interface{}) *db.MOOperation {
+	return &db.MOOperation{
+		Environment: environment,
+		CONO:        compass.GetStringFromAny(record, "CONO"),
+		FACI:        compass.GetStringFromAny(record, "FACI"),
+		MFNO:        compass.GetStringFromAny(record, "MFNO"),
+		PRNO:        compass.GetStringFromAny(record, "PRNO"),
+		OPNO:        compass.GetStringFromAny(record, "OPNO"),
+		PLGR:        compass.GetStringFromAny(record, "PLGR"),
+		OPDS:        compass.GetStringFromAny(record, "OPDS"),
+		WOST:        compass.GetStringFromAny(record, "WOST"),
+		SETI:        compass.GetStringFromAny(record, "SETI"),
+		PITI:        compass.GetStringFromAny(record, "PITI"),
+		PUSN:        compass.GetStringFromAny(record, "PUSN"),
+		ORQT:        compass.GetStringFromAny(record, "ORQT"),
+		MAQT:        compass.GetStringFromAny(record, "MAQT"),
+		STDT:        compass.GetStringFromAny(record, "STDT"),
+		FIDT:        compass.GetStringFromAny(record, "FIDT"),
+		LMDT:        compass.GetStringFromAny(record, "LMDT"),
+		M3Timestamp: compass.GetStringFromAny(record, "timestamp"),
+	}
+}
+
+// newProductOperationRecord maps an MPDOPE record to its database record - all fields stored as strings
+func newProductOperationRecord(environment string, record map[string]interface{}) *db.ProductOperation {
+	return &db.ProductOperation{
+		Environment: environment,
+		CONO:        compass.GetStringFromAny(record, "CONO"),
+		FACI:        compass.GetStringFromAny(record, "FACI"),
+		PRNO:        compass.GetStringFromAny(record, "PRNO"),
+		STRT:        compass.GetStringFromAny(record, "STRT"),
+		OPNO:        compass.GetStringFromAny(record, "OPNO"),
+		PLGR:        compass.GetStringFromAny(record, "PLGR"),
+		OPDS:        compass.GetStringFromAny(record, "OPDS"),
+		SETI:        compass.GetStringFromAny(record, "SETI"),
+		PITI:        compass.GetStringFromAny(record, "PITI"),
+		PUSN:        compass.GetStringFromAny(record, "PUSN"),
+		FDAT:        compass.GetStringFromAny(record, "FDAT"),
+		TDAT:        compass.GetStringFromAny(record, "TDAT"),
+		LMDT:        compass.GetStringFromAny(record, "LMDT"),
+		M3Timestamp: compass.GetStringFromAny(record, "timestamp"),
+	}
+}
+
+// newWorkCenterRecord maps an MPDWCT record to its database record - all fields stored as strings
+func newWorkCenterRecord(environment string, record map[string]interface{}) *db.WorkCenter {
+	return &db.WorkCenter{
+		Environment: environment,
+		CONO:        compass.GetStringFromAny(record, "CONO"),
+		FACI:        compass.GetStringFromAny(record, "FACI"),
+		PLGR:        compass.GetStringFromAny(record, "PLGR"),
+		PLNM:        compass.GetStringFromAny(record, "PLNM"),
+		WCTY:        compass.GetStringFromAny(record, "WCTY"),
+		NOMA:        compass.GetStringFromAny(record, "NOMA"),
+		LMDT:        compass.GetStringFromAny(record, "LMDT"),
+		M3Timestamp: compass.GetStringFromAny(record, "timestamp"),
+	}
+}
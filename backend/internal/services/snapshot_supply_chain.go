@@ -0,0 +1,71 @@
+package services
+
+import (
+	"context"
+	"log"
+
+	"github.com/pinggolf/m3-planning-tools/internal/compass"
+	"github.com/pinggolf/m3-planning-tools/internal/db"
+)
+
+// RefreshSupplyChainLinks loads the pre-allocation links (MPREAL) of a facility's warehouses,
+// which the supply chain API walks to build multi-level pegging trees
+// A relinked chain does not bump the order headers, so every refresh reloads the facility's
+// links in full, whatever the refresh mode
+// Returns the count of records processed
+func (s *SnapshotService) RefreshSupplyChainLinks(ctx context.Context, environment, company string, facility string, mode string) (int, error) {
+	log.Printf("Refreshing supply chain links for environment '%s', company '%s' and facility '%s' (%s)...", environment, company, facility, mode)
+
+	qb, err := compass.NewQueryBuilder(compass.GetFullRefreshDate(), company, facility, "GB")
+	if err != nil {
+		return 0, err
+	}
+	loads := []facilityLoad{
+		{
+			table: db.SupplyChainLinksTable,
+			label: "supply chain links",
+			query: qb.BuildMPREALQuery(),
+			insert: func(ctx context.Context, records []map[string]interface{}) (int, error) {
+				rows := make([]*db.SupplyChainLink, 0, len(records))
+				for _, record := range records {
+					rows = append(rows, newSupplyChainLinkRecord(environment, record))
+				}
+				return len(rows), s.db.BatchInsertSupplyChainLinks(ctx, rows)
+			},
+		},
+	}
+
+	total, err := s.loadFacilityTables(ctx, environment, facility, loads)
+	if err != nil {
+		return 0, err
+	}
+
+	log.Printf("Supply chain refresh completed - inserted %d records", total)
+	return total, nil
+}
+
+// newSupplyChainLinkRecord maps an MPREAL record to its database record - all fields stored as strings
+func newSupplyChainLinkRecord(environment string, record map[string]interface{}) *db.SupplyChainLink {
+	return &db.SupplyChainLink{
+		Environment: environment,
+		CONO:        compass.GetStringFromAny(record, "CONO"),
+		FACI:        compass.GetStringFromAny(record, "FACI"),
+		WHLO:        compass.GetStringFromAny(record, "WHLO"),
+		ITNO:        compass.GetStringFromAny(record, "ITNO"),
+		AOCA:        compass.GetStringFromAny(record, "AOCA"),
+		ARDN:        compass.GetStringFromAny(record, "ARDN"),
+		ARDL:        compass.GetStringFromAny(record, "ARDL"),
+		ARDX:        compass.GetStringFromAny(record, "ARDX"),
+		DOCA:        compass.GetStringFromAny(record, "DOCA"),
+		DRDN:        compass.GetStringFromAny(record, "DRDN"),
+		DRDL:        compass.GetStringFromAny(record, "DRDL"),
+		DRDX:        compass.GetStringFromAny(record, "DRDX"),
+		PQTY:        compass.GetStringFromAny(record, "PQTY"),
+		PQTR:        compass.GetStringFromAny(record, "PQTR"),
+		SCNB:        compass.GetStringFromAny(record, "SCNB"),
+		RESP:        compass.GetStringFromAny(record, "RESP"),
+		PATY:        compass.GetStringFromAny(record, "PATY"),
+		LMDT:        compass.GetStringFromAny(record, "LMDT"),
+		M3Timestamp: compass.GetStringFromAny(record, "timestamp"),
+	}
+}
@@ -0,0 +1,327 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/pinggolf/m3-planning-tools/internal/db"
+)
+
+// SupplyChainService builds multi-level pegging trees from the MPREAL links of a supply chain
+type SupplyChainService struct {
+	queries *db.Queries
+}
+
+// NewSupplyChainService creates a new supply chain service
+func NewSupplyChainService(queries *db.Queries) *SupplyChainService {
+	return &SupplyChainService{queries: queries}
+}
+
+// Supply chain node types
+const (
+	SupplyChainNodeCO    = "CO"
+	SupplyChainNodeDO    = "DO"
+	SupplyChainNodePO    = "PO"
+	SupplyChainNodeMO    = "MO"
+	SupplyChainNodeMOP   = "MOP"
+	SupplyChainNodeOther = "OTHER"
+)
+
+// supplyChainNodeTypes maps MPREAL order categories (AOCA/DOCA) to node types
+// Data Fabric returns the three-digit M3 categories used by the order queries (101 MO, 311 CO
+// line, 500/501 DO, 510/511 DO demand); the single-digit categories of older extracts are kept too
+var supplyChainNodeTypes = map[string]string{
+	"100": SupplyChainNodeMOP,
+	"101": SupplyChainNodeMO,
+	"250": SupplyChainNodePO,
+	"251": SupplyChainNodePO,
+	"311": SupplyChainNodeCO,
+	"500": SupplyChainNodeDO,
+	"501": SupplyChainNodeDO,
+	"510": SupplyChainNodeDO,
+	"511": SupplyChainNodeDO,
+	"1":   SupplyChainNodePO,
+	"2":   SupplyChainNodeMO,
+	"3":   SupplyChainNodeCO,
+	"4":   SupplyChainNodeDO,
+	"5":   SupplyChainNodeMOP,
+}
+
+// SupplyChainOrderCategories returns the MPREAL order categories an order of a node type can appear under
+func SupplyChainOrderCategories(nodeType string) []string {
+	var categories []string
+	for category, t := range supplyChainNodeTypes {
+		if t == nodeType {
+			categories = append(categories, category)
+		}
+	}
+	return categories
+}
+
+// SupplyChainNode is one order of a pegging tree; its children are the orders supplying it
+// Dates are YYYYMMDD strings. Orders outside the snapshot (DOs, POs, other facilities) only
+// carry what the MPREAL link itself holds.
+type SupplyChainNode struct {
+	NodeType          string             `json:"nodeType"`      // CO, DO, PO, MO, MOP or OTHER
+	OrderCategory     string             `json:"orderCategory"` // MPREAL AOCA/DOCA
+	OrderNumber       string             `json:"orderNumber"`
+	OrderLine         string             `json:"orderLine,omitempty"`
+	OrderSuffix       string             `json:"orderSuffix,omitempty"`
+	ItemNumber        string             `json:"itemNumber,omitempty"`
+	Facility          string             `json:"facility,omitempty"`
+	Warehouse         string             `json:"warehouse,omitempty"`
+	Status            string             `json:"status,omitempty"`
+	Quantity          string             `json:"quantity,omitempty"`       // Ordered quantity, or remaining quantity of a CO line
+	PeggedQuantity    string             `json:"peggedQuantity,omitempty"` // MPREAL PQTY reserved for the parent
+	StartDate         string             `json:"startDate,omitempty"`      // Planned start (MO/MOP)
+	FinishDate        string             `json:"finishDate,omitempty"`     // Planned finish (MO/MOP)
+	DeliveryDate      string             `json:"deliveryDate,omitempty"`   // Confirmed, else requested delivery date (CO line)
+	SlackDays         *int               `json:"slackDays,omitempty"`      // Days between this order's finish and its parent's need date; negative = late
+	CustomerNumber    string             `json:"customerNumber,omitempty"`
+	CustomerName      string             `json:"customerName,omitempty"`
+	ProductionOrderID int64              `json:"productionOrderId,omitempty"` // production_orders id of an MO/MOP
+	InSnapshot        bool               `json:"inSnapshot"`
+	Children          []*SupplyChainNode `json:"children"`
+}
+
+// SupplyChainTree is the pegging tree of one supply chain (SCNB), rooted at its top-level demand
+type SupplyChainTree struct {
+	SupplyChainNumber string             `json:"supplyChainNumber"`
+	LinkCount         int                `json:"linkCount"`
+	Roots             []*SupplyChainNode `json:"roots"`
+}
+
+// supplyChainEdge pegs a supply order to a demand order
+type supplyChainEdge struct {
+	supplyKey string
+	quantity  string
+}
+
+// GetTree builds the pegging tree of a supply chain, or returns nil if it has no links
+func (s *SupplyChainService) GetTree(ctx context.Context, environment, scnb string) (*SupplyChainTree, error) {
+	links, err := s.queries.GetSupplyChainLinks(ctx, environment, scnb)
+	if err != nil {
+		return nil, err
+	}
+	if len(links) == 0 {
+		return nil, nil
+	}
+
+	// Every link is an edge from its demand order down to its supply order
+	nodes := make(map[string]*SupplyChainNode)
+	var order []string
+	addNode := func(category, number, line, suffix string, link *db.SupplyChainLink, supply bool) string {
+		node := newSupplyChainNode(category, number, line, suffix, link, supply)
+		key := supplyChainNodeKey(node)
+		if existing, ok := nodes[key]; !ok {
+			nodes[key] = node
+			order = append(order, key)
+		} else if existing.ItemNumber == "" {
+			existing.ItemNumber = node.ItemNumber
+			existing.Warehouse = node.Warehouse
+		}
+		return key
+	}
+
+	edges := make(map[string][]supplyChainEdge)
+	isSupply := make(map[string]bool)
+	for _, link := range links {
+		demandKey := addNode(link.DOCA, link.DRDN, link.DRDL, link.DRDX, link, false)
+		supplyKey := addNode(link.AOCA, link.ARDN, link.ARDL, link.ARDX, link, true)
+		edges[demandKey] = append(edges[demandKey], supplyChainEdge{supplyKey: supplyKey, quantity: link.PQTY})
+		isSupply[supplyKey] = true
+	}
+
+	if err := s.enrichNodes(ctx, environment, nodes); err != nil {
+		return nil, err
+	}
+
+	// Roots are the demands nothing else depends on - normally the CO line
+	var rootKeys []string
+	for _, key := range order {
+		if !isSupply[key] {
+			rootKeys = append(rootKeys, key)
+		}
+	}
+	if len(rootKeys) == 0 {
+		// A chain that only loops back on itself still gets a starting point
+		rootKeys = append(rootKeys, order[0])
+	}
+
+	tree := &SupplyChainTree{SupplyChainNumber: scnb, LinkCount: len(links)}
+	for _, key := range rootKeys {
+		tree.Roots = append(tree.Roots, buildSupplyChainNode(key, "", nodes, edges, map[string]bool{}))
+	}
+
+	return tree, nil
+}
+
+// GetTreesForOrder builds the pegging trees of every supply chain an order takes part in
+// line is only used for CO, DO and PO lines and may be empty to match any line
+func (s *SupplyChainService) GetTreesForOrder(ctx context.Context, environment, nodeType, number, line string) ([]*SupplyChainTree, error) {
+	categories := SupplyChainOrderCategories(nodeType)
+	if len(categories) == 0 {
+		return nil, fmt.Errorf("unknown order type: %s", nodeType)
+	}
+
+	numbers, err := s.queries.FindSupplyChainNumbers(ctx, environment, categories, number, line)
+	if err != nil {
+		return nil, err
+	}
+
+	trees := make([]*SupplyChainTree, 0, len(numbers))
+	for _, scnb := range numbers {
+		tree, err := s.GetTree(ctx, environment, scnb)
+		if err != nil {
+			return nil, err
+		}
+		if tree != nil {
+			trees = append(trees, tree)
+		}
+	}
+
+	return trees, nil
+}
+
+// newSupplyChainNode creates the node of one side of a link, with the link's item and warehouse
+// MOs and MOPs are one node per order: as a demand their line is the component sequence and
+// the link's item is the component, not the order's item
+func newSupplyChainNode(category, number, line, suffix string, link *db.SupplyChainLink, supply bool) *SupplyChainNode {
+	nodeType, ok := supplyChainNodeTypes[category]
+	if !ok {
+		nodeType = SupplyChainNodeOther
+	}
+
+	node := &SupplyChainNode{
+		NodeType:      nodeType,
+		OrderCategory: category,
+		OrderNumber:   number,
+		Facility:      link.FACI,
+		Children:      []*SupplyChainNode{},
+	}
+	manufacturing := nodeType == SupplyChainNodeMO || nodeType == SupplyChainNodeMOP
+	if !manufacturing {
+		node.OrderLine = line
+		node.OrderSuffix = suffix
+	}
+	if supply || !manufacturing {
+		node.ItemNumber = link.ITNO
+		node.Warehouse = link.WHLO
+	}
+	return node
+}
+
+func supplyChainNodeKey(node *SupplyChainNode) string {
+	return strings.Join([]string{node.NodeType, node.OrderNumber, node.OrderLine, node.OrderSuffix}, "|")
+}
+
+// buildSupplyChainNode copies a node and its supplies into a tree
+// A node pegged to several demands appears under each of them; path guards against cycles
+func buildSupplyChainNode(key, peggedQuantity string, nodes map[string]*SupplyChainNode, edges map[string][]supplyChainEdge, path map[string]bool) *SupplyChainNode {
+	node := *nodes[key]
+	node.PeggedQuantity = peggedQuantity
+	node.Children = []*SupplyChainNode{}
+	if path[key] {
+		return &node
+	}
+
+	path[key] = true
+	needDate := supplyChainNeedDate(&node)
+	for _, edge := range edges[key] {
+		child := buildSupplyChainNode(edge.supplyKey, edge.quantity, nodes, edges, path)
+		if finish, ok := parseYYYYMMDD(child.FinishDate); ok && !needDate.IsZero() {
+			slack := int(needDate.Sub(finish).Hours() / 24)
+			child.SlackDays = &slack
+		}
+		node.Children = append(node.Children, child)
+	}
+	delete(path, key)
+
+	return &node
+}
+
+// supplyChainNeedDate is the date a node needs its supplies by: the delivery date of a CO
+// line or the planned start of an MO/MOP
+func supplyChainNeedDate(node *SupplyChainNode) time.Time {
+	date := node.StartDate
+	if node.NodeType == SupplyChainNodeCO {
+		date = node.DeliveryDate
+	}
+	t, _ := parseYYYYMMDD(date)
+	return t
+}
+
+func parseYYYYMMDD(value string) (time.Time, bool) {
+	t, err := time.Parse("20060102", value)
+	if err != nil {
+		return time.Time{}, false
+	}
+	return t, true
+}
+
+// enrichNodes fills in the MO/MOP and CO line nodes from the snapshot
+func (s *SupplyChainService) enrichNodes(ctx context.Context, environment string, nodes map[string]*SupplyChainNode) error {
+	var moNumbers, mopNumbers, coNumbers []string
+	for _, node := range nodes {
+		switch node.NodeType {
+		case SupplyChainNodeMO:
+			moNumbers = append(moNumbers, node.OrderNumber)
+		case SupplyChainNodeMOP:
+			mopNumbers = append(mopNumbers, node.OrderNumber)
+		case SupplyChainNodeCO:
+			coNumbers = append(coNumbers, node.OrderNumber)
+		}
+	}
+
+	for orderType, numbers := range map[string][]string{SupplyChainNodeMO: moNumbers, SupplyChainNodeMOP: mopNumbers} {
+		orders, err := s.queries.GetProductionOrdersByNumbers(ctx, environment, orderType, numbers)
+		if err != nil {
+			return err
+		}
+		for _, order := range orders {
+			node, ok := nodes[strings.Join([]string{orderType, order.OrderNumber, "", ""}, "|")]
+			if !ok {
+				continue
+			}
+			node.InSnapshot = true
+			node.ProductionOrderID = order.ID
+			node.ItemNumber = order.ItemNumber
+			node.Facility = order.Facility
+			node.Warehouse = order.Warehouse
+			node.Status = order.Status
+			if orderType == SupplyChainNodeMOP {
+				node.Status = order.ProposalStatus
+			}
+			node.Quantity = order.OrderedQuantity
+			node.StartDate = order.PlannedStartDate
+			node.FinishDate = order.PlannedFinishDate
+		}
+	}
+
+	lines, err := s.queries.GetSupplyChainCOLines(ctx, environment, coNumbers)
+	if err != nil {
+		return err
+	}
+	for _, line := range lines {
+		node, ok := nodes[strings.Join([]string{SupplyChainNodeCO, line.OrderNumber, line.LineNumber, line.LineSuffix}, "|")]
+		if !ok {
+			continue
+		}
+		node.InSnapshot = true
+		node.ItemNumber = line.ItemNumber
+		node.Facility = line.Facility
+		node.Warehouse = line.Warehouse
+		node.Status = line.Status
+		node.Quantity = line.RemainingQuantity
+		node.DeliveryDate = line.ConfirmedDate
+		if _, ok := parseYYYYMMDD(node.DeliveryDate); !ok {
+			node.DeliveryDate = line.RequestedDate
+		}
+		node.CustomerNumber = line.CustomerNumber
+		node.CustomerName = line.CustomerName
+	}
+
+	return nil
+}
@@ -0,0 +1,159 @@
+package simulator
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/mux"
+)
+
+// CompassPath is the Data Fabric Compass API prefix; use <simulator URL>+CompassPath as the Compass base URL
+const CompassPath = "/DATAFABRIC/compass/v2/"
+
+// queryJob is a submitted Compass query. Queries run synchronously on submit,
+// so a job is always finished or failed by the time its status is polled.
+type queryJob struct {
+	id           string
+	query        string
+	records      []map[string]interface{}
+	errorMessage string
+}
+
+func (s *Simulator) registerCompassRoutes(r *mux.Router) {
+	compass := r.PathPrefix(CompassPath).Subrouter()
+	compass.HandleFunc("/jobs/", s.handleSubmitQuery).Methods("POST")
+	compass.HandleFunc("/jobs/{id}/status/", s.handleQueryStatus).Methods("GET")
+	compass.HandleFunc("/jobs/{id}/result/", s.handleQueryResult).Methods("GET")
+	compass.HandleFunc("/jobs/{id}/", s.handleCancelQuery).Methods("DELETE")
+}
+
+// RunQuery interprets a Compass SQL query against the fixture tables
+func (s *Simulator) RunQuery(query string) ([]map[string]interface{}, error) {
+	parsed, err := parseQuery(query)
+	if err != nil {
+		return nil, fmt.Errorf("unsupported query: %w", err)
+	}
+	return executeQuery(parsed, s.store.Table)
+}
+
+// handleSubmitQuery accepts a text/plain SQL body and creates a job
+func (s *Simulator) handleSubmitQuery(w http.ResponseWriter, r *http.Request) {
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		http.Error(w, "Failed to read query", http.StatusBadRequest)
+		return
+	}
+
+	job := &queryJob{query: string(body)}
+	records, err := s.RunQuery(job.query)
+	if err != nil {
+		log.Printf("Simulator: query failed: %v", err)
+		job.errorMessage = err.Error()
+	} else {
+		// records=0 means all records
+		if maxRecords, _ := strconv.Atoi(r.URL.Query().Get("records")); maxRecords > 0 && len(records) > maxRecords {
+			records = records[:maxRecords]
+		}
+		job.records = records
+	}
+
+	s.mu.Lock()
+	s.nextJobID++
+	job.id = fmt.Sprintf("sim-%06d", s.nextJobID)
+	s.jobs[job.id] = job
+	s.mu.Unlock()
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusAccepted)
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"queryId": job.id,
+		"status":  "RUNNING",
+	})
+}
+
+func (s *Simulator) getJob(w http.ResponseWriter, r *http.Request) (*queryJob, bool) {
+	id := mux.Vars(r)["id"]
+
+	s.mu.Lock()
+	job, ok := s.jobs[id]
+	s.mu.Unlock()
+
+	if !ok {
+		http.Error(w, fmt.Sprintf("Query %s not found", id), http.StatusNotFound)
+		return nil, false
+	}
+	return job, true
+}
+
+// handleQueryStatus reports FINISHED with the row count, or FAILED with the parse/execution error
+func (s *Simulator) handleQueryStatus(w http.ResponseWriter, r *http.Request) {
+	job, ok := s.getJob(w, r)
+	if !ok {
+		return
+	}
+
+	response := map[string]interface{}{
+		"queryId":  job.id,
+		"status":   "FINISHED",
+		"rowCount": len(job.records),
+	}
+	if job.errorMessage != "" {
+		response["status"] = "FAILED"
+		response["rowCount"] = 0
+		response["errorMessage"] = job.errorMessage
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
+}
+
+// handleQueryResult returns a page of rows as a raw JSON array, like Data Fabric
+func (s *Simulator) handleQueryResult(w http.ResponseWriter, r *http.Request) {
+	job, ok := s.getJob(w, r)
+	if !ok {
+		return
+	}
+
+	if job.errorMessage != "" {
+		http.Error(w, job.errorMessage, http.StatusBadRequest)
+		return
+	}
+
+	offset, _ := strconv.Atoi(r.URL.Query().Get("offset"))
+	limit, err := strconv.Atoi(r.URL.Query().Get("limit"))
+	if err != nil || limit <= 0 {
+		limit = len(job.records)
+	}
+	if offset < 0 {
+		offset = 0
+	}
+
+	page := make([]map[string]interface{}, 0)
+	if offset < len(job.records) {
+		end := offset + limit
+		if end > len(job.records) {
+			end = len(job.records)
+		}
+		page = job.records[offset:end]
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(page)
+}
+
+// handleCancelQuery drops a job
+func (s *Simulator) handleCancelQuery(w http.ResponseWriter, r *http.Request) {
+	if _, ok := s.getJob(w, r); !ok {
+		return
+	}
+
+	s.mu.Lock()
+	delete(s.jobs, mux.Vars(r)["id"])
+	s.mu.Unlock()
+
+	w.WriteHeader(http.StatusOK)
+}
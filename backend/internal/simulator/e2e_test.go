@@ -0,0 +1,195 @@
+package simulator_test
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"net/http/httptest"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/pinggolf/m3-planning-tools/internal/compass"
+	"github.com/pinggolf/m3-planning-tools/internal/db"
+	"github.com/pinggolf/m3-planning-tools/internal/m3api"
+	"github.com/pinggolf/m3-planning-tools/internal/services"
+	"github.com/pinggolf/m3-planning-tools/internal/simulator"
+)
+
+const environment = "TRN"
+
+// TestRefreshDetectAndAct runs a full refresh and detection against the fixtures, executes
+// issue actions for two detected orders and checks the M3 writes and the next refresh.
+// It needs a scratch PostgreSQL database whose TRN snapshot it replaces:
+//
+//	TEST_DATABASE_URL=postgres://localhost/m3_planning_test?sslmode=disable go test ./internal/simulator/
+func TestRefreshDetectAndAct(t *testing.T) {
+	databaseURL := os.Getenv("TEST_DATABASE_URL")
+	if databaseURL == "" {
+		t.Skip("TEST_DATABASE_URL is not set")
+	}
+
+	ctx := context.Background()
+	database, err := sql.Open("postgres", databaseURL)
+	if err != nil {
+		t.Fatalf("failed to open database: %v", err)
+	}
+	t.Cleanup(func() { database.Close() })
+	if err := db.RunMigrations(database, "../../migrations"); err != nil {
+		t.Fatalf("failed to run migrations: %v", err)
+	}
+	queries := db.New(database)
+
+	sim, srv := startSimulator(t)
+
+	jobID := refreshAndDetect(t, ctx, queries, srv)
+
+	issues := unlinkedIssues(t, ctx, queries, jobID)
+	moIssue, mopIssue := issues["MO:7000002"], issues["MOP:5000004"]
+	if moIssue == nil || mopIssue == nil {
+		t.Fatalf("expected unlinked issues for MO 7000002 and MOP 5000004, got %v", issueKeys(issues))
+	}
+
+	// Issue actions, with the parameters the delete MO and delete MOP handlers send
+	client := m3api.NewClient(srv.URL+"/", testToken)
+	moCompany := issueCompany(t, moIssue)
+	if _, err := client.Execute(ctx, "PMS100MI", "DltMO", map[string]string{
+		"MFNO": moIssue.ProductionOrderNumber.String,
+		"CONO": moCompany,
+	}); err != nil {
+		t.Fatalf("DltMO failed: %v", err)
+	}
+	mopCompany := issueCompany(t, mopIssue)
+	if _, err := client.Execute(ctx, "PMS170MI", "DelPlannedMO", map[string]string{
+		"PLPN": mopIssue.ProductionOrderNumber.String,
+		"CONO": mopCompany,
+	}); err != nil {
+		t.Fatalf("DelPlannedMO failed: %v", err)
+	}
+
+	assertWrites(t, sim.Writes(), []simulator.Write{
+		{Seq: 1, Program: "PMS100MI", Transaction: "DltMO", Params: map[string]string{"MFNO": "7000002", "CONO": company}},
+		{Seq: 2, Program: "PMS170MI", Transaction: "DelPlannedMO", Params: map[string]string{"PLPN": "5000004", "CONO": company}},
+	})
+
+	// The next refresh no longer loads the deleted orders, so their issues are gone
+	nextJobID := refreshAndDetect(t, ctx, queries, srv)
+	issues = unlinkedIssues(t, ctx, queries, nextJobID)
+	if issues["MO:7000002"] != nil || issues["MOP:5000004"] != nil {
+		t.Errorf("deleted orders should not be detected again, got %v", issueKeys(issues))
+	}
+}
+
+// refreshAndDetect loads the fixtures the way the snapshot worker does (staging, data jobs,
+// finalize, promote), runs all detectors and completes the job. Returns the refresh job ID.
+func refreshAndDetect(t *testing.T, ctx context.Context, queries *db.Queries, srv *httptest.Server) string {
+	t.Helper()
+
+	jobID := fmt.Sprintf("simulator-%d", time.Now().UnixNano())
+	if err := queries.CreateRefreshJob(ctx, jobID, environment, "simulator", "snapshot_refresh"); err != nil {
+		t.Fatalf("failed to create refresh job: %v", err)
+	}
+	if err := queries.StartJob(ctx, jobID); err != nil {
+		t.Fatalf("failed to start refresh job: %v", err)
+	}
+	if err := queries.PrepareSnapshotStaging(ctx, environment, false); err != nil {
+		t.Fatalf("failed to prepare staging: %v", err)
+	}
+
+	staging := queries.Staging()
+	snapshotService := services.NewSnapshotService(compass.NewClient(srv.URL+simulator.CompassPath, testToken), staging)
+	mode := services.RefreshModeFull
+
+	dataJobs := []struct {
+		name string
+		run  func() (int, error)
+	}{
+		{"planned orders", func() (int, error) {
+			return snapshotService.RefreshPlannedOrders(ctx, environment, company, facility, mode)
+		}},
+		{"manufacturing orders", func() (int, error) {
+			return snapshotService.RefreshManufacturingOrders(ctx, environment, company, facility, mode)
+		}},
+		{"customer order lines", func() (int, error) {
+			return snapshotService.RefreshOpenCustomerOrderLines(ctx, environment, company, facility, language, mode)
+		}},
+		{"materials", func() (int, error) {
+			return snapshotService.RefreshMaterials(ctx, environment, company, facility, mode)
+		}},
+		{"operations", func() (int, error) {
+			return snapshotService.RefreshOperations(ctx, environment, company, facility, mode)
+		}},
+		{"supply chain links", func() (int, error) {
+			return snapshotService.RefreshSupplyChainLinks(ctx, environment, company, facility, mode)
+		}},
+	}
+	for _, job := range dataJobs {
+		count, err := job.run()
+		if err != nil {
+			t.Fatalf("%s refresh failed: %v", job.name, err)
+		}
+		if count == 0 {
+			t.Errorf("%s refresh loaded no records", job.name)
+		}
+	}
+
+	if err := staging.UpdateProductionOrdersFromMOPs(ctx); err != nil {
+		t.Fatalf("failed to finalize MOPs: %v", err)
+	}
+	if err := staging.UpdateProductionOrdersFromMOs(ctx); err != nil {
+		t.Fatalf("failed to finalize MOs: %v", err)
+	}
+	if err := queries.PromoteSnapshotStaging(ctx, environment); err != nil {
+		t.Fatalf("failed to promote staging: %v", err)
+	}
+
+	detectionService := services.NewDetectionService(queries, services.NewDetectorConfigService(queries))
+	if err := detectionService.RunAllDetectors(ctx, jobID, environment, company, []string{facility}); err != nil {
+		t.Fatalf("detection failed: %v", err)
+	}
+	// Issue queries read the latest completed refresh
+	if err := queries.CompleteJob(ctx, jobID); err != nil {
+		t.Fatalf("failed to complete refresh job: %v", err)
+	}
+
+	return jobID
+}
+
+// unlinkedIssues returns a job's unlinked production order issues keyed by "<type>:<order number>"
+func unlinkedIssues(t *testing.T, ctx context.Context, queries *db.Queries, jobID string) map[string]*db.DetectedIssue {
+	t.Helper()
+
+	issues, err := queries.GetIssuesByDetectorType(ctx, environment, "unlinked_production_orders", 1000)
+	if err != nil {
+		t.Fatalf("failed to load issues: %v", err)
+	}
+
+	byOrder := make(map[string]*db.DetectedIssue)
+	for _, issue := range issues {
+		if issue.JobID == jobID {
+			byOrder[issue.ProductionOrderType.String+":"+issue.ProductionOrderNumber.String] = issue
+		}
+	}
+	return byOrder
+}
+
+func issueKeys(issues map[string]*db.DetectedIssue) []string {
+	keys := make([]string, 0, len(issues))
+	for key := range issues {
+		keys = append(keys, key)
+	}
+	return keys
+}
+
+// issueCompany reads the company the detector stored in the issue data
+func issueCompany(t *testing.T, issue *db.DetectedIssue) string {
+	t.Helper()
+
+	var issueData map[string]interface{}
+	if err := json.Unmarshal([]byte(issue.IssueData), &issueData); err != nil {
+		t.Fatalf("failed to parse issue data: %v", err)
+	}
+	company, _ := issueData["company"].(string)
+	return company
+}
@@ -0,0 +1,185 @@
+package simulator
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Fixture layout, relative to the fixtures directory:
+//
+//	tables/<TABLE>.json                  Data Lake rows served by the Compass API, e.g. tables/MWOHED.json
+//	mi/<PROGRAM>.<TRANSACTION>.json      Records returned by read-only MI transactions, e.g. mi/CRS008MI.ListFacility.json
+//	users/me.json                        Infor user profile returned by ifsservice/usermgt/v2/users/me
+//
+// Table files are JSON arrays of objects keyed by Data Lake column name. Values keep
+// their JSON type, so numeric M3 fields (PLPN, STDT, LMDT, ...) can be numbers as they
+// are in Data Fabric. Rows without "deleted" default to 'false' and rows without
+// "timestamp" get the load time. Tables without a file are empty.
+
+// Store holds the simulator's fixture data. Tables are mutated by recorded M3 writes.
+type Store struct {
+	mu          sync.RWMutex
+	dir         string
+	tables      map[string][]map[string]interface{}
+	miResponses map[string][]map[string]interface{} // PROGRAM.TRANSACTION -> records
+	userProfile map[string]interface{}
+}
+
+// LoadStore reads all fixtures from a directory
+func LoadStore(dir string) (*Store, error) {
+	s := &Store{dir: dir}
+	if err := s.Reload(); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// Reload re-reads the fixtures from disk, discarding any changes made by writes
+func (s *Store) Reload() error {
+	tables, err := loadRecordFiles(filepath.Join(s.dir, "tables"))
+	if err != nil {
+		return err
+	}
+
+	loadedAt := time.Now().UTC().Format(time.RFC3339)
+	for name, records := range tables {
+		for _, record := range records {
+			if _, ok := record["deleted"]; !ok {
+				record["deleted"] = "false"
+			}
+			if _, ok := record["timestamp"]; !ok {
+				record["timestamp"] = loadedAt
+			}
+		}
+		tables[name] = records
+	}
+
+	miResponses, err := loadRecordFiles(filepath.Join(s.dir, "mi"))
+	if err != nil {
+		return err
+	}
+
+	var userProfile map[string]interface{}
+	if data, err := os.ReadFile(filepath.Join(s.dir, "users", "me.json")); err == nil {
+		if err := json.Unmarshal(data, &userProfile); err != nil {
+			return fmt.Errorf("failed to parse users/me.json: %w", err)
+		}
+	} else if !os.IsNotExist(err) {
+		return fmt.Errorf("failed to read users/me.json: %w", err)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.tables = tables
+	s.miResponses = miResponses
+	s.userProfile = userProfile
+	return nil
+}
+
+// loadRecordFiles reads every *.json file in a directory as an array of records,
+// keyed by the upper-cased file name without extension. A missing directory is empty.
+func loadRecordFiles(dir string) (map[string][]map[string]interface{}, error) {
+	result := make(map[string][]map[string]interface{})
+
+	entries, err := os.ReadDir(dir)
+	if os.IsNotExist(err) {
+		return result, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read fixture directory %s: %w", dir, err)
+	}
+
+	for _, entry := range entries {
+		if entry.IsDir() || filepath.Ext(entry.Name()) != ".json" {
+			continue
+		}
+		path := filepath.Join(dir, entry.Name())
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read fixture %s: %w", path, err)
+		}
+		var records []map[string]interface{}
+		if err := json.Unmarshal(data, &records); err != nil {
+			return nil, fmt.Errorf("failed to parse fixture %s: %w", path, err)
+		}
+		name := strings.ToUpper(strings.TrimSuffix(entry.Name(), ".json"))
+		result[name] = records
+	}
+
+	return result, nil
+}
+
+// Table returns a snapshot of a table's rows; the row maps are copies
+func (s *Store) Table(name string) []map[string]interface{} {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	rows := s.tables[strings.ToUpper(name)]
+	out := make([]map[string]interface{}, len(rows))
+	for i, row := range rows {
+		out[i] = copyRecord(row)
+	}
+	return out
+}
+
+// SetTable replaces a table's rows, e.g. to seed an in-process test
+func (s *Store) SetTable(name string, rows []map[string]interface{}) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.tables[strings.ToUpper(name)] = rows
+}
+
+// SetMIResponse replaces the records returned by a read-only MI transaction
+func (s *Store) SetMIResponse(program, transaction string, records []map[string]interface{}) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.miResponses[miKey(program, transaction)] = records
+}
+
+// miResponse returns a transaction's fixture records, and whether a fixture exists
+func (s *Store) miResponse(program, transaction string) ([]map[string]interface{}, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	records, ok := s.miResponses[miKey(program, transaction)]
+	if !ok {
+		return nil, false
+	}
+	out := make([]map[string]interface{}, len(records))
+	for i, record := range records {
+		out[i] = copyRecord(record)
+	}
+	return out, true
+}
+
+// updateRow applies fn to the first row of a table matching the predicate.
+// Returns false if no row matched.
+func (s *Store) updateRow(table string, match func(row map[string]interface{}) bool, fn func(row map[string]interface{})) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, row := range s.tables[strings.ToUpper(table)] {
+		if match(row) {
+			fn(row)
+			return true
+		}
+	}
+	return false
+}
+
+func miKey(program, transaction string) string {
+	return strings.ToUpper(program + "." + transaction)
+}
+
+func copyRecord(record map[string]interface{}) map[string]interface{} {
+	out := make(map[string]interface{}, len(record))
+	for k, v := range record {
+		out[k] = v
+	}
+	return out
+}
@@ -0,0 +1,340 @@
+package simulator
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gorilla/mux"
+)
+
+// Write is an M3 write transaction received by the simulator
+type Write struct {
+	Seq          int               `json:"seq"`
+	ReceivedAt   time.Time         `json:"receivedAt"`
+	Program      string            `json:"program"`
+	Transaction  string            `json:"transaction"`
+	Params       map[string]string `json:"params"`
+	Bulk         bool              `json:"bulk"`
+	ErrorMessage string            `json:"errorMessage,omitempty"`
+}
+
+// writeHandler applies a write transaction to the fixture tables.
+// Returns an M3-style error message, or "" on success.
+type writeHandler func(s *Simulator, params map[string]string) string
+
+// writeHandlers are the MI write transactions the app issues, keyed by PROGRAM.TRANSACTION
+var writeHandlers = map[string]writeHandler{
+	"PMS100MI.RESCHEDULE":   (*Simulator).rescheduleMO,
+	"PMS100MI.DLTMO":        (*Simulator).deleteMO,
+	"PMS100MI.CLOSEMO":      (*Simulator).closeMO,
+	"PMS170MI.DELPLANNEDMO": (*Simulator).deletePlannedMO,
+	"PMS170MI.UPDAT":        (*Simulator).updatePlannedMO,
+}
+
+// executeDefaults are query parameters m3api.Client adds to every GET that are not MI fields
+var executeDefaults = map[string]bool{
+	"dateformat": true, "excludeempty": true, "righttrim": true, "metadata": true,
+	"returnsystemfields": true, "maxrecs": true,
+}
+
+func (s *Simulator) registerM3Routes(r *mux.Router) {
+	r.HandleFunc("/M3/m3api-rest/v2/execute/{program}/{transaction}", s.handleExecute).Methods("GET")
+	r.HandleFunc("/M3/m3api-rest/v2/execute", s.handleExecuteBulk).Methods("POST")
+}
+
+// Execute runs one MI transaction. Writes are recorded and applied to the fixture
+// tables; reads return the matching mi/ fixture records.
+func (s *Simulator) Execute(program, transaction string, params map[string]string, bulk bool) ([]map[string]interface{}, string) {
+	key := miKey(program, transaction)
+
+	if handler, ok := writeHandlers[key]; ok {
+		errorMessage := handler(s, params)
+
+		s.mu.Lock()
+		s.writes = append(s.writes, Write{
+			Seq:          len(s.writes) + 1,
+			ReceivedAt:   s.now(),
+			Program:      strings.ToUpper(program),
+			Transaction:  transaction,
+			Params:       params,
+			Bulk:         bulk,
+			ErrorMessage: errorMessage,
+		})
+		s.mu.Unlock()
+
+		return []map[string]interface{}{}, errorMessage
+	}
+
+	records, ok := s.store.miResponse(program, transaction)
+	if !ok {
+		return nil, fmt.Sprintf("Transaction %s/%s is not available in the simulator", program, transaction)
+	}
+
+	// Filter on the request fields that exist in the fixture records (e.g. CONO)
+	filtered := make([]map[string]interface{}, 0, len(records))
+	for _, record := range records {
+		matches := true
+		for field, value := range params {
+			if v, exists := record[field]; exists && value != "" && strings.TrimSpace(toString(v)) != strings.TrimSpace(value) {
+				matches = false
+				break
+			}
+		}
+		if matches {
+			filtered = append(filtered, record)
+		}
+	}
+	return filtered, ""
+}
+
+// handleExecute serves GET execute/{program}/{transaction}. Like M3, transaction
+// errors are reported in the result body with HTTP 200.
+func (s *Simulator) handleExecute(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+
+	params := make(map[string]string)
+	for key, values := range r.URL.Query() {
+		if executeDefaults[strings.ToLower(key)] || len(values) == 0 {
+			continue
+		}
+		params[key] = values[0]
+	}
+
+	records, errorMessage := s.Execute(vars["program"], vars["transaction"], params, false)
+
+	result := map[string]interface{}{
+		"transaction": vars["transaction"],
+		"records":     records,
+	}
+	failed := 0
+	if errorMessage != "" {
+		result["errorMessage"] = errorMessage
+		result["errorType"] = "ServerReturnedNOK"
+		failed = 1
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"results":                     []interface{}{result},
+		"nrOfSuccessfullTransactions": 1 - failed,
+		"nrOfFailedTransactions":      failed,
+		"wasTerminated":               false,
+	})
+}
+
+// handleExecuteBulk serves the POST bulk endpoint used by m3api.Client.ExecuteProgramBulk
+func (s *Simulator) handleExecuteBulk(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		Program            string `json:"program"`
+		MaxReturnedRecords int    `json:"maxReturnedRecords"`
+		Transactions       []struct {
+			Transaction string            `json:"transaction"`
+			Record      map[string]string `json:"record"`
+		} `json:"transactions"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid bulk request body", http.StatusBadRequest)
+		return
+	}
+	if req.Program == "" {
+		http.Error(w, "program is required", http.StatusBadRequest)
+		return
+	}
+
+	results := make([]map[string]interface{}, 0, len(req.Transactions))
+	succeeded, failed := 0, 0
+	for _, txn := range req.Transactions {
+		params := txn.Record
+		if params == nil {
+			params = map[string]string{}
+		}
+
+		records, errorMessage := s.Execute(req.Program, txn.Transaction, params, true)
+		if req.MaxReturnedRecords > 0 && len(records) > req.MaxReturnedRecords {
+			records = records[:req.MaxReturnedRecords]
+		}
+
+		result := map[string]interface{}{
+			"transaction": txn.Transaction,
+			"parameters":  params,
+		}
+		if errorMessage != "" {
+			result["errorMessage"] = errorMessage
+			result["errorType"] = "ServerReturnedNOK"
+			failed++
+		} else {
+			result["records"] = records
+			succeeded++
+		}
+		results = append(results, result)
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"results":                      results,
+		"wasTerminated":                false,
+		"nrOfSuccessfullTransactions":  succeeded,
+		"nrOfFailedTransactions":       failed,
+		"nrOfNotProcessedTransactions": 0,
+	})
+}
+
+// matchManufacturingOrder matches an MWOHED row by FACI/MFNO
+func matchManufacturingOrder(params map[string]string) func(row map[string]interface{}) bool {
+	return func(row map[string]interface{}) bool {
+		return toString(row["deleted"]) != "true" &&
+			fieldEquals(row, "MFNO", params["MFNO"]) &&
+			(params["FACI"] == "" || fieldEquals(row, "FACI", params["FACI"]))
+	}
+}
+
+// matchPlannedOrder matches an MMOPLP row by PLPN (and CONO when given)
+func matchPlannedOrder(params map[string]string) func(row map[string]interface{}) bool {
+	return func(row map[string]interface{}) bool {
+		return toString(row["deleted"]) != "true" &&
+			fieldEquals(row, "PLPN", params["PLPN"]) &&
+			(params["CONO"] == "" || fieldEquals(row, "CONO", params["CONO"]))
+	}
+}
+
+// fieldEquals compares a row field to a request value, numerically when both are numbers
+func fieldEquals(row map[string]interface{}, field, value string) bool {
+	cmp, ok := compareValues(row[field], strings.TrimSpace(value))
+	if !ok {
+		return false
+	}
+	if cmp == 0 {
+		return true
+	}
+	// String columns may hold numbers with different padding ("0001" vs "1")
+	a, aok := toNumber(row[field])
+	b, bok := toNumber(value)
+	return aok && bok && a == b
+}
+
+func (s *Simulator) rescheduleMO(params map[string]string) string {
+	if params["MFNO"] == "" {
+		return "Manufacturing order number must be entered"
+	}
+	found := s.store.updateRow("MWOHED", matchManufacturingOrder(params), func(row map[string]interface{}) {
+		shiftDates(row, params["STDT"], params["FIDT"])
+		s.touch(row)
+	})
+	if !found {
+		return fmt.Sprintf("Manufacturing order %s does not exist", params["MFNO"])
+	}
+	return ""
+}
+
+func (s *Simulator) deleteMO(params map[string]string) string {
+	if params["MFNO"] == "" {
+		return "Manufacturing order number must be entered"
+	}
+	found := s.store.updateRow("MWOHED", matchManufacturingOrder(params), func(row map[string]interface{}) {
+		row["deleted"] = "true"
+		s.touch(row)
+	})
+	if !found {
+		return fmt.Sprintf("Manufacturing order %s does not exist", params["MFNO"])
+	}
+	return ""
+}
+
+func (s *Simulator) closeMO(params map[string]string) string {
+	if params["MFNO"] == "" {
+		return "Manufacturing order number must be entered"
+	}
+	found := s.store.updateRow("MWOHED", matchManufacturingOrder(params), func(row map[string]interface{}) {
+		setLike(row, "WHST", "90")
+		s.touch(row)
+	})
+	if !found {
+		return fmt.Sprintf("Manufacturing order %s does not exist", params["MFNO"])
+	}
+	return ""
+}
+
+func (s *Simulator) deletePlannedMO(params map[string]string) string {
+	if params["PLPN"] == "" {
+		return "Planned order number must be entered"
+	}
+	found := s.store.updateRow("MMOPLP", matchPlannedOrder(params), func(row map[string]interface{}) {
+		row["deleted"] = "true"
+		s.touch(row)
+	})
+	if !found {
+		return fmt.Sprintf("Planned order %s does not exist", params["PLPN"])
+	}
+	return ""
+}
+
+// plannedOrderKeyFields identify the MOP or are processing flags; they are never written to the row
+var plannedOrderKeyFields = map[string]bool{
+	"CONO": true, "FACI": true, "PLPN": true, "PLPS": true, "IGWA": true, "STDT": true, "FIDT": true,
+}
+
+func (s *Simulator) updatePlannedMO(params map[string]string) string {
+	if params["PLPN"] == "" {
+		return "Planned order number must be entered"
+	}
+	found := s.store.updateRow("MMOPLP", matchPlannedOrder(params), func(row map[string]interface{}) {
+		shiftDates(row, params["STDT"], params["FIDT"])
+		for field, value := range params {
+			if _, exists := row[field]; exists && !plannedOrderKeyFields[field] && value != "" {
+				setLike(row, field, value)
+			}
+		}
+		s.touch(row)
+	})
+	if !found {
+		return fmt.Sprintf("Planned order %s does not exist", params["PLPN"])
+	}
+	return ""
+}
+
+// touch stamps a changed row so the next incremental refresh (LMDT >= last sync) sees it
+func (s *Simulator) touch(row map[string]interface{}) {
+	now := s.now()
+	setLike(row, "LMDT", now.Format("20060102"))
+	row["timestamp"] = now.UTC().Format(time.RFC3339)
+}
+
+// shiftDates sets a new start and/or finish date. When only one is given the other
+// moves by the same number of days, keeping the order's lead time.
+func shiftDates(row map[string]interface{}, newStart, newFinish string) {
+	const layout = "20060102"
+
+	oldStart, startErr := time.Parse(layout, toString(row["STDT"]))
+	oldFinish, finishErr := time.Parse(layout, toString(row["FIDT"]))
+
+	switch {
+	case newStart != "" && newFinish != "":
+		setLike(row, "STDT", newStart)
+		setLike(row, "FIDT", newFinish)
+	case newStart != "":
+		if start, err := time.Parse(layout, newStart); err == nil && startErr == nil && finishErr == nil {
+			setLike(row, "FIDT", oldFinish.Add(start.Sub(oldStart)).Format(layout))
+		}
+		setLike(row, "STDT", newStart)
+	case newFinish != "":
+		if finish, err := time.Parse(layout, newFinish); err == nil && startErr == nil && finishErr == nil {
+			setLike(row, "STDT", oldStart.Add(finish.Sub(oldFinish)).Format(layout))
+		}
+		setLike(row, "FIDT", newFinish)
+	}
+}
+
+// setLike stores a value using the JSON type the field already has, so numeric
+// Data Lake columns stay numbers
+func setLike(row map[string]interface{}, field, value string) {
+	if _, isNumber := row[field].(float64); isNumber {
+		if f, ok := toNumber(value); ok {
+			row[field] = f
+			return
+		}
+	}
+	row[field] = value
+}
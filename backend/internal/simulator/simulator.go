@@ -0,0 +1,176 @@
+// Package simulator is an offline stand-in for the Infor endpoints the app talks to:
+// the Data Fabric Compass query API, the M3 MI REST API (single and bulk execute),
+// the ION OAuth endpoints and the user management profile endpoint.
+//
+// Compass queries are interpreted against JSON fixture tables (see fixtures.go).
+// PMS100MI/PMS170MI writes are recorded and applied to those tables, so a refresh,
+// detection run and issue action can be exercised end to end without live M3.
+//
+// Run it in-process with httptest.NewServer(sim.Handler()), or standalone with
+// `server simulate`, and point the environment's base URLs at it:
+//
+//	TRN_API_BASE_URL=http://localhost:8090/
+//	TRN_COMPASS_BASE_URL=http://localhost:8090/DATAFABRIC/compass/v2/
+//	TRN_AUTH_ENDPOINT=http://localhost:8090/as/authorization.oauth2
+//	TRN_TOKEN_ENDPOINT=http://localhost:8090/as/token.oauth2
+package simulator
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"sync"
+	"time"
+
+	"github.com/gorilla/mux"
+)
+
+// Simulator serves the fake Compass and M3 APIs from a fixture Store
+type Simulator struct {
+	store *Store
+
+	mu        sync.Mutex
+	jobs      map[string]*queryJob
+	nextJobID int
+	writes    []Write
+
+	// Now is the clock used to stamp LMDT on written rows; defaults to time.Now
+	Now func() time.Time
+}
+
+// New creates a simulator serving the fixtures in dir
+func New(dir string) (*Simulator, error) {
+	store, err := LoadStore(dir)
+	if err != nil {
+		return nil, err
+	}
+	return NewWithStore(store), nil
+}
+
+// NewWithStore creates a simulator over an existing store
+func NewWithStore(store *Store) *Simulator {
+	return &Simulator{
+		store: store,
+		jobs:  make(map[string]*queryJob),
+	}
+}
+
+// Store returns the simulator's fixture store
+func (s *Simulator) Store() *Store {
+	return s.store
+}
+
+func (s *Simulator) now() time.Time {
+	if s.Now != nil {
+		return s.Now()
+	}
+	return time.Now()
+}
+
+// Writes returns the M3 write transactions received so far, in order
+func (s *Simulator) Writes() []Write {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	out := make([]Write, len(s.writes))
+	copy(out, s.writes)
+	return out
+}
+
+// Reset reloads the fixtures and clears recorded writes and query jobs
+func (s *Simulator) Reset() error {
+	if err := s.store.Reload(); err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.writes = nil
+	s.jobs = make(map[string]*queryJob)
+	return nil
+}
+
+// Handler returns the HTTP handler serving all simulated endpoints
+func (s *Simulator) Handler() http.Handler {
+	r := mux.NewRouter()
+
+	s.registerCompassRoutes(r)
+	s.registerM3Routes(r)
+
+	// ION OAuth and user management
+	r.HandleFunc("/as/authorization.oauth2", s.handleAuthorize).Methods("GET")
+	r.HandleFunc("/as/token.oauth2", s.handleToken).Methods("POST")
+	r.HandleFunc("/ifsservice/usermgt/v2/users/me", s.handleUserProfile).Methods("GET")
+
+	// Simulator control
+	r.HandleFunc("/simulator/writes", s.handleListWrites).Methods("GET")
+	r.HandleFunc("/simulator/reset", s.handleReset).Methods("POST")
+
+	return r
+}
+
+// handleAuthorize skips the login page and redirects straight back with a code
+func (s *Simulator) handleAuthorize(w http.ResponseWriter, r *http.Request) {
+	redirectURI, err := url.Parse(r.URL.Query().Get("redirect_uri"))
+	if err != nil || redirectURI.String() == "" {
+		http.Error(w, "redirect_uri is required", http.StatusBadRequest)
+		return
+	}
+
+	q := redirectURI.Query()
+	q.Set("code", "simulator")
+	q.Set("state", r.URL.Query().Get("state"))
+	redirectURI.RawQuery = q.Encode()
+
+	http.Redirect(w, r, redirectURI.String(), http.StatusFound)
+}
+
+// handleToken issues a token for any grant (authorization code, refresh, client credentials)
+func (s *Simulator) handleToken(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"access_token":  "simulator-access-token",
+		"refresh_token": "simulator-refresh-token",
+		"token_type":    "Bearer",
+		"expires_in":    3600,
+	})
+}
+
+// handleUserProfile returns users/me.json, or a default simulator user
+func (s *Simulator) handleUserProfile(w http.ResponseWriter, r *http.Request) {
+	s.store.mu.RLock()
+	profile := s.store.userProfile
+	s.store.mu.RUnlock()
+
+	if profile == nil {
+		profile = map[string]interface{}{
+			"id":          "simulator",
+			"userName":    "SIMULATOR",
+			"displayName": "Simulator User",
+			"name":        map[string]string{"givenName": "Simulator", "familyName": "User"},
+			"emails":      []map[string]interface{}{{"value": "simulator@localhost", "type": "work", "primary": true}},
+		}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"response": map[string]interface{}{
+			"userlist": []interface{}{profile},
+		},
+	})
+}
+
+// handleListWrites returns the recorded M3 writes
+func (s *Simulator) handleListWrites(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(s.Writes())
+}
+
+// handleReset reloads the fixtures and clears recorded writes
+func (s *Simulator) handleReset(w http.ResponseWriter, r *http.Request) {
+	if err := s.Reset(); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
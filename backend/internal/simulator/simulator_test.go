@@ -0,0 +1,201 @@
+package simulator_test
+
+import (
+	"context"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/pinggolf/m3-planning-tools/internal/compass"
+	"github.com/pinggolf/m3-planning-tools/internal/m3api"
+	"github.com/pinggolf/m3-planning-tools/internal/simulator"
+)
+
+const (
+	fixturesDir = "../../testdata/simulator"
+	company     = "100"
+	facility    = "A01"
+	language    = "GB"
+)
+
+// startSimulator serves the fixture simulator in-process for the duration of the test
+func startSimulator(t *testing.T) (*simulator.Simulator, *httptest.Server) {
+	t.Helper()
+
+	sim, err := simulator.New(fixturesDir)
+	if err != nil {
+		t.Fatalf("failed to load fixtures: %v", err)
+	}
+	sim.Now = func() time.Time { return time.Date(2026, 10, 16, 9, 0, 0, 0, time.UTC) }
+
+	srv := httptest.NewServer(sim.Handler())
+	t.Cleanup(srv.Close)
+	return sim, srv
+}
+
+func testToken() (string, error) {
+	return "simulator", nil
+}
+
+// findRow returns the first row whose field equals value, or nil
+func findRow(rows []map[string]interface{}, field string, value interface{}) map[string]interface{} {
+	for _, row := range rows {
+		if row[field] == value {
+			return row
+		}
+	}
+	return nil
+}
+
+// TestQueryBuilderQueries runs every query the refresh jobs build against the fixtures
+func TestQueryBuilderQueries(t *testing.T) {
+	sim, _ := startSimulator(t)
+
+	qb, err := compass.NewQueryBuilder(0, company, facility, language)
+	if err != nil {
+		t.Fatalf("failed to create query builder: %v", err)
+	}
+	byNumbers, err := qb.BuildCustomerOrderLinesByOrderNumbersQuery([]string{"1000001"})
+	if err != nil {
+		t.Fatalf("failed to build order number query: %v", err)
+	}
+
+	tests := []struct {
+		name     string
+		query    string
+		wantRows int
+	}{
+		{"customer order lines", qb.BuildCustomerOrderLinesQuery(), 3},
+		{"open customer order lines", qb.BuildOpenCustomerOrderLinesQuery(), 3},
+		{"customer order lines by number", byNumbers, 2},
+		{"customer orders", qb.BuildCustomerOrdersQuery(), 2},
+		{"manufacturing orders", qb.BuildManufacturingOrdersQuery(), 3},
+		{"planned orders", qb.BuildPlannedOrdersQuery(), 4},
+		{"planned orders with CO links", qb.BuildPlannedOrdersWithCOLinksQuery(), 3},
+		{"pre-allocations", qb.BuildMPREALQuery(), 6},
+		{"material lines", qb.BuildMaterialLinesQuery(), 4},
+		{"product structures", qb.BuildProductStructuresQuery(20261016), 5},
+		{"item balances", qb.BuildItemBalancesQuery(), 3},
+		{"item locations", qb.BuildItemLocationsQuery(), 4},
+		{"operations", qb.BuildOperationsQuery(), 4},
+		{"product operations", qb.BuildProductOperationsQuery(20261016), 4},
+		{"work centers", qb.BuildWorkCentersQuery(), 3},
+		{"manufacturing order removals", qb.BuildManufacturingOrderRemovalsQuery(), 1},
+		{"planned order removals", qb.BuildPlannedOrderRemovalsQuery(), 1},
+		{"customer order line removals", qb.BuildCustomerOrderLineRemovalsQuery(), 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rows, err := sim.RunQuery(tt.query)
+			if err != nil {
+				t.Fatalf("query failed: %v\n%s", err, tt.query)
+			}
+			if len(rows) != tt.wantRows {
+				t.Errorf("got %d rows, want %d", len(rows), tt.wantRows)
+			}
+		})
+	}
+}
+
+// TestM3Writes sends issue action transactions through m3api.Client and checks that they are
+// recorded and applied to the fixture tables
+func TestM3Writes(t *testing.T) {
+	sim, srv := startSimulator(t)
+	ctx := context.Background()
+	client := m3api.NewClient(srv.URL+"/", testToken)
+
+	if _, err := client.Execute(ctx, "PMS170MI", "DelPlannedMO", map[string]string{"PLPN": "5000004", "CONO": company}); err != nil {
+		t.Fatalf("DelPlannedMO failed: %v", err)
+	}
+	if _, err := client.Execute(ctx, "PMS100MI", "Reschedule", map[string]string{"FACI": facility, "MFNO": "7000001", "STDT": "20261201"}); err != nil {
+		t.Fatalf("Reschedule failed: %v", err)
+	}
+	if _, err := client.Execute(ctx, "PMS100MI", "DltMO", map[string]string{"MFNO": "7999999", "CONO": company}); err != nil {
+		t.Fatalf("DltMO failed: %v", err)
+	}
+	_, err := client.ExecuteProgramBulk(ctx, "PMS100MI", []m3api.BulkRequestItem{
+		{Transaction: "CloseMO", Record: map[string]string{"FACI": facility, "MFNO": "7000003"}},
+	})
+	if err != nil {
+		t.Fatalf("bulk CloseMO failed: %v", err)
+	}
+
+	want := []simulator.Write{
+		{Seq: 1, Program: "PMS170MI", Transaction: "DelPlannedMO", Params: map[string]string{"PLPN": "5000004", "CONO": company}},
+		{Seq: 2, Program: "PMS100MI", Transaction: "Reschedule", Params: map[string]string{"FACI": facility, "MFNO": "7000001", "STDT": "20261201"}},
+		{Seq: 3, Program: "PMS100MI", Transaction: "DltMO", Params: map[string]string{"MFNO": "7999999", "CONO": company},
+			ErrorMessage: "Manufacturing order 7999999 does not exist"},
+		{Seq: 4, Program: "PMS100MI", Transaction: "CloseMO", Params: map[string]string{"FACI": facility, "MFNO": "7000003"}, Bulk: true},
+	}
+	assertWrites(t, sim.Writes(), want)
+
+	mop := findRow(sim.Store().Table("MMOPLP"), "PLPN", 5000004.0)
+	if mop == nil || mop["deleted"] != "true" {
+		t.Errorf("MOP 5000004 should be marked deleted, got %v", mop)
+	}
+
+	// Reschedule keeps the lead time (8 days) and stamps LMDT with the simulator clock
+	mo := findRow(sim.Store().Table("MWOHED"), "MFNO", "7000001")
+	if mo == nil || mo["STDT"] != 20261201.0 || mo["FIDT"] != 20261209.0 || mo["LMDT"] != 20261016.0 {
+		t.Errorf("MO 7000001 should be rescheduled to 20261201-20261209 with LMDT 20261016, got %v", mo)
+	}
+
+	closed := findRow(sim.Store().Table("MWOHED"), "MFNO", "7000003")
+	if closed == nil || closed["WHST"] != "90" {
+		t.Errorf("MO 7000003 should be closed, got %v", closed)
+	}
+
+	// An incremental refresh since the previous sync picks up the rescheduled MO as a change
+	// and the closed MO as a removal
+	qb, err := compass.NewQueryBuilder(20261010, company, facility, language)
+	if err != nil {
+		t.Fatalf("failed to create query builder: %v", err)
+	}
+	rows, err := sim.RunQuery(qb.BuildManufacturingOrdersQuery())
+	if err != nil {
+		t.Fatalf("incremental MO query failed: %v", err)
+	}
+	if len(rows) != 1 || findRow(rows, "MFNO", "7000001") == nil {
+		t.Errorf("incremental MO query should return only MO 7000001, got %v", rows)
+	}
+	removals, err := sim.RunQuery(qb.BuildManufacturingOrderRemovalsQuery())
+	if err != nil {
+		t.Fatalf("incremental MO removals query failed: %v", err)
+	}
+	if len(removals) != 1 || findRow(removals, "MFNO", "7000003") == nil {
+		t.Errorf("incremental MO removals query should return only MO 7000003, got %v", removals)
+	}
+
+	if err := sim.Reset(); err != nil {
+		t.Fatalf("reset failed: %v", err)
+	}
+	if writes := sim.Writes(); len(writes) != 0 {
+		t.Errorf("reset should clear writes, got %d", len(writes))
+	}
+	if mop := findRow(sim.Store().Table("MMOPLP"), "PLPN", 5000004.0); mop == nil || mop["deleted"] != "false" {
+		t.Errorf("reset should restore MOP 5000004, got %v", mop)
+	}
+}
+
+// assertWrites compares recorded writes, ignoring the receive time
+func assertWrites(t *testing.T, got, want []simulator.Write) {
+	t.Helper()
+
+	if len(got) != len(want) {
+		t.Fatalf("got %d writes, want %d: %+v", len(got), len(want), got)
+	}
+	for i := range want {
+		g, w := got[i], want[i]
+		if g.Seq != w.Seq || g.Program != w.Program || g.Transaction != w.Transaction ||
+			g.Bulk != w.Bulk || g.ErrorMessage != w.ErrorMessage || len(g.Params) != len(w.Params) {
+			t.Errorf("write %d: got %+v, want %+v", i+1, g, w)
+			continue
+		}
+		for field, value := range w.Params {
+			if g.Params[field] != value {
+				t.Errorf("write %d: %s = %q, want %q", i+1, field, g.Params[field], value)
+			}
+		}
+	}
+}
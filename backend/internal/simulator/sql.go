@@ -0,0 +1,871 @@
+package simulator
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// This file interprets the SQL subset emitted by compass.QueryBuilder and the ad-hoc
+// lookup/debug queries: SELECT with aliases, COALESCE and CAST, FROM with LEFT/INNER
+// JOINs, WHERE with AND/OR/NOT, comparisons, IN and IS NULL, ORDER BY and LIMIT.
+// Anything else is rejected with an error so unsupported queries fail loudly.
+
+type tokenKind int
+
+const (
+	tokIdent tokenKind = iota
+	tokString
+	tokNumber
+	tokSymbol
+	tokEOF
+)
+
+type token struct {
+	kind  tokenKind
+	value string
+}
+
+// tokenize splits a query into tokens, dropping `--` comments
+func tokenize(query string) ([]token, error) {
+	var tokens []token
+	runes := []rune(query)
+	i := 0
+
+	isIdentRune := func(r rune) bool {
+		return unicode.IsLetter(r) || unicode.IsDigit(r) || r == '_' || r == '.'
+	}
+
+	for i < len(runes) {
+		r := runes[i]
+		switch {
+		case unicode.IsSpace(r):
+			i++
+		case r == '-' && i+1 < len(runes) && runes[i+1] == '-':
+			for i < len(runes) && runes[i] != '\n' {
+				i++
+			}
+		case r == '\'':
+			var sb strings.Builder
+			i++
+			for {
+				if i >= len(runes) {
+					return nil, fmt.Errorf("unterminated string literal")
+				}
+				if runes[i] == '\'' {
+					// '' is an escaped quote
+					if i+1 < len(runes) && runes[i+1] == '\'' {
+						sb.WriteRune('\'')
+						i += 2
+						continue
+					}
+					i++
+					break
+				}
+				sb.WriteRune(runes[i])
+				i++
+			}
+			tokens = append(tokens, token{tokString, sb.String()})
+		case r == '"':
+			// Quoted identifier, e.g. "default".OOLINE
+			var sb strings.Builder
+			i++
+			for i < len(runes) && runes[i] != '"' {
+				sb.WriteRune(runes[i])
+				i++
+			}
+			if i >= len(runes) {
+				return nil, fmt.Errorf("unterminated quoted identifier")
+			}
+			i++
+			for i < len(runes) && isIdentRune(runes[i]) {
+				sb.WriteRune(runes[i])
+				i++
+			}
+			tokens = append(tokens, token{tokIdent, sb.String()})
+		case unicode.IsDigit(r):
+			start := i
+			for i < len(runes) && (unicode.IsDigit(runes[i]) || runes[i] == '.') {
+				i++
+			}
+			tokens = append(tokens, token{tokNumber, string(runes[start:i])})
+		case unicode.IsLetter(r) || r == '_':
+			start := i
+			for i < len(runes) && isIdentRune(runes[i]) {
+				i++
+			}
+			tokens = append(tokens, token{tokIdent, string(runes[start:i])})
+		case r == '<' || r == '>' || r == '!':
+			if i+1 < len(runes) && (runes[i+1] == '=' || (r == '<' && runes[i+1] == '>')) {
+				tokens = append(tokens, token{tokSymbol, string(runes[i : i+2])})
+				i += 2
+			} else {
+				tokens = append(tokens, token{tokSymbol, string(r)})
+				i++
+			}
+		case strings.ContainsRune("(),=*;", r):
+			tokens = append(tokens, token{tokSymbol, string(r)})
+			i++
+		default:
+			return nil, fmt.Errorf("unexpected character %q", r)
+		}
+	}
+
+	return append(tokens, token{kind: tokEOF}), nil
+}
+
+// expr is a scalar or boolean expression evaluated against a joined row
+type expr interface {
+	eval(row joinedRow) interface{}
+}
+
+// joinedRow holds one record per table alias, in FROM/JOIN order.
+// A nil record is an unmatched LEFT JOIN.
+type joinedRow struct {
+	aliases []string
+	records []map[string]interface{}
+}
+
+// record returns the record bound to an alias
+func (row joinedRow) record(alias string) map[string]interface{} {
+	for i, a := range row.aliases {
+		if strings.EqualFold(a, alias) {
+			return row.records[i]
+		}
+	}
+	return nil
+}
+
+// with returns a copy of the row with an additional aliased record
+func (row joinedRow) with(alias string, record map[string]interface{}) joinedRow {
+	return joinedRow{
+		aliases: append(append([]string{}, row.aliases...), alias),
+		records: append(append([]map[string]interface{}{}, row.records...), record),
+	}
+}
+
+type columnRef struct {
+	qualifier string
+	name      string
+}
+
+type literal struct{ value interface{} }
+
+type coalesceExpr struct{ args []expr }
+
+type castExpr struct {
+	arg      expr
+	typeName string
+}
+
+type compareExpr struct {
+	op          string
+	left, right expr
+}
+
+type inExpr struct {
+	arg    expr
+	values []expr
+	negate bool
+}
+
+type isNullExpr struct {
+	arg    expr
+	negate bool
+}
+
+type logicalExpr struct {
+	op          string // AND, OR
+	left, right expr
+}
+
+type notExpr struct{ arg expr }
+
+type selectItem struct {
+	expr  expr
+	alias string
+	star  bool
+}
+
+type tableRef struct {
+	table string
+	alias string
+}
+
+type joinClause struct {
+	tableRef
+	left bool
+	on   expr
+}
+
+type orderItem struct {
+	expr expr
+	desc bool
+}
+
+// selectQuery is a parsed SELECT statement
+type selectQuery struct {
+	items   []selectItem
+	from    tableRef
+	joins   []joinClause
+	where   expr
+	orderBy []orderItem
+	limit   int // 0 = no limit
+}
+
+type parser struct {
+	tokens []token
+	pos    int
+}
+
+// parseQuery parses a single SELECT statement
+func parseQuery(query string) (*selectQuery, error) {
+	tokens, err := tokenize(query)
+	if err != nil {
+		return nil, err
+	}
+	p := &parser{tokens: tokens}
+
+	q, err := p.parseSelect()
+	if err != nil {
+		return nil, err
+	}
+	p.acceptSymbol(";")
+	if p.peek().kind != tokEOF {
+		return nil, fmt.Errorf("unexpected %q after end of query", p.peek().value)
+	}
+	return q, nil
+}
+
+func (p *parser) peek() token {
+	return p.tokens[p.pos]
+}
+
+func (p *parser) next() token {
+	t := p.tokens[p.pos]
+	if t.kind != tokEOF {
+		p.pos++
+	}
+	return t
+}
+
+func (p *parser) isKeyword(keyword string) bool {
+	t := p.peek()
+	return t.kind == tokIdent && strings.EqualFold(t.value, keyword)
+}
+
+func (p *parser) acceptKeyword(keyword string) bool {
+	if p.isKeyword(keyword) {
+		p.pos++
+		return true
+	}
+	return false
+}
+
+func (p *parser) expectKeyword(keyword string) error {
+	if !p.acceptKeyword(keyword) {
+		return fmt.Errorf("expected %s, got %q", keyword, p.peek().value)
+	}
+	return nil
+}
+
+func (p *parser) acceptSymbol(symbol string) bool {
+	t := p.peek()
+	if t.kind == tokSymbol && t.value == symbol {
+		p.pos++
+		return true
+	}
+	return false
+}
+
+func (p *parser) expectSymbol(symbol string) error {
+	if !p.acceptSymbol(symbol) {
+		return fmt.Errorf("expected %q, got %q", symbol, p.peek().value)
+	}
+	return nil
+}
+
+// reservedWords cannot be used as implicit aliases
+var reservedWords = map[string]bool{
+	"SELECT": true, "FROM": true, "WHERE": true, "LEFT": true, "INNER": true, "JOIN": true,
+	"ON": true, "AND": true, "OR": true, "NOT": true, "ORDER": true, "BY": true, "LIMIT": true,
+	"AS": true, "IN": true, "IS": true, "NULL": true, "OUTER": true, "ASC": true, "DESC": true,
+	"GROUP": true, "HAVING": true, "UNION": true,
+}
+
+func (p *parser) parseAlias() string {
+	if p.acceptKeyword("AS") {
+		return p.next().value
+	}
+	t := p.peek()
+	if t.kind == tokIdent && !reservedWords[strings.ToUpper(t.value)] {
+		p.pos++
+		return t.value
+	}
+	return ""
+}
+
+func (p *parser) parseSelect() (*selectQuery, error) {
+	if err := p.expectKeyword("SELECT"); err != nil {
+		return nil, err
+	}
+
+	q := &selectQuery{}
+	for {
+		if p.acceptSymbol("*") {
+			q.items = append(q.items, selectItem{star: true})
+		} else {
+			e, err := p.parseOperand()
+			if err != nil {
+				return nil, err
+			}
+			q.items = append(q.items, selectItem{expr: e, alias: p.parseAlias()})
+		}
+		if !p.acceptSymbol(",") {
+			break
+		}
+	}
+
+	if err := p.expectKeyword("FROM"); err != nil {
+		return nil, err
+	}
+	from, err := p.parseTableRef()
+	if err != nil {
+		return nil, err
+	}
+	q.from = from
+
+	for p.isKeyword("LEFT") || p.isKeyword("INNER") || p.isKeyword("JOIN") {
+		left := p.acceptKeyword("LEFT")
+		if left {
+			p.acceptKeyword("OUTER")
+		} else {
+			p.acceptKeyword("INNER")
+		}
+		if err := p.expectKeyword("JOIN"); err != nil {
+			return nil, err
+		}
+		ref, err := p.parseTableRef()
+		if err != nil {
+			return nil, err
+		}
+		if err := p.expectKeyword("ON"); err != nil {
+			return nil, err
+		}
+		on, err := p.parseExpr()
+		if err != nil {
+			return nil, err
+		}
+		q.joins = append(q.joins, joinClause{tableRef: ref, left: left, on: on})
+	}
+
+	if p.acceptKeyword("WHERE") {
+		where, err := p.parseExpr()
+		if err != nil {
+			return nil, err
+		}
+		q.where = where
+	}
+
+	if p.acceptKeyword("ORDER") {
+		if err := p.expectKeyword("BY"); err != nil {
+			return nil, err
+		}
+		for {
+			e, err := p.parseOperand()
+			if err != nil {
+				return nil, err
+			}
+			item := orderItem{expr: e}
+			if p.acceptKeyword("DESC") {
+				item.desc = true
+			} else {
+				p.acceptKeyword("ASC")
+			}
+			q.orderBy = append(q.orderBy, item)
+			if !p.acceptSymbol(",") {
+				break
+			}
+		}
+	}
+
+	if p.acceptKeyword("LIMIT") {
+		t := p.next()
+		limit, err := strconv.Atoi(t.value)
+		if t.kind != tokNumber || err != nil {
+			return nil, fmt.Errorf("invalid LIMIT %q", t.value)
+		}
+		q.limit = limit
+	}
+
+	return q, nil
+}
+
+func (p *parser) parseTableRef() (tableRef, error) {
+	t := p.next()
+	if t.kind != tokIdent {
+		return tableRef{}, fmt.Errorf("expected table name, got %q", t.value)
+	}
+	// Strip schema prefixes such as "default".OOLINE
+	table := t.value
+	if idx := strings.LastIndex(table, "."); idx >= 0 {
+		table = table[idx+1:]
+	}
+	table = strings.ToUpper(table)
+
+	alias := p.parseAlias()
+	if alias == "" {
+		alias = table
+	}
+	return tableRef{table: table, alias: alias}, nil
+}
+
+func (p *parser) parseExpr() (expr, error) {
+	left, err := p.parseAnd()
+	if err != nil {
+		return nil, err
+	}
+	for p.acceptKeyword("OR") {
+		right, err := p.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+		left = &logicalExpr{op: "OR", left: left, right: right}
+	}
+	return left, nil
+}
+
+func (p *parser) parseAnd() (expr, error) {
+	left, err := p.parseNot()
+	if err != nil {
+		return nil, err
+	}
+	for p.acceptKeyword("AND") {
+		right, err := p.parseNot()
+		if err != nil {
+			return nil, err
+		}
+		left = &logicalExpr{op: "AND", left: left, right: right}
+	}
+	return left, nil
+}
+
+func (p *parser) parseNot() (expr, error) {
+	if p.acceptKeyword("NOT") {
+		arg, err := p.parseNot()
+		if err != nil {
+			return nil, err
+		}
+		return &notExpr{arg: arg}, nil
+	}
+	return p.parsePredicate()
+}
+
+func (p *parser) parsePredicate() (expr, error) {
+	if p.acceptSymbol("(") {
+		e, err := p.parseExpr()
+		if err != nil {
+			return nil, err
+		}
+		if err := p.expectSymbol(")"); err != nil {
+			return nil, err
+		}
+		return e, nil
+	}
+
+	left, err := p.parseOperand()
+	if err != nil {
+		return nil, err
+	}
+
+	if p.acceptKeyword("IS") {
+		negate := p.acceptKeyword("NOT")
+		if err := p.expectKeyword("NULL"); err != nil {
+			return nil, err
+		}
+		return &isNullExpr{arg: left, negate: negate}, nil
+	}
+
+	negate := p.acceptKeyword("NOT")
+	if p.acceptKeyword("IN") {
+		if err := p.expectSymbol("("); err != nil {
+			return nil, err
+		}
+		in := &inExpr{arg: left, negate: negate}
+		for {
+			v, err := p.parseOperand()
+			if err != nil {
+				return nil, err
+			}
+			in.values = append(in.values, v)
+			if !p.acceptSymbol(",") {
+				break
+			}
+		}
+		if err := p.expectSymbol(")"); err != nil {
+			return nil, err
+		}
+		return in, nil
+	}
+	if negate {
+		return nil, fmt.Errorf("expected IN after NOT, got %q", p.peek().value)
+	}
+
+	t := p.peek()
+	if t.kind != tokSymbol {
+		return nil, fmt.Errorf("expected comparison operator, got %q", t.value)
+	}
+	switch t.value {
+	case "=", "<>", "!=", "<", "<=", ">", ">=":
+		p.pos++
+	default:
+		return nil, fmt.Errorf("unsupported operator %q", t.value)
+	}
+	right, err := p.parseOperand()
+	if err != nil {
+		return nil, err
+	}
+	return &compareExpr{op: t.value, left: left, right: right}, nil
+}
+
+func (p *parser) parseOperand() (expr, error) {
+	t := p.next()
+	switch t.kind {
+	case tokString:
+		return &literal{value: t.value}, nil
+	case tokNumber:
+		f, err := strconv.ParseFloat(t.value, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid number %q", t.value)
+		}
+		return &literal{value: f}, nil
+	case tokIdent:
+		switch strings.ToUpper(t.value) {
+		case "NULL":
+			return &literal{}, nil
+		case "TRUE", "FALSE":
+			return &literal{value: strings.ToLower(t.value)}, nil
+		case "COALESCE":
+			if err := p.expectSymbol("("); err != nil {
+				return nil, err
+			}
+			c := &coalesceExpr{}
+			for {
+				arg, err := p.parseOperand()
+				if err != nil {
+					return nil, err
+				}
+				c.args = append(c.args, arg)
+				if !p.acceptSymbol(",") {
+					break
+				}
+			}
+			return c, p.expectSymbol(")")
+		case "CAST":
+			if err := p.expectSymbol("("); err != nil {
+				return nil, err
+			}
+			arg, err := p.parseOperand()
+			if err != nil {
+				return nil, err
+			}
+			if err := p.expectKeyword("AS"); err != nil {
+				return nil, err
+			}
+			typeName := strings.ToUpper(p.next().value)
+			return &castExpr{arg: arg, typeName: typeName}, p.expectSymbol(")")
+		}
+		if reservedWords[strings.ToUpper(t.value)] {
+			return nil, fmt.Errorf("unexpected keyword %s", t.value)
+		}
+		ref := &columnRef{name: t.value}
+		if idx := strings.LastIndex(t.value, "."); idx >= 0 {
+			ref.qualifier = t.value[:idx]
+			ref.name = t.value[idx+1:]
+		}
+		return ref, nil
+	}
+	return nil, fmt.Errorf("unexpected %q", t.value)
+}
+
+// lookupField reads a field from a record, falling back to a case-insensitive match
+func lookupField(record map[string]interface{}, name string) interface{} {
+	if record == nil {
+		return nil
+	}
+	if v, ok := record[name]; ok {
+		return v
+	}
+	for k, v := range record {
+		if strings.EqualFold(k, name) {
+			return v
+		}
+	}
+	return nil
+}
+
+func (c *columnRef) eval(row joinedRow) interface{} {
+	if c.qualifier != "" {
+		return lookupField(row.record(c.qualifier), c.name)
+	}
+	// Unqualified columns resolve against the first source that has them
+	for _, record := range row.records {
+		if v := lookupField(record, c.name); v != nil {
+			return v
+		}
+	}
+	return nil
+}
+
+func (l *literal) eval(joinedRow) interface{} {
+	return l.value
+}
+
+func (c *coalesceExpr) eval(row joinedRow) interface{} {
+	for _, arg := range c.args {
+		if v := arg.eval(row); v != nil {
+			return v
+		}
+	}
+	return nil
+}
+
+func (c *castExpr) eval(row joinedRow) interface{} {
+	v := c.arg.eval(row)
+	if v == nil {
+		return nil
+	}
+	switch c.typeName {
+	case "BIGINT", "INT", "INTEGER", "LONG":
+		f, ok := toNumber(v)
+		if !ok {
+			return nil
+		}
+		return float64(int64(f))
+	case "DOUBLE", "DECIMAL", "FLOAT":
+		f, ok := toNumber(v)
+		if !ok {
+			return nil
+		}
+		return f
+	case "STRING", "VARCHAR":
+		return toString(v)
+	}
+	return v
+}
+
+func (c *compareExpr) eval(row joinedRow) interface{} {
+	cmp, ok := compareValues(c.left.eval(row), c.right.eval(row))
+	if !ok {
+		return false
+	}
+	switch c.op {
+	case "=":
+		return cmp == 0
+	case "<>", "!=":
+		return cmp != 0
+	case "<":
+		return cmp < 0
+	case "<=":
+		return cmp <= 0
+	case ">":
+		return cmp > 0
+	case ">=":
+		return cmp >= 0
+	}
+	return false
+}
+
+func (in *inExpr) eval(row joinedRow) interface{} {
+	v := in.arg.eval(row)
+	if v == nil {
+		return false
+	}
+	for _, candidate := range in.values {
+		if cmp, ok := compareValues(v, candidate.eval(row)); ok && cmp == 0 {
+			return !in.negate
+		}
+	}
+	return in.negate
+}
+
+func (n *isNullExpr) eval(row joinedRow) interface{} {
+	isNull := n.arg.eval(row) == nil
+	return isNull != n.negate
+}
+
+func (l *logicalExpr) eval(row joinedRow) interface{} {
+	left := truthy(l.left.eval(row))
+	if l.op == "AND" {
+		return left && truthy(l.right.eval(row))
+	}
+	return left || truthy(l.right.eval(row))
+}
+
+func (n *notExpr) eval(row joinedRow) interface{} {
+	return !truthy(n.arg.eval(row))
+}
+
+func truthy(v interface{}) bool {
+	b, ok := v.(bool)
+	return ok && b
+}
+
+// toNumber converts JSON numbers and numeric strings to float64
+func toNumber(v interface{}) (float64, bool) {
+	switch n := v.(type) {
+	case float64:
+		return n, true
+	case int:
+		return float64(n), true
+	case int64:
+		return float64(n), true
+	case string:
+		f, err := strconv.ParseFloat(strings.TrimSpace(n), 64)
+		return f, err == nil
+	}
+	return 0, false
+}
+
+// toString formats a value the way Data Fabric serializes it in string comparisons
+func toString(v interface{}) string {
+	switch s := v.(type) {
+	case string:
+		return s
+	case bool:
+		return strconv.FormatBool(s)
+	case float64:
+		return strconv.FormatFloat(s, 'f', -1, 64)
+	case nil:
+		return ""
+	}
+	return fmt.Sprint(v)
+}
+
+// compareValues compares like Spark SQL: numerically when either side is a number,
+// otherwise as strings. Returns ok=false when either side is NULL.
+func compareValues(a, b interface{}) (int, bool) {
+	if a == nil || b == nil {
+		return 0, false
+	}
+
+	_, aIsString := a.(string)
+	_, bIsString := b.(string)
+	if !aIsString || !bIsString {
+		if af, ok := toNumber(a); ok {
+			if bf, ok := toNumber(b); ok {
+				switch {
+				case af < bf:
+					return -1, true
+				case af > bf:
+					return 1, true
+				}
+				return 0, true
+			}
+		}
+	}
+
+	return strings.Compare(toString(a), toString(b)), true
+}
+
+// executeQuery runs a parsed query against the store's tables
+func executeQuery(q *selectQuery, tables func(name string) []map[string]interface{}) ([]map[string]interface{}, error) {
+	rows := make([]joinedRow, 0)
+	for _, record := range tables(q.from.table) {
+		rows = append(rows, joinedRow{
+			aliases: []string{q.from.alias},
+			records: []map[string]interface{}{record},
+		})
+	}
+
+	for _, join := range q.joins {
+		joinRecords := tables(join.table)
+		joined := make([]joinedRow, 0, len(rows))
+		for _, row := range rows {
+			matched := false
+			for _, record := range joinRecords {
+				candidate := row.with(join.alias, record)
+				if truthy(join.on.eval(candidate)) {
+					joined = append(joined, candidate)
+					matched = true
+				}
+			}
+			if !matched && join.left {
+				joined = append(joined, row.with(join.alias, nil))
+			}
+		}
+		rows = joined
+	}
+
+	if q.where != nil {
+		filtered := rows[:0]
+		for _, row := range rows {
+			if truthy(q.where.eval(row)) {
+				filtered = append(filtered, row)
+			}
+		}
+		rows = filtered
+	}
+
+	if len(q.orderBy) > 0 {
+		sort.SliceStable(rows, func(i, j int) bool {
+			for _, item := range q.orderBy {
+				a, b := item.expr.eval(rows[i]), item.expr.eval(rows[j])
+				cmp, ok := compareValues(a, b)
+				if !ok {
+					// NULLs sort first
+					switch {
+					case a == nil && b != nil:
+						cmp = -1
+					case a != nil && b == nil:
+						cmp = 1
+					default:
+						continue
+					}
+				}
+				if cmp == 0 {
+					continue
+				}
+				if item.desc {
+					return cmp > 0
+				}
+				return cmp < 0
+			}
+			return false
+		})
+	}
+
+	if q.limit > 0 && len(rows) > q.limit {
+		rows = rows[:q.limit]
+	}
+
+	results := make([]map[string]interface{}, 0, len(rows))
+	for _, row := range rows {
+		out := make(map[string]interface{})
+		for _, item := range q.items {
+			if item.star {
+				for k, v := range row.records[0] {
+					out[k] = v
+				}
+				continue
+			}
+			out[item.outputName()] = item.expr.eval(row)
+		}
+		results = append(results, out)
+	}
+
+	return results, nil
+}
+
+// outputName is the result column name: the alias, or the bare column name
+func (item selectItem) outputName() string {
+	if item.alias != "" {
+		return item.alias
+	}
+	if ref, ok := item.expr.(*columnRef); ok {
+		return ref.name
+	}
+	return "col"
+}
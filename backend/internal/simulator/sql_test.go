@@ -0,0 +1,227 @@
+package simulator
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// sqlTestTables is a small data set covering the value shapes found in Data Lake tables:
+// JSON numbers, numeric strings, explicit and missing NULLs and the string deleted flag
+var sqlTestTables = map[string][]map[string]interface{}{
+	"OOLINE": {
+		{"CONO": 100.0, "ORNO": "1000001", "PONR": 1.0, "ITNO": "FG-1000", "ORST": "22", "RORN": "7000001", "deleted": "false"},
+		{"CONO": 100.0, "ORNO": "1000001", "PONR": 2.0, "ITNO": "FG-3000", "ORST": "22", "RORN": nil, "deleted": "false"},
+		{"CONO": 100.0, "ORNO": "1000002", "PONR": 1.0, "ITNO": "O'BRIEN", "ORST": "77", "deleted": "true"},
+	},
+	"OOHEAD": {
+		{"CONO": 100.0, "ORNO": "1000001", "CUNO": "CUST01"},
+	},
+}
+
+func runTestQuery(query string) ([]map[string]interface{}, error) {
+	q, err := parseQuery(query)
+	if err != nil {
+		return nil, err
+	}
+	return executeQuery(q, func(name string) []map[string]interface{} {
+		return sqlTestTables[name]
+	})
+}
+
+func TestExecuteQuery(t *testing.T) {
+	tests := []struct {
+		name  string
+		query string
+		want  []map[string]interface{}
+	}{
+		{
+			name:  "star",
+			query: `SELECT * FROM OOLINE WHERE ORNO = '1000002'`,
+			want:  []map[string]interface{}{sqlTestTables["OOLINE"][2]},
+		},
+		{
+			name:  "aliases and schema prefix",
+			query: `SELECT l.ORNO AS order_no, l.PONR line FROM "default".OOLINE l WHERE l.deleted = 'false' AND l.PONR = 2`,
+			want:  []map[string]interface{}{{"order_no": "1000001", "line": 2.0}},
+		},
+		{
+			name:  "case-insensitive columns",
+			query: `select orno from ooline where ponr = 2`,
+			want:  []map[string]interface{}{{"orno": "1000001"}},
+		},
+		{
+			name:  "numeric comparison against numeric string",
+			query: `SELECT ORNO FROM OOLINE WHERE ORST > 30`,
+			want:  []map[string]interface{}{{"ORNO": "1000002"}},
+		},
+		{
+			name:  "boolean literal compares as string",
+			query: `SELECT PONR FROM OOLINE WHERE deleted = FALSE ORDER BY PONR`,
+			want:  []map[string]interface{}{{"PONR": 1.0}, {"PONR": 2.0}},
+		},
+		{
+			name:  "coalesce",
+			query: `SELECT PONR, COALESCE(RORN, '') AS rorn FROM OOLINE WHERE ORNO = '1000001' ORDER BY PONR`,
+			want:  []map[string]interface{}{{"PONR": 1.0, "rorn": "7000001"}, {"PONR": 2.0, "rorn": ""}},
+		},
+		{
+			name:  "cast",
+			query: `SELECT CAST(PONR AS STRING) AS ponr, CAST(ORST AS INT) AS orst, CAST(RORN AS BIGINT) AS rorn FROM OOLINE WHERE ORNO = '1000002'`,
+			want:  []map[string]interface{}{{"ponr": "1", "orst": 77.0, "rorn": nil}},
+		},
+		{
+			name:  "AND binds tighter than OR",
+			query: `SELECT ORNO, PONR FROM OOLINE WHERE ORNO = '1000002' OR ORNO = '1000001' AND PONR = 2 ORDER BY ORNO`,
+			want:  []map[string]interface{}{{"ORNO": "1000001", "PONR": 2.0}, {"ORNO": "1000002", "PONR": 1.0}},
+		},
+		{
+			name:  "parentheses",
+			query: `SELECT ORNO, PONR FROM OOLINE WHERE (ORNO = '1000002' OR ORNO = '1000001') AND PONR = 1 ORDER BY ORNO`,
+			want:  []map[string]interface{}{{"ORNO": "1000001", "PONR": 1.0}, {"ORNO": "1000002", "PONR": 1.0}},
+		},
+		{
+			name:  "NOT",
+			query: `SELECT PONR FROM OOLINE WHERE NOT deleted = 'true' ORDER BY PONR`,
+			want:  []map[string]interface{}{{"PONR": 1.0}, {"PONR": 2.0}},
+		},
+		{
+			name:  "IN",
+			query: `SELECT ITNO FROM OOLINE WHERE ITNO IN ('FG-1000', 'FG-3000') ORDER BY ITNO`,
+			want:  []map[string]interface{}{{"ITNO": "FG-1000"}, {"ITNO": "FG-3000"}},
+		},
+		{
+			name:  "NOT IN",
+			query: `SELECT ITNO FROM OOLINE WHERE ITNO NOT IN ('FG-1000', 'FG-3000')`,
+			want:  []map[string]interface{}{{"ITNO": "O'BRIEN"}},
+		},
+		{
+			name:  "escaped quote",
+			query: `SELECT ORNO FROM OOLINE WHERE ITNO = 'O''BRIEN'`,
+			want:  []map[string]interface{}{{"ORNO": "1000002"}},
+		},
+		{
+			name:  "IS NULL matches explicit and missing values",
+			query: `SELECT ORNO, PONR FROM OOLINE WHERE RORN IS NULL ORDER BY ORNO`,
+			want:  []map[string]interface{}{{"ORNO": "1000001", "PONR": 2.0}, {"ORNO": "1000002", "PONR": 1.0}},
+		},
+		{
+			name:  "IS NOT NULL",
+			query: `SELECT PONR FROM OOLINE WHERE RORN IS NOT NULL`,
+			want:  []map[string]interface{}{{"PONR": 1.0}},
+		},
+		{
+			name:  "comparison with NULL is never true",
+			query: `SELECT PONR FROM OOLINE WHERE RORN <> '7000001'`,
+			want:  []map[string]interface{}{},
+		},
+		{
+			name:  "left join keeps unmatched rows",
+			query: `SELECT l.ORNO, l.PONR, h.CUNO FROM OOLINE l LEFT JOIN OOHEAD h ON h.CONO = l.CONO AND h.ORNO = l.ORNO ORDER BY l.ORNO, l.PONR`,
+			want: []map[string]interface{}{
+				{"ORNO": "1000001", "PONR": 1.0, "CUNO": "CUST01"},
+				{"ORNO": "1000001", "PONR": 2.0, "CUNO": "CUST01"},
+				{"ORNO": "1000002", "PONR": 1.0, "CUNO": nil},
+			},
+		},
+		{
+			name:  "inner join drops unmatched rows",
+			query: `SELECT l.PONR, CUNO FROM OOLINE l INNER JOIN OOHEAD h ON h.ORNO = l.ORNO ORDER BY l.PONR DESC`,
+			want:  []map[string]interface{}{{"PONR": 2.0, "CUNO": "CUST01"}, {"PONR": 1.0, "CUNO": "CUST01"}},
+		},
+		{
+			name:  "order by desc with limit",
+			query: `SELECT PONR, ITNO FROM OOLINE ORDER BY ITNO DESC LIMIT 2`,
+			want:  []map[string]interface{}{{"PONR": 1.0, "ITNO": "O'BRIEN"}, {"PONR": 2.0, "ITNO": "FG-3000"}},
+		},
+		{
+			name:  "NULLs sort first",
+			query: `SELECT ORNO, PONR FROM OOLINE ORDER BY RORN, PONR`,
+			want: []map[string]interface{}{
+				{"ORNO": "1000002", "PONR": 1.0},
+				{"ORNO": "1000001", "PONR": 2.0},
+				{"ORNO": "1000001", "PONR": 1.0},
+			},
+		},
+		{
+			name:  "comments and trailing semicolon",
+			query: "-- open lines\nSELECT ORNO FROM OOLINE -- line two only\nWHERE PONR = 2;",
+			want:  []map[string]interface{}{{"ORNO": "1000001"}},
+		},
+		{
+			name:  "missing table is empty",
+			query: `SELECT * FROM MWOHED`,
+			want:  []map[string]interface{}{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := runTestQuery(tt.query)
+			if err != nil {
+				t.Fatalf("query failed: %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseQueryRejectsUnsupportedSQL(t *testing.T) {
+	tests := []struct {
+		name    string
+		query   string
+		wantErr string
+	}{
+		{"not a select", `UPDATE OOLINE SET ORST = '99'`, "expected SELECT"},
+		{"group by", `SELECT ORNO FROM OOLINE GROUP BY ORNO`, "after end of query"},
+		{"second statement", `SELECT ORNO FROM OOLINE; SELECT ORNO FROM OOHEAD`, "after end of query"},
+		{"aggregate", `SELECT SUM(ORQT) FROM OOLINE`, "expected FROM"},
+		{"like", `SELECT ORNO FROM OOLINE WHERE ORNO LIKE '1%'`, "expected comparison operator"},
+		{"NOT without IN", `SELECT ORNO FROM OOLINE WHERE ORNO NOT = '1'`, "expected IN after NOT"},
+		{"unterminated string", `SELECT ORNO FROM OOLINE WHERE ITNO = 'FG-1000`, "unterminated string literal"},
+		{"unterminated identifier", `SELECT ORNO FROM "default.OOLINE`, "unterminated quoted identifier"},
+		{"non-numeric limit", `SELECT ORNO FROM OOLINE LIMIT ALL`, "invalid LIMIT"},
+		{"missing join condition", `SELECT ORNO FROM OOLINE l LEFT JOIN OOHEAD h WHERE h.CUNO = 'X'`, "expected ON"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := parseQuery(tt.query)
+			if err == nil {
+				t.Fatalf("expected an error for %q", tt.query)
+			}
+			if !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("error %q does not contain %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestCompareValues(t *testing.T) {
+	tests := []struct {
+		name   string
+		a, b   interface{}
+		want   int
+		wantOK bool
+	}{
+		{"equal numbers", 22.0, 22.0, 0, true},
+		{"number and numeric string", 22.0, "22", 0, true},
+		{"numeric when either side is a number", 10.0, "9", 1, true},
+		{"string comparison when both are strings", "10", "9", -1, true},
+		{"non-numeric string falls back to string comparison", "abc", 1.0, 1, true},
+		{"boolean as string", true, "true", 0, true},
+		{"left NULL", nil, "x", 0, false},
+		{"right NULL", "x", nil, 0, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := compareValues(tt.a, tt.b)
+			if got != tt.want || ok != tt.wantOK {
+				t.Errorf("compareValues(%v, %v) = %d, %v; want %d, %v", tt.a, tt.b, got, ok, tt.want, tt.wantOK)
+			}
+		})
+	}
+}
@@ -0,0 +1,88 @@
+package workers
+
+import (
+	"context"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/pinggolf/m3-planning-tools/internal/db"
+	"github.com/pinggolf/m3-planning-tools/internal/services"
+)
+
+// NotificationDigestScheduler sends queued digest notifications on the notification_digest_cron schedule
+// Digest items are claimed atomically, so every server instance can run the scheduler safely
+type NotificationDigestScheduler struct {
+	db           *db.Queries
+	notifier     *services.NotificationService
+	environments []string
+	stopChan     chan struct{}
+	wg           sync.WaitGroup
+}
+
+// NewNotificationDigestScheduler creates a new digest scheduler for the given environments
+func NewNotificationDigestScheduler(database *db.Queries, notifier *services.NotificationService, environments []string) *NotificationDigestScheduler {
+	return &NotificationDigestScheduler{
+		db:           database,
+		notifier:     notifier,
+		environments: environments,
+		stopChan:     make(chan struct{}),
+	}
+}
+
+// Start begins evaluating the digest schedule once per minute
+func (s *NotificationDigestScheduler) Start() {
+	s.wg.Add(1)
+	go s.run()
+	log.Println("Notification digest scheduler started")
+}
+
+// Stop gracefully stops the scheduler
+func (s *NotificationDigestScheduler) Stop() {
+	close(s.stopChan)
+	s.wg.Wait()
+	log.Println("Notification digest scheduler stopped")
+}
+
+// run is the main scheduler loop, ticking at the start of every minute
+func (s *NotificationDigestScheduler) run() {
+	defer s.wg.Done()
+
+	for {
+		now := time.Now()
+		wait := now.Truncate(time.Minute).Add(time.Minute).Sub(now)
+
+		select {
+		case <-time.After(wait):
+			tick := time.Now().Truncate(time.Minute)
+			for _, env := range s.environments {
+				s.evaluateEnvironment(env, tick)
+			}
+		case <-s.stopChan:
+			return
+		}
+	}
+}
+
+// evaluateEnvironment sends the environment's pending digests when the digest cron matches
+func (s *NotificationDigestScheduler) evaluateEnvironment(environment string, tick time.Time) {
+	if services.LoadSystemSettingString(s.db, environment, "notifications_enabled", "true") != "true" {
+		return
+	}
+
+	expr := services.LoadSystemSettingString(s.db, environment, "notification_digest_cron", "0 7 * * 1-5")
+	cron, err := ParseCron(expr)
+	if err != nil {
+		log.Printf("Notification digest: invalid notification_digest_cron for %s: %v", environment, err)
+		return
+	}
+
+	if !cron.Matches(tick) {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Minute)
+	defer cancel()
+
+	s.notifier.SendDigests(ctx, environment)
+}
@@ -0,0 +1,239 @@
+package workers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/pinggolf/m3-planning-tools/internal/auth"
+	"github.com/pinggolf/m3-planning-tools/internal/db"
+	"github.com/pinggolf/m3-planning-tools/internal/queue"
+	"github.com/pinggolf/m3-planning-tools/internal/services"
+)
+
+// scheduledRefreshUserID is recorded as the refresh job's user for scheduler-started jobs
+const scheduledRefreshUserID = "scheduler"
+
+// RefreshSchedule is one entry of the scheduled_refreshes system setting
+type RefreshSchedule struct {
+	Name       string   `json:"name"`
+	Cron       string   `json:"cron"` // "minute hour day-of-month month day-of-week"
+	Company    string   `json:"company"`
+	Facility   string   `json:"facility"`
+	Facilities []string `json:"facilities,omitempty"` // Refresh several facilities in one job; overrides Facility
+	Language   string   `json:"language,omitempty"`   // Defaults to GB
+	Mode       string   `json:"mode,omitempty"`       // Defaults to the snapshot_refresh_mode setting
+	Timezone   string   `json:"timezone,omitempty"`   // IANA name, defaults to server local time
+	Enabled    *bool    `json:"enabled,omitempty"`    // Defaults to true
+}
+
+// RefreshScheduler publishes snapshot refreshes on cron schedules using service account credentials
+type RefreshScheduler struct {
+	nats             *queue.Manager
+	db               *db.Queries
+	credentialBroker *auth.CredentialBroker
+	environments     []string
+	stopChan         chan struct{}
+	wg               sync.WaitGroup
+}
+
+// NewRefreshScheduler creates a new refresh scheduler
+func NewRefreshScheduler(nats *queue.Manager, database *db.Queries, credentialBroker *auth.CredentialBroker, environments []string) *RefreshScheduler {
+	return &RefreshScheduler{
+		nats:             nats,
+		db:               database,
+		credentialBroker: credentialBroker,
+		environments:     environments,
+		stopChan:         make(chan struct{}),
+	}
+}
+
+// Start begins evaluating schedules once per minute
+func (s *RefreshScheduler) Start() {
+	s.wg.Add(1)
+	go s.run()
+	log.Println("Refresh scheduler started")
+}
+
+// Stop gracefully stops the scheduler
+func (s *RefreshScheduler) Stop() {
+	close(s.stopChan)
+	s.wg.Wait()
+	log.Println("Refresh scheduler stopped")
+}
+
+// run is the main scheduler loop, ticking at the start of every minute
+func (s *RefreshScheduler) run() {
+	defer s.wg.Done()
+
+	for {
+		now := time.Now()
+		wait := now.Truncate(time.Minute).Add(time.Minute).Sub(now)
+
+		select {
+		case <-time.After(wait):
+			tick := time.Now().Truncate(time.Minute)
+			for _, env := range s.environments {
+				s.evaluateEnvironment(env, tick)
+			}
+		case <-s.stopChan:
+			return
+		}
+	}
+}
+
+// evaluateEnvironment fires every enabled schedule of an environment that matches the given minute
+func (s *RefreshScheduler) evaluateEnvironment(environment string, tick time.Time) {
+	if services.LoadSystemSettingString(s.db, environment, "scheduled_refresh_enabled", "false") != "true" {
+		return
+	}
+
+	schedules, err := LoadRefreshSchedules(s.db, environment)
+	if err != nil {
+		log.Printf("Refresh scheduler: %v", err)
+		return
+	}
+
+	for _, schedule := range schedules {
+		if schedule.Enabled != nil && !*schedule.Enabled {
+			continue
+		}
+
+		cron, err := ParseCron(schedule.Cron)
+		if err != nil {
+			log.Printf("Refresh scheduler: schedule %q (%s) has invalid cron: %v", schedule.Name, environment, err)
+			continue
+		}
+
+		localTick := tick
+		if schedule.Timezone != "" {
+			location, err := time.LoadLocation(schedule.Timezone)
+			if err != nil {
+				log.Printf("Refresh scheduler: schedule %q (%s) has invalid timezone %q: %v", schedule.Name, environment, schedule.Timezone, err)
+				continue
+			}
+			localTick = tick.In(location)
+		}
+
+		if !cron.Matches(localTick) {
+			continue
+		}
+
+		s.fire(environment, schedule, tick)
+	}
+}
+
+// fire claims a schedule occurrence and publishes its refresh
+func (s *RefreshScheduler) fire(environment string, schedule RefreshSchedule, tick time.Time) {
+	ctx := context.Background()
+
+	// Claim first so only one server instance starts this occurrence
+	claimed, err := s.db.ClaimScheduledRefreshRun(ctx, environment, schedule.Name, tick.UTC(), schedule.Company, schedule.Facility)
+	if err != nil {
+		log.Printf("Refresh scheduler: %v", err)
+		return
+	}
+	if !claimed {
+		return
+	}
+
+	jobID, err := s.publishRefresh(ctx, environment, schedule)
+	if err != nil {
+		log.Printf("Refresh scheduler: schedule %q (%s) failed: %v", schedule.Name, environment, err)
+		s.db.UpdateScheduledRefreshRun(ctx, environment, schedule.Name, tick.UTC(), jobID, "failed", err.Error())
+		return
+	}
+	if jobID == "" {
+		s.db.UpdateScheduledRefreshRun(ctx, environment, schedule.Name, tick.UTC(), "", "skipped", "a refresh is already running")
+		return
+	}
+
+	s.db.UpdateScheduledRefreshRun(ctx, environment, schedule.Name, tick.UTC(), jobID, "queued", "")
+	log.Printf("Refresh scheduler: schedule %q queued job %s for %s %s/%s", schedule.Name, jobID, environment, schedule.Company, schedule.Facility)
+}
+
+// publishRefresh creates the refresh job and publishes it to the snapshot worker
+// Returns an empty job ID when skipped because a refresh is already active
+func (s *RefreshScheduler) publishRefresh(ctx context.Context, environment string, schedule RefreshSchedule) (string, error) {
+	active, err := s.db.GetActiveRefreshJob(ctx, environment)
+	if err != nil {
+		return "", err
+	}
+	if active != nil {
+		log.Printf("Refresh scheduler: skipping schedule %q (%s), job %s is still %s", schedule.Name, environment, active.ID, active.Status)
+		return "", nil
+	}
+
+	refreshMode := schedule.Mode
+	if refreshMode == "" {
+		refreshMode = services.LoadSystemSettingString(s.db, environment, "snapshot_refresh_mode", services.RefreshModeFull)
+	}
+
+	jobID := fmt.Sprintf("job-%d", time.Now().UnixNano())
+	if err := s.db.CreateRefreshJob(ctx, jobID, environment, scheduledRefreshUserID, "snapshot_refresh"); err != nil {
+		return "", fmt.Errorf("failed to create job: %w", err)
+	}
+
+	credentialRef, err := s.credentialBroker.IssueForServiceAccount(ctx, jobID, environment, scheduledRefreshUserID)
+	if err != nil {
+		s.db.FailJob(ctx, jobID, "Failed to issue job credential")
+		return jobID, err
+	}
+
+	msg := SnapshotRefreshMessage{
+		JobID:         jobID,
+		Environment:   environment,
+		UserID:        scheduledRefreshUserID,
+		CredentialRef: credentialRef,
+		Company:       schedule.Company,
+		Facility:      schedule.Facility,
+		Facilities:    schedule.Facilities,
+		Language:      schedule.Language,
+		RefreshMode:   refreshMode,
+	}
+
+	msgData, _ := json.Marshal(msg)
+	if err := s.nats.Publish(queue.GetSnapshotRefreshSubject(environment), msgData); err != nil {
+		s.db.FailJob(ctx, jobID, "Failed to publish job to queue")
+		return jobID, fmt.Errorf("failed to publish refresh: %w", err)
+	}
+
+	return jobID, nil
+}
+
+// LoadRefreshSchedules reads and validates the scheduled_refreshes setting for an environment
+func LoadRefreshSchedules(database *db.Queries, environment string) ([]RefreshSchedule, error) {
+	raw := services.LoadSystemSettingString(database, environment, "scheduled_refreshes", "[]")
+
+	var schedules []RefreshSchedule
+	if err := json.Unmarshal([]byte(raw), &schedules); err != nil {
+		return nil, fmt.Errorf("invalid scheduled_refreshes setting for %s: %w", environment, err)
+	}
+
+	valid := make([]RefreshSchedule, 0, len(schedules))
+	for _, schedule := range schedules {
+		if len(schedule.Facilities) > 0 {
+			schedule.Facility = schedule.Facilities[0]
+		}
+		if schedule.Company == "" || schedule.Facility == "" || schedule.Cron == "" {
+			log.Printf("Refresh scheduler: ignoring %s schedule %q without cron, company or facility", environment, schedule.Name)
+			continue
+		}
+		if schedule.Name == "" {
+			schedule.Name = fmt.Sprintf("%s-%s", schedule.Company, schedule.Facility)
+		}
+		if schedule.Language == "" {
+			schedule.Language = "GB"
+		}
+		if schedule.Mode != "" && schedule.Mode != services.RefreshModeFull && schedule.Mode != services.RefreshModeIncremental {
+			log.Printf("Refresh scheduler: ignoring %s schedule %q with invalid mode %q", environment, schedule.Name, schedule.Mode)
+			continue
+		}
+		valid = append(valid, schedule)
+	}
+
+	return valid, nil
+}
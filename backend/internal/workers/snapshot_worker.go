@@ -9,6 +9,7 @@ import (
 	"time"
 
 	"github.com/nats-io/nats.go"
+	"github.com/pinggolf/m3-planning-tools/internal/auth"
 	"github.com/pinggolf/m3-planning-tools/internal/compass"
 	"github.com/pinggolf/m3-planning-tools/internal/config"
 	"github.com/pinggolf/m3-planning-tools/internal/db"
@@ -18,37 +19,53 @@ import (
 
 // SnapshotWorker handles async snapshot refresh jobs
 type SnapshotWorker struct {
-	nats           *queue.Manager
-	db             *db.Queries
-	config         *config.Config
-	jobContexts    map[string]context.CancelFunc // Track job cancellation contexts
-	jobContextsMux sync.RWMutex                  // Protect concurrent access
+	nats             *queue.Manager
+	db               *db.Queries
+	config           *config.Config
+	jobContexts      map[string]context.CancelFunc // Track job cancellation contexts
+	jobContextsMux   sync.RWMutex                  // Protect concurrent access
+	notifier         *services.NotificationService
+	rateLimiter      *services.RateLimiterService // Shared throttle for Compass page requests
+	credentialBroker *auth.CredentialBroker       // Exchanges job credential references for tokens
 }
 
 // NewSnapshotWorker creates a new snapshot worker
-func NewSnapshotWorker(nats *queue.Manager, database *db.Queries, cfg *config.Config) *SnapshotWorker {
+func NewSnapshotWorker(nats *queue.Manager, database *db.Queries, cfg *config.Config, credentialBroker *auth.CredentialBroker) *SnapshotWorker {
 	return &SnapshotWorker{
-		nats:        nats,
-		db:          database,
-		config:      cfg,
-		jobContexts: make(map[string]context.CancelFunc),
+		nats:             nats,
+		db:               database,
+		config:           cfg,
+		jobContexts:      make(map[string]context.CancelFunc),
+		notifier:         services.NewNotificationService(database, cfg),
+		rateLimiter:      services.NewRateLimiterService(database),
+		credentialBroker: credentialBroker,
 	}
 }
 
 // SnapshotRefreshMessage represents a snapshot refresh request
 type SnapshotRefreshMessage struct {
-	JobID       string `json:"jobId"`
-	Environment string `json:"environment"`
-	UserID      string `json:"userId,omitempty"`
-	AccessToken string `json:"accessToken"`
-	Company     string `json:"company"`
-	Facility    string `json:"facility"`
-	Language    string `json:"language"`
+	JobID         string   `json:"jobId"`
+	Environment   string   `json:"environment"`
+	UserID        string   `json:"userId,omitempty"`
+	CredentialRef string   `json:"credentialRef"` // Job-scoped reference exchanged with the credential broker
+	Company       string   `json:"company"`
+	Facility      string   `json:"facility"`
+	Facilities    []string `json:"facilities,omitempty"` // Multi-facility refresh; overrides Facility when set
+	Language      string   `json:"language"`
+	RefreshMode   string   `json:"refreshMode,omitempty"` // "full" (default) or "incremental"
+}
+
+// FacilityList returns the facilities a refresh covers
+func (m SnapshotRefreshMessage) FacilityList() []string {
+	if len(m.Facilities) > 0 {
+		return m.Facilities
+	}
+	return []string{m.Facility}
 }
 
 // PhaseProgress represents the status of a single parallel phase
 type PhaseProgress struct {
-	Phase            string    `json:"phase"`                      // "mops", "mos", "cos"
+	Phase            string    `json:"phase"`                      // "mops", "mos", "cos", "materials", "operations", "supply_chain"
 	Status           string    `json:"status"`                     // "pending", "running", "completed", "failed"
 	CurrentOperation string    `json:"currentOperation,omitempty"` // "Querying...", "Processing...", "Inserting..."
 	RecordCount      int       `json:"recordCount"`                // Records processed
@@ -91,32 +108,79 @@ type ProgressUpdate struct {
 }
 
 
-// DataBatchJobMessage represents work for loading one data type (MOPs, MOs, or COs)
+// DataBatchJobMessage represents work for loading one data type (MOPs, MOs, COs, materials, operations or supply chain links)
 type DataBatchJobMessage struct {
-	JobID       string `json:"jobId"`
-	ParentJobID string `json:"parentJobId"`
-	DataType    string `json:"dataType"`    // "mops", "mos", "cos"
-	Environment string `json:"environment"`
-	AccessToken string `json:"accessToken"`
-	Company     string `json:"company"`
-	Facility    string `json:"facility"`
-	Language    string `json:"language"`
+	JobID         string `json:"jobId"`
+	ParentJobID   string `json:"parentJobId"`
+	DataType      string `json:"dataType"` // "mops", "mos", "cos", "materials", "operations", "supply_chain"
+	Environment   string `json:"environment"`
+	CredentialRef string `json:"credentialRef"`
+	Company       string `json:"company"`
+	Facility      string `json:"facility"`
+	Language      string `json:"language"`
+	RefreshMode   string `json:"refreshMode,omitempty"` // "full" or "incremental"
+	Attempt       int    `json:"attempt,omitempty"`     // 1 for the first delivery; redelivered jobs resume from their checkpoint
+}
+
+// snapshotDataTypes are the data jobs published per facility for a refresh, in display order
+// Each type is one parallel phase; "materials" and "operations" load the tables of the
+// material shortage and work center overload detectors, "supply_chain" the MPREAL pegging links
+var snapshotDataTypes = []string{"mops", "mos", "cos", "materials", "operations", "supply_chain"}
+
+// Data job redelivery timing
+const (
+	batchHeartbeatInterval = 30 * time.Second // How often a running batch job reports it is alive
+	dataJobStallTimeout    = 3 * time.Minute  // Silence after which a started batch job's worker is presumed lost
+)
+
+// dataJobID returns the job ID of one data type and facility of a refresh
+func dataJobID(parentJobID, dataType, facility string) string {
+	return fmt.Sprintf("%s-%s-%s", parentJobID, dataType, facility)
+}
+
+// newDataBatchJob builds the first delivery of a refresh's data job for one data type and facility
+func newDataBatchJob(req SnapshotRefreshMessage, dataType, facility string) DataBatchJobMessage {
+	return DataBatchJobMessage{
+		JobID:         dataJobID(req.JobID, dataType, facility),
+		ParentJobID:   req.JobID,
+		DataType:      dataType,
+		Environment:   req.Environment,
+		CredentialRef: req.CredentialRef,
+		Company:       req.Company,
+		Facility:      facility,
+		Language:      req.Language,
+		RefreshMode:   req.RefreshMode,
+		Attempt:       1,
+	}
 }
 
 // BatchStartMessage signals that a worker has picked up a batch job
 type BatchStartMessage struct {
 	JobID       string    `json:"jobId"`
 	ParentJobID string    `json:"parentJobId"`
-	DataType    string    `json:"dataType"` // "mops", "mos", "cos"
+	DataType    string    `json:"dataType"` // "mops", "mos", "cos", "materials", "operations", "supply_chain"
+	Facility    string    `json:"facility"`
+	Attempt     int       `json:"attempt,omitempty"`
 	StartTime   time.Time `json:"startTime"`
 }
 
+// BatchHeartbeatMessage signals that a batch job is still running
+type BatchHeartbeatMessage struct {
+	JobID       string `json:"jobId"`
+	ParentJobID string `json:"parentJobId"`
+	DataType    string `json:"dataType"` // "mops", "mos", "cos", "materials", "operations", "supply_chain"
+	Facility    string `json:"facility"`
+	Attempt     int    `json:"attempt,omitempty"`
+}
+
 // BatchCompletionMessage signals data type loading completion
 type BatchCompletionMessage struct {
 	JobID       string `json:"jobId"`
 	ParentJobID string `json:"parentJobId"`
-	DataType    string `json:"dataType"` // "mops", "mos", "cos"
+	DataType    string `json:"dataType"` // "mops", "mos", "cos", "materials", "operations", "supply_chain"
+	Facility    string `json:"facility"`
 	RecordCount int    `json:"recordCount"`
+	Attempt     int    `json:"attempt,omitempty"`
 	Success     bool   `json:"success"`
 	Error       string `json:"error,omitempty"`
 }
@@ -125,7 +189,8 @@ type BatchCompletionMessage struct {
 type PhaseSubProgressMessage struct {
 	JobID            string `json:"jobId"`            // "abc123-mops"
 	ParentJobID      string `json:"parentJobId"`      // "abc123"
-	DataType         string `json:"dataType"`         // "mops", "mos", "cos"
+	DataType         string `json:"dataType"`         // "mops", "mos", "cos", "materials", "operations", "supply_chain"
+	Facility         string `json:"facility"`         // "AZ1"
 	CurrentOperation string `json:"currentOperation"` // "Querying...", "Processing...", "Inserting..."
 	RecordCount      int    `json:"recordCount"`      // Running count if available
 }
@@ -136,9 +201,18 @@ type DetectorJobMessage struct {
 	ParentJobID  string `json:"parentJobId"`  // "abc123"
 	DetectorName string `json:"detectorName"` // "unlinked_production_orders"
 	DisplayLabel string `json:"displayLabel"` // "Unlinked Production Orders"
-	Environment  string `json:"environment"`  // "TRN" or "PRD"
-	Company      string `json:"company"`      // "100"
-	Facility     string `json:"facility"`     // "AZ1"
+	Environment  string `json:"environment"`  // Environment key, e.g. "TRN"
+	Company      string   `json:"company"`              // "100"
+	Facility     string   `json:"facility"`             // "AZ1"
+	Facilities   []string `json:"facilities,omitempty"` // Multi-facility detection; overrides Facility when set
+}
+
+// FacilityList returns the facilities a detector job covers
+func (m DetectorJobMessage) FacilityList() []string {
+	if len(m.Facilities) > 0 {
+		return m.Facilities
+	}
+	return []string{m.Facility}
 }
 
 // DetectorStartMessage signals that a worker has picked up a detector job
@@ -164,43 +238,46 @@ type DetectorCompletionMessage struct {
 // DetectorCoordinatorMessage triggers coordination of a manual detection job
 type DetectorCoordinatorMessage struct {
 	JobID          string   `json:"jobId"`          // Detection job ID (e.g., "det-123456789")
-	Environment    string   `json:"environment"`    // "TRN" or "PRD"
+	Environment    string   `json:"environment"`    // Environment key, e.g. "TRN"
 	DetectorNames  []string `json:"detectorNames"`  // List of detectors being run
 	TotalDetectors int      `json:"totalDetectors"` // Total count for progress tracking
-	Company        string   `json:"company"`        // Company code
-	Facility       string   `json:"facility"`       // Facility code
+	Company        string   `json:"company"`              // Company code
+	Facility       string   `json:"facility"`             // Facility code
+	Facilities     []string `json:"facilities,omitempty"` // All facilities in the snapshot
+}
+
+// FacilityList returns the facilities the detection job covers
+func (m DetectorCoordinatorMessage) FacilityList() []string {
+	if len(m.Facilities) > 0 {
+		return m.Facilities
+	}
+	return []string{m.Facility}
 }
 
 // Start starts the snapshot worker and subscribes to NATS subjects
 func (w *SnapshotWorker) Start() error {
 	log.Println("Starting snapshot worker...")
 
-	// Subscribe to TRN refresh requests (coordinator)
-	_, err := w.nats.QueueSubscribe(
-		queue.SubjectSnapshotRefreshTRN,
-		queue.QueueGroupSnapshot,
-		w.handleRefreshRequest,
-	)
-	if err != nil {
-		return fmt.Errorf("failed to subscribe to TRN refresh: %w", err)
-	}
+	// Every configured environment gets its own set of subscriptions
+	environments := w.config.EnvironmentKeys()
 
-	// Subscribe to PRD refresh requests (coordinator)
-	_, err = w.nats.QueueSubscribe(
-		queue.SubjectSnapshotRefreshPRD,
-		queue.QueueGroupSnapshot,
-		w.handleRefreshRequest,
-	)
-	if err != nil {
-		return fmt.Errorf("failed to subscribe to PRD refresh: %w", err)
+	// Subscribe to refresh requests (coordinator) of each environment
+	for _, env := range environments {
+		_, err := w.nats.QueueSubscribe(
+			queue.GetSnapshotRefreshSubject(env),
+			queue.QueueGroupSnapshot,
+			w.handleRefreshRequest,
+		)
+		if err != nil {
+			return fmt.Errorf("failed to subscribe to %s refresh: %w", env, err)
+		}
 	}
 
 	// Subscribe to each data type individually for parallel distribution
 	// IMPORTANT: Wildcard subscriptions (snapshot.batch.TRN.>) create a single FIFO queue,
 	// causing sequential processing. Individual subscriptions with the same queue group
 	// enable NATS to distribute messages in parallel across workers.
-	dataTypes := []string{"mops", "mos", "cos"}
-	environments := []string{"TRN", "PRD"}
+	dataTypes := snapshotDataTypes
 
 	for _, env := range environments {
 		for _, dataType := range dataTypes {
@@ -224,7 +301,12 @@ func (w *SnapshotWorker) Start() error {
 		"unlinked_production_orders",
 		"joint_delivery_date_mismatch",
 		"dlix_date_mismatch",
-		// DISABLED: "co_quantity_mismatch" - requires PAQT from MPTAWY table which has severe performance issues
+		"material_shortage",
+		"work_center_overload",
+		"late_delivery",
+		"co_quantity_mismatch",
+		"orphaned_co_demand",
+		"multi_level_date_conflict",
 	}
 
 	for _, env := range environments {
@@ -257,7 +339,7 @@ func (w *SnapshotWorker) Start() error {
 	log.Println("Subscribed to manual detection coordinator queues")
 
 	// Subscribe to cancellation requests (all workers should listen)
-	_, err = w.nats.Subscribe("snapshot.cancel.*", w.handleCancelRequest)
+	_, err := w.nats.Subscribe("snapshot.cancel.*", w.handleCancelRequest)
 	if err != nil {
 		return fmt.Errorf("failed to subscribe to cancellation requests: %w", err)
 	}
@@ -408,29 +490,38 @@ func (w *SnapshotWorker) processRefresh(req SnapshotRefreshMessage) error {
 		return fmt.Errorf("job cancelled: %w", ctx.Err())
 	}
 
-	// Phase 0: Truncate database (must complete first)
-	log.Printf("Phase 0: Truncating database for job %s", req.JobID)
-	w.publishDetailedProgress(req.JobID, "running", "Preparing database", "Truncating tables",
+	// Decide between full reload and LMDT delta refresh
+	req.RefreshMode = w.resolveRefreshMode(ctx, req)
+	incremental := req.RefreshMode == services.RefreshModeIncremental
+
+	// Phase 0: Prepare the staging generation (must complete first)
+	// Live tables keep serving the previous snapshot until runFinalize promotes staging
+	operation := "Clearing staging tables"
+	if incremental {
+		operation = "Copying current snapshot into staging for incremental refresh"
+	}
+	log.Printf("Phase 0: Preparing staging tables for job %s (%s)", req.JobID, req.RefreshMode)
+	w.publishDetailedProgress(req.JobID, "running", "Preparing database", operation,
 		0, 4, 0, 0, 0, 0, nil, nil, 0, 0, 0, 0)
 
-	if err := w.db.TruncateAnalysisTables(ctx, req.Environment); err != nil {
+	if err := w.db.PrepareSnapshotStaging(ctx, req.Environment, incremental); err != nil {
 		// Check if error is due to cancellation
 		if ctx.Err() != nil {
-			log.Printf("Job %s cancelled during truncate", req.JobID)
+			log.Printf("Job %s cancelled during staging preparation", req.JobID)
 			return fmt.Errorf("job cancelled: %w", ctx.Err())
 		}
-		w.publishError(req.JobID, fmt.Sprintf("Truncate failed: %v", err))
+		w.publishError(req.JobID, fmt.Sprintf("Staging preparation failed: %v", err))
 		w.db.FailJob(ctx, req.JobID, err.Error())
-		return fmt.Errorf("truncate failed: %w", err)
+		return fmt.Errorf("staging preparation failed: %w", err)
 	}
 
-	// Check for cancellation after truncate
+	// Check for cancellation after staging preparation
 	if ctx.Err() != nil {
-		log.Printf("Job %s cancelled after truncate", req.JobID)
+		log.Printf("Job %s cancelled after staging preparation", req.JobID)
 		return fmt.Errorf("job cancelled: %w", ctx.Err())
 	}
 
-	log.Printf("Phase 0 complete: Database truncated")
+	log.Printf("Phase 0 complete: Staging tables prepared")
 	w.publishDetailedProgress(req.JobID, "running", "Database prepared", "Publishing data jobs",
 		1, 4, 20, 0, 0, 0, nil, nil, 0, 0, 0, 0)
 
@@ -439,6 +530,54 @@ func (w *SnapshotWorker) processRefresh(req SnapshotRefreshMessage) error {
 	return w.publishDataJobs(req)
 }
 
+// resolveRefreshMode returns the refresh mode to use for a job
+// Incremental refresh needs a live snapshot of the same company/facility to build on.
+// Live tables only ever hold a fully promoted generation, so any earlier refresh that
+// loaded this context is a valid base; otherwise the job falls back to a full refresh
+func (w *SnapshotWorker) resolveRefreshMode(ctx context.Context, req SnapshotRefreshMessage) string {
+	if req.RefreshMode != services.RefreshModeIncremental {
+		return services.RefreshModeFull
+	}
+
+	previous, err := w.db.GetPreviousSnapshotRefreshJob(ctx, req.Environment, req.JobID)
+	if err != nil {
+		log.Printf("Job %s: %v - using full refresh", req.JobID, err)
+		return services.RefreshModeFull
+	}
+	if previous == nil {
+		log.Printf("Job %s: no previous refresh - using full refresh", req.JobID)
+		return services.RefreshModeFull
+	}
+
+	company, facilities, err := w.db.GetRefreshJobContext(ctx, previous.ID)
+	if err != nil {
+		log.Printf("Job %s: %v - using full refresh", req.JobID, err)
+		return services.RefreshModeFull
+	}
+	if company != req.Company || !sameFacilities(facilities, req.FacilityList()) {
+		log.Printf("Job %s: snapshot holds %s/%v but %s/%v was requested - using full refresh",
+			req.JobID, company, facilities, req.Company, req.FacilityList())
+		return services.RefreshModeFull
+	}
+
+	return services.RefreshModeIncremental
+}
+
+// sameFacilities reports whether two facility lists hold the same set of facilities
+func sameFacilities(a, b []string) bool {
+	set := make(map[string]bool, len(a))
+	for _, f := range a {
+		set[f] = true
+	}
+	for _, f := range b {
+		if !set[f] {
+			return false
+		}
+		delete(set, f)
+	}
+	return len(set) == 0
+}
+
 // publishDetailedProgress publishes a detailed progress update with extended metrics
 func (w *SnapshotWorker) publishDetailedProgress(jobID, status, currentStep, currentOperation string, completedSteps, totalSteps, progressPct, coLines, mos, mops int, parallelPhases []PhaseProgress, parallelDetectors []DetectorProgress, recordsPerSec float64, estimatedSecsRemaining, currentBatch, totalBatches int) {
 	update := ProgressUpdate{
@@ -501,11 +640,12 @@ func (w *SnapshotWorker) publishError(jobID, errorMsg string) {
 }
 
 // publishPhaseSubProgress publishes intermediate progress for a data type
-func (w *SnapshotWorker) publishPhaseSubProgress(parentJobID, dataType, operation string, recordCount int) {
+func (w *SnapshotWorker) publishPhaseSubProgress(parentJobID, dataType, facility, operation string, recordCount int) {
 	msg := PhaseSubProgressMessage{
-		JobID:            fmt.Sprintf("%s-%s", parentJobID, dataType),
+		JobID:            dataJobID(parentJobID, dataType, facility),
 		ParentJobID:      parentJobID,
 		DataType:         dataType,
+		Facility:         facility,
 		CurrentOperation: operation,
 		RecordCount:      recordCount,
 	}
@@ -531,7 +671,11 @@ func (w *SnapshotWorker) handleBatchJob(msg *nats.Msg) {
 		return
 	}
 
-	log.Printf("Processing %s data for job %s", job.DataType, job.JobID)
+	if job.Attempt == 0 {
+		job.Attempt = 1
+	}
+
+	log.Printf("Processing %s data for job %s (facility %s, attempt %d)", job.DataType, job.JobID, job.Facility, job.Attempt)
 
 	// Create context with timeout for Compass SQL queries
 	// 30 minutes should be sufficient for even large datasets
@@ -549,6 +693,8 @@ func (w *SnapshotWorker) handleBatchJob(msg *nats.Msg) {
 		JobID:       job.JobID,
 		ParentJobID: job.ParentJobID,
 		DataType:    job.DataType,
+		Facility:    job.Facility,
+		Attempt:     job.Attempt,
 		StartTime:   time.Now(),
 	}
 	startData, _ := json.Marshal(startMsg)
@@ -558,6 +704,10 @@ func (w *SnapshotWorker) handleBatchJob(msg *nats.Msg) {
 		// Non-fatal, continue processing
 	}
 
+	// Heartbeat while loading so the coordinator can tell a long job from a lost worker
+	stopHeartbeat := w.startBatchHeartbeat(job)
+	defer stopHeartbeat()
+
 	// Get environment config
 	envConfig, err := w.config.GetEnvironmentConfig(job.Environment)
 	if err != nil {
@@ -567,16 +717,19 @@ func (w *SnapshotWorker) handleBatchJob(msg *nats.Msg) {
 	}
 
 	// Create Compass client
-	getToken := func() (string, error) {
-		return job.AccessToken, nil
-	}
+	// Tokens come from the credential broker, which refreshes them as long-running jobs outlive them
+	getToken := w.credentialBroker.TokenSource(job.CredentialRef, job.ParentJobID)
 	compassClient := compass.NewClient(envConfig.CompassBaseURL, getToken)
-	snapshotService := services.NewSnapshotService(compassClient, w.db)
+	// Load into the staging generation - live tables are replaced only after finalize succeeds
+	snapshotService := services.NewSnapshotService(compassClient, w.db.Staging())
+	snapshotService.SetRateLimiter(w.rateLimiter)
+	// Checkpoint Compass pages so a redelivery of this job resumes where it stopped
+	snapshotService.SetCheckpointJob(job.JobID, job.ParentJobID)
 
 	// Set progress callback to publish intermediate updates
 	snapshotService.SetProgressCallback(func(phase string, stepNum, totalSteps int, message string, mopCount, moCount, coCount, currentRecordCount int) {
 		// Use the currentRecordCount parameter which contains the real-time progress
-		w.publishPhaseSubProgress(job.ParentJobID, job.DataType, message, currentRecordCount)
+		w.publishPhaseSubProgress(job.ParentJobID, job.DataType, job.Facility, message, currentRecordCount)
 	})
 
 	var recordCount int
@@ -585,13 +738,22 @@ func (w *SnapshotWorker) handleBatchJob(msg *nats.Msg) {
 	// Execute full query based on data type (no ID range filtering)
 	switch job.DataType {
 	case "mops":
-		recordCount, fetchErr = snapshotService.RefreshPlannedOrders(ctx, job.Environment, job.Company, job.Facility)
+		recordCount, fetchErr = snapshotService.RefreshPlannedOrders(ctx, job.Environment, job.Company, job.Facility, job.RefreshMode)
 
 	case "mos":
-		recordCount, fetchErr = snapshotService.RefreshManufacturingOrders(ctx, job.Environment, job.Company, job.Facility)
+		recordCount, fetchErr = snapshotService.RefreshManufacturingOrders(ctx, job.Environment, job.Company, job.Facility, job.RefreshMode)
 
 	case "cos":
-		recordCount, fetchErr = snapshotService.RefreshOpenCustomerOrderLines(ctx, job.Environment, job.Company, job.Facility, job.Language)
+		recordCount, fetchErr = snapshotService.RefreshOpenCustomerOrderLines(ctx, job.Environment, job.Company, job.Facility, job.Language, job.RefreshMode)
+
+	case "materials":
+		recordCount, fetchErr = snapshotService.RefreshMaterials(ctx, job.Environment, job.Company, job.Facility, job.RefreshMode)
+
+	case "operations":
+		recordCount, fetchErr = snapshotService.RefreshOperations(ctx, job.Environment, job.Company, job.Facility, job.RefreshMode)
+
+	case "supply_chain":
+		recordCount, fetchErr = snapshotService.RefreshSupplyChainLinks(ctx, job.Environment, job.Company, job.Facility, job.RefreshMode)
 
 	default:
 		log.Printf("Unknown data type: %s", job.DataType)
@@ -599,7 +761,13 @@ func (w *SnapshotWorker) handleBatchJob(msg *nats.Msg) {
 		return
 	}
 
-	log.Printf("Completed %s data: %d records", job.DataType, recordCount)
+	log.Printf("Completed %s data for facility %s: %d records", job.DataType, job.Facility, recordCount)
+
+	if fetchErr == nil {
+		if err := w.db.DeleteCompassQueryCheckpoint(ctx, job.JobID); err != nil {
+			log.Printf("Warning: %v", err)
+		}
+	}
 
 	// Publish completion
 	w.publishBatchCompletion(job, recordCount, fetchErr)
@@ -611,7 +779,9 @@ func (w *SnapshotWorker) publishBatchCompletion(job DataBatchJobMessage, recordC
 		JobID:       job.JobID,
 		ParentJobID: job.ParentJobID,
 		DataType:    job.DataType,
+		Facility:    job.Facility,
 		RecordCount: recordCount,
+		Attempt:     job.Attempt,
 		Success:     err == nil,
 	}
 	if err != nil {
@@ -628,6 +798,36 @@ func (w *SnapshotWorker) publishBatchCompletion(job DataBatchJobMessage, recordC
 	}
 }
 
+// startBatchHeartbeat publishes heartbeats for a running batch job until the returned func is called
+func (w *SnapshotWorker) startBatchHeartbeat(job DataBatchJobMessage) func() {
+	done := make(chan struct{})
+
+	go func() {
+		ticker := time.NewTicker(batchHeartbeatInterval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-done:
+				return
+			case <-ticker.C:
+				data, _ := json.Marshal(BatchHeartbeatMessage{
+					JobID:       job.JobID,
+					ParentJobID: job.ParentJobID,
+					DataType:    job.DataType,
+					Facility:    job.Facility,
+					Attempt:     job.Attempt,
+				})
+				if err := w.nats.Publish(queue.GetBatchHeartbeatSubject(job.ParentJobID), data); err != nil {
+					log.Printf("Failed to publish batch heartbeat: %v", err)
+				}
+			}
+		}
+	}()
+
+	return func() { close(done) }
+}
+
 // handleDetectorJob processes a single detector execution
 // This is the worker that executes individual detectors distributed via NATS
 func (w *SnapshotWorker) handleDetectorJob(msg *nats.Msg) {
@@ -693,8 +893,8 @@ func (w *SnapshotWorker) handleDetectorJob(msg *nats.Msg) {
 		return
 	}
 
-	// Execute detector
-	issuesFound, err := detector.Detect(ctx, w.db, job.ParentJobID, job.Environment, job.Company, job.Facility)
+	// Execute detector for every facility; issues from all facilities land in the same job
+	issuesFound, err := detectionService.RunDetector(ctx, detector, job.ParentJobID, job.Environment, job.Company, job.FacilityList())
 
 	if err != nil {
 		log.Printf("Detector '%s' failed: %v", job.DetectorName, err)
@@ -731,22 +931,24 @@ func (w *SnapshotWorker) publishDetectorCompletion(job DetectorJobMessage, issue
 	}
 }
 
-// publishDataJobs publishes 3 data jobs (MOPs, MOs, COs) to NATS and waits for completion
+// publishDataJobs publishes one data job per data type (MOPs, MOs, COs, materials, operations, supply chain links) and facility to NATS and waits for completion
 func (w *SnapshotWorker) publishDataJobs(req SnapshotRefreshMessage) error {
 	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Minute)
 	defer cancel()
 
-	log.Printf("Phase 1: Publishing 3 data jobs to NATS queue...")
+	facilities := req.FacilityList()
+	totalJobs := len(snapshotDataTypes) * len(facilities)
+
+	log.Printf("Phase 1: Publishing %d data jobs (%d facilities) to NATS queue...", totalJobs, len(facilities))
 
 	// Initialize parallel phases as pending
-	initialPhases := []PhaseProgress{
-		{Phase: "mops", Status: "pending"},
-		{Phase: "mos", Status: "pending"},
-		{Phase: "cos", Status: "pending"},
+	initialPhases := make([]PhaseProgress, 0, len(snapshotDataTypes))
+	for _, dataType := range snapshotDataTypes {
+		initialPhases = append(initialPhases, PhaseProgress{Phase: dataType, Status: "pending"})
 	}
 
-	// Create phase records in database for tracking
-	dataTypes := []string{"mops", "mos", "cos"}
+	// Create phase records in database for tracking (one per data type across all facilities)
+	dataTypes := snapshotDataTypes
 	for _, phaseType := range dataTypes {
 		if err := w.db.CreateRefreshJobPhase(ctx, req.JobID, phaseType); err != nil {
 			log.Printf("Warning: failed to create phase record for %s: %v", phaseType, err)
@@ -755,61 +957,135 @@ func (w *SnapshotWorker) publishDataJobs(req SnapshotRefreshMessage) error {
 	}
 
 	w.publishDetailedProgress(req.JobID, "running", "Publishing data jobs", "Distributing work to workers",
-		1, 4, 25, 0, 0, 0, initialPhases, nil, 0, 0, 0, 3)
-
-	// Publish 3 jobs (one per data type)
-
-	for _, dataType := range dataTypes {
-		job := DataBatchJobMessage{
-			JobID:       fmt.Sprintf("%s-%s", req.JobID, dataType),
-			ParentJobID: req.JobID,
-			DataType:    dataType,
-			Environment: req.Environment,
-			AccessToken: req.AccessToken,
-			Company:     req.Company,
-			Facility:    req.Facility,
-			Language:    req.Language,
-		}
+		1, 4, 25, 0, 0, 0, initialPhases, nil, 0, 0, 0, totalJobs)
 
-		data, _ := json.Marshal(job)
-		subject := queue.GetBatchSubject(req.Environment, dataType)
-		if err := w.nats.Publish(subject, data); err != nil {
-			errMsg := fmt.Sprintf("Failed to publish %s job: %v", dataType, err)
-			w.publishError(req.JobID, errMsg)
-			w.db.FailJob(ctx, req.JobID, err.Error())
-			return fmt.Errorf(errMsg)
+	// Publish one job per data type and facility
+	for _, facility := range facilities {
+		for _, dataType := range dataTypes {
+			job := newDataBatchJob(req, dataType, facility)
+
+			data, _ := json.Marshal(job)
+			subject := queue.GetBatchSubject(req.Environment, dataType)
+			if err := w.nats.Publish(subject, data); err != nil {
+				errMsg := fmt.Sprintf("Failed to publish %s job for facility %s: %v", dataType, facility, err)
+				w.publishError(req.JobID, errMsg)
+				w.db.FailJob(ctx, req.JobID, err.Error())
+				return fmt.Errorf(errMsg)
+			}
 		}
 	}
 
-	log.Printf("Published 3 data jobs, waiting for completion...")
+	log.Printf("Published %d data jobs, waiting for completion...", totalJobs)
 
-	// Phase 2: Wait for all 3 jobs to complete
+	// Phase 2: Wait for all jobs to complete
 	return w.waitForDataJobs(req)
 }
 
-// waitForDataJobs waits for exactly 3 data jobs to complete, then runs finalize and detection
+// waitForDataJobs waits for every data type/facility job to complete, then runs finalize and detection
+// Each data type is shown as one phase; it completes once all of its facility jobs have completed
+// A job that fails, or whose worker stops sending heartbeats, is redelivered up to
+// data_job_max_attempts times; the redelivered job resumes from its Compass checkpoint
 func (w *SnapshotWorker) waitForDataJobs(req SnapshotRefreshMessage) error {
 	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Minute)
 	defer cancel()
 
-	log.Printf("Phase 2: Waiting for 3 data jobs to complete...")
+	// Checkpoints only matter while this refresh is loading
+	defer func() {
+		if err := w.db.DeleteCompassQueryCheckpointsForJob(context.Background(), req.JobID); err != nil {
+			log.Printf("Warning: %v", err)
+		}
+	}()
+
+	facilities := req.FacilityList()
+	facilityCount := len(facilities)
+	totalJobs := len(snapshotDataTypes) * facilityCount
+
+	log.Printf("Phase 2: Waiting for %d data jobs to complete...", totalJobs)
 
 	// Track completions
 	completedJobs := 0
-	recordsByType := map[string]int{
-		"mops": 0,
-		"mos":  0,
-		"cos":  0,
-	}
+	recordsByType := make(map[string]int)
+	completedByType := make(map[string]int)
+
+	// Running record counts per data type and facility (for in-flight progress)
+	facilityRecords := make(map[string]map[string]int)
 
 	// Track parallel phase states
-	phaseStates := map[string]*PhaseProgress{
-		"mops": {Phase: "mops", Status: "pending"},
-		"mos":  {Phase: "mos", Status: "pending"},
-		"cos":  {Phase: "cos", Status: "pending"},
+	phaseStates := make(map[string]*PhaseProgress)
+	for _, dataType := range snapshotDataTypes {
+		facilityRecords[dataType] = make(map[string]int)
+		phaseStates[dataType] = &PhaseProgress{Phase: dataType, Status: "pending"}
 	}
 	var mu sync.Mutex
 
+	// Delivery state per data job, for redelivering failed or lost jobs
+	maxAttempts := services.LoadSystemSettingInt(w.db, req.Environment, "data_job_max_attempts", 3)
+	attempts := make(map[string]int)       // job ID -> current attempt
+	started := make(map[string]bool)       // job ID -> current attempt was picked up by a worker
+	lastSeen := make(map[string]time.Time) // job ID -> last start, heartbeat or progress of the current attempt
+	doneJobs := make(map[string]bool)      // job ID -> completed successfully
+	for _, facility := range facilities {
+		for _, dataType := range snapshotDataTypes {
+			attempts[dataJobID(req.JobID, dataType, facility)] = 1
+		}
+	}
+
+	// phaseSnapshot converts phase states to slice for JSON (caller holds mu)
+	phaseSnapshot := func() []PhaseProgress {
+		parallelPhases := make([]PhaseProgress, 0, len(snapshotDataTypes))
+		for _, phase := range snapshotDataTypes {
+			parallelPhases = append(parallelPhases, *phaseStates[phase])
+		}
+		return parallelPhases
+	}
+
+	// redeliverJob republishes a failed or lost data job, which resumes from its checkpoint
+	// Returns false once the job has used all its attempts (caller holds mu)
+	redeliverJob := func(dataType, facility, reason string) bool {
+		jobID := dataJobID(req.JobID, dataType, facility)
+		if attempts[jobID] >= maxAttempts {
+			return false
+		}
+
+		job := newDataBatchJob(req, dataType, facility)
+		job.Attempt = attempts[jobID] + 1
+		log.Printf("Redelivering data job %s (attempt %d/%d): %s", jobID, job.Attempt, maxAttempts, reason)
+
+		data, _ := json.Marshal(job)
+		if err := w.nats.Publish(queue.GetBatchSubject(req.Environment, dataType), data); err != nil {
+			log.Printf("Failed to redeliver data job %s: %v", jobID, err)
+			return false
+		}
+
+		attempts[jobID] = job.Attempt
+		started[jobID] = false
+		lastSeen[jobID] = time.Now()
+		phaseStates[dataType].CurrentOperation = fmt.Sprintf("Retrying %s (attempt %d/%d)", facility, job.Attempt, maxAttempts)
+		return true
+	}
+
+	// failDataJob fails the refresh because a data job could not be loaded (caller holds mu)
+	failDataJob := func(dataType, facility, errText string) {
+		errMsg := fmt.Sprintf("Data job %s failed for facility %s: %s", dataType, facility, errText)
+		log.Printf(errMsg)
+		started[dataJobID(req.JobID, dataType, facility)] = false
+
+		// Update phase state to failed
+		phaseStates[dataType].Status = "failed"
+		phaseStates[dataType].Error = errText
+		phaseStates[dataType].EndTime = time.Now()
+
+		// Persist phase failure to database
+		dbCtx := context.Background()
+		if err := w.db.FailRefreshJobPhase(dbCtx, req.JobID, dataType, errText); err != nil {
+			log.Printf("Warning: failed to persist phase failure for %s: %v", dataType, err)
+		}
+
+		w.publishError(req.JobID, errMsg)
+		w.db.FailJob(ctx, req.JobID, errText)
+		cancel() // Cancel context to abort
+	}
+
 	// Subscribe to batch start events
 	startSubject := queue.GetBatchStartSubject(req.JobID)
 	startSub, err := w.nats.Subscribe(startSubject, func(msg *nats.Msg) {
@@ -822,38 +1098,40 @@ func (w *SnapshotWorker) waitForDataJobs(req SnapshotRefreshMessage) error {
 		mu.Lock()
 		defer mu.Unlock()
 
-		// Update phase state to running
-		phaseStates[start.DataType].Status = "running"
-		phaseStates[start.DataType].StartTime = start.StartTime
-
-		// Persist phase start to database
-		dbCtx := context.Background()
-		if err := w.db.StartRefreshJobPhase(dbCtx, req.JobID, start.DataType); err != nil {
-			log.Printf("Warning: failed to persist phase start for %s: %v", start.DataType, err)
-			// Non-fatal, continue
+		jobID := dataJobID(req.JobID, start.DataType, start.Facility)
+		if start.Attempt == 0 || start.Attempt == attempts[jobID] {
+			started[jobID] = true
+			lastSeen[jobID] = time.Now()
 		}
 
-		log.Printf("Data job started: %s", start.DataType)
+		// First facility to start moves the phase to running
+		if phaseStates[start.DataType].Status == "pending" {
+			phaseStates[start.DataType].Status = "running"
+			phaseStates[start.DataType].StartTime = start.StartTime
 
-		// Convert phase states to slice for JSON
-		parallelPhases := make([]PhaseProgress, 0, 3)
-		for _, phase := range []string{"mops", "mos", "cos"} {
-			parallelPhases = append(parallelPhases, *phaseStates[phase])
+			// Persist phase start to database
+			dbCtx := context.Background()
+			if err := w.db.StartRefreshJobPhase(dbCtx, req.JobID, start.DataType); err != nil {
+				log.Printf("Warning: failed to persist phase start for %s: %v", start.DataType, err)
+				// Non-fatal, continue
+			}
 		}
 
+		log.Printf("Data job started: %s (facility %s)", start.DataType, start.Facility)
+
 		// Send progress update showing running status
 		totalMops := recordsByType["mops"]
 		totalMos := recordsByType["mos"]
 		totalCos := recordsByType["cos"]
-		progress := 25 + (completedJobs * 15)
+		progress := 25 + (completedJobs * 45 / totalJobs)
 
 		w.publishDetailedProgress(req.JobID, "running", "Loading data",
-			fmt.Sprintf("Loading %s", start.DataType),
+			fmt.Sprintf("Loading %s for %s", start.DataType, start.Facility),
 			2, 4, progress,
 			totalCos, totalMos, totalMops,
-			parallelPhases,
+			phaseSnapshot(),
 			nil,
-			0, 0, completedJobs, 3)
+			0, 0, completedJobs, totalJobs)
 	})
 
 	if err != nil {
@@ -873,31 +1151,36 @@ func (w *SnapshotWorker) waitForDataJobs(req SnapshotRefreshMessage) error {
 		mu.Lock()
 		defer mu.Unlock()
 
+		lastSeen[subProgress.JobID] = time.Now()
+
 		// Ignore updates after phase completed (race condition protection)
 		if phaseStates[subProgress.DataType].Status == "completed" ||
 			phaseStates[subProgress.DataType].Status == "failed" {
 			return
 		}
 
-		// Update phase state with current operation
-		phaseStates[subProgress.DataType].CurrentOperation = subProgress.CurrentOperation
+		// Update phase state with current operation and the running count across facilities
+		operation := subProgress.CurrentOperation
+		if facilityCount > 1 {
+			operation = fmt.Sprintf("%s: %s", subProgress.Facility, subProgress.CurrentOperation)
+		}
+		phaseStates[subProgress.DataType].CurrentOperation = operation
 		if subProgress.RecordCount > 0 {
-			phaseStates[subProgress.DataType].RecordCount = subProgress.RecordCount
+			facilityRecords[subProgress.DataType][subProgress.Facility] = subProgress.RecordCount
+			total := 0
+			for _, count := range facilityRecords[subProgress.DataType] {
+				total += count
+			}
+			phaseStates[subProgress.DataType].RecordCount = total
 		}
 
-		log.Printf("Phase %s: %s", subProgress.DataType, subProgress.CurrentOperation)
-
-		// Aggregate and publish progress update
-		parallelPhases := make([]PhaseProgress, 0, 3)
-		for _, phase := range []string{"mops", "mos", "cos"} {
-			parallelPhases = append(parallelPhases, *phaseStates[phase])
-		}
+		log.Printf("Phase %s: %s", subProgress.DataType, operation)
 
-		progress := 25 + (completedJobs * 15)
+		progress := 25 + (completedJobs * 45 / totalJobs)
 		w.publishDetailedProgress(req.JobID, "running", "Loading data",
-			subProgress.CurrentOperation, 2, 4, progress,
+			operation, 2, 4, progress,
 			recordsByType["cos"], recordsByType["mos"], recordsByType["mops"],
-			parallelPhases, nil, 0, 0, completedJobs, 3)
+			phaseSnapshot(), nil, 0, 0, completedJobs, totalJobs)
 	})
 
 	if err != nil {
@@ -905,6 +1188,28 @@ func (w *SnapshotWorker) waitForDataJobs(req SnapshotRefreshMessage) error {
 	}
 	defer subProgressSub.Unsubscribe()
 
+	// Subscribe to heartbeats from running data jobs
+	heartbeatSubject := queue.GetBatchHeartbeatSubject(req.JobID)
+	heartbeatSub, err := w.nats.Subscribe(heartbeatSubject, func(msg *nats.Msg) {
+		var heartbeat BatchHeartbeatMessage
+		if err := json.Unmarshal(msg.Data, &heartbeat); err != nil {
+			log.Printf("Failed to parse batch heartbeat: %v", err)
+			return
+		}
+
+		mu.Lock()
+		defer mu.Unlock()
+
+		if heartbeat.Attempt == 0 || heartbeat.Attempt == attempts[heartbeat.JobID] {
+			lastSeen[heartbeat.JobID] = time.Now()
+		}
+	})
+
+	if err != nil {
+		return fmt.Errorf("failed to subscribe to batch heartbeats: %w", err)
+	}
+	defer heartbeatSub.Unsubscribe()
+
 	// Subscribe to completion events
 	completeSubject := queue.GetBatchCompleteSubject(req.JobID)
 	subscription, err := w.nats.Subscribe(completeSubject, func(msg *nats.Msg) {
@@ -917,66 +1222,64 @@ func (w *SnapshotWorker) waitForDataJobs(req SnapshotRefreshMessage) error {
 		mu.Lock()
 		defer mu.Unlock()
 
-		if !completion.Success {
-			errMsg := fmt.Sprintf("Data job %s failed: %s", completion.DataType, completion.Error)
-			log.Printf(errMsg)
-
-			// Update phase state to failed
-			phaseStates[completion.DataType].Status = "failed"
-			phaseStates[completion.DataType].Error = completion.Error
-			phaseStates[completion.DataType].EndTime = time.Now()
+		// A redelivered job can complete twice if the earlier attempt was only slow
+		jobID := dataJobID(req.JobID, completion.DataType, completion.Facility)
+		if doneJobs[jobID] {
+			log.Printf("Ignoring duplicate completion of data job %s (attempt %d)", jobID, completion.Attempt)
+			return
+		}
 
-			// Persist phase failure to database
-			dbCtx := context.Background()
-			if err := w.db.FailRefreshJobPhase(dbCtx, req.JobID, completion.DataType, completion.Error); err != nil {
-				log.Printf("Warning: failed to persist phase failure for %s: %v", completion.DataType, err)
+		if !completion.Success {
+			// Failures of attempts that were already redelivered are stale
+			if completion.Attempt != 0 && completion.Attempt < attempts[jobID] {
+				log.Printf("Ignoring failure of superseded attempt %d of data job %s: %s", completion.Attempt, jobID, completion.Error)
+				return
 			}
-
-			w.publishError(req.JobID, errMsg)
-			w.db.FailJob(ctx, req.JobID, completion.Error)
-			cancel() // Cancel context to abort
+			if redeliverJob(completion.DataType, completion.Facility, completion.Error) {
+				return
+			}
+			failDataJob(completion.DataType, completion.Facility, completion.Error)
 			return
 		}
 
-		// Update phase state to completed
-		phaseStates[completion.DataType].Status = "completed"
-		phaseStates[completion.DataType].CurrentOperation = "" // Clear transient operation
-		phaseStates[completion.DataType].RecordCount = completion.RecordCount
-		phaseStates[completion.DataType].EndTime = time.Now()
+		doneJobs[jobID] = true
+		completedJobs++
+		completedByType[completion.DataType]++
+		recordsByType[completion.DataType] += completion.RecordCount
+		facilityRecords[completion.DataType][completion.Facility] = completion.RecordCount
+		phaseStates[completion.DataType].RecordCount = recordsByType[completion.DataType]
+
+		// Phase completes once every facility has loaded this data type
+		if completedByType[completion.DataType] >= facilityCount {
+			phaseStates[completion.DataType].Status = "completed"
+			phaseStates[completion.DataType].CurrentOperation = "" // Clear transient operation
+			phaseStates[completion.DataType].EndTime = time.Now()
 
-		// Persist phase completion to database
-		dbCtx := context.Background()
-		if err := w.db.CompleteRefreshJobPhase(dbCtx, req.JobID, completion.DataType, completion.RecordCount); err != nil {
-			log.Printf("Warning: failed to persist phase completion for %s: %v", completion.DataType, err)
-			// Non-fatal, continue
+			// Persist phase completion to database
+			dbCtx := context.Background()
+			if err := w.db.CompleteRefreshJobPhase(dbCtx, req.JobID, completion.DataType, recordsByType[completion.DataType]); err != nil {
+				log.Printf("Warning: failed to persist phase completion for %s: %v", completion.DataType, err)
+				// Non-fatal, continue
+			}
 		}
 
-		completedJobs++
-		recordsByType[completion.DataType] = completion.RecordCount
-
 		totalMops := recordsByType["mops"]
 		totalMos := recordsByType["mos"]
 		totalCos := recordsByType["cos"]
 
 		// Calculate progress
-		progress := 25 + (completedJobs * 15) // 25% base + 15% per job (up to 70%)
-
-		log.Printf("Data job completed: %s (%d records), total: %d/3 jobs",
-			completion.DataType, completion.RecordCount, completedJobs)
+		progress := 25 + (completedJobs * 45 / totalJobs) // 25% base + up to 45% for loading
 
-		// Convert phase states to slice for JSON
-		parallelPhases := make([]PhaseProgress, 0, 3)
-		for _, phase := range []string{"mops", "mos", "cos"} {
-			parallelPhases = append(parallelPhases, *phaseStates[phase])
-		}
+		log.Printf("Data job completed: %s for %s (%d records), total: %d/%d jobs",
+			completion.DataType, completion.Facility, completion.RecordCount, completedJobs, totalJobs)
 
 		w.publishDetailedProgress(req.JobID, "running", "Loading data",
-			fmt.Sprintf("Loaded %s", completion.DataType),
+			fmt.Sprintf("Loaded %s for %s", completion.DataType, completion.Facility),
 			2, 4, progress,
 			totalCos, totalMos, totalMops,
-			parallelPhases,
+			phaseSnapshot(),
 			nil,
-			0, 0, completedJobs, 3)
+			0, 0, completedJobs, totalJobs)
 	})
 
 	if err != nil {
@@ -984,7 +1287,7 @@ func (w *SnapshotWorker) waitForDataJobs(req SnapshotRefreshMessage) error {
 	}
 	defer subscription.Unsubscribe()
 
-	// Wait for all 3 jobs to complete
+	// Wait for all jobs to complete
 	ticker := time.NewTicker(1 * time.Second)
 	defer ticker.Stop()
 
@@ -994,16 +1297,30 @@ func (w *SnapshotWorker) waitForDataJobs(req SnapshotRefreshMessage) error {
 			mu.Lock()
 			completed := completedJobs
 			mu.Unlock()
-			if completed < 3 {
-				return fmt.Errorf("timeout waiting for data jobs (completed %d/3)", completed)
+			if completed < totalJobs {
+				return fmt.Errorf("timeout waiting for data jobs (completed %d/%d)", completed, totalJobs)
 			}
 		case <-ticker.C:
 			mu.Lock()
 			completed := completedJobs
+
+			// A started job that has gone silent lost its worker (e.g. a restart); queued jobs are not checked
+			for _, facility := range facilities {
+				for _, dataType := range snapshotDataTypes {
+					jobID := dataJobID(req.JobID, dataType, facility)
+					if doneJobs[jobID] || !started[jobID] || time.Since(lastSeen[jobID]) < dataJobStallTimeout {
+						continue
+					}
+					reason := fmt.Sprintf("no heartbeat for %v", dataJobStallTimeout)
+					if !redeliverJob(dataType, facility, reason) {
+						failDataJob(dataType, facility, "worker stopped responding: "+reason)
+					}
+				}
+			}
 			mu.Unlock()
 
-			if completed >= 3 {
-				log.Printf("All 3 data jobs completed")
+			if completed >= totalJobs {
+				log.Printf("All %d data jobs completed", totalJobs)
 				mu.Lock()
 				totalMops := recordsByType["mops"]
 				totalMos := recordsByType["mos"]
@@ -1032,20 +1349,38 @@ func (w *SnapshotWorker) runFinalize(req SnapshotRefreshMessage, totalCos, total
 		nil,
 		0, 0, 0, 0)
 
-	if err := w.db.UpdateProductionOrdersFromMOPs(ctx); err != nil {
+	staging := w.db.Staging()
+
+	if err := staging.UpdateProductionOrdersFromMOPs(ctx); err != nil {
 		errMsg := fmt.Sprintf("Finalize MOPs failed: %v", err)
 		w.publishError(req.JobID, errMsg)
 		w.db.FailJob(ctx, req.JobID, err.Error())
 		return fmt.Errorf(errMsg)
 	}
 
-	if err := w.db.UpdateProductionOrdersFromMOs(ctx); err != nil {
+	if err := staging.UpdateProductionOrdersFromMOs(ctx); err != nil {
 		errMsg := fmt.Sprintf("Finalize MOs failed: %v", err)
 		w.publishError(req.JobID, errMsg)
 		w.db.FailJob(ctx, req.JobID, err.Error())
 		return fmt.Errorf(errMsg)
 	}
 
+	// Switch the environment's live data to the new generation in one transaction
+	w.publishDetailedProgress(req.JobID, "running", "Finalizing data", "Switching to new snapshot",
+		3, 4, 80,
+		totalCos, totalMos, totalMops,
+		nil,
+		nil,
+		0, 0, 0, 0)
+
+	if err := w.db.PromoteSnapshotStaging(ctx, req.Environment); err != nil {
+		errMsg := fmt.Sprintf("Snapshot switch failed: %v", err)
+		w.publishError(req.JobID, errMsg)
+		w.db.FailJob(ctx, req.JobID, err.Error())
+		return fmt.Errorf("snapshot switch failed: %w", err)
+	}
+	log.Printf("Promoted staging snapshot to live for environment %s", req.Environment)
+
 	// Phase 4: Parallel Detection via NATS
 	log.Printf("Phase 4: Publishing detector jobs for job %s", req.JobID)
 	w.publishDetailedProgress(req.JobID, "running", "Starting issue detection", "Publishing detector jobs",
@@ -1144,6 +1479,7 @@ func (w *SnapshotWorker) publishDetectorJobs(req SnapshotRefreshMessage, totalCo
 			Environment:  req.Environment,
 			Company:      req.Company,
 			Facility:     req.Facility,
+			Facilities:   req.Facilities,
 		}
 
 		data, _ := json.Marshal(job)
@@ -1396,20 +1732,34 @@ func (w *SnapshotWorker) waitForDetectorJobs(req SnapshotRefreshMessage, totalDe
 
 				log.Printf("Detection complete: %d total issues found across %d detectors", totalIssues, len(issues))
 
-				// Run anomaly detection after issue detection completes
-				log.Printf("Running anomaly detection for job %s", req.JobID)
 				detectorConfigService := services.NewDetectorConfigService(w.db)
 				detectionService := services.NewDetectionService(w.db, detectorConfigService)
-				if err := detectionService.RunAnomalyDetectors(dbCtx, req.JobID, req.Environment, req.Company, req.Facility); err != nil {
+
+				// Diff against the previous job: new, persisting and resolved issues
+				detectionService.TrackIssueLifecycle(dbCtx, req.JobID, req.Environment, req.FacilityList(), issues)
+
+				// Run anomaly detection after issue detection completes
+				log.Printf("Running anomaly detection for job %s", req.JobID)
+				if err := detectionService.RunAnomalyDetectors(dbCtx, req.JobID, req.Environment, req.Company, req.FacilityList()); err != nil {
 					log.Printf("Anomaly detection failed: %v", err)
 					// Don't fail the job if anomaly detection fails
 				} else {
 					log.Printf("Anomaly detection completed for job %s", req.JobID)
 				}
 
-				// Mark refresh job complete
+				// Notify subscribers of new anomalies and issues
+				w.notifier.NotifyJob(dbCtx, req.Environment, req.JobID)
+
+				// Mark refresh job complete - issue queries switch to this job's results
 				w.db.CompleteJob(dbCtx, req.JobID)
 
+				w.recordIssueHistory(dbCtx, req.Environment, req.JobID)
+
+				// Drop the previous job's issues now that they are no longer displayed
+				if err := w.db.PruneIssuesExceptJob(dbCtx, req.Environment, req.JobID); err != nil {
+					log.Printf("Warning: failed to prune previous issues: %v", err)
+				}
+
 				statusMsg := "Data refresh completed"
 				if failed > 0 {
 					statusMsg = fmt.Sprintf("Data refresh completed (%d detectors failed)", failed)
@@ -1436,6 +1786,19 @@ func (w *SnapshotWorker) waitForDetectorJobs(req SnapshotRefreshMessage, totalDe
 	}
 }
 
+// recordIssueHistory retains the completed job's issues for trend analytics and prunes expired history
+func (w *SnapshotWorker) recordIssueHistory(ctx context.Context, environment, jobID string) {
+	if err := w.db.RecordIssueHistory(ctx, environment, jobID); err != nil {
+		log.Printf("Warning: failed to record issue history: %v", err)
+		return
+	}
+
+	retentionDays := services.LoadSystemSettingInt(w.db, environment, "issue_history_retention_days", 90)
+	if err := w.db.PruneIssueHistory(ctx, environment, retentionDays); err != nil {
+		log.Printf("Warning: failed to prune issue history: %v", err)
+	}
+}
+
 // coordinateManualDetection coordinates a manual detection job, aggregating detector progress and publishing updates
 func (w *SnapshotWorker) coordinateManualDetection(req DetectorCoordinatorMessage) error {
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Minute)
@@ -1627,22 +1990,30 @@ func (w *SnapshotWorker) coordinateManualDetection(req DetectorCoordinatorMessag
 					log.Printf("Warning: failed to complete detection job: %v", err)
 				}
 
+				// Diff against the previous job: new, persisting and resolved issues
+				detectionService.TrackIssueLifecycle(dbCtx, req.JobID, req.Environment, req.FacilityList(), issues)
+
 				// Mark refresh job as completed
 				if err := w.db.CompleteJob(dbCtx, req.JobID); err != nil {
 					log.Printf("Warning: failed to complete refresh job: %v", err)
 				}
 
+				w.recordIssueHistory(dbCtx, req.Environment, req.JobID)
+
 				log.Printf("Detection complete: %d total issues found across %d detectors", totalIssues, len(issues))
 
 				// Run anomaly detection after issue detection completes
 				log.Printf("Running anomaly detection for job %s", req.JobID)
-				if err := detectionService.RunAnomalyDetectors(dbCtx, req.JobID, req.Environment, req.Company, req.Facility); err != nil {
+				if err := detectionService.RunAnomalyDetectors(dbCtx, req.JobID, req.Environment, req.Company, req.FacilityList()); err != nil {
 					log.Printf("Anomaly detection failed: %v", err)
 					// Don't fail the job if anomaly detection fails
 				} else {
 					log.Printf("Anomaly detection completed for job %s", req.JobID)
 				}
 
+				// Notify subscribers of new anomalies and issues
+				w.notifier.NotifyJob(dbCtx, req.Environment, req.JobID)
+
 				// Convert final detector states to slice for JSON
 				parallelDetectors := make([]DetectorProgress, 0, len(detectorStates))
 				for _, name := range req.DetectorNames {
@@ -0,0 +1,2 @@
+-- Remove snapshot refresh mode setting
+DELETE FROM system_settings WHERE setting_key = 'snapshot_refresh_mode';
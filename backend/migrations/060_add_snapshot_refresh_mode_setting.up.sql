@@ -0,0 +1,9 @@
+-- Default snapshot refresh mode
+-- 'full' truncates and reloads all snapshot tables
+-- 'incremental' loads only MWOHED/MMOPLP/OOLINE rows changed since the last sync (LMDT)
+-- and removes rows deleted or closed in M3; falls back to full when the previous refresh did not complete
+INSERT INTO system_settings (environment, setting_key, setting_value, setting_type, description, category, created_at)
+VALUES
+    ('TRN', 'snapshot_refresh_mode', 'full', 'string', 'Refresh Mode: "full" reloads all data, "incremental" loads only rows changed in M3 since the last successful refresh', 'data_refresh', NOW()),
+    ('PRD', 'snapshot_refresh_mode', 'full', 'string', 'Refresh Mode: "full" reloads all data, "incremental" loads only rows changed in M3 since the last successful refresh', 'data_refresh', NOW())
+ON CONFLICT (environment, setting_key) DO NOTHING;
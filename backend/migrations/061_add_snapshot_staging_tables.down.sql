@@ -0,0 +1,5 @@
+-- Drop snapshot staging tables
+DROP TABLE IF EXISTS production_orders_staging;
+DROP TABLE IF EXISTS planned_manufacturing_orders_staging;
+DROP TABLE IF EXISTS manufacturing_orders_staging;
+DROP TABLE IF EXISTS customer_order_lines_staging;
@@ -0,0 +1,29 @@
+-- ========================================
+-- Snapshot Staging Tables (blue/green refresh)
+-- ========================================
+-- Snapshot refreshes load into these tables and the worker copies an environment's
+-- rows into the live tables in a single transaction only after finalize succeeds.
+-- A failed or cancelled refresh leaves the live tables on the previous snapshot.
+--
+-- LIKE ... INCLUDING ALL copies columns, defaults (including the shared id sequences,
+-- so ids stay unique across both generations), constraints and indexes.
+-- Future migrations that add columns to a live snapshot table must add them here too.
+
+CREATE TABLE IF NOT EXISTS customer_order_lines_staging (LIKE customer_order_lines INCLUDING ALL);
+CREATE TABLE IF NOT EXISTS manufacturing_orders_staging (LIKE manufacturing_orders INCLUDING ALL);
+CREATE TABLE IF NOT EXISTS planned_manufacturing_orders_staging (LIKE planned_manufacturing_orders INCLUDING ALL);
+CREATE TABLE IF NOT EXISTS production_orders_staging (LIKE production_orders INCLUDING ALL);
+
+-- Foreign keys are not copied by LIKE; point staging production orders at staging MOs/MOPs
+ALTER TABLE production_orders_staging
+    ADD CONSTRAINT fk_production_orders_staging_mo
+    FOREIGN KEY (mo_id) REFERENCES manufacturing_orders_staging(id) ON DELETE CASCADE;
+
+ALTER TABLE production_orders_staging
+    ADD CONSTRAINT fk_production_orders_staging_mop
+    FOREIGN KEY (mop_id) REFERENCES planned_manufacturing_orders_staging(id) ON DELETE CASCADE;
+
+COMMENT ON TABLE customer_order_lines_staging IS 'Staging generation of customer_order_lines, promoted to live after a successful snapshot refresh';
+COMMENT ON TABLE manufacturing_orders_staging IS 'Staging generation of manufacturing_orders, promoted to live after a successful snapshot refresh';
+COMMENT ON TABLE planned_manufacturing_orders_staging IS 'Staging generation of planned_manufacturing_orders, promoted to live after a successful snapshot refresh';
+COMMENT ON TABLE production_orders_staging IS 'Staging generation of production_orders, promoted to live after a successful snapshot refresh';
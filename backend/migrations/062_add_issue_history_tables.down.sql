@@ -0,0 +1,4 @@
+-- Remove issue history tables and retention setting
+DELETE FROM system_settings WHERE setting_key = 'issue_history_retention_days';
+DROP TABLE IF EXISTS issue_history;
+DROP TABLE IF EXISTS issue_count_history;
@@ -0,0 +1,54 @@
+-- ========================================
+-- Issue History for Trend Analytics
+-- ========================================
+-- detected_issues only keeps the latest job's issues. These tables retain what each
+-- completed refresh found so trends can be charted over time.
+
+-- Aggregated counts per completed job, detector, facility and warehouse
+CREATE TABLE issue_count_history (
+    id BIGSERIAL PRIMARY KEY,
+    environment VARCHAR(10) NOT NULL,
+    job_id VARCHAR(36) NOT NULL,
+    detector_type VARCHAR(100) NOT NULL,
+    facility VARCHAR(10) NOT NULL,
+    warehouse VARCHAR(10) NOT NULL DEFAULT '',
+    issue_count INTEGER NOT NULL,
+    recorded_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    CONSTRAINT uq_issue_count_history UNIQUE (job_id, detector_type, facility, warehouse)
+);
+
+CREATE INDEX idx_issue_count_history_env_recorded ON issue_count_history(environment, recorded_at DESC);
+CREATE INDEX idx_issue_count_history_detector ON issue_count_history(environment, detector_type);
+
+-- One row per issue per completed job (per-issue history keyed by the stable issue_key)
+CREATE TABLE issue_history (
+    id BIGSERIAL PRIMARY KEY,
+    environment VARCHAR(10) NOT NULL,
+    job_id VARCHAR(36) NOT NULL,
+    detector_type VARCHAR(100) NOT NULL,
+    facility VARCHAR(10) NOT NULL,
+    warehouse VARCHAR(10),
+    issue_key VARCHAR(200) NOT NULL,
+    production_order_number VARCHAR(50),
+    production_order_type VARCHAR(10),
+    co_number VARCHAR(50),
+    co_line VARCHAR(50),
+    recorded_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX idx_issue_history_key ON issue_history(environment, detector_type, issue_key);
+CREATE INDEX idx_issue_history_env_recorded ON issue_history(environment, recorded_at DESC);
+CREATE INDEX idx_issue_history_job ON issue_history(job_id);
+
+COMMENT ON TABLE issue_count_history IS 'Issue counts per detector/facility/warehouse for every completed refresh job';
+COMMENT ON TABLE issue_history IS 'Per-issue occurrence history across completed refresh jobs, keyed by issue_key';
+
+-- Retention for history rows
+INSERT INTO system_settings (environment, setting_key, setting_value, setting_type, description, category, constraints) VALUES
+    ('TRN', 'issue_history_retention_days', '90', 'integer',
+     'Issue History Retention: Number of days per-issue history and issue count trends are kept',
+     'detection', '{"min": 7, "max": 1825, "unit": "days"}'::jsonb),
+    ('PRD', 'issue_history_retention_days', '365', 'integer',
+     'Issue History Retention: Number of days per-issue history and issue count trends are kept',
+     'detection', '{"min": 7, "max": 1825, "unit": "days"}'::jsonb)
+ON CONFLICT (environment, setting_key) DO NOTHING;
@@ -0,0 +1,7 @@
+-- Remove issue lifecycle tracking
+ALTER TABLE issue_detection_jobs
+    DROP COLUMN IF EXISTS new_issues,
+    DROP COLUMN IF EXISTS persisting_issues,
+    DROP COLUMN IF EXISTS resolved_issues;
+
+DROP TABLE IF EXISTS issue_lifecycle;
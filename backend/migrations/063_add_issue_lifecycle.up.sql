@@ -0,0 +1,39 @@
+-- ========================================
+-- Issue Lifecycle Tracking
+-- ========================================
+-- issue_key is stable across refreshes, so each refresh can be diffed against the
+-- previous one to tell new, persisting and resolved issues apart.
+
+CREATE TABLE issue_lifecycle (
+    id BIGSERIAL PRIMARY KEY,
+    environment VARCHAR(10) NOT NULL,
+    detector_type VARCHAR(100) NOT NULL,
+    issue_key VARCHAR(200) NOT NULL,
+    facility VARCHAR(10) NOT NULL,
+    status VARCHAR(20) NOT NULL DEFAULT 'open',
+    first_seen_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    first_seen_job_id VARCHAR(36) NOT NULL,
+    last_seen_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    last_seen_job_id VARCHAR(36) NOT NULL,
+    resolved_at TIMESTAMP,
+    resolved_job_id VARCHAR(36),
+    CONSTRAINT uq_issue_lifecycle UNIQUE (environment, detector_type, issue_key),
+    CONSTRAINT chk_issue_lifecycle_status CHECK (status IN ('open', 'resolved'))
+);
+
+CREATE INDEX idx_issue_lifecycle_env_status ON issue_lifecycle(environment, status);
+CREATE INDEX idx_issue_lifecycle_first_seen_job ON issue_lifecycle(first_seen_job_id);
+CREATE INDEX idx_issue_lifecycle_resolved_at ON issue_lifecycle(environment, resolved_at DESC) WHERE status = 'resolved';
+
+COMMENT ON TABLE issue_lifecycle IS 'First/last seen and resolution state of each issue_key across refresh jobs';
+COMMENT ON COLUMN issue_lifecycle.first_seen_job_id IS 'Job the issue (re)appeared in; equals the current job for issues new since the last refresh';
+
+-- Per-job lifecycle summary
+ALTER TABLE issue_detection_jobs
+    ADD COLUMN new_issues INTEGER DEFAULT 0,
+    ADD COLUMN persisting_issues INTEGER DEFAULT 0,
+    ADD COLUMN resolved_issues INTEGER DEFAULT 0;
+
+COMMENT ON COLUMN issue_detection_jobs.new_issues IS 'Issue keys not open in the previous job';
+COMMENT ON COLUMN issue_detection_jobs.persisting_issues IS 'Issue keys also open in the previous job';
+COMMENT ON COLUMN issue_detection_jobs.resolved_issues IS 'Issue keys open in the previous job that this job no longer found';
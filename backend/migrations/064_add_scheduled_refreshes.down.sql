@@ -0,0 +1,3 @@
+-- Remove scheduled refreshes
+DELETE FROM system_settings WHERE setting_key IN ('scheduled_refresh_enabled', 'scheduled_refreshes');
+DROP TABLE IF EXISTS scheduled_refresh_runs;
@@ -0,0 +1,39 @@
+-- ========================================
+-- Scheduled Snapshot Refreshes
+-- ========================================
+-- The refresh scheduler reads cron schedules from system_settings and publishes
+-- SnapshotRefreshMessage using service account tokens. Each fired schedule minute is
+-- claimed in scheduled_refresh_runs so multiple server instances never start it twice.
+
+CREATE TABLE scheduled_refresh_runs (
+    id BIGSERIAL PRIMARY KEY,
+    environment VARCHAR(10) NOT NULL,
+    schedule_name VARCHAR(100) NOT NULL,
+    scheduled_for TIMESTAMP NOT NULL,
+    company VARCHAR(10) NOT NULL,
+    facility VARCHAR(10) NOT NULL,
+    job_id VARCHAR(36),
+    status VARCHAR(20) NOT NULL DEFAULT 'claimed',
+    message TEXT,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    CONSTRAINT uq_scheduled_refresh_run UNIQUE (environment, schedule_name, scheduled_for),
+    CONSTRAINT chk_scheduled_refresh_status CHECK (status IN ('claimed', 'queued', 'skipped', 'failed'))
+);
+
+CREATE INDEX idx_scheduled_refresh_runs_env_created ON scheduled_refresh_runs(environment, created_at DESC);
+
+COMMENT ON TABLE scheduled_refresh_runs IS 'One row per fired refresh schedule occurrence (claim + outcome)';
+
+-- Scheduler settings
+-- scheduled_refreshes is a JSON array of:
+--   {"name": "morning", "cron": "0 5 * * 1-5", "company": "100", "facility": "A01",
+--    "language": "GB", "mode": "incremental", "timezone": "America/Chicago", "enabled": true}
+-- cron uses 5 fields (minute hour day-of-month month day-of-week); mode and timezone are optional
+INSERT INTO system_settings (environment, setting_key, setting_value, setting_type, description, category, created_at)
+VALUES
+    ('TRN', 'scheduled_refresh_enabled', 'false', 'boolean', 'Scheduled Refresh: Run snapshot refreshes automatically on the configured schedules', 'data_refresh', NOW()),
+    ('PRD', 'scheduled_refresh_enabled', 'false', 'boolean', 'Scheduled Refresh: Run snapshot refreshes automatically on the configured schedules', 'data_refresh', NOW()),
+    ('TRN', 'scheduled_refreshes', '[]', 'json', 'Refresh Schedules: List of {name, cron, company, facility, language, mode, timezone, enabled} entries; cron is "minute hour day month weekday"', 'data_refresh', NOW()),
+    ('PRD', 'scheduled_refreshes', '[]', 'json', 'Refresh Schedules: List of {name, cron, company, facility, language, mode, timezone, enabled} entries; cron is "minute hour day month weekday"', 'data_refresh', NOW())
+ON CONFLICT (environment, setting_key) DO NOTHING;
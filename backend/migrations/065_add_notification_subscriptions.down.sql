@@ -0,0 +1,6 @@
+-- Remove outbound notifications
+DELETE FROM system_settings WHERE setting_key IN ('notifications_enabled', 'notification_digest_cron');
+
+DROP TABLE IF EXISTS notification_deliveries;
+DROP TABLE IF EXISTS notification_digest_items;
+DROP TABLE IF EXISTS notification_subscriptions;
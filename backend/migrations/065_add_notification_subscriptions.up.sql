@@ -0,0 +1,65 @@
+-- ========================================
+-- Outbound Notifications
+-- ========================================
+-- Users subscribe to new critical anomalies and new issues per detector, facility and
+-- minimum severity. After each detection run matching items are either sent immediately
+-- or queued in notification_digest_items for the next digest (notification_digest_cron).
+
+CREATE TABLE notification_subscriptions (
+    id BIGSERIAL PRIMARY KEY,
+    environment VARCHAR(10) NOT NULL,
+    user_id VARCHAR(100) NOT NULL,         -- Matches user_profiles.user_id
+    channel VARCHAR(20) NOT NULL,          -- email, webhook, teams, slack
+    target TEXT NOT NULL,                  -- Email address or webhook URL
+    detector_type VARCHAR(100),            -- NULL = all detectors
+    facility VARCHAR(10),                  -- NULL = all facilities (applies to issues only)
+    min_severity VARCHAR(20) NOT NULL DEFAULT 'critical',
+    delivery VARCHAR(20) NOT NULL DEFAULT 'immediate',
+    enabled BOOLEAN NOT NULL DEFAULT TRUE,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    CONSTRAINT chk_notification_channel CHECK (channel IN ('email', 'webhook', 'teams', 'slack')),
+    CONSTRAINT chk_notification_min_severity CHECK (min_severity IN ('info', 'warning', 'critical')),
+    CONSTRAINT chk_notification_delivery CHECK (delivery IN ('immediate', 'digest'))
+);
+
+CREATE INDEX idx_notification_subscriptions_env_user ON notification_subscriptions(environment, user_id);
+CREATE INDEX idx_notification_subscriptions_env_enabled ON notification_subscriptions(environment) WHERE enabled;
+
+COMMENT ON TABLE notification_subscriptions IS 'Per-user outbound notification subscriptions for anomalies and new issues';
+
+-- Items waiting for the next digest; claimed atomically by sent_at so only one instance sends them
+CREATE TABLE notification_digest_items (
+    id BIGSERIAL PRIMARY KEY,
+    subscription_id BIGINT NOT NULL REFERENCES notification_subscriptions(id) ON DELETE CASCADE,
+    job_id VARCHAR(36) NOT NULL,
+    items JSONB NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    sent_at TIMESTAMP
+);
+
+CREATE INDEX idx_notification_digest_items_pending ON notification_digest_items(subscription_id) WHERE sent_at IS NULL;
+
+-- Delivery log for troubleshooting and the subscription UI
+CREATE TABLE notification_deliveries (
+    id BIGSERIAL PRIMARY KEY,
+    subscription_id BIGINT NOT NULL REFERENCES notification_subscriptions(id) ON DELETE CASCADE,
+    job_id VARCHAR(36),
+    digest BOOLEAN NOT NULL DEFAULT FALSE,
+    item_count INTEGER NOT NULL DEFAULT 0,
+    status VARCHAR(20) NOT NULL,
+    error_message TEXT,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    CONSTRAINT chk_notification_delivery_status CHECK (status IN ('sent', 'failed'))
+);
+
+CREATE INDEX idx_notification_deliveries_subscription ON notification_deliveries(subscription_id, created_at DESC);
+
+-- Notification settings
+INSERT INTO system_settings (environment, setting_key, setting_value, setting_type, description, category, created_at)
+VALUES
+    ('TRN', 'notifications_enabled', 'true', 'boolean', 'Notifications: Send subscribed alerts for new critical anomalies and issues after detection', 'notifications', NOW()),
+    ('PRD', 'notifications_enabled', 'true', 'boolean', 'Notifications: Send subscribed alerts for new critical anomalies and issues after detection', 'notifications', NOW()),
+    ('TRN', 'notification_digest_cron', '0 7 * * 1-5', 'string', 'Digest Schedule: Cron expression ("minute hour day month weekday", server time) for sending digest subscriptions', 'notifications', NOW()),
+    ('PRD', 'notification_digest_cron', '0 7 * * 1-5', 'string', 'Digest Schedule: Cron expression ("minute hour day month weekday", server time) for sending digest subscriptions', 'notifications', NOW())
+ON CONFLICT (environment, setting_key) DO NOTHING;
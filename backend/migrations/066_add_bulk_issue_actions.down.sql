@@ -0,0 +1,3 @@
+-- Remove bulk issue actions
+DROP TABLE IF EXISTS bulk_action_items;
+DROP TABLE IF EXISTS bulk_action_jobs;
@@ -0,0 +1,49 @@
+-- ========================================
+-- Bulk Issue Actions
+-- ========================================
+-- A bulk action applies one issue action (delete MOP, delete/close MO, align JDCD group)
+-- to many issues. The planned M3 transactions are stored per item so results can be
+-- reviewed after execution. Kept separate from refresh_jobs because issue queries key
+-- off the latest completed refresh job.
+
+CREATE TABLE bulk_action_jobs (
+    id VARCHAR(36) PRIMARY KEY,
+    environment VARCHAR(10) NOT NULL,
+    user_id VARCHAR(100),
+    action VARCHAR(30) NOT NULL,
+    status VARCHAR(20) NOT NULL DEFAULT 'running',
+    selection JSONB NOT NULL,              -- Issue IDs or filter used to select issues
+    total_transactions INTEGER NOT NULL DEFAULT 0,
+    succeeded INTEGER NOT NULL DEFAULT 0,
+    failed INTEGER NOT NULL DEFAULT 0,
+    skipped_issues INTEGER NOT NULL DEFAULT 0,
+    error_message TEXT,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    completed_at TIMESTAMP,
+    CONSTRAINT chk_bulk_action_job_status CHECK (status IN ('running', 'completed', 'failed'))
+);
+
+CREATE INDEX idx_bulk_action_jobs_env_created ON bulk_action_jobs(environment, created_at DESC);
+
+CREATE TABLE bulk_action_items (
+    id BIGSERIAL PRIMARY KEY,
+    job_id VARCHAR(36) NOT NULL REFERENCES bulk_action_jobs(id) ON DELETE CASCADE,
+    seq INTEGER NOT NULL,
+    issue_id BIGINT NOT NULL,
+    facility VARCHAR(10) NOT NULL,
+    order_type VARCHAR(10),
+    order_number VARCHAR(20),
+    program VARCHAR(20) NOT NULL,
+    transaction_name VARCHAR(30) NOT NULL,
+    record JSONB NOT NULL,
+    status VARCHAR(20) NOT NULL DEFAULT 'pending',
+    error_message TEXT,
+    updated_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    CONSTRAINT chk_bulk_action_item_status CHECK (status IN ('pending', 'succeeded', 'failed'))
+);
+
+CREATE INDEX idx_bulk_action_items_job ON bulk_action_items(job_id, seq);
+
+COMMENT ON TABLE bulk_action_jobs IS 'Bulk issue action executions (one M3 bulk run per job)';
+COMMENT ON TABLE bulk_action_items IS 'Planned M3 transactions of a bulk action and their results';
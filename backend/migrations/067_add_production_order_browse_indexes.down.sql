@@ -0,0 +1,4 @@
+-- Migration 067 rollback: Remove production order browse indexes
+
+DROP INDEX IF EXISTS idx_production_orders_browse_start;
+DROP INDEX IF EXISTS idx_production_orders_browse_finish;
@@ -0,0 +1,9 @@
+-- Migration 067: Indexes for the production order browse API
+-- The list endpoint sorts on COALESCE(<column>, '') with id as tie-breaker and pages by keyset,
+-- so the default date sorts need matching expression indexes per environment
+
+CREATE INDEX IF NOT EXISTS idx_production_orders_browse_start
+ON production_orders(environment, (COALESCE(planned_start_date, '')), id);
+
+CREATE INDEX IF NOT EXISTS idx_production_orders_browse_finish
+ON production_orders(environment, (COALESCE(planned_finish_date, '')), id);
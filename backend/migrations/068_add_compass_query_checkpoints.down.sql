@@ -0,0 +1,8 @@
+-- Remove resumable Compass query checkpoints
+DELETE FROM system_settings WHERE setting_key IN (
+    'compass_page_concurrency',
+    'compass_page_max_retries',
+    'compass_page_retry_backoff_ms',
+    'data_job_max_attempts'
+);
+DROP TABLE IF EXISTS compass_query_checkpoints;
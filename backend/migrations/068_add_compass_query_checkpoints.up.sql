@@ -0,0 +1,37 @@
+-- ========================================
+-- Resumable Compass Queries
+-- ========================================
+-- Each data batch job (one data type and facility of a refresh) records the Compass query
+-- it is reading and the offset of the next page to load. When the job is redelivered after
+-- a worker restart or failure it resumes reading the same Compass query from that offset
+-- instead of starting over. The query is resumed by ID rather than rebuilt, since an
+-- incremental query's sync date moves as rows are loaded.
+-- Rows are removed when the batch completes and when the parent refresh job ends.
+
+CREATE TABLE compass_query_checkpoints (
+    job_id VARCHAR(100) PRIMARY KEY,        -- DataBatchJobMessage.JobID, e.g. "<parent>-mos-A01"
+    parent_job_id VARCHAR(36) NOT NULL,
+    environment VARCHAR(10) NOT NULL,
+    compass_query_id VARCHAR(100) NOT NULL,
+    total_records INTEGER NOT NULL DEFAULT 0,
+    next_offset INTEGER NOT NULL DEFAULT 0,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX idx_compass_query_checkpoints_parent ON compass_query_checkpoints(parent_job_id);
+
+COMMENT ON TABLE compass_query_checkpoints IS 'Last loaded offset of the Compass query behind each in-flight data batch job';
+
+-- Page fetch settings
+INSERT INTO system_settings (environment, setting_key, setting_value, setting_type, description, category, constraints, created_at)
+VALUES
+    ('TRN', 'compass_page_concurrency', '4', 'integer', 'Parallel Page Fetches: Number of Compass result pages fetched at the same time per data job. Requests still respect the API throttle settings', 'data_refresh', '{"min": 1, "max": 16}'::jsonb, NOW()),
+    ('PRD', 'compass_page_concurrency', '2', 'integer', 'Parallel Page Fetches: Number of Compass result pages fetched at the same time per data job. Requests still respect the API throttle settings', 'data_refresh', '{"min": 1, "max": 16}'::jsonb, NOW()),
+    ('TRN', 'compass_page_max_retries', '3', 'integer', 'Page Retries: Number of times a failed Compass result page is retried, with exponential backoff', 'data_refresh', '{"min": 0, "max": 10}'::jsonb, NOW()),
+    ('PRD', 'compass_page_max_retries', '3', 'integer', 'Page Retries: Number of times a failed Compass result page is retried, with exponential backoff', 'data_refresh', '{"min": 0, "max": 10}'::jsonb, NOW()),
+    ('TRN', 'compass_page_retry_backoff_ms', '1000', 'integer', 'Retry Backoff: Delay before the first page retry; doubled for each further retry', 'data_refresh', '{"min": 100, "max": 60000, "unit": "ms"}'::jsonb, NOW()),
+    ('PRD', 'compass_page_retry_backoff_ms', '1000', 'integer', 'Retry Backoff: Delay before the first page retry; doubled for each further retry', 'data_refresh', '{"min": 100, "max": 60000, "unit": "ms"}'::jsonb, NOW()),
+    ('TRN', 'data_job_max_attempts', '3', 'integer', 'Data Job Attempts: Times a failed or lost data job is redelivered before the refresh fails. Redelivered jobs resume from their last loaded page', 'data_refresh', '{"min": 1, "max": 10}'::jsonb, NOW()),
+    ('PRD', 'data_job_max_attempts', '3', 'integer', 'Data Job Attempts: Times a failed or lost data job is redelivered before the refresh fails. Redelivered jobs resume from their last loaded page', 'data_refresh', '{"min": 1, "max": 10}'::jsonb, NOW())
+ON CONFLICT (environment, setting_key) DO NOTHING;
@@ -0,0 +1,18 @@
+-- Remove material shortage detector settings
+DELETE FROM system_settings
+WHERE setting_key LIKE 'detector_material_shortage_%';
+
+DELETE FROM refresh_job_phases WHERE phase_type = 'materials';
+ALTER TABLE refresh_job_phases DROP CONSTRAINT IF EXISTS chk_phase_type;
+ALTER TABLE refresh_job_phases ADD CONSTRAINT chk_phase_type
+    CHECK (phase_type IN ('mops', 'mos', 'cos'));
+
+DROP TABLE IF EXISTS item_locations_staging;
+DROP TABLE IF EXISTS item_balances_staging;
+DROP TABLE IF EXISTS product_structures_staging;
+DROP TABLE IF EXISTS mo_materials_staging;
+
+DROP TABLE IF EXISTS item_locations;
+DROP TABLE IF EXISTS item_balances;
+DROP TABLE IF EXISTS product_structures;
+DROP TABLE IF EXISTS mo_materials;
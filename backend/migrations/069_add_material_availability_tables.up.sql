@@ -0,0 +1,215 @@
+-- ========================================
+-- Material Availability Snapshot Tables
+-- ========================================
+-- Loaded per facility by the "materials" data job of a snapshot refresh and read by the
+-- material shortage detector:
+--   mo_materials        MWOMAT - component requirements of open MOs
+--   product_structures  MPDMAT - product structure components, exploded for firmed MOPs
+--   item_balances       MITBAL - on-hand and allocated balance per warehouse/item
+--   item_locations      MITLOC - approved on-hand stock per location and lot
+-- All M3 fields are stored as VARCHAR, as received from Data Fabric (see migration 014).
+-- Every refresh reloads a facility's rows in full, since balances change without the
+-- order headers changing.
+
+CREATE TABLE mo_materials (
+    id BIGSERIAL PRIMARY KEY,
+    environment VARCHAR(10) NOT NULL,
+
+    -- M3 Core Identifiers
+    cono VARCHAR(10) NOT NULL,
+    faci VARCHAR(10) NOT NULL,
+    mfno VARCHAR(50) NOT NULL,
+    prno VARCHAR(50),
+    mseq VARCHAR(10) NOT NULL,
+
+    -- Component
+    mtno VARCHAR(50) NOT NULL,
+    whlo VARCHAR(10),
+    opno VARCHAR(10),
+    wmst VARCHAR(10),
+    spmt VARCHAR(10),
+    peun VARCHAR(10),
+
+    -- Quantities (required, reported/issued, allocated)
+    reqt VARCHAR(30),
+    rpqt VARCHAR(30),
+    alqt VARCHAR(30),
+
+    -- Reservation date (YYYYMMDD)
+    rdat VARCHAR(10),
+
+    -- M3 Audit Fields
+    lmdt VARCHAR(10),
+    m3_timestamp TEXT,
+
+    -- Application Metadata
+    sync_timestamp TIMESTAMP DEFAULT NOW(),
+    created_at TIMESTAMP DEFAULT NOW(),
+    updated_at TIMESTAMP DEFAULT NOW(),
+
+    CONSTRAINT unique_mo_material UNIQUE (environment, cono, faci, mfno, mseq)
+);
+
+CREATE INDEX idx_mo_materials_order ON mo_materials(environment, faci, mfno);
+CREATE INDEX idx_mo_materials_component ON mo_materials(environment, whlo, mtno);
+
+CREATE TABLE product_structures (
+    id BIGSERIAL PRIMARY KEY,
+    environment VARCHAR(10) NOT NULL,
+
+    -- M3 Core Identifiers
+    cono VARCHAR(10) NOT NULL,
+    faci VARCHAR(10) NOT NULL,
+    prno VARCHAR(50) NOT NULL,
+    strt VARCHAR(10) NOT NULL,
+    mseq VARCHAR(10) NOT NULL,
+
+    -- Component
+    mtno VARCHAR(50) NOT NULL,
+    opno VARCHAR(10),
+    peun VARCHAR(10),
+
+    -- Quantity per parent unit and waste percentage
+    cnqt VARCHAR(30),
+    wapc VARCHAR(30),
+
+    -- Validity (YYYYMMDD, empty to date = open ended)
+    fdat VARCHAR(10) NOT NULL DEFAULT '',
+    tdat VARCHAR(10),
+
+    -- M3 Audit Fields
+    lmdt VARCHAR(10),
+    m3_timestamp TEXT,
+
+    -- Application Metadata
+    sync_timestamp TIMESTAMP DEFAULT NOW(),
+    created_at TIMESTAMP DEFAULT NOW(),
+    updated_at TIMESTAMP DEFAULT NOW(),
+
+    CONSTRAINT unique_product_structure UNIQUE (environment, cono, faci, prno, strt, mseq, fdat)
+);
+
+CREATE INDEX idx_product_structures_product ON product_structures(environment, faci, prno);
+
+CREATE TABLE item_balances (
+    id BIGSERIAL PRIMARY KEY,
+    environment VARCHAR(10) NOT NULL,
+
+    -- M3 Core Identifiers (faci from the warehouse, MITWHL)
+    cono VARCHAR(10) NOT NULL,
+    faci VARCHAR(10) NOT NULL,
+    whlo VARCHAR(10) NOT NULL,
+    itno VARCHAR(50) NOT NULL,
+
+    -- Quantities (on hand approved, allocated, under inspection, rejected)
+    stqt VARCHAR(30),
+    alqt VARCHAR(30),
+    quqt VARCHAR(30),
+    rjqt VARCHAR(30),
+
+    -- M3 Audit Fields
+    lmdt VARCHAR(10),
+    m3_timestamp TEXT,
+
+    -- Application Metadata
+    sync_timestamp TIMESTAMP DEFAULT NOW(),
+    created_at TIMESTAMP DEFAULT NOW(),
+    updated_at TIMESTAMP DEFAULT NOW(),
+
+    CONSTRAINT unique_item_balance UNIQUE (environment, cono, whlo, itno)
+);
+
+CREATE INDEX idx_item_balances_faci ON item_balances(environment, faci);
+
+CREATE TABLE item_locations (
+    id BIGSERIAL PRIMARY KEY,
+    environment VARCHAR(10) NOT NULL,
+
+    -- M3 Core Identifiers (faci from the warehouse, MITWHL)
+    cono VARCHAR(10) NOT NULL,
+    faci VARCHAR(10) NOT NULL,
+    whlo VARCHAR(10) NOT NULL,
+    itno VARCHAR(50) NOT NULL,
+    whsl VARCHAR(50) NOT NULL,
+    bano VARCHAR(50) NOT NULL DEFAULT '',
+    camu VARCHAR(50) NOT NULL DEFAULT '',
+
+    -- Status and quantities
+    stas VARCHAR(10),
+    stqt VARCHAR(30),
+    alqt VARCHAR(30),
+
+    -- M3 Audit Fields
+    lmdt VARCHAR(10),
+    m3_timestamp TEXT,
+
+    -- Application Metadata
+    sync_timestamp TIMESTAMP DEFAULT NOW(),
+    created_at TIMESTAMP DEFAULT NOW(),
+    updated_at TIMESTAMP DEFAULT NOW(),
+
+    CONSTRAINT unique_item_location UNIQUE (environment, cono, whlo, itno, whsl, bano, camu)
+);
+
+CREATE INDEX idx_item_locations_item ON item_locations(environment, whlo, itno);
+
+COMMENT ON TABLE mo_materials IS 'MWOMAT component requirements of open MOs, loaded by the materials data job';
+COMMENT ON TABLE product_structures IS 'MPDMAT product structure components, used to explode firmed MOP requirements';
+COMMENT ON TABLE item_balances IS 'MITBAL on-hand and allocated balances per warehouse and item';
+COMMENT ON TABLE item_locations IS 'MITLOC approved on-hand stock per location and lot';
+
+-- Staging generations (see migration 061)
+CREATE TABLE IF NOT EXISTS mo_materials_staging (LIKE mo_materials INCLUDING ALL);
+CREATE TABLE IF NOT EXISTS product_structures_staging (LIKE product_structures INCLUDING ALL);
+CREATE TABLE IF NOT EXISTS item_balances_staging (LIKE item_balances INCLUDING ALL);
+CREATE TABLE IF NOT EXISTS item_locations_staging (LIKE item_locations INCLUDING ALL);
+
+COMMENT ON TABLE mo_materials_staging IS 'Staging generation of mo_materials, promoted to live after a successful snapshot refresh';
+COMMENT ON TABLE product_structures_staging IS 'Staging generation of product_structures, promoted to live after a successful snapshot refresh';
+COMMENT ON TABLE item_balances_staging IS 'Staging generation of item_balances, promoted to live after a successful snapshot refresh';
+COMMENT ON TABLE item_locations_staging IS 'Staging generation of item_locations, promoted to live after a successful snapshot refresh';
+
+-- Track the materials data job as a refresh phase
+ALTER TABLE refresh_job_phases DROP CONSTRAINT IF EXISTS chk_phase_type;
+ALTER TABLE refresh_job_phases ADD CONSTRAINT chk_phase_type
+    CHECK (phase_type IN ('mops', 'mos', 'cos', 'materials'));
+
+-- ========================================
+-- MATERIAL SHORTAGE DETECTOR
+-- ========================================
+-- Flags MOs and firmed MOPs starting within the horizon whose component requirements
+-- are not covered by available on-hand stock
+
+INSERT INTO system_settings (environment, setting_key, setting_value, setting_type, description, category, constraints) VALUES
+
+    -- TRN Environment
+    ('TRN', 'detector_material_shortage_enabled',
+     'true',
+     'boolean',
+     'Enable detection of MOs and firmed MOPs whose components are not covered by on-hand stock',
+     'detection',
+     '{}'::jsonb),
+
+    ('TRN', 'detector_material_shortage_horizon_days',
+     '{"global": 14, "overrides": []}',
+     'json',
+     'Check orders starting within the next N days (hierarchical, overdue orders are always checked)',
+     'detection',
+     '{"min": 0, "max": 365, "unit": "days", "hierarchical": true}'::jsonb),
+
+    -- PRD Environment
+    ('PRD', 'detector_material_shortage_enabled',
+     'true',
+     'boolean',
+     'Enable detection of MOs and firmed MOPs whose components are not covered by on-hand stock',
+     'detection',
+     '{}'::jsonb),
+
+    ('PRD', 'detector_material_shortage_horizon_days',
+     '{"global": 14, "overrides": []}',
+     'json',
+     'Check orders starting within the next N days (hierarchical, overdue orders are always checked)',
+     'detection',
+     '{"min": 0, "max": 365, "unit": "days", "hierarchical": true}'::jsonb)
+
+ON CONFLICT (environment, setting_key) DO NOTHING;
@@ -0,0 +1,16 @@
+-- Remove work center overload detector settings
+DELETE FROM system_settings
+WHERE setting_key LIKE 'detector_work_center_overload_%';
+
+DELETE FROM refresh_job_phases WHERE phase_type = 'operations';
+ALTER TABLE refresh_job_phases DROP CONSTRAINT IF EXISTS chk_phase_type;
+ALTER TABLE refresh_job_phases ADD CONSTRAINT chk_phase_type
+    CHECK (phase_type IN ('mops', 'mos', 'cos', 'materials'));
+
+DROP TABLE IF EXISTS work_centers_staging;
+DROP TABLE IF EXISTS product_operations_staging;
+DROP TABLE IF EXISTS mo_operations_staging;
+
+DROP TABLE IF EXISTS work_centers;
+DROP TABLE IF EXISTS product_operations;
+DROP TABLE IF EXISTS mo_operations;
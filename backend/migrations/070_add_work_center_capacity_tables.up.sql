@@ -0,0 +1,236 @@
+-- ========================================
+-- Work Center Capacity Snapshot Tables
+-- ========================================
+-- Loaded per facility by the "operations" data job of a snapshot refresh and read by the
+-- work center overload detector:
+--   mo_operations       MWOOPE - operations of open MOs, with their work center and times
+--   product_operations  MPDOPE - routing operations, used to load firmed and planned MOPs
+--   work_centers        MPDWCT - work centers and their number of resources
+-- Replaces the unused mo_operations table dropped in migration 036.
+-- All M3 fields are stored as VARCHAR, as received from Data Fabric (see migration 014).
+-- Every refresh reloads a facility's rows in full, since reported operations change
+-- without the order headers changing.
+
+CREATE TABLE mo_operations (
+    id BIGSERIAL PRIMARY KEY,
+    environment VARCHAR(10) NOT NULL,
+
+    -- M3 Core Identifiers
+    cono VARCHAR(10) NOT NULL,
+    faci VARCHAR(10) NOT NULL,
+    mfno VARCHAR(50) NOT NULL,
+    prno VARCHAR(50),
+    opno VARCHAR(10) NOT NULL,
+
+    -- Operation
+    plgr VARCHAR(50),
+    opds TEXT,
+    wost VARCHAR(10),
+
+    -- Times (setup hours, run hours per PUSN units)
+    seti VARCHAR(30),
+    piti VARCHAR(30),
+    pusn VARCHAR(30),
+
+    -- Quantities (ordered, manufactured)
+    orqt VARCHAR(30),
+    maqt VARCHAR(30),
+
+    -- Planned start/finish dates (YYYYMMDD)
+    stdt VARCHAR(10),
+    fidt VARCHAR(10),
+
+    -- M3 Audit Fields
+    lmdt VARCHAR(10),
+    m3_timestamp TEXT,
+
+    -- Application Metadata
+    sync_timestamp TIMESTAMP DEFAULT NOW(),
+    created_at TIMESTAMP DEFAULT NOW(),
+    updated_at TIMESTAMP DEFAULT NOW(),
+
+    CONSTRAINT unique_mo_operation UNIQUE (environment, cono, faci, mfno, opno)
+);
+
+CREATE INDEX idx_mo_operations_order ON mo_operations(environment, faci, mfno);
+CREATE INDEX idx_mo_operations_work_center ON mo_operations(environment, faci, plgr);
+
+CREATE TABLE product_operations (
+    id BIGSERIAL PRIMARY KEY,
+    environment VARCHAR(10) NOT NULL,
+
+    -- M3 Core Identifiers
+    cono VARCHAR(10) NOT NULL,
+    faci VARCHAR(10) NOT NULL,
+    prno VARCHAR(50) NOT NULL,
+    strt VARCHAR(10) NOT NULL,
+    opno VARCHAR(10) NOT NULL,
+
+    -- Operation
+    plgr VARCHAR(50),
+    opds TEXT,
+
+    -- Times (setup hours, run hours per PUSN units)
+    seti VARCHAR(30),
+    piti VARCHAR(30),
+    pusn VARCHAR(30),
+
+    -- Validity (YYYYMMDD, empty to date = open ended)
+    fdat VARCHAR(10) NOT NULL DEFAULT '',
+    tdat VARCHAR(10),
+
+    -- M3 Audit Fields
+    lmdt VARCHAR(10),
+    m3_timestamp TEXT,
+
+    -- Application Metadata
+    sync_timestamp TIMESTAMP DEFAULT NOW(),
+    created_at TIMESTAMP DEFAULT NOW(),
+    updated_at TIMESTAMP DEFAULT NOW(),
+
+    CONSTRAINT unique_product_operation UNIQUE (environment, cono, faci, prno, strt, opno, fdat)
+);
+
+CREATE INDEX idx_product_operations_product ON product_operations(environment, faci, prno);
+
+CREATE TABLE work_centers (
+    id BIGSERIAL PRIMARY KEY,
+    environment VARCHAR(10) NOT NULL,
+
+    -- M3 Core Identifiers
+    cono VARCHAR(10) NOT NULL,
+    faci VARCHAR(10) NOT NULL,
+    plgr VARCHAR(50) NOT NULL,
+
+    -- Work center
+    plnm TEXT,
+    wcty VARCHAR(10),
+
+    -- Number of machines/resources working in parallel
+    noma VARCHAR(30),
+
+    -- M3 Audit Fields
+    lmdt VARCHAR(10),
+    m3_timestamp TEXT,
+
+    -- Application Metadata
+    sync_timestamp TIMESTAMP DEFAULT NOW(),
+    created_at TIMESTAMP DEFAULT NOW(),
+    updated_at TIMESTAMP DEFAULT NOW(),
+
+    CONSTRAINT unique_work_center UNIQUE (environment, cono, faci, plgr)
+);
+
+COMMENT ON TABLE mo_operations IS 'MWOOPE operations of open MOs, loaded by the operations data job';
+COMMENT ON TABLE product_operations IS 'MPDOPE routing operations, used to load MOPs onto work centers';
+COMMENT ON TABLE work_centers IS 'MPDWCT work centers and their number of resources';
+
+-- Staging generations (see migration 061)
+CREATE TABLE IF NOT EXISTS mo_operations_staging (LIKE mo_operations INCLUDING ALL);
+CREATE TABLE IF NOT EXISTS product_operations_staging (LIKE product_operations INCLUDING ALL);
+CREATE TABLE IF NOT EXISTS work_centers_staging (LIKE work_centers INCLUDING ALL);
+
+COMMENT ON TABLE mo_operations_staging IS 'Staging generation of mo_operations, promoted to live after a successful snapshot refresh';
+COMMENT ON TABLE product_operations_staging IS 'Staging generation of product_operations, promoted to live after a successful snapshot refresh';
+COMMENT ON TABLE work_centers_staging IS 'Staging generation of work_centers, promoted to live after a successful snapshot refresh';
+
+-- Track the operations data job as a refresh phase
+ALTER TABLE refresh_job_phases DROP CONSTRAINT IF EXISTS chk_phase_type;
+ALTER TABLE refresh_job_phases ADD CONSTRAINT chk_phase_type
+    CHECK (phase_type IN ('mops', 'mos', 'cos', 'materials', 'operations'));
+
+-- ========================================
+-- WORK CENTER OVERLOAD DETECTOR
+-- ========================================
+-- Compares the planned hours of MO operations and routed MOPs per work center and bucket
+-- against the work center's capacity (resources x hours per resource x working days)
+
+INSERT INTO system_settings (environment, setting_key, setting_value, setting_type, description, category, constraints) VALUES
+
+    -- TRN Environment
+    ('TRN', 'detector_work_center_overload_enabled',
+     'true',
+     'boolean',
+     'Enable detection of work centers loaded beyond their capacity',
+     'detection',
+     '{}'::jsonb),
+
+    ('TRN', 'detector_work_center_overload_horizon_days',
+     '{"global": 28, "overrides": []}',
+     'json',
+     'Check load within the next N days (hierarchical, overdue operations count against the first bucket)',
+     'detection',
+     '{"min": 1, "max": 365, "unit": "days", "hierarchical": true}'::jsonb),
+
+    ('TRN', 'detector_work_center_overload_bucket_days',
+     '{"global": 7, "overrides": []}',
+     'json',
+     'Bucket size - 1 compares load per day, 7 per week (hierarchical, buckets start on Monday)',
+     'detection',
+     '{"min": 1, "max": 28, "unit": "days", "hierarchical": true}'::jsonb),
+
+    ('TRN', 'detector_work_center_overload_hours_per_resource',
+     '{"global": 8, "overrides": []}',
+     'json',
+     'Available hours per resource (MPDWCT machines) per working day (hierarchical)',
+     'detection',
+     '{"min": 0, "max": 24, "unit": "hours", "hierarchical": true}'::jsonb),
+
+    ('TRN', 'detector_work_center_overload_working_days_per_week',
+     '{"global": 5, "overrides": []}',
+     'json',
+     'Working days per week, counted from Monday (hierarchical)',
+     'detection',
+     '{"min": 1, "max": 7, "unit": "days", "hierarchical": true}'::jsonb),
+
+    ('TRN', 'detector_work_center_overload_tolerance_percent',
+     '{"global": 0, "overrides": []}',
+     'json',
+     'Flag a bucket when load exceeds capacity by more than this percentage (hierarchical)',
+     'detection',
+     '{"min": 0, "max": 500, "unit": "%", "hierarchical": true}'::jsonb),
+
+    -- PRD Environment
+    ('PRD', 'detector_work_center_overload_enabled',
+     'true',
+     'boolean',
+     'Enable detection of work centers loaded beyond their capacity',
+     'detection',
+     '{}'::jsonb),
+
+    ('PRD', 'detector_work_center_overload_horizon_days',
+     '{"global": 28, "overrides": []}',
+     'json',
+     'Check load within the next N days (hierarchical, overdue operations count against the first bucket)',
+     'detection',
+     '{"min": 1, "max": 365, "unit": "days", "hierarchical": true}'::jsonb),
+
+    ('PRD', 'detector_work_center_overload_bucket_days',
+     '{"global": 7, "overrides": []}',
+     'json',
+     'Bucket size - 1 compares load per day, 7 per week (hierarchical, buckets start on Monday)',
+     'detection',
+     '{"min": 1, "max": 28, "unit": "days", "hierarchical": true}'::jsonb),
+
+    ('PRD', 'detector_work_center_overload_hours_per_resource',
+     '{"global": 8, "overrides": []}',
+     'json',
+     'Available hours per resource (MPDWCT machines) per working day (hierarchical)',
+     'detection',
+     '{"min": 0, "max": 24, "unit": "hours", "hierarchical": true}'::jsonb),
+
+    ('PRD', 'detector_work_center_overload_working_days_per_week',
+     '{"global": 5, "overrides": []}',
+     'json',
+     'Working days per week, counted from Monday (hierarchical)',
+     'detection',
+     '{"min": 1, "max": 7, "unit": "days", "hierarchical": true}'::jsonb),
+
+    ('PRD', 'detector_work_center_overload_tolerance_percent',
+     '{"global": 0, "overrides": []}',
+     'json',
+     'Flag a bucket when load exceeds capacity by more than this percentage (hierarchical)',
+     'detection',
+     '{"min": 0, "max": 500, "unit": "%", "hierarchical": true}'::jsonb)
+
+ON CONFLICT (environment, setting_key) DO NOTHING;
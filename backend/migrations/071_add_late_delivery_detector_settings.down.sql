@@ -0,0 +1,10 @@
+-- ========================================
+-- Rollback Migration 071: Remove Late Delivery Detector configuration settings
+-- ========================================
+
+DELETE FROM system_settings
+WHERE setting_key IN (
+  'detector_late_delivery_enabled',
+  'detector_late_delivery_tolerance_days',
+  'detector_late_delivery_lead_time_days'
+);
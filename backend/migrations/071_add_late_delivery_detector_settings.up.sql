@@ -0,0 +1,54 @@
+-- ========================================
+-- LATE DELIVERY DETECTOR
+-- ========================================
+-- Adds configuration settings for the Late Delivery Detector
+-- This detector identifies MOs/MOPs linked to a CO line that finish after the line's confirmed
+-- delivery date (CODT, or DWDT when not yet confirmed) minus the transport lead time
+
+INSERT INTO system_settings (environment, setting_key, setting_value, setting_type, description, category, constraints) VALUES
+
+    -- TRN Environment Settings
+    ('TRN', 'detector_late_delivery_enabled',
+     'true',
+     'boolean',
+     'Enable detection of production orders finishing too late for their linked CO line delivery date',
+     'detection',
+     '{}'::jsonb),
+
+    ('TRN', 'detector_late_delivery_tolerance_days',
+     '{"global": 0, "overrides": []}',
+     'json',
+     'Allow production orders to finish up to N days after the required finish date (hierarchical)',
+     'detection',
+     '{"min": 0, "max": 30, "unit": "days", "hierarchical": true}'::jsonb),
+
+    ('TRN', 'detector_late_delivery_lead_time_days',
+     '{"global": 0, "overrides": []}',
+     'json',
+     'Transport lead time - orders must finish N days before the delivery date (hierarchical)',
+     'detection',
+     '{"min": 0, "max": 90, "unit": "days", "hierarchical": true}'::jsonb),
+
+    -- PRD Environment Settings
+    ('PRD', 'detector_late_delivery_enabled',
+     'true',
+     'boolean',
+     'Enable detection of production orders finishing too late for their linked CO line delivery date',
+     'detection',
+     '{}'::jsonb),
+
+    ('PRD', 'detector_late_delivery_tolerance_days',
+     '{"global": 0, "overrides": []}',
+     'json',
+     'Allow production orders to finish up to N days after the required finish date (hierarchical)',
+     'detection',
+     '{"min": 0, "max": 30, "unit": "days", "hierarchical": true}'::jsonb),
+
+    ('PRD', 'detector_late_delivery_lead_time_days',
+     '{"global": 0, "overrides": []}',
+     'json',
+     'Transport lead time - orders must finish N days before the delivery date (hierarchical)',
+     'detection',
+     '{"min": 0, "max": 90, "unit": "days", "hierarchical": true}'::jsonb)
+
+ON CONFLICT (environment, setting_key) DO NOTHING;
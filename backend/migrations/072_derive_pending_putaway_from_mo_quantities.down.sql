@@ -0,0 +1,5 @@
+COMMENT ON COLUMN manufacturing_orders.pending_putaway_qty IS
+    'Pending putaway quantity from MPTAWY.TRQT - quantity awaiting warehouse putaway';
+
+COMMENT ON COLUMN production_orders.pending_putaway_qty IS
+    'Pending putaway quantity from MPTAWY.TRQT (MOs only) - quantity awaiting warehouse putaway';
@@ -0,0 +1,8 @@
+-- Pending putaway is now derived from MWOHED (MAQT - RVQT) instead of the MPTAWY join,
+-- which was too slow to query, so the CO quantity mismatch detector can run again
+
+COMMENT ON COLUMN manufacturing_orders.pending_putaway_qty IS
+    'Pending putaway quantity from MWOHED MAQT - RVQT - reported as manufactured but not yet received into stock';
+
+COMMENT ON COLUMN production_orders.pending_putaway_qty IS
+    'Pending putaway quantity from MWOHED MAQT - RVQT (MOs only) - reported as manufactured but not yet received into stock';
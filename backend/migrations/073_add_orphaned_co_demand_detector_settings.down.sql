@@ -0,0 +1,12 @@
+-- ========================================
+-- Rollback Migration 073: Remove Orphaned CO Demand Detector configuration settings
+-- ========================================
+
+DELETE FROM system_settings
+WHERE setting_key IN (
+  'detector_orphaned_co_demand_enabled',
+  'detector_orphaned_co_demand_horizon_days',
+  'detector_orphaned_co_demand_include_item_types',
+  'detector_orphaned_co_demand_exclude_item_types',
+  'detector_orphaned_co_demand_min_quantity_threshold'
+);
@@ -0,0 +1,83 @@
+-- ========================================
+-- ORPHANED CO DEMAND DETECTOR
+-- ========================================
+-- Adds configuration settings for the Orphaned CO Demand Detector
+-- This detector identifies open CO lines (status 20-29) with remaining quantity (RNQT) that no
+-- MO/MOP in production_orders is linked to, and whose delivery date (CODT, or DWDT when not yet
+-- confirmed) falls inside the planning horizon. Item types come from MITMAS (customer_order_lines.item_type)
+
+INSERT INTO system_settings (environment, setting_key, setting_value, setting_type, description, category, constraints) VALUES
+
+    -- TRN Environment Settings
+    ('TRN', 'detector_orphaned_co_demand_enabled',
+     'true',
+     'boolean',
+     'Enable detection of open CO lines with remaining quantity and no linked MO/MOP',
+     'detection',
+     '{}'::jsonb),
+
+    ('TRN', 'detector_orphaned_co_demand_horizon_days',
+     '{"global": 28, "overrides": []}',
+     'json',
+     'Only flag CO lines with a delivery date within N days from today - overdue lines are always flagged (hierarchical)',
+     'detection',
+     '{"min": 1, "max": 365, "unit": "days", "hierarchical": true}'::jsonb),
+
+    ('TRN', 'detector_orphaned_co_demand_include_item_types',
+     '[]',
+     'json',
+     'Only flag CO lines for these MITMAS item types (e.g., ["FG", "SA"]) - empty means all item types',
+     'detection',
+     '{}'::jsonb),
+
+    ('TRN', 'detector_orphaned_co_demand_exclude_item_types',
+     '[]',
+     'json',
+     'Never flag CO lines for these MITMAS item types (e.g., purchased or service items)',
+     'detection',
+     '{}'::jsonb),
+
+    ('TRN', 'detector_orphaned_co_demand_min_quantity_threshold',
+     '0',
+     'float',
+     'Only flag CO lines with remaining quantity >= threshold (0 = all)',
+     'detection',
+     '{"min": 0, "unit": "quantity"}'::jsonb),
+
+    -- PRD Environment Settings
+    ('PRD', 'detector_orphaned_co_demand_enabled',
+     'true',
+     'boolean',
+     'Enable detection of open CO lines with remaining quantity and no linked MO/MOP',
+     'detection',
+     '{}'::jsonb),
+
+    ('PRD', 'detector_orphaned_co_demand_horizon_days',
+     '{"global": 28, "overrides": []}',
+     'json',
+     'Only flag CO lines with a delivery date within N days from today - overdue lines are always flagged (hierarchical)',
+     'detection',
+     '{"min": 1, "max": 365, "unit": "days", "hierarchical": true}'::jsonb),
+
+    ('PRD', 'detector_orphaned_co_demand_include_item_types',
+     '[]',
+     'json',
+     'Only flag CO lines for these MITMAS item types (e.g., ["FG", "SA"]) - empty means all item types',
+     'detection',
+     '{}'::jsonb),
+
+    ('PRD', 'detector_orphaned_co_demand_exclude_item_types',
+     '[]',
+     'json',
+     'Never flag CO lines for these MITMAS item types (e.g., purchased or service items)',
+     'detection',
+     '{}'::jsonb),
+
+    ('PRD', 'detector_orphaned_co_demand_min_quantity_threshold',
+     '0',
+     'float',
+     'Only flag CO lines with remaining quantity >= threshold (0 = all)',
+     'detection',
+     '{"min": 0, "unit": "quantity"}'::jsonb)
+
+ON CONFLICT (environment, setting_key) DO NOTHING;
@@ -0,0 +1,7 @@
+DELETE FROM refresh_job_phases WHERE phase_type = 'supply_chain';
+ALTER TABLE refresh_job_phases DROP CONSTRAINT IF EXISTS chk_phase_type;
+ALTER TABLE refresh_job_phases ADD CONSTRAINT chk_phase_type
+    CHECK (phase_type IN ('mops', 'mos', 'cos', 'materials', 'operations'));
+
+DROP TABLE IF EXISTS supply_chain_links_staging;
+DROP TABLE IF EXISTS supply_chain_links;
@@ -0,0 +1,72 @@
+-- ========================================
+-- Supply Chain Links Snapshot Table
+-- ========================================
+-- Loaded per facility by the "supply_chain" data job of a snapshot refresh and read by the
+-- supply chain (pegging) API:
+--   supply_chain_links  MPREAL - pre-allocation links pegging an acquisition order
+--                       (AOCA/ARDN/ARDL/ARDX) to a demand order (DOCA/DRDN/DRDL/DRDX)
+-- All links of a multi-level chain (CO line -> DO/PO -> MO -> sub-level MOs/MOPs) share the
+-- chain's SCNB. The facility is the one of the link's warehouse (MITWHL.FACI).
+-- All M3 fields are stored as VARCHAR, as received from Data Fabric (see migration 014).
+
+CREATE TABLE supply_chain_links (
+    id BIGSERIAL PRIMARY KEY,
+    environment VARCHAR(10) NOT NULL,
+
+    -- M3 Core Identifiers
+    cono VARCHAR(10) NOT NULL,
+    faci VARCHAR(10) NOT NULL,
+    whlo VARCHAR(10),
+    itno VARCHAR(50),
+
+    -- Acquisition (supply) order: category, number, line, suffix
+    aoca VARCHAR(10) NOT NULL,
+    ardn VARCHAR(50) NOT NULL,
+    ardl VARCHAR(10) NOT NULL,
+    ardx VARCHAR(10) NOT NULL,
+
+    -- Demand order: category, number, line, suffix
+    doca VARCHAR(10) NOT NULL,
+    drdn VARCHAR(50) NOT NULL,
+    drdl VARCHAR(10) NOT NULL,
+    drdx VARCHAR(10) NOT NULL,
+
+    -- Pegged quantity (basic U/M, remaining)
+    pqty VARCHAR(30),
+    pqtr VARCHAR(30),
+
+    -- Supply Chain Number
+    scnb VARCHAR(20) NOT NULL,
+
+    -- Planning
+    resp VARCHAR(20),
+    paty VARCHAR(10),
+
+    -- M3 Audit Fields
+    lmdt VARCHAR(10),
+    m3_timestamp TEXT,
+
+    -- Application Metadata
+    sync_timestamp TIMESTAMP DEFAULT NOW(),
+    created_at TIMESTAMP DEFAULT NOW(),
+    updated_at TIMESTAMP DEFAULT NOW(),
+
+    CONSTRAINT unique_supply_chain_link UNIQUE (environment, cono, aoca, ardn, ardl, ardx, doca, drdn, drdl, drdx)
+);
+
+CREATE INDEX idx_supply_chain_links_scnb ON supply_chain_links(environment, scnb);
+CREATE INDEX idx_supply_chain_links_acquisition ON supply_chain_links(environment, ardn);
+CREATE INDEX idx_supply_chain_links_demand ON supply_chain_links(environment, drdn);
+CREATE INDEX idx_supply_chain_links_facility ON supply_chain_links(environment, faci);
+
+COMMENT ON TABLE supply_chain_links IS 'MPREAL pre-allocation links, loaded by the supply_chain data job and walked by the pegging API';
+
+-- Staging generation (see migration 061)
+CREATE TABLE IF NOT EXISTS supply_chain_links_staging (LIKE supply_chain_links INCLUDING ALL);
+
+COMMENT ON TABLE supply_chain_links_staging IS 'Staging generation of supply_chain_links, promoted to live after a successful snapshot refresh';
+
+-- Track the supply chain data job as a refresh phase
+ALTER TABLE refresh_job_phases DROP CONSTRAINT IF EXISTS chk_phase_type;
+ALTER TABLE refresh_job_phases ADD CONSTRAINT chk_phase_type
+    CHECK (phase_type IN ('mops', 'mos', 'cos', 'materials', 'operations', 'supply_chain'));
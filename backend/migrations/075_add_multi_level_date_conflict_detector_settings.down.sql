@@ -0,0 +1,11 @@
+-- ========================================
+-- Rollback Migration 075: Remove Multi-Level Date Conflict Detector configuration settings
+-- ========================================
+
+DELETE FROM system_settings
+WHERE setting_key IN (
+  'detector_multi_level_date_conflict_enabled',
+  'detector_multi_level_date_conflict_tolerance_days',
+  'detector_multi_level_date_conflict_exclude_mo_statuses',
+  'detector_multi_level_date_conflict_exclude_mop_statuses'
+);
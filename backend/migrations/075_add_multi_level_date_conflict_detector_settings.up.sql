@@ -0,0 +1,70 @@
+-- ========================================
+-- MULTI-LEVEL DATE CONFLICT DETECTOR
+-- ========================================
+-- Adds configuration settings for the Multi-Level Date Conflict Detector
+-- This detector walks the MO hierarchy (MFLO/MFHL from MWOHED) and the MOP hierarchy
+-- (PLLO/PLHL from MMOPLP) and identifies lower-level orders whose finish date (FIDT) is after
+-- the start date (STDT) of the parent order they feed. Each issue carries the full chain up to
+-- the top-level order
+
+INSERT INTO system_settings (environment, setting_key, setting_value, setting_type, description, category, constraints) VALUES
+
+    -- TRN Environment Settings
+    ('TRN', 'detector_multi_level_date_conflict_enabled',
+     'true',
+     'boolean',
+     'Enable detection of lower-level MOs/MOPs finishing after the parent order they feed starts',
+     'detection',
+     '{}'::jsonb),
+
+    ('TRN', 'detector_multi_level_date_conflict_tolerance_days',
+     '{"global": 0, "overrides": []}',
+     'json',
+     'Only flag lower-level orders finishing more than N days after the parent start date (hierarchical)',
+     'detection',
+     '{"min": 0, "max": 90, "unit": "days", "hierarchical": true}'::jsonb),
+
+    ('TRN', 'detector_multi_level_date_conflict_exclude_mo_statuses',
+     '["90"]',
+     'json',
+     'Skip MOs with these WHST codes, as component or as parent (default: skip finished)',
+     'detection',
+     '{}'::jsonb),
+
+    ('TRN', 'detector_multi_level_date_conflict_exclude_mop_statuses',
+     '[]',
+     'json',
+     'Skip MOPs with these PSTS codes, as component or as parent',
+     'detection',
+     '{}'::jsonb),
+
+    -- PRD Environment Settings
+    ('PRD', 'detector_multi_level_date_conflict_enabled',
+     'true',
+     'boolean',
+     'Enable detection of lower-level MOs/MOPs finishing after the parent order they feed starts',
+     'detection',
+     '{}'::jsonb),
+
+    ('PRD', 'detector_multi_level_date_conflict_tolerance_days',
+     '{"global": 0, "overrides": []}',
+     'json',
+     'Only flag lower-level orders finishing more than N days after the parent start date (hierarchical)',
+     'detection',
+     '{"min": 0, "max": 90, "unit": "days", "hierarchical": true}'::jsonb),
+
+    ('PRD', 'detector_multi_level_date_conflict_exclude_mo_statuses',
+     '["90"]',
+     'json',
+     'Skip MOs with these WHST codes, as component or as parent (default: skip finished)',
+     'detection',
+     '{}'::jsonb),
+
+    ('PRD', 'detector_multi_level_date_conflict_exclude_mop_statuses',
+     '[]',
+     'json',
+     'Skip MOPs with these PSTS codes, as component or as parent',
+     'detection',
+     '{}'::jsonb)
+
+ON CONFLICT (environment, setting_key) DO NOTHING;
@@ -0,0 +1,5 @@
+-- ========================================
+-- Rollback Migration 076: Remove job credentials
+-- ========================================
+
+DROP TABLE IF EXISTS job_credentials;
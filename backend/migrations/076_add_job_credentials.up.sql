@@ -0,0 +1,36 @@
+-- ========================================
+-- Job Credentials (credential broker)
+-- ========================================
+-- NATS messages of a snapshot refresh carry an opaque, job-scoped credential reference instead
+-- of an OAuth access token. Workers exchange the reference for a token through the credential
+-- broker, which refreshes the token while the job runs:
+--   source 'user'             - tokens of the user who started the refresh (refresh token kept)
+--   source 'service_account'  - client credentials token of the environment's service account
+-- Only the SHA-256 hash of the reference is stored; tokens are encrypted (AES-GCM, key derived
+-- from SESSION_SECRET). A reference is valid until expires_at and only while its refresh job is
+-- pending or running. Rows are removed with their job or once expired.
+
+CREATE TABLE IF NOT EXISTS job_credentials (
+    reference_hash VARCHAR(64) PRIMARY KEY,
+    job_id VARCHAR(36) NOT NULL REFERENCES refresh_jobs(id) ON DELETE CASCADE,
+    environment VARCHAR(10) NOT NULL,
+    user_id VARCHAR(100),
+    source VARCHAR(20) NOT NULL,
+
+    -- Encrypted tokens (user source only; service account tokens are fetched on demand)
+    access_token TEXT,
+    refresh_token TEXT,
+    token_expiry TIMESTAMP,
+
+    expires_at TIMESTAMP NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMP NOT NULL DEFAULT NOW(),
+
+    CONSTRAINT chk_job_credentials_source CHECK (source IN ('user', 'service_account'))
+);
+
+CREATE INDEX idx_job_credentials_job_id ON job_credentials(job_id);
+CREATE INDEX idx_job_credentials_expires_at ON job_credentials(expires_at);
+
+COMMENT ON TABLE job_credentials IS 'Job-scoped credential references exchanged by workers for M3 OAuth tokens';
+COMMENT ON COLUMN job_credentials.reference_hash IS 'SHA-256 (hex) of the reference carried in NATS messages';
@@ -0,0 +1,2 @@
+-- Remove role-based authorization settings
+DELETE FROM system_settings WHERE setting_key IN ('role_mappings', 'default_role');
@@ -0,0 +1,21 @@
+-- ========================================
+-- Role-Based Authorization Settings
+-- ========================================
+-- Issue write actions (ignore, delete MOP/MO, close MO, align dates, bulk actions, audit revert)
+-- require a planning role in the issue's facility. Roles come from the Infor groups of the
+-- cached user profile:
+--   viewer         - read only
+--   planner        - ignore issues, delete MOPs, align order dates
+--   planning-admin - planner actions plus delete/close MOs and revert audit log entries
+-- role_mappings is a JSON array of:
+--   {"group": "M3-Planner-A01", "role": "planner", "facilities": ["A01"]}
+-- group matches the Infor group display name or value; omitted facilities (or "*") mean all
+-- facilities. Users get the highest role any matching mapping grants, and at least default_role.
+
+INSERT INTO system_settings (environment, setting_key, setting_value, setting_type, description, category, created_at)
+VALUES
+    ('TRN', 'role_mappings', '[{"group": "Infor-SystemAdministrator", "role": "planning-admin", "facilities": ["*"]}]', 'json', 'Role Mappings: List of {group, role, facilities} entries mapping Infor groups to viewer, planner or planning-admin per facility', 'authorization', NOW()),
+    ('PRD', 'role_mappings', '[{"group": "Infor-SystemAdministrator", "role": "planning-admin", "facilities": ["*"]}]', 'json', 'Role Mappings: List of {group, role, facilities} entries mapping Infor groups to viewer, planner or planning-admin per facility', 'authorization', NOW()),
+    ('TRN', 'default_role', 'viewer', 'string', 'Default Role: Role of users no mapping applies to (viewer, planner or planning-admin)', 'authorization', NOW()),
+    ('PRD', 'default_role', 'viewer', 'string', 'Default Role: Role of users no mapping applies to (viewer, planner or planning-admin)', 'authorization', NOW())
+ON CONFLICT (environment, setting_key) DO NOTHING;
@@ -0,0 +1,3 @@
+-- Remove action requests
+DELETE FROM system_settings WHERE setting_key = 'approval_required_operations';
+DROP TABLE IF EXISTS action_requests;
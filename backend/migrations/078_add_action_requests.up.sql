@@ -0,0 +1,51 @@
+-- ========================================
+-- Action Requests (Two-Person Approval)
+-- ========================================
+-- Destructive M3 operations listed in approval_required_operations are not executed
+-- directly. A planner submits an action request with a reason; a second user holding the
+-- operation's permission approves it (the server then executes the M3 transaction with the
+-- approver's session) or rejects it. The order is copied from the issue because detected
+-- issues are replaced on every refresh.
+
+CREATE TABLE action_requests (
+    id BIGSERIAL PRIMARY KEY,
+    environment VARCHAR(10) NOT NULL,
+    operation VARCHAR(30) NOT NULL,        -- delete_mo, close_mo
+    issue_id BIGINT NOT NULL,              -- No FK: issues are deleted on refresh
+    detector_type VARCHAR(50) NOT NULL,
+    company VARCHAR(10),
+    facility VARCHAR(10) NOT NULL,
+    order_type VARCHAR(10) NOT NULL,
+    order_number VARCHAR(20) NOT NULL,
+    order_status VARCHAR(10),              -- Order status when the request was submitted
+    reason TEXT NOT NULL,
+    status VARCHAR(20) NOT NULL DEFAULT 'pending',
+    requested_by VARCHAR(100) NOT NULL,
+    requested_by_name VARCHAR(255),
+    requested_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    reviewed_by VARCHAR(100),
+    reviewed_by_name VARCHAR(255),
+    reviewed_at TIMESTAMP,
+    review_comment TEXT,
+    executed_at TIMESTAMP,
+    error_message TEXT,
+    m3_response JSONB,
+    updated_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    CONSTRAINT chk_action_request_status CHECK (status IN ('pending', 'approved', 'rejected', 'cancelled', 'executed', 'failed'))
+);
+
+CREATE INDEX idx_action_requests_env_status ON action_requests(environment, status, requested_at DESC);
+
+-- One open request per order and operation
+CREATE UNIQUE INDEX idx_action_requests_open_order ON action_requests(environment, operation, facility, order_number)
+    WHERE status IN ('pending', 'approved');
+
+COMMENT ON TABLE action_requests IS 'Destructive M3 operations awaiting or past a second user''s approval';
+
+-- approval_required_operations is a JSON array of operations (delete_mo, close_mo) that
+-- need an approved action request instead of executing immediately
+INSERT INTO system_settings (environment, setting_key, setting_value, setting_type, description, category, created_at)
+VALUES
+    ('TRN', 'approval_required_operations', '[]', 'json', 'Approval Required Operations: Operations (delete_mo, close_mo) that need a second user''s approval before the M3 transaction runs', 'authorization', NOW()),
+    ('PRD', 'approval_required_operations', '["delete_mo", "close_mo"]', 'json', 'Approval Required Operations: Operations (delete_mo, close_mo) that need a second user''s approval before the M3 transaction runs', 'authorization', NOW())
+ON CONFLICT (environment, setting_key) DO NOTHING;
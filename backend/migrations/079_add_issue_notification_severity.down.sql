@@ -0,0 +1,2 @@
+-- Remove issue notification severities
+DELETE FROM system_settings WHERE setting_key = 'issue_notification_severity';
@@ -0,0 +1,7 @@
+-- Severity of each issue detector's new issues when matching notification subscriptions
+-- detected_issues has no severity of its own; detectors missing from the map count as "warning"
+INSERT INTO system_settings (environment, setting_key, setting_value, setting_type, description, category, created_at)
+VALUES
+    ('TRN', 'issue_notification_severity', '{"late_delivery": "critical", "material_shortage": "critical", "multi_level_date_conflict": "critical", "work_center_overload": "warning", "orphaned_co_demand": "warning", "co_quantity_mismatch": "warning", "dlix_date_mismatch": "warning", "joint_delivery_date_mismatch": "warning", "unlinked_production_orders": "info"}', 'json', 'Issue Notification Severity: Severity (info, warning, critical) of each detector''s new issues for subscription matching; unlisted detectors are warning', 'notifications', NOW()),
+    ('PRD', 'issue_notification_severity', '{"late_delivery": "critical", "material_shortage": "critical", "multi_level_date_conflict": "critical", "work_center_overload": "warning", "orphaned_co_demand": "warning", "co_quantity_mismatch": "warning", "dlix_date_mismatch": "warning", "joint_delivery_date_mismatch": "warning", "unlinked_production_orders": "info"}', 'json', 'Issue Notification Severity: Severity (info, warning, critical) of each detector''s new issues for subscription matching; unlisted detectors are warning', 'notifications', NOW())
+ON CONFLICT (environment, setting_key) DO NOTHING;
@@ -0,0 +1,13 @@
+-- ========================================
+-- ROLLBACK: RESTORE VIEWER DEFAULT ROLE
+-- ========================================
+-- Reverts default_role back to viewer
+-- Only reverts records that haven't been manually modified
+
+UPDATE system_settings
+SET
+    setting_value = 'viewer',
+    last_modified_at = CURRENT_TIMESTAMP
+WHERE setting_key = 'default_role'
+AND setting_value = 'planner'
+AND (last_modified_by IS NULL OR last_modified_by = '');
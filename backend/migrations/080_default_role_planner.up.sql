@@ -0,0 +1,16 @@
+-- ========================================
+-- DEFAULT ROLE: PLANNER
+-- ========================================
+-- Before role-based authorization every signed-in user could ignore issues, delete MOPs and
+-- align order dates. Seeding default_role as viewer (077) silently removed those actions from
+-- every user without a role mapping on upgrade, so unmapped users default to planner instead.
+-- Set default_role to viewer once role_mappings grant planner to the right Infor groups.
+-- Only updates records that are still at the seeded value (not modified by users)
+
+UPDATE system_settings
+SET
+    setting_value = 'planner',
+    last_modified_at = CURRENT_TIMESTAMP
+WHERE setting_key = 'default_role'
+AND setting_value = 'viewer'
+AND (last_modified_by IS NULL OR last_modified_by = '');
@@ -12,6 +12,7 @@ import Anomalies from './pages/Anomalies';
 import Settings from './pages/Settings';
 import Profile from './pages/Profile';
 import AuditLogs from './pages/AuditLogs';
+import ActionRequests from './pages/ActionRequests';
 
 // Protected route wrapper
 const ProtectedRoute: React.FC<{ children: React.ReactNode }> = ({ children }) => {
@@ -112,6 +113,14 @@ function App() {
               </ProtectedRouteWithContext>
             }
           />
+          <Route
+            path="/approvals"
+            element={
+              <ProtectedRouteWithContext>
+                <ActionRequests />
+              </ProtectedRouteWithContext>
+            }
+          />
         </Routes>
       </Router>
     </AuthProvider>
@@ -33,6 +33,14 @@ function AlertTriangleIcon({ className }: { className?: string }) {
   );
 }
 
+function CheckBadgeIcon({ className }: { className?: string }) {
+  return (
+    <svg className={className} fill="none" viewBox="0 0 24 24" strokeWidth={1.5} stroke="currentColor">
+      <path strokeLinecap="round" strokeLinejoin="round" d="M9 12.75L11.25 15 15 9.75M21 12c0 1.268-.63 2.39-1.593 3.068a3.745 3.745 0 01-1.043 3.296 3.745 3.745 0 01-3.296 1.043A3.745 3.745 0 0112 21c-1.268 0-2.39-.63-3.068-1.593a3.746 3.746 0 01-3.296-1.043 3.745 3.745 0 01-1.043-3.296A3.745 3.745 0 013 12c0-1.268.63-2.39 1.593-3.068a3.745 3.745 0 011.043-3.296 3.746 3.746 0 013.296-1.043A3.746 3.746 0 0112 3c1.268 0 2.39.63 3.068 1.593a3.746 3.746 0 013.296 1.043 3.746 3.746 0 011.043 3.296A3.745 3.745 0 0121 12z" />
+    </svg>
+  );
+}
+
 function UserIcon({ className }: { className?: string }) {
   return (
     <svg className={className} fill="none" viewBox="0 0 24 24" strokeWidth={1.5} stroke="currentColor">
@@ -84,7 +92,7 @@ function XMarkIcon({ className }: { className?: string }) {
 
 export const AppLayout: React.FC<AppLayoutProps> = ({ children }) => {
   const location = useLocation();
-  const { environment, logout, userProfile } = useAuth();
+  const { environment, isProduction, logout, userProfile } = useAuth();
   const { effectiveContext } = useContextManagement();
   const [contextSwitcherOpen, setContextSwitcherOpen] = useState(false);
   const [mobileMenuOpen, setMobileMenuOpen] = useState(false);
@@ -113,6 +121,7 @@ export const AppLayout: React.FC<AppLayoutProps> = ({ children }) => {
     { name: 'Dashboard', href: '/', icon: HomeIcon },
     { name: 'Issues', href: '/issues', icon: ExclamationIcon },
     { name: 'Anomalies', href: '/anomalies', icon: AlertTriangleIcon, count: anomalyCount },
+    { name: 'Approvals', href: '/approvals', icon: CheckBadgeIcon },
     { name: 'Audit Log', href: '/audit-logs', icon: DocumentTextIcon },
     ...(isAdmin ? [{ name: 'Settings', href: '/settings', icon: CogIcon }] : []),
     { name: 'Profile', href: '/profile', icon: UserIcon },
@@ -127,7 +136,7 @@ export const AppLayout: React.FC<AppLayoutProps> = ({ children }) => {
   };
 
   const handleSwitchEnvironment = async () => {
-    if (window.confirm(`Switch to ${environment === 'TRN' ? 'PRD' : 'TRN'} environment? This will log you out.`)) {
+    if (window.confirm(`Switch away from the ${environment} environment? This will log you out.`)) {
       await handleLogout();
     }
   };
@@ -153,7 +162,7 @@ export const AppLayout: React.FC<AppLayoutProps> = ({ children }) => {
           <span className="text-sm font-semibold text-white">Planning Tools</span>
         </div>
         <span className={`px-2 py-0.5 rounded text-xs font-bold ${
-          environment === 'PRD' ? 'bg-error-500 text-white' : 'bg-primary-500 text-white'
+          isProduction ? 'bg-error-500 text-white' : 'bg-primary-500 text-white'
         }`}>
           {environment}
         </span>
@@ -193,14 +202,14 @@ export const AppLayout: React.FC<AppLayoutProps> = ({ children }) => {
             <button
               onClick={handleSwitchEnvironment}
               className={`w-full flex items-center justify-between px-3 py-2 rounded-lg text-sm font-medium transition-colors ${
-                environment === 'PRD'
+                isProduction
                   ? 'bg-error-500/20 text-error-400 hover:bg-error-500/30'
                   : 'bg-primary-500/20 text-primary-400 hover:bg-primary-500/30'
               }`}
             >
               <span>Environment</span>
               <span className={`px-2 py-0.5 rounded text-xs font-bold ${
-                environment === 'PRD' ? 'bg-error-500 text-white' : 'bg-primary-500 text-white'
+                isProduction ? 'bg-error-500 text-white' : 'bg-primary-500 text-white'
               }`}>
                 {environment}
               </span>
@@ -9,6 +9,8 @@ interface ConfirmModalProps {
   onConfirm: () => void;
   onCancel: () => void;
   isDestructive?: boolean;
+  confirmDisabled?: boolean;
+  children?: React.ReactNode; // Extra content below the message, e.g. form fields
 }
 
 export const ConfirmModal: React.FC<ConfirmModalProps> = ({
@@ -20,6 +22,8 @@ export const ConfirmModal: React.FC<ConfirmModalProps> = ({
   onConfirm,
   onCancel,
   isDestructive = false,
+  confirmDisabled = false,
+  children,
 }) => {
   if (!isOpen) return null;
 
@@ -60,6 +64,7 @@ export const ConfirmModal: React.FC<ConfirmModalProps> = ({
                 <div className="mt-2">
                   <p className="text-sm text-slate-500">{message}</p>
                 </div>
+                {children && <div className="mt-4">{children}</div>}
               </div>
             </div>
           </div>
@@ -67,7 +72,8 @@ export const ConfirmModal: React.FC<ConfirmModalProps> = ({
             <button
               type="button"
               onClick={onConfirm}
-              className={`inline-flex w-full justify-center rounded-md px-3 py-2 text-sm font-semibold text-white shadow-sm sm:ml-3 sm:w-auto ${
+              disabled={confirmDisabled}
+              className={`inline-flex w-full justify-center rounded-md px-3 py-2 text-sm font-semibold text-white shadow-sm disabled:cursor-not-allowed disabled:opacity-50 sm:ml-3 sm:w-auto ${
                 isDestructive
                   ? 'bg-red-600 hover:bg-red-500 focus-visible:outline focus-visible:outline-2 focus-visible:outline-offset-2 focus-visible:outline-red-600'
                   : 'bg-primary-600 hover:bg-primary-500 focus-visible:outline focus-visible:outline-2 focus-visible:outline-offset-2 focus-visible:outline-primary-600'
@@ -1,6 +1,8 @@
 import React from 'react';
 import { ActionMenuButton, ActionMenuItem } from './ActionMenuButton';
 import { Trash2, XCircle, Calendar, EyeOff, Eye, Info } from 'lucide-react';
+import type { EffectivePermissions, Permission } from '../types';
+import { hasPermission } from '../utils/permissions';
 
 interface Issue {
   id: number;
@@ -21,6 +23,8 @@ interface Issue {
 
 export interface IssueActionsMenuProps {
   issue: Issue;
+  permissions: EffectivePermissions | null; // Write actions the user's role doesn't grant in the issue's facility are hidden
+  approvalRequired?: string[]; // Operations (delete_mo, close_mo) that are submitted as action requests
   onIgnore: (issueId: number) => void;
   onUnignore: (issueId: number) => void;
   onDeleteMOP: (issue: Issue) => void;
@@ -68,6 +72,8 @@ function WrenchIcon({ className }: { className?: string }) {
 
 export const IssueActionsMenu: React.FC<IssueActionsMenuProps> = ({
   issue,
+  permissions,
+  approvalRequired = [],
   onIgnore,
   onUnignore,
   onDeleteMOP,
@@ -80,29 +86,38 @@ export const IssueActionsMenu: React.FC<IssueActionsMenuProps> = ({
   // Build the list of available actions based on issue type and status
   const getAvailableActions = (): ActionMenuItem[] => {
     const actions: ActionMenuItem[] = [];
-
-    // Ignore/Unignore - ALWAYS available (first item)
-    if (issue.isIgnored) {
-      actions.push({
-        id: 'unignore',
-        label: 'Unignore Issue',
-        icon: <Eye className="h-4 w-4" />,
-        variant: 'default',
-        disabled: false,
-      });
-    } else {
-      actions.push({
-        id: 'ignore',
-        label: 'Ignore Issue',
-        icon: <EyeOff className="h-4 w-4" />,
-        variant: 'default',
-        disabled: false,
-      });
+    const can = (permission: Permission) =>
+      hasPermission(permissions, permission, issue.facility);
+    // Operations that need approval are offered to anyone who may request them
+    const canRun = (operation: string, permission: Permission) =>
+      approvalRequired.includes(operation) ? can('issues.request') : can(permission);
+    const labelFor = (operation: string, label: string) =>
+      approvalRequired.includes(operation) ? `Request ${label}` : label;
+
+    // Ignore/Unignore - first item, for roles that may ignore issues
+    if (can('issues.ignore')) {
+      if (issue.isIgnored) {
+        actions.push({
+          id: 'unignore',
+          label: 'Unignore Issue',
+          icon: <Eye className="h-4 w-4" />,
+          variant: 'default',
+          disabled: false,
+        });
+      } else {
+        actions.push({
+          id: 'ignore',
+          label: 'Ignore Issue',
+          icon: <EyeOff className="h-4 w-4" />,
+          variant: 'default',
+          disabled: false,
+        });
+      }
     }
 
     // Delete - For unlinked_production_orders
     if (issue.detectorType === 'unlinked_production_orders') {
-      if (issue.productionOrderType === 'MOP') {
+      if (issue.productionOrderType === 'MOP' && can('issues.delete_mop')) {
         actions.push({
           id: 'delete-mop',
           label: 'Delete MOP',
@@ -110,10 +125,10 @@ export const IssueActionsMenu: React.FC<IssueActionsMenuProps> = ({
           variant: 'danger',
           disabled: false,
         });
-      } else if (canDeleteMO(issue)) {
+      } else if (canDeleteMO(issue) && canRun('delete_mo', 'issues.delete_mo')) {
         actions.push({
           id: 'delete-mo',
-          label: 'Delete MO',
+          label: labelFor('delete_mo', 'Delete MO'),
           icon: <Trash2 className="h-4 w-4" />,
           variant: 'danger',
           disabled: false,
@@ -121,10 +136,10 @@ export const IssueActionsMenu: React.FC<IssueActionsMenuProps> = ({
       }
 
       // Close action - for closeable MOs
-      if (canCloseMO(issue)) {
+      if (canCloseMO(issue) && canRun('close_mo', 'issues.close_mo')) {
         actions.push({
           id: 'close-mo',
-          label: 'Close MO',
+          label: labelFor('close_mo', 'Close MO'),
           icon: <XCircle className="h-4 w-4" />,
           variant: 'warning',
           disabled: false,
@@ -146,21 +161,23 @@ export const IssueActionsMenu: React.FC<IssueActionsMenuProps> = ({
         disabled: false,
       });
 
-      actions.push({
-        id: 'align-earliest',
-        label: 'Align to Earliest Date',
-        icon: <Calendar className="h-4 w-4" />,
-        variant: 'info',
-        disabled: false,
-      });
+      if (can('issues.align')) {
+        actions.push({
+          id: 'align-earliest',
+          label: 'Align to Earliest Date',
+          icon: <Calendar className="h-4 w-4" />,
+          variant: 'info',
+          disabled: false,
+        });
 
-      actions.push({
-        id: 'align-latest',
-        label: 'Align to Latest Date',
-        icon: <Calendar className="h-4 w-4" />,
-        variant: 'success',
-        disabled: false,
-      });
+        actions.push({
+          id: 'align-latest',
+          label: 'Align to Latest Date',
+          icon: <Calendar className="h-4 w-4" />,
+          variant: 'success',
+          disabled: false,
+        });
+      }
     }
 
     return actions;
@@ -197,6 +214,11 @@ export const IssueActionsMenu: React.FC<IssueActionsMenuProps> = ({
 
   const actions = getAvailableActions();
 
+  // Nothing to offer (e.g. a viewer on an issue without details)
+  if (actions.length === 0) {
+    return null;
+  }
+
   return (
     <ActionMenuButton
       label="Fix"
@@ -1,14 +1,16 @@
 import React, { createContext, useContext, useState, useEffect, ReactNode } from 'react';
 import { api } from '../services/api';
-import type { AuthStatus, UserContext, UserProfile } from '../types';
+import type { AuthStatus, M3Environment, UserContext, UserProfile } from '../types';
 
 interface AuthContextType {
   isAuthenticated: boolean;
-  environment?: 'TRN' | 'PRD';
+  environment?: string;
+  environments: M3Environment[];
+  isProduction: boolean;
   userContext?: UserContext;
   userProfile?: UserProfile;
   loading: boolean;
-  login: (environment: 'TRN' | 'PRD') => Promise<void>;
+  login: (environment: string) => Promise<void>;
   logout: () => Promise<void>;
   setUserContext: (context: UserContext) => Promise<void>;
   refreshProfile: () => Promise<void>;
@@ -18,7 +20,8 @@ const AuthContext = createContext<AuthContextType | undefined>(undefined);
 
 export const AuthProvider: React.FC<{ children: ReactNode }> = ({ children }) => {
   const [isAuthenticated, setIsAuthenticated] = useState(false);
-  const [environment, setEnvironment] = useState<'TRN' | 'PRD' | undefined>();
+  const [environment, setEnvironment] = useState<string | undefined>();
+  const [environments, setEnvironments] = useState<M3Environment[]>([]);
   const [userContext, setUserContextState] = useState<UserContext | undefined>();
   const [userProfile, setUserProfile] = useState<UserProfile | undefined>();
   const [loading, setLoading] = useState(true);
@@ -26,8 +29,19 @@ export const AuthProvider: React.FC<{ children: ReactNode }> = ({ children }) =>
   // Check authentication status on mount
   useEffect(() => {
     checkAuthStatus();
+    loadEnvironments();
   }, []);
 
+  const loadEnvironments = async () => {
+    try {
+      setEnvironments(await api.getEnvironments());
+    } catch (error) {
+      console.error('Failed to load environments:', error);
+    }
+  };
+
+  const isProduction = environments.find((env) => env.key === environment)?.production ?? false;
+
   const checkAuthStatus = async () => {
     try {
       const status: AuthStatus = await api.getAuthStatus();
@@ -43,7 +57,7 @@ export const AuthProvider: React.FC<{ children: ReactNode }> = ({ children }) =>
     }
   };
 
-  const login = async (env: 'TRN' | 'PRD') => {
+  const login = async (env: string) => {
     try {
       const { authUrl } = await api.login(env);
       // Redirect to OAuth provider
@@ -92,6 +106,8 @@ export const AuthProvider: React.FC<{ children: ReactNode }> = ({ children }) =>
       value={{
         isAuthenticated,
         environment,
+        environments,
+        isProduction,
         userContext,
         userProfile,
         loading,
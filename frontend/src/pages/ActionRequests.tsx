@@ -0,0 +1,305 @@
+import React, { useState, useEffect } from 'react';
+import { AppLayout } from '../components/AppLayout';
+import { ConfirmModal } from '../components/ConfirmModal';
+import { api } from '../services/api';
+import type { ActionRequest, ActionRequestStatus, EffectivePermissions } from '../types';
+import { hasPermission } from '../utils/permissions';
+import { ToastContainer } from '../components/Toast';
+import { useToast } from '../hooks/useToast';
+
+const operationLabels: Record<string, string> = {
+  delete_mo: 'Delete MO',
+  close_mo: 'Close MO',
+};
+
+const statusBadgeColors: Record<ActionRequestStatus, string> = {
+  pending: 'bg-yellow-100 text-yellow-800',
+  approved: 'bg-blue-100 text-blue-800',
+  rejected: 'bg-slate-100 text-slate-800',
+  cancelled: 'bg-slate-100 text-slate-600',
+  executed: 'bg-green-100 text-green-800',
+  failed: 'bg-red-100 text-red-800',
+};
+
+type ReviewAction = 'approve' | 'reject' | 'cancel';
+
+const ActionRequests: React.FC = () => {
+  const toast = useToast();
+
+  const [requests, setRequests] = useState<ActionRequest[]>([]);
+  const [approvalRequired, setApprovalRequired] = useState<string[]>([]);
+  const [permissions, setPermissions] = useState<EffectivePermissions | null>(null);
+  const [loading, setLoading] = useState(true);
+  const [selectedStatus, setSelectedStatus] = useState<ActionRequestStatus | ''>('pending');
+
+  // Request being approved, rejected or cancelled
+  const [reviewing, setReviewing] = useState<{ request: ActionRequest; action: ReviewAction } | null>(null);
+  const [comment, setComment] = useState('');
+  const [isSubmitting, setIsSubmitting] = useState(false);
+
+  useEffect(() => {
+    api.getPermissions()
+      .then(setPermissions)
+      .catch((error) => console.error('Failed to fetch permissions:', error));
+  }, []);
+
+  useEffect(() => {
+    loadRequests();
+  }, [selectedStatus]);
+
+  const loadRequests = async () => {
+    setLoading(true);
+    try {
+      const response = await api.getActionRequests(selectedStatus || undefined);
+      setRequests(response.requests);
+      setApprovalRequired(response.approvalRequiredOperations);
+    } catch (err: any) {
+      console.error('Failed to load action requests:', err);
+      toast.error(err.response?.data || 'Failed to load action requests');
+    } finally {
+      setLoading(false);
+    }
+  };
+
+  // Approvers need the operation's own permission and must not be the requester
+  const canReview = (request: ActionRequest) =>
+    request.status === 'pending' &&
+    permissions !== null &&
+    request.requestedBy !== permissions.userId &&
+    hasPermission(permissions, `issues.${request.operation}`, request.facility);
+
+  const canCancel = (request: ActionRequest) =>
+    request.status === 'pending' && permissions !== null && request.requestedBy === permissions.userId;
+
+  const openReview = (request: ActionRequest, action: ReviewAction) => {
+    setComment('');
+    setReviewing({ request, action });
+  };
+
+  const handleReviewConfirm = async () => {
+    if (!reviewing) return;
+
+    const { request, action } = reviewing;
+    const label = `${operationLabels[request.operation] || request.operation} ${request.orderNumber}`;
+    setIsSubmitting(true);
+    try {
+      if (action === 'approve') {
+        await api.approveActionRequest(request.id, comment.trim() || undefined);
+        toast.success(`Approved: ${label} executed in M3`);
+      } else if (action === 'reject') {
+        await api.rejectActionRequest(request.id, comment.trim() || undefined);
+        toast.success(`Rejected: ${label}`);
+      } else {
+        await api.cancelActionRequest(request.id);
+        toast.success(`Cancelled: ${label}`);
+      }
+      setReviewing(null);
+    } catch (err: any) {
+      console.error(`Failed to ${action} action request:`, err);
+      const data = err.response?.data;
+      toast.error(data?.error || (typeof data === 'string' && data) || `Failed to ${action} request. Please try again.`);
+      setReviewing(null);
+    } finally {
+      setIsSubmitting(false);
+      await loadRequests();
+    }
+  };
+
+  const formatDateTime = (dateStr?: string) => {
+    if (!dateStr) return '-';
+    return new Date(dateStr).toLocaleString();
+  };
+
+  const reviewTitles: Record<ReviewAction, string> = {
+    approve: 'Approve Request',
+    reject: 'Reject Request',
+    cancel: 'Cancel Request',
+  };
+
+  const reviewMessage = (request: ActionRequest, action: ReviewAction) => {
+    const label = `${operationLabels[request.operation] || request.operation} ${request.orderNumber} (${request.facility})`;
+    switch (action) {
+      case 'approve':
+        return `Approving runs ${label} in M3 immediately using your session. This cannot be undone. Reason given: "${request.reason}"`;
+      case 'reject':
+        return `Reject the request to ${label}? The order is left unchanged.`;
+      default:
+        return `Withdraw your request to ${label}?`;
+    }
+  };
+
+  return (
+    <AppLayout>
+      <ToastContainer toasts={toast.toasts} onClose={toast.removeToast} />
+      <div className="px-4 py-6 sm:px-6 lg:px-12 lg:py-10">
+        <div className="max-w-full mx-auto">
+          {/* Header */}
+          <div className="mb-6">
+            <h1 className="text-3xl font-bold text-slate-900">Approvals</h1>
+            <p className="mt-2 text-sm text-slate-600">
+              {approvalRequired.length > 0
+                ? `${approvalRequired.map((op) => operationLabels[op] || op).join(' and ')} require approval by a second user in this environment`
+                : 'No operations require approval in this environment'}
+            </p>
+          </div>
+
+          {/* Status Filter */}
+          <div className="bg-white shadow rounded-lg p-6 mb-6">
+            <label htmlFor="status" className="block text-sm font-medium text-slate-700 mb-1">
+              Status
+            </label>
+            <select
+              id="status"
+              value={selectedStatus}
+              onChange={(e) => setSelectedStatus(e.target.value as ActionRequestStatus | '')}
+              className="w-full md:w-64 px-3 py-2 border border-slate-300 rounded-md shadow-sm focus:outline-none focus:ring-2 focus:ring-blue-500 focus:border-blue-500"
+            >
+              <option value="pending">Pending</option>
+              <option value="executed">Executed</option>
+              <option value="failed">Failed</option>
+              <option value="rejected">Rejected</option>
+              <option value="cancelled">Cancelled</option>
+              <option value="">All</option>
+            </select>
+          </div>
+
+          {/* Requests Table */}
+          <div className="bg-white shadow rounded-lg overflow-hidden">
+            {loading ? (
+              <div className="p-8 text-center">
+                <div className="inline-block h-8 w-8 animate-spin rounded-full border-4 border-solid border-blue-600 border-r-transparent"></div>
+                <p className="mt-2 text-sm text-slate-600">Loading requests...</p>
+              </div>
+            ) : requests.length === 0 ? (
+              <div className="p-8 text-center text-slate-500">
+                No requests found
+              </div>
+            ) : (
+              <div className="overflow-x-auto">
+                <table className="min-w-full divide-y divide-slate-200">
+                  <thead className="bg-slate-50">
+                    <tr>
+                      <th scope="col" className="px-6 py-3 text-left text-xs font-medium text-slate-500 uppercase tracking-wider">
+                        Requested
+                      </th>
+                      <th scope="col" className="px-6 py-3 text-left text-xs font-medium text-slate-500 uppercase tracking-wider">
+                        Operation
+                      </th>
+                      <th scope="col" className="px-6 py-3 text-left text-xs font-medium text-slate-500 uppercase tracking-wider">
+                        Order
+                      </th>
+                      <th scope="col" className="px-6 py-3 text-left text-xs font-medium text-slate-500 uppercase tracking-wider">
+                        Reason
+                      </th>
+                      <th scope="col" className="px-6 py-3 text-left text-xs font-medium text-slate-500 uppercase tracking-wider">
+                        Status
+                      </th>
+                      <th scope="col" className="px-6 py-3 text-left text-xs font-medium text-slate-500 uppercase tracking-wider">
+                        Actions
+                      </th>
+                    </tr>
+                  </thead>
+                  <tbody className="bg-white divide-y divide-slate-200">
+                    {requests.map((request) => (
+                      <tr key={request.id} className="hover:bg-slate-50 align-top">
+                        <td className="px-6 py-4 whitespace-nowrap text-sm text-slate-900">
+                          <div>{formatDateTime(request.requestedAt)}</div>
+                          <div className="text-slate-500">{request.requestedByName || request.requestedBy}</div>
+                        </td>
+                        <td className="px-6 py-4 whitespace-nowrap text-sm font-medium text-slate-900">
+                          {operationLabels[request.operation] || request.operation}
+                        </td>
+                        <td className="px-6 py-4 whitespace-nowrap text-sm text-slate-900">
+                          <div>{request.orderType} {request.orderNumber}</div>
+                          <div className="text-slate-500">
+                            {request.facility}
+                            {request.orderStatus && ` · status ${request.orderStatus}`}
+                          </div>
+                        </td>
+                        <td className="px-6 py-4 text-sm text-slate-700 max-w-md whitespace-pre-wrap">
+                          {request.reason}
+                        </td>
+                        <td className="px-6 py-4 whitespace-nowrap text-sm">
+                          <span className={`inline-flex items-center px-2.5 py-0.5 rounded-full text-xs font-medium ${statusBadgeColors[request.status]}`}>
+                            {request.status}
+                          </span>
+                          {request.reviewedBy && (
+                            <div className="mt-1 text-slate-500">
+                              by {request.reviewedByName || request.reviewedBy}, {formatDateTime(request.reviewedAt)}
+                            </div>
+                          )}
+                          {request.reviewComment && (
+                            <div className="mt-1 text-slate-500 italic">{request.reviewComment}</div>
+                          )}
+                          {request.errorMessage && (
+                            <div className="mt-1 text-red-600">{request.errorMessage}</div>
+                          )}
+                        </td>
+                        <td className="px-6 py-4 whitespace-nowrap text-sm space-x-3">
+                          {canReview(request) && (
+                            <>
+                              <button
+                                onClick={() => openReview(request, 'approve')}
+                                className="text-green-700 hover:text-green-900 font-medium focus:outline-none"
+                              >
+                                Approve
+                              </button>
+                              <button
+                                onClick={() => openReview(request, 'reject')}
+                                className="text-red-600 hover:text-red-900 font-medium focus:outline-none"
+                              >
+                                Reject
+                              </button>
+                            </>
+                          )}
+                          {canCancel(request) && (
+                            <button
+                              onClick={() => openReview(request, 'cancel')}
+                              className="text-slate-600 hover:text-slate-900 font-medium focus:outline-none"
+                            >
+                              Cancel
+                            </button>
+                          )}
+                        </td>
+                      </tr>
+                    ))}
+                  </tbody>
+                </table>
+              </div>
+            )}
+          </div>
+        </div>
+      </div>
+
+      {/* Review Confirmation Modal */}
+      <ConfirmModal
+        isOpen={reviewing !== null}
+        title={reviewing ? reviewTitles[reviewing.action] : ''}
+        message={reviewing ? reviewMessage(reviewing.request, reviewing.action) : ''}
+        confirmLabel={isSubmitting ? 'Submitting...' : reviewing ? reviewTitles[reviewing.action].split(' ')[0] : 'Confirm'}
+        cancelLabel="Back"
+        onConfirm={handleReviewConfirm}
+        onCancel={() => setReviewing(null)}
+        isDestructive={reviewing?.action === 'approve'}
+        confirmDisabled={isSubmitting}
+      >
+        {reviewing && reviewing.action !== 'cancel' && (
+          <div>
+            <label htmlFor="review-comment" className="block text-sm font-medium text-slate-700 mb-1">
+              Comment (optional)
+            </label>
+            <textarea
+              id="review-comment"
+              rows={2}
+              value={comment}
+              onChange={(e) => setComment(e.target.value)}
+              className="w-full px-3 py-2 border border-slate-300 rounded-md shadow-sm text-sm focus:outline-none focus:ring-2 focus:ring-blue-500 focus:border-blue-500"
+            />
+          </div>
+        )}
+      </ConfirmModal>
+    </AppLayout>
+  );
+};
+
+export default ActionRequests;
@@ -120,6 +120,7 @@ const AuditLogs: React.FC = () => {
                 >
                   <option value="">All Entity Types</option>
                   <option value="issue">Issue</option>
+                  <option value="action_request">Action Request</option>
                   <option value="jdcd_group">JDCD Group</option>
                   <option value="context_cache">Context Cache</option>
                 </select>
@@ -146,6 +147,11 @@ const AuditLogs: React.FC = () => {
                   <option value="delete_mo">Delete MO</option>
                   <option value="close_mo">Close MO</option>
                   <option value="align_earliest">Align Earliest</option>
+                  <option value="submit">Submit Request</option>
+                  <option value="approve">Approve Request</option>
+                  <option value="reject">Reject Request</option>
+                  <option value="cancel">Cancel Request</option>
+                  <option value="execution_failed">Approved Action Failed</option>
                   <option value="refresh_all">Refresh All</option>
                 </select>
               </div>
@@ -78,7 +78,7 @@ const stats = [
 ];
 
 const Dashboard: React.FC = () => {
-  const { isAuthenticated, loading: authLoading } = useAuth();
+  const { isAuthenticated, environment, loading: authLoading } = useAuth();
   const { effectiveContext, loadEffectiveContext } = useContextManagement();
   const toast = useToast();
   const [summary, setSummary] = useState<SnapshotSummary | null>(null);
@@ -276,7 +276,7 @@ const Dashboard: React.FC = () => {
             </div>
             <div className="flex items-center gap-2">
               <DetectorTrigger
-                environment={effectiveContext?.environment || 'PRD'}
+                environment={effectiveContext?.environment || environment || ''}
                 disabled={refreshing || snapshotStatus?.status === 'running' || !summary}
                 onTrigger={(jobId) => {
                   console.log('Detection triggered:', jobId);
@@ -378,6 +378,9 @@ const Dashboard: React.FC = () => {
                     label={
                       phase.phase === 'mops' ? 'Planned Manufacturing Orders' :
                       phase.phase === 'mos' ? 'Manufacturing Orders' :
+                      phase.phase === 'materials' ? 'Materials & Stock Balances' :
+                      phase.phase === 'operations' ? 'Operations & Work Centers' :
+                      phase.phase === 'supply_chain' ? 'Supply Chain Links' :
                       'Customer Order Lines'
                     }
                   />
@@ -4,7 +4,7 @@ import { AppLayout } from '../components/AppLayout';
 import { buildM3BookmarkURL, M3Config } from '../utils/m3Links';
 import { dateDiffDays, getVarianceBadgeColor } from '../utils/m3DateUtils';
 import { api } from '../services/api';
-import { Issue, AnomalySummary } from '../types';
+import { Issue, AnomalySummary, EffectivePermissions, ActionRequestOperation } from '../types';
 import { ConfirmModal } from '../components/ConfirmModal';
 import { JointDeliveryDetailModal } from '../components/JointDeliveryDetailModal';
 import { ToastContainer } from '../components/Toast';
@@ -92,6 +92,27 @@ function formatM3DateRelative(dateStr: string | number): { relative: string; abs
   return { relative, absolute };
 }
 
+// Reason field of an action request submitted for approval
+const ActionRequestReasonInput: React.FC<{ value: string; onChange: (value: string) => void }> = ({
+  value,
+  onChange,
+}) => (
+  <div>
+    <label htmlFor="action-request-reason" className="block text-sm font-medium text-slate-700 mb-1">
+      Reason
+    </label>
+    <textarea
+      id="action-request-reason"
+      rows={3}
+      maxLength={2000}
+      value={value}
+      onChange={(e) => onChange(e.target.value)}
+      placeholder="Why should this order be removed?"
+      className="w-full px-3 py-2 border border-slate-300 rounded-md shadow-sm text-sm focus:outline-none focus:ring-2 focus:ring-blue-500 focus:border-blue-500"
+    />
+  </div>
+);
+
 const Issues: React.FC = () => {
   const [summary, setSummary] = useState<IssueSummary | null>(null);
   const [issues, setIssues] = useState<Issue[]>([]);
@@ -100,6 +121,9 @@ const Issues: React.FC = () => {
   const [selectedWarehouse, setSelectedWarehouse] = useState<string>('');
   const [showIgnored, setShowIgnored] = useState<boolean>(false);
   const [m3Config, setM3Config] = useState<M3Config | null>(null);
+  const [permissions, setPermissions] = useState<EffectivePermissions | null>(null);
+  const [approvalRequired, setApprovalRequired] = useState<ActionRequestOperation[]>([]);
+  const [requestReason, setRequestReason] = useState('');
   const [detectorLabels, setDetectorLabels] = useState<Record<string, string>>({});
   const [deleteModalOpen, setDeleteModalOpen] = useState(false);
   const [issueToDelete, setIssueToDelete] = useState<Issue | null>(null);
@@ -147,6 +171,8 @@ const Issues: React.FC = () => {
 
     // Fetch config and summary once on mount
     fetchM3Config();
+    fetchPermissions();
+    fetchApprovalRequired();
     fetchSummary();
 
     // Mark as initialized to allow fetching
@@ -208,6 +234,23 @@ const Issues: React.FC = () => {
     }
   };
 
+  const fetchPermissions = async () => {
+    try {
+      setPermissions(await api.getPermissions());
+    } catch (error) {
+      console.error('Failed to fetch permissions:', error);
+    }
+  };
+
+  const fetchApprovalRequired = async () => {
+    try {
+      const { approvalRequiredOperations } = await api.getActionRequests('pending');
+      setApprovalRequired(approvalRequiredOperations);
+    } catch (error) {
+      console.error('Failed to fetch approval settings:', error);
+    }
+  };
+
   const fetchSummary = async () => {
     try {
       const data = await api.getIssueSummary(showIgnored);
@@ -308,15 +351,41 @@ const Issues: React.FC = () => {
     return !isNaN(statusNum) && statusNum > 22;
   };
 
+  // Submits an MO delete/close for a second user's approval instead of executing it
+  const submitActionRequest = async (issue: Issue, operation: ActionRequestOperation) => {
+    try {
+      await api.createActionRequest(issue.id, operation, requestReason.trim());
+      setRequestReason('');
+      const label = operation === 'delete_mo' ? 'Delete' : 'Close';
+      toast.success(`${label} request for MO ${issue.productionOrderNumber} submitted for approval`);
+      return true;
+    } catch (err: any) {
+      console.error('Failed to submit action request:', err);
+      toast.error(err.response?.data || 'Failed to submit request. Please try again.');
+      return false;
+    }
+  };
+
   // Delete MO handlers
   const handleDeleteMOClick = (issue: Issue) => {
     setIssueToDelete(issue);
+    setRequestReason('');
     setDeleteModalOpen(true);
   };
 
   const handleDeleteMOConfirm = async () => {
     if (!issueToDelete) return;
 
+    if (approvalRequired.includes('delete_mo')) {
+      setIsDeleting(true);
+      if (await submitActionRequest(issueToDelete, 'delete_mo')) {
+        setDeleteModalOpen(false);
+        setIssueToDelete(null);
+      }
+      setIsDeleting(false);
+      return;
+    }
+
     setIsDeleting(true);
     try {
       const moNumber = issueToDelete.productionOrderNumber;
@@ -338,12 +407,23 @@ const Issues: React.FC = () => {
   // Close MO handlers
   const handleCloseMOClick = (issue: Issue) => {
     setIssueToClose(issue);
+    setRequestReason('');
     setCloseMOModalOpen(true);
   };
 
   const handleCloseMOConfirm = async () => {
     if (!issueToClose) return;
 
+    if (approvalRequired.includes('close_mo')) {
+      setIsClosing(true);
+      if (await submitActionRequest(issueToClose, 'close_mo')) {
+        setCloseMOModalOpen(false);
+        setIssueToClose(null);
+      }
+      setIsClosing(false);
+      return;
+    }
+
     setIsClosing(true);
     try {
       const moNumber = issueToClose.productionOrderNumber;
@@ -716,6 +796,8 @@ const Issues: React.FC = () => {
                       <td className="whitespace-nowrap px-6 py-4 text-sm">
                         <IssueActionsMenu
                           issue={issue}
+                          permissions={permissions}
+                          approvalRequired={approvalRequired}
                           onIgnore={handleIgnore}
                           onUnignore={handleUnignore}
                           onDeleteMOP={handleDeleteMOPClick}
@@ -803,29 +885,58 @@ const Issues: React.FC = () => {
           )}
         </div>
 
-        {/* Delete MOP/MO Confirmation Modal */}
-        <ConfirmModal
-          isOpen={deleteModalOpen}
-          title={issueToDelete?.productionOrderType === 'MOP' ? 'Delete Manufacturing Order Proposal' : 'Delete Manufacturing Order'}
-          message={`Are you sure you want to delete ${issueToDelete?.productionOrderType} ${issueToDelete?.productionOrderNumber}? This action cannot be undone and will permanently delete the order from M3.`}
-          confirmLabel={isDeleting ? 'Deleting...' : `Delete ${issueToDelete?.productionOrderType || 'Order'}`}
-          cancelLabel="Cancel"
-          onConfirm={issueToDelete?.productionOrderType === 'MOP' ? handleDeleteMOPConfirm : handleDeleteMOConfirm}
-          onCancel={handleDeleteMOPCancel}
-          isDestructive={true}
-        />
+        {/* Delete MOP/MO Confirmation Modal (MO deletes may need approval) */}
+        {issueToDelete?.productionOrderType === 'MO' && approvalRequired.includes('delete_mo') ? (
+          <ConfirmModal
+            isOpen={deleteModalOpen}
+            title="Request Manufacturing Order Deletion"
+            message={`Deleting MO ${issueToDelete?.productionOrderNumber} requires approval by a second user. The MO is deleted from M3 once the request is approved.`}
+            confirmLabel={isDeleting ? 'Submitting...' : 'Submit Request'}
+            cancelLabel="Cancel"
+            onConfirm={handleDeleteMOConfirm}
+            onCancel={handleDeleteMOPCancel}
+            isDestructive={true}
+            confirmDisabled={isDeleting || requestReason.trim() === ''}
+          >
+            <ActionRequestReasonInput value={requestReason} onChange={setRequestReason} />
+          </ConfirmModal>
+        ) : (
+          <ConfirmModal
+            isOpen={deleteModalOpen}
+            title={issueToDelete?.productionOrderType === 'MOP' ? 'Delete Manufacturing Order Proposal' : 'Delete Manufacturing Order'}
+            message={`Are you sure you want to delete ${issueToDelete?.productionOrderType} ${issueToDelete?.productionOrderNumber}? This action cannot be undone and will permanently delete the order from M3.`}
+            confirmLabel={isDeleting ? 'Deleting...' : `Delete ${issueToDelete?.productionOrderType || 'Order'}`}
+            cancelLabel="Cancel"
+            onConfirm={issueToDelete?.productionOrderType === 'MOP' ? handleDeleteMOPConfirm : handleDeleteMOConfirm}
+            onCancel={handleDeleteMOPCancel}
+            isDestructive={true}
+          />
+        )}
 
         {/* Close MO Confirmation Modal */}
         <ConfirmModal
           isOpen={closeMOModalOpen}
-          title="Close Manufacturing Order"
-          message={`Are you sure you want to close MO ${issueToClose?.productionOrderNumber}? This will mark the order as complete in M3. This action cannot be undone.`}
-          confirmLabel={isClosing ? 'Closing...' : 'Close MO'}
+          title={approvalRequired.includes('close_mo') ? 'Request Manufacturing Order Close' : 'Close Manufacturing Order'}
+          message={
+            approvalRequired.includes('close_mo')
+              ? `Closing MO ${issueToClose?.productionOrderNumber} requires approval by a second user. The MO is closed in M3 once the request is approved.`
+              : `Are you sure you want to close MO ${issueToClose?.productionOrderNumber}? This will mark the order as complete in M3. This action cannot be undone.`
+          }
+          confirmLabel={
+            approvalRequired.includes('close_mo')
+              ? (isClosing ? 'Submitting...' : 'Submit Request')
+              : (isClosing ? 'Closing...' : 'Close MO')
+          }
           cancelLabel="Cancel"
           onConfirm={handleCloseMOConfirm}
           onCancel={handleCloseMOCancel}
           isDestructive={true}
-        />
+          confirmDisabled={approvalRequired.includes('close_mo') && (isClosing || requestReason.trim() === '')}
+        >
+          {approvalRequired.includes('close_mo') && (
+            <ActionRequestReasonInput value={requestReason} onChange={setRequestReason} />
+          )}
+        </ConfirmModal>
 
         {/* Align Earliest Confirmation Modal */}
         <ConfirmModal
@@ -915,6 +1026,188 @@ const IssueDetailsCell: React.FC<{
     );
   }
 
+  if (detectorType === 'co_quantity_mismatch') {
+    const variance = Number(issueData.quantity_variance) || 0;
+    const orders: Array<Record<string, any>> = issueData.production_orders || [];
+    const pendingPutaway = orders.filter((order) => Number(order.pending_putaway_qty) > 0).length;
+
+    return (
+      <div className="text-xs">
+        <div>
+          Orders: <span className="font-medium">{issueData.total_po_quantity}</span>
+          {' / '}CO remaining: <span className="font-medium">{issueData.co_remaining_quantity}</span>
+        </div>
+        <div>
+          <span className={`font-semibold ${variance > 0 ? 'text-amber-700' : 'text-red-700'}`}>
+            {variance > 0 ? `+${variance}` : variance} {variance > 0 ? 'over-supplied' : 'under-supplied'}
+          </span>
+        </div>
+        {issueData.countable_po_count !== issueData.total_po_count && (
+          <div className="text-slate-400">
+            {issueData.countable_po_count} of {issueData.total_po_count} orders counted
+          </div>
+        )}
+        {pendingPutaway > 0 && (
+          <div className="text-slate-400">{pendingPutaway} pending putaway</div>
+        )}
+      </div>
+    );
+  }
+
+  if (detectorType === 'material_shortage') {
+    const startDate = issueData.start_date ? formatM3DateRelative(issueData.start_date) : null;
+    const components: Array<Record<string, any>> = issueData.components || [];
+
+    return (
+      <div className="text-xs">
+        {components.slice(0, 3).map((component) => (
+          <div key={`${component.warehouse}-${component.component}-${component.sequence}`}>
+            {component.component}:{' '}
+            <span className="font-semibold text-red-700">
+              short {component.shortage_quantity} {component.unit}
+            </span>
+          </div>
+        ))}
+        {components.length > 3 && (
+          <div className="text-slate-400">+{components.length - 3} more components</div>
+        )}
+        {startDate && (
+          <div>
+            Start:{' '}
+            <span
+              className="cursor-help border-b border-dotted border-slate-400"
+              title={startDate.absolute}
+            >
+              {startDate.relative}
+            </span>
+          </div>
+        )}
+      </div>
+    );
+  }
+
+  if (detectorType === 'late_delivery') {
+    const finishDate = issueData.planned_finish_date ? formatM3DateRelative(issueData.planned_finish_date) : null;
+    const deliveryDate = issueData.confirmed_delivery_date || issueData.requested_delivery_date;
+
+    return (
+      <div className="text-xs">
+        <div>
+          <span className="font-semibold text-red-700">{issueData.days_late} days late</span>
+        </div>
+        {finishDate && (
+          <div>
+            Finish:{' '}
+            <span
+              className="cursor-help border-b border-dotted border-slate-400"
+              title={finishDate.absolute}
+            >
+              {finishDate.relative}
+            </span>
+          </div>
+        )}
+        {deliveryDate && (
+          <div>
+            {issueData.delivery_date_source === 'requested' ? 'Requested' : 'Confirmed'}: {formatM3Date(deliveryDate)}
+          </div>
+        )}
+        {issueData.lead_time_days > 0 && (
+          <div className="text-slate-400">Lead time: {issueData.lead_time_days} days</div>
+        )}
+      </div>
+    );
+  }
+
+  if (detectorType === 'orphaned_co_demand') {
+    const deliveryDate = issueData.confirmed_delivery_date || issueData.requested_delivery_date;
+    const daysUntil = issueData.days_until_delivery;
+
+    return (
+      <div className="text-xs">
+        <div>
+          <span className="font-medium">{issueData.item_number}</span>
+          {issueData.item_type && <span className="text-slate-500"> ({issueData.item_type})</span>}
+        </div>
+        <div>
+          Remaining: <span className="font-semibold text-red-700">{issueData.remaining_quantity}</span>
+        </div>
+        {deliveryDate && (
+          <div>
+            {issueData.delivery_date_source === 'requested' ? 'Requested' : 'Confirmed'}: {formatM3Date(deliveryDate)}
+            {daysUntil !== undefined && daysUntil < 0 && (
+              <span className="text-red-700"> ({-daysUntil} days overdue)</span>
+            )}
+          </div>
+        )}
+        {issueData.customer_name && (
+          <div className="text-slate-400">{issueData.customer_name}</div>
+        )}
+      </div>
+    );
+  }
+
+  if (detectorType === 'multi_level_date_conflict') {
+    const chain: Array<Record<string, any>> = issueData.chain || [];
+
+    return (
+      <div className="text-xs">
+        <div>
+          <span className="font-semibold text-red-700">{issueData.days_late} days after parent start</span>
+        </div>
+        <div>
+          Finish: {formatM3Date(issueData.finish_date)} / {issueData.parent_order_number} start:{' '}
+          {formatM3Date(issueData.parent_start_date)}
+        </div>
+        {chain.length > 0 && (
+          <div
+            className="text-slate-400 cursor-help"
+            title={chain
+              .map((link) => `${link.order_type} ${link.order_number} ${link.item_number}: ${formatM3Date(link.start_date)} - ${formatM3Date(link.finish_date)}`)
+              .join('\n')}
+          >
+            {chain.map((link) => link.item_number).join(' > ')}
+          </div>
+        )}
+      </div>
+    );
+  }
+
+  if (detectorType === 'work_center_overload') {
+    const orders: Array<Record<string, any>> = issueData.orders || [];
+    const numOrders = issueData.num_orders || orders.length;
+
+    return (
+      <div className="text-xs">
+        <div>
+          <span className="font-medium">{issueData.work_center}</span>
+          {issueData.work_center_name && (
+            <span className="text-slate-500"> {issueData.work_center_name}</span>
+          )}
+        </div>
+        <div>
+          {issueData.bucket_start && formatM3Date(issueData.bucket_start)}
+          {issueData.bucket_days > 1 && issueData.bucket_end && ` – ${formatM3Date(issueData.bucket_end)}`}
+        </div>
+        <div>
+          <span className="font-semibold text-red-700">
+            {issueData.load_hours}h / {issueData.capacity_hours}h
+          </span>
+          {issueData.utilization_percent !== undefined && (
+            <span className="text-slate-500"> ({issueData.utilization_percent}%)</span>
+          )}
+        </div>
+        {orders.slice(0, 3).map((order) => (
+          <div key={`${order.order_type}-${order.order_number}`} className="text-slate-500">
+            {order.order_type} {order.order_number}: {order.hours}h
+          </div>
+        ))}
+        {numOrders > 3 && (
+          <div className="text-slate-400">+{numOrders - 3} more orders</div>
+        )}
+      </div>
+    );
+  }
+
   if (detectorType === 'joint_delivery_date_mismatch' || detectorType === 'dlix_date_mismatch') {
     // Validate required data exists
     if (!issueData.min_date || !issueData.max_date) {
@@ -1,9 +1,27 @@
 import React, { useState } from 'react';
 import { useAuth } from '../contexts/AuthContext';
 
+// Full class names per accent so Tailwind keeps them; production environments are shown in red
+const accentClasses = {
+  primary: {
+    ring: 'focus:ring-primary-500',
+    selected: 'border-primary-500 bg-primary-500/10',
+    text: 'text-primary-400',
+    dot: 'bg-primary-500',
+  },
+  error: {
+    ring: 'focus:ring-error-500',
+    selected: 'border-error-500 bg-error-500/10',
+    text: 'text-error-400',
+    dot: 'bg-error-500',
+  },
+};
+
 const Login: React.FC = () => {
-  const { login } = useAuth();
-  const [selectedEnv, setSelectedEnv] = useState<'TRN' | 'PRD'>('TRN');
+  const { login, environments } = useAuth();
+  const [selectedEnv, setSelectedEnv] = useState<string | undefined>();
+  // Default to the first configured environment until the user picks one
+  const activeEnv = selectedEnv ?? environments[0]?.key;
   const [loading, setLoading] = useState(false);
   const [error, setError] = useState<string | null>(null);
 
@@ -11,7 +29,10 @@ const Login: React.FC = () => {
     setLoading(true);
     setError(null);
     try {
-      await login(selectedEnv);
+      if (!activeEnv) {
+        throw new Error('No environment selected');
+      }
+      await login(activeEnv);
     } catch (err) {
       setError('Failed to initiate login. Please try again.');
       setLoading(false);
@@ -49,45 +70,32 @@ const Login: React.FC = () => {
               Select Environment
             </label>
             <div className="grid grid-cols-2 gap-3">
-              <button
-                type="button"
-                onClick={() => setSelectedEnv('TRN')}
-                className={`relative flex flex-col items-center justify-center rounded-lg border-2 py-4 px-4 transition-all duration-200 focus:outline-none focus:ring-2 focus:ring-primary-500 focus:ring-offset-2 focus:ring-offset-slate-800 ${
-                  selectedEnv === 'TRN'
-                    ? 'border-primary-500 bg-primary-500/10'
-                    : 'border-slate-600 bg-slate-700/50 hover:border-slate-500 hover:bg-slate-700'
-                }`}
-              >
-                <span className={`text-2xl font-bold ${selectedEnv === 'TRN' ? 'text-primary-400' : 'text-slate-300'}`}>
-                  TRN
-                </span>
-                <span className={`mt-1 text-xs ${selectedEnv === 'TRN' ? 'text-primary-400' : 'text-slate-500'}`}>
-                  Training
-                </span>
-                {selectedEnv === 'TRN' && (
-                  <div className="absolute -top-1 -right-1 h-3 w-3 rounded-full bg-primary-500 ring-2 ring-slate-800" />
-                )}
-              </button>
-
-              <button
-                type="button"
-                onClick={() => setSelectedEnv('PRD')}
-                className={`relative flex flex-col items-center justify-center rounded-lg border-2 py-4 px-4 transition-all duration-200 focus:outline-none focus:ring-2 focus:ring-error-500 focus:ring-offset-2 focus:ring-offset-slate-800 ${
-                  selectedEnv === 'PRD'
-                    ? 'border-error-500 bg-error-500/10'
-                    : 'border-slate-600 bg-slate-700/50 hover:border-slate-500 hover:bg-slate-700'
-                }`}
-              >
-                <span className={`text-2xl font-bold ${selectedEnv === 'PRD' ? 'text-error-400' : 'text-slate-300'}`}>
-                  PRD
-                </span>
-                <span className={`mt-1 text-xs ${selectedEnv === 'PRD' ? 'text-error-400' : 'text-slate-500'}`}>
-                  Production
-                </span>
-                {selectedEnv === 'PRD' && (
-                  <div className="absolute -top-1 -right-1 h-3 w-3 rounded-full bg-error-500 ring-2 ring-slate-800" />
-                )}
-              </button>
+              {environments.map((env) => {
+                const selected = activeEnv === env.key;
+                const accent = accentClasses[env.production ? 'error' : 'primary'];
+                return (
+                  <button
+                    key={env.key}
+                    type="button"
+                    onClick={() => setSelectedEnv(env.key)}
+                    className={`relative flex flex-col items-center justify-center rounded-lg border-2 py-4 px-4 transition-all duration-200 focus:outline-none focus:ring-2 ${accent.ring} focus:ring-offset-2 focus:ring-offset-slate-800 ${
+                      selected
+                        ? accent.selected
+                        : 'border-slate-600 bg-slate-700/50 hover:border-slate-500 hover:bg-slate-700'
+                    }`}
+                  >
+                    <span className={`text-2xl font-bold ${selected ? accent.text : 'text-slate-300'}`}>
+                      {env.key}
+                    </span>
+                    <span className={`mt-1 text-xs ${selected ? accent.text : 'text-slate-500'}`}>
+                      {env.label}
+                    </span>
+                    {selected && (
+                      <div className={`absolute -top-1 -right-1 h-3 w-3 rounded-full ${accent.dot} ring-2 ring-slate-800`} />
+                    )}
+                  </button>
+                );
+              })}
             </div>
           </div>
 
@@ -102,7 +110,7 @@ const Login: React.FC = () => {
           <button
             type="button"
             onClick={handleLogin}
-            disabled={loading}
+            disabled={loading || !activeEnv}
             className="w-full flex justify-center items-center gap-2 rounded-lg bg-primary-600 px-4 py-3 text-sm font-semibold text-white shadow-sm transition-all duration-200 hover:bg-primary-500 focus:outline-none focus:ring-2 focus:ring-primary-500 focus:ring-offset-2 focus:ring-offset-slate-800 disabled:opacity-50 disabled:cursor-not-allowed"
           >
             {loading ? (
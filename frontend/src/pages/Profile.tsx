@@ -226,7 +226,7 @@ const Profile: React.FC = () => {
 // Basic Info Tab Component
 interface BasicInfoTabProps {
   userProfile: any;
-  environment?: 'TRN' | 'PRD';
+  environment?: string;
 }
 
 const BasicInfoTab: React.FC<BasicInfoTabProps> = ({ userProfile, environment }) => (
@@ -428,6 +428,84 @@ const SystemSettingsForm: React.FC<SystemSettingsFormProps> = ({
               onSettingsChange(newSettings);
             }}
           />
+
+          {/* CO Quantity Mismatch Detector Section */}
+          <DetectorSection
+            detectorName="co_quantity_mismatch"
+            detectorLabel="CO Line Quantity Mismatches"
+            detectorDescription="Detects customer order lines where production order quantities don't match remaining quantity (RNQA), accounting for pending putaway status"
+            settings={settings.categories['detection']}
+            onSettingsChange={(updated) => {
+              const newSettings = { ...settings };
+              newSettings.categories['detection'] = updated;
+              onSettingsChange(newSettings);
+            }}
+          />
+
+          {/* Material Shortage Detector Section */}
+          <DetectorSection
+            detectorName="material_shortage"
+            detectorLabel="Material Shortages"
+            detectorDescription="Detects MOs and firmed MOPs starting within the horizon whose components are not covered by on-hand stock"
+            settings={settings.categories['detection']}
+            onSettingsChange={(updated) => {
+              const newSettings = { ...settings };
+              newSettings.categories['detection'] = updated;
+              onSettingsChange(newSettings);
+            }}
+          />
+
+          {/* Work Center Overload Detector Section */}
+          <DetectorSection
+            detectorName="work_center_overload"
+            detectorLabel="Work Center Overloads"
+            detectorDescription="Detects work centers whose planned MO and MOP operation hours exceed their capacity per day or week. Capacity is resources x hours per resource x working days from a fixed weekday pattern; M3 work center calendars (holidays, shutdowns, shift patterns) are not used"
+            settings={settings.categories['detection']}
+            onSettingsChange={(updated) => {
+              const newSettings = { ...settings };
+              newSettings.categories['detection'] = updated;
+              onSettingsChange(newSettings);
+            }}
+          />
+
+          {/* Late Delivery Detector Section */}
+          <DetectorSection
+            detectorName="late_delivery"
+            detectorLabel="Late to Delivery"
+            detectorDescription="Detects production orders finishing after their linked CO line's confirmed delivery date minus transport lead time"
+            settings={settings.categories['detection']}
+            onSettingsChange={(updated) => {
+              const newSettings = { ...settings };
+              newSettings.categories['detection'] = updated;
+              onSettingsChange(newSettings);
+            }}
+          />
+
+          {/* Orphaned CO Demand Detector Section */}
+          <DetectorSection
+            detectorName="orphaned_co_demand"
+            detectorLabel="Orphaned CO Demand"
+            detectorDescription="Detects open customer order lines with remaining quantity, no linked MO/MOP and a delivery date inside the planning horizon"
+            settings={settings.categories['detection']}
+            onSettingsChange={(updated) => {
+              const newSettings = { ...settings };
+              newSettings.categories['detection'] = updated;
+              onSettingsChange(newSettings);
+            }}
+          />
+
+          {/* Multi-Level Date Conflict Detector Section */}
+          <DetectorSection
+            detectorName="multi_level_date_conflict"
+            detectorLabel="Component After Parent Start"
+            detectorDescription="Detects lower-level MOs/MOPs finishing after the start date of the parent order they feed in a multi-level structure"
+            settings={settings.categories['detection']}
+            onSettingsChange={(updated) => {
+              const newSettings = { ...settings };
+              newSettings.categories['detection'] = updated;
+              onSettingsChange(newSettings);
+            }}
+          />
         </div>
 
         {/* Save Button */}
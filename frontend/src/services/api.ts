@@ -1,10 +1,19 @@
 import axios, { AxiosInstance } from 'axios';
 import type {
   AuthStatus,
+  ActionRequest,
+  ActionRequestList,
+  ActionRequestOperation,
+  ActionRequestStatus,
+  EffectivePermissions,
+  M3Environment,
   UserContext,
   UserProfile,
-  ManufacturingOrder,
-  PlannedManufacturingOrder,
+  ProductionOrder,
+  ProductionOrderFilters,
+  ProductionOrderDetail,
+  OrderDetail,
+  CursorPagination,
   Issue,
   SnapshotStatus,
   SnapshotSummary,
@@ -20,6 +29,14 @@ import type {
   RefreshResult,
   AuditLog,
   AuditLogFilters,
+  AuditLogRevertResult,
+  NotificationSubscription,
+  NotificationSubscriptionInput,
+  BulkIssueActionRequest,
+  BulkIssueActionPlan,
+  BulkActionItem,
+  SupplyChainTree,
+  OrderSupplyChains,
 } from '../types';
 
 // IssueSummary represents aggregated issue counts from the backend
@@ -94,7 +111,12 @@ class ApiService {
   }
 
   // Authentication
-  async login(environment: 'TRN' | 'PRD'): Promise<{ authUrl: string }> {
+  async getEnvironments(): Promise<M3Environment[]> {
+    const response = await this.client.get('/auth/environments');
+    return response.data;
+  }
+
+  async login(environment: string): Promise<{ authUrl: string }> {
     const response = await this.client.post('/auth/login', { environment });
     return response.data;
   }
@@ -190,8 +212,16 @@ class ApiService {
   }
 
   // Snapshot Management
-  async refreshSnapshot(): Promise<{ jobId: string; status: string; message: string }> {
-    const response = await this.client.post('/snapshot/refresh');
+  async refreshSnapshot(
+    mode?: 'full' | 'incremental',
+    options?: { facilities?: string[]; allFacilities?: boolean }
+  ): Promise<{ jobId: string; status: string; refreshMode: string; facilities: string[]; message: string }> {
+    const body = {
+      ...(mode && { mode }),
+      ...(options?.facilities?.length && { facilities: options.facilities }),
+      ...(options?.allFacilities && { allFacilities: true }),
+    };
+    const response = await this.client.post('/snapshot/refresh', Object.keys(body).length ? body : undefined);
     return response.data;
   }
 
@@ -215,18 +245,66 @@ class ApiService {
     return response.data;
   }
 
+  // Production Orders (unified MO/MOP view, keyset pagination)
+  async listProductionOrders(
+    filters?: ProductionOrderFilters
+  ): Promise<{ data: ProductionOrder[]; pagination: CursorPagination }> {
+    const params = new URLSearchParams();
+    if (filters?.facility) params.append('facility', filters.facility);
+    if (filters?.warehouse) params.append('warehouse', filters.warehouse);
+    if (filters?.item) params.append('item', filters.item);
+    if (filters?.product) params.append('product', filters.product);
+    if (filters?.orderType) params.append('order_type', filters.orderType);
+    if (filters?.moTypes?.length) params.append('mo_type', filters.moTypes.join(','));
+    if (filters?.statuses?.length) params.append('status', filters.statuses.join(','));
+    if (filters?.startFrom) params.append('start_from', filters.startFrom);
+    if (filters?.startTo) params.append('start_to', filters.startTo);
+    if (filters?.finishFrom) params.append('finish_from', filters.finishFrom);
+    if (filters?.finishTo) params.append('finish_to', filters.finishTo);
+    if (filters?.coNumber) params.append('co_number', filters.coNumber);
+    if (filters?.coLine) params.append('co_line', filters.coLine);
+    if (filters?.includeDeleted) params.append('include_deleted', 'true');
+    if (filters?.sort) params.append('sort', filters.sort);
+    if (filters?.order) params.append('order', filters.order);
+    if (filters?.pageSize) params.append('page_size', filters.pageSize.toString());
+    if (filters?.cursor) params.append('cursor', filters.cursor);
+
+    const response = await this.client.get(`/production-orders?${params}`);
+    return response.data;
+  }
+
+  async getProductionOrder(id: number): Promise<ProductionOrderDetail> {
+    const response = await this.client.get(`/production-orders/${id}`);
+    return response.data;
+  }
+
   // Manufacturing Orders (full details)
-  async getManufacturingOrder(id: number): Promise<ManufacturingOrder> {
+  async getManufacturingOrder(id: number): Promise<OrderDetail> {
     const response = await this.client.get(`/manufacturing-orders/${id}`);
     return response.data;
   }
 
   // Planned Orders (full details)
-  async getPlannedOrder(id: number): Promise<PlannedManufacturingOrder> {
+  async getPlannedOrder(id: number): Promise<OrderDetail> {
     const response = await this.client.get(`/planned-orders/${id}`);
     return response.data;
   }
 
+  // Supply chain (MPREAL pegging trees)
+  async getSupplyChain(scnb: string): Promise<SupplyChainTree> {
+    const response = await this.client.get(`/supply-chain/${encodeURIComponent(scnb)}`);
+    return response.data;
+  }
+
+  async getSupplyChainsForOrder(orderType: string, orderNumber: string, line?: string): Promise<OrderSupplyChains> {
+    const params = line ? { line } : {};
+    const response = await this.client.get(
+      `/supply-chain/orders/${encodeURIComponent(orderType)}/${encodeURIComponent(orderNumber)}`,
+      { params }
+    );
+    return response.data;
+  }
+
   // Issues
   async getIssueSummary(includeIgnored: boolean = false): Promise<IssueSummary> {
     const params = includeIgnored ? { include_ignored: 'true' } : {};
@@ -234,11 +312,24 @@ class ApiService {
     return response.data;
   }
 
+  async getIssueTrends(params?: {
+    days?: number;
+    detector_type?: string;
+    facility?: string;
+    warehouse?: string;
+    group_by?: 'detector_type' | 'facility' | 'warehouse';
+    issue_key?: string;
+  }) {
+    const response = await this.client.get('/issues/trends', { params });
+    return response.data;
+  }
+
   async listIssues(params?: {
     severity?: string;
     type?: string;
     warehouse?: string;
     includeIgnored?: boolean;
+    newOnly?: boolean;
     page?: number;
     pageSize?: number;
   }): Promise<PaginatedResponse<Issue>> {
@@ -247,6 +338,7 @@ class ApiService {
     if (params?.type) queryParams.detector_type = params.type;
     if (params?.warehouse) queryParams.warehouse = params.warehouse;
     if (params?.includeIgnored) queryParams.include_ignored = 'true';
+    if (params?.newOnly) queryParams.new_only = 'true';
     if (params?.page) queryParams.page = params.page;
     if (params?.pageSize) queryParams.page_size = params.pageSize;
 
@@ -254,6 +346,12 @@ class ApiService {
     return response.data;
   }
 
+  // Effective issue write permissions of the current user, per facility
+  async getPermissions(): Promise<EffectivePermissions> {
+    const response = await this.client.get<EffectivePermissions>('/permissions');
+    return response.data;
+  }
+
   async ignoreIssue(issueId: number, notes?: string): Promise<void> {
     await this.client.post(`/issues/${issueId}/ignore`, { notes });
   }
@@ -277,6 +375,40 @@ class ApiService {
     return response.data;
   }
 
+  async createActionRequest(
+    issueId: number,
+    operation: ActionRequestOperation,
+    reason: string
+  ): Promise<ActionRequest> {
+    const response = await this.client.post<ActionRequest>(`/issues/${issueId}/action-requests`, {
+      operation,
+      reason,
+    });
+    return response.data;
+  }
+
+  async getActionRequests(status?: ActionRequestStatus): Promise<ActionRequestList> {
+    const response = await this.client.get<ActionRequestList>('/action-requests', {
+      params: status ? { status } : undefined,
+    });
+    return response.data;
+  }
+
+  async approveActionRequest(id: number, comment?: string): Promise<ActionRequest> {
+    const response = await this.client.post<ActionRequest>(`/action-requests/${id}/approve`, { comment });
+    return response.data;
+  }
+
+  async rejectActionRequest(id: number, comment?: string): Promise<ActionRequest> {
+    const response = await this.client.post<ActionRequest>(`/action-requests/${id}/reject`, { comment });
+    return response.data;
+  }
+
+  async cancelActionRequest(id: number): Promise<ActionRequest> {
+    const response = await this.client.post<ActionRequest>(`/action-requests/${id}/cancel`);
+    return response.data;
+  }
+
   async alignEarliestMOs(issueId: number): Promise<{
     success: boolean;
     aligned_count: number;
@@ -307,6 +439,28 @@ class ApiService {
     return response.data;
   }
 
+  // Bulk issue actions: preview with dryRun (default), then execute and follow
+  // progress via EventSource on /api/issues/bulk/{jobId}/progress
+  async previewBulkIssueAction(request: BulkIssueActionRequest): Promise<BulkIssueActionPlan> {
+    const response = await this.client.post<BulkIssueActionPlan>('/issues/bulk', { ...request, dryRun: true });
+    return response.data;
+  }
+
+  async executeBulkIssueAction(request: BulkIssueActionRequest): Promise<{
+    jobId: string;
+    action: string;
+    totalTransactions: number;
+    skipped: Array<{ issueId: number; reason: string }>;
+  }> {
+    const response = await this.client.post('/issues/bulk', { ...request, dryRun: false });
+    return response.data;
+  }
+
+  async getBulkIssueAction(jobId: string): Promise<{ job: any; items: BulkActionItem[]; completedAt?: string }> {
+    const response = await this.client.get(`/issues/bulk/${jobId}`);
+    return response.data;
+  }
+
   // Anomalies
   async getAnomalySummary(): Promise<{
     total: number;
@@ -350,7 +504,7 @@ class ApiService {
     startDate?: string;
     endDate?: string;
     facility?: string;
-  }): Promise<any> {
+  }): Promise<{ startDate: string; endDate: string; orders: ProductionOrder[]; truncated: boolean }> {
     const response = await this.client.get('/analysis/timeline', { params });
     return response.data;
   }
@@ -375,6 +529,33 @@ class ApiService {
     await this.client.put('/settings/system', { settings });
   }
 
+  // Notification subscriptions
+  async listNotificationSubscriptions(): Promise<NotificationSubscription[]> {
+    const response = await this.client.get<NotificationSubscription[]>('/notifications/subscriptions');
+    return response.data;
+  }
+
+  async createNotificationSubscription(subscription: NotificationSubscriptionInput): Promise<NotificationSubscription> {
+    const response = await this.client.post<NotificationSubscription>('/notifications/subscriptions', subscription);
+    return response.data;
+  }
+
+  async updateNotificationSubscription(id: number, subscription: NotificationSubscriptionInput): Promise<NotificationSubscription> {
+    const response = await this.client.put<NotificationSubscription>(`/notifications/subscriptions/${id}`, subscription);
+    return response.data;
+  }
+
+  async deleteNotificationSubscription(id: number): Promise<void> {
+    await this.client.delete(`/notifications/subscriptions/${id}`);
+  }
+
+  async testNotificationSubscription(id: number): Promise<{ success: boolean; error?: string }> {
+    const response = await this.client.post(`/notifications/subscriptions/${id}/test`, null, {
+      validateStatus: (status) => status === 200 || status === 502,
+    });
+    return response.data;
+  }
+
   // Audit Logs
   async listAuditLogs(filters?: AuditLogFilters): Promise<PaginatedResponse<AuditLog>> {
     const params = new URLSearchParams();
@@ -390,6 +571,11 @@ class ApiService {
     const response = await this.client.get(`/audit-logs?${params}`);
     return response.data;
   }
+
+  async revertAuditLog(id: number): Promise<AuditLogRevertResult> {
+    const response = await this.client.post(`/audit-logs/${id}/revert`);
+    return response.data;
+  }
 }
 
 export const api = new ApiService();
@@ -6,9 +6,16 @@ export interface UserContext {
   warehouse?: string;
 }
 
+// A configured M3 environment (tenant), listed on the login page
+export interface M3Environment {
+  key: string;
+  label: string;
+  production: boolean;
+}
+
 export interface AuthStatus {
   authenticated: boolean;
-  environment?: 'TRN' | 'PRD';
+  environment?: string;
   userContext?: UserContext;
   userProfile?: UserProfile;
 }
@@ -100,19 +107,116 @@ export interface ProductionOrder {
   id: number;
   orderNumber: string;
   orderType: 'MO' | 'MOP';
-  itemNumber: string;
-  itemDescription?: string;
+  company: string;
   facility: string;
   warehouse?: string;
-  plannedStartDate: string;
-  plannedFinishDate: string;
-  orderedQuantity: number;
-  quantityUnit?: string;
-  status: string;
+  itemNumber: string;
+  productNumber?: string;
+  moType?: string;
+  moTypeDescription?: string;
+  status?: string;
+  proposalStatus?: string;
+  orderedQuantity?: string;
+  plannedStartDate?: string;
+  plannedFinishDate?: string;
+  linkedCoNumber?: string;
+  linkedCoLine?: string;
+  linkedCoSuffix?: string;
   moId?: number;
   mopId?: number;
 }
 
+export type ProductionOrderSort =
+  | 'plannedStartDate'
+  | 'plannedFinishDate'
+  | 'orderNumber'
+  | 'itemNumber'
+  | 'productNumber'
+  | 'warehouse'
+  | 'status';
+
+export interface ProductionOrderFilters {
+  facility?: string;
+  warehouse?: string;
+  item?: string;
+  product?: string;
+  orderType?: 'MO' | 'MOP';
+  moTypes?: string[];
+  statuses?: string[];
+  startFrom?: string;  // YYYYMMDD
+  startTo?: string;
+  finishFrom?: string;
+  finishTo?: string;
+  coNumber?: string;
+  coLine?: string;
+  includeDeleted?: boolean;
+  sort?: ProductionOrderSort;
+  order?: 'asc' | 'desc';
+  pageSize?: number;
+  cursor?: string;
+}
+
+export interface CursorPagination {
+  pageSize: number;
+  hasMore: boolean;
+  nextCursor?: string;
+}
+
+// Full MO/MOP snapshot row (attributes) with its linked customer order line
+export interface OrderDetail {
+  id: number;
+  orderType: 'MO' | 'MOP';
+  orderNumber: string;
+  facility: string;
+  attributes: Record<string, any>;
+  linkedCoLine: Record<string, any> | null;
+}
+
+export type SupplyChainNodeType = 'CO' | 'DO' | 'PO' | 'MO' | 'MOP' | 'OTHER';
+
+// One order of an MPREAL pegging tree; children are the orders supplying it. Dates are YYYYMMDD.
+export interface SupplyChainNode {
+  nodeType: SupplyChainNodeType;
+  orderCategory: string;
+  orderNumber: string;
+  orderLine?: string;
+  orderSuffix?: string;
+  itemNumber?: string;
+  facility?: string;
+  warehouse?: string;
+  status?: string;
+  quantity?: string;
+  peggedQuantity?: string;
+  startDate?: string;
+  finishDate?: string;
+  deliveryDate?: string;
+  slackDays?: number;          // Days between this order's finish and its parent's need date; negative = late
+  customerNumber?: string;
+  customerName?: string;
+  productionOrderId?: number;
+  inSnapshot: boolean;
+  children: SupplyChainNode[];
+}
+
+export interface SupplyChainTree {
+  supplyChainNumber: string;
+  linkCount: number;
+  roots: SupplyChainNode[];
+}
+
+export interface OrderSupplyChains {
+  orderType: SupplyChainNodeType;
+  orderNumber: string;
+  line: string;
+  supplyChains: SupplyChainTree[];
+}
+
+export interface ProductionOrderDetail {
+  order: ProductionOrder;
+  attributes: Record<string, any> | null;
+  linkedCoLine: Record<string, any> | null;
+}
+
 // Manufacturing Order (full details)
 export interface ManufacturingOrder {
   id: number;
@@ -271,6 +375,10 @@ export interface Issue {
   detectedAt: string;
   issueData: Record<string, any>;
   isIgnored?: boolean;
+  isNew?: boolean;
+  firstSeenAt?: string;
+  lastSeenAt?: string;
+  ageDays?: number;
 }
 
 // Anomaly types
@@ -304,7 +412,7 @@ export interface AnomalySummary {
 
 // Snapshot types
 export interface PhaseProgress {
-  phase: string;                    // "mops" | "mos" | "cos"
+  phase: string;                    // "mops" | "mos" | "cos" | "materials" | "operations" | "supply_chain"
   status: 'pending' | 'running' | 'completed' | 'failed';
   currentOperation?: string;        // "Querying...", "Processing...", "Inserting..."
   recordCount?: number;
@@ -364,6 +472,77 @@ export interface UserSettings {
   defaultCompany?: string;
 }
 
+// Bulk issue action types
+export type BulkIssueAction = 'delete_mop' | 'delete_mo' | 'close_mo' | 'align_earliest' | 'align_latest';
+
+export interface BulkIssueFilter {
+  detectorType?: string;
+  facility?: string;
+  warehouse?: string;
+  newOnly?: boolean;
+  includeIgnored?: boolean;
+}
+
+export interface BulkIssueActionRequest {
+  action: BulkIssueAction;
+  issueIds?: number[];
+  filter?: BulkIssueFilter;
+  dryRun?: boolean; // Defaults to true (preview only)
+  expectedTransactions?: number; // Execution is rejected if the plan changed since the preview
+}
+
+export interface BulkActionItem {
+  id: number;
+  seq: number;
+  issueId: number;
+  facility: string;
+  orderType: string;
+  orderNumber: string;
+  program: string;
+  transaction: string;
+  record: Record<string, string>;
+  status: 'pending' | 'succeeded' | 'failed' | '';
+  errorMessage?: string;
+}
+
+export interface BulkIssueActionPlan {
+  action: BulkIssueAction;
+  dryRun: boolean;
+  issueCount: number;
+  transactions: BulkActionItem[];
+  byProgram: Record<string, number>;
+  skipped: Array<{ issueId: number; reason: string }>;
+  approvalRequired?: boolean; // Execution is refused; submit action requests per issue instead
+}
+
+export interface BulkActionProgress {
+  jobId: string;
+  status: 'running' | 'completed' | 'failed';
+  progress: number;
+  processed: number;
+  total: number;
+  succeeded: number;
+  failed: number;
+  results?: BulkActionItem[];
+  error?: string;
+}
+
+// Notification subscription types
+export interface NotificationSubscription {
+  id: number;
+  channel: 'email' | 'webhook' | 'teams' | 'slack';
+  target: string;
+  detectorType: string; // Empty = all detectors
+  facility: string; // Empty = all facilities
+  minSeverity: 'info' | 'warning' | 'critical';
+  delivery: 'immediate' | 'digest';
+  enabled: boolean;
+  createdAt: string;
+  updatedAt: string;
+}
+
+export type NotificationSubscriptionInput = Omit<NotificationSubscription, 'id' | 'createdAt' | 'updatedAt'>;
+
 export interface SystemSetting {
   key: string;
   value: string;
@@ -423,3 +602,85 @@ export interface AuditLogFilters {
   page?: number;
   pageSize?: number;
 }
+
+export interface AuditLogRevertResult {
+  success: boolean;
+  reverted_count: number;
+  failed_count: number;
+  skipped_count: number;
+  results: Array<{
+    order_type: string;
+    order_number: string;
+    program?: string;
+    transaction?: string;
+    record?: Record<string, string>;
+    success: boolean;
+    error?: string;
+  }>;
+}
+
+// Role-based authorization: issue write permissions granted per facility
+export type PlanningRole = 'viewer' | 'planner' | 'planning-admin';
+
+export type Permission =
+  | 'issues.ignore'
+  | 'issues.delete_mop'
+  | 'issues.align'
+  | 'issues.delete_mo'
+  | 'issues.close_mo'
+  | 'issues.request'
+  | 'audit.revert';
+
+export interface FacilityRole {
+  role: PlanningRole;
+  permissions: Permission[];
+}
+
+export interface EffectivePermissions {
+  userId: string;
+  environment: string;
+  matchedGroups: string[];
+  default: FacilityRole; // Applies to every facility not listed in facilities
+  facilities: Record<string, FacilityRole>;
+}
+
+// Two-person approval of destructive M3 operations
+export type ActionRequestOperation = 'delete_mo' | 'close_mo';
+
+export type ActionRequestStatus =
+  | 'pending'
+  | 'approved'
+  | 'rejected'
+  | 'cancelled'
+  | 'executed'
+  | 'failed';
+
+export interface ActionRequest {
+  id: number;
+  environment: string;
+  operation: ActionRequestOperation;
+  issueId: number;
+  detectorType: string;
+  company?: string;
+  facility: string;
+  orderType: string;
+  orderNumber: string;
+  orderStatus?: string;
+  reason: string;
+  status: ActionRequestStatus;
+  requestedBy: string;
+  requestedByName?: string;
+  requestedAt: string;
+  reviewedBy?: string;
+  reviewedByName?: string;
+  reviewedAt?: string;
+  reviewComment?: string;
+  executedAt?: string;
+  errorMessage?: string;
+  m3Response?: any;
+}
+
+export interface ActionRequestList {
+  requests: ActionRequest[];
+  approvalRequiredOperations: ActionRequestOperation[];
+}
@@ -0,0 +1,18 @@
+import type { EffectivePermissions, Permission } from '../types';
+
+/**
+ * Checks whether the user holds a permission in a facility
+ * @param permissions - Effective permissions from /api/permissions (null while loading)
+ * @param permission - Permission to check, e.g. 'issues.delete_mo'
+ * @param facility - Facility the action applies to (FACI)
+ * @returns true if the user's role in the facility grants the permission
+ */
+export function hasPermission(
+  permissions: EffectivePermissions | null,
+  permission: Permission,
+  facility: string
+): boolean {
+  if (!permissions) return false;
+  const role = permissions.facilities[facility] ?? permissions.default;
+  return role.permissions.includes(permission);
+}